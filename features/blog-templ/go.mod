@@ -4,4 +4,8 @@ go 1.23.0
 
 toolchain go1.24.4
 
-require github.com/a-h/templ v0.3.943
+require (
+	github.com/a-h/templ v0.3.943
+	golang.org/x/crypto v0.40.0
+	golang.org/x/net v0.42.0
+)
@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+// maxAPIBodyBytes limits the size of JSON request bodies
+const maxAPIBodyBytes = 1 << 20
+
+// postResponse is the JSON representation of a post
+type postResponse struct {
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	Slug        string     `json:"slug,omitempty"`
+	Content     string     `json:"content"`
+	Author      string     `json:"author"`
+	Tags        []string   `json:"tags"`
+	Category    string     `json:"category,omitempty"`
+	CoverImage  string     `json:"cover_image_url,omitempty"`
+	Published   bool       `json:"published"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	URL         string     `json:"url"`
+}
+
+// postListResponse wraps a list of posts
+type postListResponse struct {
+	Posts []postResponse `json:"posts"`
+	Count int            `json:"count"`
+}
+
+// postRequest is the body accepted when creating or updating a post
+type postRequest struct {
+	Title      string   `json:"title"`
+	Content    string   `json:"content"`
+	Tags       []string `json:"tags"`
+	Category   string   `json:"category"`
+	CoverImage string   `json:"cover_image_url"`
+	// Published defaults to true when omitted
+	Published *bool `json:"published"`
+}
+
+// errorResponse is the body of every API error
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// APIListPosts lists published posts, or searches them with ?q= and the
+// same filters as the search page
+func (h *Handler) APIListPosts(w http.ResponseWriter, r *http.Request) {
+	if !acceptsJSON(w, r) {
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	order := models.ParseSortOrder(r.URL.Query().Get("sort"))
+	if strings.TrimSpace(query) == "" && order == models.SortRelevance {
+		order = models.SortNewest
+	}
+
+	filter, err := parseSearchFilter(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	posts := models.FilterPosts(models.PublishedOnly(h.store.Search(query)), filter)
+	posts = models.SortPosts(posts, order)
+
+	resp := postListResponse{Posts: make([]postResponse, 0, len(posts)), Count: len(posts)}
+	for _, post := range posts {
+		resp.Posts = append(resp.Posts, h.toPostResponse(r, post))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// APIGetPost returns a single post
+func (h *Handler) APIGetPost(w http.ResponseWriter, r *http.Request) {
+	if !acceptsJSON(w, r) {
+		return
+	}
+
+	viewer, _ := h.apiAuthenticate(r)
+	post, ok := h.apiLookupPost(w, r, viewer)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.toPostResponse(r, post))
+}
+
+// APICreatePost creates a post authored by the authenticated user
+func (h *Handler) APICreatePost(w http.ResponseWriter, r *http.Request) {
+	if !acceptsJSON(w, r) {
+		return
+	}
+
+	user, ok := h.apiUser(w, r)
+	if !ok {
+		return
+	}
+
+	req, ok := decodePostRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := h.checkCategory(req.Category); err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	post := models.Post{
+		Title:          strings.TrimSpace(req.Title),
+		Content:        strings.TrimSpace(req.Content),
+		Author:         user.DisplayName,
+		AuthorUsername: user.Username,
+		Tags:           cleanTags(req.Tags),
+		Category:       req.Category,
+		CoverImageURL:  strings.TrimSpace(req.CoverImage),
+		Published:      req.Published == nil || *req.Published,
+	}
+
+	if err := h.store.Add(post); err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	// The newly added post is at the beginning
+	created := h.store.GetAll()[0]
+	h.announcePost(r, created)
+
+	w.Header().Set("Location", fmt.Sprintf("/api/posts/%d", created.ID))
+	writeJSON(w, http.StatusCreated, h.toPostResponse(r, created))
+}
+
+// APIUpdatePost replaces the title, content, tags, category and published state of a post
+func (h *Handler) APIUpdatePost(w http.ResponseWriter, r *http.Request) {
+	if !acceptsJSON(w, r) {
+		return
+	}
+
+	user, ok := h.apiUser(w, r)
+	if !ok {
+		return
+	}
+
+	post, ok := h.apiAuthorPost(w, r, user)
+	if !ok {
+		return
+	}
+
+	req, ok := decodePostRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := h.checkCategory(req.Category); err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	wasPublished := post.Published
+	post.Title = strings.TrimSpace(req.Title)
+	post.Content = strings.TrimSpace(req.Content)
+	post.Tags = cleanTags(req.Tags)
+	post.Category = req.Category
+	post.CoverImageURL = strings.TrimSpace(req.CoverImage)
+	if req.Published != nil {
+		post.Published = *req.Published
+	}
+
+	updated, err := h.store.Update(post)
+	if err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if !wasPublished {
+		h.announcePost(r, updated)
+	}
+
+	writeJSON(w, http.StatusOK, h.toPostResponse(r, updated))
+}
+
+// APIDeletePost moves a post to the trash
+func (h *Handler) APIDeletePost(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.apiUser(w, r)
+	if !ok {
+		return
+	}
+
+	post, ok := h.apiAuthorPost(w, r, user)
+	if !ok {
+		return
+	}
+
+	if err := h.store.Delete(post.ID); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiAuthenticate returns the user from the session cookie or HTTP Basic credentials
+func (h *Handler) apiAuthenticate(r *http.Request) (models.User, bool) {
+	if user, ok := models.UserFromContext(r.Context()); ok {
+		return user, true
+	}
+
+	if username, password, ok := r.BasicAuth(); ok {
+		if user, err := h.users.Authenticate(username, password); err == nil {
+			return user, true
+		}
+	}
+
+	return models.User{}, false
+}
+
+// apiUser is apiAuthenticate for endpoints that require a login, writing a 401 when there is none
+func (h *Handler) apiUser(w http.ResponseWriter, r *http.Request) (models.User, bool) {
+	if user, ok := h.apiAuthenticate(r); ok {
+		return user, true
+	}
+
+	w.Header().Set("WWW-Authenticate", `Basic realm="Blog Doodle API"`)
+	writeJSONError(w, http.StatusUnauthorized, "authentication required")
+	return models.User{}, false
+}
+
+// apiLookupPost resolves the {id} path value like lookupPost, with JSON errors.
+// Drafts are only visible to their author.
+func (h *Handler) apiLookupPost(w http.ResponseWriter, r *http.Request, viewer models.User) (models.Post, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid post ID")
+		return models.Post{}, false
+	}
+
+	post, err := h.store.GetByID(id)
+	if errors.Is(err, models.ErrPostNotFound) || (err == nil && !post.Published && !ownsPost(viewer, post)) {
+		writeJSONError(w, http.StatusNotFound, models.ErrPostNotFound.Error())
+		return models.Post{}, false
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return models.Post{}, false
+	}
+
+	return post, true
+}
+
+// apiAuthorPost looks up a post the user is allowed to change
+func (h *Handler) apiAuthorPost(w http.ResponseWriter, r *http.Request, user models.User) (models.Post, bool) {
+	post, ok := h.apiLookupPost(w, r, user)
+	if !ok {
+		return models.Post{}, false
+	}
+
+	if !ownsPost(user, post) {
+		writeJSONError(w, http.StatusForbidden, "only the author can change this post")
+		return models.Post{}, false
+	}
+
+	return post, true
+}
+
+// toPostResponse converts a post to its JSON representation
+func (h *Handler) toPostResponse(r *http.Request, post models.Post) postResponse {
+	resp := postResponse{
+		ID:         post.ID,
+		Title:      post.Title,
+		Slug:       post.Slug,
+		Content:    post.Content,
+		Author:     post.Author,
+		Tags:       post.Tags,
+		Category:   post.Category,
+		CoverImage: post.CoverImageURL,
+		Published:  post.Published,
+		CreatedAt:  post.CreatedAt,
+		URL:        postURL(h.siteURL(r), post),
+	}
+	if resp.Tags == nil {
+		resp.Tags = []string{}
+	}
+	if !post.UpdatedAt.IsZero() {
+		resp.UpdatedAt = &post.UpdatedAt
+	}
+	if !post.PublishedAt.IsZero() {
+		resp.PublishedAt = &post.PublishedAt
+	}
+	return resp
+}
+
+// decodePostRequest reads a JSON post body, writing 415/400 on bad input
+func decodePostRequest(w http.ResponseWriter, r *http.Request) (postRequest, bool) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		writeJSONError(w, http.StatusUnsupportedMediaType, "request body must be application/json")
+		return postRequest{}, false
+	}
+
+	var req postRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return postRequest{}, false
+	}
+
+	return req, true
+}
+
+// acceptsJSON checks the Accept header, writing 406 if JSON isn't acceptable
+func acceptsJSON(w http.ResponseWriter, r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return true
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+		switch mediaType {
+		case "application/json", "application/*", "*/*":
+			return true
+		}
+	}
+
+	writeJSONError(w, http.StatusNotAcceptable, "this endpoint only produces application/json")
+	return false
+}
+
+// cleanTags trims tags and drops empty ones
+func cleanTags(tags []string) []string {
+	var cleaned []string
+	for _, tag := range tags {
+		if trimmed := strings.TrimSpace(tag); trimmed != "" {
+			cleaned = append(cleaned, trimmed)
+		}
+	}
+	return cleaned
+}
+
+// writeJSON encodes v with the given status code
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONError writes an errorResponse
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+func newAPIHandler(t *testing.T) (*Handler, *models.MemoryStore) {
+	t.Helper()
+
+	users := models.NewUserStore()
+	if _, err := users.Register("jane", "Jane Doe", "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.Register("john", "John Smith", "battery staple"); err != nil {
+		t.Fatal(err)
+	}
+
+	store := models.NewStore()
+	return New(store, WithUserStore(users), WithBaseURL("https://blog.example.com")), store
+}
+
+func newJSONRequest(method, target, body string, pathID string) *http.Request {
+	var req *http.Request
+	if body == "" {
+		req = httptest.NewRequest(method, target, nil)
+	} else {
+		req = httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if pathID != "" {
+		req.SetPathValue("id", pathID)
+	}
+	return req
+}
+
+func decodeBody[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var v T
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatalf("Response is not valid JSON: %v\n%s", err, w.Body.String())
+	}
+	return v
+}
+
+func TestAPIListPosts(t *testing.T) {
+	handler, _ := newAPIHandler(t)
+
+	w := httptest.NewRecorder()
+	handler.APIListPosts(w, newJSONRequest("GET", "/api/posts", "", ""))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Expected JSON content type, got %s", ct)
+	}
+
+	resp := decodeBody[postListResponse](t, w)
+	if resp.Count != 4 || len(resp.Posts) != 4 {
+		t.Fatalf("Expected 4 posts, got %d", resp.Count)
+	}
+	if resp.Posts[0].ID != 4 {
+		t.Errorf("Expected newest post first, got %d", resp.Posts[0].ID)
+	}
+	if resp.Posts[0].URL != "https://blog.example.com/posts/type-safe-html-templates" {
+		t.Errorf("Unexpected post URL: %s", resp.Posts[0].URL)
+	}
+}
+
+func TestAPISearchPosts(t *testing.T) {
+	handler, _ := newAPIHandler(t)
+
+	w := httptest.NewRecorder()
+	handler.APIListPosts(w, newJSONRequest("GET", "/api/posts?q=htmx+search", "", ""))
+
+	resp := decodeBody[postListResponse](t, w)
+	if resp.Count != 1 || resp.Posts[0].ID != 2 {
+		t.Errorf("Expected only post 2, got %+v", resp.Posts)
+	}
+}
+
+func TestAPIFilterPosts(t *testing.T) {
+	handler, _ := newAPIHandler(t)
+
+	w := httptest.NewRecorder()
+	handler.APIListPosts(w, newJSONRequest("GET", "/api/posts?q=go&author=jane&tag=backend", "", ""))
+
+	resp := decodeBody[postListResponse](t, w)
+	if resp.Count != 1 || resp.Posts[0].ID != 3 {
+		t.Errorf("Expected only post 3, got %+v", resp.Posts)
+	}
+
+	w = httptest.NewRecorder()
+	handler.APIListPosts(w, newJSONRequest("GET", "/api/posts?to=soon", "", ""))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a bad date, got %d", w.Code)
+	}
+}
+
+func TestAPIGetPost(t *testing.T) {
+	handler, _ := newAPIHandler(t)
+
+	w := httptest.NewRecorder()
+	handler.APIGetPost(w, newJSONRequest("GET", "/api/posts/1", "", "1"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	post := decodeBody[postResponse](t, w)
+	if post.Title != "Getting Started with Templ and HTMX" {
+		t.Errorf("Unexpected title: %s", post.Title)
+	}
+
+	for id, status := range map[string]int{"999": http.StatusNotFound, "abc": http.StatusBadRequest} {
+		w := httptest.NewRecorder()
+		handler.APIGetPost(w, newJSONRequest("GET", "/api/posts/"+id, "", id))
+		if w.Code != status {
+			t.Errorf("id=%s: expected status %d, got %d", id, status, w.Code)
+		}
+		if decodeBody[errorResponse](t, w).Error == "" {
+			t.Errorf("id=%s: expected JSON error message", id)
+		}
+	}
+}
+
+func TestAPICreatePost(t *testing.T) {
+	handler, store := newAPIHandler(t)
+
+	// Anonymous requests are rejected
+	w := httptest.NewRecorder()
+	handler.APICreatePost(w, newJSONRequest("POST", "/api/posts", `{"title":"T","content":"C"}`, ""))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401, got %d", w.Code)
+	}
+	if w.Header().Get("WWW-Authenticate") == "" {
+		t.Error("Expected WWW-Authenticate header")
+	}
+
+	// HTTP Basic credentials work for non-browser clients
+	req := newJSONRequest("POST", "/api/posts", `{"title":"From the API","content":"Hello","tags":[" api ",""]}`, "")
+	req.SetBasicAuth("jane", "correct horse")
+	w = httptest.NewRecorder()
+	handler.APICreatePost(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	post := decodeBody[postResponse](t, w)
+	if w.Header().Get("Location") != "/api/posts/"+strconv.Itoa(post.ID) {
+		t.Errorf("Unexpected Location header: %s", w.Header().Get("Location"))
+	}
+	if post.Author != "Jane Doe" || !post.Published {
+		t.Errorf("Expected published post by Jane Doe, got %+v", post)
+	}
+	if len(post.Tags) != 1 || post.Tags[0] != "api" {
+		t.Errorf("Expected cleaned tags, got %v", post.Tags)
+	}
+
+	stored, err := store.GetByID(post.ID)
+	if err != nil || stored.AuthorUsername != "jane" {
+		t.Errorf("Expected stored post owned by jane, got %+v", stored)
+	}
+}
+
+func TestAPICreatePostErrors(t *testing.T) {
+	handler, _ := newAPIHandler(t)
+
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		status      int
+	}{
+		{name: "validation", body: `{"title":"","content":"C"}`, contentType: "application/json", status: http.StatusUnprocessableEntity},
+		{name: "malformed JSON", body: `{"title":`, contentType: "application/json", status: http.StatusBadRequest},
+		{name: "unknown field", body: `{"title":"T","content":"C","author":"x"}`, contentType: "application/json", status: http.StatusBadRequest},
+		{name: "form body", body: "title=T&content=C", contentType: "application/x-www-form-urlencoded", status: http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newJSONRequest("POST", "/api/posts", tt.body, "")
+			req.Header.Set("Content-Type", tt.contentType)
+			req = asUser(req, models.User{Username: "jane", DisplayName: "Jane Doe"})
+			w := httptest.NewRecorder()
+
+			handler.APICreatePost(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+}
+
+func TestAPIContentNegotiation(t *testing.T) {
+	handler, _ := newAPIHandler(t)
+
+	for accept, status := range map[string]int{
+		"text/html":                      http.StatusNotAcceptable,
+		"application/json":               http.StatusOK,
+		"text/html, application/*;q=0.5": http.StatusOK,
+		"*/*":                            http.StatusOK,
+		"application/json;q=0":           http.StatusNotAcceptable,
+	} {
+		req := newJSONRequest("GET", "/api/posts", "", "")
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+
+		handler.APIListPosts(w, req)
+
+		if w.Code != status {
+			t.Errorf("Accept %q: expected status %d, got %d", accept, status, w.Code)
+		}
+	}
+}
+
+func TestAPIUpdatePost(t *testing.T) {
+	handler, store := newAPIHandler(t)
+	jane := models.User{Username: "jane", DisplayName: "Jane Doe"}
+	john := models.User{Username: "john", DisplayName: "John Smith"}
+	body := `{"title":"Edited","content":"New body","tags":["edit"]}`
+
+	// Post 1 belongs to jane
+	w := httptest.NewRecorder()
+	handler.APIUpdatePost(w, asUser(newJSONRequest("PUT", "/api/posts/1", body, "1"), john))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for another author, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.APIUpdatePost(w, asUser(newJSONRequest("PUT", "/api/posts/1", body, "1"), jane))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	resp := decodeBody[postResponse](t, w)
+	if resp.Title != "Edited" || resp.UpdatedAt == nil {
+		t.Errorf("Expected edited post with updated_at, got %+v", resp)
+	}
+	if !resp.Published {
+		t.Error("Expected omitted published to keep the post published")
+	}
+
+	stored, _ := store.GetByID(1)
+	if stored.Content != "New body" {
+		t.Errorf("Expected stored content to change, got %q", stored.Content)
+	}
+}
+
+func TestAPIDeletePost(t *testing.T) {
+	handler, store := newAPIHandler(t)
+	jane := models.User{Username: "jane", DisplayName: "Jane Doe"}
+
+	w := httptest.NewRecorder()
+	handler.APIDeletePost(w, newJSONRequest("DELETE", "/api/posts/1", "", "1"))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.APIDeletePost(w, asUser(newJSONRequest("DELETE", "/api/posts/2", "", "2"), jane))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for John's post, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.APIDeletePost(w, asUser(newJSONRequest("DELETE", "/api/posts/1", "", "1"), jane))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if _, err := store.GetByID(1); err != models.ErrPostNotFound {
+		t.Error("Expected post to be deleted")
+	}
+}
+
+func TestAPIDraftVisibility(t *testing.T) {
+	handler, store := newAPIHandler(t)
+	store.Add(models.Post{Title: "Hidden", Content: "Draft", AuthorUsername: "jane"})
+	id := strconv.Itoa(store.GetAll()[0].ID)
+
+	w := httptest.NewRecorder()
+	handler.APIGetPost(w, newJSONRequest("GET", "/api/posts/"+id, "", id))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for anonymous draft access, got %d", w.Code)
+	}
+
+	req := newJSONRequest("GET", "/api/posts/"+id, "", id)
+	req.SetBasicAuth("jane", "correct horse")
+	w = httptest.NewRecorder()
+	handler.APIGetPost(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected author to see the draft, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.APIListPosts(w, newJSONRequest("GET", "/api/posts", "", ""))
+	if strings.Contains(w.Body.String(), "Hidden") {
+		t.Error("Expected draft to be excluded from the list")
+	}
+}
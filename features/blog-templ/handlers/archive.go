@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// Archive lists every month with published posts
+func (h *Handler) Archive(w http.ResponseWriter, r *http.Request) {
+	templates.ArchivePage(h.store.Archive()).Render(r.Context(), w)
+}
+
+// ArchiveMonth lists the posts published in one month, with links to the
+// neighbouring months that have posts
+func (h *Handler) ArchiveMonth(w http.ResponseWriter, r *http.Request) {
+	year, err := strconv.Atoi(r.PathValue("year"))
+	if err != nil || year < 1 {
+		http.NotFound(w, r)
+		return
+	}
+	month, err := strconv.Atoi(r.PathValue("month"))
+	if err != nil || month < 1 || month > 12 {
+		http.NotFound(w, r)
+		return
+	}
+
+	months := h.store.Archive()
+	for i, m := range months {
+		if m.Year != year || m.Month != time.Month(month) {
+			continue
+		}
+
+		var nav templates.ArchiveNav
+		if i > 0 {
+			nav.Newer = &months[i-1]
+		}
+		if i < len(months)-1 {
+			nav.Older = &months[i+1]
+		}
+
+		posts := models.InMonth(h.store.GetAll(), year, time.Month(month))
+		posts = models.SortPosts(posts, models.SortNewest)
+		templates.ArchiveMonthPage(m, posts, nav).Render(r.Context(), w)
+		return
+	}
+
+	http.NotFound(w, r)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+// newArchiveHandler serves posts published in Dec 2023, Jan 2024 and Mar 2024
+func newArchiveHandler(t *testing.T) *Handler {
+	t.Helper()
+
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	}
+	posts := []models.Post{
+		{ID: 1, Title: "Year in review", Content: "x", Published: true, PublishedAt: date(2023, time.December, 30)},
+		{ID: 2, Title: "Fresh start", Content: "x", Published: true, PublishedAt: date(2024, time.January, 2)},
+		{ID: 3, Title: "Winter notes", Content: "x", Published: true, PublishedAt: date(2024, time.January, 20)},
+		{ID: 4, Title: "Spring cleaning", Content: "x", Published: true, PublishedAt: date(2024, time.March, 1)},
+	}
+
+	data, err := json.Marshal(posts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "posts.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := models.NewJSONStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(store)
+}
+
+func getArchiveMonth(handler *Handler, year, month string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/archive/"+year+"/"+month, nil)
+	req.SetPathValue("year", year)
+	req.SetPathValue("month", month)
+	w := httptest.NewRecorder()
+	handler.ArchiveMonth(w, req)
+	return w
+}
+
+func TestArchivePage(t *testing.T) {
+	handler := newArchiveHandler(t)
+
+	w := httptest.NewRecorder()
+	handler.Archive(w, httptest.NewRequest("GET", "/archive", nil))
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`href="/archive/2024/03"`,
+		`href="/archive/2024/01"`,
+		`href="/archive/2023/12"`,
+		"2 posts",
+		`<h3 class="archive-year">2023</h3>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected archive page to contain %s", want)
+		}
+	}
+	if strings.Index(body, "/archive/2024/03") > strings.Index(body, "/archive/2023/12") {
+		t.Error("Expected newest months first")
+	}
+}
+
+func TestArchiveMonthPage(t *testing.T) {
+	handler := newArchiveHandler(t)
+
+	w := getArchiveMonth(handler, "2024", "01")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	body := w.Body.String()
+	if !strings.Contains(body, "Posts from January 2024") {
+		t.Error("Expected the month in the heading")
+	}
+	if !strings.Contains(body, "Fresh start") || !strings.Contains(body, "Winter notes") {
+		t.Error("Expected both January posts")
+	}
+	if strings.Contains(body, "Spring cleaning") {
+		t.Error("Expected posts from other months to be left out")
+	}
+	if strings.Index(body, "Winter notes") > strings.Index(body, "Fresh start") {
+		t.Error("Expected newest posts first")
+	}
+
+	// February has no posts, so navigation skips it
+	if !strings.Contains(body, `href="/archive/2023/12" rel="prev"`) {
+		t.Error("Expected a link to the previous month with posts")
+	}
+	if !strings.Contains(body, `href="/archive/2024/03" rel="next"`) {
+		t.Error("Expected a link to the next month with posts")
+	}
+}
+
+func TestArchiveMonthEdges(t *testing.T) {
+	handler := newArchiveHandler(t)
+
+	body := getArchiveMonth(handler, "2024", "3").Body.String()
+	if strings.Contains(body, `rel="next"`) {
+		t.Error("Expected no newer link on the newest month")
+	}
+	if !strings.Contains(body, `rel="prev"`) {
+		t.Error("Expected an older link on the newest month")
+	}
+}
+
+func TestArchiveMonthNotFound(t *testing.T) {
+	handler := newArchiveHandler(t)
+
+	for _, tt := range []struct{ year, month string }{
+		{"2024", "02"},
+		{"2024", "13"},
+		{"2024", "0"},
+		{"abcd", "01"},
+		{"2024", "jan"},
+	} {
+		if w := getArchiveMonth(handler, tt.year, tt.month); w.Code != http.StatusNotFound {
+			t.Errorf("%s/%s: expected status 404, got %d", tt.year, tt.month, w.Code)
+		}
+	}
+}
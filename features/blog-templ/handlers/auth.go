@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// sessionCookieName is the cookie holding the login session token
+const sessionCookieName = "blog_session"
+
+// LoadSession attaches the logged-in user, if any, to every request context
+func (h *Handler) LoadSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, ok := h.sessionUser(r); ok {
+			r = r.WithContext(models.ContextWithUser(r.Context(), user))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireAuth only lets logged-in users through, sending everyone else to /login
+func (h *Handler) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := models.UserFromContext(r.Context()); ok {
+			next(w, r)
+			return
+		}
+
+		loginURL := "/login?next=" + url.QueryEscape(r.URL.RequestURI())
+		if r.Header.Get("HX-Request") == "true" {
+			// HTMX ignores 3xx for swaps, so ask it to navigate instead
+			w.Header().Set("HX-Redirect", loginURL)
+			http.Error(w, "Login required", http.StatusUnauthorized)
+			return
+		}
+		http.Redirect(w, r, loginURL, http.StatusSeeOther)
+	}
+}
+
+// LoginForm renders the login page
+func (h *Handler) LoginForm(w http.ResponseWriter, r *http.Request) {
+	templates.LoginPage(safeNext(r.URL.Query().Get("next")), "").Render(r.Context(), w)
+}
+
+// Login checks credentials and starts a session
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	next := safeNext(r.FormValue("next"))
+
+	user, err := h.users.Authenticate(r.FormValue("username"), r.FormValue("password"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		templates.LoginPage(next, templates.T(r.Context(), "login.invalid")).Render(r.Context(), w)
+		return
+	}
+
+	token, err := h.sessions.Create(user.Username)
+	if err != nil {
+		http.Error(w, "Failed to start session", http.StatusInternalServerError)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(models.SessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   h.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// Logout ends the current session
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		h.sessions.Delete(cookie.Value)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// sessionUser resolves the session cookie to a user
+func (h *Handler) sessionUser(r *http.Request) (models.User, bool) {
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return models.User{}, false
+	}
+
+	username, ok := h.sessions.Get(cookie.Value)
+	if !ok {
+		return models.User{}, false
+	}
+
+	return h.users.GetByUsername(username)
+}
+
+// safeNext only allows local redirect targets, defaulting to the home page
+func safeNext(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+// testUser is the author attached to requests that need a login
+var testUser = models.User{Username: "tester", DisplayName: "Test Author"}
+
+func newAuthHandler(t *testing.T) *Handler {
+	t.Helper()
+
+	users := models.NewUserStore()
+	if _, err := users.Register("jane", "Jane Doe", "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	return New(models.NewStore(), WithUserStore(users))
+}
+
+func login(t *testing.T, handler *Handler, username, password, next string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	form := url.Values{"username": {username}, "password": {password}, "next": {next}}
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	handler.Login(w, req)
+	return w
+}
+
+func TestLoginSuccess(t *testing.T) {
+	handler := newAuthHandler(t)
+
+	w := login(t, handler, "jane", "correct horse", "/new")
+
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status 303, got %d", w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc != "/new" {
+		t.Errorf("Expected redirect to /new, got %s", loc)
+	}
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != sessionCookieName {
+		t.Fatalf("Expected session cookie, got %v", cookies)
+	}
+	if !cookies[0].HttpOnly || cookies[0].SameSite != http.SameSiteLaxMode {
+		t.Error("Expected HttpOnly, SameSite=Lax session cookie")
+	}
+
+	// The cookie now identifies the user on later requests
+	var seen models.User
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = models.UserFromContext(r.Context())
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(cookies[0])
+	handler.LoadSession(next).ServeHTTP(httptest.NewRecorder(), req)
+
+	if seen.DisplayName != "Jane Doe" {
+		t.Errorf("Expected Jane Doe in request context, got %+v", seen)
+	}
+}
+
+func TestLoginFailure(t *testing.T) {
+	handler := newAuthHandler(t)
+
+	w := login(t, handler, "jane", "wrong password", "/")
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", w.Code)
+	}
+	if len(w.Result().Cookies()) != 0 {
+		t.Error("Expected no session cookie on failed login")
+	}
+	if !strings.Contains(w.Body.String(), "invalid username or password") {
+		t.Error("Expected error message on login page")
+	}
+}
+
+func TestLoginRejectsOpenRedirect(t *testing.T) {
+	handler := newAuthHandler(t)
+
+	for _, next := range []string{"https://evil.example.com", "//evil.example.com", "/\\evil.example.com"} {
+		w := login(t, handler, "jane", "correct horse", next)
+		if loc := w.Header().Get("Location"); loc != "/" {
+			t.Errorf("next=%q: expected redirect to /, got %s", next, loc)
+		}
+	}
+}
+
+func TestLogout(t *testing.T) {
+	handler := newAuthHandler(t)
+	cookie := login(t, handler, "jane", "correct horse", "/").Result().Cookies()[0]
+
+	req := httptest.NewRequest("POST", "/logout", nil)
+	req.AddCookie(cookie)
+	w := httptest.NewRecorder()
+
+	handler.Logout(w, req)
+
+	if w.Code != http.StatusSeeOther {
+		t.Errorf("Expected status 303, got %d", w.Code)
+	}
+	if _, ok := handler.sessions.Get(cookie.Value); ok {
+		t.Error("Expected session to be deleted")
+	}
+}
+
+func TestRequireAuth(t *testing.T) {
+	handler := newAuthHandler(t)
+	protected := handler.RequireAuth(handler.NewPostForm)
+
+	// Anonymous page request is redirected to the login page
+	req := httptest.NewRequest("GET", "/new", nil)
+	w := httptest.NewRecorder()
+	protected(w, req)
+
+	if w.Code != http.StatusSeeOther {
+		t.Errorf("Expected status 303, got %d", w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc != "/login?next=%2Fnew" {
+		t.Errorf("Unexpected redirect: %s", loc)
+	}
+
+	// Anonymous HTMX request gets an HX-Redirect
+	req = httptest.NewRequest("POST", "/posts", nil)
+	req.Header.Set("HX-Request", "true")
+	w = httptest.NewRecorder()
+	handler.RequireAuth(handler.CreatePost)(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", w.Code)
+	}
+	if w.Header().Get("HX-Redirect") == "" {
+		t.Error("Expected HX-Redirect header")
+	}
+
+	// Logged-in users get through
+	req = httptest.NewRequest("GET", "/new", nil)
+	req = req.WithContext(models.ContextWithUser(req.Context(), testUser))
+	w = httptest.NewRecorder()
+	protected(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
+
+func TestCreatePostRequiresUser(t *testing.T) {
+	handler := New(models.NewStore())
+
+	form := url.Values{"title": {"Title"}, "content": {"Content"}}
+	req := httptest.NewRequest("POST", "/posts", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	handler.CreatePost(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", w.Code)
+	}
+}
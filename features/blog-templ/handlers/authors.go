@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// WithAuthorStore sets the store of author profiles
+func WithAuthorStore(authors models.AuthorStore) Option {
+	return func(h *Handler) {
+		h.authors = authors
+	}
+}
+
+// AuthorPosts shows an author's profile and their published posts
+func (h *Handler) AuthorPosts(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("name")
+	posts := models.ByAuthor(models.PublishedOnly(h.store.GetAll()), username)
+
+	author, err := h.authors.Get(username)
+	if errors.Is(err, models.ErrAuthorNotFound) {
+		// Authors who never filled in a profile still get a page for their posts
+		if len(posts) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		author = models.Author{Username: posts[0].AuthorUsername, Name: posts[0].Author}
+	}
+
+	posts = models.SortPosts(posts, models.SortNewest)
+	templates.AuthorPage(author, posts).Render(r.Context(), w)
+}
+
+// Profile shows the logged-in author's profile form
+func (h *Handler) Profile(w http.ResponseWriter, r *http.Request) {
+	user, ok := models.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Login required", http.StatusUnauthorized)
+		return
+	}
+
+	templates.ProfilePage(h.profileOf(user), "").Render(r.Context(), w)
+}
+
+// UpdateProfile saves the logged-in author's bio and avatar
+func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	user, ok := models.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Login required", http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	// The name stays in step with the account, which is what posts are signed with
+	author := models.Author{
+		Username:  user.Username,
+		Name:      user.DisplayName,
+		Bio:       r.FormValue("bio"),
+		AvatarURL: r.FormValue("avatar_url"),
+	}
+	saved, err := h.authors.Save(author)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		templates.ProfilePage(author, err.Error()).Render(r.Context(), w)
+		return
+	}
+
+	http.Redirect(w, r, saved.URL(), http.StatusSeeOther)
+}
+
+// profileOf returns user's author profile, or an empty one under their
+// name if they haven't saved one yet
+func (h *Handler) profileOf(user models.User) models.Author {
+	author, err := h.authors.Get(user.Username)
+	if err != nil {
+		return models.Author{Username: user.Username, Name: user.DisplayName}
+	}
+	return author
+}
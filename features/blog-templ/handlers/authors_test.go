@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+func authorRequest(name string) *http.Request {
+	req := httptest.NewRequest("GET", "/authors/"+name, nil)
+	req.SetPathValue("name", name)
+	return req
+}
+
+func TestAuthorPostsPage(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+	store.Add(models.Post{Title: "Jane's Draft", Content: "Secret", Author: "Jane Doe", AuthorUsername: "jane"})
+
+	w := httptest.NewRecorder()
+	handler.AuthorPosts(w, authorRequest("jane"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "Jane Doe") || !strings.Contains(body, "Writes about Go") {
+		t.Error("Expected the author's name and bio")
+	}
+	if !strings.Contains(body, "Getting Started with Templ") || strings.Contains(body, "Building Interactive UIs with HTMX") {
+		t.Error("Expected only Jane's posts")
+	}
+	if strings.Contains(body, "Jane&#39;s Draft") {
+		t.Error("Expected drafts to be hidden")
+	}
+}
+
+func TestAuthorPostsWithoutProfile(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store, WithAuthorStore(models.NewAuthorStore()))
+	store.Add(models.Post{Title: "Guest Post", Content: "Hello", Author: "Guest Writer", AuthorUsername: "guest", Published: true})
+
+	// Authors with posts but no saved profile still get a page
+	w := httptest.NewRecorder()
+	handler.AuthorPosts(w, authorRequest("guest"))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Guest Writer") {
+		t.Errorf("Expected a page for the guest author, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.AuthorPosts(w, authorRequest("nobody"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown author, got %d", w.Code)
+	}
+}
+
+func TestPostCardLinksToAuthor(t *testing.T) {
+	handler := New(models.NewStore())
+
+	w := httptest.NewRecorder()
+	handler.Index(w, httptest.NewRequest("GET", "/", nil))
+
+	if !strings.Contains(w.Body.String(), `href="/authors/john"`) {
+		t.Error("Expected post cards to link to the author's page")
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	authors := models.NewAuthorStore()
+	handler := New(models.NewStore(), WithAuthorStore(authors))
+
+	form := url.Values{"bio": {"Testing all the things"}, "avatar_url": {"https://example.com/me.png"}}
+	req := httptest.NewRequest("POST", "/profile", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handler.UpdateProfile(w, asUser(req, testUser))
+
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/authors/tester" {
+		t.Fatalf("Expected a redirect to the author page, got %d %s", w.Code, w.Header().Get("Location"))
+	}
+	author, err := authors.Get("tester")
+	if err != nil || author.Name != testUser.DisplayName || author.Bio != "Testing all the things" {
+		t.Errorf("Expected the profile to be saved under the account's name, got %+v, %v", author, err)
+	}
+
+	w = httptest.NewRecorder()
+	handler.Profile(w, asUser(httptest.NewRequest("GET", "/profile", nil), testUser))
+	if !strings.Contains(w.Body.String(), "Testing all the things") {
+		t.Error("Expected the profile form to show the saved bio")
+	}
+}
+
+func TestUpdateProfileInvalidAvatar(t *testing.T) {
+	handler := New(models.NewStore())
+
+	form := url.Values{"bio": {"Hi"}, "avatar_url": {"javascript:alert(1)"}}
+	req := httptest.NewRequest("POST", "/profile", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handler.UpdateProfile(w, asUser(req, testUser))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), models.ErrInvalidAvatarURL.Error()) {
+		t.Error("Expected the error on the form")
+	}
+}
+
+func TestSearchFilterListsAuthors(t *testing.T) {
+	handler := New(models.NewStore())
+
+	w := httptest.NewRecorder()
+	handler.Index(w, httptest.NewRequest("GET", "/", nil))
+
+	if !strings.Contains(w.Body.String(), `<option value="jane">Jane Doe</option>`) {
+		t.Error("Expected the author filter to list authors")
+	}
+}
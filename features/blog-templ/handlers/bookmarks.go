@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// WithBookmarkStore sets the store of readers' reading lists
+func WithBookmarkStore(bookmarks models.BookmarkStore) Option {
+	return func(h *Handler) {
+		h.bookmarks = bookmarks
+	}
+}
+
+// LoadBookmarks is middleware that puts the reader's reading list in the
+// request context, so post cards can mark saved posts and the nav can show
+// how many there are
+func (h *Handler) LoadBookmarks(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie(visitorCookieName); err == nil && cookie.Value != "" {
+			if ids := h.bookmarks.List(cookie.Value); len(ids) > 0 {
+				r = r.WithContext(templates.ContextWithBookmarks(r.Context(), ids))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ToggleBookmark adds a post to the reader's reading list, or takes it off,
+// and responds with out-of-band swaps for every bookmark button of the post
+// and the reading list count
+func (h *Handler) ToggleBookmark(w http.ResponseWriter, r *http.Request) {
+	post, ok := h.lookupPost(w, r)
+	if !ok {
+		return
+	}
+	if !post.Published {
+		http.NotFound(w, r)
+		return
+	}
+
+	visitor, ok := h.visitorID(w, r)
+	if !ok {
+		http.Error(w, "Failed to identify visitor", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := h.bookmarks.Toggle(visitor, post.ID); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, models.ErrReadingListFull) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	ctx := templates.ContextWithBookmarks(r.Context(), h.bookmarks.List(visitor))
+	templates.BookmarkUpdate(post.ID).Render(ctx, w)
+}
+
+// ReadingList shows the posts the reader bookmarked. Posts that were since
+// trashed or unpublished are left out, but keep their bookmarks in case
+// they come back.
+func (h *Handler) ReadingList(w http.ResponseWriter, r *http.Request) {
+	var (
+		posts []models.Post
+		ids   []int
+	)
+	if cookie, err := r.Cookie(visitorCookieName); err == nil && cookie.Value != "" {
+		for _, id := range h.bookmarks.List(cookie.Value) {
+			if post, err := h.store.GetByID(id); err == nil && post.Published {
+				posts = append(posts, post)
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	// Count only the posts listed, so the nav agrees with the page
+	ctx := templates.ContextWithBookmarks(r.Context(), ids)
+	templates.ReadingListPage(posts).Render(ctx, w)
+}
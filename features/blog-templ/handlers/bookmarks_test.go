@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+// bookmarkSite serves bookmarking and the reading list behind LoadBookmarks
+func bookmarkSite(handler *Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /posts/{id}/bookmark", handler.ToggleBookmark)
+	mux.HandleFunc("GET /reading-list", handler.ReadingList)
+	mux.HandleFunc("GET /posts/{id}", handler.PostDetail)
+	return handler.LoadBookmarks(mux)
+}
+
+// sendAs makes a request carrying cookies and returns the response
+func sendAs(site http.Handler, method, target string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	site.ServeHTTP(w, req)
+	return w
+}
+
+func TestToggleBookmark(t *testing.T) {
+	site := bookmarkSite(New(models.NewStore()))
+
+	w := sendAs(site, "POST", "/posts/2/bookmark", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	cookies := w.Result().Cookies()
+
+	// The response swaps the button into every copy on the page, and the
+	// count into the nav
+	body := w.Body.String()
+	if !strings.Contains(body, `hx-swap-oob="outerHTML:.bookmark-2"`) || !strings.Contains(body, `aria-pressed="true"`) {
+		t.Errorf("Expected a pressed bookmark button swapped out-of-band, got:\n%s", body)
+	}
+	if !strings.Contains(body, `id="reading-list-count" class="reading-list-count" hx-swap-oob="true">1</span>`) {
+		t.Errorf("Expected the reading list count swapped out-of-band, got:\n%s", body)
+	}
+
+	sendAs(site, "POST", "/posts/4/bookmark", cookies)
+	w = sendAs(site, "POST", "/posts/2/bookmark", cookies)
+	body = w.Body.String()
+	if !strings.Contains(body, `aria-pressed="false"`) || !strings.Contains(body, `hx-swap-oob="true">1</span>`) {
+		t.Errorf("Expected toggling again to remove the bookmark, got:\n%s", body)
+	}
+
+	// Pages mark saved posts
+	if body := sendAs(site, "GET", "/posts/4", cookies).Body.String(); !strings.Contains(body, `aria-pressed="true"`) {
+		t.Error("Expected the post page to show the post as saved")
+	}
+}
+
+func TestToggleBookmarkRejectsDrafts(t *testing.T) {
+	store := models.NewStore()
+	id := addDraft(t, store)
+	site := bookmarkSite(New(store))
+
+	if w := sendAs(site, "POST", "/posts/"+strconv.Itoa(id)+"/bookmark", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+	if w := sendAs(site, "POST", "/posts/999/bookmark", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestReadingList(t *testing.T) {
+	store := models.NewStore()
+	site := bookmarkSite(New(store))
+
+	if body := sendAs(site, "GET", "/reading-list", nil).Body.String(); !strings.Contains(body, "Your reading list is empty") {
+		t.Error("Expected an empty reading list for a new reader")
+	}
+
+	cookies := sendAs(site, "POST", "/posts/1/bookmark", nil).Result().Cookies()
+	sendAs(site, "POST", "/posts/3/bookmark", cookies)
+	store.Delete(1)
+
+	body := sendAs(site, "GET", "/reading-list", cookies).Body.String()
+	if !strings.Contains(body, "Why Go is Great for Web Development") {
+		t.Error("Expected the bookmarked post on the reading list")
+	}
+	if strings.Contains(body, "Getting Started with Templ and HTMX") {
+		t.Error("Expected deleted posts to be left out")
+	}
+	if !strings.Contains(body, `class="reading-list-count">1</span>`) {
+		t.Error("Expected the nav to count the reading list")
+	}
+
+	// Another reader has their own list
+	if body := sendAs(site, "GET", "/reading-list", nil).Body.String(); strings.Contains(body, "Why Go is Great") {
+		t.Error("Expected reading lists to be kept per session")
+	}
+}
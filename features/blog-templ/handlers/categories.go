@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// WithCategoryStore sets the store of categories posts can be filed under
+func WithCategoryStore(categories models.CategoryStore) Option {
+	return func(h *Handler) {
+		h.categories = categories
+	}
+}
+
+// CategoryPosts handles the landing page for a single category
+func (h *Handler) CategoryPosts(w http.ResponseWriter, r *http.Request) {
+	category, ok := h.lookupCategory(w, r)
+	if !ok {
+		return
+	}
+
+	posts := models.InCategory(models.PublishedOnly(h.store.GetAll()), category.Slug)
+	posts = models.SortPosts(posts, models.SortNewest)
+	templates.CategoryPage(category, posts).Render(r.Context(), w)
+}
+
+// CategoryRSSFeed serves a category's most recent posts as an RSS 2.0 feed
+func (h *Handler) CategoryRSSFeed(w http.ResponseWriter, r *http.Request) {
+	category, ok := h.lookupCategory(w, r)
+	if !ok {
+		return
+	}
+
+	posts := models.InCategory(models.PublishedOnly(h.store.GetAll()), category.Slug)
+	writeRSS(w, h.siteURL(r), categoryFeed(category), posts)
+}
+
+// CategoryAtomFeed serves a category's most recent posts as an Atom 1.0 feed
+func (h *Handler) CategoryAtomFeed(w http.ResponseWriter, r *http.Request) {
+	category, ok := h.lookupCategory(w, r)
+	if !ok {
+		return
+	}
+
+	posts := models.InCategory(models.PublishedOnly(h.store.GetAll()), category.Slug)
+	writeAtom(w, h.siteURL(r), categoryFeed(category), posts)
+}
+
+// Categories shows the category management page
+func (h *Handler) Categories(w http.ResponseWriter, r *http.Request) {
+	h.renderCategories(w, r, http.StatusOK, "")
+}
+
+// AddCategory creates a category from the management page form
+func (h *Handler) AddCategory(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	_, err := h.categories.Add(r.FormValue("name"), r.FormValue("description"))
+	if errors.Is(err, models.ErrCategoryExists) {
+		h.renderCategories(w, r, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		h.renderCategories(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	http.Redirect(w, r, "/admin/categories", http.StatusSeeOther)
+}
+
+// DeleteCategory removes a category that no post is filed under
+func (h *Handler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	category, ok := h.lookupCategory(w, r)
+	if !ok {
+		return
+	}
+
+	// Trashed posts can be restored, so they still count
+	if used := h.categoryUsage()[category.Slug]; used > 0 {
+		h.renderCategories(w, r, http.StatusConflict,
+			fmt.Sprintf("%s still has %d posts; move them to another category first", category.Name, used))
+		return
+	}
+
+	if err := h.categories.Delete(category.Slug); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/categories", http.StatusSeeOther)
+}
+
+// renderCategories writes the management page with an optional error
+func (h *Handler) renderCategories(w http.ResponseWriter, r *http.Request, status int, errMsg string) {
+	w.WriteHeader(status)
+	templates.CategoriesPage(h.categories.All(), h.categoryUsage(), errMsg).Render(r.Context(), w)
+}
+
+// categoryUsage counts the posts, including drafts and trashed posts,
+// filed under each category slug
+func (h *Handler) categoryUsage() map[string]int {
+	usage := make(map[string]int)
+	for _, posts := range [][]models.Post{h.store.GetAll(), h.store.Trash()} {
+		for _, post := range posts {
+			if post.Category != "" {
+				usage[post.Category]++
+			}
+		}
+	}
+	return usage
+}
+
+// lookupCategory resolves the {slug} path value to a category, writing a
+// 404 response and returning false when there is none
+func (h *Handler) lookupCategory(w http.ResponseWriter, r *http.Request) (models.Category, bool) {
+	category, err := h.categories.Get(r.PathValue("slug"))
+	if err != nil {
+		http.NotFound(w, r)
+		return models.Category{}, false
+	}
+	return category, true
+}
+
+// checkCategory returns an error unless slug is empty or names a category
+func (h *Handler) checkCategory(slug string) error {
+	if slug == "" {
+		return nil
+	}
+	if _, err := h.categories.Get(slug); err != nil {
+		return fmt.Errorf("unknown category %q", slug)
+	}
+	return nil
+}
+
+// categoryFeed describes the feed of a category's posts
+func categoryFeed(category models.Category) feedInfo {
+	description := category.Description
+	if description == "" {
+		description = feedDescription
+	}
+
+	return feedInfo{
+		title:       category.Name + " - " + feedTitle,
+		description: description,
+		page:        category.URL(),
+		path:        category.URL(),
+	}
+}
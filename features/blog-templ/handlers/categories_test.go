@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+func categoryRequest(method, slug string) *http.Request {
+	req := httptest.NewRequest(method, "/categories/"+slug, nil)
+	req.SetPathValue("slug", slug)
+	return req
+}
+
+func TestCategoryPosts(t *testing.T) {
+	handler := New(models.NewStore())
+
+	w := httptest.NewRecorder()
+	handler.CategoryPosts(w, categoryRequest("GET", "tutorials"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "Tutorials") || !strings.Contains(body, `href="/categories/tutorials/feed.xml"`) {
+		t.Error("Expected the category name and feed links")
+	}
+	if !strings.Contains(body, "/posts/1") || strings.Contains(body, "/posts/3") {
+		t.Error("Expected only the category's posts")
+	}
+
+	w = httptest.NewRecorder()
+	handler.CategoryPosts(w, categoryRequest("GET", "nope"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown category, got %d", w.Code)
+	}
+}
+
+func TestCategoryFeeds(t *testing.T) {
+	handler := New(models.NewStore(), WithBaseURL("https://blog.example.com"))
+
+	w := httptest.NewRecorder()
+	handler.CategoryRSSFeed(w, categoryRequest("GET", "web-development"))
+
+	var rss rssFeed
+	if err := xml.Unmarshal(w.Body.Bytes(), &rss); err != nil {
+		t.Fatalf("Feed is not valid XML: %v", err)
+	}
+	if len(rss.Channel.Items) != 2 {
+		t.Errorf("Expected 2 items, got %d", len(rss.Channel.Items))
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "<link>https://blog.example.com/categories/web-development</link>") {
+		t.Error("Expected the channel to link to the category page")
+	}
+	if !strings.Contains(body, `href="https://blog.example.com/categories/web-development/feed.xml"`) {
+		t.Error("Expected a self link to the category feed")
+	}
+
+	w = httptest.NewRecorder()
+	handler.CategoryAtomFeed(w, categoryRequest("GET", "web-development"))
+
+	var atom atomFeed
+	if err := xml.Unmarshal(w.Body.Bytes(), &atom); err != nil {
+		t.Fatalf("Feed is not valid XML: %v", err)
+	}
+	if len(atom.Entries) != 2 || !strings.HasPrefix(atom.Title, "Web Development") {
+		t.Errorf("Expected the category's 2 entries, got %d in %q", len(atom.Entries), atom.Title)
+	}
+}
+
+func TestCreatePostWithCategory(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+
+	form := url.Values{"title": {"Filed"}, "content": {"Body"}, "category": {"tutorials"}}
+	req := httptest.NewRequest("POST", "/posts", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handler.CreatePost(w, asUser(req, testUser))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if post := store.GetAll()[0]; post.Category != "tutorials" {
+		t.Errorf("Expected the post in tutorials, got %q", post.Category)
+	}
+
+	form.Set("category", "made-up")
+	req = httptest.NewRequest("POST", "/posts", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	handler.CreatePost(w, asUser(req, testUser))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown category, got %d", w.Code)
+	}
+}
+
+func TestNewPostFormListsCategories(t *testing.T) {
+	handler := New(models.NewStore())
+
+	w := httptest.NewRecorder()
+	handler.NewPostForm(w, asUser(httptest.NewRequest("GET", "/new", nil), testUser))
+
+	if !strings.Contains(w.Body.String(), `<option value="web-development">Web Development</option>`) {
+		t.Error("Expected a category dropdown")
+	}
+}
+
+func TestAddCategory(t *testing.T) {
+	categories := models.NewCategoryStore()
+	handler := New(models.NewStore(), WithCategoryStore(categories))
+
+	post := func(name string) *httptest.ResponseRecorder {
+		form := url.Values{"name": {name}, "description": {"Notes"}}
+		req := httptest.NewRequest("POST", "/admin/categories", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handler.AddCategory(w, asUser(req, testUser))
+		return w
+	}
+
+	if w := post("Release Notes"); w.Code != http.StatusSeeOther {
+		t.Fatalf("Expected redirect, got %d", w.Code)
+	}
+	if _, err := categories.Get("release-notes"); err != nil {
+		t.Errorf("Expected the category to be added: %v", err)
+	}
+
+	if w := post("release notes"); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for a duplicate, got %d", w.Code)
+	}
+	if w := post(""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a missing name, got %d", w.Code)
+	}
+}
+
+func TestDeleteCategory(t *testing.T) {
+	store := models.NewStore()
+	categories := models.NewCategoryStore()
+	categories.Add("Empty", "")
+	handler := New(store, WithCategoryStore(categories))
+
+	w := httptest.NewRecorder()
+	handler.DeleteCategory(w, asUser(categoryRequest("POST", "empty"), testUser))
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("Expected redirect, got %d", w.Code)
+	}
+	if _, err := categories.Get("empty"); err == nil {
+		t.Error("Expected the unused category to be deleted")
+	}
+
+	// Posts in the trash still count as using their category
+	store.Delete(1)
+	store.Delete(2)
+	w = httptest.NewRecorder()
+	handler.DeleteCategory(w, asUser(categoryRequest("POST", "tutorials"), testUser))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for a category in use, got %d", w.Code)
+	}
+	if _, err := categories.Get("tutorials"); err != nil {
+		t.Error("Expected the category in use to be kept")
+	}
+}
+
+func TestPostDetailLinksCategory(t *testing.T) {
+	handler := New(models.NewStore())
+
+	req := httptest.NewRequest("GET", "/posts/1", nil)
+	req.SetPathValue("id", "1")
+	w := httptest.NewRecorder()
+	handler.PostDetail(w, req)
+
+	if !strings.Contains(w.Body.String(), `<a class="post-category" href="/categories/tutorials">Tutorials</a>`) {
+		t.Error("Expected a link to the post's category")
+	}
+}
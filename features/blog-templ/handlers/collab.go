@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+const (
+	// maxCollabMessage caps the size of one message from an editor
+	maxCollabMessage = 1 << 20
+	// collabSendBuffer is how many messages an editor may fall behind
+	// before being disconnected
+	collabSendBuffer = 64
+)
+
+// collabColors are handed to editors in turn so their cursors stand apart
+var collabColors = []string{"#e74c3c", "#3498db", "#27ae60", "#9b59b6", "#e67e22", "#16a085"}
+
+// errCollabClosed is returned when joining a draft after shutdown started
+var errCollabClosed = errors.New("collaborative editing is shutting down")
+
+// collabMessage is a message on a draft's WebSocket. Editors send "edit"
+// (a field's new value), "cursor" (their selection) and "save". The server
+// sends "init" on joining, then "edit", "presence", "saved" and "error".
+type collabMessage struct {
+	Type    string       `json:"type"`
+	Field   string       `json:"field,omitempty"`
+	Value   string       `json:"value,omitempty"`
+	Start   int          `json:"start,omitempty"`
+	End     int          `json:"end,omitempty"`
+	Version int          `json:"version,omitempty"`
+	Peer    string       `json:"peer,omitempty"`
+	Title   string       `json:"title,omitempty"`
+	Content string       `json:"content,omitempty"`
+	Peers   []collabPeer `json:"peers,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// collabPeer describes an editor and their cursor for presence
+type collabPeer struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+	Field string `json:"field,omitempty"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// collabClient is one editor's connection
+type collabClient struct {
+	peer collabPeer
+	conn *websocket.Conn
+	send chan collabMessage
+}
+
+// writeLoop sends queued messages until the client leaves
+func (c *collabClient) writeLoop() {
+	for msg := range c.send {
+		if err := websocket.JSON.Send(c.conn, msg); err != nil {
+			c.conn.Close()
+		}
+	}
+}
+
+// collabRoom holds the live copy of a draft while authors edit it.
+// Concurrent edits to a field are resolved by last writer wins: each edit
+// replaces the field and bumps the version, and every editor, the sender
+// included, gets it in the order the room applied it.
+type collabRoom struct {
+	postID  int
+	mu      sync.Mutex
+	title   string
+	content string
+	version int
+	dirty   bool
+	clients map[*collabClient]struct{}
+}
+
+// edit replaces a field and passes the change to every editor
+func (room *collabRoom) edit(from *collabClient, field, value string) {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	switch field {
+	case "title":
+		room.title = value
+	case "content":
+		room.content = value
+	default:
+		return
+	}
+	room.version++
+	room.dirty = true
+	room.broadcast(collabMessage{Type: "edit", Field: field, Value: value, Version: room.version, Peer: from.peer.ID})
+}
+
+// moveCursor records where an editor's selection is and shares it
+func (room *collabRoom) moveCursor(from *collabClient, field string, start, end int) {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if field != "title" && field != "content" {
+		field = ""
+	}
+	from.peer.Field, from.peer.Start, from.peer.End = field, start, end
+	room.broadcast(room.presence())
+}
+
+// save writes the live title and content to the store
+func (room *collabRoom) save(store models.Store) (int, error) {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	post, err := store.GetByID(room.postID)
+	if err != nil {
+		return 0, err
+	}
+	post.Title = strings.TrimSpace(room.title)
+	post.Content = strings.TrimSpace(room.content)
+	if _, err := store.Update(post); err != nil {
+		return 0, err
+	}
+	room.dirty = false
+	return room.version, nil
+}
+
+// presence lists the room's editors. The caller must hold room.mu.
+func (room *collabRoom) presence() collabMessage {
+	peers := make([]collabPeer, 0, len(room.clients))
+	for client := range room.clients {
+		peers = append(peers, client.peer)
+	}
+	slices.SortFunc(peers, func(a, b collabPeer) int {
+		x, _ := strconv.Atoi(a.ID)
+		y, _ := strconv.Atoi(b.ID)
+		return x - y
+	})
+	return collabMessage{Type: "presence", Peers: peers}
+}
+
+// broadcast queues msg for every editor, disconnecting any too far behind
+// to keep up. The caller must hold room.mu.
+func (room *collabRoom) broadcast(msg collabMessage) {
+	for client := range room.clients {
+		select {
+		case client.send <- msg:
+		default:
+			client.conn.Close()
+		}
+	}
+}
+
+// collabHub tracks the drafts being edited
+type collabHub struct {
+	store  models.Store
+	mu     sync.Mutex
+	rooms  map[int]*collabRoom
+	nextID int
+	closed bool
+}
+
+func newCollabHub(store models.Store) *collabHub {
+	return &collabHub{store: store, rooms: make(map[int]*collabRoom)}
+}
+
+// join adds an editor to post's room, opening the room from the stored
+// post when nobody is editing it yet
+func (hub *collabHub) join(post models.Post, user models.User, conn *websocket.Conn) (*collabRoom, *collabClient, error) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.closed {
+		return nil, nil, errCollabClosed
+	}
+	room, ok := hub.rooms[post.ID]
+	if !ok {
+		// Reload, since the post may have been saved since the page loaded
+		latest, err := hub.store.GetByID(post.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		room = &collabRoom{
+			postID:  latest.ID,
+			title:   latest.Title,
+			content: latest.Content,
+			clients: make(map[*collabClient]struct{}),
+		}
+		hub.rooms[post.ID] = room
+	}
+
+	hub.nextID++
+	client := &collabClient{
+		peer: collabPeer{
+			ID:    strconv.Itoa(hub.nextID),
+			Name:  user.DisplayName,
+			Color: collabColors[(hub.nextID-1)%len(collabColors)],
+		},
+		conn: conn,
+		send: make(chan collabMessage, collabSendBuffer),
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	room.clients[client] = struct{}{}
+	client.send <- collabMessage{
+		Type:    "init",
+		Peer:    client.peer.ID,
+		Title:   room.title,
+		Content: room.content,
+		Version: room.version,
+	}
+	room.broadcast(room.presence())
+	return room, client, nil
+}
+
+// leave removes an editor. The last editor out closes the room, saving
+// changes nobody saved.
+func (hub *collabHub) leave(room *collabRoom, client *collabClient) error {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	room.mu.Lock()
+	delete(room.clients, client)
+	close(client.send)
+	empty, dirty := len(room.clients) == 0, room.dirty
+	if !empty {
+		room.broadcast(room.presence())
+	}
+	room.mu.Unlock()
+
+	if !empty {
+		return nil
+	}
+	// Still holding hub.mu, so nobody reopens the room from a stale post
+	delete(hub.rooms, room.postID)
+	if dirty {
+		_, err := room.save(hub.store)
+		return err
+	}
+	return nil
+}
+
+// close disconnects every editor, whose rooms then save as they empty,
+// and turns away new ones
+func (hub *collabHub) close() {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.closed = true
+	for _, room := range hub.rooms {
+		room.mu.Lock()
+		for client := range room.clients {
+			client.conn.Close()
+		}
+		room.mu.Unlock()
+	}
+}
+
+// EditDraft shows the collaborative editor for a draft. Any logged-in
+// author may open it, so authors share a draft by sharing the page's link.
+func (h *Handler) EditDraft(w http.ResponseWriter, r *http.Request) {
+	post, ok := h.lookupDraft(w, r)
+	if !ok {
+		return
+	}
+	templates.DraftEditor(post, isAuthor(r, post)).Render(r.Context(), w)
+}
+
+// CollabSocket upgrades to a WebSocket that keeps a draft in sync between
+// the authors editing it and shares where each one's cursor is
+func (h *Handler) CollabSocket(w http.ResponseWriter, r *http.Request) {
+	user, ok := models.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Login required", http.StatusUnauthorized)
+		return
+	}
+	post, ok := h.lookupDraft(w, r)
+	if !ok {
+		return
+	}
+
+	// Sessions outlive the request, so shutdown waits for them to save
+	h.background.Add(1)
+	defer h.background.Done()
+
+	server := websocket.Server{
+		Handshake: sameOrigin,
+		Handler: func(conn *websocket.Conn) {
+			h.collabSession(conn, post, user)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// collabSession relays one editor's messages until they disconnect
+func (h *Handler) collabSession(conn *websocket.Conn, post models.Post, user models.User) {
+	conn.MaxPayloadBytes = maxCollabMessage
+	// A hijacked connection keeps the server's timeouts, which would end
+	// long editing sessions
+	conn.SetDeadline(time.Time{})
+
+	room, client, err := h.collab.join(post, user, conn)
+	if err != nil {
+		return
+	}
+	go client.writeLoop()
+	defer func() {
+		if err := h.collab.leave(room, client); err != nil {
+			h.logger.Error("saving draft failed", "post_id", post.ID, "error", err)
+		}
+	}()
+
+	for {
+		var msg collabMessage
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			return
+		}
+
+		switch msg.Type {
+		case "edit":
+			room.edit(client, msg.Field, msg.Value)
+		case "cursor":
+			room.moveCursor(client, msg.Field, msg.Start, msg.End)
+		case "save":
+			version, err := room.save(h.store)
+			room.mu.Lock()
+			if err != nil {
+				select {
+				case client.send <- collabMessage{Type: "error", Error: err.Error()}:
+				default:
+				}
+			} else {
+				room.broadcast(collabMessage{Type: "saved", Version: version, Peer: client.peer.ID})
+			}
+			room.mu.Unlock()
+		}
+	}
+}
+
+// lookupDraft resolves the {id} path value to a draft, writing a 400 or
+// 404 response when that fails
+func (h *Handler) lookupDraft(w http.ResponseWriter, r *http.Request) (models.Post, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return models.Post{}, false
+	}
+
+	post, err := h.store.GetByID(id)
+	if err != nil || post.Published {
+		http.NotFound(w, r)
+		return models.Post{}, false
+	}
+	return post, true
+}
+
+// sameOrigin rejects WebSocket handshakes from other sites, which would
+// otherwise ride on the author's session cookie
+func sameOrigin(config *websocket.Config, r *http.Request) error {
+	origin, err := websocket.Origin(config, r)
+	if err != nil {
+		return err
+	}
+	if origin == nil || origin.Host != r.Host {
+		return errors.New("cross-origin WebSocket request")
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+var coAuthor = models.User{Username: "jane", DisplayName: "Jane Doe"}
+
+// collabServer serves draft WebSockets, logging in whoever the X-Test-User
+// header names
+func collabServer(t *testing.T, handler *Handler) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ws/drafts/{id}", handler.CollabSocket)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("X-Test-User") {
+		case testUser.Username:
+			r = asUser(r, testUser)
+		case coAuthor.Username:
+			r = asUser(r, coAuthor)
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// dialDraft opens a draft's WebSocket as user
+func dialDraft(t *testing.T, server *httptest.Server, id int, user models.User) *websocket.Conn {
+	t.Helper()
+
+	config, err := websocket.NewConfig("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/drafts/"+strconv.Itoa(id), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Header.Set("X-Test-User", user.Username)
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// receiveUntil reads messages until one of type kind arrives
+func receiveUntil(t *testing.T, conn *websocket.Conn, kind string) collabMessage {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		var msg collabMessage
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			t.Fatalf("Waiting for %q: %v", kind, err)
+		}
+		if msg.Type == kind {
+			return msg
+		}
+	}
+}
+
+func TestCollabSyncsEditsAndCursors(t *testing.T) {
+	store := models.NewStore()
+	id := addDraft(t, store)
+	handler := New(store)
+	server := collabServer(t, handler)
+
+	author := dialDraft(t, server, id, testUser)
+	if init := receiveUntil(t, author, "init"); init.Title != "Secret Draft" || init.Content != "Work in progress" {
+		t.Fatalf("Expected the draft in the init message, got %+v", init)
+	}
+	other := dialDraft(t, server, id, coAuthor)
+	receiveUntil(t, other, "init")
+
+	presence := receiveUntil(t, author, "presence")
+	for len(presence.Peers) < 2 {
+		presence = receiveUntil(t, author, "presence")
+	}
+	if presence.Peers[0].Name != "Test Author" || presence.Peers[1].Name != "Jane Doe" {
+		t.Errorf("Expected both authors present, got %+v", presence.Peers)
+	}
+
+	websocket.JSON.Send(author, collabMessage{Type: "edit", Field: "content", Value: "Written together"})
+	edit := receiveUntil(t, other, "edit")
+	if edit.Field != "content" || edit.Value != "Written together" || edit.Version != 1 {
+		t.Errorf("Expected the co-author to get the edit, got %+v", edit)
+	}
+
+	websocket.JSON.Send(other, collabMessage{Type: "cursor", Field: "content", Start: 3, End: 7})
+	presence = receiveUntil(t, author, "presence")
+	if jane := presence.Peers[1]; jane.Field != "content" || jane.Start != 3 || jane.End != 7 {
+		t.Errorf("Expected the co-author's cursor, got %+v", jane)
+	}
+
+	websocket.JSON.Send(other, collabMessage{Type: "save"})
+	receiveUntil(t, author, "saved")
+	post, _ := store.GetByID(id)
+	if post.Content != "Written together" || post.Published {
+		t.Errorf("Expected the saved draft, got %+v", post)
+	}
+}
+
+func TestCollabSavesWhenLastEditorLeaves(t *testing.T) {
+	store := models.NewStore()
+	id := addDraft(t, store)
+	handler := New(store)
+	server := collabServer(t, handler)
+
+	conn := dialDraft(t, server, id, testUser)
+	receiveUntil(t, conn, "init")
+	websocket.JSON.Send(conn, collabMessage{Type: "edit", Field: "title", Value: "Unsaved Title"})
+	receiveUntil(t, conn, "edit")
+	conn.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		post, _ := store.GetByID(id)
+		if post.Title == "Unsaved Title" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the edit to be saved on leaving, got %q", post.Title)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCollabRejects(t *testing.T) {
+	store := models.NewStore()
+	id := addDraft(t, store)
+	server := collabServer(t, New(store))
+
+	tests := []struct {
+		name   string
+		path   string
+		user   string
+		origin string
+		status int
+	}{
+		{"anonymous", "/ws/drafts/" + strconv.Itoa(id), "", server.URL, http.StatusUnauthorized},
+		{"published posts", "/ws/drafts/1", testUser.Username, server.URL, http.StatusNotFound},
+		{"other sites", "/ws/drafts/" + strconv.Itoa(id), testUser.Username, "https://evil.example", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", server.URL+tt.path, nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		req.Header.Set("Origin", tt.origin)
+		req.Header.Set("X-Test-User", tt.user)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, resp.StatusCode)
+		}
+	}
+}
+
+func TestEditDraftPage(t *testing.T) {
+	store := models.NewStore()
+	id := addDraft(t, store)
+	handler := New(store)
+
+	req := httptest.NewRequest("GET", "/drafts/"+strconv.Itoa(id)+"/edit", nil)
+	req.SetPathValue("id", strconv.Itoa(id))
+	w := httptest.NewRecorder()
+	handler.EditDraft(w, asUser(req, coAuthor))
+
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, `data-socket="/ws/drafts/`+strconv.Itoa(id)+`"`) {
+		t.Fatalf("Expected the editor, got %d", w.Code)
+	}
+	// Only the draft's author gets the publish button
+	if strings.Contains(body, `hx-post="/posts/`+strconv.Itoa(id)+`/publish"`) {
+		t.Error("Expected no publish button for a co-author")
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// WithSpamChecker sets the checks new comments go through before they are
+// stored. The default combines a honeypot field with keyword heuristics.
+func WithSpamChecker(checker models.SpamChecker) Option {
+	return func(h *Handler) {
+		h.spam = checker
+	}
+}
+
+// ListComments renders the comment list for a post
+func (h *Handler) ListComments(w http.ResponseWriter, r *http.Request) {
+	post, ok := h.lookupPost(w, r)
+	if !ok {
+		return
+	}
+	templates.CommentList(h.comments.ForPost(post.ID)).Render(r.Context(), w)
+}
+
+// CreateComment adds a comment to a post and re-renders the comment list.
+// Comments the spam checks reject are dropped; suspect ones are held for
+// moderation.
+func (h *Handler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	post, ok := h.lookupPost(w, r)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	if wait := h.commentLimiter.allow(clientIP(r)); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Round(time.Second)/time.Second)))
+		http.Error(w, "You're commenting too fast. Please wait a moment.", http.StatusTooManyRequests)
+		return
+	}
+
+	comment := models.Comment{
+		PostID:  post.ID,
+		Author:  r.FormValue("author"),
+		Content: r.FormValue("content"),
+	}
+
+	verdict, err := h.spam.Check(r.Context(), models.CommentSubmission{
+		Comment:   comment,
+		IP:        clientIP(r),
+		UserAgent: r.UserAgent(),
+		Referrer:  r.Referer(),
+		Permalink: postURL(h.siteURL(r), post),
+		Honeypot:  r.FormValue(templates.CommentHoneypotField),
+	})
+	if err != nil {
+		// Hold the comment rather than publish it unchecked
+		h.logger.Warn("spam check failed", "post_id", post.ID, "error", err)
+		verdict = max(verdict, models.SpamSuspect)
+	}
+
+	switch verdict {
+	case models.SpamRejected:
+		h.logger.Info("comment rejected as spam", "post_id", post.ID, "ip", clientIP(r))
+		http.Error(w, "Your comment looks like spam and was not posted.", http.StatusUnprocessableEntity)
+		return
+	case models.SpamSuspect:
+		comment.Status = models.CommentPending
+	}
+
+	if _, err := h.comments.Add(comment); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if comment.Status == models.CommentPending {
+		templates.CommentHeld(h.comments.ForPost(post.ID)).Render(r.Context(), w)
+		return
+	}
+	templates.CommentList(h.comments.ForPost(post.ID)).Render(r.Context(), w)
+}
+
+// ModerationQueue lists the comments held back by the spam checks
+func (h *Handler) ModerationQueue(w http.ResponseWriter, r *http.Request) {
+	var held []templates.HeldComment
+	for _, comment := range h.comments.Pending() {
+		post, err := h.store.GetByID(comment.PostID)
+		if err != nil {
+			// Comments on purged posts have nowhere to appear
+			continue
+		}
+		held = append(held, templates.HeldComment{Comment: comment, Post: post})
+	}
+
+	templates.ModerationPage(held).Render(r.Context(), w)
+}
+
+// ApproveComment publishes a held comment
+func (h *Handler) ApproveComment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.comments.Approve(id); err != nil {
+		writeCommentError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/comments", http.StatusSeeOther)
+}
+
+// DeleteComment removes a comment, typically spam from the moderation queue
+func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.comments.Delete(id); err != nil {
+		writeCommentError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/comments", http.StatusSeeOther)
+}
+
+// writeCommentError responds 404 for unknown comments and 500 otherwise
+func writeCommentError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, models.ErrCommentNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
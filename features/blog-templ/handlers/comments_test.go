@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+func newCommentRequest(postID string, form url.Values) *http.Request {
+	req := httptest.NewRequest("POST", "/posts/"+postID+"/comments", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetPathValue("id", postID)
+	return req
+}
+
+func TestPostDetailHandler(t *testing.T) {
+	handler := New(models.NewStore())
+
+	req := httptest.NewRequest("GET", "/posts/1", nil)
+	req.SetPathValue("id", "1")
+	w := httptest.NewRecorder()
+
+	handler.PostDetail(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	body := w.Body.String()
+	for _, expected := range []string{"Getting Started with Templ and HTMX", "comment-list", "/posts/1/comments"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Response body missing expected content: %s", expected)
+		}
+	}
+}
+
+func TestPostDetailHandlerErrors(t *testing.T) {
+	handler := New(models.NewStore())
+
+	tests := []struct {
+		name           string
+		id             string
+		expectedStatus int
+	}{
+		{name: "Unknown post", id: "999", expectedStatus: http.StatusNotFound},
+		{name: "Unknown slug", id: "abc", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/posts/"+tt.id, nil)
+			req.SetPathValue("id", tt.id)
+			w := httptest.NewRecorder()
+
+			handler.PostDetail(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestListCommentsHandler(t *testing.T) {
+	comments := models.NewCommentStore()
+	comments.Add(models.Comment{PostID: 1, Author: "Reader", Content: "Great intro"})
+	handler := New(models.NewStore(), WithCommentStore(comments))
+
+	req := httptest.NewRequest("GET", "/posts/1/comments", nil)
+	req.SetPathValue("id", "1")
+	w := httptest.NewRecorder()
+
+	handler.ListComments(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "Great intro") {
+		t.Error("Expected comment in response")
+	}
+
+	// Another post has no comments yet
+	req = httptest.NewRequest("GET", "/posts/2/comments", nil)
+	req.SetPathValue("id", "2")
+	w = httptest.NewRecorder()
+
+	handler.ListComments(w, req)
+
+	if !strings.Contains(w.Body.String(), "No comments yet") {
+		t.Error("Expected empty state for post without comments")
+	}
+}
+
+func TestCreateCommentHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		postID         string
+		formData       url.Values
+		expectedStatus int
+	}{
+		{
+			name:           "Valid comment",
+			postID:         "1",
+			formData:       url.Values{"author": {"Reader"}, "content": {"Nice <b>post</b>"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Missing content",
+			postID:         "1",
+			formData:       url.Values{"author": {"Reader"}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Missing author",
+			postID:         "1",
+			formData:       url.Values{"content": {"Hello"}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unknown post",
+			postID:         "999",
+			formData:       url.Values{"author": {"Reader"}, "content": {"Hello"}},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comments := models.NewCommentStore()
+			handler := New(models.NewStore(), WithCommentStore(comments), WithCommentInterval(0))
+
+			w := httptest.NewRecorder()
+			handler.CreateComment(w, newCommentRequest(tt.postID, tt.formData))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				body := w.Body.String()
+				if !strings.Contains(body, "Nice &lt;b&gt;post&lt;/b&gt;") {
+					t.Error("Expected escaped comment content in response")
+				}
+				if len(comments.ForPost(1)) != 1 {
+					t.Error("Expected comment to be stored")
+				}
+			}
+		})
+	}
+}
+
+func TestCreateCommentRateLimit(t *testing.T) {
+	handler := New(models.NewStore(), WithCommentInterval(time.Minute))
+	form := url.Values{"author": {"Reader"}, "content": {"Hello"}}
+
+	w := httptest.NewRecorder()
+	handler.CreateComment(w, newCommentRequest("1", form))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected first comment to succeed, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.CreateComment(w, newCommentRequest("1", form))
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After header")
+	}
+}
+
+func TestCreateCommentSpam(t *testing.T) {
+	tests := []struct {
+		name     string
+		form     url.Values
+		status   int
+		held     bool
+		rejected bool
+	}{
+		{
+			name:     "Honeypot filled in",
+			form:     url.Values{"author": {"Bot"}, "content": {"Nice post"}, "website": {"http://spam.example"}},
+			status:   http.StatusUnprocessableEntity,
+			rejected: true,
+		},
+		{
+			name:     "Blocked word",
+			form:     url.Values{"author": {"Bot"}, "content": {"Best online casino"}},
+			status:   http.StatusUnprocessableEntity,
+			rejected: true,
+		},
+		{
+			name:   "Borderline",
+			form:   url.Values{"author": {"Stranger"}, "content": {"Click here for a free gift"}},
+			status: http.StatusOK,
+			held:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comments := models.NewCommentStore()
+			handler := New(models.NewStore(), WithCommentStore(comments), WithCommentInterval(0))
+
+			w := httptest.NewRecorder()
+			handler.CreateComment(w, newCommentRequest("1", tt.form))
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+			if len(comments.ForPost(1)) != 0 {
+				t.Error("Expected the comment not to be published")
+			}
+			if held := len(comments.Pending()) == 1; held != tt.held {
+				t.Errorf("Expected held %v, got %v", tt.held, held)
+			}
+			if tt.held && !strings.Contains(w.Body.String(), "once an author has reviewed it") {
+				t.Error("Expected a note that the comment awaits moderation")
+			}
+		})
+	}
+}
+
+// brokenSpamChecker fails like an unreachable spam service
+type brokenSpamChecker struct{}
+
+func (brokenSpamChecker) Check(context.Context, models.CommentSubmission) (models.SpamVerdict, error) {
+	return models.SpamClean, errors.New("service unavailable")
+}
+
+func TestCreateCommentHeldWhenSpamCheckFails(t *testing.T) {
+	var logs bytes.Buffer
+	comments := models.NewCommentStore()
+	handler := New(models.NewStore(), WithCommentStore(comments), WithCommentInterval(0),
+		WithSpamChecker(brokenSpamChecker{}), WithLogger(jsonLogger(&logs)))
+
+	w := httptest.NewRecorder()
+	handler.CreateComment(w, newCommentRequest("1", url.Values{"author": {"Reader"}, "content": {"Hello"}}))
+
+	if w.Code != http.StatusOK || len(comments.Pending()) != 1 {
+		t.Errorf("Expected the comment to be held, got %d with %d pending", w.Code, len(comments.Pending()))
+	}
+	if !strings.Contains(logs.String(), "service unavailable") {
+		t.Errorf("Expected the failure to be logged, got %s", logs.String())
+	}
+}
+
+func TestModerateComments(t *testing.T) {
+	comments := models.NewCommentStore()
+	spam, _ := comments.Add(models.Comment{PostID: 1, Author: "Spammer", Content: "Cheap backlinks", Status: models.CommentPending})
+	good, _ := comments.Add(models.Comment{PostID: 2, Author: "Reader", Content: "Click here to see my demo", Status: models.CommentPending})
+	handler := New(models.NewStore(), WithCommentStore(comments))
+
+	w := httptest.NewRecorder()
+	handler.ModerationQueue(w, asUser(httptest.NewRequest("GET", "/admin/comments", nil), testUser))
+	body := w.Body.String()
+	if !strings.Contains(body, "Cheap backlinks") || !strings.Contains(body, "Building Real-time Search with HTMX") {
+		t.Errorf("Expected the held comments and their posts, got:\n%s", body)
+	}
+
+	moderate := func(action string, id int) int {
+		target := "/admin/comments/" + strconv.Itoa(id) + "/" + action
+		req := httptest.NewRequest("POST", target, nil)
+		req.SetPathValue("id", strconv.Itoa(id))
+		w := httptest.NewRecorder()
+		if action == "approve" {
+			handler.ApproveComment(w, asUser(req, testUser))
+		} else {
+			handler.DeleteComment(w, asUser(req, testUser))
+		}
+		return w.Code
+	}
+
+	if status := moderate("approve", good.ID); status != http.StatusSeeOther {
+		t.Errorf("Expected a redirect after approving, got %d", status)
+	}
+	if status := moderate("delete", spam.ID); status != http.StatusSeeOther {
+		t.Errorf("Expected a redirect after deleting, got %d", status)
+	}
+	if status := moderate("delete", spam.ID); status != http.StatusNotFound {
+		t.Errorf("Expected 404 for a deleted comment, got %d", status)
+	}
+
+	if len(comments.Pending()) != 0 || len(comments.ForPost(2)) != 1 || len(comments.ForPost(1)) != 0 {
+		t.Error("Expected the approved comment published and the spam gone")
+	}
+}
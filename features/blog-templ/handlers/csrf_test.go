@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// csrfSite serves the home page and a mutating endpoint behind ProtectCSRF
+func csrfSite(handler *Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", handler.Index)
+	mux.HandleFunc("POST /theme", handler.SetTheme)
+	mux.HandleFunc("POST /api/posts", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	return handler.ProtectCSRF(mux)
+}
+
+// csrfCookie fetches the home page and returns the token cookie it issued
+func csrfCookie(t *testing.T, site http.Handler) *http.Cookie {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	site.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == csrfCookieName {
+			if !strings.Contains(w.Body.String(), cookie.Value) {
+				t.Fatal("Expected the page to embed the CSRF token")
+			}
+			return cookie
+		}
+	}
+	t.Fatal("Expected a CSRF cookie")
+	return nil
+}
+
+func TestCSRFFormField(t *testing.T) {
+	site := csrfSite(New(models.NewStore()))
+	cookie := csrfCookie(t, site)
+
+	tests := []struct {
+		name  string
+		token string
+		code  int
+	}{
+		{name: "valid token", token: cookie.Value, code: http.StatusSeeOther},
+		{name: "missing token", token: "", code: http.StatusForbidden},
+		{name: "wrong token", token: strings.Repeat("0", len(cookie.Value)), code: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		form := url.Values{"theme": {"dark"}}
+		if tt.token != "" {
+			form.Set(templates.CSRFFieldName, tt.token)
+		}
+		req := httptest.NewRequest("POST", "/theme", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(cookie)
+		w := httptest.NewRecorder()
+
+		site.ServeHTTP(w, req)
+
+		if w.Code != tt.code {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.code, w.Code)
+		}
+	}
+}
+
+func TestCSRFHeader(t *testing.T) {
+	site := csrfSite(New(models.NewStore()))
+	cookie := csrfCookie(t, site)
+
+	form := url.Values{"theme": {"dark"}}
+	req := httptest.NewRequest("POST", "/theme", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set(templates.CSRFHeaderName, cookie.Value)
+	req.AddCookie(cookie)
+	w := httptest.NewRecorder()
+
+	site.ServeHTTP(w, req)
+
+	if w.Code != http.StatusSeeOther {
+		t.Errorf("Expected status 303, got %d", w.Code)
+	}
+}
+
+func TestCSRFRejectsRequestWithoutCookie(t *testing.T) {
+	site := csrfSite(New(models.NewStore()))
+
+	// A forged request can guess neither the cookie nor the token
+	form := url.Values{"theme": {"dark"}, templates.CSRFFieldName: {"forged"}}
+	req := httptest.NewRequest("POST", "/theme", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	site.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", w.Code)
+	}
+}
+
+func TestCSRFExemptsAPI(t *testing.T) {
+	site := csrfSite(New(models.NewStore()))
+
+	req := httptest.NewRequest("POST", "/api/posts", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	site.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected the API to skip CSRF checks, got %d", w.Code)
+	}
+}
+
+func TestCSRFTokenInForms(t *testing.T) {
+	handler := New(models.NewStore())
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /new", handler.NewPostForm)
+	mux.HandleFunc("GET /login", handler.LoginForm)
+	site := handler.ProtectCSRF(mux)
+
+	for _, path := range []string{"/new", "/login"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: "abc123"})
+		w := httptest.NewRecorder()
+
+		site.ServeHTTP(w, req)
+
+		body := w.Body.String()
+		if !strings.Contains(body, `name="csrf_token" value="abc123"`) {
+			t.Errorf("%s: expected the CSRF field in the form", path)
+		}
+		if !strings.Contains(body, `hx-headers="{&#34;X-CSRF-Token&#34;:&#34;abc123&#34;}"`) {
+			t.Errorf("%s: expected HTMX to send the CSRF header", path)
+		}
+		if len(w.Result().Cookies()) != 0 {
+			t.Errorf("%s: expected the existing token to be reused", path)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+// addDraft stores a draft by testUser and returns its ID
+func addDraft(t *testing.T, store *models.MemoryStore) int {
+	t.Helper()
+
+	err := store.Add(models.Post{
+		Title:          "Secret Draft",
+		Content:        "Work in progress",
+		Author:         testUser.DisplayName,
+		AuthorUsername: testUser.Username,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store.GetAll()[0].ID
+}
+
+func asUser(req *http.Request, user models.User) *http.Request {
+	return req.WithContext(models.ContextWithUser(req.Context(), user))
+}
+
+func TestCreatePostAsDraft(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+
+	form := url.Values{"title": {"Draft Post"}, "content": {"Later"}, "action": {"draft"}}
+	req := httptest.NewRequest("POST", "/posts", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	handler.CreatePost(w, asUser(req, testUser))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("HX-Redirect"); got != "/drafts" {
+		t.Errorf("Expected HX-Redirect to /drafts, got %q", got)
+	}
+
+	post := store.GetAll()[0]
+	if post.Published {
+		t.Error("Expected post to be saved as a draft")
+	}
+	if post.AuthorUsername != testUser.Username {
+		t.Errorf("Expected author username %q, got %q", testUser.Username, post.AuthorUsername)
+	}
+}
+
+func TestDraftsHiddenFromPublicViews(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+	addDraft(t, store)
+
+	views := []struct {
+		name  string
+		serve func(w http.ResponseWriter, r *http.Request)
+		path  string
+	}{
+		{name: "index", serve: handler.Index, path: "/"},
+		{name: "search", serve: handler.Search, path: "/search?q=secret"},
+		{name: "rss", serve: handler.RSSFeed, path: "/feed.xml"},
+		{name: "atom", serve: handler.AtomFeed, path: "/atom.xml"},
+		{name: "sitemap", serve: handler.Sitemap, path: "/sitemap.xml"},
+	}
+
+	for _, view := range views {
+		t.Run(view.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			view.serve(w, httptest.NewRequest("GET", view.path, nil))
+
+			if strings.Contains(w.Body.String(), "Secret Draft") {
+				t.Errorf("Draft leaked into %s", view.name)
+			}
+		})
+	}
+}
+
+func TestDraftDetailVisibility(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+	id := strconv.Itoa(addDraft(t, store))
+
+	newReq := func() *http.Request {
+		req := httptest.NewRequest("GET", "/posts/"+id, nil)
+		req.SetPathValue("id", id)
+		return req
+	}
+
+	w := httptest.NewRecorder()
+	handler.PostDetail(w, newReq())
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for anonymous visitor, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.PostDetail(w, asUser(newReq(), models.User{Username: "someone-else"}))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another user, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.PostDetail(w, asUser(newReq(), testUser))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for the author, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "This is a draft") {
+		t.Error("Expected draft banner for the author")
+	}
+}
+
+func TestDraftsHandler(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+	addDraft(t, store)
+
+	w := httptest.NewRecorder()
+	handler.Drafts(w, asUser(httptest.NewRequest("GET", "/drafts", nil), testUser))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "Secret Draft") {
+		t.Error("Expected the author's draft to be listed")
+	}
+
+	w = httptest.NewRecorder()
+	handler.Drafts(w, asUser(httptest.NewRequest("GET", "/drafts", nil), models.User{Username: "other"}))
+	if strings.Contains(w.Body.String(), "Secret Draft") {
+		t.Error("Expected other users not to see the draft")
+	}
+}
+
+func TestPublishPostHandler(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+	id := addDraft(t, store)
+	idStr := strconv.Itoa(id)
+
+	newReq := func() *http.Request {
+		req := httptest.NewRequest("POST", "/posts/"+idStr+"/publish", nil)
+		req.SetPathValue("id", idStr)
+		return req
+	}
+
+	// Posts by other authors are hidden, so publishing them is a 404
+	w := httptest.NewRecorder()
+	handler.PublishPost(w, asUser(newReq(), models.User{Username: "other"}))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another user, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.PublishPost(w, asUser(newReq(), testUser))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	post, _ := store.GetByID(id)
+	if !post.Published {
+		t.Error("Expected post to be published")
+	}
+
+	// Now it shows up on the public index
+	w = httptest.NewRecorder()
+	handler.Index(w, httptest.NewRequest("GET", "/", nil))
+	if !strings.Contains(w.Body.String(), "Secret Draft") {
+		t.Error("Expected published post on the index")
+	}
+}
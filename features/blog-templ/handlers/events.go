@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// eventBufferSize is how many events a slow client may fall behind before
+// further events are dropped for it
+const eventBufferSize = 16
+
+// sseEvent is a named server-sent event
+type sseEvent struct {
+	name string
+	data string
+}
+
+// broker fans events out to every connected SSE client
+type broker struct {
+	mu      sync.Mutex
+	clients map[chan sseEvent]struct{}
+	// done is closed when the server shuts down, ending every stream
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newBroker creates a broker with no clients
+func newBroker() *broker {
+	return &broker{
+		clients: make(map[chan sseEvent]struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// close ends every open stream and any opened later
+func (b *broker) close() {
+	b.closeOnce.Do(func() { close(b.done) })
+}
+
+// subscribe registers a new client and returns its event channel
+func (b *broker) subscribe() chan sseEvent {
+	ch := make(chan sseEvent, eventBufferSize)
+
+	b.mu.Lock()
+	b.clients[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch
+}
+
+// unsubscribe removes a client
+func (b *broker) unsubscribe(ch chan sseEvent) {
+	b.mu.Lock()
+	delete(b.clients, ch)
+	b.mu.Unlock()
+}
+
+// publish sends an event to every client without blocking on slow ones
+func (b *broker) publish(event sseEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.clients {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// count returns the number of connected clients
+func (b *broker) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.clients)
+}
+
+// Events streams live updates to the browser as server-sent events.
+// Each newly published post arrives as a "post" event carrying its rendered card.
+func (h *Handler) Events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// Streams stay open indefinitely, so the server's write timeout can't apply
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	events := h.events.subscribe()
+	defer h.events.unsubscribe(events)
+
+	// Comments keep idle connections from being closed by proxies
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.events.done:
+			return
+		case event := <-events:
+			writeEvent(w, event)
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// broadcastPost pushes a newly published post's card to connected clients
+func (h *Handler) broadcastPost(post models.Post) {
+	if !post.Published {
+		return
+	}
+
+	// Render outside the request so the card is the same for every reader
+	var buf bytes.Buffer
+	if err := templates.PostCard(post).Render(context.Background(), &buf); err != nil {
+		return
+	}
+
+	h.events.publish(sseEvent{name: "post", data: buf.String()})
+}
+
+// writeEvent writes an event in the text/event-stream format, splitting
+// multi-line data across data fields
+func writeEvent(w http.ResponseWriter, event sseEvent) {
+	fmt.Fprintf(w, "event: %s\n", event.name)
+	for _, line := range strings.Split(event.data, "\n") {
+		fmt.Fprintf(w, "data: %s\n", line)
+	}
+	fmt.Fprint(w, "\n")
+}
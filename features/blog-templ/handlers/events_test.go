@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+// syncRecorder is a ResponseRecorder safe to read while a stream is being written
+type syncRecorder struct {
+	mu sync.Mutex
+	*httptest.ResponseRecorder
+}
+
+func (r *syncRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ResponseRecorder.Write(p)
+}
+
+func (r *syncRecorder) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ResponseRecorder.Flush()
+}
+
+func (r *syncRecorder) body() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Body.String()
+}
+
+// streamEvents starts the Events handler and waits until it is subscribed
+func streamEvents(t *testing.T, handler *Handler) (*syncRecorder, context.CancelFunc, <-chan struct{}) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/events", nil).WithContext(ctx)
+	w := &syncRecorder{ResponseRecorder: httptest.NewRecorder()}
+	done := make(chan struct{})
+
+	go func() {
+		handler.Events(w, req)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for handler.events.count() == 0 {
+		if time.Now().After(deadline) {
+			cancel()
+			t.Fatal("Events handler never subscribed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	return w, cancel, done
+}
+
+func waitForBody(t *testing.T, w *syncRecorder, want string) string {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		body := w.body()
+		if strings.Contains(body, want) {
+			return body
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected stream to contain %q, got:\n%s", want, body)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestEventsBroadcastsNewPost(t *testing.T) {
+	handler := New(models.NewStore())
+	w, cancel, done := streamEvents(t, handler)
+	defer func() { cancel(); <-done }()
+
+	form := url.Values{}
+	form.Add("title", "Live Post")
+	form.Add("content", "Fresh off the press")
+	form.Add("action", "publish")
+
+	req := httptest.NewRequest("POST", "/posts", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	handler.CreatePost(httptest.NewRecorder(), asUser(req, testUser))
+
+	body := waitForBody(t, w, "Live Post")
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected text/event-stream, got %s", ct)
+	}
+	if !strings.Contains(body, "event: post\n") {
+		t.Error("Expected a named post event")
+	}
+	for _, line := range strings.Split(strings.TrimSpace(body), "\n") {
+		if line != "" && !strings.HasPrefix(line, "event: ") && !strings.HasPrefix(line, "data: ") {
+			t.Errorf("Unexpected line in event stream: %q", line)
+		}
+	}
+}
+
+func TestEventsSkipsDrafts(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+	w, cancel, done := streamEvents(t, handler)
+
+	form := url.Values{}
+	form.Add("title", "Secret Draft")
+	form.Add("content", "Not yet")
+	form.Add("action", "draft")
+
+	req := httptest.NewRequest("POST", "/posts", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	handler.CreatePost(httptest.NewRecorder(), asUser(req, testUser))
+
+	// Publishing the draft announces it
+	draft := store.GetAll()[0]
+	req = httptest.NewRequest("POST", "/posts/1/publish", nil)
+	req.SetPathValue("id", strconv.Itoa(draft.ID))
+	handler.PublishPost(httptest.NewRecorder(), asUser(req, testUser))
+
+	body := waitForBody(t, w, "Secret Draft")
+	if strings.Count(body, "event: post") != 1 {
+		t.Errorf("Expected exactly one post event, got:\n%s", body)
+	}
+
+	cancel()
+	<-done
+	if handler.events.count() != 0 {
+		t.Error("Expected client to be unsubscribed after disconnect")
+	}
+}
+
+func TestEventsHeartbeat(t *testing.T) {
+	handler := New(models.NewStore())
+	handler.heartbeat = 5 * time.Millisecond
+
+	w, cancel, done := streamEvents(t, handler)
+	defer func() { cancel(); <-done }()
+
+	waitForBody(t, w, ": ping\n\n")
+}
+
+func TestBrokerDropsEventsForSlowClients(t *testing.T) {
+	b := newBroker()
+	ch := b.subscribe()
+
+	// Publishing past the buffer must not block
+	for i := 0; i < eventBufferSize*2; i++ {
+		b.publish(sseEvent{name: "post", data: "x"})
+	}
+
+	if len(ch) != eventBufferSize {
+		t.Errorf("Expected %d buffered events, got %d", eventBufferSize, len(ch))
+	}
+
+	b.unsubscribe(ch)
+	if b.count() != 0 {
+		t.Error("Expected no clients after unsubscribe")
+	}
+}
+
+func TestEventsRequiresFlusher(t *testing.T) {
+	handler := New(models.NewStore())
+	w := struct{ http.ResponseWriter }{httptest.NewRecorder()}
+
+	handler.Events(w, httptest.NewRequest("GET", "/events", nil))
+
+	if rec := w.ResponseWriter.(*httptest.ResponseRecorder); rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", rec.Code)
+	}
+}
+
+func TestCloseStreamsEndsEvents(t *testing.T) {
+	handler := New(models.NewStore())
+	_, cancel, done := streamEvents(t, handler)
+	defer cancel()
+
+	handler.CloseStreams()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the stream to end when streams are closed")
+	}
+}
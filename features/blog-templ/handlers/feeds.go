@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+const (
+	feedTitle       = "Blog Doodle"
+	feedDescription = "Real-time search with Templ & HTMX"
+	// feedSize is the number of most recent posts included in feeds
+	feedSize = 20
+	// summaryLength is the maximum number of characters in a post summary
+	summaryLength = 200
+)
+
+// rssFeed is the root element of an RSS 2.0 document
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	SelfLink      atomLink  `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Categories  []string `xml:"category"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// atomFeed is the root element of an Atom 1.0 document
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     atomAuthor     `xml:"author"`
+	Summary    string         `xml:"summary"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// feedInfo describes one feed: the site's main feed or a category's
+type feedInfo struct {
+	title       string
+	description string
+	// page is the path of the HTML page the feed mirrors
+	page string
+	// path is the feed's own path, without the .xml file name
+	path string
+}
+
+// siteFeed describes the feed of every published post
+var siteFeed = feedInfo{title: feedTitle, description: feedDescription, page: "/"}
+
+// RSSFeed serves the most recent posts as an RSS 2.0 feed
+func (h *Handler) RSSFeed(w http.ResponseWriter, r *http.Request) {
+	writeRSS(w, h.siteURL(r), siteFeed, models.PublishedOnly(h.store.GetAll()))
+}
+
+// AtomFeed serves the most recent posts as an Atom 1.0 feed
+func (h *Handler) AtomFeed(w http.ResponseWriter, r *http.Request) {
+	writeAtom(w, h.siteURL(r), siteFeed, models.PublishedOnly(h.store.GetAll()))
+}
+
+// writeRSS writes the most recent of posts as an RSS 2.0 feed
+func writeRSS(w http.ResponseWriter, base string, info feedInfo, posts []models.Post) {
+	posts = recentPosts(posts, feedSize)
+
+	feed := rssFeed{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:       info.title,
+			Link:        base + info.page,
+			Description: info.description,
+			SelfLink: atomLink{
+				Href: base + info.path + "/feed.xml",
+				Rel:  "self",
+				Type: "application/rss+xml",
+			},
+		},
+	}
+
+	if len(posts) > 0 {
+		feed.Channel.LastBuildDate = posts[0].PublishedAt.Format(time.RFC1123Z)
+	}
+
+	for _, post := range posts {
+		link := postURL(base, post)
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       post.Title,
+			Link:        link,
+			Description: summarize(post.Content, summaryLength),
+			Categories:  post.Tags,
+			GUID:        rssGUID{IsPermaLink: true, Value: postIDURL(base, post)},
+			PubDate:     post.PublishedAt.Format(time.RFC1123Z),
+		})
+	}
+
+	writeXML(w, "application/rss+xml; charset=utf-8", feed)
+}
+
+// writeAtom writes the most recent of posts as an Atom 1.0 feed
+func writeAtom(w http.ResponseWriter, base string, info feedInfo, posts []models.Post) {
+	posts = recentPosts(posts, feedSize)
+
+	feed := atomFeed{
+		Title: info.title,
+		ID:    base + info.page,
+		Links: []atomLink{
+			{Href: base + info.path + "/atom.xml", Rel: "self", Type: "application/atom+xml"},
+			{Href: base + info.page, Rel: "alternate", Type: "text/html"},
+		},
+	}
+
+	// Atom requires <updated>; fall back to now for an empty feed
+	updated := time.Now()
+	if len(posts) > 0 {
+		updated = posts[0].LastModified()
+	}
+	feed.Updated = updated.Format(time.RFC3339)
+
+	for _, post := range posts {
+		link := postURL(base, post)
+		entry := atomEntry{
+			Title:     post.Title,
+			ID:        postIDURL(base, post),
+			Link:      atomLink{Href: link, Rel: "alternate", Type: "text/html"},
+			Published: post.PublishedAt.Format(time.RFC3339),
+			Updated:   post.LastModified().Format(time.RFC3339),
+			Author:    atomAuthor{Name: post.Author},
+			Summary:   summarize(post.Content, summaryLength),
+		}
+		for _, tag := range post.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	writeXML(w, "application/atom+xml; charset=utf-8", feed)
+}
+
+// siteURL returns the configured base URL, or one derived from the request
+func (h *Handler) siteURL(r *http.Request) string {
+	if h.baseURL != "" {
+		return h.baseURL
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// postURL returns the absolute URL of a post's detail page
+func postURL(base string, post models.Post) string {
+	return base + post.URL()
+}
+
+// postIDURL returns the absolute URL of a post by its ID. Unlike its slug,
+// a post's ID never changes, so feeds use this to identify entries.
+func postIDURL(base string, post models.Post) string {
+	return fmt.Sprintf("%s/posts/%d", base, post.ID)
+}
+
+// recentPosts returns up to n posts, most recently published first
+func recentPosts(posts []models.Post, n int) []models.Post {
+	sorted := make([]models.Post, len(posts))
+	copy(sorted, posts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].PublishedAt.After(sorted[j].PublishedAt)
+	})
+
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// summarize shortens content to at most max characters, cutting at a word boundary
+func summarize(content string, max int) string {
+	content = strings.Join(strings.Fields(content), " ")
+	if utf8.RuneCountInString(content) <= max {
+		return content
+	}
+
+	runes := []rune(content)
+	cut := string(runes[:max])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
+
+// writeXML encodes v as an indented XML document
+func writeXML(w http.ResponseWriter, contentType string, v any) {
+	out, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		http.Error(w, "Failed to generate XML", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write([]byte(xml.Header))
+	w.Write(out)
+}
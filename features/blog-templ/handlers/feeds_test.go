@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+func TestRSSFeedHandler(t *testing.T) {
+	handler := New(models.NewStore(), WithBaseURL("https://blog.example.com/"))
+
+	req := httptest.NewRequest("GET", "/feed.xml", nil)
+	w := httptest.NewRecorder()
+
+	handler.RSSFeed(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/rss+xml") {
+		t.Errorf("Expected RSS content type, got %s", ct)
+	}
+
+	var feed rssFeed
+	if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("Feed is not valid XML: %v", err)
+	}
+
+	if feed.Version != "2.0" {
+		t.Errorf("Expected RSS version 2.0, got %s", feed.Version)
+	}
+	if len(feed.Channel.Items) != 4 {
+		t.Fatalf("Expected 4 items, got %d", len(feed.Channel.Items))
+	}
+
+	// Newest post first, linked by slug and identified by ID, which never
+	// changes
+	first := feed.Channel.Items[0]
+	if first.Title != "Type-Safe HTML Templates" {
+		t.Errorf("Expected newest post first, got %s", first.Title)
+	}
+	if first.Link != "https://blog.example.com/posts/type-safe-html-templates" {
+		t.Errorf("Unexpected item link: %s", first.Link)
+	}
+	if first.GUID.Value != "https://blog.example.com/posts/4" || !first.GUID.IsPermaLink {
+		t.Errorf("Expected permalink GUID, got %+v", first.GUID)
+	}
+	if first.PubDate == "" || first.Description == "" {
+		t.Error("Expected pubDate and description to be set")
+	}
+
+	if !strings.Contains(w.Body.String(), `<atom:link href="https://blog.example.com/feed.xml" rel="self"`) {
+		t.Error("Expected atom:link self reference")
+	}
+}
+
+func TestAtomFeedHandler(t *testing.T) {
+	handler := New(models.NewStore())
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/atom.xml", nil)
+	w := httptest.NewRecorder()
+
+	handler.AtomFeed(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/atom+xml") {
+		t.Errorf("Expected Atom content type, got %s", ct)
+	}
+
+	var feed atomFeed
+	if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("Feed is not valid XML: %v", err)
+	}
+
+	if feed.Updated == "" {
+		t.Error("Expected feed updated timestamp")
+	}
+	if len(feed.Entries) != 4 {
+		t.Fatalf("Expected 4 entries, got %d", len(feed.Entries))
+	}
+
+	// Base URL falls back to the request host
+	entry := feed.Entries[0]
+	if entry.ID != "http://localhost:8080/posts/4" {
+		t.Errorf("Unexpected entry ID: %s", entry.ID)
+	}
+	if entry.Author.Name != "John Smith" {
+		t.Errorf("Expected author John Smith, got %s", entry.Author.Name)
+	}
+	if len(entry.Categories) != 3 {
+		t.Errorf("Expected 3 categories, got %d", len(entry.Categories))
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		max     int
+		want    string
+	}{
+		{name: "short content", content: "Hello world", max: 20, want: "Hello world"},
+		{name: "collapses whitespace", content: "Hello\n\n  world", max: 20, want: "Hello world"},
+		{name: "cuts at word boundary", content: "Hello wonderful world", max: 12, want: "Hello…"},
+		{name: "multibyte characters", content: "안녕하세요 반갑습니다", max: 7, want: "안녕하세요…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := summarize(tt.content, tt.max); got != tt.want {
+				t.Errorf("summarize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,1158 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// This file holds a small GraphQL engine: enough of the language for
+// clients to query the blog (operations, variables, aliases, fragments and
+// @skip/@include), but no mutations, subscriptions or introspection.
+// Resolvers receive every parent object at a level at once, so a field
+// such as Post.author is resolved for a whole list of posts with one
+// batched lookup, the way a dataloader would.
+
+const (
+	// maxGraphQLDepth limits how deeply queries may nest fields
+	maxGraphQLDepth = 10
+	// maxGraphQLQuery limits the length of a query document
+	maxGraphQLQuery = 16 << 10
+)
+
+// gqlError is an error in the GraphQL response format
+type gqlError struct {
+	Message   string        `json:"message"`
+	Locations []gqlLocation `json:"locations,omitempty"`
+	Path      []any         `json:"path,omitempty"`
+}
+
+type gqlLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (e *gqlError) Error() string {
+	return e.Message
+}
+
+// --- Lexer ---
+
+type gqlTokenKind int
+
+const (
+	tokEOF gqlTokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type gqlToken struct {
+	kind  gqlTokenKind
+	value string
+	pos   int
+}
+
+// lexGraphQL splits a query document into tokens. Commas, whitespace and
+// comments are insignificant in GraphQL and are dropped.
+func lexGraphQL(src string) ([]gqlToken, error) {
+	var tokens []gqlToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' && src[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "..."):
+			tokens = append(tokens, gqlToken{tokPunct, "...", i})
+			i += 3
+		case strings.IndexByte("!$&()[]{}:=@|", c) >= 0:
+			tokens = append(tokens, gqlToken{tokPunct, string(c), i})
+			i++
+		case c == '_' || isLetter(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || isLetter(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			tokens = append(tokens, gqlToken{tokName, src[start:i], start})
+		case c == '-' || isDigit(c):
+			start := i
+			kind := tokInt
+			i++
+			for i < len(src) && isDigit(src[i]) {
+				i++
+			}
+			if i < len(src) && src[i] == '.' {
+				kind = tokFloat
+				for i++; i < len(src) && isDigit(src[i]); i++ {
+				}
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				kind = tokFloat
+				i++
+				if i < len(src) && (src[i] == '+' || src[i] == '-') {
+					i++
+				}
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, gqlToken{kind, src[start:i], start})
+		case c == '"':
+			value, end, err := lexString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, gqlToken{tokString, value, i})
+			i = end
+		default:
+			r, _ := utf8.DecodeRuneInString(src[i:])
+			return nil, syntaxError(src, i, fmt.Sprintf("unexpected character %q", r))
+		}
+	}
+	return append(tokens, gqlToken{tokEOF, "", len(src)}), nil
+}
+
+// lexString reads the string starting at src[start], returning its value
+// and the offset just past it
+func lexString(src string, start int) (string, int, error) {
+	if strings.HasPrefix(src[start:], `"""`) {
+		end := strings.Index(src[start+3:], `"""`)
+		if end < 0 {
+			return "", 0, syntaxError(src, start, "unterminated block string")
+		}
+		return strings.TrimSpace(src[start+3 : start+3+end]), start + 3 + end + 3, nil
+	}
+
+	var b strings.Builder
+	for i := start + 1; i < len(src); i++ {
+		switch c := src[i]; c {
+		case '"':
+			return b.String(), i + 1, nil
+		case '\n', '\r':
+			return "", 0, syntaxError(src, i, "unterminated string")
+		case '\\':
+			i++
+			if i >= len(src) {
+				return "", 0, syntaxError(src, i, "unterminated string")
+			}
+			switch src[i] {
+			case '"', '\\', '/':
+				b.WriteByte(src[i])
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if i+4 >= len(src) {
+					return "", 0, syntaxError(src, i, "invalid unicode escape")
+				}
+				n, err := strconv.ParseUint(src[i+1:i+5], 16, 16)
+				if err != nil {
+					return "", 0, syntaxError(src, i, "invalid unicode escape")
+				}
+				b.WriteRune(rune(n))
+				i += 4
+			default:
+				return "", 0, syntaxError(src, i, fmt.Sprintf("invalid escape \\%c", src[i]))
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, syntaxError(src, start, "unterminated string")
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// syntaxError returns an error located at offset pos of src
+func syntaxError(src string, pos int, message string) *gqlError {
+	return &gqlError{Message: "Syntax error: " + message, Locations: []gqlLocation{location(src, pos)}}
+}
+
+// location converts an offset into a line and column, both from 1
+func location(src string, pos int) gqlLocation {
+	before := src[:min(pos, len(src))]
+	line := strings.Count(before, "\n") + 1
+	column := utf8.RuneCountInString(before[strings.LastIndexByte(before, '\n')+1:]) + 1
+	return gqlLocation{Line: line, Column: column}
+}
+
+// --- Parser ---
+
+// gqlDocument is a parsed query document
+type gqlDocument struct {
+	operations []*gqlOperation
+	fragments  map[string]*gqlFragment
+}
+
+type gqlOperation struct {
+	kind       string
+	name       string
+	vars       []gqlVarDef
+	selections []*gqlSelection
+}
+
+type gqlVarDef struct {
+	name       string
+	typ        *gqlTypeRef
+	defaultVal *gqlValue
+	pos        int
+}
+
+type gqlFragment struct {
+	name       string
+	on         string
+	selections []*gqlSelection
+}
+
+type gqlSelectionKind int
+
+const (
+	selField gqlSelectionKind = iota
+	selSpread
+	selInline
+)
+
+// gqlSelection is a field, a fragment spread (name is the fragment) or an
+// inline fragment
+type gqlSelection struct {
+	kind       gqlSelectionKind
+	alias      string
+	name       string
+	on         string
+	args       []gqlArgument
+	directives []gqlDirective
+	selections []*gqlSelection
+	pos        int
+}
+
+// responseKey is the name a field appears under in the response
+func (s *gqlSelection) responseKey() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.name
+}
+
+type gqlArgument struct {
+	name  string
+	value *gqlValue
+	pos   int
+}
+
+type gqlDirective struct {
+	name string
+	args []gqlArgument
+	pos  int
+}
+
+type gqlValueKind int
+
+const (
+	valVariable gqlValueKind = iota
+	valInt
+	valFloat
+	valString
+	valBoolean
+	valNull
+	valEnum
+	valList
+	valObject
+)
+
+type gqlValue struct {
+	kind   gqlValueKind
+	raw    string
+	list   []*gqlValue
+	fields []gqlArgument
+	pos    int
+}
+
+// gqlTypeRef is a type such as String, [Post!] or ID!
+type gqlTypeRef struct {
+	name    string
+	elem    *gqlTypeRef
+	nonNull bool
+}
+
+func (t *gqlTypeRef) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+type gqlParser struct {
+	src    string
+	tokens []gqlToken
+	i      int
+}
+
+// parseGraphQL parses a query document
+func parseGraphQL(src string) (doc *gqlDocument, err error) {
+	tokens, err := lexGraphQL(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &gqlParser{src: src, tokens: tokens}
+
+	// The recursive descent below panics with a *gqlError to unwind
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(*gqlError)
+			if !ok {
+				panic(r)
+			}
+			doc, err = nil, e
+		}
+	}()
+
+	doc = &gqlDocument{fragments: make(map[string]*gqlFragment)}
+	for p.peek().kind != tokEOF {
+		switch tok := p.peek(); {
+		case tok.kind == tokPunct && tok.value == "{":
+			doc.operations = append(doc.operations, &gqlOperation{kind: "query", selections: p.selectionSet()})
+		case tok.kind == tokName && tok.value == "fragment":
+			p.next()
+			frag := &gqlFragment{name: p.name()}
+			if frag.name == "on" {
+				p.fail(tok.pos, "a fragment can't be called \"on\"")
+			}
+			p.keyword("on")
+			frag.on = p.name()
+			p.directives()
+			frag.selections = p.selectionSet()
+			if _, dup := doc.fragments[frag.name]; dup {
+				p.fail(tok.pos, fmt.Sprintf("there can be only one fragment named %q", frag.name))
+			}
+			doc.fragments[frag.name] = frag
+		case tok.kind == tokName && (tok.value == "query" || tok.value == "mutation" || tok.value == "subscription"):
+			p.next()
+			op := &gqlOperation{kind: tok.value}
+			if p.peek().kind == tokName {
+				op.name = p.name()
+			}
+			if p.skip("(") {
+				for !p.skip(")") {
+					pos := p.expect("$").pos
+					def := gqlVarDef{name: p.name(), pos: pos}
+					p.expect(":")
+					def.typ = p.typeRef()
+					if p.skip("=") {
+						def.defaultVal = p.value(true)
+					}
+					op.vars = append(op.vars, def)
+				}
+			}
+			p.directives()
+			op.selections = p.selectionSet()
+			doc.operations = append(doc.operations, op)
+		default:
+			p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, &gqlError{Message: "The document has no operations"}
+	}
+	return doc, nil
+}
+
+func (p *gqlParser) peek() gqlToken {
+	return p.tokens[p.i]
+}
+
+func (p *gqlParser) next() gqlToken {
+	tok := p.tokens[p.i]
+	if tok.kind != tokEOF {
+		p.i++
+	}
+	return tok
+}
+
+func (p *gqlParser) fail(pos int, message string) {
+	panic(syntaxError(p.src, pos, message))
+}
+
+func (p *gqlParser) unexpected() {
+	tok := p.peek()
+	if tok.kind == tokEOF {
+		p.fail(tok.pos, "unexpected end of document")
+	}
+	p.fail(tok.pos, fmt.Sprintf("unexpected %q", tok.value))
+}
+
+// skip consumes the punctuator punct if it is next
+func (p *gqlParser) skip(punct string) bool {
+	if tok := p.peek(); tok.kind == tokPunct && tok.value == punct {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *gqlParser) expect(punct string) gqlToken {
+	tok := p.peek()
+	if !p.skip(punct) {
+		p.unexpected()
+	}
+	return tok
+}
+
+func (p *gqlParser) keyword(word string) {
+	if tok := p.peek(); tok.kind != tokName || tok.value != word {
+		p.unexpected()
+	}
+	p.i++
+}
+
+func (p *gqlParser) name() string {
+	tok := p.peek()
+	if tok.kind != tokName {
+		p.unexpected()
+	}
+	p.i++
+	return tok.value
+}
+
+func (p *gqlParser) selectionSet() []*gqlSelection {
+	p.expect("{")
+	var selections []*gqlSelection
+	for !p.skip("}") {
+		selections = append(selections, p.selection())
+	}
+	if len(selections) == 0 {
+		p.fail(p.tokens[p.i-1].pos, "empty selection set")
+	}
+	return selections
+}
+
+func (p *gqlParser) selection() *gqlSelection {
+	pos := p.peek().pos
+	if p.skip("...") {
+		if tok := p.peek(); tok.kind == tokName && tok.value != "on" {
+			return &gqlSelection{kind: selSpread, name: p.name(), directives: p.directives(), pos: pos}
+		}
+		sel := &gqlSelection{kind: selInline, pos: pos}
+		if tok := p.peek(); tok.kind == tokName && tok.value == "on" {
+			p.next()
+			sel.on = p.name()
+		}
+		sel.directives = p.directives()
+		sel.selections = p.selectionSet()
+		return sel
+	}
+
+	sel := &gqlSelection{kind: selField, name: p.name(), pos: pos}
+	if p.skip(":") {
+		sel.alias, sel.name = sel.name, p.name()
+	}
+	sel.args = p.arguments(false)
+	sel.directives = p.directives()
+	if tok := p.peek(); tok.kind == tokPunct && tok.value == "{" {
+		sel.selections = p.selectionSet()
+	}
+	return sel
+}
+
+func (p *gqlParser) arguments(constant bool) []gqlArgument {
+	if !p.skip("(") {
+		return nil
+	}
+	var args []gqlArgument
+	for !p.skip(")") {
+		arg := gqlArgument{pos: p.peek().pos, name: p.name()}
+		p.expect(":")
+		arg.value = p.value(constant)
+		args = append(args, arg)
+	}
+	return args
+}
+
+func (p *gqlParser) directives() []gqlDirective {
+	var directives []gqlDirective
+	for p.peek().kind == tokPunct && p.peek().value == "@" {
+		pos := p.next().pos
+		directives = append(directives, gqlDirective{name: p.name(), args: p.arguments(false), pos: pos})
+	}
+	return directives
+}
+
+// value parses an input value; constant values can't use variables
+func (p *gqlParser) value(constant bool) *gqlValue {
+	tok := p.peek()
+	v := &gqlValue{raw: tok.value, pos: tok.pos}
+	switch {
+	case tok.kind == tokPunct && tok.value == "$" && !constant:
+		p.next()
+		v.kind, v.raw = valVariable, p.name()
+		return v
+	case tok.kind == tokPunct && tok.value == "[":
+		p.next()
+		v.kind = valList
+		for !p.skip("]") {
+			v.list = append(v.list, p.value(constant))
+		}
+		return v
+	case tok.kind == tokPunct && tok.value == "{":
+		p.next()
+		v.kind = valObject
+		for !p.skip("}") {
+			field := gqlArgument{pos: p.peek().pos, name: p.name()}
+			p.expect(":")
+			field.value = p.value(constant)
+			v.fields = append(v.fields, field)
+		}
+		return v
+	case tok.kind == tokInt:
+		v.kind = valInt
+	case tok.kind == tokFloat:
+		v.kind = valFloat
+	case tok.kind == tokString:
+		v.kind = valString
+	case tok.kind == tokName && (tok.value == "true" || tok.value == "false"):
+		v.kind = valBoolean
+	case tok.kind == tokName && tok.value == "null":
+		v.kind = valNull
+	case tok.kind == tokName:
+		v.kind = valEnum
+	default:
+		p.unexpected()
+	}
+	p.next()
+	return v
+}
+
+func (p *gqlParser) typeRef() *gqlTypeRef {
+	var t *gqlTypeRef
+	if p.skip("[") {
+		t = &gqlTypeRef{elem: p.typeRef()}
+		p.expect("]")
+	} else {
+		t = &gqlTypeRef{name: p.name()}
+	}
+	t.nonNull = p.skip("!")
+	return t
+}
+
+// mustParseType parses a type written in a schema definition
+func mustParseType(s string) *gqlTypeRef {
+	tokens, err := lexGraphQL(s)
+	if err != nil {
+		panic(err)
+	}
+	p := &gqlParser{src: s, tokens: tokens}
+	t := p.typeRef()
+	if p.peek().kind != tokEOF {
+		panic("invalid type " + s)
+	}
+	return t
+}
+
+// --- Schema ---
+
+// gqlResolver resolves a field for every parent object at one level of the
+// query at once, returning one value per parent. Lists are []any, objects
+// are whatever the field's type resolvers expect, and scalars are string,
+// int, float64 or bool.
+type gqlResolver func(req *gqlRequest, parents []any, args map[string]any) ([]any, error)
+
+type gqlSchema struct {
+	types map[string]*gqlObjectType
+	// order lists the types as they print in the SDL
+	order []*gqlObjectType
+}
+
+type gqlObjectType struct {
+	name   string
+	doc    string
+	fields []*gqlFieldDef
+}
+
+type gqlFieldDef struct {
+	name    string
+	doc     string
+	typ     string
+	args    []gqlArgDef
+	resolve gqlResolver
+	ref     *gqlTypeRef
+}
+
+type gqlArgDef struct {
+	name string
+	typ  string
+	// def is the default value, or nil for none
+	def any
+	ref *gqlTypeRef
+}
+
+// gqlScalars are the built-in scalar types the blog's schema uses
+var gqlScalars = map[string]bool{"ID": true, "String": true, "Int": true, "Float": true, "Boolean": true}
+
+// newGQLSchema builds a schema from object types, the first being Query
+func newGQLSchema(types ...*gqlObjectType) *gqlSchema {
+	s := &gqlSchema{types: make(map[string]*gqlObjectType), order: types}
+	for _, t := range types {
+		s.types[t.name] = t
+		for _, f := range t.fields {
+			f.ref = mustParseType(f.typ)
+			for i := range f.args {
+				f.args[i].ref = mustParseType(f.args[i].typ)
+			}
+		}
+	}
+	return s
+}
+
+func (t *gqlObjectType) field(name string) *gqlFieldDef {
+	for _, f := range t.fields {
+		if f.name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// SDL prints the schema in the GraphQL schema definition language
+func (s *gqlSchema) SDL() string {
+	var b strings.Builder
+	for i, t := range s.order {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if t.doc != "" {
+			fmt.Fprintf(&b, "%q\n", t.doc)
+		}
+		fmt.Fprintf(&b, "type %s {\n", t.name)
+		for _, f := range t.fields {
+			if f.doc != "" {
+				fmt.Fprintf(&b, "  %q\n", f.doc)
+			}
+			b.WriteString("  " + f.name)
+			if len(f.args) > 0 {
+				args := make([]string, len(f.args))
+				for j, a := range f.args {
+					args[j] = a.name + ": " + a.typ
+					if a.def != nil {
+						def, _ := json.Marshal(a.def)
+						args[j] += " = " + string(def)
+					}
+				}
+				b.WriteString("(" + strings.Join(args, ", ") + ")")
+			}
+			b.WriteString(": " + f.typ + "\n")
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// --- Execution ---
+
+// gqlRequest is one GraphQL request being executed
+type gqlRequest struct {
+	h      *Handler
+	r      *http.Request
+	schema *gqlSchema
+	src    string
+	doc    *gqlDocument
+	vars   map[string]any
+	// declared holds every variable the operation defines, even those
+	// left out of the request
+	declared map[string]bool
+}
+
+// gqlObject is a response object, keeping fields in query order
+type gqlObject []gqlPair
+
+type gqlPair struct {
+	key   string
+	value any
+}
+
+func (o gqlObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, pair := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(pair.key)
+		b.Write(key)
+		b.WriteByte(':')
+		value, err := json.Marshal(pair.value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// executeGraphQL parses, validates and runs a query against schema
+func executeGraphQL(h *Handler, r *http.Request, schema *gqlSchema, query, operationName string, variables map[string]any) (any, error) {
+	if len(query) > maxGraphQLQuery {
+		return nil, &gqlError{Message: fmt.Sprintf("The query is longer than %d bytes", maxGraphQLQuery)}
+	}
+	doc, err := parseGraphQL(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var op *gqlOperation
+	for _, candidate := range doc.operations {
+		if operationName == "" && len(doc.operations) == 1 || candidate.name == operationName {
+			op = candidate
+		}
+	}
+	switch {
+	case op == nil && operationName == "":
+		return nil, &gqlError{Message: "The document has several operations, so operationName is required"}
+	case op == nil:
+		return nil, &gqlError{Message: fmt.Sprintf("Unknown operation %q", operationName)}
+	case op.kind != "query":
+		return nil, &gqlError{Message: fmt.Sprintf("Only queries are supported, not %ss", op.kind)}
+	}
+
+	req := &gqlRequest{h: h, r: r, schema: schema, src: query, doc: doc, declared: make(map[string]bool)}
+	if req.vars, err = req.coerceVariables(op.vars, variables); err != nil {
+		return nil, err
+	}
+
+	results, err := req.executeObjects(schema.order[0], []any{struct{}{}}, op.selections, nil, 1)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+func (req *gqlRequest) errorAt(pos int, path []any, format string, args ...any) *gqlError {
+	return &gqlError{
+		Message:   fmt.Sprintf(format, args...),
+		Locations: []gqlLocation{location(req.src, pos)},
+		Path:      path,
+	}
+}
+
+// coerceVariables checks the request's variables against the operation's
+// definitions, filling in defaults
+func (req *gqlRequest) coerceVariables(defs []gqlVarDef, values map[string]any) (map[string]any, error) {
+	vars := make(map[string]any)
+	for _, def := range defs {
+		if !isInputType(def.typ) {
+			return nil, req.errorAt(def.pos, nil, "Variable $%s has type %s, which isn't an input type", def.name, def.typ)
+		}
+		req.declared[def.name] = true
+		value, given := values[def.name]
+		if !given && def.defaultVal != nil {
+			v, err := req.literal(def.defaultVal, def.typ, nil)
+			if err != nil {
+				return nil, err
+			}
+			vars[def.name] = v
+			continue
+		}
+		if !given && !def.typ.nonNull {
+			continue
+		}
+		v, err := coerceJSON(value, def.typ)
+		if err != nil {
+			return nil, req.errorAt(def.pos, nil, "Variable $%s: %v", def.name, err)
+		}
+		vars[def.name] = v
+	}
+	return vars, nil
+}
+
+func isInputType(t *gqlTypeRef) bool {
+	if t.elem != nil {
+		return isInputType(t.elem)
+	}
+	return gqlScalars[t.name]
+}
+
+// coerceJSON converts a JSON variable value to the input type t
+func coerceJSON(value any, t *gqlTypeRef) (any, error) {
+	if value == nil {
+		if t.nonNull {
+			return nil, fmt.Errorf("expected a value of type %s", t)
+		}
+		return nil, nil
+	}
+	if t.elem != nil {
+		items, ok := value.([]any)
+		if !ok {
+			items = []any{value}
+		}
+		list := make([]any, len(items))
+		for i, item := range items {
+			v, err := coerceJSON(item, t.elem)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = v
+		}
+		return list, nil
+	}
+
+	switch t.name {
+	case "String":
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case "ID":
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case float64:
+			if v == math.Trunc(v) {
+				return strconv.FormatFloat(v, 'f', -1, 64), nil
+			}
+		}
+	case "Int":
+		if f, ok := value.(float64); ok && f == math.Trunc(f) && math.Abs(f) <= math.MaxInt32 {
+			return int(f), nil
+		}
+	case "Float":
+		if f, ok := value.(float64); ok {
+			return f, nil
+		}
+	case "Boolean":
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("expected a value of type %s", t)
+}
+
+// literal converts a value written in the query to the input type t
+func (req *gqlRequest) literal(v *gqlValue, t *gqlTypeRef, path []any) (any, error) {
+	if v.kind == valVariable {
+		if !req.declared[v.raw] {
+			return nil, req.errorAt(v.pos, path, "Variable $%s is not defined", v.raw)
+		}
+		value := req.vars[v.raw]
+		if value == nil && t.nonNull {
+			return nil, req.errorAt(v.pos, path, "Variable $%s must not be null here", v.raw)
+		}
+		return value, nil
+	}
+	if v.kind == valNull {
+		if t.nonNull {
+			return nil, req.errorAt(v.pos, path, "Expected a value of type %s, found null", t)
+		}
+		return nil, nil
+	}
+	if t.elem != nil {
+		items := v.list
+		if v.kind != valList {
+			items = []*gqlValue{v}
+		}
+		list := make([]any, len(items))
+		for i, item := range items {
+			value, err := req.literal(item, t.elem, path)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = value
+		}
+		return list, nil
+	}
+
+	switch {
+	case t.name == "String" && v.kind == valString:
+		return v.raw, nil
+	case t.name == "ID" && (v.kind == valString || v.kind == valInt):
+		return v.raw, nil
+	case t.name == "Int" && v.kind == valInt:
+		if n, err := strconv.ParseInt(v.raw, 10, 32); err == nil {
+			return int(n), nil
+		}
+	case t.name == "Float" && (v.kind == valFloat || v.kind == valInt):
+		if f, err := strconv.ParseFloat(v.raw, 64); err == nil {
+			return f, nil
+		}
+	case t.name == "Boolean" && v.kind == valBoolean:
+		return v.raw == "true", nil
+	}
+	return nil, req.errorAt(v.pos, path, "Expected a value of type %s, found %s", t, v.raw)
+}
+
+// arguments checks a field's arguments against its definition, filling in
+// defaults
+func (req *gqlRequest) arguments(def *gqlFieldDef, sel *gqlSelection, path []any) (map[string]any, error) {
+	args := make(map[string]any)
+	for _, arg := range sel.args {
+		if !hasArg(def.args, arg.name) {
+			return nil, req.errorAt(arg.pos, path, "Unknown argument %q on field %q", arg.name, def.name)
+		}
+	}
+	for _, argDef := range def.args {
+		var given *gqlArgument
+		for i := range sel.args {
+			if sel.args[i].name == argDef.name {
+				given = &sel.args[i]
+			}
+		}
+		// An argument set to a variable left out of the request falls back
+		// to its default
+		if given != nil && given.value.kind == valVariable && req.declared[given.value.raw] {
+			if _, ok := req.vars[given.value.raw]; !ok && argDef.def != nil {
+				given = nil
+			}
+		}
+		if given == nil {
+			if argDef.def != nil {
+				args[argDef.name] = argDef.def
+			} else if argDef.ref.nonNull {
+				return nil, req.errorAt(sel.pos, path, "Field %q needs argument %q of type %s", def.name, argDef.name, argDef.typ)
+			}
+			continue
+		}
+		value, err := req.literal(given.value, argDef.ref, path)
+		if err != nil {
+			return nil, err
+		}
+		args[argDef.name] = value
+	}
+	return args, nil
+}
+
+func hasArg(defs []gqlArgDef, name string) bool {
+	for _, def := range defs {
+		if def.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// collectedField is one response key with every selection of it, which
+// GraphQL merges into one field
+type collectedField struct {
+	key        string
+	selections []*gqlSelection
+}
+
+// collectFields flattens fragments and applies @skip and @include,
+// grouping fields by response key in query order
+func (req *gqlRequest) collectFields(t *gqlObjectType, selections []*gqlSelection, fields []collectedField, visited map[string]bool) ([]collectedField, error) {
+	for _, sel := range selections {
+		include, err := req.included(sel)
+		if err != nil {
+			return nil, err
+		}
+		if !include {
+			continue
+		}
+
+		switch sel.kind {
+		case selField:
+			key := sel.responseKey()
+			merged := false
+			for i := range fields {
+				if fields[i].key == key {
+					if fields[i].selections[0].name != sel.name {
+						return nil, req.errorAt(sel.pos, nil, "Fields %q and %q both use the response key %q", fields[i].selections[0].name, sel.name, key)
+					}
+					fields[i].selections = append(fields[i].selections, sel)
+					merged = true
+				}
+			}
+			if !merged {
+				fields = append(fields, collectedField{key: key, selections: []*gqlSelection{sel}})
+			}
+		case selSpread:
+			frag, ok := req.doc.fragments[sel.name]
+			if !ok {
+				return nil, req.errorAt(sel.pos, nil, "Unknown fragment %q", sel.name)
+			}
+			if visited[sel.name] {
+				continue
+			}
+			if frag.on != t.name {
+				return nil, req.errorAt(sel.pos, nil, "Fragment %q is on %s and can't be spread in %s", sel.name, frag.on, t.name)
+			}
+			visited[sel.name] = true
+			if fields, err = req.collectFields(t, frag.selections, fields, visited); err != nil {
+				return nil, err
+			}
+			delete(visited, sel.name)
+		case selInline:
+			if sel.on != "" && sel.on != t.name {
+				return nil, req.errorAt(sel.pos, nil, "Inline fragment on %s can't be used in %s", sel.on, t.name)
+			}
+			if fields, err = req.collectFields(t, sel.selections, fields, visited); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return fields, nil
+}
+
+// included evaluates a selection's @skip and @include directives
+func (req *gqlRequest) included(sel *gqlSelection) (bool, error) {
+	for _, d := range sel.directives {
+		if d.name != "skip" && d.name != "include" {
+			return false, req.errorAt(d.pos, nil, "Unknown directive @%s", d.name)
+		}
+		if len(d.args) != 1 || d.args[0].name != "if" {
+			return false, req.errorAt(d.pos, nil, "@%s needs exactly one argument, if", d.name)
+		}
+		value, err := req.literal(d.args[0].value, &gqlTypeRef{name: "Boolean", nonNull: true}, nil)
+		if err != nil {
+			return false, err
+		}
+		if value.(bool) == (d.name == "skip") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// executeObjects resolves selections on every parent of type t together,
+// returning one response object per parent
+func (req *gqlRequest) executeObjects(t *gqlObjectType, parents []any, selections []*gqlSelection, path []any, depth int) ([]gqlObject, error) {
+	if depth > maxGraphQLDepth {
+		return nil, req.errorAt(selections[0].pos, path, "The query nests more than %d levels deep", maxGraphQLDepth)
+	}
+	fields, err := req.collectFields(t, selections, nil, make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]gqlObject, len(parents))
+	for _, field := range fields {
+		sel := field.selections[0]
+		fieldPath := append(path[:len(path):len(path)], field.key)
+
+		if sel.name == "__typename" {
+			for i := range results {
+				results[i] = append(results[i], gqlPair{field.key, t.name})
+			}
+			continue
+		}
+		def := t.field(sel.name)
+		if def == nil {
+			return nil, req.errorAt(sel.pos, fieldPath, "Cannot query field %q on type %s", sel.name, t.name)
+		}
+		args, err := req.arguments(def, sel, fieldPath)
+		if err != nil {
+			return nil, err
+		}
+
+		var subselections []*gqlSelection
+		for _, s := range field.selections {
+			subselections = append(subselections, s.selections...)
+		}
+
+		// With no parents there is nothing to resolve, but the rest of the
+		// query is still checked, so mistakes don't hide behind empty lists
+		var values []any
+		if len(parents) > 0 {
+			if values, err = def.resolve(req, parents, args); err != nil {
+				return nil, req.errorAt(sel.pos, fieldPath, "%v", err)
+			}
+		}
+		completed, err := req.complete(def.ref, values, subselections, sel, fieldPath, depth)
+		if err != nil {
+			return nil, err
+		}
+		for i := range results {
+			results[i] = append(results[i], gqlPair{field.key, completed[i]})
+		}
+	}
+	return results, nil
+}
+
+// complete turns resolved values of type t into response values, resolving
+// the subselections of objects in one batch across all of them
+func (req *gqlRequest) complete(t *gqlTypeRef, values []any, selections []*gqlSelection, sel *gqlSelection, path []any, depth int) ([]any, error) {
+	for _, v := range values {
+		if t.nonNull && v == nil {
+			return nil, req.errorAt(sel.pos, path, "Cannot return null for non-null field of type %s", t)
+		}
+	}
+
+	if t.elem != nil {
+		// Flatten every list so their items complete together
+		var items []any
+		lengths := make([]int, len(values))
+		for i, v := range values {
+			if v == nil {
+				lengths[i] = -1
+				continue
+			}
+			list := v.([]any)
+			lengths[i] = len(list)
+			items = append(items, list...)
+		}
+		completed, err := req.complete(t.elem, items, selections, sel, path, depth)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]any, len(values))
+		for i, n := range lengths {
+			if n >= 0 {
+				out[i], completed = completed[:n:n], completed[n:]
+			}
+		}
+		return out, nil
+	}
+
+	if gqlScalars[t.name] {
+		if len(selections) > 0 {
+			return nil, req.errorAt(sel.pos, path, "Field %q of type %s can't have a selection", sel.name, t)
+		}
+		return values, nil
+	}
+
+	objType := req.schema.types[t.name]
+	if len(selections) == 0 {
+		return nil, req.errorAt(sel.pos, path, "Field %q of type %s must have a selection of subfields", sel.name, t)
+	}
+	var parents []any
+	for _, v := range values {
+		if v != nil {
+			parents = append(parents, v)
+		}
+	}
+	out := make([]any, len(values))
+	objects, err := req.executeObjects(objType, parents, selections, path, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range values {
+		if v != nil {
+			out[i], objects = objects[0], objects[1:]
+		}
+	}
+	return out, nil
+}
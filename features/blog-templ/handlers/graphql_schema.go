@@ -0,0 +1,422 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+// maxGraphQLFirst caps how many items a list field returns
+const maxGraphQLFirst = 50
+
+// gqlTag is a tag and how many published posts carry it
+type gqlTag struct {
+	name  string
+	count int
+}
+
+// gqlPostPage is a page of posts with the cursor of the next one
+type gqlPostPage struct {
+	posts []models.Post
+	next  string
+}
+
+// blogSchema is the GraphQL schema served at /graphql. Only published
+// posts are visible through it.
+var blogSchema = newGQLSchema(
+	&gqlObjectType{name: "Query", fields: []*gqlFieldDef{
+		{
+			name: "posts", typ: "PostPage!", doc: "Published posts, newest first, a page at a time",
+			args:    []gqlArgDef{{name: "first", typ: "Int", def: 10}, {name: "after", typ: "String"}},
+			resolve: resolvePosts,
+		},
+		{
+			name: "post", typ: "Post", doc: "A published post by ID",
+			args:    []gqlArgDef{{name: "id", typ: "ID!"}},
+			resolve: resolvePost,
+		},
+		{
+			name: "search", typ: "[Post!]!", doc: "Published posts matching a query, best match first",
+			args: []gqlArgDef{
+				{name: "query", typ: "String!"},
+				{name: "tag", typ: "String"},
+				{name: "author", typ: "String"},
+				{name: "category", typ: "String"},
+				{name: "first", typ: "Int", def: 10},
+			},
+			resolve: resolveSearch,
+		},
+		{name: "tags", typ: "[Tag!]!", doc: "Every tag on a published post, most used first", resolve: resolveTags},
+		{
+			name: "tag", typ: "Tag", doc: "A tag by name, ignoring case",
+			args:    []gqlArgDef{{name: "name", typ: "String!"}},
+			resolve: resolveTag,
+		},
+		{name: "authors", typ: "[Author!]!", doc: "Authors with a profile", resolve: resolveAuthors},
+		{
+			name: "author", typ: "Author", doc: "An author by username",
+			args:    []gqlArgDef{{name: "username", typ: "String!"}},
+			resolve: resolveAuthor,
+		},
+	}},
+	&gqlObjectType{name: "PostPage", doc: "A page of posts", fields: []*gqlFieldDef{
+		{name: "posts", typ: "[Post!]!", resolve: pageField(func(p gqlPostPage) any { return postList(p.posts) })},
+		{name: "nextCursor", typ: "String", doc: "Pass as after for the next page; null on the last page", resolve: pageField(func(p gqlPostPage) any { return nullIfEmpty(p.next) })},
+	}},
+	&gqlObjectType{name: "Post", doc: "A blog post", fields: []*gqlFieldDef{
+		{name: "id", typ: "ID!", resolve: postField(func(_ *gqlRequest, p models.Post) any { return strconv.Itoa(p.ID) })},
+		{name: "title", typ: "String!", resolve: postField(func(_ *gqlRequest, p models.Post) any { return p.Title })},
+		{name: "slug", typ: "String", resolve: postField(func(_ *gqlRequest, p models.Post) any { return nullIfEmpty(p.Slug) })},
+		{name: "content", typ: "String!", doc: "Markdown source", resolve: postField(func(_ *gqlRequest, p models.Post) any { return p.Content })},
+		{name: "summary", typ: "String!", resolve: postField(func(_ *gqlRequest, p models.Post) any { return summarize(p.Content, summaryLength) })},
+		{name: "url", typ: "String!", resolve: postField(func(req *gqlRequest, p models.Post) any { return postURL(req.h.siteURL(req.r), p) })},
+		{name: "tags", typ: "[String!]!", resolve: postField(func(_ *gqlRequest, p models.Post) any { return stringList(p.Tags) })},
+		{name: "category", typ: "String", doc: "Category slug", resolve: postField(func(_ *gqlRequest, p models.Post) any { return nullIfEmpty(p.Category) })},
+		{name: "coverImageUrl", typ: "String", resolve: postField(func(_ *gqlRequest, p models.Post) any { return nullIfEmpty(p.CoverImageURL) })},
+		{name: "publishedAt", typ: "String!", doc: "RFC 3339 timestamp", resolve: postField(func(_ *gqlRequest, p models.Post) any { return p.PublishedAt.Format(time.RFC3339) })},
+		{name: "views", typ: "Int!", resolve: postField(func(_ *gqlRequest, p models.Post) any { return p.Views })},
+		{name: "author", typ: "Author!", resolve: resolvePostAuthors},
+	}},
+	&gqlObjectType{name: "Tag", fields: []*gqlFieldDef{
+		{name: "name", typ: "String!", resolve: tagField(func(_ *gqlRequest, t gqlTag) any { return t.name })},
+		{name: "count", typ: "Int!", doc: "Published posts with the tag", resolve: tagField(func(_ *gqlRequest, t gqlTag) any { return t.count })},
+		{name: "url", typ: "String!", resolve: tagField(func(req *gqlRequest, t gqlTag) any { return req.h.siteURL(req.r) + "/tags/" + url.PathEscape(t.name) })},
+		{
+			name: "posts", typ: "[Post!]!", doc: "Newest first",
+			args:    []gqlArgDef{{name: "first", typ: "Int", def: 10}},
+			resolve: resolveTagPosts,
+		},
+	}},
+	&gqlObjectType{name: "Author", fields: []*gqlFieldDef{
+		{name: "username", typ: "String!", resolve: authorField(func(_ *gqlRequest, a models.Author) any { return a.Username })},
+		{name: "name", typ: "String!", resolve: authorField(func(_ *gqlRequest, a models.Author) any { return a.Name })},
+		{name: "bio", typ: "String", resolve: authorField(func(_ *gqlRequest, a models.Author) any { return nullIfEmpty(a.Bio) })},
+		{name: "avatarUrl", typ: "String", resolve: authorField(func(_ *gqlRequest, a models.Author) any { return nullIfEmpty(a.AvatarURL) })},
+		{name: "url", typ: "String!", resolve: authorField(func(req *gqlRequest, a models.Author) any { return req.h.siteURL(req.r) + a.URL() })},
+		{
+			name: "posts", typ: "[Post!]!", doc: "Newest first",
+			args:    []gqlArgDef{{name: "first", typ: "Int", def: 10}},
+			resolve: resolveAuthorPosts,
+		},
+	}},
+)
+
+// GraphQL runs a GraphQL query, sent as JSON in a POST body or as query
+// parameters of a GET. The schema is served by GraphQLSchema.
+func (h *Handler) GraphQL(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Query         string         `json:"query"`
+		OperationName string         `json:"operationName"`
+		Variables     map[string]any `json:"variables"`
+	}
+	if r.Method == http.MethodPost {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType != "application/json" {
+			writeJSONError(w, http.StatusUnsupportedMediaType, "request body must be application/json")
+			return
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIBodyBytes)).Decode(&params); err != nil {
+			writeGraphQLErrors(w, http.StatusBadRequest, &gqlError{Message: "Invalid JSON body: " + err.Error()})
+			return
+		}
+	} else {
+		query := r.URL.Query()
+		params.Query = query.Get("query")
+		params.OperationName = query.Get("operationName")
+		if vars := query.Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &params.Variables); err != nil {
+				writeGraphQLErrors(w, http.StatusBadRequest, &gqlError{Message: "Invalid variables: " + err.Error()})
+				return
+			}
+		}
+	}
+	if strings.TrimSpace(params.Query) == "" {
+		writeGraphQLErrors(w, http.StatusBadRequest, &gqlError{Message: "Missing query"})
+		return
+	}
+
+	data, err := executeGraphQL(h, r, blogSchema, params.Query, params.OperationName, params.Variables)
+	if err != nil {
+		var gqlErr *gqlError
+		if !errors.As(err, &gqlErr) {
+			gqlErr = &gqlError{Message: err.Error()}
+		}
+		writeGraphQLErrors(w, http.StatusBadRequest, gqlErr)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": data})
+}
+
+// GraphQLSchema serves the schema in the GraphQL schema definition language
+func (h *Handler) GraphQLSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, blogSchema.SDL())
+}
+
+// writeGraphQLErrors writes a GraphQL response with errors and no data
+func writeGraphQLErrors(w http.ResponseWriter, status int, errs ...*gqlError) {
+	writeJSON(w, status, map[string]any{"errors": errs})
+}
+
+// --- Query resolvers, called with the root as the only parent ---
+
+func resolvePosts(req *gqlRequest, _ []any, args map[string]any) ([]any, error) {
+	first, err := firstArg(args)
+	if err != nil {
+		return nil, err
+	}
+	after, _ := args["after"].(string)
+	posts, next, err := req.h.store.After(after, first)
+	if err != nil {
+		return nil, err
+	}
+	return []any{gqlPostPage{posts: posts, next: next}}, nil
+}
+
+func resolvePost(req *gqlRequest, _ []any, args map[string]any) ([]any, error) {
+	id, err := strconv.Atoi(args["id"].(string))
+	if err != nil {
+		return []any{nil}, nil
+	}
+	post, err := req.h.store.GetByID(id)
+	if err != nil || !post.Published {
+		return []any{nil}, nil
+	}
+	return []any{post}, nil
+}
+
+func resolveSearch(req *gqlRequest, _ []any, args map[string]any) ([]any, error) {
+	first, err := firstArg(args)
+	if err != nil {
+		return nil, err
+	}
+	tag, _ := args["tag"].(string)
+	author, _ := args["author"].(string)
+	category, _ := args["category"].(string)
+	filter, _ := models.ParseSearchFilter(author, tag, category, "", "")
+
+	query := args["query"].(string)
+	posts := models.FilterPosts(models.PublishedOnly(req.h.store.Search(query)), filter)
+	if strings.TrimSpace(query) == "" {
+		posts = models.SortPosts(posts, models.SortNewest)
+	}
+	return []any{postList(posts[:min(first, len(posts))])}, nil
+}
+
+func resolveTags(req *gqlRequest, _ []any, _ map[string]any) ([]any, error) {
+	var tags []any
+	for _, tag := range countTags(models.PublishedOnly(req.h.store.GetAll())) {
+		tags = append(tags, tag)
+	}
+	return []any{tags}, nil
+}
+
+func resolveTag(req *gqlRequest, _ []any, args map[string]any) ([]any, error) {
+	name := args["name"].(string)
+	for _, tag := range countTags(models.PublishedOnly(req.h.store.GetAll())) {
+		if strings.EqualFold(tag.name, name) {
+			return []any{tag}, nil
+		}
+	}
+	return []any{nil}, nil
+}
+
+func resolveAuthors(req *gqlRequest, _ []any, _ map[string]any) ([]any, error) {
+	var authors []any
+	for _, author := range req.h.authors.All() {
+		authors = append(authors, author)
+	}
+	return []any{authors}, nil
+}
+
+func resolveAuthor(req *gqlRequest, _ []any, args map[string]any) ([]any, error) {
+	username := args["username"].(string)
+	if author, err := req.h.authors.Get(username); err == nil {
+		return []any{author}, nil
+	}
+	// Like author pages, fall back to the byline of the author's posts
+	if posts := models.ByAuthor(models.PublishedOnly(req.h.store.GetAll()), username); len(posts) > 0 {
+		return []any{models.Author{Username: posts[0].AuthorUsername, Name: posts[0].Author}}, nil
+	}
+	return []any{nil}, nil
+}
+
+// --- Batched resolvers, called once for every parent at a level ---
+
+// resolvePostAuthors looks up the authors of every post at once, reading
+// the author store a single time however many posts there are
+func resolvePostAuthors(req *gqlRequest, parents []any, _ map[string]any) ([]any, error) {
+	profiles := make(map[string]models.Author)
+	for _, author := range req.h.authors.All() {
+		profiles[author.Username] = author
+	}
+
+	authors := make([]any, len(parents))
+	for i, parent := range parents {
+		post := parent.(models.Post)
+		author, ok := profiles[post.AuthorUsername]
+		if !ok || post.AuthorUsername == "" {
+			author = models.Author{Username: post.AuthorUsername, Name: post.Author}
+		}
+		authors[i] = author
+	}
+	return authors, nil
+}
+
+// resolveTagPosts lists the posts of every tag from one read of the store
+func resolveTagPosts(req *gqlRequest, parents []any, args map[string]any) ([]any, error) {
+	first, err := firstArg(args)
+	if err != nil {
+		return nil, err
+	}
+	byTag := make(map[string][]models.Post)
+	for _, post := range models.SortPosts(models.PublishedOnly(req.h.store.GetAll()), models.SortNewest) {
+		for _, tag := range post.Tags {
+			byTag[strings.ToLower(tag)] = append(byTag[strings.ToLower(tag)], post)
+		}
+	}
+
+	lists := make([]any, len(parents))
+	for i, parent := range parents {
+		posts := byTag[strings.ToLower(parent.(gqlTag).name)]
+		lists[i] = postList(posts[:min(first, len(posts))])
+	}
+	return lists, nil
+}
+
+// resolveAuthorPosts lists the posts of every author from one read of the
+// store
+func resolveAuthorPosts(req *gqlRequest, parents []any, args map[string]any) ([]any, error) {
+	first, err := firstArg(args)
+	if err != nil {
+		return nil, err
+	}
+	byAuthor := make(map[string][]models.Post)
+	for _, post := range models.SortPosts(models.PublishedOnly(req.h.store.GetAll()), models.SortNewest) {
+		byAuthor[post.AuthorUsername] = append(byAuthor[post.AuthorUsername], post)
+	}
+
+	lists := make([]any, len(parents))
+	for i, parent := range parents {
+		posts := byAuthor[parent.(models.Author).Username]
+		lists[i] = postList(posts[:min(first, len(posts))])
+	}
+	return lists, nil
+}
+
+// --- Helpers ---
+
+// postField resolves a field computed from each post on its own
+func postField(fn func(*gqlRequest, models.Post) any) gqlResolver {
+	return func(req *gqlRequest, parents []any, _ map[string]any) ([]any, error) {
+		values := make([]any, len(parents))
+		for i, parent := range parents {
+			values[i] = fn(req, parent.(models.Post))
+		}
+		return values, nil
+	}
+}
+
+// tagField resolves a field computed from each tag on its own
+func tagField(fn func(*gqlRequest, gqlTag) any) gqlResolver {
+	return func(req *gqlRequest, parents []any, _ map[string]any) ([]any, error) {
+		values := make([]any, len(parents))
+		for i, parent := range parents {
+			values[i] = fn(req, parent.(gqlTag))
+		}
+		return values, nil
+	}
+}
+
+// authorField resolves a field computed from each author on its own
+func authorField(fn func(*gqlRequest, models.Author) any) gqlResolver {
+	return func(req *gqlRequest, parents []any, _ map[string]any) ([]any, error) {
+		values := make([]any, len(parents))
+		for i, parent := range parents {
+			values[i] = fn(req, parent.(models.Author))
+		}
+		return values, nil
+	}
+}
+
+// pageField resolves a field of each post page
+func pageField(fn func(gqlPostPage) any) gqlResolver {
+	return func(_ *gqlRequest, parents []any, _ map[string]any) ([]any, error) {
+		values := make([]any, len(parents))
+		for i, parent := range parents {
+			values[i] = fn(parent.(gqlPostPage))
+		}
+		return values, nil
+	}
+}
+
+// countTags counts the posts carrying each tag, treating tags that differ
+// only in case as one and keeping the first spelling seen. The most used
+// tags come first.
+func countTags(posts []models.Post) []gqlTag {
+	var tags []gqlTag
+	index := make(map[string]int)
+	for _, post := range posts {
+		for _, name := range post.Tags {
+			key := strings.ToLower(name)
+			if i, ok := index[key]; ok {
+				tags[i].count++
+				continue
+			}
+			index[key] = len(tags)
+			tags = append(tags, gqlTag{name: name, count: 1})
+		}
+	}
+	slices.SortFunc(tags, func(a, b gqlTag) int {
+		if a.count != b.count {
+			return b.count - a.count
+		}
+		return strings.Compare(strings.ToLower(a.name), strings.ToLower(b.name))
+	})
+	return tags
+}
+
+// firstArg reads a list's first argument, capped at maxGraphQLFirst
+func firstArg(args map[string]any) (int, error) {
+	first, ok := args["first"].(int)
+	if !ok {
+		return 10, nil
+	}
+	if first < 0 {
+		return 0, errors.New("first must not be negative")
+	}
+	return min(first, maxGraphQLFirst), nil
+}
+
+func postList(posts []models.Post) []any {
+	list := make([]any, len(posts))
+	for i, post := range posts {
+		list[i] = post
+	}
+	return list
+}
+
+func stringList(values []string) []any {
+	list := make([]any, len(values))
+	for i, v := range values {
+		list[i] = v
+	}
+	return list
+}
+
+// nullIfEmpty maps "" to null for optional string fields
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+// countingStore counts how often the whole store is read
+type countingStore struct {
+	models.Store
+	getAll int
+}
+
+func (s *countingStore) GetAll() []models.Post {
+	s.getAll++
+	return s.Store.GetAll()
+}
+
+// graphQLResponse is the body of a GraphQL response
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []gqlError      `json:"errors"`
+}
+
+// postGraphQL sends query to handler as a JSON POST and decodes the answer
+func postGraphQL(t *testing.T, handler *Handler, query string, variables map[string]any) (int, graphQLResponse) {
+	t.Helper()
+
+	body, _ := json.Marshal(map[string]any{"query": query, "variables": variables})
+	req := httptest.NewRequest("POST", "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.GraphQL(w, req)
+
+	var resp graphQLResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	return w.Code, resp
+}
+
+func TestGraphQLPosts(t *testing.T) {
+	handler := New(models.NewStore())
+
+	status, resp := postGraphQL(t, handler, `
+		query Recent($first: Int) {
+			posts(first: $first) {
+				posts { id title author { username name } }
+				nextCursor
+			}
+		}`, map[string]any{"first": 2})
+	if status != http.StatusOK || len(resp.Errors) > 0 {
+		t.Fatalf("Expected 200 without errors, got %d %+v", status, resp.Errors)
+	}
+
+	// Fields come back in the order the query asked for them
+	want := `{"posts":{"posts":[` +
+		`{"id":"4","title":"Type-Safe HTML Templates","author":{"username":"john","name":"John Smith"}},` +
+		`{"id":"3","title":"Why Go is Great for Web Development","author":{"username":"jane","name":"Jane Doe"}}],`
+	if !strings.HasPrefix(string(resp.Data), want) {
+		t.Errorf("Expected the newest posts first, got %s", resp.Data)
+	}
+
+	var data struct {
+		Posts struct{ NextCursor string }
+	}
+	json.Unmarshal(resp.Data, &data)
+	_, resp = postGraphQL(t, handler, `query($after: String) { posts(first: 10, after: $after) { posts { id } nextCursor } }`,
+		map[string]any{"after": data.Posts.NextCursor})
+	if got := string(resp.Data); got != `{"posts":{"posts":[{"id":"2"},{"id":"1"}],"nextCursor":null}}` {
+		t.Errorf("Expected the last page, got %s", got)
+	}
+}
+
+func TestGraphQLHidesDrafts(t *testing.T) {
+	store := models.NewStore()
+	id := addDraft(t, store)
+	handler := New(store)
+
+	_, resp := postGraphQL(t, handler, `query($id: ID!) { post(id: $id) { title } }`, map[string]any{"id": id})
+	if got := string(resp.Data); got != `{"post":null}` {
+		t.Errorf("Expected drafts to be hidden, got %s", got)
+	}
+	_, resp = postGraphQL(t, handler, `{ search(query: "Secret") { id } }`, nil)
+	if got := string(resp.Data); got != `{"search":[]}` {
+		t.Errorf("Expected drafts to be left out of search, got %s", got)
+	}
+}
+
+func TestGraphQLFragmentsAndAliases(t *testing.T) {
+	handler := New(models.NewStore())
+
+	_, resp := postGraphQL(t, handler, `
+		query($withViews: Boolean!) {
+			first: post(id: "1") { ...card }
+			second: post(id: 2) { ... on Post { heading: title } views @include(if: $withViews) }
+		}
+		fragment card on Post { title tags views @skip(if: $withViews) }`, map[string]any{"withViews": false})
+	want := `{"first":{"title":"Getting Started with Templ and HTMX","tags":["templ","htmx","go","tutorial"],"views":0},` +
+		`"second":{"heading":"Building Real-time Search with HTMX"}}`
+	if len(resp.Errors) > 0 || string(resp.Data) != want {
+		t.Errorf("Expected %s, got %s %+v", want, resp.Data, resp.Errors)
+	}
+}
+
+func TestGraphQLTagsAndAuthors(t *testing.T) {
+	handler := New(models.NewStore())
+
+	_, resp := postGraphQL(t, handler, `{
+		tags { name count }
+		tag(name: "HTMX") { name posts(first: 1) { id } }
+		author(username: "jane") { name posts { id } }
+	}`, nil)
+	var data struct {
+		Tags []struct {
+			Name  string
+			Count int
+		}
+		Tag struct {
+			Name  string
+			Posts []struct{ ID string }
+		}
+		Author struct {
+			Name  string
+			Posts []struct{ ID string }
+		}
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Tags) == 0 || data.Tags[0].Name != "go" || data.Tags[0].Count != 3 {
+		t.Errorf("Expected go to be the most used tag, got %+v", data.Tags)
+	}
+	if data.Tag.Name != "htmx" || len(data.Tag.Posts) != 1 || data.Tag.Posts[0].ID != "2" {
+		t.Errorf("Expected the newest htmx post, got %+v", data.Tag)
+	}
+	if data.Author.Name != "Jane Doe" || len(data.Author.Posts) != 2 || data.Author.Posts[0].ID != "3" {
+		t.Errorf("Expected Jane's posts newest first, got %+v", data.Author)
+	}
+}
+
+func TestGraphQLBatchesLookups(t *testing.T) {
+	store := &countingStore{Store: models.NewStore()}
+	handler := New(store)
+
+	// Listing the tags reads the store once, then the posts of every author
+	// and of every tag take one more read each, however many there are
+	_, resp := postGraphQL(t, handler, `{ authors { posts { id } } tags { posts { id } } }`, nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("Unexpected errors: %+v", resp.Errors)
+	}
+	if store.getAll != 3 {
+		t.Errorf("Expected 3 reads of the store, got %d", store.getAll)
+	}
+}
+
+func TestGraphQLErrors(t *testing.T) {
+	handler := New(models.NewStore())
+
+	tests := []struct {
+		name    string
+		query   string
+		message string
+	}{
+		{"syntax", `{ posts {`, "Syntax error"},
+		{"unknown field", `{ posts { secret } }`, `Cannot query field "secret" on type PostPage`},
+		{"missing selection", `{ post(id: 1) }`, "must have a selection"},
+		{"missing argument", `{ post { title } }`, `"id"`},
+		{"undeclared variable", `{ post(id: $id) { title } }`, "$id is not defined"},
+		{"mutation", `mutation { deletePost(id: 1) }`, "mutation"},
+		{"too deep", `{ authors { posts { author { posts { author { posts { author { posts { author { posts { id } } } } } } } } } } }`, "deep"},
+	}
+	for _, tt := range tests {
+		status, resp := postGraphQL(t, handler, tt.query, nil)
+		if status != http.StatusBadRequest || len(resp.Errors) != 1 || resp.Data != nil {
+			t.Errorf("%s: expected 400 with one error, got %d %s %+v", tt.name, status, resp.Data, resp.Errors)
+			continue
+		}
+		if !strings.Contains(resp.Errors[0].Message, tt.message) {
+			t.Errorf("%s: expected an error mentioning %q, got %q", tt.name, tt.message, resp.Errors[0].Message)
+		}
+	}
+}
+
+func TestGraphQLOverGET(t *testing.T) {
+	handler := New(models.NewStore())
+
+	params := url.Values{
+		"query":     {`query($name: String!) { tag(name: $name) { count } }`},
+		"variables": {`{"name":"templ"}`},
+	}
+	w := httptest.NewRecorder()
+	handler.GraphQL(w, httptest.NewRequest("GET", "/graphql?"+params.Encode(), nil))
+	if got := strings.TrimSpace(w.Body.String()); got != `{"data":{"tag":{"count":2}}}` {
+		t.Errorf("Expected the tag count, got %d %s", w.Code, got)
+	}
+
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader("query={posts{nextCursor}}"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	handler.GraphQL(w, req)
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected form posts to be refused, got %d", w.Code)
+	}
+}
+
+func TestGraphQLSchema(t *testing.T) {
+	w := httptest.NewRecorder()
+	New(models.NewStore()).GraphQLSchema(w, httptest.NewRequest("GET", "/graphql/schema", nil))
+
+	body := w.Body.String()
+	for _, want := range []string{"type Query {", "posts(first: Int = 10, after: String): PostPage!", "author: Author!"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected the schema to contain %q:\n%s", want, body)
+		}
+	}
+}
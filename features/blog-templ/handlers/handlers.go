@@ -1,45 +1,358 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/homveloper/doodle/features/blog-templ/models"
 	"github.com/homveloper/doodle/features/blog-templ/templates"
 )
 
+const (
+	// relatedCount is how many related posts the detail page suggests
+	relatedCount = 3
+	// defaultPageSize is how many posts the index loads per scroll
+	defaultPageSize = 10
+)
+
 // Handler manages HTTP requests for the blog
 type Handler struct {
-	store *models.Store
+	store          models.Store
+	comments       models.CommentStore
+	commentLimiter *rateLimiter
+	requestLimiter *tokenBucket
+	baseURL        string
+	users          models.UserStore
+	sessions       *models.SessionStore
+	secureCookies  bool
+	events         *broker
+	images         models.ImageStore
+	heartbeat      time.Duration
+	views          *seenTracker
+	reactions      *seenTracker
+	trashRetention time.Duration
+	categories     models.CategoryStore
+	pageSize       int
+	subscribers    models.SubscriberStore
+	mailer         models.Mailer
+	authors        models.AuthorStore
+	logger         *slog.Logger
+	metrics        *requestMetrics
+	collab         *collabHub
+	bookmarks      models.BookmarkStore
+	spam           models.SpamChecker
+	trending       *models.Trending
+	// background tracks work that outlives its request, such as emails
+	background sync.WaitGroup
+}
+
+// Option configures optional Handler dependencies
+type Option func(*Handler)
+
+// WithCommentStore sets the store used for post comments
+func WithCommentStore(comments models.CommentStore) Option {
+	return func(h *Handler) {
+		h.comments = comments
+	}
+}
+
+// WithCommentInterval sets the minimum time between comments from one client
+func WithCommentInterval(interval time.Duration) Option {
+	return func(h *Handler) {
+		h.commentLimiter = newRateLimiter(interval)
+	}
+}
+
+// WithRateLimit limits each client to perMinute requests on the endpoints
+// wrapped in RateLimit, allowing bursts of up to burst requests.
+// A zero rate disables the limit.
+func WithRateLimit(perMinute, burst int) Option {
+	return func(h *Handler) {
+		h.requestLimiter = newTokenBucket(perMinute, burst)
+	}
+}
+
+// WithBaseURL sets the public site URL used for absolute links in feeds
+// and the sitemap.
+// When unset, it is derived from each request's Host header.
+func WithBaseURL(baseURL string) Option {
+	return func(h *Handler) {
+		h.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithUserStore sets the store of author accounts allowed to log in
+func WithUserStore(users models.UserStore) Option {
+	return func(h *Handler) {
+		h.users = users
+	}
+}
+
+// WithSecureCookies marks session cookies Secure so they are only sent over HTTPS
+func WithSecureCookies(secure bool) Option {
+	return func(h *Handler) {
+		h.secureCookies = secure
+	}
+}
+
+// WithImageStore enables image uploads, kept in the given store
+func WithImageStore(images models.ImageStore) Option {
+	return func(h *Handler) {
+		h.images = images
+	}
+}
+
+// WithPageSize sets how many posts the index loads at a time as readers scroll
+func WithPageSize(size int) Option {
+	return func(h *Handler) {
+		h.pageSize = size
+	}
+}
+
+// WithLogger sets where the handler logs failures that don't reach the
+// client, such as undeliverable emails. It defaults to slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(h *Handler) {
+		h.logger = logger
+	}
 }
 
 // New creates a new handler with a post store
-func New(store *models.Store) *Handler {
-	return &Handler{
-		store: store,
+func New(store models.Store, opts ...Option) *Handler {
+	h := &Handler{
+		store:          store,
+		comments:       models.NewCommentStore(),
+		commentLimiter: newRateLimiter(10 * time.Second),
+		requestLimiter: newTokenBucket(60, 30),
+		users:          models.NewUserStore(),
+		sessions:       models.NewSessionStore(),
+		events:         newBroker(),
+		heartbeat:      30 * time.Second,
+		views:          newSeenTracker(24 * time.Hour),
+		reactions:      newSeenTracker(30 * 24 * time.Hour),
+		trashRetention: 30 * 24 * time.Hour,
+		categories:     models.NewCategoryStore(),
+		pageSize:       defaultPageSize,
+		subscribers:    models.NewSubscriberStore(),
+		authors:        models.NewAuthorStore(),
+		logger:         slog.Default(),
+		metrics:        newRequestMetrics(),
+		collab:         newCollabHub(store),
+		bookmarks:      models.NewBookmarkStore(),
+		spam:           models.SpamCheckers{models.HoneypotChecker{}, models.NewKeywordChecker()},
+		trending:       models.NewTrending(24 * time.Hour),
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// CloseStreams ends open server-sent event streams and collaborative
+// editing sessions. Register it with http.Server.RegisterOnShutdown, since
+// Shutdown otherwise waits for streams that never go idle, and doesn't
+// know about WebSockets at all.
+func (h *Handler) CloseStreams() {
+	h.events.close()
+	h.collab.close()
+}
+
+// Drain waits for background work started by requests, such as sending
+// new-post emails, to finish. It returns ctx's error if ctx ends first.
+func (h *Handler) Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		h.background.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
 // Index handles the home page
 func (h *Handler) Index(w http.ResponseWriter, r *http.Request) {
-	posts := h.store.GetAll()
-	templates.Index(posts).Render(r.Context(), w)
+	order := models.ParseSortOrder(r.URL.Query().Get("sort"))
+
+	// With no query to rank, "best match" shows the newest posts first
+	listOrder := order
+	if listOrder == models.SortRelevance {
+		listOrder = models.SortNewest
+	}
+
+	// Newest first is the only order that scrolls page by page; the others
+	// still list every post
+	var (
+		posts []models.Post
+		next  string
+	)
+	if listOrder == models.SortNewest {
+		var err error
+		if posts, next, err = h.store.After("", h.pageSize); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		posts = models.SortPosts(models.PublishedOnly(h.store.GetAll()), listOrder)
+	}
+
+	templates.Index(posts, next, order, h.store.MostViewed(popularCount), h.categories.All(), h.authors.All(), h.mailer != nil).Render(r.Context(), w)
+}
+
+// MorePosts serves the page of posts after the ?cursor= for infinite scroll
+func (h *Handler) MorePosts(w http.ResponseWriter, r *http.Request) {
+	posts, next, err := h.store.After(r.URL.Query().Get("cursor"), h.pageSize)
+	if errors.Is(err, models.ErrInvalidCursor) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	templates.PostPage(posts, next).Render(r.Context(), w)
 }
 
-// Search handles the search endpoint
+// Search handles the search endpoint, narrowing the text query with the
+// author, tag, category and from/to date filters
 func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
-	posts := h.store.Search(query)
-	templates.PostList(posts).Render(r.Context(), w)
+	order := models.ParseSortOrder(r.URL.Query().Get("sort"))
+
+	filter, err := parseSearchFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Without a query there is nothing to rank, so "best match" means newest
+	if strings.TrimSpace(query) == "" && order == models.SortRelevance {
+		order = models.SortNewest
+	}
+
+	// A cleared search box goes back to the scrolling index list
+	if strings.TrimSpace(query) == "" && filter.IsZero() && order == models.SortNewest {
+		posts, next, err := h.store.After("", h.pageSize)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		templates.PagedPostList(posts, next).Render(r.Context(), w)
+		return
+	}
+
+	posts := models.FilterPosts(models.PublishedOnly(h.store.Search(query)), filter)
+	posts = models.SortPosts(posts, order)
+
+	if strings.TrimSpace(query) == "" {
+		templates.PostList(posts).Render(r.Context(), w)
+		return
+	}
+	templates.SearchResults(models.Highlight(posts, query)).Render(r.Context(), w)
+}
+
+// PostDetail handles the single post page, found by its ID or its slug
+func (h *Handler) PostDetail(w http.ResponseWriter, r *http.Request) {
+	post, ok := h.lookupPostPage(w, r)
+	if !ok {
+		return
+	}
+	h.countView(w, r, post)
+
+	related, _ := h.store.Related(post.ID, relatedCount)
+	category, _ := h.categories.Get(post.Category)
+	templates.PostDetail(post, h.postMeta(r, post), related, category).Render(r.Context(), w)
+}
+
+// postMeta describes a post for OpenGraph and Twitter link previews
+func (h *Handler) postMeta(r *http.Request, post models.Post) templates.PageMeta {
+	base := h.siteURL(r)
+
+	image := post.CoverImageURL
+	if strings.HasPrefix(image, "/") {
+		image = base + image
+	}
+
+	return templates.PageMeta{
+		Title:       post.Title,
+		Description: summarize(post.Content, summaryLength),
+		URL:         postURL(base, post),
+		Image:       image,
+		Type:        "article",
+	}
+}
+
+// parseSearchFilter reads the search filters from the query string
+func parseSearchFilter(r *http.Request) (models.SearchFilter, error) {
+	q := r.URL.Query()
+	return models.ParseSearchFilter(q.Get("author"), q.Get("tag"), q.Get("category"), q.Get("from"), q.Get("to"))
+}
+
+// lookupPost resolves the {id} path value to a post, writing a
+// 400 or 404 response and returning false when that fails.
+// Drafts are only visible to their author.
+func (h *Handler) lookupPost(w http.ResponseWriter, r *http.Request) (models.Post, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return models.Post{}, false
+	}
+
+	post, err := h.store.GetByID(id)
+	if errors.Is(err, models.ErrPostNotFound) {
+		http.NotFound(w, r)
+		return models.Post{}, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return models.Post{}, false
+	}
+
+	if !post.Published && !isAuthor(r, post) {
+		http.NotFound(w, r)
+		return models.Post{}, false
+	}
+
+	return post, true
+}
+
+// isAuthor reports whether the logged-in user wrote the post
+func isAuthor(r *http.Request, post models.Post) bool {
+	user, _ := models.UserFromContext(r.Context())
+	return ownsPost(user, post)
+}
+
+// ownsPost reports whether user wrote the post
+func ownsPost(user models.User, post models.Post) bool {
+	return post.AuthorUsername != "" && user.Username == post.AuthorUsername
 }
 
 // NewPostForm handles the new post form page
 func (h *Handler) NewPostForm(w http.ResponseWriter, r *http.Request) {
-	templates.NewPostForm().Render(r.Context(), w)
+	templates.NewPostForm(h.categories.All()).Render(r.Context(), w)
 }
 
 // CreatePost handles post creation
 func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
+	user, ok := models.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Login required", http.StatusUnauthorized)
+		return
+	}
+
 	// Parse form data
 	if err := r.ParseForm(); err != nil {
 		http.Error(w, "Invalid form data", http.StatusBadRequest)
@@ -47,8 +360,14 @@ func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	title := strings.TrimSpace(r.FormValue("title"))
+	draft := r.FormValue("action") == "draft"
 	content := strings.TrimSpace(r.FormValue("content"))
 	tagsStr := strings.TrimSpace(r.FormValue("tags"))
+	category := r.FormValue("category")
+	if err := h.checkCategory(category); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Parse tags (comma-separated)
 	var tags []string
@@ -61,12 +380,17 @@ func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Create post with default author
+	// The logged-in user is the author
 	post := models.Post{
-		Title:   title,
-		Content: content,
-		Author:  "Blog Author", // Default author as per user preference
-		Tags:    tags,
+		Title:          title,
+		Content:        content,
+		Author:         user.DisplayName,
+		AuthorUsername: user.Username,
+		Published:      !draft,
+		Tags:           tags,
+		Category:       category,
+		Images:         h.attachedImages(r.Form["images"]),
+		CoverImageURL:  strings.TrimSpace(r.FormValue("cover_image_url")),
 	}
 
 	// Add post to store
@@ -78,7 +402,51 @@ func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	// Get the newly added post (it's at the beginning)
 	posts := h.store.GetAll()
 	newPost := posts[0]
+	h.announcePost(r, newPost)
+
+	// Send the author to where the post now lives
+	if draft {
+		w.Header().Set("HX-Redirect", "/drafts")
+	} else {
+		w.Header().Set("HX-Redirect", "/")
+	}
 
 	// Return the new post card for HTMX to insert
 	templates.PostCard(newPost).Render(r.Context(), w)
 }
+
+// Drafts lists the logged-in author's unpublished posts
+func (h *Handler) Drafts(w http.ResponseWriter, r *http.Request) {
+	user, ok := models.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Login required", http.StatusUnauthorized)
+		return
+	}
+
+	drafts := models.DraftsBy(h.store.GetAll(), user.Username)
+	templates.DraftsPage(drafts).Render(r.Context(), w)
+}
+
+// PublishPost publishes one of the logged-in author's drafts
+func (h *Handler) PublishPost(w http.ResponseWriter, r *http.Request) {
+	post, ok := h.lookupPost(w, r)
+	if !ok {
+		return
+	}
+	if !isAuthor(r, post) {
+		http.Error(w, "Only the author can publish this post", http.StatusForbidden)
+		return
+	}
+
+	wasPublished := post.Published
+	post, err := h.store.Publish(post.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !wasPublished {
+		h.announcePost(r, post)
+	}
+
+	templates.DraftPublished(post).Render(r.Context(), w)
+}
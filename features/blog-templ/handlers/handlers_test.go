@@ -4,8 +4,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/homveloper/doodle/features/blog-templ/models"
 )
@@ -60,6 +63,55 @@ func TestIndexHandler(t *testing.T) {
 	}
 }
 
+// samplePostURLs are the pages of the sample posts, by ID
+var samplePostURLs = map[int]string{
+	1: "/posts/getting-started-with-templ-and-htmx",
+	2: "/posts/building-real-time-search-with-htmx",
+	3: "/posts/why-go-is-great-for-web-development",
+	4: "/posts/type-safe-html-templates",
+}
+
+func TestIndexInfiniteScroll(t *testing.T) {
+	handler := New(models.NewStore(), WithPageSize(3))
+
+	w := httptest.NewRecorder()
+	handler.Index(w, httptest.NewRequest("GET", "/", nil))
+
+	body := w.Body.String()
+	if strings.Contains(body, `href="`+samplePostURLs[1]+`"`) {
+		t.Error("Expected the oldest post to wait for the next page")
+	}
+	start := strings.Index(body, `hx-get="/posts/more?cursor=`)
+	if start < 0 || !strings.Contains(body, `hx-trigger="revealed"`) {
+		t.Fatal("Expected a sentinel that loads the next page when revealed")
+	}
+
+	// Follow the sentinel's cursor
+	target := body[start+len(`hx-get="`):]
+	target = target[:strings.Index(target, `"`)]
+	w = httptest.NewRecorder()
+	handler.MorePosts(w, httptest.NewRequest("GET", target, nil))
+
+	body = w.Body.String()
+	if !strings.Contains(body, `href="`+samplePostURLs[1]+`"`) || strings.Contains(body, `href="`+samplePostURLs[4]+`"`) {
+		t.Error("Expected only the remaining post on the next page")
+	}
+	if strings.Contains(body, "hx-trigger=\"revealed\"") {
+		t.Error("Expected no sentinel after the last page")
+	}
+}
+
+func TestMorePostsInvalidCursor(t *testing.T) {
+	handler := New(models.NewStore())
+
+	w := httptest.NewRecorder()
+	handler.MorePosts(w, httptest.NewRequest("GET", "/posts/more?cursor=bogus", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
 func TestSearchHandler(t *testing.T) {
 	store := models.NewStore()
 	handler := New(store)
@@ -269,6 +321,7 @@ func TestCreatePostHandler(t *testing.T) {
 
 			req := httptest.NewRequest("POST", "/posts", strings.NewReader(tt.formData.Encode()))
 			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req = req.WithContext(models.ContextWithUser(req.Context(), testUser))
 			w := httptest.NewRecorder()
 
 			handler.CreatePost(w, req)
@@ -291,7 +344,234 @@ func TestCreatePostHandler(t *testing.T) {
 				if posts[0].Title != tt.formData.Get("title") {
 					t.Errorf("Expected first post title '%s', got '%s'", tt.formData.Get("title"), posts[0].Title)
 				}
+				if posts[0].Author != testUser.DisplayName {
+					t.Errorf("Expected author '%s', got '%s'", testUser.DisplayName, posts[0].Author)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchHandlerHighlightsMatches(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+
+	req := httptest.NewRequest("GET", "/search?q="+url.QueryEscape("search htmx"), nil)
+	w := httptest.NewRecorder()
+
+	handler.Search(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, "<mark>Search</mark>") || !strings.Contains(body, "<mark>HTMX</mark>") {
+		t.Errorf("Expected matching words wrapped in <mark>, got:\n%s", body)
+	}
+}
+
+func TestIndexHandlerSort(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+
+	tests := []struct {
+		sort  string
+		first string
+		last  string
+	}{
+		{sort: "", first: "Type-Safe HTML Templates", last: "Getting Started with Templ and HTMX"},
+		{sort: "oldest", first: "Getting Started with Templ and HTMX", last: "Type-Safe HTML Templates"},
+		{sort: "title", first: "Building Real-time Search with HTMX", last: "Why Go is Great for Web Development"},
+	}
+
+	for _, tt := range tests {
+		t.Run("sort="+tt.sort, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/?sort="+tt.sort, nil)
+			w := httptest.NewRecorder()
+
+			handler.Index(w, req)
+
+			body := w.Body.String()
+			first, last := strings.Index(body, tt.first), strings.Index(body, tt.last)
+			if first < 0 || last < 0 || first > last {
+				t.Errorf("Expected %q before %q", tt.first, tt.last)
 			}
 		})
 	}
+
+	// The active sort option is pre-selected
+	req := httptest.NewRequest("GET", "/?sort=author", nil)
+	w := httptest.NewRecorder()
+	handler.Index(w, req)
+
+	if !strings.Contains(w.Body.String(), `value="author" checked`) {
+		t.Error("Expected author sort option to be checked")
+	}
+}
+
+func TestSearchHandlerSort(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+
+	// "go" matches several posts; sort by oldest overrides relevance
+	req := httptest.NewRequest("GET", "/search?q=go&sort=oldest", nil)
+	w := httptest.NewRecorder()
+
+	handler.Search(w, req)
+
+	body := w.Body.String()
+	older := strings.Index(body, samplePostURLs[1]+`"`)
+	newer := strings.Index(body, samplePostURLs[4]+`"`)
+	if older < 0 || newer < 0 || older > newer {
+		t.Error("Expected oldest matching post first")
+	}
+}
+
+func TestSearchHandlerFilters(t *testing.T) {
+	handler := New(models.NewStore())
+	since := time.Now().AddDate(0, 0, -4).Format(models.FilterDateFormat)
+
+	tests := []struct {
+		name   string
+		target string
+		want   []int
+	}{
+		{"author", "/search?author=jane", []int{1, 3}},
+		{"author username with query", "/search?q=go&author=JOHN", []int{4}},
+		{"tag", "/search?tag=HTMX", []int{1, 2}},
+		{"category", "/search?category=web-development", []int{3, 4}},
+		{"from date", "/search?from=" + since, []int{3, 4}},
+		{"to date", "/search?to=" + since, []int{1, 2}},
+		{"combined", "/search?q=go&tag=templ&from=" + since, []int{4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.Search(w, httptest.NewRequest("GET", tt.target, nil))
+
+			body := w.Body.String()
+			for id := 1; id <= 4; id++ {
+				want := slices.Contains(tt.want, id)
+				if got := strings.Contains(body, samplePostURLs[id]+`"`); got != want {
+					t.Errorf("Post %d shown = %v, want %v", id, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchHandlerInvalidDate(t *testing.T) {
+	handler := New(models.NewStore())
+
+	for _, target := range []string{"/search?from=yesterday", "/search?from=2024-05-02&to=2024-05-01"} {
+		w := httptest.NewRecorder()
+		handler.Search(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", target, w.Code)
+		}
+	}
+}
+
+func TestIndexShowsSearchFilters(t *testing.T) {
+	handler := New(models.NewStore())
+
+	w := httptest.NewRecorder()
+	handler.Index(w, httptest.NewRequest("GET", "/", nil))
+
+	body := w.Body.String()
+	for _, field := range []string{`name="author"`, `name="tag"`, `name="category"`, `name="from"`, `name="to"`} {
+		if !strings.Contains(body, field) {
+			t.Errorf("Expected filter field %s", field)
+		}
+	}
+	if !strings.Contains(body, `<option value="tutorials">Tutorials</option>`) {
+		t.Error("Expected categories in the filter panel")
+	}
+}
+
+func TestPostDetailSocialMeta(t *testing.T) {
+	store := models.NewStore()
+	store.Add(models.Post{
+		Title:         "Covered",
+		Content:       "A post with a cover image",
+		Author:        "Jane Doe",
+		Published:     true,
+		CoverImageURL: "/uploads/0123456789abcdef0123456789abcdef.jpg",
+	})
+	id := store.GetAll()[0].ID
+	handler := New(store, WithBaseURL("https://blog.example.com"))
+
+	req := httptest.NewRequest("GET", "/posts/"+strconv.Itoa(id), nil)
+	req.SetPathValue("id", strconv.Itoa(id))
+	w := httptest.NewRecorder()
+	handler.PostDetail(w, req)
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`<meta property="og:title" content="Covered">`,
+		`<meta property="og:type" content="article">`,
+		`<meta property="og:url" content="https://blog.example.com/posts/covered">`,
+		`<meta property="og:description" content="A post with a cover image">`,
+		`<meta property="og:image" content="https://blog.example.com/uploads/0123456789abcdef0123456789abcdef.jpg">`,
+		`<meta name="twitter:card" content="summary_large_image">`,
+		`class="post-cover"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected post page to contain %s", want)
+		}
+	}
+}
+
+func TestPostDetailSocialMetaWithoutCover(t *testing.T) {
+	handler := New(models.NewStore())
+
+	req := httptest.NewRequest("GET", "/posts/1", nil)
+	req.SetPathValue("id", "1")
+	w := httptest.NewRecorder()
+	handler.PostDetail(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, `<meta name="twitter:card" content="summary">`) {
+		t.Error("Expected a summary Twitter card without a cover image")
+	}
+	if strings.Contains(body, "og:image") {
+		t.Error("Expected no og:image without a cover image")
+	}
+}
+
+func TestIndexShowsCoverImages(t *testing.T) {
+	store := models.NewStore()
+	store.Add(models.Post{
+		Title:         "Covered",
+		Content:       "Body",
+		Author:        "Jane Doe",
+		Published:     true,
+		CoverImageURL: "https://images.example.com/cover.jpg",
+	})
+	handler := New(store)
+
+	w := httptest.NewRecorder()
+	handler.Index(w, httptest.NewRequest("GET", "/", nil))
+
+	if !strings.Contains(w.Body.String(), `src="https://images.example.com/cover.jpg"`) {
+		t.Error("Expected the post card to show its cover image")
+	}
+	if strings.Contains(w.Body.String(), "og:title") {
+		t.Error("Expected no post OpenGraph tags on the index page")
+	}
+}
+
+func TestPostDetailShowsRelatedPosts(t *testing.T) {
+	handler := New(models.NewStore())
+
+	req := httptest.NewRequest("GET", "/posts/4", nil)
+	req.SetPathValue("id", "4")
+	w := httptest.NewRecorder()
+	handler.PostDetail(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, "Related posts") {
+		t.Fatal("Expected a related posts section")
+	}
+	if !strings.Contains(body, `href="`+samplePostURLs[1]+`"`) {
+		t.Error("Expected the other templ post to be suggested")
+	}
 }
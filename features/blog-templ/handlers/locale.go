@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// localeCookieName is the cookie remembering the reader's language
+const localeCookieName = "blog_lang"
+
+// localeCookieMaxAge keeps the preference for a year
+const localeCookieMaxAge = 365 * 24 * time.Hour
+
+// LoadLocale is middleware that picks the UI language for templates.
+// A ?lang= query parameter wins and is remembered in a cookie; otherwise
+// the cookie is used, then the browser's Accept-Language header.
+func (h *Handler) LoadLocale(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Language")
+		r = r.WithContext(templates.ContextWithLocale(r.Context(), h.resolveLocale(w, r)))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// resolveLocale returns the reader's language, saving an explicit choice
+func (h *Handler) resolveLocale(w http.ResponseWriter, r *http.Request) templates.Locale {
+	if locale, ok := templates.ParseLocale(r.URL.Query().Get("lang")); ok {
+		http.SetCookie(w, &http.Cookie{
+			Name:     localeCookieName,
+			Value:    string(locale),
+			Path:     "/",
+			MaxAge:   int(localeCookieMaxAge / time.Second),
+			HttpOnly: true,
+			Secure:   h.secureCookies,
+			SameSite: http.SameSiteLaxMode,
+		})
+		return locale
+	}
+
+	if cookie, err := r.Cookie(localeCookieName); err == nil {
+		if locale, ok := templates.ParseLocale(cookie.Value); ok {
+			return locale
+		}
+	}
+
+	return acceptLanguage(r.Header.Get("Accept-Language"))
+}
+
+// acceptLanguage returns the supported language the browser prefers most,
+// or the default when none of its languages are supported
+func acceptLanguage(header string) templates.Locale {
+	type candidate struct {
+		locale templates.Locale
+		q      float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		if locale, ok := templates.ParseLocale(tag); ok {
+			candidates = append(candidates, candidate{locale, q})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return templates.DefaultLocale
+	}
+
+	// Stable so equally weighted languages keep the browser's order
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].locale
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+func TestAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   templates.Locale
+	}{
+		{header: "", want: templates.LocaleEnglish},
+		{header: "ko-KR,ko;q=0.9,en-US;q=0.8,en;q=0.7", want: templates.LocaleKorean},
+		{header: "en-US,en;q=0.9,ko;q=0.8", want: templates.LocaleEnglish},
+		{header: "fr-FR,fr;q=0.9,ko;q=0.5", want: templates.LocaleKorean},
+		{header: "en;q=0.3, ko;q=0.7", want: templates.LocaleKorean},
+		{header: "ko;q=0, en;q=0.1", want: templates.LocaleEnglish},
+		{header: "de, fr", want: templates.LocaleEnglish},
+	}
+
+	for _, tt := range tests {
+		if got := acceptLanguage(tt.header); got != tt.want {
+			t.Errorf("acceptLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestLoadLocalePrecedence(t *testing.T) {
+	handler := New(models.NewStore())
+	site := handler.LoadLocale(http.HandlerFunc(handler.Index))
+
+	tests := []struct {
+		name   string
+		target string
+		cookie string
+		accept string
+		lang   string
+	}{
+		{name: "default", target: "/", lang: "en"},
+		{name: "header", target: "/", accept: "ko-KR,ko;q=0.9", lang: "ko"},
+		{name: "cookie beats header", target: "/", cookie: "en", accept: "ko", lang: "en"},
+		{name: "query beats cookie", target: "/?lang=ko", cookie: "en", lang: "ko"},
+		{name: "unknown query ignored", target: "/?lang=xx", accept: "ko", lang: "ko"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.target, nil)
+		if tt.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: localeCookieName, Value: tt.cookie})
+		}
+		if tt.accept != "" {
+			req.Header.Set("Accept-Language", tt.accept)
+		}
+		w := httptest.NewRecorder()
+
+		site.ServeHTTP(w, req)
+
+		if !strings.Contains(w.Body.String(), `<html lang="`+tt.lang+`"`) {
+			t.Errorf("%s: expected <html lang=%q>", tt.name, tt.lang)
+		}
+		if w.Header().Get("Vary") != "Accept-Language" {
+			t.Errorf("%s: expected Vary: Accept-Language", tt.name)
+		}
+	}
+}
+
+func TestLoadLocaleRemembersQueryChoice(t *testing.T) {
+	handler := New(models.NewStore())
+	site := handler.LoadLocale(http.HandlerFunc(handler.Index))
+
+	req := httptest.NewRequest("GET", "/?lang=ko-KR", nil)
+	w := httptest.NewRecorder()
+
+	site.ServeHTTP(w, req)
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != localeCookieName || cookies[0].Value != "ko" {
+		t.Fatalf("Expected a ko language cookie, got %v", cookies)
+	}
+	if cookies[0].MaxAge <= 0 {
+		t.Error("Expected the language cookie to persist")
+	}
+}
+
+func TestKoreanPages(t *testing.T) {
+	handler := New(models.NewStore())
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", handler.Index)
+	mux.HandleFunc("GET /archive", handler.Archive)
+	mux.HandleFunc("GET /new", handler.NewPostForm)
+	site := handler.LoadLocale(mux)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "/", want: []string{"블로그 두들 - 홈", "✏️ 새 글 쓰기", "최신순", `aria-current="true">한국어</a>`}},
+		{path: "/archive", want: []string{"아카이브 - 블로그 두들", "← 홈으로"}},
+		{path: "/new", want: []string{"새 글 쓰기", "임시 저장"}},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.Header.Set("Accept-Language", "ko")
+		w := httptest.NewRecorder()
+
+		site.ServeHTTP(w, req)
+
+		body := w.Body.String()
+		for _, want := range tt.want {
+			if !strings.Contains(body, want) {
+				t.Errorf("%s: expected %q in Korean page", tt.path, want)
+			}
+		}
+		if strings.Contains(body, "Write New Post") {
+			t.Errorf("%s: found untranslated English text", tt.path)
+		}
+	}
+}
+
+func TestKoreanLoginError(t *testing.T) {
+	handler := New(models.NewStore())
+	site := handler.LoadLocale(http.HandlerFunc(handler.Login))
+
+	req := httptest.NewRequest("POST", "/login?lang=ko", strings.NewReader("username=nobody&password=wrong"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	site.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "아이디 또는 비밀번호가 올바르지 않습니다") {
+		t.Error("Expected a Korean login error")
+	}
+}
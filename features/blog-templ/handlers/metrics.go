@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the request latency
+// histogram
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// routeKey identifies a route for latency metrics
+type routeKey struct {
+	method string
+	route  string
+}
+
+// requestKey identifies a route and response status for request counts
+type requestKey struct {
+	routeKey
+	status int
+}
+
+// histogram counts observations into latencyBuckets
+type histogram struct {
+	// buckets[i] counts observations up to latencyBuckets[i], not cumulative
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+// requestMetrics counts requests and their latencies by route
+type requestMetrics struct {
+	mu        sync.Mutex
+	started   time.Time
+	requests  map[requestKey]uint64
+	latencies map[routeKey]*histogram
+}
+
+func newRequestMetrics() *requestMetrics {
+	return &requestMetrics{
+		started:   time.Now(),
+		requests:  make(map[requestKey]uint64),
+		latencies: make(map[routeKey]*histogram),
+	}
+}
+
+// observe records one served request
+func (m *requestMetrics) observe(key requestKey, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[key]++
+	hist, ok := m.latencies[key.routeKey]
+	if !ok {
+		hist = &histogram{buckets: make([]uint64, len(latencyBuckets))}
+		m.latencies[key.routeKey] = hist
+	}
+	seconds := duration.Seconds()
+	if i, _ := slices.BinarySearch(latencyBuckets, seconds); i < len(latencyBuckets) {
+		hist.buckets[i]++
+	}
+	hist.sum += seconds
+	hist.count++
+}
+
+// metricMethods are the request methods given their own label value, so
+// clients can't create unbounded series with made-up methods
+var metricMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// RecordMetrics is middleware that counts requests and their latencies by
+// method, route and status for /metrics. Routes are the mux patterns such
+// as /posts/{id}, so it must run inside the middlewares that replace the
+// request, closest to the mux.
+func (h *Handler) RecordMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		method := r.Method
+		if !slices.Contains(metricMethods, method) {
+			method = "OTHER"
+		}
+		// The mux sets the matched pattern, such as "GET /posts/{id}"
+		route := r.Pattern
+		if _, path, ok := strings.Cut(route, " "); ok {
+			route = path
+		}
+		if route == "" {
+			route = "unmatched"
+		}
+
+		key := requestKey{routeKey: routeKey{method: method, route: route}, status: rec.status()}
+		h.metrics.observe(key, time.Since(start))
+	})
+}
+
+// Healthz reports that the server is up, for load balancer and
+// orchestrator health checks
+func (h *Handler) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":         "ok",
+		"uptime_seconds": int(time.Since(h.metrics.started).Seconds()),
+	})
+}
+
+// Metrics serves request counts, latencies and store sizes in the
+// Prometheus text format
+func (h *Handler) Metrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+
+	h.writeRequestMetrics(w)
+	h.writeStoreMetrics(w)
+
+	fmt.Fprintln(w, "# HELP blog_uptime_seconds Seconds since the server started.")
+	fmt.Fprintln(w, "# TYPE blog_uptime_seconds gauge")
+	fmt.Fprintf(w, "blog_uptime_seconds %s\n", formatFloat(time.Since(h.metrics.started).Seconds()))
+}
+
+// writeRequestMetrics writes the request counter and latency histogram,
+// sorted so scrapes are easy to compare
+func (h *Handler) writeRequestMetrics(w io.Writer) {
+	m := h.metrics
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP blog_http_requests_total Requests served, by method, route and status.")
+	fmt.Fprintln(w, "# TYPE blog_http_requests_total counter")
+	keys := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b requestKey) int {
+		return cmp.Or(compareRoutes(a.routeKey, b.routeKey), cmp.Compare(a.status, b.status))
+	})
+	for _, key := range keys {
+		fmt.Fprintf(w, "blog_http_requests_total{method=%s,route=%s,status=\"%d\"} %d\n",
+			labelValue(key.method), labelValue(key.route), key.status, m.requests[key])
+	}
+
+	fmt.Fprintln(w, "# HELP blog_http_request_duration_seconds How long requests took to serve, by method and route.")
+	fmt.Fprintln(w, "# TYPE blog_http_request_duration_seconds histogram")
+	routes := make([]routeKey, 0, len(m.latencies))
+	for route := range m.latencies {
+		routes = append(routes, route)
+	}
+	slices.SortFunc(routes, compareRoutes)
+	for _, route := range routes {
+		hist := m.latencies[route]
+		labels := fmt.Sprintf("method=%s,route=%s", labelValue(route.method), labelValue(route.route))
+
+		var cumulative uint64
+		for i, bound := range latencyBuckets {
+			cumulative += hist.buckets[i]
+			fmt.Fprintf(w, "blog_http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(w, "blog_http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, hist.count)
+		fmt.Fprintf(w, "blog_http_request_duration_seconds_sum{%s} %s\n", labels, formatFloat(hist.sum))
+		fmt.Fprintf(w, "blog_http_request_duration_seconds_count{%s} %d\n", labels, hist.count)
+	}
+}
+
+// writeStoreMetrics writes gauges for the size of the blog's stores
+func (h *Handler) writeStoreMetrics(w io.Writer) {
+	posts := h.store.GetAll()
+	published := len(models.PublishedOnly(posts))
+
+	fmt.Fprintln(w, "# HELP blog_posts Posts in the store, by state.")
+	fmt.Fprintln(w, "# TYPE blog_posts gauge")
+	fmt.Fprintf(w, "blog_posts{state=\"published\"} %d\n", published)
+	fmt.Fprintf(w, "blog_posts{state=\"draft\"} %d\n", len(posts)-published)
+	fmt.Fprintf(w, "blog_posts{state=\"trashed\"} %d\n", len(h.store.Trash()))
+
+	fmt.Fprintln(w, "# HELP blog_subscribers Confirmed email subscribers.")
+	fmt.Fprintln(w, "# TYPE blog_subscribers gauge")
+	fmt.Fprintf(w, "blog_subscribers %d\n", len(h.subscribers.Confirmed()))
+
+	fmt.Fprintln(w, "# HELP blog_event_streams Open live update streams.")
+	fmt.Fprintln(w, "# TYPE blog_event_streams gauge")
+	fmt.Fprintf(w, "blog_event_streams %d\n", h.events.count())
+}
+
+func compareRoutes(a, b routeKey) int {
+	return cmp.Or(cmp.Compare(a.route, b.route), cmp.Compare(a.method, b.method))
+}
+
+// labelValue quotes a Prometheus label value, escaping backslashes,
+// quotes and newlines
+func labelValue(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// formatFloat formats a sample value in the shortest exact form
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+func TestHealthz(t *testing.T) {
+	w := httptest.NewRecorder()
+	New(models.NewStore()).Healthz(w, httptest.NewRequest("GET", "/healthz", nil))
+
+	var body struct{ Status string }
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || body.Status != "ok" {
+		t.Errorf("Expected 200 ok, got %d %q", w.Code, body.Status)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	handler := New(models.NewStore())
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /posts/{id}", handler.PostDetail)
+	mux.HandleFunc("GET /metrics", handler.Metrics)
+	site := handler.RecordMetrics(mux)
+
+	for _, target := range []string{"/posts/1", "/posts/2", "/posts/999"} {
+		site.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+	}
+	site.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("BREW", "/coffee", nil))
+
+	w := httptest.NewRecorder()
+	site.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %q", w.Header().Get("Content-Type"))
+	}
+	for _, want := range []string{
+		`blog_http_requests_total{method="GET",route="/posts/{id}",status="200"} 2`,
+		`blog_http_requests_total{method="GET",route="/posts/{id}",status="404"} 1`,
+		`blog_http_requests_total{method="OTHER",route="unmatched",status="404"} 1`,
+		`blog_http_request_duration_seconds_bucket{method="GET",route="/posts/{id}",le="+Inf"} 3`,
+		`blog_http_request_duration_seconds_count{method="GET",route="/posts/{id}"} 3`,
+		`blog_posts{state="published"} 4`,
+		`blog_posts{state="draft"} 0`,
+		`blog_posts{state="trashed"} 0`,
+		"blog_subscribers 0",
+		"blog_event_streams 0",
+		"# TYPE blog_http_request_duration_seconds histogram",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	m := newRequestMetrics()
+	key := requestKey{routeKey: routeKey{method: "GET", route: "/"}, status: 200}
+	m.observe(key, 5*time.Millisecond)
+	m.observe(key, 300*time.Millisecond)
+	m.observe(key, time.Minute)
+
+	hist := m.latencies[key.routeKey]
+	// 5ms lands in the inclusive 0.005 bucket, and a minute in none but +Inf
+	if hist.buckets[0] != 1 || hist.buckets[6] != 1 || hist.count != 3 {
+		t.Errorf("Unexpected buckets %v (count %d)", hist.buckets, hist.count)
+	}
+}
+
+func TestLabelValue(t *testing.T) {
+	if got := labelValue("a\"b\\c\nd"); got != `"a\"b\\c\nd"` {
+		t.Errorf("Unexpected escaping %s", got)
+	}
+}
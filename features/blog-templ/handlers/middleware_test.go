@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+func TestChainOrder(t *testing.T) {
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	h := Chain(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		order = append(order, "handler")
+	}), mark("a"), mark("b"))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if strings.Join(order, ",") != "a,b,handler" {
+		t.Errorf("Expected a,b,handler, got %v", order)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if len(seen) != 16 || w.Header().Get("X-Request-ID") != seen {
+		t.Errorf("Expected a generated ID in the context and response, got %q and %q", seen, w.Header().Get("X-Request-ID"))
+	}
+
+	// A proxy's ID is kept, unless it could mess up the logs
+	for id, keep := range map[string]bool{"abc-123": true, "bad id\nforged": false, strings.Repeat("x", 65): false} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Request-ID", id)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		if (seen == id) != keep {
+			t.Errorf("Incoming ID %q: keep=%v, got %q", id, keep, seen)
+		}
+	}
+}
+
+// jsonLogger returns a logger writing JSON lines to buf
+func jsonLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func TestLogRequests(t *testing.T) {
+	var buf bytes.Buffer
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusTeapot)
+	}), RequestID, LogRequests(jsonLogger(&buf)))
+
+	req := httptest.NewRequest("GET", "/brew?cup=1", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry struct {
+		Level     string
+		Msg       string
+		RequestID string `json:"request_id"`
+		Method    string
+		Path      string
+		Status    int
+		Bytes     int
+		Duration  *int64
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a JSON log line, got %q: %v", buf.String(), err)
+	}
+	if entry.Level != "INFO" || entry.Msg != "request" || entry.RequestID != "req-1" ||
+		entry.Method != "GET" || entry.Path != "/brew?cup=1" || entry.Status != http.StatusTeapot ||
+		entry.Bytes != 5 || entry.Duration == nil {
+		t.Errorf("Unexpected log line %q", buf.String())
+	}
+}
+
+func TestLogRequestsServerErrors(t *testing.T) {
+	var buf bytes.Buffer
+	LogRequests(jsonLogger(&buf))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if !strings.Contains(buf.String(), `"level":"ERROR"`) {
+		t.Errorf("Expected a 502 to be logged as an error, got %q", buf.String())
+	}
+}
+
+func TestRecover(t *testing.T) {
+	var buf bytes.Buffer
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}), RequestID, Recover(jsonLogger(&buf)))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+	if !strings.Contains(buf.String(), `"panic":"boom"`) || !strings.Contains(buf.String(), "goroutine") {
+		t.Errorf("Expected the panic and stack to be logged, got %q", buf.String())
+	}
+}
+
+func TestRecoverLetsAbortThrough(t *testing.T) {
+	h := Recover(slog.New(slog.NewTextHandler(io.Discard, nil)))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if recover() != http.ErrAbortHandler {
+			t.Error("Expected ErrAbortHandler to be re-raised")
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func gzipRequest(target string) *http.Request {
+	req := httptest.NewRequest("GET", target, nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	return req
+}
+
+func TestGzipCompressesPages(t *testing.T) {
+	h := Gzip(http.HandlerFunc(New(models.NewStore()).Index))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, gzipRequest("/"))
+
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected a gzipped page, got headers %v", w.Header())
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), "Getting Started with Templ") {
+		t.Error("Expected the decompressed page to list posts")
+	}
+}
+
+func TestGzipSkips(t *testing.T) {
+	png := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG..."))
+	})
+	noContent := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		req     *http.Request
+	}{
+		{"client without gzip", http.HandlerFunc(New(models.NewStore()).Index), httptest.NewRequest("GET", "/", nil)},
+		{"images", png, gzipRequest("/uploads/a.png")},
+		{"empty responses", noContent, gzipRequest("/")},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		Gzip(tt.handler).ServeHTTP(w, tt.req)
+		if w.Header().Get("Content-Encoding") != "" {
+			t.Errorf("%s: expected no compression", tt.name)
+		}
+		if w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("%s: expected Vary: Accept-Encoding", tt.name)
+		}
+	}
+}
+
+func TestGzipKeepsStatus(t *testing.T) {
+	w := httptest.NewRecorder()
+	Gzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})).ServeHTTP(w, gzipRequest("/missing"))
+
+	if w.Code != http.StatusNotFound || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("Expected a gzipped 404, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	for header, want := range map[string]bool{
+		"gzip":              true,
+		"deflate, gzip;q=1": true,
+		"GZIP":              true,
+		"gzip;q=0":          false,
+		"br, deflate":       false,
+		"":                  false,
+	} {
+		if got := acceptsGzip(header); got != want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// maxPreviewBytes limits the form body sent for a Markdown preview
+const maxPreviewBytes = 256 << 10
+
+// Preview renders the submitted Markdown content as sanitized HTML for the
+// new post form's live preview panel
+func (h *Handler) Preview(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxPreviewBytes)
+	if err := r.ParseForm(); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Post is too long to preview", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	templates.MarkdownPreview(models.RenderMarkdown(r.FormValue("content"))).Render(r.Context(), w)
+}
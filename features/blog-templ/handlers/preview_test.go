@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+func previewRequest(content string) *http.Request {
+	form := url.Values{"content": {content}}
+	req := httptest.NewRequest("POST", "/preview", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return asUser(req, testUser)
+}
+
+func TestPreviewRendersMarkdown(t *testing.T) {
+	handler := New(models.NewStore())
+
+	w := httptest.NewRecorder()
+	handler.Preview(w, previewRequest("## Hello\n\nSome **bold** <script>alert(1)</script>"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "<h2>Hello</h2>") || !strings.Contains(body, "<strong>bold</strong>") {
+		t.Errorf("Expected rendered Markdown, got %s", body)
+	}
+	if strings.Contains(body, "<script>") {
+		t.Error("Expected raw HTML to be escaped")
+	}
+}
+
+func TestPreviewEmptyContent(t *testing.T) {
+	handler := New(models.NewStore())
+
+	w := httptest.NewRecorder()
+	handler.Preview(w, previewRequest(""))
+
+	if !strings.Contains(w.Body.String(), "preview-empty") {
+		t.Error("Expected the empty preview hint")
+	}
+}
+
+func TestPreviewTooLarge(t *testing.T) {
+	handler := New(models.NewStore())
+
+	w := httptest.NewRecorder()
+	handler.Preview(w, previewRequest(strings.Repeat("a", maxPreviewBytes)))
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413, got %d", w.Code)
+	}
+}
+
+func TestNewPostFormHasPreview(t *testing.T) {
+	handler := New(models.NewStore())
+
+	w := httptest.NewRecorder()
+	handler.NewPostForm(w, asUser(httptest.NewRequest("GET", "/new", nil), testUser))
+
+	body := w.Body.String()
+	if !strings.Contains(body, `hx-post="/preview"`) || !strings.Contains(body, `id="markdown-preview"`) {
+		t.Error("Expected the content field to drive a preview panel")
+	}
+}
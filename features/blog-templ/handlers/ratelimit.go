@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimiter allows one action per client within a fixed interval
+type rateLimiter struct {
+	interval time.Duration
+	mu       sync.Mutex
+	last     map[string]time.Time
+	now      func() time.Time
+}
+
+// newRateLimiter creates a limiter; a zero interval disables limiting
+func newRateLimiter(interval time.Duration) *rateLimiter {
+	return &rateLimiter{
+		interval: interval,
+		last:     make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// allow records an action for key and returns zero if it is permitted,
+// or how long the client still has to wait
+func (l *rateLimiter) allow(key string) time.Duration {
+	if l.interval <= 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if last, ok := l.last[key]; ok {
+		if wait := l.interval - now.Sub(last); wait > 0 {
+			return wait
+		}
+	}
+
+	l.last[key] = now
+
+	// Drop stale entries so the map doesn't grow without bound
+	for k, t := range l.last {
+		if now.Sub(t) >= l.interval {
+			delete(l.last, k)
+		}
+	}
+
+	return 0
+}
+
+// clientIP returns the remote host of the request without the port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// tokenBucket limits each client to a steady rate of requests with room
+// for short bursts. A bucket holds up to burst tokens, refills at rate
+// tokens per second, and every request spends one.
+type tokenBucket struct {
+	rate      float64
+	burst     float64
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// bucket is one client's remaining tokens as of last
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket allows perMinute requests per client with bursts of up to
+// burst requests; a zero rate disables limiting
+func newTokenBucket(perMinute, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// take spends a token for key and returns zero if the request is allowed,
+// or how long until the client has a token again
+func (l *tokenBucket) take(key string) time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return 0
+}
+
+// prune drops buckets that have refilled completely, since a new bucket
+// starts full anyway. It runs at most once per refill period.
+func (l *tokenBucket) prune(now time.Time) {
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	if now.Sub(l.lastPrune) < refill {
+		return
+	}
+	l.lastPrune = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RateLimit rejects clients that exceed the request rate set with
+// WithRateLimit, answering 429 with a Retry-After header
+func (h *Handler) RateLimit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if wait := h.requestLimiter.take(clientIP(r)); wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "Too many requests. Please slow down.", http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
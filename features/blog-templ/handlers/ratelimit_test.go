@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(10 * time.Second)
+	limiter.now = func() time.Time { return now }
+
+	if wait := limiter.allow("1.2.3.4"); wait != 0 {
+		t.Fatalf("Expected first action to be allowed, got wait %v", wait)
+	}
+	if wait := limiter.allow("1.2.3.4"); wait != 10*time.Second {
+		t.Errorf("Expected wait of 10s, got %v", wait)
+	}
+	if wait := limiter.allow("5.6.7.8"); wait != 0 {
+		t.Error("Expected other clients to be unaffected")
+	}
+
+	now = now.Add(10 * time.Second)
+	if wait := limiter.allow("1.2.3.4"); wait != 0 {
+		t.Errorf("Expected action to be allowed after the interval, got wait %v", wait)
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	limiter := newRateLimiter(0)
+
+	for i := 0; i < 3; i++ {
+		if wait := limiter.allow("1.2.3.4"); wait != 0 {
+			t.Fatalf("Expected disabled limiter to allow everything, got wait %v", wait)
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	limiter := newTokenBucket(60, 3)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if wait := limiter.take("1.2.3.4"); wait != 0 {
+			t.Fatalf("Expected burst request %d to be allowed, got wait %v", i+1, wait)
+		}
+	}
+	if wait := limiter.take("1.2.3.4"); wait != time.Second {
+		t.Errorf("Expected wait of 1s once the burst is spent, got %v", wait)
+	}
+	if wait := limiter.take("5.6.7.8"); wait != 0 {
+		t.Error("Expected other clients to be unaffected")
+	}
+
+	// One token refills per second at 60 requests per minute
+	now = now.Add(time.Second)
+	if wait := limiter.take("1.2.3.4"); wait != 0 {
+		t.Errorf("Expected a refilled token to be allowed, got wait %v", wait)
+	}
+	if wait := limiter.take("1.2.3.4"); wait == 0 {
+		t.Error("Expected only one token to have refilled")
+	}
+
+	// Idle clients refill to the burst size, no further
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if wait := limiter.take("1.2.3.4"); wait != 0 {
+			t.Fatalf("Expected request %d after idling to be allowed, got wait %v", i+1, wait)
+		}
+	}
+	if wait := limiter.take("1.2.3.4"); wait == 0 {
+		t.Error("Expected the bucket to hold no more than the burst")
+	}
+}
+
+func TestTokenBucketPrunesFullBuckets(t *testing.T) {
+	now := time.Now()
+	limiter := newTokenBucket(60, 5)
+	limiter.now = func() time.Time { return now }
+
+	limiter.take("1.2.3.4")
+	now = now.Add(time.Minute)
+	limiter.take("5.6.7.8")
+
+	if _, ok := limiter.buckets["1.2.3.4"]; ok {
+		t.Error("Expected the refilled bucket to be pruned")
+	}
+	if len(limiter.buckets) != 1 {
+		t.Errorf("Expected 1 bucket, got %d", len(limiter.buckets))
+	}
+}
+
+func TestTokenBucketDisabled(t *testing.T) {
+	limiter := newTokenBucket(0, 1)
+
+	for i := 0; i < 3; i++ {
+		if wait := limiter.take("1.2.3.4"); wait != 0 {
+			t.Fatalf("Expected disabled limiter to allow everything, got wait %v", wait)
+		}
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	handler := New(models.NewStore(), WithRateLimit(30, 2))
+	search := handler.RateLimit(handler.Search)
+
+	codes := make([]int, 3)
+	for i := range codes {
+		req := httptest.NewRequest("GET", "/search?q=go", nil)
+		w := httptest.NewRecorder()
+		search(w, req)
+		codes[i] = w.Code
+
+		if w.Code == http.StatusTooManyRequests {
+			if retry := w.Header().Get("Retry-After"); retry != "2" {
+				t.Errorf("Expected Retry-After: 2, got %q", retry)
+			}
+		}
+	}
+
+	want := []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}
+	for i := range want {
+		if codes[i] != want[i] {
+			t.Errorf("Request %d: expected status %d, got %d", i+1, want[i], codes[i])
+		}
+	}
+
+	// Limits are per client
+	req := httptest.NewRequest("GET", "/search?q=go", nil)
+	req.RemoteAddr = "10.0.0.9:4321"
+	w := httptest.NewRecorder()
+	search(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected another client to be allowed, got %d", w.Code)
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// React records a reader's reaction to a post, once per reaction and
+// browser session, and responds with out-of-band swaps that update every
+// reaction bar for the post on the page
+func (h *Handler) React(w http.ResponseWriter, r *http.Request) {
+	post, ok := h.lookupPost(w, r)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	reaction := models.Reaction(r.FormValue("reaction"))
+	if reaction == "" {
+		reaction = models.ReactionLike
+	}
+	if !models.ValidReaction(reaction) {
+		http.Error(w, models.ErrInvalidReaction.Error(), http.StatusBadRequest)
+		return
+	}
+
+	visitor, ok := h.visitorID(w, r)
+	if !ok {
+		http.Error(w, "Failed to identify visitor", http.StatusInternalServerError)
+		return
+	}
+
+	if h.reactions.first(fmt.Sprintf("%s:%d:%s", visitor, post.ID, reaction)) {
+		updated, err := h.store.React(post.ID, reaction)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		post = updated
+		h.trending.Record(post.ID, models.ActivityReaction)
+	}
+
+	templates.ReactionBarUpdate(post).Render(r.Context(), w)
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+func newReactRequest(id, reaction string, cookies ...*http.Cookie) *http.Request {
+	form := url.Values{}
+	if reaction != "" {
+		form.Set("reaction", reaction)
+	}
+	req := httptest.NewRequest("POST", "/posts/"+id+"/like", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetPathValue("id", id)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	return req
+}
+
+func TestReactCountsOncePerSession(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+
+	w := httptest.NewRecorder()
+	handler.React(w, newReactRequest("1", ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	cookies := w.Result().Cookies()
+
+	// The response swaps the updated bar into every copy on the page
+	body := w.Body.String()
+	if !strings.Contains(body, `hx-swap-oob="outerHTML:.reactions-1"`) {
+		t.Errorf("Expected an out-of-band swap, got:\n%s", body)
+	}
+	if !strings.Contains(body, `<span class="reaction-count">1</span>`) {
+		t.Error("Expected the like count in the response")
+	}
+
+	// Liking again from the same session is ignored
+	handler.React(httptest.NewRecorder(), newReactRequest("1", "", cookies...))
+	// A different reaction from the same session counts
+	handler.React(httptest.NewRecorder(), newReactRequest("1", "🎉", cookies...))
+	// Another reader's like counts
+	handler.React(httptest.NewRecorder(), newReactRequest("1", string(models.ReactionLike)))
+
+	post, _ := store.GetByID(1)
+	if post.Reactions[models.ReactionLike] != 2 || post.Reactions["🎉"] != 1 {
+		t.Errorf("Unexpected reaction counts: %v", post.Reactions)
+	}
+}
+
+func TestReactErrors(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+	draft := addDraft(t, store)
+
+	tests := []struct {
+		name     string
+		id       string
+		reaction string
+		status   int
+	}{
+		{name: "unknown reaction", id: "1", reaction: "💩", status: http.StatusBadRequest},
+		{name: "missing post", id: "999", status: http.StatusNotFound},
+		{name: "draft", id: strconv.Itoa(draft), status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.React(w, newReactRequest(tt.id, tt.reaction))
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+}
+
+func TestPostCardShowsReactions(t *testing.T) {
+	store := models.NewStore()
+	store.React(2, "❤️")
+	handler := New(store)
+
+	w := httptest.NewRecorder()
+	handler.Index(w, httptest.NewRequest("GET", "/", nil))
+
+	body := w.Body.String()
+	if !strings.Contains(body, `class="reactions reactions-2"`) {
+		t.Fatal("Expected a reaction bar on the post card")
+	}
+	if !strings.Contains(body, `hx-post="/posts/2/like"`) {
+		t.Error("Expected reaction buttons to post to the like endpoint")
+	}
+	if strings.Contains(body, "hx-swap-oob") {
+		t.Error("Expected no out-of-band swaps in a full page")
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// Revisions lists the previous versions of one of the logged-in author's posts
+func (h *Handler) Revisions(w http.ResponseWriter, r *http.Request) {
+	post, ok := h.lookupAuthorPost(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.store.Revisions(post.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	templates.RevisionsPage(post, revisions).Render(r.Context(), w)
+}
+
+// Revision shows how an old version differs from the current post
+func (h *Handler) Revision(w http.ResponseWriter, r *http.Request) {
+	post, ok := h.lookupAuthorPost(w, r)
+	if !ok {
+		return
+	}
+
+	number, err := strconv.Atoi(r.PathValue("rev"))
+	if err != nil {
+		http.Error(w, "Invalid revision number", http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := h.store.Revisions(post.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, rev := range revisions {
+		if rev.Number == number {
+			diff := models.DiffLines(rev.Content, post.Content)
+			templates.RevisionPage(post, rev, diff).Render(r.Context(), w)
+			return
+		}
+	}
+
+	http.NotFound(w, r)
+}
+
+// RestoreRevision puts an old version of a post back and shows the post
+func (h *Handler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	post, ok := h.lookupAuthorPost(w, r)
+	if !ok {
+		return
+	}
+
+	number, err := strconv.Atoi(r.PathValue("rev"))
+	if err != nil {
+		http.Error(w, "Invalid revision number", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.store.RestoreRevision(post.ID, number); errors.Is(err, models.ErrRevisionNotFound) {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, post.URL(), http.StatusSeeOther)
+}
+
+// lookupAuthorPost is lookupPost for pages only the post's author may use
+func (h *Handler) lookupAuthorPost(w http.ResponseWriter, r *http.Request) (models.Post, bool) {
+	post, ok := h.lookupPost(w, r)
+	if !ok {
+		return models.Post{}, false
+	}
+	if !isAuthor(r, post) {
+		http.Error(w, "Only the author can change this post", http.StatusForbidden)
+		return models.Post{}, false
+	}
+	return post, true
+}
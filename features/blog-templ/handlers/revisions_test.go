@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+// addEditedPost stores a post by testUser, edits it once and returns its ID
+func addEditedPost(t *testing.T, store *models.MemoryStore) int {
+	t.Helper()
+
+	id := addDraft(t, store)
+	post, _ := store.GetByID(id)
+	post.Title = "Edited Draft"
+	post.Content = "Work in progress\nNow with a second line"
+	if _, err := store.Update(post); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func revisionRequest(method, target string, id int, rev string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	req.SetPathValue("id", strconv.Itoa(id))
+	if rev != "" {
+		req.SetPathValue("rev", rev)
+	}
+	return req
+}
+
+func TestRevisionsPage(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+	id := addEditedPost(t, store)
+
+	w := httptest.NewRecorder()
+	handler.Revisions(w, asUser(revisionRequest("GET", "/posts/x/revisions", id, ""), testUser))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "Revision 1") || !strings.Contains(body, "Secret Draft") {
+		t.Error("Expected the original version in the history")
+	}
+	if !strings.Contains(body, "/revisions/1") {
+		t.Error("Expected a link to the revision's diff")
+	}
+}
+
+func TestRevisionsAreAuthorOnly(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+	id := addEditedPost(t, store)
+
+	// Sample posts are published, so others can see the post but not its history
+	published := store.GetAll()[len(store.GetAll())-1]
+	w := httptest.NewRecorder()
+	handler.Revisions(w, asUser(revisionRequest("GET", "/", published.ID, ""), testUser))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for another author's post, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.RestoreRevision(w, revisionRequest("POST", "/", id, "1"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a draft without login, got %d", w.Code)
+	}
+}
+
+func TestRevisionDiff(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+	id := addEditedPost(t, store)
+
+	w := httptest.NewRecorder()
+	handler.Revision(w, asUser(revisionRequest("GET", "/", id, "1"), testUser))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `<div class="diff-line diff-insert">+ Now with a second line</div>`) {
+		t.Error("Expected the added line in the diff")
+	}
+	if !strings.Contains(body, "Title: Secret Draft → Edited Draft") {
+		t.Error("Expected the title change")
+	}
+	if !strings.Contains(body, `action="/posts/`+strconv.Itoa(id)+`/revisions/1/restore"`) {
+		t.Error("Expected a restore form")
+	}
+
+	for _, rev := range []string{"7", "abc"} {
+		w = httptest.NewRecorder()
+		handler.Revision(w, asUser(revisionRequest("GET", "/", id, rev), testUser))
+		if w.Code == http.StatusOK {
+			t.Errorf("rev %q: expected an error, got 200", rev)
+		}
+	}
+}
+
+func TestRestoreRevisionHandler(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+	id := addEditedPost(t, store)
+
+	w := httptest.NewRecorder()
+	handler.RestoreRevision(w, asUser(revisionRequest("POST", "/", id, "1"), testUser))
+
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status 303, got %d", w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc != "/posts/secret-draft" {
+		t.Errorf("Expected redirect to the post, got %s", loc)
+	}
+
+	post, _ := store.GetByID(id)
+	if post.Title != "Secret Draft" {
+		t.Errorf("Expected the original title back, got %q", post.Title)
+	}
+
+	w = httptest.NewRecorder()
+	handler.RestoreRevision(w, asUser(revisionRequest("POST", "/", id, "99"), testUser))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a missing revision, got %d", w.Code)
+	}
+}
+
+func TestPostDetailLinksHistoryForAuthor(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+	id := addEditedPost(t, store)
+	store.Publish(id)
+
+	for _, author := range []bool{true, false} {
+		req := revisionRequest("GET", "/", id, "")
+		if author {
+			req = asUser(req, testUser)
+		}
+		w := httptest.NewRecorder()
+		handler.PostDetail(w, req)
+
+		if got := strings.Contains(w.Body.String(), "/revisions\""); got != author {
+			t.Errorf("author=%v: expected history link %v, got %v", author, author, got)
+		}
+	}
+}
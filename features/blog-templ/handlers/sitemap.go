@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// sitemapDateFormat is the W3C datetime format used for <lastmod>
+const sitemapDateFormat = "2006-01-02"
+
+// urlSet is the root element of a sitemap document
+type urlSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// TagPosts handles the landing page for a single tag
+func (h *Handler) TagPosts(w http.ResponseWriter, r *http.Request) {
+	tag := r.PathValue("tag")
+	posts := models.PublishedOnly(h.store.ByTag(tag))
+	if len(posts) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	templates.TagPage(tag, posts).Render(r.Context(), w)
+}
+
+// Sitemap lists the index, category and tag pages and post pages for
+// search engines
+func (h *Handler) Sitemap(w http.ResponseWriter, r *http.Request) {
+	base := h.siteURL(r)
+	posts := models.PublishedOnly(h.store.GetAll())
+
+	var (
+		newest          time.Time
+		tagOrder        []string
+		tagNames        = make(map[string]string)
+		tagUpdated      = make(map[string]time.Time)
+		categoryUpdated = make(map[string]time.Time)
+		postURLs        []sitemapURL
+	)
+
+	for _, post := range posts {
+		modified := post.LastModified()
+		if modified.After(newest) {
+			newest = modified
+		}
+
+		postURLs = append(postURLs, sitemapURL{
+			Loc:     postURL(base, post),
+			LastMod: modified.Format(sitemapDateFormat),
+		})
+
+		if post.Category != "" && modified.After(categoryUpdated[post.Category]) {
+			categoryUpdated[post.Category] = modified
+		}
+
+		// Tags are matched case-insensitively, so list each one once
+		for _, tag := range post.Tags {
+			key := strings.ToLower(tag)
+			if _, seen := tagNames[key]; !seen {
+				tagNames[key] = tag
+				tagOrder = append(tagOrder, key)
+			}
+			if modified.After(tagUpdated[key]) {
+				tagUpdated[key] = modified
+			}
+		}
+	}
+
+	index := sitemapURL{Loc: base + "/"}
+	if !newest.IsZero() {
+		index.LastMod = newest.Format(sitemapDateFormat)
+	}
+
+	set := urlSet{URLs: []sitemapURL{index}}
+	for _, category := range h.categories.All() {
+		if updated, ok := categoryUpdated[category.Slug]; ok {
+			set.URLs = append(set.URLs, sitemapURL{
+				Loc:     base + category.URL(),
+				LastMod: updated.Format(sitemapDateFormat),
+			})
+		}
+	}
+	for _, key := range tagOrder {
+		set.URLs = append(set.URLs, sitemapURL{
+			Loc:     base + "/tags/" + url.PathEscape(tagNames[key]),
+			LastMod: tagUpdated[key].Format(sitemapDateFormat),
+		})
+	}
+	set.URLs = append(set.URLs, postURLs...)
+
+	writeXML(w, "application/xml; charset=utf-8", set)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+func TestSitemapHandler(t *testing.T) {
+	handler := New(models.NewStore(), WithBaseURL("https://blog.example.com"))
+
+	req := httptest.NewRequest("GET", "/sitemap.xml", nil)
+	w := httptest.NewRecorder()
+
+	handler.Sitemap(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/xml") {
+		t.Errorf("Expected XML content type, got %s", ct)
+	}
+
+	var set urlSet
+	if err := xml.Unmarshal(w.Body.Bytes(), &set); err != nil {
+		t.Fatalf("Sitemap is not valid XML: %v", err)
+	}
+
+	locs := make(map[string]string)
+	for _, u := range set.URLs {
+		if _, dup := locs[u.Loc]; dup {
+			t.Errorf("Duplicate sitemap entry: %s", u.Loc)
+		}
+		locs[u.Loc] = u.LastMod
+	}
+
+	expected := []string{
+		"https://blog.example.com/",
+		"https://blog.example.com/posts/getting-started-with-templ-and-htmx",
+		"https://blog.example.com/posts/type-safe-html-templates",
+		"https://blog.example.com/tags/htmx",
+		"https://blog.example.com/tags/web%20development",
+		"https://blog.example.com/categories/tutorials",
+	}
+	for _, loc := range expected {
+		lastMod, ok := locs[loc]
+		if !ok {
+			t.Errorf("Sitemap missing %s", loc)
+			continue
+		}
+		if lastMod == "" {
+			t.Errorf("Expected lastmod for %s", loc)
+		}
+	}
+
+	// 1 index + 2 categories + 8 distinct tags + 4 posts
+	if len(set.URLs) != 15 {
+		t.Errorf("Expected 15 URLs, got %d", len(set.URLs))
+	}
+}
+
+func TestSitemapUsesUpdatedAt(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store, WithBaseURL("https://blog.example.com"))
+
+	store.Add(models.Post{Title: "Fresh", Content: "Just written", Published: true})
+	post := store.GetAll()[0]
+
+	req := httptest.NewRequest("GET", "/sitemap.xml", nil)
+	w := httptest.NewRecorder()
+	handler.Sitemap(w, req)
+
+	want := "<loc>https://blog.example.com" + post.URL() + "</loc>\n    <lastmod>" + post.LastModified().Format(sitemapDateFormat) + "</lastmod>"
+	if !strings.Contains(w.Body.String(), want) {
+		t.Errorf("Expected lastmod from the post's last modification, body:\n%s", w.Body.String())
+	}
+}
+
+func TestTagPostsHandler(t *testing.T) {
+	handler := New(models.NewStore())
+
+	req := httptest.NewRequest("GET", "/tags/htmx", nil)
+	req.SetPathValue("tag", "htmx")
+	w := httptest.NewRecorder()
+
+	handler.TagPosts(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	body := w.Body.String()
+	if !strings.Contains(body, "Building Real-time Search with HTMX") {
+		t.Error("Expected tagged post in response")
+	}
+	if strings.Contains(body, "Why Go is Great for Web Development") {
+		t.Error("Expected untagged post to be excluded")
+	}
+
+	req = httptest.NewRequest("GET", "/tags/unknown", nil)
+	req.SetPathValue("tag", "unknown")
+	w = httptest.NewRecorder()
+
+	handler.TagPosts(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown tag, got %d", w.Code)
+	}
+}
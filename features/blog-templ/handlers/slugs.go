@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+// lookupPostPage resolves the {id} path value of a post page, which may be
+// the post's ID or its slug. A slug the post had before it was renamed is
+// redirected permanently to the post's URL, so old links and search
+// results keep working; false is returned then too.
+func (h *Handler) lookupPostPage(w http.ResponseWriter, r *http.Request) (models.Post, bool) {
+	slug := r.PathValue("id")
+	if _, err := strconv.Atoi(slug); err == nil {
+		return h.lookupPost(w, r)
+	}
+
+	post, err := h.store.BySlug(slug)
+	if errors.Is(err, models.ErrPostNotFound) {
+		http.NotFound(w, r)
+		return models.Post{}, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return models.Post{}, false
+	}
+
+	if !post.Published && !isAuthor(r, post) {
+		http.NotFound(w, r)
+		return models.Post{}, false
+	}
+
+	if post.Slug != slug {
+		target := post.URL()
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+		return models.Post{}, false
+	}
+
+	return post, true
+}
+
+// ChangeSlug lets a post's author rename the slug in its URL, then shows
+// the post at its new URL. The old slug redirects to the new one.
+func (h *Handler) ChangeSlug(w http.ResponseWriter, r *http.Request) {
+	post, ok := h.lookupAuthorPost(w, r)
+	if !ok {
+		return
+	}
+
+	slug := strings.TrimSpace(r.FormValue("slug"))
+	post, err := h.store.SetSlug(post.ID, slug)
+	switch {
+	case errors.Is(err, models.ErrInvalidSlug):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, models.ErrSlugTaken):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, post.URL(), http.StatusSeeOther)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+func slugRequest(id int, slug string) *http.Request {
+	form := url.Values{"slug": {slug}}
+	req := httptest.NewRequest("POST", "/posts/"+strconv.Itoa(id)+"/slug", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetPathValue("id", strconv.Itoa(id))
+	return req
+}
+
+func getPostPage(handler *Handler, slug string, user *models.User) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/posts/"+slug+"?ref=feed", nil)
+	req.SetPathValue("id", slug)
+	if user != nil {
+		req = asUser(req, *user)
+	}
+	w := httptest.NewRecorder()
+	handler.PostDetail(w, req)
+	return w
+}
+
+func TestPostDetailBySlug(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+
+	w := getPostPage(handler, "type-safe-html-templates", nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Type-Safe HTML Templates") {
+		t.Fatalf("Expected the post by its slug, got %d", w.Code)
+	}
+
+	// Renamed slugs redirect permanently, keeping the query string
+	store.SetSlug(4, "templ-types")
+	w = getPostPage(handler, "type-safe-html-templates", nil)
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/posts/templ-types?ref=feed" {
+		t.Errorf("Expected a 301 to the new URL, got %d %s", w.Code, w.Header().Get("Location"))
+	}
+
+	if w := getPostPage(handler, "no-such-post", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestPostDetailBySlugHidesDrafts(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+	id := addDraft(t, store)
+	store.SetSlug(id, "hidden")
+
+	for _, slug := range []string{"hidden", "secret-draft"} {
+		if w := getPostPage(handler, slug, nil); w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404, got %d", slug, w.Code)
+		}
+	}
+	if w := getPostPage(handler, "hidden", &testUser); w.Code != http.StatusOK {
+		t.Errorf("Expected the author to see their draft, got %d", w.Code)
+	}
+}
+
+func TestChangeSlug(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+	id := addDraft(t, store)
+
+	w := httptest.NewRecorder()
+	handler.ChangeSlug(w, asUser(slugRequest(id, " my-draft "), testUser))
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/posts/my-draft" {
+		t.Fatalf("Expected a redirect to the new URL, got %d %s", w.Code, w.Header().Get("Location"))
+	}
+	if post, _ := store.GetByID(id); post.Slug != "my-draft" {
+		t.Errorf("Expected the slug to change, got %q", post.Slug)
+	}
+
+	// The author sees the form on the post page
+	if body := getPostPage(handler, "my-draft", &testUser).Body.String(); !strings.Contains(body, `name="slug" value="my-draft"`) {
+		t.Error("Expected the slug form for the author")
+	}
+	if body := getPostPage(handler, "type-safe-html-templates", &testUser).Body.String(); strings.Contains(body, `name="slug"`) {
+		t.Error("Expected no slug form on other authors' posts")
+	}
+}
+
+func TestChangeSlugErrors(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+	id := addDraft(t, store)
+	jane := models.User{Username: "jane", DisplayName: "Jane Doe"}
+
+	tests := []struct {
+		name   string
+		req    *http.Request
+		status int
+	}{
+		{"invalid slug", asUser(slugRequest(id, "Not A Slug"), testUser), http.StatusBadRequest},
+		{"numeric slug", asUser(slugRequest(id, "42"), testUser), http.StatusBadRequest},
+		{"taken slug", asUser(slugRequest(id, "type-safe-html-templates"), testUser), http.StatusConflict},
+		{"not the author", asUser(slugRequest(4, "mine-now"), jane), http.StatusForbidden},
+		{"unknown post", asUser(slugRequest(999, "anything"), testUser), http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ChangeSlug(w, tt.req)
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+
+	if post, _ := store.GetByID(id); post.Slug != "secret-draft" {
+		t.Errorf("Expected the slug unchanged, got %q", post.Slug)
+	}
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// WithSubscriberStore sets the store of email subscribers
+func WithSubscriberStore(subscribers models.SubscriberStore) Option {
+	return func(h *Handler) {
+		h.subscribers = subscribers
+	}
+}
+
+// WithMailer enables email subscriptions, sending confirmations and
+// new-post notifications through mailer. Without a mailer the subscription
+// endpoints return 404 and the subscribe form is hidden.
+func WithMailer(mailer models.Mailer) Option {
+	return func(h *Handler) {
+		h.mailer = mailer
+	}
+}
+
+// Subscribe starts a subscription and emails the confirmation link
+func (h *Handler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	if h.mailer == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	locale := templates.LocaleFromContext(r.Context())
+	sub, err := h.subscribers.Subscribe(r.FormValue("email"), string(locale))
+	switch {
+	case errors.Is(err, models.ErrInvalidEmail):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, models.ErrAlreadySubscribed):
+		// Answer as for a new address so the form doesn't reveal who subscribes
+		templates.SubscribeStatus().Render(r.Context(), w)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	confirmURL := h.siteURL(r) + "/subscribe/confirm?token=" + url.QueryEscape(sub.Token)
+	if err := h.mailer.Send(templates.ConfirmationEmail(r.Context(), sub.Email, confirmURL)); err != nil {
+		h.logger.LogAttrs(r.Context(), slog.LevelError, "sending confirmation email failed",
+			slog.String("request_id", RequestIDFromContext(r.Context())),
+			slog.String("to", sub.Email),
+			slog.Any("error", err),
+		)
+		http.Error(w, "Couldn't send the confirmation email. Please try again later.", http.StatusBadGateway)
+		return
+	}
+
+	templates.SubscribeStatus().Render(r.Context(), w)
+}
+
+// ConfirmSubscription activates a subscription from the emailed link
+func (h *Handler) ConfirmSubscription(w http.ResponseWriter, r *http.Request) {
+	if h.mailer == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	sub, err := h.subscribers.Confirm(r.URL.Query().Get("token"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		templates.SubscriptionNotFound().Render(r.Context(), w)
+		return
+	}
+
+	templates.SubscriptionConfirmed(sub).Render(r.Context(), w)
+}
+
+// UnsubscribeForm asks the reader to confirm unsubscribing. Following the
+// emailed link alone doesn't unsubscribe, so link scanners in mail clients
+// can't end a subscription by prefetching it.
+func (h *Handler) UnsubscribeForm(w http.ResponseWriter, r *http.Request) {
+	if h.mailer == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	sub, err := h.subscribers.Get(r.URL.Query().Get("token"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		templates.SubscriptionNotFound().Render(r.Context(), w)
+		return
+	}
+
+	templates.UnsubscribePage(sub).Render(r.Context(), w)
+}
+
+// Unsubscribe ends a subscription
+func (h *Handler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	if h.mailer == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.subscribers.Unsubscribe(r.FormValue("token")); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		templates.SubscriptionNotFound().Render(r.Context(), w)
+		return
+	}
+
+	templates.Unsubscribed().Render(r.Context(), w)
+}
+
+// announcePost tells live readers and email subscribers about a newly
+// published post
+func (h *Handler) announcePost(r *http.Request, post models.Post) {
+	h.broadcastPost(post)
+	if !post.Published || h.mailer == nil {
+		return
+	}
+
+	// Send in the background so publishing doesn't wait on the mail server
+	base := h.siteURL(r)
+	subscribers := h.subscribers.Confirmed()
+	h.background.Add(1)
+	go func() {
+		defer h.background.Done()
+		h.notifySubscribers(base, post, subscribers)
+	}()
+}
+
+// notifySubscribers emails each subscriber a summary of post in the
+// language they subscribed in
+func (h *Handler) notifySubscribers(base string, post models.Post, subscribers []models.Subscriber) {
+	summary := summarize(post.Content, summaryLength)
+	for _, sub := range subscribers {
+		locale, ok := templates.ParseLocale(sub.Locale)
+		if !ok {
+			locale = templates.DefaultLocale
+		}
+		ctx := templates.ContextWithLocale(context.Background(), locale)
+
+		unsubscribeURL := base + "/unsubscribe?token=" + url.QueryEscape(sub.Token)
+		msg := templates.NewPostEmail(ctx, sub.Email, post, summary, postURL(base, post), unsubscribeURL)
+		if err := h.mailer.Send(msg); err != nil {
+			h.logger.Error("notifying subscriber failed", "to", sub.Email, "post_id", post.ID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// fakeMailer hands sent messages to the test
+type fakeMailer struct {
+	sent chan models.Message
+}
+
+func newFakeMailer() *fakeMailer {
+	return &fakeMailer{sent: make(chan models.Message, 10)}
+}
+
+func (m *fakeMailer) Send(msg models.Message) error {
+	m.sent <- msg
+	return nil
+}
+
+// next waits for the next sent message
+func (m *fakeMailer) next(t *testing.T) models.Message {
+	t.Helper()
+	select {
+	case msg := <-m.sent:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for an email")
+		return models.Message{}
+	}
+}
+
+func subscribeRequest(email string) *http.Request {
+	form := url.Values{"email": {email}}
+	req := httptest.NewRequest("POST", "/subscribe", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+// tokenFrom extracts the token query parameter from the link in an email
+func tokenFrom(t *testing.T, body, path string) string {
+	t.Helper()
+	start := strings.Index(body, path+"?token=")
+	if start < 0 {
+		t.Fatalf("Expected a %s link in %q", path, body)
+	}
+	link := strings.Fields(body[start:])[0]
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query().Get("token")
+}
+
+func TestSubscribeSendsConfirmation(t *testing.T) {
+	mailer := newFakeMailer()
+	subscribers := models.NewSubscriberStore()
+	handler := New(models.NewStore(), WithMailer(mailer), WithSubscriberStore(subscribers), WithBaseURL("https://blog.example.com"))
+
+	w := httptest.NewRecorder()
+	handler.Subscribe(w, subscribeRequest("reader@example.com"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	msg := mailer.next(t)
+	if msg.To != "reader@example.com" {
+		t.Errorf("Expected the confirmation to go to the reader, got %s", msg.To)
+	}
+	token := tokenFrom(t, msg.Body, "https://blog.example.com/subscribe/confirm")
+
+	// Nothing is sent to unconfirmed addresses
+	if len(subscribers.Confirmed()) != 0 {
+		t.Fatal("Expected the subscription to wait for confirmation")
+	}
+
+	w = httptest.NewRecorder()
+	handler.ConfirmSubscription(w, httptest.NewRequest("GET", "/subscribe/confirm?token="+url.QueryEscape(token), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 confirming, got %d", w.Code)
+	}
+	if len(subscribers.Confirmed()) != 1 {
+		t.Error("Expected the subscription to be confirmed")
+	}
+}
+
+func TestSubscribeInvalidEmail(t *testing.T) {
+	handler := New(models.NewStore(), WithMailer(newFakeMailer()))
+
+	w := httptest.NewRecorder()
+	handler.Subscribe(w, subscribeRequest("nope"))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+// brokenMailer fails every send
+type brokenMailer struct{}
+
+func (brokenMailer) Send(models.Message) error {
+	return errors.New("connection refused")
+}
+
+func TestSubscribeMailFailure(t *testing.T) {
+	var logs bytes.Buffer
+	handler := New(models.NewStore(), WithMailer(brokenMailer{}), WithLogger(jsonLogger(&logs)))
+
+	w := httptest.NewRecorder()
+	handler.Subscribe(w, subscribeRequest("reader@example.com"))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("Expected status 502, got %d", w.Code)
+	}
+	if !strings.Contains(logs.String(), `"to":"reader@example.com"`) || !strings.Contains(logs.String(), "connection refused") {
+		t.Errorf("Expected the failure to be logged, got %q", logs.String())
+	}
+}
+
+func TestSubscribeAlreadySubscribed(t *testing.T) {
+	mailer := newFakeMailer()
+	subscribers := models.NewSubscriberStore()
+	sub, _ := subscribers.Subscribe("reader@example.com", "en")
+	subscribers.Confirm(sub.Token)
+	handler := New(models.NewStore(), WithMailer(mailer), WithSubscriberStore(subscribers))
+
+	// The reply doesn't reveal that the address is subscribed, and no email goes out
+	w := httptest.NewRecorder()
+	handler.Subscribe(w, subscribeRequest("reader@example.com"))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if len(mailer.sent) != 0 {
+		t.Error("Expected no email for an existing subscriber")
+	}
+}
+
+func TestSubscriptionsDisabledWithoutMailer(t *testing.T) {
+	handler := New(models.NewStore())
+
+	w := httptest.NewRecorder()
+	handler.Subscribe(w, subscribeRequest("reader@example.com"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.Index(w, httptest.NewRequest("GET", "/", nil))
+	if strings.Contains(w.Body.String(), "subscribe-box") {
+		t.Error("Expected no subscribe form without a mailer")
+	}
+}
+
+func TestIndexShowsSubscribeForm(t *testing.T) {
+	handler := New(models.NewStore(), WithMailer(newFakeMailer()))
+
+	w := httptest.NewRecorder()
+	handler.Index(w, httptest.NewRequest("GET", "/", nil))
+	if !strings.Contains(w.Body.String(), `hx-post="/subscribe"`) {
+		t.Error("Expected the subscribe form on the home page")
+	}
+}
+
+func TestPublishNotifiesSubscribers(t *testing.T) {
+	mailer := newFakeMailer()
+	subscribers := models.NewSubscriberStore()
+	sub, _ := subscribers.Subscribe("reader@example.com", "ko")
+	subscribers.Confirm(sub.Token)
+	subscribers.Subscribe("pending@example.com", "en")
+
+	store := models.NewStore()
+	handler := New(store, WithMailer(mailer), WithSubscriberStore(subscribers), WithBaseURL("https://blog.example.com"))
+	id := addDraft(t, store)
+
+	req := httptest.NewRequest("POST", "/posts/1/publish", nil)
+	req.SetPathValue("id", strconv.Itoa(id))
+	w := httptest.NewRecorder()
+	handler.PublishPost(w, asUser(req, testUser))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	msg := mailer.next(t)
+	if msg.To != "reader@example.com" {
+		t.Errorf("Expected only the confirmed subscriber to be emailed, got %s", msg.To)
+	}
+	if !strings.Contains(msg.Subject, "Secret Draft") || !strings.Contains(msg.Body, "Work in progress") {
+		t.Errorf("Expected the post title and summary, got %+v", msg)
+	}
+	if !strings.Contains(msg.Body, "https://blog.example.com/posts/secret-draft") {
+		t.Errorf("Expected a link to the post, got %s", msg.Body)
+	}
+	// The email is in the language the reader subscribed in
+	if !strings.HasPrefix(msg.Subject, "새 글") {
+		t.Errorf("Expected a Korean subject, got %s", msg.Subject)
+	}
+	if tokenFrom(t, msg.Body, "https://blog.example.com/unsubscribe") != sub.Token {
+		t.Error("Expected the unsubscribe link to carry the subscriber's token")
+	}
+
+	select {
+	case extra := <-mailer.sent:
+		t.Errorf("Expected one email, also got one to %s", extra.To)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDraftsDontNotifySubscribers(t *testing.T) {
+	mailer := newFakeMailer()
+	subscribers := models.NewSubscriberStore()
+	sub, _ := subscribers.Subscribe("reader@example.com", "en")
+	subscribers.Confirm(sub.Token)
+	handler := New(models.NewStore(), WithMailer(mailer), WithSubscriberStore(subscribers))
+
+	form := url.Values{"title": {"Draft Post"}, "content": {"Later"}, "action": {"draft"}}
+	req := httptest.NewRequest("POST", "/posts", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	handler.CreatePost(httptest.NewRecorder(), asUser(req, testUser))
+
+	select {
+	case msg := <-mailer.sent:
+		t.Errorf("Expected no email for a draft, got %+v", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	subscribers := models.NewSubscriberStore()
+	sub, _ := subscribers.Subscribe("reader@example.com", "en")
+	subscribers.Confirm(sub.Token)
+	handler := New(models.NewStore(), WithMailer(newFakeMailer()), WithSubscriberStore(subscribers))
+
+	// Following the link only asks for confirmation
+	w := httptest.NewRecorder()
+	handler.UnsubscribeForm(w, httptest.NewRequest("GET", "/unsubscribe?token="+url.QueryEscape(sub.Token), nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "reader@example.com") {
+		t.Fatalf("Expected the unsubscribe confirmation, got %d", w.Code)
+	}
+	if len(subscribers.Confirmed()) != 1 {
+		t.Fatal("Expected the link alone not to unsubscribe")
+	}
+
+	form := url.Values{"token": {sub.Token}}
+	req := httptest.NewRequest("POST", "/unsubscribe", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	handler.Unsubscribe(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if len(subscribers.Confirmed()) != 0 {
+		t.Error("Expected the subscription to be removed")
+	}
+
+	w = httptest.NewRecorder()
+	handler.UnsubscribeForm(w, httptest.NewRequest("GET", "/unsubscribe?token="+url.QueryEscape(sub.Token), nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a used link, got %d", w.Code)
+	}
+}
+
+func TestConfirmationEmailLocalized(t *testing.T) {
+	ctx := templates.ContextWithLocale(context.Background(), templates.LocaleKorean)
+	msg := templates.ConfirmationEmail(ctx, "reader@example.com", "https://blog.example.com/subscribe/confirm?token=abc")
+	if !strings.Contains(msg.Subject, "구독") || !strings.Contains(msg.Body, "token=abc") {
+		t.Errorf("Expected a Korean confirmation with the link, got %+v", msg)
+	}
+}
+
+// blockingMailer holds every send until release is closed
+type blockingMailer struct {
+	release chan struct{}
+}
+
+func (m *blockingMailer) Send(models.Message) error {
+	<-m.release
+	return nil
+}
+
+func TestDrainWaitsForEmails(t *testing.T) {
+	mailer := &blockingMailer{release: make(chan struct{})}
+	subscribers := models.NewSubscriberStore()
+	sub, _ := subscribers.Subscribe("reader@example.com", "en")
+	subscribers.Confirm(sub.Token)
+	store := models.NewStore()
+	handler := New(store, WithMailer(mailer), WithSubscriberStore(subscribers))
+	id := addDraft(t, store)
+
+	req := httptest.NewRequest("POST", "/posts/1/publish", nil)
+	req.SetPathValue("id", strconv.Itoa(id))
+	handler.PublishPost(httptest.NewRecorder(), asUser(req, testUser))
+
+	// While the email is stuck, Drain gives up when its context ends
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := handler.Drain(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected Drain to time out, got %v", err)
+	}
+
+	close(mailer.release)
+	if err := handler.Drain(context.Background()); err != nil {
+		t.Errorf("Expected Drain to return once the email is sent, got %v", err)
+	}
+}
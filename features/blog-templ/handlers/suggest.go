@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// suggestCount is how many post titles, and how many tags, the search
+// suggestions list at most
+const suggestCount = 5
+
+// Suggest completes what the reader has typed in the search box with
+// matching post titles and tags. An empty query gets an empty list, which
+// hides the dropdown.
+func (h *Handler) Suggest(w http.ResponseWriter, r *http.Request) {
+	suggestions := h.store.Suggest(r.URL.Query().Get("q"), suggestCount)
+	templates.SuggestionList(suggestions).Render(r.Context(), w)
+}
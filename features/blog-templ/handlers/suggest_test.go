@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+func TestSuggestHandler(t *testing.T) {
+	handler := New(models.NewStore())
+
+	w := httptest.NewRecorder()
+	handler.Suggest(w, httptest.NewRequest("GET", "/suggest?q=go", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`id="suggestion-post-0" class="suggestion" role="option" aria-selected="false"`,
+		`href="` + samplePostURLs[3] + `"`,
+		`<mark>Go</mark>`,
+		`href="/tags/go"`,
+		`3 posts`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected suggestions to contain %s, got:\n%s", want, body)
+		}
+	}
+
+	// No query, no suggestions, so the dropdown hides
+	w = httptest.NewRecorder()
+	handler.Suggest(w, httptest.NewRequest("GET", "/suggest?q=", nil))
+	if strings.TrimSpace(w.Body.String()) != "" {
+		t.Errorf("Expected an empty list, got:\n%s", w.Body.String())
+	}
+}
+
+func TestIndexHasSuggestionDropdown(t *testing.T) {
+	handler := New(models.NewStore())
+
+	w := httptest.NewRecorder()
+	handler.Index(w, httptest.NewRequest("GET", "/", nil))
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`role="combobox" aria-autocomplete="list" aria-controls="search-suggestions"`,
+		`hx-get="/suggest" hx-trigger="input delay:150ms from:#search-input"`,
+		`id="search-suggestions" class="search-suggestions" role="listbox"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected the home page to contain %s", want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// themeCookieName is the cookie remembering the reader's colour scheme
+const themeCookieName = "blog_theme"
+
+// themeCookieMaxAge keeps the preference for a year
+const themeCookieMaxAge = 365 * 24 * time.Hour
+
+// LoadTheme is middleware that reads the theme cookie so Layout can render
+// the chosen theme without a flash of the wrong colours
+func (h *Handler) LoadTheme(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie(themeCookieName); err == nil {
+			r = r.WithContext(templates.ContextWithTheme(r.Context(), templates.ParseTheme(cookie.Value)))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SetTheme stores the reader's theme choice and sends them back to the
+// page they came from
+func (h *Handler) SetTheme(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	value := r.FormValue("theme")
+	if value != string(templates.ThemeLight) && value != string(templates.ThemeDark) {
+		http.Error(w, "Unknown theme", http.StatusBadRequest)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     themeCookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(themeCookieMaxAge / time.Second),
+		HttpOnly: true,
+		Secure:   h.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, refererPath(r), http.StatusSeeOther)
+}
+
+// refererPath returns the local path of the Referer header, or "/" when it
+// is missing or points at another site
+func refererPath(r *http.Request) string {
+	ref, err := url.Parse(r.Referer())
+	if err != nil || ref.Host != r.Host {
+		return "/"
+	}
+	return safeNext(ref.RequestURI())
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+func TestSetTheme(t *testing.T) {
+	handler := New(models.NewStore())
+
+	form := url.Values{"theme": {"dark"}}
+	req := httptest.NewRequest("POST", "/theme", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Referer", "http://example.com/posts/2?x=1")
+	w := httptest.NewRecorder()
+
+	handler.SetTheme(w, req)
+
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status 303, got %d", w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc != "/posts/2?x=1" {
+		t.Errorf("Expected redirect back to the page, got %s", loc)
+	}
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != themeCookieName || cookies[0].Value != "dark" {
+		t.Fatalf("Expected a dark theme cookie, got %v", cookies)
+	}
+	if cookies[0].MaxAge <= 0 {
+		t.Error("Expected the theme cookie to persist")
+	}
+}
+
+func TestSetThemeRejectsUnknownTheme(t *testing.T) {
+	handler := New(models.NewStore())
+
+	form := url.Values{"theme": {"neon"}}
+	req := httptest.NewRequest("POST", "/theme", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	handler.SetTheme(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestSetThemeIgnoresForeignReferer(t *testing.T) {
+	handler := New(models.NewStore())
+
+	form := url.Values{"theme": {"light"}}
+	req := httptest.NewRequest("POST", "/theme", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Referer", "https://evil.example.net/phish")
+	w := httptest.NewRecorder()
+
+	handler.SetTheme(w, req)
+
+	if loc := w.Header().Get("Location"); loc != "/" {
+		t.Errorf("Expected redirect home, got %s", loc)
+	}
+}
+
+func TestLayoutRendersThemeFromCookie(t *testing.T) {
+	handler := New(models.NewStore())
+	site := handler.LoadTheme(http.HandlerFunc(handler.Index))
+
+	tests := []struct {
+		cookie string
+		class  string
+		toggle string
+	}{
+		{cookie: "", class: `class="theme-light"`, toggle: `value="dark"`},
+		{cookie: "dark", class: `class="theme-dark"`, toggle: `value="light"`},
+		{cookie: "bogus", class: `class="theme-light"`, toggle: `value="dark"`},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		if tt.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: themeCookieName, Value: tt.cookie})
+		}
+		w := httptest.NewRecorder()
+
+		site.ServeHTTP(w, req)
+
+		body := w.Body.String()
+		if !strings.Contains(body, tt.class) {
+			t.Errorf("cookie %q: expected <html %s>", tt.cookie, tt.class)
+		}
+		toggle := body[strings.Index(body, `class="theme-toggle"`):]
+		if !strings.Contains(toggle[:300], tt.toggle) {
+			t.Errorf("cookie %q: expected toggle to switch with %s", tt.cookie, tt.toggle)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// WithTrashRetention sets how long deleted posts stay in the trash before
+// they are purged for good
+func WithTrashRetention(retention time.Duration) Option {
+	return func(h *Handler) {
+		h.trashRetention = retention
+	}
+}
+
+// DeletePost moves one of the logged-in author's posts to the trash
+func (h *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
+	post, ok := h.lookupAuthorPost(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.Delete(post.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/trash", http.StatusSeeOther)
+}
+
+// Trash lists the logged-in author's deleted posts
+func (h *Handler) Trash(w http.ResponseWriter, r *http.Request) {
+	user, ok := models.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Login required", http.StatusUnauthorized)
+		return
+	}
+
+	// Don't offer to restore posts that are already past their retention
+	if _, err := h.PurgeExpiredTrash(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var posts []models.Post
+	for _, post := range h.store.Trash() {
+		if ownsPost(user, post) {
+			posts = append(posts, post)
+		}
+	}
+
+	templates.TrashPage(posts, h.trashRetention).Render(r.Context(), w)
+}
+
+// RestorePost takes a post out of the trash and shows it
+func (h *Handler) RestorePost(w http.ResponseWriter, r *http.Request) {
+	post, ok := h.lookupTrashedPost(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.store.Restore(post.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, post.URL(), http.StatusSeeOther)
+}
+
+// PurgePost permanently deletes a post from the trash
+func (h *Handler) PurgePost(w http.ResponseWriter, r *http.Request) {
+	post, ok := h.lookupTrashedPost(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.Purge(post.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/trash", http.StatusSeeOther)
+}
+
+// PurgeExpiredTrash permanently deletes posts that have been in the trash
+// longer than the retention period, returning how many were removed
+func (h *Handler) PurgeExpiredTrash() (int, error) {
+	return h.store.PurgeTrash(time.Now().Add(-h.trashRetention))
+}
+
+// lookupTrashedPost resolves the {id} path value to one of the logged-in
+// author's trashed posts, writing a 400 or 404 response when that fails
+func (h *Handler) lookupTrashedPost(w http.ResponseWriter, r *http.Request) (models.Post, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return models.Post{}, false
+	}
+
+	for _, post := range h.store.Trash() {
+		if post.ID == id && isAuthor(r, post) {
+			return post, true
+		}
+	}
+
+	http.NotFound(w, r)
+	return models.Post{}, false
+}
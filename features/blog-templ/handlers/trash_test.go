@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+func trashRequest(method string, id int) *http.Request {
+	req := httptest.NewRequest(method, "/", nil)
+	req.SetPathValue("id", strconv.Itoa(id))
+	return asUser(req, testUser)
+}
+
+func TestDeletePostMovesToTrash(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+	id := addDraft(t, store)
+
+	w := httptest.NewRecorder()
+	handler.DeletePost(w, trashRequest("POST", id))
+
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/trash" {
+		t.Fatalf("Expected redirect to /trash, got %d %s", w.Code, w.Header().Get("Location"))
+	}
+	if trash := store.Trash(); len(trash) != 1 || trash[0].ID != id {
+		t.Errorf("Expected the post in the trash, got %+v", trash)
+	}
+
+	// Other authors' posts can't be deleted
+	w = httptest.NewRecorder()
+	handler.DeletePost(w, trashRequest("POST", 1))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", w.Code)
+	}
+}
+
+func TestTrashPageListsOwnPosts(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store, WithTrashRetention(7*24*time.Hour))
+	id := addDraft(t, store)
+	store.Delete(id)
+	store.Delete(1) // another author's post
+
+	w := httptest.NewRecorder()
+	handler.Trash(w, asUser(httptest.NewRequest("GET", "/trash", nil), testUser))
+
+	body := w.Body.String()
+	if !strings.Contains(body, "Secret Draft") {
+		t.Error("Expected the author's trashed post")
+	}
+	if strings.Contains(body, `action="/trash/1/restore"`) {
+		t.Error("Expected other authors' posts to be hidden")
+	}
+	if !strings.Contains(body, `action="/trash/`+strconv.Itoa(id)+`/purge"`) {
+		t.Error("Expected a delete forever button")
+	}
+}
+
+func TestTrashPagePurgesExpiredPosts(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store, WithTrashRetention(0))
+	id := addDraft(t, store)
+	store.Delete(id)
+
+	w := httptest.NewRecorder()
+	handler.Trash(w, asUser(httptest.NewRequest("GET", "/trash", nil), testUser))
+
+	if strings.Contains(w.Body.String(), "Secret Draft") || len(store.Trash()) != 0 {
+		t.Error("Expected posts past their retention to be purged")
+	}
+}
+
+func TestRestoreAndPurgePost(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+	kept := addDraft(t, store)
+	gone := addDraft(t, store)
+	store.Delete(kept)
+	store.Delete(gone)
+
+	w := httptest.NewRecorder()
+	handler.RestorePost(w, trashRequest("POST", kept))
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/posts/secret-draft" {
+		t.Fatalf("Expected redirect to the restored post, got %d %s", w.Code, w.Header().Get("Location"))
+	}
+	if _, err := store.GetByID(kept); err != nil {
+		t.Errorf("Expected the post to be restored, got %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	handler.PurgePost(w, trashRequest("POST", gone))
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status 303, got %d", w.Code)
+	}
+	if len(store.Trash()) != 0 {
+		t.Error("Expected the post to be purged")
+	}
+
+	// Live posts and missing IDs aren't in the trash
+	for _, id := range []int{kept, 999} {
+		w = httptest.NewRecorder()
+		handler.PurgePost(w, trashRequest("POST", id))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("id %d: expected status 404, got %d", id, w.Code)
+		}
+	}
+}
+
+func TestRestoreOthersPostIsNotFound(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+	store.Delete(1)
+
+	w := httptest.NewRecorder()
+	handler.RestorePost(w, trashRequest("POST", 1))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// trendingCount is how many posts and tags the trending widget lists
+const trendingCount = 5
+
+// WithTrendingWindow sets how far back views and reactions count towards
+// what is trending
+func WithTrendingWindow(window time.Duration) Option {
+	return func(h *Handler) {
+		h.trending = models.NewTrending(window)
+	}
+}
+
+// Trending serves the trending widget's posts and tags, which the widget
+// polls to stay fresh
+func (h *Handler) Trending(w http.ResponseWriter, r *http.Request) {
+	posts, tags := h.trending.Top(h.store.GetAll(), trendingCount)
+	templates.TrendingList(posts, tags).Render(r.Context(), w)
+}
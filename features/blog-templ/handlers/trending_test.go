@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+func getTrending(handler *Handler) string {
+	w := httptest.NewRecorder()
+	handler.Trending(w, httptest.NewRequest("GET", "/trending", nil))
+	return w.Body.String()
+}
+
+func TestTrendingFromViewsAndReactions(t *testing.T) {
+	handler := New(models.NewStore())
+
+	if body := getTrending(handler); !strings.Contains(body, "Nothing is trending yet") {
+		t.Errorf("Expected an empty widget before any activity, got:\n%s", body)
+	}
+
+	// Two readers view post 2; one reader likes post 3, which counts more
+	viewPost(handler, "2", browserUA)
+	viewPost(handler, "2", browserUA)
+	handler.React(httptest.NewRecorder(), newReactRequest("3", ""))
+	// Bots don't count
+	viewPost(handler, "1", "Googlebot/2.1")
+
+	body := getTrending(handler)
+	first := strings.Index(body, `href="`+samplePostURLs[3]+`"`)
+	second := strings.Index(body, `href="`+samplePostURLs[2]+`"`)
+	if first < 0 || second < 0 || first > second {
+		t.Errorf("Expected post 3 then post 2, got:\n%s", body)
+	}
+	if strings.Contains(body, samplePostURLs[1]) {
+		t.Error("Expected bot views to be ignored")
+	}
+	if !strings.Contains(body, `href="/tags/web%20development"`) {
+		t.Error("Expected the trending posts' tags")
+	}
+}
+
+func TestIndexPollsTrending(t *testing.T) {
+	handler := New(models.NewStore())
+
+	w := httptest.NewRecorder()
+	handler.Index(w, httptest.NewRequest("GET", "/", nil))
+
+	if body := w.Body.String(); !strings.Contains(body, `hx-get="/trending" hx-trigger="load, every 60s"`) {
+		t.Error("Expected the trending widget to load and poll /trending")
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// uploadCacheControl lets browsers cache uploads forever; names are random
+// and files are never rewritten
+const uploadCacheControl = "public, max-age=31536000, immutable"
+
+// UploadImage stores an image from a multipart form and returns a preview
+// that attaches it to the post being written
+func (h *Handler) UploadImage(w http.ResponseWriter, r *http.Request) {
+	if h.images == nil {
+		http.Error(w, "Image uploads are disabled", http.StatusNotFound)
+		return
+	}
+
+	// Allow some room for the multipart framing around the file
+	r.Body = http.MaxBytesReader(w, r.Body, models.MaxImageSize+1<<20)
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, models.ErrImageTooLarge.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Choose an image to upload", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, models.MaxImageSize+1))
+	if err != nil {
+		http.Error(w, "Failed to read upload", http.StatusBadRequest)
+		return
+	}
+
+	img, err := h.images.Save(data)
+	switch {
+	case errors.Is(err, models.ErrImageTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	case errors.Is(err, models.ErrUnsupportedImage):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	case err != nil:
+		http.Error(w, "Failed to store image", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	templates.UploadedImage(img).Render(r.Context(), w)
+}
+
+// Uploads serves stored images under /uploads/
+func (h *Handler) Uploads() http.Handler {
+	if h.images == nil {
+		return http.NotFoundHandler()
+	}
+
+	files := http.StripPrefix("/uploads/", http.FileServerFS(h.images))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// No directory listings
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", uploadCacheControl)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		files.ServeHTTP(w, r)
+	})
+}
+
+// attachedImages keeps the submitted image names that refer to stored uploads
+func (h *Handler) attachedImages(names []string) []string {
+	if h.images == nil {
+		return nil
+	}
+
+	var images []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] || !h.images.Exists(name) {
+			continue
+		}
+		seen[name] = true
+		images = append(images, name)
+	}
+	return images
+}
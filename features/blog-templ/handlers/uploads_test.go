@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+func newUploadHandler(t *testing.T) (*Handler, *models.MemoryStore) {
+	t.Helper()
+
+	images, err := models.NewDiskImageStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := models.NewStore()
+	return New(store, WithImageStore(images)), store
+}
+
+func newUploadRequest(t *testing.T, data []byte) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("image", "photo.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/uploads", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return asUser(req, testUser)
+}
+
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 640, 480))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+var uploadedNamePattern = regexp.MustCompile(`name="images" value="([0-9a-f]{32}\.png)"`)
+
+// uploadImage uploads a PNG and returns its stored name
+func uploadImage(t *testing.T, handler *Handler) string {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	handler.UploadImage(w, newUploadRequest(t, testPNG(t)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	match := uploadedNamePattern.FindStringSubmatch(w.Body.String())
+	if match == nil {
+		t.Fatalf("Expected hidden images input in response, got:\n%s", w.Body.String())
+	}
+	return match[1]
+}
+
+func TestUploadImage(t *testing.T) {
+	handler, _ := newUploadHandler(t)
+	name := uploadImage(t, handler)
+
+	// The original and its thumbnail are served with long-lived cache headers
+	for _, path := range []string{models.ImageURL(name), models.ImageURL(models.ThumbnailName(name))} {
+		w := httptest.NewRecorder()
+		handler.Uploads().ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", path, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+			t.Errorf("%s: expected image/png, got %s", path, ct)
+		}
+		if cc := w.Header().Get("Cache-Control"); !strings.Contains(cc, "max-age=31536000") {
+			t.Errorf("%s: expected long cache lifetime, got %q", path, cc)
+		}
+	}
+}
+
+func TestUploadImageRejectsInvalidFiles(t *testing.T) {
+	handler, _ := newUploadHandler(t)
+
+	w := httptest.NewRecorder()
+	handler.UploadImage(w, newUploadRequest(t, []byte("#!/bin/sh\necho not an image")))
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected status 415, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.UploadImage(w, newUploadRequest(t, make([]byte, models.MaxImageSize+1)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413, got %d", w.Code)
+	}
+
+	// A form without a file
+	req := httptest.NewRequest("POST", "/uploads", strings.NewReader("title=x"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	handler.UploadImage(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestUploadsDisabledWithoutImageStore(t *testing.T) {
+	handler := New(models.NewStore())
+
+	w := httptest.NewRecorder()
+	handler.UploadImage(w, newUploadRequest(t, testPNG(t)))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.Uploads().ServeHTTP(w, httptest.NewRequest("GET", "/uploads/x.png", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestUploadsHasNoDirectoryListing(t *testing.T) {
+	handler, _ := newUploadHandler(t)
+	uploadImage(t, handler)
+
+	w := httptest.NewRecorder()
+	handler.Uploads().ServeHTTP(w, httptest.NewRequest("GET", "/uploads/", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestCreatePostAttachesUploadedImages(t *testing.T) {
+	handler, store := newUploadHandler(t)
+	name := uploadImage(t, handler)
+
+	form := url.Values{}
+	form.Add("title", "With Pictures")
+	form.Add("content", "Look at this")
+	form.Add("images", name)
+	form.Add("images", name)
+	form.Add("images", "ffffffffffffffffffffffffffffffff.png")
+	form.Add("images", "../main.go")
+
+	req := httptest.NewRequest("POST", "/posts", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	handler.CreatePost(httptest.NewRecorder(), asUser(req, testUser))
+
+	post := store.GetAll()[0]
+	if len(post.Images) != 1 || post.Images[0] != name {
+		t.Fatalf("Expected only the uploaded image to be attached, got %v", post.Images)
+	}
+
+	req = httptest.NewRequest("GET", "/posts/1", nil)
+	req.SetPathValue("id", strconv.Itoa(post.ID))
+	w := httptest.NewRecorder()
+	handler.PostDetail(w, req)
+
+	if !strings.Contains(w.Body.String(), models.ImageURL(models.ThumbnailName(name))) {
+		t.Error("Expected post detail to show the image thumbnail")
+	}
+}
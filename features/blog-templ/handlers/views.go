@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+	"github.com/homveloper/doodle/features/blog-templ/templates"
+)
+
+// visitorCookieName is the browser-session cookie used to count each
+// reader's view of a post once
+const visitorCookieName = "blog_visitor"
+
+// popularCount is how many posts the popular posts sidebar lists
+const popularCount = 5
+
+// botMarkers are User-Agent fragments of crawlers and link previewers
+var botMarkers = []string{
+	"bot", "crawl", "spider", "slurp", "facebookexternalhit",
+	"preview", "headless", "curl", "wget", "python-requests",
+}
+
+// seenTracker remembers keys such as visitor and post pairs so repeated
+// actions within the window are not counted again
+type seenTracker struct {
+	window    time.Duration
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// newSeenTracker creates a tracker that forgets keys after window
+func newSeenTracker(window time.Duration) *seenTracker {
+	return &seenTracker{
+		window: window,
+		seen:   make(map[string]time.Time),
+		now:    time.Now,
+	}
+}
+
+// first records key and reports whether it was not seen within the window
+func (t *seenTracker) first(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if now.Sub(t.lastPrune) > t.window {
+		for key, at := range t.seen {
+			if now.Sub(at) > t.window {
+				delete(t.seen, key)
+			}
+		}
+		t.lastPrune = now
+	}
+
+	if at, ok := t.seen[key]; ok && now.Sub(at) <= t.window {
+		return false
+	}
+	t.seen[key] = now
+	return true
+}
+
+// isBot reports whether a User-Agent looks automated; clients that send
+// none are treated as bots too
+func isBot(userAgent string) bool {
+	ua := strings.ToLower(userAgent)
+	if strings.TrimSpace(ua) == "" {
+		return true
+	}
+	for _, marker := range botMarkers {
+		if strings.Contains(ua, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// countView records a reader's view of a post. Drafts, the author's own
+// visits, bots and repeat views from the same browser session are skipped.
+func (h *Handler) countView(w http.ResponseWriter, r *http.Request, post models.Post) {
+	if !post.Published || isAuthor(r, post) || isBot(r.UserAgent()) {
+		return
+	}
+
+	visitor, ok := h.visitorID(w, r)
+	if !ok || !h.views.first(visitor+":"+strconv.Itoa(post.ID)) {
+		return
+	}
+
+	if _, err := h.store.RecordView(post.ID); err == nil {
+		h.trending.Record(post.ID, models.ActivityView)
+	}
+}
+
+// visitorID returns the visitor cookie, issuing a new one if needed
+func (h *Handler) visitorID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if cookie, err := r.Cookie(visitorCookieName); err == nil && cookie.Value != "" {
+		return cookie.Value, true
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", false
+	}
+	id := hex.EncodeToString(b)
+
+	// No expiry: the cookie lasts for the browser session
+	http.SetCookie(w, &http.Cookie{
+		Name:     visitorCookieName,
+		Value:    id,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   h.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return id, true
+}
+
+// Stats shows view counts for every post
+func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
+	posts := h.store.GetAll()
+
+	var stats templates.SiteStats
+	for _, post := range posts {
+		stats.Posts++
+		stats.Views += post.Views
+		if post.Published {
+			stats.Published++
+		}
+	}
+	stats.Drafts = stats.Posts - stats.Published
+
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].Views > posts[j].Views
+	})
+
+	templates.StatsPage(stats, posts).Render(r.Context(), w)
+}
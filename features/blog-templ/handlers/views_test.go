@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+const browserUA = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 Chrome/120.0 Safari/537.36"
+
+// viewPost requests a post page, returning the response
+func viewPost(handler *Handler, id, userAgent string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/posts/"+id, nil)
+	req.SetPathValue("id", id)
+	req.Header.Set("User-Agent", userAgent)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	handler.PostDetail(w, req)
+	return w
+}
+
+func views(t *testing.T, store models.Store, id int) int {
+	t.Helper()
+	post, err := store.GetByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return post.Views
+}
+
+func TestPostDetailCountsViewsOncePerSession(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+
+	w := viewPost(handler, "1", browserUA)
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != visitorCookieName {
+		t.Fatalf("Expected a visitor cookie, got %v", cookies)
+	}
+	if !cookies[0].HttpOnly || !cookies[0].Expires.IsZero() {
+		t.Error("Expected an HttpOnly browser-session cookie")
+	}
+
+	// Reloading in the same session doesn't count again
+	viewPost(handler, "1", browserUA, cookies...)
+	if got := views(t, store, 1); got != 1 {
+		t.Errorf("Expected 1 view, got %d", got)
+	}
+
+	// A different browser counts
+	viewPost(handler, "1", browserUA)
+	if got := views(t, store, 1); got != 2 {
+		t.Errorf("Expected 2 views, got %d", got)
+	}
+
+	// The same session viewing another post counts for that post
+	viewPost(handler, "2", browserUA, cookies...)
+	if got := views(t, store, 2); got != 1 {
+		t.Errorf("Expected 1 view of post 2, got %d", got)
+	}
+}
+
+func TestPostDetailIgnoresBots(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+
+	for _, ua := range []string{
+		"",
+		"Googlebot/2.1 (+http://www.google.com/bot.html)",
+		"facebookexternalhit/1.1",
+		"curl/8.4.0",
+		"Mozilla/5.0 (compatible; bingbot/2.0)",
+	} {
+		viewPost(handler, "1", ua)
+	}
+
+	if got := views(t, store, 1); got != 0 {
+		t.Errorf("Expected bots not to be counted, got %d views", got)
+	}
+}
+
+func TestPostDetailIgnoresAuthorViews(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+
+	// Post 1 is Jane's
+	req := httptest.NewRequest("GET", "/posts/1", nil)
+	req.SetPathValue("id", "1")
+	req.Header.Set("User-Agent", browserUA)
+	req = asUser(req, models.User{Username: "jane", DisplayName: "Jane Doe"})
+	handler.PostDetail(httptest.NewRecorder(), req)
+
+	if got := views(t, store, 1); got != 0 {
+		t.Errorf("Expected the author's view not to count, got %d", got)
+	}
+}
+
+func TestSeenTrackerWindow(t *testing.T) {
+	tracker := newSeenTracker(time.Hour)
+	now := time.Now()
+	tracker.now = func() time.Time { return now }
+
+	if !tracker.first("v1:1") {
+		t.Fatal("Expected first view to count")
+	}
+	if tracker.first("v1:1") {
+		t.Error("Expected repeat view to be ignored")
+	}
+
+	now = now.Add(2 * time.Hour)
+	if !tracker.first("v1:1") {
+		t.Error("Expected view after the window to count again")
+	}
+	if len(tracker.seen) != 1 {
+		t.Errorf("Expected expired entries to be pruned, have %d", len(tracker.seen))
+	}
+}
+
+func TestIndexShowsPopularPosts(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+	store.RecordView(2)
+	store.RecordView(2)
+	store.RecordView(3)
+
+	w := httptest.NewRecorder()
+	handler.Index(w, httptest.NewRequest("GET", "/", nil))
+
+	body := w.Body.String()
+	aside := body[strings.Index(body, `<aside class="popular-posts">`):]
+	if !strings.Contains(aside, "2 views") || !strings.Contains(aside, "1 view<") {
+		t.Error("Expected popular posts with their view counts")
+	}
+	if strings.Index(aside, `href="`+samplePostURLs[2]+`"`) > strings.Index(aside, `href="`+samplePostURLs[3]+`"`) {
+		t.Error("Expected the most viewed post first")
+	}
+}
+
+func TestStatsPage(t *testing.T) {
+	store := models.NewStore()
+	handler := New(store)
+	addDraft(t, store)
+	store.RecordView(1)
+	store.RecordView(1)
+	store.RecordView(4)
+
+	w := httptest.NewRecorder()
+	handler.Stats(w, asUser(httptest.NewRequest("GET", "/admin/stats", nil), testUser))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`<span class="stat-value">5</span> <span class="stat-label">Posts</span>`,
+		`<span class="stat-value">4</span> <span class="stat-label">Published</span>`,
+		`<span class="stat-value">1</span> <span class="stat-label">Drafts</span>`,
+		`<span class="stat-value">3</span> <span class="stat-label">Views</span>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected stats page to contain %s", want)
+		}
+	}
+}
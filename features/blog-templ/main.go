@@ -1,29 +1,381 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/homveloper/doodle/features/blog-templ/handlers"
 	"github.com/homveloper/doodle/features/blog-templ/models"
 )
 
 func main() {
+	dataFile := flag.String("data", "", "path to a JSON data file (uses an in-memory store when empty)")
+	viewFlush := flag.Duration("view-flush", 30*time.Second, "how often view counts are written to the -data file")
+	baseURL := flag.String("base-url", "", "public site URL for feed and sitemap links (derived from requests when empty)")
+	secureCookies := flag.Bool("secure-cookies", false, "only send session cookies over HTTPS")
+	uploadDir := flag.String("uploads", "uploads", "directory for uploaded images")
+	rateLimit := flag.Int("rate-limit", 60, "requests per minute each client may make to search, post and comment endpoints (0 disables)")
+	rateBurst := flag.Int("rate-burst", 30, "requests a client may make in a burst before -rate-limit applies")
+	trashDays := flag.Int("trash-days", 30, "days deleted posts stay in the trash before they are purged")
+	trendingWindow := flag.Duration("trending-window", 24*time.Hour, "how far back views and reactions count towards trending posts and tags")
+	smtpAddr := flag.String("smtp-addr", "", "SMTP server (host:port) for subscription emails (logs emails instead when empty)")
+	smtpFrom := flag.String("smtp-from", "blog@localhost", "sender address for subscription emails")
+	smtpUser := flag.String("smtp-user", "", "SMTP username; the password is read from BLOG_SMTP_PASSWORD")
+	akismetKey := flag.String("akismet-key", envString("BLOG_AKISMET_KEY", ""), "Akismet API key for checking comments for spam (env BLOG_AKISMET_KEY)")
+	port := flag.Int("port", envInt("PORT", 8080), "port to listen on (env PORT)")
+	readTimeout := flag.Duration("read-timeout", envDuration("BLOG_READ_TIMEOUT", 30*time.Second), "maximum time to read a request, body included (env BLOG_READ_TIMEOUT)")
+	writeTimeout := flag.Duration("write-timeout", envDuration("BLOG_WRITE_TIMEOUT", 30*time.Second), "maximum time to write a response; live event streams are exempt (env BLOG_WRITE_TIMEOUT)")
+	idleTimeout := flag.Duration("idle-timeout", envDuration("BLOG_IDLE_TIMEOUT", 2*time.Minute), "how long idle keep-alive connections stay open (env BLOG_IDLE_TIMEOUT)")
+	shutdownTimeout := flag.Duration("shutdown-timeout", envDuration("BLOG_SHUTDOWN_TIMEOUT", 15*time.Second), "how long to wait for in-flight requests on shutdown (env BLOG_SHUTDOWN_TIMEOUT)")
+	logLevel := flag.String("log-level", envString("BLOG_LOG_LEVEL", "info"), "minimum level to log: debug, info, warn or error (env BLOG_LOG_LEVEL)")
+	logFormat := flag.String("log-format", envString("BLOG_LOG_FORMAT", "text"), "log output: text, or json for log collectors (env BLOG_LOG_FORMAT)")
+	flag.Parse()
+
+	logger, err := newLogger(os.Stderr, *logLevel, *logFormat)
+	if err != nil {
+		fatal("Invalid logging flags", "error", err)
+	}
+	// Also routes the log package, which net/http uses for server errors
+	slog.SetDefault(logger)
+
 	// Create store and handler
-	store := models.NewStore()
-	handler := handlers.New(store)
+	var store models.Store = models.NewStore()
+	var jsonStore *models.JSONStore
+	if *dataFile != "" {
+		jsonStore, err = models.NewJSONStore(*dataFile)
+		if err != nil {
+			fatal("Failed to load posts", "file", *dataFile, "error", err)
+		}
+		store = jsonStore
+		logger.Info("Persisting posts", "file", *dataFile)
+	}
+	store = models.NewLoggingStore(store, logger)
+
+	// Subcommands: "export <dir>" and "import <dir>" migrate posts to and
+	// from Markdown files instead of starting the server
+	switch command := flag.Arg(0); command {
+	case "":
+	case "export", "import":
+		dir := flag.Arg(1)
+		if dir == "" {
+			fatal("Missing directory", "usage", fmt.Sprintf("%s [flags] %s <dir>", os.Args[0], command))
+		}
+		if command == "import" && *dataFile == "" {
+			fatal("import needs -data so the imported posts are saved")
+		}
+		if err := migrate(command, store, dir); err != nil {
+			fatal("Migration failed", "command", command, "dir", dir, "error", err)
+		}
+		return
+	default:
+		fatal("Unknown command (want export or import)", "command", command)
+	}
+
+	// Demo author accounts matching the sample posts
+	demoPassword := os.Getenv("BLOG_DEMO_PASSWORD")
+	if demoPassword == "" {
+		demoPassword = "doodle-demo"
+	}
+	users := models.NewUserStore()
+	for _, account := range []struct{ username, name string }{
+		{"jane", "Jane Doe"},
+		{"john", "John Smith"},
+	} {
+		if _, err := users.Register(account.username, account.name, demoPassword); err != nil {
+			fatal("Failed to create demo user", "username", account.username, "error", err)
+		}
+	}
+
+	images, err := models.NewDiskImageStore(*uploadDir)
+	if err != nil {
+		fatal("Failed to open upload directory", "dir", *uploadDir, "error", err)
+	}
+
+	// Without an SMTP server, subscription emails are printed to the log
+	var mailer models.Mailer = models.NewLogMailer(logger)
+	if *smtpAddr != "" {
+		mailer = models.NewSMTPMailer(*smtpAddr, *smtpFrom, *smtpUser, os.Getenv("BLOG_SMTP_PASSWORD"))
+		logger.Info("Sending subscription emails over SMTP", "addr", *smtpAddr)
+	}
+
+	// Comments go through a honeypot and keyword heuristics, and Akismet
+	// too when there is a key for it
+	spam := models.SpamCheckers{models.HoneypotChecker{}, models.NewKeywordChecker()}
+	if *akismetKey != "" {
+		if *baseURL == "" {
+			fatal("-akismet-key needs -base-url to identify the blog")
+		}
+		spam = append(spam, models.NewAkismetChecker(*akismetKey, *baseURL))
+		logger.Info("Checking comments with Akismet")
+	}
+
+	handler := handlers.New(store,
+		handlers.WithBaseURL(*baseURL),
+		handlers.WithUserStore(users),
+		handlers.WithSecureCookies(*secureCookies),
+		handlers.WithImageStore(images),
+		handlers.WithRateLimit(*rateLimit, *rateBurst),
+		handlers.WithTrashRetention(time.Duration(*trashDays)*24*time.Hour),
+		handlers.WithTrendingWindow(*trendingWindow),
+		handlers.WithMailer(mailer),
+		handlers.WithLogger(logger),
+		handlers.WithSpamChecker(spam),
+	)
+
+	// Purge expired trash now and every hour after
+	go func() {
+		for ; ; time.Sleep(time.Hour) {
+			if _, err := handler.PurgeExpiredTrash(); err != nil {
+				logger.Error("Failed to purge trash", "error", err)
+			}
+		}
+	}()
+
+	// Write view counts every -view-flush rather than on every view
+	if jsonStore != nil {
+		go func() {
+			for range time.Tick(*viewFlush) {
+				if err := jsonStore.FlushViews(); err != nil {
+					logger.Error("Failed to save view counts", "error", err)
+				}
+			}
+		}()
+	}
 
 	// Register routes
 	http.HandleFunc("/", handler.Index)
-	http.HandleFunc("/search", handler.Search)
-	http.HandleFunc("/new", handler.NewPostForm)
-	http.HandleFunc("/posts", handler.CreatePost)
+	http.HandleFunc("/search", handler.RateLimit(handler.Search))
+	http.HandleFunc("GET /suggest", handler.Suggest)
+	http.HandleFunc("/new", handler.RequireAuth(handler.NewPostForm))
+	http.HandleFunc("/posts", handler.RateLimit(handler.RequireAuth(handler.CreatePost)))
+	http.HandleFunc("POST /preview", handler.RequireAuth(handler.Preview))
+	http.HandleFunc("GET /posts/more", handler.MorePosts)
+	http.HandleFunc("GET /posts/{id}", handler.PostDetail)
+	http.HandleFunc("GET /posts/{id}/comments", handler.RateLimit(handler.ListComments))
+	http.HandleFunc("POST /posts/{id}/comments", handler.RateLimit(handler.CreateComment))
+	http.HandleFunc("POST /posts/{id}/like", handler.React)
+	http.HandleFunc("POST /posts/{id}/bookmark", handler.ToggleBookmark)
+	http.HandleFunc("GET /reading-list", handler.ReadingList)
+	http.HandleFunc("GET /trending", handler.Trending)
+	http.HandleFunc("GET /feed.xml", handler.RSSFeed)
+	http.HandleFunc("GET /atom.xml", handler.AtomFeed)
+	http.HandleFunc("GET /sitemap.xml", handler.Sitemap)
+	http.HandleFunc("GET /tags/{tag}", handler.TagPosts)
+	http.HandleFunc("GET /categories/{slug}", handler.CategoryPosts)
+	http.HandleFunc("GET /categories/{slug}/feed.xml", handler.CategoryRSSFeed)
+	http.HandleFunc("GET /categories/{slug}/atom.xml", handler.CategoryAtomFeed)
+	http.HandleFunc("GET /authors/{name}", handler.AuthorPosts)
+	http.HandleFunc("GET /profile", handler.RequireAuth(handler.Profile))
+	http.HandleFunc("POST /profile", handler.RequireAuth(handler.UpdateProfile))
+	http.HandleFunc("GET /archive", handler.Archive)
+	http.HandleFunc("GET /archive/{year}/{month}", handler.ArchiveMonth)
+	http.HandleFunc("GET /drafts", handler.RequireAuth(handler.Drafts))
+	http.HandleFunc("GET /drafts/{id}/edit", handler.RequireAuth(handler.EditDraft))
+	http.HandleFunc("GET /ws/drafts/{id}", handler.CollabSocket)
+	http.HandleFunc("POST /posts/{id}/publish", handler.RequireAuth(handler.PublishPost))
+	http.HandleFunc("POST /posts/{id}/delete", handler.RequireAuth(handler.DeletePost))
+	http.HandleFunc("POST /posts/{id}/slug", handler.RequireAuth(handler.ChangeSlug))
+	http.HandleFunc("GET /trash", handler.RequireAuth(handler.Trash))
+	http.HandleFunc("POST /trash/{id}/restore", handler.RequireAuth(handler.RestorePost))
+	http.HandleFunc("POST /trash/{id}/purge", handler.RequireAuth(handler.PurgePost))
+	http.HandleFunc("GET /posts/{id}/revisions", handler.RequireAuth(handler.Revisions))
+	http.HandleFunc("GET /posts/{id}/revisions/{rev}", handler.RequireAuth(handler.Revision))
+	http.HandleFunc("POST /posts/{id}/revisions/{rev}/restore", handler.RequireAuth(handler.RestoreRevision))
+	http.HandleFunc("GET /admin/stats", handler.RequireAuth(handler.Stats))
+	http.HandleFunc("GET /admin/categories", handler.RequireAuth(handler.Categories))
+	http.HandleFunc("POST /admin/categories", handler.RequireAuth(handler.AddCategory))
+	http.HandleFunc("POST /admin/categories/{slug}/delete", handler.RequireAuth(handler.DeleteCategory))
+	http.HandleFunc("GET /admin/comments", handler.RequireAuth(handler.ModerationQueue))
+	http.HandleFunc("POST /admin/comments/{id}/approve", handler.RequireAuth(handler.ApproveComment))
+	http.HandleFunc("POST /admin/comments/{id}/delete", handler.RequireAuth(handler.DeleteComment))
+	http.HandleFunc("POST /subscribe", handler.RateLimit(handler.Subscribe))
+	http.HandleFunc("GET /subscribe/confirm", handler.ConfirmSubscription)
+	http.HandleFunc("GET /unsubscribe", handler.UnsubscribeForm)
+	http.HandleFunc("POST /unsubscribe", handler.Unsubscribe)
+	http.HandleFunc("GET /login", handler.LoginForm)
+	http.HandleFunc("POST /login", handler.Login)
+	http.HandleFunc("POST /logout", handler.Logout)
+	http.HandleFunc("POST /theme", handler.SetTheme)
+	http.HandleFunc("GET /events", handler.Events)
+	http.HandleFunc("POST /uploads", handler.RequireAuth(handler.UploadImage))
+	http.Handle("GET /uploads/", handler.Uploads())
+	http.HandleFunc("GET /api/posts", handler.APIListPosts)
+	http.HandleFunc("POST /api/posts", handler.APICreatePost)
+	http.HandleFunc("GET /api/posts/{id}", handler.APIGetPost)
+	http.HandleFunc("PUT /api/posts/{id}", handler.APIUpdatePost)
+	http.HandleFunc("DELETE /api/posts/{id}", handler.APIDeletePost)
+	http.HandleFunc("GET /graphql", handler.RateLimit(handler.GraphQL))
+	http.HandleFunc("POST /graphql", handler.RateLimit(handler.GraphQL))
+	http.HandleFunc("GET /graphql/schema", handler.GraphQLSchema)
+	http.HandleFunc("GET /healthz", handler.Healthz)
+	http.HandleFunc("GET /metrics", handler.Metrics)
+
+	// Outermost first: every request gets an ID and a log line, even when
+	// a handler panics. RecordMetrics sits next to the mux so it sees the
+	// matched route.
+	site := handlers.Chain(http.DefaultServeMux,
+		handlers.RequestID,
+		handlers.LogRequests(logger),
+		handlers.Recover(logger),
+		handlers.Gzip,
+		handler.LoadSession,
+		handler.ProtectCSRF,
+		handler.LoadTheme,
+		handler.LoadLocale,
+		handler.LoadBookmarks,
+		handler.RecordMetrics,
+	)
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", *port),
+		Handler:           site,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       *readTimeout,
+		WriteTimeout:      *writeTimeout,
+		IdleTimeout:       *idleTimeout,
+	}
+	// Event streams never go idle, so end them when shutdown starts
+	server.RegisterOnShutdown(handler.CloseStreams)
 
 	// Start server
-	port := 8080
-	fmt.Printf("🚀 Blog server starting on http://localhost:%d\n", port)
-	fmt.Println("📝 Try searching for: templ, htmx, go, web development")
-	fmt.Println("✏️  Click 'Write New Post' to create your own posts!")
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), nil))
+	logger.Info("Blog server starting", "url", fmt.Sprintf("http://localhost:%d", *port))
+	logger.Info("Demo accounts", "usernames", "jane, john", "password", demoPassword)
+
+	if err := serve(server, handler, *shutdownTimeout); err != nil {
+		fatal("Server failed", "error", err)
+	}
+	// Keep the views counted since the last flush
+	if jsonStore != nil {
+		if err := jsonStore.FlushViews(); err != nil {
+			fatal("Failed to save view counts", "error", err)
+		}
+	}
+}
+
+// serve runs server until it fails or the process gets SIGINT or SIGTERM.
+// On a signal it stops accepting connections and waits up to timeout for
+// in-flight requests and background emails to finish.
+func serve(server *http.Server, handler *handlers.Handler, timeout time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	// A second signal kills the process instead of waiting
+	stop()
+
+	slog.Info("Shutting down", "timeout", timeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	if err := handler.Drain(shutdownCtx); err != nil {
+		return fmt.Errorf("waiting for background work: %w", err)
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	slog.Info("Server stopped")
+	return nil
+}
+
+// newLogger creates a logger that writes level and above to w, as text
+// or as JSON lines
+func newLogger(w io.Writer, level, format string) (*slog.Logger, error) {
+	var min slog.Level
+	if err := min.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: min}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q (want text or json)", format)
+}
+
+// fatal logs msg at error level and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// envString returns the environment variable key, or fallback when it is
+// unset
+func envString(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// envInt returns the integer in the environment variable key, or fallback
+// when it is unset
+func envInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		fatal("Invalid environment variable (want a number)", "key", key, "value", value)
+	}
+	return n
+}
+
+// envDuration returns the duration, such as "30s", in the environment
+// variable key, or fallback when it is unset
+func envDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		fatal("Invalid environment variable (want a duration such as 30s)", "key", key, "value", value)
+	}
+	return d
+}
+
+// migrate runs the export or import subcommand
+func migrate(command string, store models.Store, dir string) error {
+	if command == "export" {
+		posts := store.GetAll()
+		if err := models.ExportMarkdown(posts, dir); err != nil {
+			return err
+		}
+		slog.Info("Exported posts", "count", len(posts), "dir", dir)
+		return nil
+	}
+
+	posts, err := models.ImportMarkdown(store, dir)
+	if err != nil {
+		return err
+	}
+	slog.Info("Imported posts", "count", len(posts), "dir", dir)
+	return nil
 }
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ArchiveMonth is a calendar month with published posts
+type ArchiveMonth struct {
+	Year  int
+	Month time.Month
+	Count int
+}
+
+// URL returns the path of the month's archive page
+func (m ArchiveMonth) URL() string {
+	return fmt.Sprintf("/archive/%d/%02d", m.Year, int(m.Month))
+}
+
+// Label returns the month for display, e.g. "January 2024"
+func (m ArchiveMonth) Label() string {
+	return fmt.Sprintf("%s %d", m.Month, m.Year)
+}
+
+// Archive groups published posts by the month they were published,
+// newest month first
+func (s *MemoryStore) Archive() []ArchiveMonth {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[ArchiveMonth]int)
+	for _, post := range s.posts {
+		if !post.Published {
+			continue
+		}
+		counts[ArchiveMonth{Year: post.PublishedAt.Year(), Month: post.PublishedAt.Month()}]++
+	}
+
+	months := make([]ArchiveMonth, 0, len(counts))
+	for month, n := range counts {
+		month.Count = n
+		months = append(months, month)
+	}
+
+	sort.Slice(months, func(i, j int) bool {
+		if months[i].Year != months[j].Year {
+			return months[i].Year > months[j].Year
+		}
+		return months[i].Month > months[j].Month
+	})
+
+	return months
+}
+
+// InMonth returns the posts published in the given month
+func InMonth(posts []Post, year int, month time.Month) []Post {
+	var results []Post
+	for _, post := range posts {
+		if post.Published && post.PublishedAt.Year() == year && post.PublishedAt.Month() == month {
+			results = append(results, post)
+		}
+	}
+	return results
+}
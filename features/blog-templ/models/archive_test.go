@@ -0,0 +1,61 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func newArchiveStore() *MemoryStore {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	}
+	return newMemoryStore([]Post{
+		{ID: 1, Title: "Old", Content: "x", Published: true, PublishedAt: date(2023, time.December, 30)},
+		{ID: 2, Title: "New year", Content: "x", Published: true, PublishedAt: date(2024, time.January, 2)},
+		{ID: 3, Title: "Same month", Content: "x", Published: true, PublishedAt: date(2024, time.January, 20)},
+		{ID: 4, Title: "Spring", Content: "x", Published: true, PublishedAt: date(2024, time.March, 1)},
+		{ID: 5, Title: "Draft", Content: "x", CreatedAt: date(2024, time.February, 1)},
+	})
+}
+
+func TestArchive(t *testing.T) {
+	months := newArchiveStore().Archive()
+
+	want := []ArchiveMonth{
+		{Year: 2024, Month: time.March, Count: 1},
+		{Year: 2024, Month: time.January, Count: 2},
+		{Year: 2023, Month: time.December, Count: 1},
+	}
+	if len(months) != len(want) {
+		t.Fatalf("Expected %d months, got %v", len(want), months)
+	}
+	for i := range want {
+		if months[i] != want[i] {
+			t.Errorf("Month %d: expected %+v, got %+v", i, want[i], months[i])
+		}
+	}
+}
+
+func TestArchiveMonthURLAndLabel(t *testing.T) {
+	m := ArchiveMonth{Year: 2024, Month: time.March}
+
+	if m.URL() != "/archive/2024/03" {
+		t.Errorf("Unexpected URL %q", m.URL())
+	}
+	if m.Label() != "March 2024" {
+		t.Errorf("Unexpected label %q", m.Label())
+	}
+}
+
+func TestInMonth(t *testing.T) {
+	store := newArchiveStore()
+
+	posts := InMonth(store.GetAll(), 2024, time.January)
+	if len(posts) != 2 {
+		t.Fatalf("Expected 2 posts in January 2024, got %d", len(posts))
+	}
+
+	if posts := InMonth(store.GetAll(), 2024, time.February); len(posts) != 0 {
+		t.Errorf("Expected drafts to be left out, got %d posts", len(posts))
+	}
+}
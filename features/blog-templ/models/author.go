@@ -0,0 +1,146 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// MaxBioLength is the longest accepted author bio, in characters
+const MaxBioLength = 500
+
+var (
+	// ErrAuthorNotFound is returned when no author has the requested username
+	ErrAuthorNotFound = errors.New("author not found")
+	// ErrInvalidAvatarURL is returned for avatar URLs that aren't http(s) or site paths
+	ErrInvalidAvatarURL = errors.New("avatar must be an http(s) URL or a path on this site")
+)
+
+// Author is the public profile of someone who writes posts. It shares its
+// username with the author's login account and their posts' AuthorUsername.
+type Author struct {
+	Username  string `json:"username"`
+	Name      string `json:"name"`
+	Bio       string `json:"bio,omitempty"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+}
+
+// URL returns the path of the author's profile page
+func (a Author) URL() string {
+	return "/authors/" + url.PathEscape(a.Username)
+}
+
+// AuthorStore manages author profiles
+type AuthorStore interface {
+	All() []Author
+	Get(username string) (Author, error)
+	Save(author Author) (Author, error)
+}
+
+// MemoryAuthorStore keeps author profiles in memory
+type MemoryAuthorStore struct {
+	authors map[string]Author
+	mu      sync.Mutex
+}
+
+// NewAuthorStore creates an in-memory author store with the authors of the
+// sample posts
+func NewAuthorStore() *MemoryAuthorStore {
+	s := &MemoryAuthorStore{authors: make(map[string]Author)}
+	for _, author := range sampleAuthors() {
+		s.authors[author.Username] = author
+	}
+	return s
+}
+
+// sampleAuthors returns the demo profiles the blog starts with
+func sampleAuthors() []Author {
+	return []Author{
+		{Username: "jane", Name: "Jane Doe", Bio: "Writes about Go, templates and the tools in between."},
+		{Username: "john", Name: "John Smith", Bio: "Front-end developer exploring HTMX and hypermedia."},
+	}
+}
+
+// All returns every author sorted by name
+func (s *MemoryAuthorStore) All() []Author {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	authors := make([]Author, 0, len(s.authors))
+	for _, author := range s.authors {
+		authors = append(authors, author)
+	}
+	slices.SortFunc(authors, func(a, b Author) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return authors
+}
+
+// Get returns the author with the given username (case-insensitive)
+func (s *MemoryAuthorStore) Get(username string) (Author, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	author, ok := s.authors[strings.ToLower(strings.TrimSpace(username))]
+	if !ok {
+		return Author{}, ErrAuthorNotFound
+	}
+	return author, nil
+}
+
+// Save creates or replaces an author's profile
+func (s *MemoryAuthorStore) Save(author Author) (Author, error) {
+	author.Username = strings.ToLower(strings.TrimSpace(author.Username))
+	author.Name = strings.TrimSpace(author.Name)
+	author.Bio = strings.TrimSpace(author.Bio)
+	author.AvatarURL = strings.TrimSpace(author.AvatarURL)
+
+	if author.Username == "" {
+		return Author{}, errors.New("username is required")
+	}
+	if author.Name == "" {
+		author.Name = author.Username
+	}
+	if utf8.RuneCountInString(author.Bio) > MaxBioLength {
+		return Author{}, errors.New("bio must be at most 500 characters")
+	}
+	if author.AvatarURL != "" && !avatarURLAllowed(author.AvatarURL) {
+		return Author{}, ErrInvalidAvatarURL
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.authors[author.Username] = author
+	return author, nil
+}
+
+// avatarURLAllowed reports whether an avatar can be shown as an image:
+// an absolute http(s) URL or a path on this site, such as an upload
+func avatarURLAllowed(avatar string) bool {
+	u, err := url.Parse(avatar)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return u.Host != ""
+	case "":
+		return strings.HasPrefix(avatar, "/") && !strings.HasPrefix(avatar, "//")
+	}
+	return false
+}
+
+// ByAuthor returns the posts written by the author with the given username
+func ByAuthor(posts []Post, username string) []Post {
+	var matched []Post
+	for _, post := range posts {
+		if strings.EqualFold(post.AuthorUsername, username) {
+			matched = append(matched, post)
+		}
+	}
+	return matched
+}
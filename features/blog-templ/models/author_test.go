@@ -0,0 +1,67 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAuthorStoreSeeded(t *testing.T) {
+	store := NewAuthorStore()
+
+	authors := store.All()
+	if len(authors) != 2 || authors[0].Username != "jane" || authors[1].Username != "john" {
+		t.Fatalf("Expected the sample authors sorted by name, got %+v", authors)
+	}
+
+	jane, err := store.Get(" JANE ")
+	if err != nil || jane.Name != "Jane Doe" {
+		t.Errorf("Expected a case-insensitive lookup, got %+v, %v", jane, err)
+	}
+	if jane.URL() != "/authors/jane" {
+		t.Errorf("Unexpected URL %s", jane.URL())
+	}
+
+	if _, err := store.Get("nobody"); !errors.Is(err, ErrAuthorNotFound) {
+		t.Errorf("Expected ErrAuthorNotFound, got %v", err)
+	}
+}
+
+func TestAuthorStoreSave(t *testing.T) {
+	store := NewAuthorStore()
+
+	saved, err := store.Save(Author{Username: "Ada", Bio: "  First programmer  ", AvatarURL: "/uploads/ada.png"})
+	if err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	if saved.Username != "ada" || saved.Name != "ada" || saved.Bio != "First programmer" {
+		t.Errorf("Expected a normalized profile, got %+v", saved)
+	}
+	if got, _ := store.Get("ada"); got != saved {
+		t.Errorf("Expected Get() to return the saved profile, got %+v", got)
+	}
+
+	for _, avatar := range []string{"javascript:alert(1)", "//evil.example.com/a.png", "data:image/png;base64,AAAA", "https://"} {
+		if _, err := store.Save(Author{Username: "ada", AvatarURL: avatar}); !errors.Is(err, ErrInvalidAvatarURL) {
+			t.Errorf("Save() with avatar %q: expected ErrInvalidAvatarURL, got %v", avatar, err)
+		}
+	}
+	if _, err := store.Save(Author{Username: "ada", Bio: strings.Repeat("가", MaxBioLength+1)}); err == nil {
+		t.Error("Expected an error for a bio over the limit")
+	}
+	if _, err := store.Save(Author{Username: "ada", Bio: strings.Repeat("가", MaxBioLength)}); err != nil {
+		t.Errorf("Expected a bio at the limit to be accepted, got %v", err)
+	}
+}
+
+func TestByAuthor(t *testing.T) {
+	store := NewStore()
+
+	if got := ids(ByAuthor(store.GetAll(), "JOHN")); !slices.Equal(got, []int{2, 4}) {
+		t.Errorf("Expected John's posts 2 and 4, got %v", got)
+	}
+	if got := ByAuthor(store.GetAll(), "nobody"); len(got) != 0 {
+		t.Errorf("Expected no posts, got %v", ids(got))
+	}
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"sync"
+)
+
+// MaxBookmarks is how many posts one reading list may hold
+const MaxBookmarks = 200
+
+// ErrReadingListFull is returned when bookmarking past MaxBookmarks
+var ErrReadingListFull = errors.New("reading list is full")
+
+// BookmarkStore keeps readers' reading lists. Readers don't log in, so
+// each list is keyed by the reader's browser session.
+type BookmarkStore interface {
+	// Toggle bookmarks a post, or removes the bookmark if there is one,
+	// and reports whether the post is now bookmarked
+	Toggle(session string, postID int) (bool, error)
+	// List returns the IDs of a session's bookmarked posts, most recently
+	// bookmarked first
+	List(session string) []int
+}
+
+// MemoryBookmarkStore keeps reading lists in memory
+type MemoryBookmarkStore struct {
+	lists map[string][]int // by session, most recent first
+	mu    sync.Mutex
+}
+
+// NewBookmarkStore creates an empty in-memory bookmark store
+func NewBookmarkStore() *MemoryBookmarkStore {
+	return &MemoryBookmarkStore{lists: make(map[string][]int)}
+}
+
+// Toggle bookmarks a post or removes its bookmark
+func (s *MemoryBookmarkStore) Toggle(session string, postID int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := s.lists[session]
+	if i := slices.Index(list, postID); i >= 0 {
+		list = slices.Delete(list, i, i+1)
+		if len(list) == 0 {
+			delete(s.lists, session)
+		} else {
+			s.lists[session] = list
+		}
+		return false, nil
+	}
+
+	if len(list) >= MaxBookmarks {
+		return false, ErrReadingListFull
+	}
+	s.lists[session] = append([]int{postID}, list...)
+	return true, nil
+}
+
+// List returns a session's bookmarked post IDs, most recent first
+func (s *MemoryBookmarkStore) List(session string) []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.lists[session])
+}
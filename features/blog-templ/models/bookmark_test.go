@@ -0,0 +1,44 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBookmarkToggle(t *testing.T) {
+	store := NewBookmarkStore()
+
+	store.Toggle("reader", 1)
+	store.Toggle("reader", 3)
+	if on, _ := store.Toggle("reader", 2); !on {
+		t.Error("Expected a new bookmark to be on")
+	}
+	if got := store.List("reader"); !slices.Equal(got, []int{2, 3, 1}) {
+		t.Errorf("Expected the newest bookmark first, got %v", got)
+	}
+
+	if on, _ := store.Toggle("reader", 3); on {
+		t.Error("Expected toggling again to remove the bookmark")
+	}
+	if got := store.List("reader"); !slices.Equal(got, []int{2, 1}) {
+		t.Errorf("Expected the bookmark to be gone, got %v", got)
+	}
+	if got := store.List("someone else"); len(got) != 0 {
+		t.Errorf("Expected lists to be kept per session, got %v", got)
+	}
+}
+
+func TestBookmarkLimit(t *testing.T) {
+	store := NewBookmarkStore()
+	for id := 1; id <= MaxBookmarks; id++ {
+		store.Toggle("reader", id)
+	}
+
+	if _, err := store.Toggle("reader", MaxBookmarks+1); err != ErrReadingListFull {
+		t.Errorf("Expected ErrReadingListFull, got %v", err)
+	}
+	// Removing still works on a full list
+	if on, err := store.Toggle("reader", 1); on || err != nil {
+		t.Errorf("Expected the bookmark to be removed, got %v %v", on, err)
+	}
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// MaxCategoryNameLength is the longest accepted category name
+const MaxCategoryNameLength = 50
+
+var (
+	// ErrCategoryNotFound is returned when no category has the requested slug
+	ErrCategoryNotFound = errors.New("category not found")
+	// ErrCategoryExists is returned when adding a category whose slug is taken
+	ErrCategoryExists = errors.New("a category with that name already exists")
+)
+
+// Category groups posts by topic. Unlike tags, a post has at most one
+// category and categories are managed by authors up front.
+type Category struct {
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// URL returns the path of the category's landing page
+func (c Category) URL() string {
+	return "/categories/" + c.Slug
+}
+
+// CategoryStore manages the list of categories
+type CategoryStore interface {
+	All() []Category
+	Get(slug string) (Category, error)
+	Add(name, description string) (Category, error)
+	Delete(slug string) error
+}
+
+// MemoryCategoryStore keeps categories in memory
+type MemoryCategoryStore struct {
+	categories []Category
+	mu         sync.Mutex
+}
+
+// NewCategoryStore creates an in-memory category store with the categories
+// used by the sample posts
+func NewCategoryStore() *MemoryCategoryStore {
+	return &MemoryCategoryStore{categories: sampleCategories()}
+}
+
+// sampleCategories returns the demo categories the blog starts with
+func sampleCategories() []Category {
+	return []Category{
+		{Slug: "tutorials", Name: "Tutorials", Description: "Step-by-step guides"},
+		{Slug: "web-development", Name: "Web Development", Description: "Building for the web with Go"},
+	}
+}
+
+// All returns every category sorted by name
+func (s *MemoryCategoryStore) All() []Category {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	categories := slices.Clone(s.categories)
+	slices.SortFunc(categories, func(a, b Category) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return categories
+}
+
+// Get returns the category with the given slug
+func (s *MemoryCategoryStore) Get(slug string) (Category, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, category := range s.categories {
+		if category.Slug == slug {
+			return category, nil
+		}
+	}
+
+	return Category{}, ErrCategoryNotFound
+}
+
+// Add validates and stores a category, deriving its slug from the name
+func (s *MemoryCategoryStore) Add(name, description string) (Category, error) {
+	category := Category{
+		Slug:        slugify(name),
+		Name:        strings.TrimSpace(name),
+		Description: strings.TrimSpace(description),
+	}
+
+	if category.Name == "" {
+		return Category{}, errors.New("category name is required")
+	}
+	if utf8.RuneCountInString(category.Name) > MaxCategoryNameLength {
+		return Category{}, errors.New("category name must be at most 50 characters")
+	}
+	if category.Slug == "" {
+		return Category{}, errors.New("category name must contain letters or digits")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.categories {
+		if existing.Slug == category.Slug {
+			return Category{}, ErrCategoryExists
+		}
+	}
+
+	s.categories = append(s.categories, category)
+	return category, nil
+}
+
+// Delete removes a category. Posts in it keep their slug, so callers
+// should only delete categories nothing uses.
+func (s *MemoryCategoryStore) Delete(slug string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.categories, func(c Category) bool { return c.Slug == slug })
+	if i < 0 {
+		return ErrCategoryNotFound
+	}
+
+	s.categories = slices.Delete(slices.Clip(s.categories), i, i+1)
+	return nil
+}
+
+// InCategory returns the posts in the category with the given slug
+func InCategory(posts []Post, slug string) []Post {
+	var results []Post
+	for _, post := range posts {
+		if post.Category == slug {
+			results = append(results, post)
+		}
+	}
+	return results
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCategoryStoreAdd(t *testing.T) {
+	store := NewCategoryStore()
+
+	category, err := store.Add("  Go Internals ", "How the runtime works")
+	if err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if category.Slug != "go-internals" || category.Name != "Go Internals" {
+		t.Errorf("Expected slug go-internals for Go Internals, got %+v", category)
+	}
+	if category.URL() != "/categories/go-internals" {
+		t.Errorf("Unexpected URL %s", category.URL())
+	}
+
+	got, err := store.Get("go-internals")
+	if err != nil || got != category {
+		t.Errorf("Expected Get() to return the new category, got %+v, %v", got, err)
+	}
+
+	// Slugs must be unique, whatever the name's case or punctuation
+	if _, err := store.Add("go internals!", ""); !errors.Is(err, ErrCategoryExists) {
+		t.Errorf("Expected ErrCategoryExists, got %v", err)
+	}
+}
+
+func TestCategoryStoreAddValidation(t *testing.T) {
+	store := NewCategoryStore()
+
+	for _, name := range []string{"", "   ", "!!!", strings.Repeat("a", MaxCategoryNameLength+1)} {
+		if _, err := store.Add(name, ""); err == nil {
+			t.Errorf("Expected Add(%q) to fail", name)
+		}
+	}
+}
+
+func TestCategoryStoreAllSortsByName(t *testing.T) {
+	store := NewCategoryStore()
+	store.Add("apis", "")
+
+	var names []string
+	for _, category := range store.All() {
+		names = append(names, category.Name)
+	}
+	if strings.Join(names, ",") != "apis,Tutorials,Web Development" {
+		t.Errorf("Expected categories sorted by name, got %v", names)
+	}
+}
+
+func TestCategoryStoreDelete(t *testing.T) {
+	store := NewCategoryStore()
+
+	if err := store.Delete("tutorials"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if _, err := store.Get("tutorials"); !errors.Is(err, ErrCategoryNotFound) {
+		t.Errorf("Expected ErrCategoryNotFound, got %v", err)
+	}
+	if err := store.Delete("tutorials"); !errors.Is(err, ErrCategoryNotFound) {
+		t.Errorf("Expected deleting twice to fail, got %v", err)
+	}
+}
+
+func TestInCategory(t *testing.T) {
+	posts := InCategory(NewStore().GetAll(), "tutorials")
+
+	if len(posts) != 2 {
+		t.Fatalf("Expected 2 tutorials, got %d", len(posts))
+	}
+	for _, post := range posts {
+		if post.Category != "tutorials" {
+			t.Errorf("Unexpected post %d in category %q", post.ID, post.Category)
+		}
+	}
+}
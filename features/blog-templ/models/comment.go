@@ -0,0 +1,167 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxCommentAuthorLength is the longest accepted commenter name
+	MaxCommentAuthorLength = 50
+	// MaxCommentLength is the longest accepted comment body
+	MaxCommentLength = 1000
+)
+
+// ErrCommentNotFound is returned when a comment ID doesn't exist
+var ErrCommentNotFound = errors.New("comment not found")
+
+// CommentStatus says whether a comment is shown on its post
+type CommentStatus string
+
+const (
+	// CommentApproved comments are shown on their post
+	CommentApproved CommentStatus = "approved"
+	// CommentPending comments wait in the moderation queue
+	CommentPending CommentStatus = "pending"
+)
+
+// Comment represents a reader comment on a post
+type Comment struct {
+	ID        int           `json:"id"`
+	PostID    int           `json:"post_id"`
+	Author    string        `json:"author"`
+	Content   string        `json:"content"`
+	Status    CommentStatus `json:"status"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// CommentStore manages comments on posts
+type CommentStore interface {
+	// ForPost returns a post's approved comments
+	ForPost(postID int) []Comment
+	// Add stores a comment; without a status it is approved
+	Add(comment Comment) (Comment, error)
+	// Pending returns the comments awaiting moderation
+	Pending() []Comment
+	Approve(id int) (Comment, error)
+	Delete(id int) error
+}
+
+// MemoryCommentStore keeps comments in memory
+type MemoryCommentStore struct {
+	comments []Comment
+	mu       sync.Mutex
+	nextID   int
+}
+
+// NewCommentStore creates an empty in-memory comment store
+func NewCommentStore() *MemoryCommentStore {
+	return &MemoryCommentStore{nextID: 1}
+}
+
+// ForPost returns the approved comments on a post, oldest first
+func (s *MemoryCommentStore) ForPost(postID int) []Comment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var results []Comment
+	for _, comment := range s.comments {
+		if comment.PostID == postID && comment.Status == CommentApproved {
+			results = append(results, comment)
+		}
+	}
+
+	return results
+}
+
+// Add validates and stores a comment, returning it with ID and timestamp set
+func (s *MemoryCommentStore) Add(comment Comment) (Comment, error) {
+	comment.Author = strings.TrimSpace(comment.Author)
+	comment.Content = strings.TrimSpace(comment.Content)
+	if comment.Status == "" {
+		comment.Status = CommentApproved
+	}
+
+	if err := validateComment(comment); err != nil {
+		return Comment{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comment.ID = s.nextID
+	s.nextID++
+	comment.CreatedAt = time.Now()
+
+	s.comments = append(s.comments, comment)
+
+	return comment, nil
+}
+
+// Pending returns the comments held for moderation, oldest first
+func (s *MemoryCommentStore) Pending() []Comment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var results []Comment
+	for _, comment := range s.comments {
+		if comment.Status == CommentPending {
+			results = append(results, comment)
+		}
+	}
+	return results
+}
+
+// Approve publishes a held comment
+func (s *MemoryCommentStore) Approve(id int) (Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.comments {
+		if s.comments[i].ID == id {
+			s.comments[i].Status = CommentApproved
+			return s.comments[i], nil
+		}
+	}
+	return Comment{}, ErrCommentNotFound
+}
+
+// Delete removes a comment
+func (s *MemoryCommentStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.comments {
+		if s.comments[i].ID == id {
+			s.comments = append(s.comments[:i], s.comments[i+1:]...)
+			return nil
+		}
+	}
+	return ErrCommentNotFound
+}
+
+// validateComment checks required fields and length limits
+func validateComment(comment Comment) error {
+	if comment.PostID <= 0 {
+		return errors.New("post is required")
+	}
+	if comment.Author == "" {
+		return errors.New("name is required")
+	}
+	if utf8.RuneCountInString(comment.Author) > MaxCommentAuthorLength {
+		return errors.New("name is too long")
+	}
+	if comment.Status != CommentApproved && comment.Status != CommentPending {
+		return errors.New("unknown comment status")
+	}
+	if comment.Content == "" {
+		return errors.New("comment is required")
+	}
+	if utf8.RuneCountInString(comment.Content) > MaxCommentLength {
+		return errors.New("comment is too long")
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAddComment(t *testing.T) {
+	store := NewCommentStore()
+
+	comment, err := store.Add(Comment{PostID: 1, Author: "  Reader ", Content: " Nice post! "})
+	if err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+
+	if comment.ID == 0 {
+		t.Error("Expected auto-generated ID")
+	}
+	if comment.CreatedAt.IsZero() {
+		t.Error("Expected CreatedAt to be set")
+	}
+	if comment.Author != "Reader" || comment.Content != "Nice post!" {
+		t.Errorf("Expected trimmed fields, got %q / %q", comment.Author, comment.Content)
+	}
+}
+
+func TestCommentsForPost(t *testing.T) {
+	store := NewCommentStore()
+
+	store.Add(Comment{PostID: 1, Author: "A", Content: "first"})
+	store.Add(Comment{PostID: 2, Author: "B", Content: "other post"})
+	store.Add(Comment{PostID: 1, Author: "C", Content: "second"})
+
+	comments := store.ForPost(1)
+	if len(comments) != 2 {
+		t.Fatalf("Expected 2 comments for post 1, got %d", len(comments))
+	}
+	if comments[0].Content != "first" || comments[1].Content != "second" {
+		t.Error("Expected comments oldest first")
+	}
+
+	if len(store.ForPost(3)) != 0 {
+		t.Error("Expected no comments for post 3")
+	}
+}
+
+func TestAddCommentValidation(t *testing.T) {
+	store := NewCommentStore()
+
+	tests := []struct {
+		name    string
+		comment Comment
+		wantErr bool
+	}{
+		{
+			name:    "valid comment",
+			comment: Comment{PostID: 1, Author: "Reader", Content: "Hello"},
+			wantErr: false,
+		},
+		{
+			name:    "missing post",
+			comment: Comment{Author: "Reader", Content: "Hello"},
+			wantErr: true,
+		},
+		{
+			name:    "blank author",
+			comment: Comment{PostID: 1, Author: "   ", Content: "Hello"},
+			wantErr: true,
+		},
+		{
+			name:    "blank content",
+			comment: Comment{PostID: 1, Author: "Reader", Content: "  "},
+			wantErr: true,
+		},
+		{
+			name:    "author too long",
+			comment: Comment{PostID: 1, Author: strings.Repeat("a", MaxCommentAuthorLength+1), Content: "Hello"},
+			wantErr: true,
+		},
+		{
+			name:    "content too long",
+			comment: Comment{PostID: 1, Author: "Reader", Content: strings.Repeat("a", MaxCommentLength+1)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := store.Add(tt.comment)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Add() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCommentModeration(t *testing.T) {
+	store := NewCommentStore()
+	store.Add(Comment{PostID: 1, Author: "Reader", Content: "Hello"})
+	held, err := store.Add(Comment{PostID: 1, Author: "Stranger", Content: "Check my site", Status: CommentPending})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := store.ForPost(1); len(got) != 1 || got[0].Author != "Reader" {
+		t.Errorf("Expected held comments to be hidden, got %+v", got)
+	}
+	if got := store.Pending(); len(got) != 1 || got[0].ID != held.ID {
+		t.Errorf("Expected the held comment in the queue, got %+v", got)
+	}
+
+	if _, err := store.Approve(held.ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.ForPost(1)) != 2 || len(store.Pending()) != 0 {
+		t.Error("Expected the approved comment on the post")
+	}
+
+	if err := store.Delete(held.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(held.ID); err != ErrCommentNotFound {
+		t.Errorf("Expected ErrCommentNotFound, got %v", err)
+	}
+	if _, err := store.Approve(999); err != ErrCommentNotFound {
+		t.Errorf("Expected ErrCommentNotFound, got %v", err)
+	}
+}
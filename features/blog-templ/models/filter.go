@@ -0,0 +1,115 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FilterDateFormat is the layout of the from and to search filter dates
+const FilterDateFormat = "2006-01-02"
+
+// SearchFilter narrows posts beyond the text query. Empty fields match
+// every post.
+type SearchFilter struct {
+	// Author matches the author's display name or username, ignoring case
+	Author string
+	// Tag matches one of the post's tags, ignoring case
+	Tag string
+	// Category is a category slug
+	Category string
+	// From and To bound the publication date; both days are included
+	From time.Time
+	To   time.Time
+}
+
+// ParseSearchFilter builds a filter from form values, with dates in
+// FilterDateFormat
+func ParseSearchFilter(author, tag, category, from, to string) (SearchFilter, error) {
+	filter := SearchFilter{
+		Author:   strings.TrimSpace(author),
+		Tag:      strings.TrimSpace(tag),
+		Category: strings.TrimSpace(category),
+	}
+
+	var err error
+	if filter.From, err = parseFilterDate(from); err != nil {
+		return SearchFilter{}, fmt.Errorf("invalid from date %q, want YYYY-MM-DD", from)
+	}
+	if filter.To, err = parseFilterDate(to); err != nil {
+		return SearchFilter{}, fmt.Errorf("invalid to date %q, want YYYY-MM-DD", to)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return SearchFilter{}, errors.New("the to date is before the from date")
+	}
+
+	return filter, nil
+}
+
+// parseFilterDate parses a date, returning the zero time for an empty value
+func parseFilterDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation(FilterDateFormat, value, time.Local)
+}
+
+// IsZero reports whether the filter matches every post
+func (f SearchFilter) IsZero() bool {
+	return f == SearchFilter{}
+}
+
+// Matches reports whether a post passes every set field of the filter.
+// Drafts are dated by when they were created.
+func (f SearchFilter) Matches(post Post) bool {
+	if f.Author != "" && !strings.EqualFold(post.Author, f.Author) && !strings.EqualFold(post.AuthorUsername, f.Author) {
+		return false
+	}
+	if f.Tag != "" && !hasTag(post, f.Tag) {
+		return false
+	}
+	if f.Category != "" && post.Category != f.Category {
+		return false
+	}
+
+	date := post.PublishedAt
+	if date.IsZero() {
+		date = post.CreatedAt
+	}
+	if !f.From.IsZero() && date.Before(f.From) {
+		return false
+	}
+	// To is a whole day, so compare against the start of the next one
+	if !f.To.IsZero() && !date.Before(f.To.AddDate(0, 0, 1)) {
+		return false
+	}
+
+	return true
+}
+
+// FilterPosts returns the posts matching the filter, keeping their order
+func FilterPosts(posts []Post, filter SearchFilter) []Post {
+	if filter.IsZero() {
+		return posts
+	}
+
+	var results []Post
+	for _, post := range posts {
+		if filter.Matches(post) {
+			results = append(results, post)
+		}
+	}
+	return results
+}
+
+// hasTag reports whether the post carries tag, ignoring case
+func hasTag(post Post, tag string) bool {
+	for _, t := range post.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSearchFilter(t *testing.T) {
+	filter, err := ParseSearchFilter(" jane ", "go", "", "2024-03-01", "2024-03-31")
+	if err != nil {
+		t.Fatalf("ParseSearchFilter() failed: %v", err)
+	}
+	if filter.Author != "jane" || filter.Tag != "go" {
+		t.Errorf("Expected trimmed author and tag, got %+v", filter)
+	}
+	if filter.From.Day() != 1 || filter.To.Day() != 31 || filter.To.Month() != time.March {
+		t.Errorf("Unexpected dates %v to %v", filter.From, filter.To)
+	}
+
+	empty, err := ParseSearchFilter("", "", "", "", "")
+	if err != nil || !empty.IsZero() {
+		t.Errorf("Expected a zero filter, got %+v, %v", empty, err)
+	}
+
+	for _, dates := range [][2]string{{"03/01/2024", ""}, {"", "tomorrow"}, {"2024-03-02", "2024-03-01"}} {
+		if _, err := ParseSearchFilter("", "", "", dates[0], dates[1]); err == nil {
+			t.Errorf("Expected dates %q to be rejected", dates)
+		}
+	}
+}
+
+func TestSearchFilterDates(t *testing.T) {
+	day := func(d, hour int) time.Time {
+		return time.Date(2024, time.March, d, hour, 0, 0, 0, time.Local)
+	}
+	filter := SearchFilter{From: day(10, 0), To: day(12, 0)}
+
+	tests := []struct {
+		published time.Time
+		want      bool
+	}{
+		{day(9, 23), false},
+		{day(10, 0), true},
+		{day(12, 23), true},
+		{day(13, 0), false},
+	}
+	for _, tt := range tests {
+		post := Post{Published: true, PublishedAt: tt.published}
+		if got := filter.Matches(post); got != tt.want {
+			t.Errorf("Matches(%v) = %v, want %v", tt.published, got, tt.want)
+		}
+	}
+
+	// Drafts are dated by creation
+	if !filter.Matches(Post{CreatedAt: day(11, 12)}) {
+		t.Error("Expected a draft created in range to match")
+	}
+}
+
+func TestFilterPosts(t *testing.T) {
+	posts := NewStore().GetAll()
+
+	if got := FilterPosts(posts, SearchFilter{}); len(got) != len(posts) {
+		t.Errorf("Expected a zero filter to keep all %d posts, got %d", len(posts), len(got))
+	}
+
+	got := FilterPosts(posts, SearchFilter{Author: "Jane Doe", Tag: "GO"})
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 posts, got %d", len(got))
+	}
+	for _, post := range got {
+		if post.AuthorUsername != "jane" {
+			t.Errorf("Unexpected post %d by %s", post.ID, post.Author)
+		}
+	}
+
+	if got := FilterPosts(posts, SearchFilter{Category: "tutorials", Author: "john"}); len(got) != 1 || got[0].ID != 2 {
+		t.Errorf("Expected only post 2, got %v", ids(got))
+	}
+}
@@ -0,0 +1,216 @@
+package models
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"image"
+	_ "image/gif" // register the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Upload limits
+const (
+	MaxImageSize   = 5 << 20 // 5 MB
+	MaxImagePixels = 40_000_000
+	ThumbnailWidth = 320
+)
+
+var (
+	ErrImageTooLarge    = errors.New("image must be 5 MB or smaller")
+	ErrUnsupportedImage = errors.New("only JPEG, PNG and GIF images are supported")
+)
+
+// imageExtensions maps accepted content types to file extensions
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// imageNamePattern matches the names generated by Save
+var imageNamePattern = regexp.MustCompile(`^[0-9a-f]{32}\.(jpg|png|gif)$`)
+
+// Image describes a stored upload and its thumbnail
+type Image struct {
+	Name        string `json:"name"`
+	Thumbnail   string `json:"thumbnail"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
+
+// URL returns the path the original image is served from
+func (img Image) URL() string {
+	return ImageURL(img.Name)
+}
+
+// ThumbnailURL returns the path the thumbnail is served from
+func (img Image) ThumbnailURL() string {
+	return ImageURL(img.Thumbnail)
+}
+
+// ImageURL returns the path a stored image file is served from
+func ImageURL(name string) string {
+	return "/uploads/" + name
+}
+
+// ThumbnailName returns the thumbnail file name for an image name
+func ThumbnailName(name string) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if ext == ".jpg" {
+		return base + "_thumb.jpg"
+	}
+	// GIF thumbnails are stored as PNG since only the first frame is kept
+	return base + "_thumb.png"
+}
+
+// ValidImageName reports whether name looks like an image stored by Save
+func ValidImageName(name string) bool {
+	return imageNamePattern.MatchString(name)
+}
+
+// ImageStore stores uploaded images and serves them back as files
+type ImageStore interface {
+	fs.FS
+	Save(data []byte) (Image, error)
+	Exists(name string) bool
+}
+
+// DiskImageStore keeps uploaded images in a directory
+type DiskImageStore struct {
+	dir string
+	fs.FS
+}
+
+// NewDiskImageStore creates an image store in dir, creating it if needed
+func NewDiskImageStore(dir string) (*DiskImageStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DiskImageStore{dir: dir, FS: os.DirFS(dir)}, nil
+}
+
+// Save validates an uploaded image, then writes it with a random name
+// alongside a generated thumbnail
+func (s *DiskImageStore) Save(data []byte) (Image, error) {
+	img, thumb, err := prepareImage(data)
+	if err != nil {
+		return Image{}, err
+	}
+
+	if err := os.WriteFile(filepath.Join(s.dir, img.Name), data, 0o644); err != nil {
+		return Image{}, err
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, img.Thumbnail), thumb, 0o644); err != nil {
+		os.Remove(filepath.Join(s.dir, img.Name))
+		return Image{}, err
+	}
+
+	return img, nil
+}
+
+// Exists reports whether an image with the given name has been stored
+func (s *DiskImageStore) Exists(name string) bool {
+	if !ValidImageName(name) {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(s.dir, name))
+	return err == nil
+}
+
+// prepareImage validates an upload, names it and encodes its thumbnail
+func prepareImage(data []byte) (Image, []byte, error) {
+	if len(data) > MaxImageSize {
+		return Image{}, nil, ErrImageTooLarge
+	}
+
+	// Trust the bytes, not the client-supplied filename or header
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return Image{}, nil, ErrUnsupportedImage
+	}
+
+	// Check dimensions before decoding to avoid decompression bombs
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width*cfg.Height > MaxImagePixels {
+		return Image{}, nil, ErrUnsupportedImage
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, nil, ErrUnsupportedImage
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Image{}, nil, err
+	}
+	name := hex.EncodeToString(id) + ext
+
+	var thumb bytes.Buffer
+	scaled := Thumbnail(src, ThumbnailWidth)
+	if ext == ".jpg" {
+		err = jpeg.Encode(&thumb, scaled, &jpeg.Options{Quality: 80})
+	} else {
+		err = png.Encode(&thumb, scaled)
+	}
+	if err != nil {
+		return Image{}, nil, err
+	}
+
+	return Image{
+		Name:        name,
+		Thumbnail:   ThumbnailName(name),
+		ContentType: contentType,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+	}, thumb.Bytes(), nil
+}
+
+// Thumbnail scales src down to at most width pixels wide, keeping its
+// aspect ratio. Each output pixel averages the source pixels it covers.
+func Thumbnail(src image.Image, width int) image.Image {
+	b := src.Bounds()
+	if b.Dx() <= width {
+		width = b.Dx()
+	}
+	height := max(1, b.Dy()*width/b.Dx())
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := b.Min.Y + y*b.Dy()/height
+		y1 := max(y0+1, b.Min.Y+(y+1)*b.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := b.Min.X + x*b.Dx()/width
+			x1 := max(x0+1, b.Min.X+(x+1)*b.Dx()/width)
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(pr), g+uint64(pg), bl+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(bl / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDiskImageStoreSave(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDiskImageStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := store.Save(encodePNG(t, 800, 400))
+	if err != nil {
+		t.Fatalf("Expected upload to be saved, got %v", err)
+	}
+
+	if !ValidImageName(img.Name) || filepath.Ext(img.Name) != ".png" {
+		t.Errorf("Unexpected image name %q", img.Name)
+	}
+	if img.Width != 800 || img.Height != 400 || img.ContentType != "image/png" {
+		t.Errorf("Unexpected image metadata: %+v", img)
+	}
+	if !store.Exists(img.Name) {
+		t.Error("Expected stored image to exist")
+	}
+
+	thumb, err := os.ReadFile(filepath.Join(dir, img.Thumbnail))
+	if err != nil {
+		t.Fatalf("Expected thumbnail on disk: %v", err)
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != ThumbnailWidth || cfg.Height != ThumbnailWidth/2 {
+		t.Errorf("Expected %dx%d thumbnail, got %dx%d", ThumbnailWidth, ThumbnailWidth/2, cfg.Width, cfg.Height)
+	}
+}
+
+func TestDiskImageStoreRejectsInvalidUploads(t *testing.T) {
+	store, err := NewDiskImageStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{name: "text", data: []byte("hello, not an image"), want: ErrUnsupportedImage},
+		{name: "svg", data: []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), want: ErrUnsupportedImage},
+		{name: "truncated png", data: encodePNG(t, 10, 10)[:40], want: ErrUnsupportedImage},
+		{name: "too large", data: make([]byte, MaxImageSize+1), want: ErrImageTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := store.Save(tt.data); err != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestThumbnailKeepsSmallImages(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 100, 50))
+
+	thumb := Thumbnail(src, ThumbnailWidth)
+
+	if b := thumb.Bounds(); b.Dx() != 100 || b.Dy() != 50 {
+		t.Errorf("Expected small image to keep its size, got %v", b)
+	}
+}
+
+func TestThumbnailAveragesPixels(t *testing.T) {
+	// Alternating black and white columns average out to grey
+	src := image.NewGray(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x += 2 {
+		src.SetGray(x, 0, color.Gray{Y: 255})
+		src.SetGray(x, 1, color.Gray{Y: 255})
+	}
+
+	thumb := Thumbnail(src, 2)
+
+	r, _, _, _ := thumb.At(0, 0).RGBA()
+	if got := r >> 8; got < 120 || got > 135 {
+		t.Errorf("Expected averaged grey, got %d", got)
+	}
+}
+
+func TestImageNames(t *testing.T) {
+	name := "0123456789abcdef0123456789abcdef.gif"
+
+	if !ValidImageName(name) {
+		t.Errorf("Expected %q to be valid", name)
+	}
+	for _, bad := range []string{"../secret.png", "photo.png", name + "/x", ""} {
+		if ValidImageName(bad) {
+			t.Errorf("Expected %q to be invalid", bad)
+		}
+	}
+
+	if got := ThumbnailName(name); got != "0123456789abcdef0123456789abcdef_thumb.png" {
+		t.Errorf("Unexpected GIF thumbnail name %q", got)
+	}
+	if got := ThumbnailName("0123456789abcdef0123456789abcdef.jpg"); got != "0123456789abcdef0123456789abcdef_thumb.jpg" {
+		t.Errorf("Unexpected JPEG thumbnail name %q", got)
+	}
+}
@@ -0,0 +1,274 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// JSONStore keeps blog posts in memory and persists them to a JSON file
+type JSONStore struct {
+	*MemoryStore
+	path string
+	// writeMu serializes each change with the file write that follows it,
+	// so the file never goes back to an older state
+	writeMu sync.Mutex
+	// viewsDirty is set when views were counted since the file was last
+	// written. RecordView only counts in memory; FlushViews writes them.
+	viewsDirty atomic.Bool
+}
+
+// NewJSONStore loads posts from the JSON file at path.
+// A missing file is not an error: the store starts empty and the
+// file is created on the first write.
+func NewJSONStore(path string) (*JSONStore, error) {
+	posts, err := loadPosts(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JSONStore{
+		MemoryStore: newMemoryStore(posts),
+		path:        path,
+	}, nil
+}
+
+// Add adds a new post and writes the store to disk
+func (s *JSONStore) Add(post Post) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := s.MemoryStore.Add(post); err != nil {
+		return err
+	}
+
+	return s.save()
+}
+
+// Import adds a post keeping its dates and writes the store to disk
+func (s *JSONStore) Import(post Post) (Post, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	imported, err := s.MemoryStore.Import(post)
+	if err != nil {
+		return Post{}, err
+	}
+
+	return imported, s.save()
+}
+
+// Update changes an existing post and writes the store to disk
+func (s *JSONStore) Update(post Post) (Post, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	updated, err := s.MemoryStore.Update(post)
+	if err != nil {
+		return Post{}, err
+	}
+
+	return updated, s.save()
+}
+
+// Delete moves a post to the trash and writes the store to disk
+func (s *JSONStore) Delete(id int) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := s.MemoryStore.Delete(id); err != nil {
+		return err
+	}
+
+	return s.save()
+}
+
+// Publish makes a draft publicly visible and writes the store to disk
+func (s *JSONStore) Publish(id int) (Post, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	post, err := s.MemoryStore.Publish(id)
+	if err != nil {
+		return Post{}, err
+	}
+
+	return post, s.save()
+}
+
+// RecordView counts a view of a post in memory. Writing the whole file on
+// every page view would hold up all other writes, so views reach the disk
+// with the next change or FlushViews.
+func (s *JSONStore) RecordView(id int) (int, error) {
+	views, err := s.MemoryStore.RecordView(id)
+	if err != nil {
+		return 0, err
+	}
+
+	s.viewsDirty.Store(true)
+	return views, nil
+}
+
+// FlushViews writes the store to disk if views were counted since it was
+// last written
+func (s *JSONStore) FlushViews() error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if !s.viewsDirty.Load() {
+		return nil
+	}
+	return s.save()
+}
+
+// React adds a reaction to a post and writes the store to disk
+func (s *JSONStore) React(id int, reaction Reaction) (Post, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	post, err := s.MemoryStore.React(id, reaction)
+	if err != nil {
+		return Post{}, err
+	}
+
+	return post, s.save()
+}
+
+// Restore takes a post out of the trash and writes the store to disk
+func (s *JSONStore) Restore(id int) (Post, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	post, err := s.MemoryStore.Restore(id)
+	if err != nil {
+		return Post{}, err
+	}
+
+	return post, s.save()
+}
+
+// Purge permanently deletes a trashed post and writes the store to disk
+func (s *JSONStore) Purge(id int) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := s.MemoryStore.Purge(id); err != nil {
+		return err
+	}
+
+	return s.save()
+}
+
+// PurgeTrash permanently deletes expired trash, writing the store to disk
+// if anything was removed
+func (s *JSONStore) PurgeTrash(before time.Time) (int, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	purged, _ := s.MemoryStore.PurgeTrash(before)
+	if purged == 0 {
+		return 0, nil
+	}
+
+	return purged, s.save()
+}
+
+// RestoreRevision restores an old version of a post and writes the store to disk
+func (s *JSONStore) RestoreRevision(id, number int) (Post, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	post, err := s.MemoryStore.RestoreRevision(id, number)
+	if err != nil {
+		return Post{}, err
+	}
+
+	return post, s.save()
+}
+
+// SetSlug renames a post's slug and writes the store to disk
+func (s *JSONStore) SetSlug(id int, slug string) (Post, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	post, err := s.MemoryStore.SetSlug(id, slug)
+	if err != nil {
+		return Post{}, err
+	}
+
+	return post, s.save()
+}
+
+// save writes the store to disk, views counted so far included. Views
+// counted while it writes mark the store dirty again.
+func (s *JSONStore) save() error {
+	s.viewsDirty.Store(false)
+	if err := s.writeFile(); err != nil {
+		s.viewsDirty.Store(true)
+		return err
+	}
+	return nil
+}
+
+// writeFile writes all posts, trashed ones included, atomically: the data
+// goes to a temp file in the same directory which is then renamed over the
+// target
+func (s *JSONStore) writeFile() error {
+	s.mu.RLock()
+	all := append(slices.Clip(s.posts), s.trash...)
+	data, err := json.MarshalIndent(all, "", "  ")
+	s.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("encode posts: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	// Clean up the temp file if anything below fails
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("replace data file: %w", err)
+	}
+
+	return nil
+}
+
+// loadPosts reads posts from a JSON file, returning none if it doesn't exist
+func loadPosts(path string) ([]Post, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read data file: %w", err)
+	}
+
+	var posts []Post
+	if err := json.Unmarshal(data, &posts); err != nil {
+		return nil, fmt.Errorf("decode data file: %w", err)
+	}
+
+	return posts, nil
+}
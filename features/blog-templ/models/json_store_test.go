@@ -0,0 +1,150 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Both implementations must satisfy the Store interface
+var (
+	_ Store = (*MemoryStore)(nil)
+	_ Store = (*JSONStore)(nil)
+)
+
+func TestJSONStoreMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posts.json")
+
+	store, err := NewJSONStore(path)
+	if err != nil {
+		t.Fatalf("NewJSONStore() failed: %v", err)
+	}
+
+	if len(store.GetAll()) != 0 {
+		t.Errorf("Expected empty store, got %d posts", len(store.GetAll()))
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected no data file before the first write")
+	}
+}
+
+func TestJSONStorePersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posts.json")
+
+	store, err := NewJSONStore(path)
+	if err != nil {
+		t.Fatalf("NewJSONStore() failed: %v", err)
+	}
+
+	if err := store.Add(Post{Title: "First", Content: "Hello", Tags: []string{"go"}}); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if err := store.Add(Post{Title: "Second", Content: "World"}); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+
+	reloaded, err := NewJSONStore(path)
+	if err != nil {
+		t.Fatalf("NewJSONStore() reload failed: %v", err)
+	}
+
+	posts := reloaded.GetAll()
+	if len(posts) != 2 {
+		t.Fatalf("Expected 2 posts after reload, got %d", len(posts))
+	}
+	if posts[0].Title != "Second" || posts[1].Title != "First" {
+		t.Errorf("Unexpected order after reload: %q, %q", posts[0].Title, posts[1].Title)
+	}
+	if len(posts[1].Tags) != 1 || posts[1].Tags[0] != "go" {
+		t.Errorf("Expected tags to survive reload, got %v", posts[1].Tags)
+	}
+
+	// IDs continue after the highest loaded ID
+	if err := reloaded.Add(Post{Title: "Third", Content: "Again"}); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if got := reloaded.GetAll()[0].ID; got != 3 {
+		t.Errorf("Expected next ID 3, got %d", got)
+	}
+}
+
+func TestJSONStoreValidationDoesNotWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posts.json")
+
+	store, err := NewJSONStore(path)
+	if err != nil {
+		t.Fatalf("NewJSONStore() failed: %v", err)
+	}
+
+	if err := store.Add(Post{Title: "", Content: "No title"}); err == nil {
+		t.Fatal("Expected validation error")
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected no data file after a rejected write")
+	}
+}
+
+func TestJSONStoreLeavesNoTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "posts.json")
+
+	store, err := NewJSONStore(path)
+	if err != nil {
+		t.Fatalf("NewJSONStore() failed: %v", err)
+	}
+	if err := store.Add(Post{Title: "Title", Content: "Content"}); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "posts.json" {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("Expected only posts.json in data dir, got %v", names)
+	}
+}
+
+func TestJSONStoreCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posts.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewJSONStore(path); err == nil {
+		t.Error("Expected error for corrupt data file")
+	}
+}
+
+func TestJSONStorePersistsPublish(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posts.json")
+
+	store, err := NewJSONStore(path)
+	if err != nil {
+		t.Fatalf("NewJSONStore() failed: %v", err)
+	}
+	if err := store.Add(Post{Title: "Draft", Content: "Soon"}); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if _, err := store.Publish(1); err != nil {
+		t.Fatalf("Publish() failed: %v", err)
+	}
+
+	reloaded, err := NewJSONStore(path)
+	if err != nil {
+		t.Fatalf("NewJSONStore() reload failed: %v", err)
+	}
+	post, err := reloaded.GetByID(1)
+	if err != nil {
+		t.Fatalf("GetByID() failed: %v", err)
+	}
+	if !post.Published || post.PublishedAt.IsZero() {
+		t.Error("Expected published state to survive reload")
+	}
+}
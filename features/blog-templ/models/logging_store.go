@@ -0,0 +1,135 @@
+package models
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// LoggingStore wraps a Store and logs each operation that changes posts,
+// with how long it took. Busy operations (searches, views and reactions)
+// are logged at debug level; failures are logged at warn level.
+type LoggingStore struct {
+	Store
+	logger *slog.Logger
+}
+
+// NewLoggingStore creates a store that logs store's operations to logger
+func NewLoggingStore(store Store, logger *slog.Logger) *LoggingStore {
+	return &LoggingStore{Store: store, logger: logger}
+}
+
+// Search logs the query and how many posts matched
+func (s *LoggingStore) Search(query string) []Post {
+	start := time.Now()
+	posts := s.Store.Search(query)
+	s.log(slog.LevelDebug, "search", start, nil, slog.String("query", query), slog.Int("results", len(posts)))
+	return posts
+}
+
+// Add logs the new post's title
+func (s *LoggingStore) Add(post Post) error {
+	start := time.Now()
+	err := s.Store.Add(post)
+	s.log(slog.LevelInfo, "add", start, err, slog.String("title", post.Title))
+	return err
+}
+
+// Import logs the imported post's ID
+func (s *LoggingStore) Import(post Post) (Post, error) {
+	start := time.Now()
+	imported, err := s.Store.Import(post)
+	s.log(slog.LevelInfo, "import", start, err, slog.Int("post_id", imported.ID), slog.String("title", post.Title))
+	return imported, err
+}
+
+// Update logs the updated post's ID
+func (s *LoggingStore) Update(post Post) (Post, error) {
+	start := time.Now()
+	updated, err := s.Store.Update(post)
+	s.log(slog.LevelInfo, "update", start, err, slog.Int("post_id", post.ID))
+	return updated, err
+}
+
+// Delete logs the trashed post's ID
+func (s *LoggingStore) Delete(id int) error {
+	start := time.Now()
+	err := s.Store.Delete(id)
+	s.log(slog.LevelInfo, "delete", start, err, slog.Int("post_id", id))
+	return err
+}
+
+// Restore logs the restored post's ID
+func (s *LoggingStore) Restore(id int) (Post, error) {
+	start := time.Now()
+	post, err := s.Store.Restore(id)
+	s.log(slog.LevelInfo, "restore", start, err, slog.Int("post_id", id))
+	return post, err
+}
+
+// Purge logs the purged post's ID
+func (s *LoggingStore) Purge(id int) error {
+	start := time.Now()
+	err := s.Store.Purge(id)
+	s.log(slog.LevelInfo, "purge", start, err, slog.Int("post_id", id))
+	return err
+}
+
+// PurgeTrash logs how many expired posts were purged
+func (s *LoggingStore) PurgeTrash(before time.Time) (int, error) {
+	start := time.Now()
+	n, err := s.Store.PurgeTrash(before)
+	s.log(slog.LevelInfo, "purge_trash", start, err, slog.Time("before", before), slog.Int("purged", n))
+	return n, err
+}
+
+// Publish logs the published post's ID
+func (s *LoggingStore) Publish(id int) (Post, error) {
+	start := time.Now()
+	post, err := s.Store.Publish(id)
+	s.log(slog.LevelInfo, "publish", start, err, slog.Int("post_id", id))
+	return post, err
+}
+
+// RecordView logs the viewed post's ID
+func (s *LoggingStore) RecordView(id int) (int, error) {
+	start := time.Now()
+	views, err := s.Store.RecordView(id)
+	s.log(slog.LevelDebug, "record_view", start, err, slog.Int("post_id", id))
+	return views, err
+}
+
+// React logs the post's ID and the reaction
+func (s *LoggingStore) React(id int, reaction Reaction) (Post, error) {
+	start := time.Now()
+	post, err := s.Store.React(id, reaction)
+	s.log(slog.LevelDebug, "react", start, err, slog.Int("post_id", id), slog.String("reaction", string(reaction)))
+	return post, err
+}
+
+// RestoreRevision logs the post's ID and the restored revision
+func (s *LoggingStore) RestoreRevision(id, number int) (Post, error) {
+	start := time.Now()
+	post, err := s.Store.RestoreRevision(id, number)
+	s.log(slog.LevelInfo, "restore_revision", start, err, slog.Int("post_id", id), slog.Int("revision", number))
+	return post, err
+}
+
+// SetSlug logs the post's ID and its new slug
+func (s *LoggingStore) SetSlug(id int, slug string) (Post, error) {
+	start := time.Now()
+	post, err := s.Store.SetSlug(id, slug)
+	s.log(slog.LevelInfo, "set_slug", start, err, slog.Int("post_id", id), slog.String("slug", slug))
+	return post, err
+}
+
+// log writes one store operation, raising the level to warn when it failed
+func (s *LoggingStore) log(level slog.Level, op string, start time.Time, err error, attrs ...slog.Attr) {
+	attrs = append([]slog.Attr{slog.String("op", op)}, attrs...)
+	attrs = append(attrs, slog.Duration("duration", time.Since(start)))
+	if err != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	s.logger.LogAttrs(context.Background(), level, "store", attrs...)
+}
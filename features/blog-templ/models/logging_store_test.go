@@ -0,0 +1,40 @@
+package models
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLoggingStore(t *testing.T) {
+	var buf bytes.Buffer
+	store := NewLoggingStore(NewStore(), slog.New(slog.NewTextHandler(&buf, nil)))
+
+	if _, err := store.Publish(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Publish(999); err == nil {
+		t.Fatal("Expected publishing a missing post to fail")
+	}
+	store.Search("templ")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines with debug logging off, got %q", buf.String())
+	}
+	if !strings.Contains(lines[0], "level=INFO msg=store op=publish post_id=1 duration=") {
+		t.Errorf("Unexpected log line %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "level=WARN") || !strings.Contains(lines[1], `error="post not found"`) {
+		t.Errorf("Expected the failure at warn level, got %q", lines[1])
+	}
+
+	// Searches only show up at debug level
+	buf.Reset()
+	store = NewLoggingStore(NewStore(), slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	store.Search("templ")
+	if !strings.Contains(buf.String(), "op=search query=templ results=") {
+		t.Errorf("Expected the search to be logged, got %q", buf.String())
+	}
+}
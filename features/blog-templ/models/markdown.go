@@ -0,0 +1,358 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrNoFrontMatter is returned for Markdown files that don't start with a
+// "---" front matter block
+var ErrNoFrontMatter = errors.New("missing front matter")
+
+// frontMatterDelimiter opens and closes the front matter block
+const frontMatterDelimiter = "---"
+
+// MarshalMarkdown renders a post as Markdown with YAML front matter.
+// String and list values are written as JSON, which is also valid YAML,
+// so any title round-trips safely.
+func MarshalMarkdown(post Post) []byte {
+	var b bytes.Buffer
+
+	b.WriteString(frontMatterDelimiter + "\n")
+	writeField(&b, "title", jsonValue(post.Title))
+	if post.Slug != "" {
+		writeField(&b, "slug", jsonValue(post.Slug))
+	}
+	writeField(&b, "author", jsonValue(post.Author))
+	if post.AuthorUsername != "" {
+		writeField(&b, "author_username", jsonValue(post.AuthorUsername))
+	}
+	writeField(&b, "date", post.CreatedAt.Format(time.RFC3339))
+	if !post.UpdatedAt.IsZero() {
+		writeField(&b, "updated", post.UpdatedAt.Format(time.RFC3339))
+	}
+	writeField(&b, "published", strconv.FormatBool(post.Published))
+	if !post.PublishedAt.IsZero() {
+		writeField(&b, "published_at", post.PublishedAt.Format(time.RFC3339))
+	}
+	writeField(&b, "tags", jsonValue(nonNil(post.Tags)))
+	if post.Category != "" {
+		writeField(&b, "category", jsonValue(post.Category))
+	}
+	if post.CoverImageURL != "" {
+		writeField(&b, "cover_image", jsonValue(post.CoverImageURL))
+	}
+	if len(post.Images) > 0 {
+		writeField(&b, "images", jsonValue(post.Images))
+	}
+	b.WriteString(frontMatterDelimiter + "\n\n")
+
+	b.WriteString(post.Content)
+	b.WriteString("\n")
+	return b.Bytes()
+}
+
+// UnmarshalMarkdown parses a Markdown file with YAML front matter into a
+// post. It reads the fields MarshalMarkdown writes, and also accepts the
+// plain YAML scalars, block lists and "draft" flag common in static site
+// generators such as Hugo and Jekyll. Unknown fields are ignored.
+func UnmarshalMarkdown(data []byte) (Post, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+
+	rest, ok := strings.CutPrefix(text, frontMatterDelimiter+"\n")
+	if !ok {
+		return Post{}, ErrNoFrontMatter
+	}
+	front, content, ok := strings.Cut(rest, "\n"+frontMatterDelimiter+"\n")
+	if !ok {
+		// The closing delimiter may be the last line of the file
+		front, ok = strings.CutSuffix(rest, "\n"+frontMatterDelimiter)
+		if !ok {
+			return Post{}, ErrNoFrontMatter
+		}
+		content = ""
+	}
+
+	fields, err := parseFrontMatter(front)
+	if err != nil {
+		return Post{}, err
+	}
+
+	post := Post{
+		Title:          fields.string("title"),
+		Slug:           fields.string("slug"),
+		Author:         fields.string("author"),
+		AuthorUsername: fields.string("author_username"),
+		Tags:           fields.list("tags"),
+		Category:       fields.string("category"),
+		CoverImageURL:  fields.string("cover_image"),
+		Images:         fields.list("images"),
+		Content:        strings.TrimSpace(content),
+	}
+	if post.Published, err = fields.bool("published", true); err != nil {
+		return Post{}, err
+	}
+	if draft, err := fields.bool("draft", false); err != nil {
+		return Post{}, err
+	} else if draft {
+		post.Published = false
+	}
+	if post.CreatedAt, err = fields.time("date"); err != nil {
+		return Post{}, err
+	}
+	if post.UpdatedAt, err = fields.time("updated"); err != nil {
+		return Post{}, err
+	}
+	if post.PublishedAt, err = fields.time("published_at"); err != nil {
+		return Post{}, err
+	}
+
+	return post, nil
+}
+
+// MarkdownFileName names a post's exported file after its ID and title
+func MarkdownFileName(post Post) string {
+	if slug := slugify(post.Title); slug != "" {
+		return fmt.Sprintf("%d-%s.md", post.ID, slug)
+	}
+	return fmt.Sprintf("%d.md", post.ID)
+}
+
+// ExportMarkdown writes every post to its own Markdown file in dir,
+// creating the directory if needed
+func ExportMarkdown(posts []Post, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create export directory: %w", err)
+	}
+
+	for _, post := range posts {
+		path := filepath.Join(dir, MarkdownFileName(post))
+		if err := os.WriteFile(path, MarshalMarkdown(post), 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// ImportMarkdown adds every .md file in dir to the store as a new post,
+// keeping its dates. All files are parsed before any is added, so a bad
+// file leaves the store unchanged. It returns the imported posts.
+func ImportMarkdown(store Store, dir string) ([]Post, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read import directory: %w", err)
+	}
+
+	var posts []Post
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".md" {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		post, err := UnmarshalMarkdown(data)
+		if err == nil {
+			err = validatePost(post)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		posts = append(posts, post)
+	}
+
+	// Oldest first, so the store ends up newest first like when posts are written
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].CreatedAt.Before(posts[j].CreatedAt)
+	})
+
+	imported := make([]Post, 0, len(posts))
+	for _, post := range posts {
+		added, err := store.Import(post)
+		if err != nil {
+			return imported, fmt.Errorf("import %q: %w", post.Title, err)
+		}
+		imported = append(imported, added)
+	}
+
+	return imported, nil
+}
+
+// frontMatter holds the raw values of front matter fields
+type frontMatter map[string]string
+
+// parseFrontMatter reads "key: value" lines. A key with no value followed
+// by "- item" lines is a block list, kept as a JSON array.
+func parseFrontMatter(front string) (frontMatter, error) {
+	fields := frontMatter{}
+	var listKey string
+	var list []string
+
+	flush := func() {
+		if listKey != "" {
+			fields[listKey] = jsonValue(nonNil(list))
+			listKey, list = "", nil
+		}
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(front))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRightFunc(scanner.Text(), unicode.IsSpace)
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if item, ok := strings.CutPrefix(trimmed, "- "); ok && listKey != "" {
+			list = append(list, unquote(strings.TrimSpace(item)))
+			continue
+		}
+		flush()
+
+		key, value, ok := strings.Cut(text, ":")
+		if !ok || key != strings.TrimSpace(key) || key == "" {
+			return nil, fmt.Errorf("front matter line %d: expected \"key: value\"", line)
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			listKey = key
+			continue
+		}
+		fields[key] = value
+	}
+	flush()
+
+	return fields, scanner.Err()
+}
+
+// string returns a field as a string
+func (f frontMatter) string(key string) string {
+	return unquote(f[key])
+}
+
+// list returns a field as a list, accepting JSON arrays and YAML flow
+// sequences with unquoted items
+func (f frontMatter) list(key string) []string {
+	raw := f[key]
+	if raw == "" {
+		return nil
+	}
+
+	var items []string
+	if err := json.Unmarshal([]byte(raw), &items); err == nil {
+		return cleanList(items)
+	}
+
+	inner := strings.TrimSuffix(strings.TrimPrefix(raw, "["), "]")
+	for _, item := range strings.Split(inner, ",") {
+		items = append(items, unquote(strings.TrimSpace(item)))
+	}
+	return cleanList(items)
+}
+
+// bool returns a field as a boolean, or def when it is absent
+func (f frontMatter) bool(key string, def bool) (bool, error) {
+	raw, ok := f[key]
+	if !ok {
+		return def, nil
+	}
+	value, err := strconv.ParseBool(unquote(raw))
+	if err != nil {
+		return false, fmt.Errorf("front matter %s: %q is not true or false", key, raw)
+	}
+	return value, nil
+}
+
+// time returns a field as an RFC 3339 timestamp or a plain date, or the
+// zero time when it is absent
+func (f frontMatter) time(key string) (time.Time, error) {
+	raw := f.string(key)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("front matter %s: %q is not a date", key, raw)
+}
+
+// unquote strips YAML double or single quotes from a scalar
+func unquote(raw string) string {
+	if strings.HasPrefix(raw, `"`) {
+		var s string
+		if err := json.Unmarshal([]byte(raw), &s); err == nil {
+			return s
+		}
+	}
+	if len(raw) >= 2 && strings.HasPrefix(raw, "'") && strings.HasSuffix(raw, "'") {
+		return strings.ReplaceAll(raw[1:len(raw)-1], "''", "'")
+	}
+	return raw
+}
+
+// cleanList drops empty items
+func cleanList(items []string) []string {
+	var cleaned []string
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			cleaned = append(cleaned, item)
+		}
+	}
+	return cleaned
+}
+
+// writeField writes one front matter line
+func writeField(b *bytes.Buffer, key, value string) {
+	fmt.Fprintf(b, "%s: %s\n", key, value)
+}
+
+// jsonValue encodes v as JSON without escaping HTML characters
+func jsonValue(v any) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+	return strings.TrimSpace(b.String())
+}
+
+// nonNil turns a nil slice into an empty one so it encodes as []
+func nonNil(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
+
+// slugify turns a name into a lowercase, hyphenated URL or file name part
+func slugify(title string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		hyphen = true
+	}
+	return b.String()
+}
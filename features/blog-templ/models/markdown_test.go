@@ -0,0 +1,216 @@
+package models
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	created := time.Date(2024, 3, 5, 9, 30, 0, 0, time.UTC)
+	post := Post{
+		ID:             7,
+		Title:          `Quotes "and" <brackets> & colons: ok`,
+		Content:        "# Heading\n\n---\n\nA rule above, not front matter.",
+		Author:         "Jane Doe",
+		AuthorUsername: "jane",
+		CreatedAt:      created,
+		UpdatedAt:      created.Add(time.Hour),
+		Published:      true,
+		PublishedAt:    created.Add(time.Minute),
+		Tags:           []string{"go", "web dev"},
+		CoverImageURL:  "https://example.com/cover.jpg",
+		Images:         []string{"0123456789abcdef0123456789abcdef.png"},
+	}
+
+	got, err := UnmarshalMarkdown(MarshalMarkdown(post))
+	if err != nil {
+		t.Fatalf("UnmarshalMarkdown() failed: %v", err)
+	}
+
+	// IDs belong to the store, not the file
+	post.ID = 0
+	if !reflect.DeepEqual(got, post) {
+		t.Errorf("Round trip mismatch:\n got %+v\nwant %+v", got, post)
+	}
+}
+
+func TestMarkdownDraftRoundTrip(t *testing.T) {
+	post := Post{Title: "Draft", Content: "Not yet", Author: "John", CreatedAt: time.Now().UTC().Truncate(time.Second)}
+
+	got, err := UnmarshalMarkdown(MarshalMarkdown(post))
+	if err != nil {
+		t.Fatalf("UnmarshalMarkdown() failed: %v", err)
+	}
+	if got.Published {
+		t.Error("Expected the draft to stay unpublished")
+	}
+	if got.Tags != nil {
+		t.Errorf("Expected no tags, got %v", got.Tags)
+	}
+}
+
+func TestUnmarshalStaticSiteMarkdown(t *testing.T) {
+	data := "---\r\n" +
+		"title: Hello, Hugo\r\n" +
+		"author: 'Jane O''Neil'\r\n" +
+		"date: 2023-11-02\r\n" +
+		"draft: true\r\n" +
+		"weight: 10 # unknown fields are ignored\r\n" +
+		"tags:\r\n" +
+		"  - go\r\n" +
+		"  - \"static sites\"\r\n" +
+		"categories: [blog, notes]\r\n" +
+		"---\r\n" +
+		"\r\n" +
+		"Body text.\r\n"
+
+	post, err := UnmarshalMarkdown([]byte(data))
+	if err != nil {
+		t.Fatalf("UnmarshalMarkdown() failed: %v", err)
+	}
+
+	if post.Title != "Hello, Hugo" || post.Author != "Jane O'Neil" {
+		t.Errorf("Unexpected title/author %q / %q", post.Title, post.Author)
+	}
+	if !post.CreatedAt.Equal(time.Date(2023, 11, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected date %v", post.CreatedAt)
+	}
+	if post.Published {
+		t.Error("Expected draft: true to leave the post unpublished")
+	}
+	if !reflect.DeepEqual(post.Tags, []string{"go", "static sites"}) {
+		t.Errorf("Unexpected tags %v", post.Tags)
+	}
+	if post.Content != "Body text." {
+		t.Errorf("Unexpected content %q", post.Content)
+	}
+}
+
+func TestUnmarshalMarkdownErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "no front matter", data: "# Just Markdown\n"},
+		{name: "unclosed front matter", data: "---\ntitle: x\n\nbody\n"},
+		{name: "bad line", data: "---\njust words\n---\nbody\n"},
+		{name: "bad date", data: "---\ntitle: x\ndate: yesterday\n---\nbody\n"},
+		{name: "bad bool", data: "---\ntitle: x\npublished: maybe\n---\nbody\n"},
+	}
+
+	for _, tt := range tests {
+		if _, err := UnmarshalMarkdown([]byte(tt.data)); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+
+	if _, err := UnmarshalMarkdown([]byte("no front matter")); !errors.Is(err, ErrNoFrontMatter) {
+		t.Errorf("Expected ErrNoFrontMatter, got %v", err)
+	}
+}
+
+func TestMarkdownFileName(t *testing.T) {
+	tests := []struct {
+		post Post
+		want string
+	}{
+		{post: Post{ID: 3, Title: "Why Go is Great!"}, want: "3-why-go-is-great.md"},
+		{post: Post{ID: 4, Title: "  Templ & HTMX: 2024  "}, want: "4-templ-htmx-2024.md"},
+		{post: Post{ID: 5, Title: "한글 제목"}, want: "5-한글-제목.md"},
+		{post: Post{ID: 6, Title: "???"}, want: "6.md"},
+	}
+
+	for _, tt := range tests {
+		if got := MarkdownFileName(tt.post); got != tt.want {
+			t.Errorf("MarkdownFileName(%q) = %q, want %q", tt.post.Title, got, tt.want)
+		}
+	}
+}
+
+func TestExportImportMarkdown(t *testing.T) {
+	dir := t.TempDir()
+	source := NewStore()
+	if err := ExportMarkdown(source.GetAll(), dir); err != nil {
+		t.Fatalf("ExportMarkdown() failed: %v", err)
+	}
+	// Other files in the directory are skipped
+	os.WriteFile(filepath.Join(dir, "README.txt"), []byte("notes"), 0o644)
+
+	path := filepath.Join(t.TempDir(), "posts.json")
+	target, err := NewJSONStore(path)
+	if err != nil {
+		t.Fatalf("NewJSONStore() failed: %v", err)
+	}
+
+	imported, err := ImportMarkdown(target, dir)
+	if err != nil {
+		t.Fatalf("ImportMarkdown() failed: %v", err)
+	}
+	if len(imported) != len(source.GetAll()) {
+		t.Fatalf("Expected %d imported posts, got %d", len(source.GetAll()), len(imported))
+	}
+
+	// Dates survive the trip and the store is newest first
+	reloaded, err := NewJSONStore(path)
+	if err != nil {
+		t.Fatalf("NewJSONStore() failed: %v", err)
+	}
+	posts := reloaded.GetAll()
+	for i := 1; i < len(posts); i++ {
+		if posts[i].CreatedAt.After(posts[i-1].CreatedAt) {
+			t.Errorf("Expected newest first, got %v before %v", posts[i-1].CreatedAt, posts[i].CreatedAt)
+		}
+	}
+	for _, want := range source.GetAll() {
+		found := false
+		for _, got := range posts {
+			if got.Title == want.Title {
+				found = got.CreatedAt.Equal(want.CreatedAt.Truncate(time.Second))
+			}
+		}
+		if !found {
+			t.Errorf("Expected %q to be imported with its date", want.Title)
+		}
+	}
+}
+
+func TestImportMarkdownIsAllOrNothing(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "1-good.md"), MarshalMarkdown(Post{Title: "Good", Content: "Fine", Author: "Jane"}), 0o644)
+	os.WriteFile(filepath.Join(dir, "2-bad.md"), []byte("---\ntitle: No content\n---\n"), 0o644)
+
+	store := newMemoryStore(nil)
+	_, err := ImportMarkdown(store, dir)
+	if err == nil || !strings.Contains(err.Error(), "2-bad.md") {
+		t.Fatalf("Expected an error naming the bad file, got %v", err)
+	}
+	if len(store.GetAll()) != 0 {
+		t.Errorf("Expected nothing imported, got %d posts", len(store.GetAll()))
+	}
+}
+
+func TestImportKeepsDates(t *testing.T) {
+	store := newMemoryStore(nil)
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	post, err := store.Import(Post{Title: "Old", Content: "Post", Published: true, CreatedAt: created})
+	if err != nil {
+		t.Fatalf("Import() failed: %v", err)
+	}
+	if post.ID != 1 {
+		t.Errorf("Expected a new ID of 1, got %d", post.ID)
+	}
+	if !post.CreatedAt.Equal(created) || !post.PublishedAt.Equal(created) {
+		t.Errorf("Expected dates to default to %v, got created %v published %v", created, post.CreatedAt, post.PublishedAt)
+	}
+
+	undated, _ := store.Import(Post{Title: "New", Content: "Post"})
+	if undated.CreatedAt.IsZero() || !undated.PublishedAt.IsZero() {
+		t.Errorf("Expected an undated draft to get a creation time only, got %+v", undated)
+	}
+}
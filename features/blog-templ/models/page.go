@@ -0,0 +1,70 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned when a page cursor can't be decoded
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// After returns up to limit published posts, newest first, that come after
+// cursor, along with the cursor for the following page. An empty cursor
+// starts from the newest post; an empty next cursor means this is the last
+// page. Cursors point between posts rather than at an offset, so posts
+// published or deleted while a reader scrolls don't shift the pages.
+func (s *MemoryStore) After(cursor string, limit int) ([]Post, string, error) {
+	var from Post
+	if cursor != "" {
+		var err error
+		if from, err = decodeCursor(cursor); err != nil {
+			return nil, "", err
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := SortPosts(PublishedOnly(s.posts), SortNewest)
+
+	start := 0
+	if cursor != "" {
+		for start < len(posts) && !newerThan(from, posts[start]) {
+			start++
+		}
+	}
+
+	end := min(len(posts), start+limit)
+	page := clonePosts(posts[start:end])
+	if end == len(posts) || len(page) == 0 {
+		return page, "", nil
+	}
+	return page, encodeCursor(page[len(page)-1]), nil
+}
+
+// encodeCursor returns the cursor pointing just after post
+func encodeCursor(post Post) string {
+	return fmt.Sprintf("%d_%d", post.PublishedAt.UnixNano(), post.ID)
+}
+
+// decodeCursor returns a post holding only the sort keys of a cursor
+func decodeCursor(cursor string) (Post, error) {
+	nanos, id, ok := strings.Cut(cursor, "_")
+	if !ok {
+		return Post{}, ErrInvalidCursor
+	}
+
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return Post{}, ErrInvalidCursor
+	}
+	postID, err := strconv.Atoi(id)
+	if err != nil {
+		return Post{}, ErrInvalidCursor
+	}
+
+	return Post{ID: postID, PublishedAt: time.Unix(0, n)}, nil
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestAfterPagesNewestFirst(t *testing.T) {
+	store := NewStore()
+
+	var got []int
+	cursor := ""
+	for pages := 1; ; pages++ {
+		posts, next, err := store.After(cursor, 3)
+		if err != nil {
+			t.Fatalf("After(%q) failed: %v", cursor, err)
+		}
+		got = append(got, ids(posts)...)
+		if next == "" {
+			if pages != 2 {
+				t.Errorf("Expected 2 pages of 3, got %d", pages)
+			}
+			break
+		}
+		cursor = next
+	}
+
+	if !slices.Equal(got, []int{4, 3, 2, 1}) {
+		t.Errorf("Expected every post once, newest first, got %v", got)
+	}
+}
+
+func TestAfterSkipsDrafts(t *testing.T) {
+	store := NewStore()
+	store.Add(Post{Title: "Draft", Content: "Not yet"})
+
+	posts, next, err := store.After("", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 4 || next != "" {
+		t.Errorf("Expected the 4 published posts on one page, got %v (next %q)", ids(posts), next)
+	}
+}
+
+func TestAfterCursorSurvivesChanges(t *testing.T) {
+	store := NewStore()
+
+	first, next, _ := store.After("", 2)
+	if !slices.Equal(ids(first), []int{4, 3}) {
+		t.Fatalf("Unexpected first page %v", ids(first))
+	}
+
+	// Neither a new post nor deleting the cursor's own post shifts the next page
+	store.Add(Post{Title: "Fresh", Content: "Just in", Published: true})
+	store.Delete(3)
+
+	second, _, err := store.After(next, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ids(second), []int{2, 1}) {
+		t.Errorf("Expected posts 2 and 1, got %v", ids(second))
+	}
+}
+
+func TestAfterInvalidCursor(t *testing.T) {
+	store := NewStore()
+
+	for _, cursor := range []string{"nope", "12_x", "x_3"} {
+		if _, _, err := store.After(cursor, 2); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("After(%q): expected ErrInvalidCursor, got %v", cursor, err)
+		}
+	}
+}
@@ -2,6 +2,9 @@ package models
 
 import (
 	"errors"
+	"maps"
+	"net/url"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -9,79 +12,271 @@ import (
 
 // Post represents a blog post
 type Post struct {
-	ID        int
-	Title     string
-	Content   string
-	Author    string
-	CreatedAt time.Time
-	Tags      []string
-}
-
-// Store manages blog posts
-type Store struct {
-	posts  []Post
-	mu     sync.Mutex
-	nextID int
-}
-
-// NewStore creates a new post store with sample data
-func NewStore() *Store {
-	return &Store{
-		posts: []Post{
-			{
-				ID:        1,
-				Title:     "Getting Started with Templ and HTMX",
-				Content:   "Templ is a templating language for Go that generates type-safe HTML. Combined with HTMX, you can build dynamic web applications without writing JavaScript.",
-				Author:    "Jane Doe",
-				CreatedAt: time.Now().AddDate(0, 0, -7),
-				Tags:      []string{"templ", "htmx", "go", "tutorial"},
-			},
-			{
-				ID:        2,
-				Title:     "Building Real-time Search with HTMX",
-				Content:   "HTMX makes it easy to add AJAX requests directly in HTML. With hx-get and hx-trigger, you can create real-time search without complex JavaScript.",
-				Author:    "John Smith",
-				CreatedAt: time.Now().AddDate(0, 0, -5),
-				Tags:      []string{"htmx", "search", "web development"},
-			},
-			{
-				ID:        3,
-				Title:     "Why Go is Great for Web Development",
-				Content:   "Go's simplicity, performance, and built-in concurrency make it an excellent choice for web applications. The standard library is powerful and well-designed.",
-				Author:    "Jane Doe",
-				CreatedAt: time.Now().AddDate(0, 0, -3),
-				Tags:      []string{"go", "web development", "backend"},
-			},
-			{
-				ID:        4,
-				Title:     "Type-Safe HTML Templates",
-				Content:   "Templ generates Go code from templates, giving you compile-time safety and IDE support. No more runtime template errors!",
-				Author:    "John Smith",
-				CreatedAt: time.Now().AddDate(0, 0, -1),
-				Tags:      []string{"templ", "go", "type safety"},
-			},
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	// Slug names the post in its URL; see Post.URL
+	Slug string `json:"slug,omitempty"`
+	// OldSlugs are the post's previous slugs, which redirect to it
+	OldSlugs       []string  `json:"old_slugs,omitempty"`
+	Content        string    `json:"content"`
+	Author         string    `json:"author"`
+	AuthorUsername string    `json:"author_username"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Published      bool      `json:"published"`
+	PublishedAt    time.Time `json:"published_at"`
+	Tags           []string  `json:"tags"`
+	// Category is the slug of the post's category, if any
+	Category      string           `json:"category,omitempty"`
+	Images        []string         `json:"images,omitempty"`
+	CoverImageURL string           `json:"cover_image_url,omitempty"`
+	Views         int              `json:"views"`
+	Reactions     map[Reaction]int `json:"reactions,omitempty"`
+	Revisions     []Revision       `json:"revisions,omitempty"`
+	// DeletedAt is set while the post is in the trash
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// LastModified returns when the post last changed
+func (p Post) LastModified() time.Time {
+	if p.UpdatedAt.After(p.CreatedAt) {
+		return p.UpdatedAt
+	}
+	return p.CreatedAt
+}
+
+// clone returns a deep copy of p, so changing a post handed out by the
+// store can't change the stored post through shared slices or maps
+func (p Post) clone() Post {
+	p.Tags = slices.Clone(p.Tags)
+	p.OldSlugs = slices.Clone(p.OldSlugs)
+	p.Images = slices.Clone(p.Images)
+	p.Reactions = maps.Clone(p.Reactions)
+	p.Revisions = slices.Clone(p.Revisions)
+	for i := range p.Revisions {
+		p.Revisions[i].Tags = slices.Clone(p.Revisions[i].Tags)
+		p.Revisions[i].Images = slices.Clone(p.Revisions[i].Images)
+	}
+	return p
+}
+
+// clonePosts deep-copies a list of posts
+func clonePosts(posts []Post) []Post {
+	if posts == nil {
+		return nil
+	}
+	cloned := make([]Post, len(posts))
+	for i, post := range posts {
+		cloned[i] = post.clone()
+	}
+	return cloned
+}
+
+// ErrPostNotFound is returned when no post has the requested ID
+var ErrPostNotFound = errors.New("post not found")
+
+// Store is the persistence interface used by the handlers
+type Store interface {
+	GetAll() []Post
+	GetByID(id int) (Post, error)
+	Search(query string) []Post
+	After(cursor string, limit int) ([]Post, string, error)
+	ByTag(tag string) []Post
+	Add(post Post) error
+	Import(post Post) (Post, error)
+	Update(post Post) (Post, error)
+	Delete(id int) error
+	Trash() []Post
+	Restore(id int) (Post, error)
+	Purge(id int) error
+	PurgeTrash(before time.Time) (int, error)
+	Publish(id int) (Post, error)
+	Related(postID, n int) ([]Post, error)
+	RecordView(id int) (int, error)
+	MostViewed(n int) []Post
+	React(id int, reaction Reaction) (Post, error)
+	Archive() []ArchiveMonth
+	Revisions(id int) ([]Revision, error)
+	RestoreRevision(id, number int) (Post, error)
+	Suggest(prefix string, n int) Suggestions
+	BySlug(slug string) (Post, error)
+	SetSlug(id int, slug string) (Post, error)
+}
+
+// MemoryStore keeps blog posts in memory. It is safe for concurrent use:
+// reads share a read lock, and every post it returns is a copy.
+type MemoryStore struct {
+	posts []Post
+	// trash holds deleted posts until they are restored or purged
+	trash []Post
+	index *searchIndex
+	// prefixes completes titles and tags for search suggestions
+	prefixes prefixIndex
+	mu       sync.RWMutex
+	nextID   int
+}
+
+// NewStore creates a new in-memory post store with sample data
+func NewStore() *MemoryStore {
+	return newMemoryStore(samplePosts())
+}
+
+// newMemoryStore creates an in-memory store from existing posts,
+// continuing ID generation after the highest existing ID.
+// Posts with DeletedAt set go to the trash.
+func newMemoryStore(posts []Post) *MemoryStore {
+	nextID := 1
+	for _, post := range posts {
+		if post.ID >= nextID {
+			nextID = post.ID + 1
+		}
+	}
+
+	var (
+		live, trash []Post
+		prefixes    prefixIndex
+	)
+	index := newSearchIndex()
+	for _, post := range posts {
+		if !post.DeletedAt.IsZero() {
+			trash = append(trash, post)
+			continue
+		}
+		live = append(live, post)
+		index.add(post)
+		prefixes.add(post)
+	}
+
+	s := &MemoryStore{
+		posts:    live,
+		trash:    trash,
+		index:    index,
+		prefixes: prefixes,
+		nextID:   nextID,
+	}
+	// Give posts from before slugs existed one from their title
+	for _, posts := range [][]Post{s.posts, s.trash} {
+		for i := range posts {
+			if posts[i].Slug == "" {
+				posts[i].Slug = s.uniqueSlug(posts[i].Title, posts[i].ID)
+			}
+		}
+	}
+	return s
+}
+
+// samplePosts returns the demo posts the blog starts with
+func samplePosts() []Post {
+	posts := []Post{
+		{
+			ID:             1,
+			Title:          "Getting Started with Templ and HTMX",
+			Content:        "Templ is a templating language for Go that generates type-safe HTML. Combined with HTMX, you can build dynamic web applications without writing JavaScript.",
+			Author:         "Jane Doe",
+			AuthorUsername: "jane",
+			CreatedAt:      time.Now().AddDate(0, 0, -7),
+			Tags:           []string{"templ", "htmx", "go", "tutorial"},
+			Category:       "tutorials",
 		},
-		nextID: 5, // Start from 5 since we have 4 sample posts
+		{
+			ID:             2,
+			Title:          "Building Real-time Search with HTMX",
+			Content:        "HTMX makes it easy to add AJAX requests directly in HTML. With hx-get and hx-trigger, you can create real-time search without complex JavaScript.",
+			Author:         "John Smith",
+			AuthorUsername: "john",
+			CreatedAt:      time.Now().AddDate(0, 0, -5),
+			Tags:           []string{"htmx", "search", "web development"},
+			Category:       "tutorials",
+		},
+		{
+			ID:             3,
+			Title:          "Why Go is Great for Web Development",
+			Content:        "Go's simplicity, performance, and built-in concurrency make it an excellent choice for web applications. The standard library is powerful and well-designed.",
+			Author:         "Jane Doe",
+			AuthorUsername: "jane",
+			CreatedAt:      time.Now().AddDate(0, 0, -3),
+			Tags:           []string{"go", "web development", "backend"},
+			Category:       "web-development",
+		},
+		{
+			ID:             4,
+			Title:          "Type-Safe HTML Templates",
+			Content:        "Templ generates Go code from templates, giving you compile-time safety and IDE support. No more runtime template errors!",
+			Author:         "John Smith",
+			AuthorUsername: "john",
+			CreatedAt:      time.Now().AddDate(0, 0, -1),
+			Tags:           []string{"templ", "go", "type safety"},
+			Category:       "web-development",
+		},
+	}
+
+	// Sample posts are already live
+	for i := range posts {
+		posts[i].Published = true
+		posts[i].PublishedAt = posts[i].CreatedAt
 	}
+
+	return posts
 }
 
 // GetAll returns all posts
-func (s *Store) GetAll() []Post {
-	return s.posts
+func (s *MemoryStore) GetAll() []Post {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return clonePosts(s.posts)
 }
 
-// Search returns posts matching the query
-func (s *Store) Search(query string) []Post {
-	if query == "" {
-		return s.posts
+// GetByID returns the post with the given ID
+func (s *MemoryStore) GetByID(id int) (Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, post := range s.posts {
+		if post.ID == id {
+			return post.clone(), nil
+		}
+	}
+
+	return Post{}, ErrPostNotFound
+}
+
+// Search returns posts matching every term in the query, most relevant first.
+// Terms match whole words or word prefixes in the title, content, author
+// and tags, case-insensitively. An empty query returns all posts.
+func (s *MemoryStore) Search(query string) []Post {
+	if strings.TrimSpace(query) == "" {
+		return s.GetAll()
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byID := make(map[int]Post, len(s.posts))
+	for _, post := range s.posts {
+		byID[post.ID] = post
 	}
 
-	query = strings.ToLower(query)
 	var results []Post
+	for _, id := range s.index.search(query) {
+		results = append(results, byID[id].clone())
+	}
+
+	return results
+}
+
+// ByTag returns posts carrying the given tag (case-insensitive)
+func (s *MemoryStore) ByTag(tag string) []Post {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
+	var results []Post
 	for _, post := range s.posts {
-		if s.matches(post, query) {
-			results = append(results, post)
+		for _, t := range post.Tags {
+			if strings.EqualFold(t, tag) {
+				results = append(results, post.clone())
+				break
+			}
 		}
 	}
 
@@ -89,7 +284,7 @@ func (s *Store) Search(query string) []Post {
 }
 
 // matches checks if a post matches the search query
-func (s *Store) matches(post Post, query string) bool {
+func (s *MemoryStore) matches(post Post, query string) bool {
 	// Search in title
 	if strings.Contains(strings.ToLower(post.Title), query) {
 		return true
@@ -115,26 +310,211 @@ func (s *Store) matches(post Post, query string) bool {
 	return false
 }
 
-// Add adds a new post to the store
-func (s *Store) Add(post Post) error {
-	// Validate input
+// validatePost checks the fields every post needs
+func validatePost(post Post) error {
 	if strings.TrimSpace(post.Title) == "" {
 		return errors.New("title is required")
 	}
 	if strings.TrimSpace(post.Content) == "" {
 		return errors.New("content is required")
 	}
+	if post.CoverImageURL != "" && !validCoverImageURL(post.CoverImageURL) {
+		return errors.New("cover image must be an http(s) URL or an uploaded image")
+	}
+	return nil
+}
+
+// validCoverImageURL accepts absolute http(s) URLs and paths to uploaded images
+func validCoverImageURL(raw string) bool {
+	if name, ok := strings.CutPrefix(raw, ImageURL("")); ok {
+		return ValidImageName(name)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Add adds a new post to the store
+func (s *MemoryStore) Add(post Post) error {
+	// Validate input
+	if err := validatePost(post); err != nil {
+		return err
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// Keep the caller's slices and maps out of the store
+	post = post.clone()
+
+	// An explicit slug must be valid and free; otherwise one comes from
+	// the title
+	if post.Slug != "" && !ValidSlug(post.Slug) {
+		return ErrInvalidSlug
+	}
+	if post.Slug != "" && s.slugInUse(post.Slug, 0) {
+		return ErrSlugTaken
+	}
+
 	// Set auto-generated fields
 	post.ID = s.nextID
 	s.nextID++
+	if post.Slug == "" {
+		post.Slug = s.uniqueSlug(post.Title, post.ID)
+	} else {
+		s.releaseOldSlug(post.Slug)
+	}
+	post.OldSlugs = nil
 	post.CreatedAt = time.Now()
+	if post.Published {
+		post.PublishedAt = post.CreatedAt
+	}
 
 	// Add to the beginning (most recent first)
 	s.posts = append([]Post{post}, s.posts...)
+	s.index.add(post)
+	s.prefixes.add(post)
 
 	return nil
 }
+
+// Import adds a post from another source, such as a Markdown file.
+// Unlike Add it keeps the post's dates, defaulting only the missing ones.
+// The post gets a new ID and is returned as stored.
+func (s *MemoryStore) Import(post Post) (Post, error) {
+	if err := validatePost(post); err != nil {
+		return Post{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	post = post.clone()
+	post.ID = s.nextID
+	s.nextID++
+	// Imported slugs are normalized, and numbered when they are taken
+	if post.Slug == "" {
+		post.Slug = post.Title
+	}
+	post.Slug = s.uniqueSlug(post.Slug, post.ID)
+	post.OldSlugs = nil
+	if post.CreatedAt.IsZero() {
+		post.CreatedAt = time.Now()
+	}
+	if post.Published && post.PublishedAt.IsZero() {
+		post.PublishedAt = post.CreatedAt
+	}
+
+	s.posts = append([]Post{post}, s.posts...)
+	s.index.add(post)
+	s.prefixes.add(post)
+
+	return post.clone(), nil
+}
+
+// Update replaces the editable fields of an existing post (title, content,
+// tags and published state) and returns the stored result.
+// When the content changes, the previous version is kept as a revision.
+func (s *MemoryStore) Update(post Post) (Post, error) {
+	if err := validatePost(post); err != nil {
+		return Post{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.posts {
+		if s.posts[i].ID != post.ID {
+			continue
+		}
+
+		existing := &s.posts[i]
+		if existing.contentChanged(post) {
+			existing.recordRevision()
+		}
+		existing.Title = post.Title
+		existing.Content = post.Content
+		existing.Tags = slices.Clone(post.Tags)
+		existing.Category = post.Category
+		existing.Images = slices.Clone(post.Images)
+		existing.CoverImageURL = post.CoverImageURL
+		existing.UpdatedAt = time.Now()
+		if post.Published && !existing.Published {
+			existing.PublishedAt = existing.UpdatedAt
+		}
+		existing.Published = post.Published
+
+		s.index.add(*existing)
+		s.prefixes.add(*existing)
+		return existing.clone(), nil
+	}
+
+	return Post{}, ErrPostNotFound
+}
+
+// Delete moves a post to the trash, from where it can be restored until
+// it is purged
+func (s *MemoryStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.posts {
+		if s.posts[i].ID == id {
+			post := s.posts[i]
+			post.DeletedAt = time.Now()
+			s.trash = append(s.trash, post)
+
+			s.posts = append(s.posts[:i:i], s.posts[i+1:]...)
+			s.index.remove(id)
+			s.prefixes.remove(id)
+			return nil
+		}
+	}
+
+	return ErrPostNotFound
+}
+
+// Publish makes a draft publicly visible. Publishing an already
+// published post is a no-op that keeps its original PublishedAt.
+func (s *MemoryStore) Publish(id int) (Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.posts {
+		if s.posts[i].ID != id {
+			continue
+		}
+		if !s.posts[i].Published {
+			s.posts[i].Published = true
+			s.posts[i].PublishedAt = time.Now()
+		}
+		return s.posts[i].clone(), nil
+	}
+
+	return Post{}, ErrPostNotFound
+}
+
+// PublishedOnly returns the posts that are publicly visible
+func PublishedOnly(posts []Post) []Post {
+	var results []Post
+	for _, post := range posts {
+		if post.Published {
+			results = append(results, post)
+		}
+	}
+	return results
+}
+
+// DraftsBy returns the unpublished posts written by username
+func DraftsBy(posts []Post, username string) []Post {
+	var results []Post
+	for _, post := range posts {
+		if !post.Published && post.AuthorUsername == username {
+			results = append(results, post)
+		}
+	}
+	return results
+}
@@ -1,7 +1,11 @@
 package models
 
 import (
+	"fmt"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestNewStore(t *testing.T) {
@@ -297,3 +301,298 @@ func TestAddPostAuthor(t *testing.T) {
 		t.Errorf("Expected author 'Test Author', got '%s'", posts[0].Author)
 	}
 }
+
+func TestGetByID(t *testing.T) {
+	store := NewStore()
+
+	post, err := store.GetByID(2)
+	if err != nil {
+		t.Fatalf("GetByID(2) failed: %v", err)
+	}
+	if post.ID != 2 {
+		t.Errorf("Expected post 2, got %d", post.ID)
+	}
+
+	if _, err := store.GetByID(999); err != ErrPostNotFound {
+		t.Errorf("Expected ErrPostNotFound, got %v", err)
+	}
+}
+
+func TestByTag(t *testing.T) {
+	store := NewStore()
+
+	results := store.ByTag("HTMX")
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 posts tagged htmx, got %d", len(results))
+	}
+
+	// Tags must match exactly, not as substrings
+	if len(store.ByTag("web")) != 0 {
+		t.Error("Expected no posts for partial tag 'web'")
+	}
+}
+
+func TestLastModified(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	post := Post{CreatedAt: created}
+
+	if !post.LastModified().Equal(created) {
+		t.Error("Expected CreatedAt when post was never updated")
+	}
+
+	post.UpdatedAt = created.Add(time.Hour)
+	if !post.LastModified().Equal(post.UpdatedAt) {
+		t.Error("Expected UpdatedAt after an update")
+	}
+}
+
+func TestSamplePostsArePublished(t *testing.T) {
+	store := NewStore()
+
+	for _, post := range store.GetAll() {
+		if !post.Published || post.PublishedAt.IsZero() {
+			t.Errorf("Expected sample post %d to be published", post.ID)
+		}
+	}
+}
+
+func TestAddDraftAndPublish(t *testing.T) {
+	store := NewStore()
+
+	store.Add(Post{Title: "Draft", Content: "Not yet", AuthorUsername: "jane"})
+	draft := store.GetAll()[0]
+
+	if draft.Published || !draft.PublishedAt.IsZero() {
+		t.Fatal("Expected new post without Published to be a draft")
+	}
+
+	published, err := store.Publish(draft.ID)
+	if err != nil {
+		t.Fatalf("Publish() failed: %v", err)
+	}
+	if !published.Published || published.PublishedAt.IsZero() {
+		t.Error("Expected post to be published with PublishedAt set")
+	}
+
+	// Publishing again keeps the original timestamp
+	again, _ := store.Publish(draft.ID)
+	if !again.PublishedAt.Equal(published.PublishedAt) {
+		t.Error("Expected PublishedAt to be unchanged on re-publish")
+	}
+
+	if _, err := store.Publish(999); err != ErrPostNotFound {
+		t.Errorf("Expected ErrPostNotFound, got %v", err)
+	}
+}
+
+func TestAddPublishedSetsPublishedAt(t *testing.T) {
+	store := NewStore()
+
+	store.Add(Post{Title: "Live", Content: "Now", Published: true})
+	post := store.GetAll()[0]
+
+	if !post.PublishedAt.Equal(post.CreatedAt) {
+		t.Error("Expected PublishedAt to equal CreatedAt for posts published on creation")
+	}
+}
+
+func TestPublishedOnlyAndDraftsBy(t *testing.T) {
+	posts := []Post{
+		{ID: 1, Published: true, AuthorUsername: "jane"},
+		{ID: 2, Published: false, AuthorUsername: "jane"},
+		{ID: 3, Published: false, AuthorUsername: "john"},
+	}
+
+	published := PublishedOnly(posts)
+	if len(published) != 1 || published[0].ID != 1 {
+		t.Errorf("Expected only post 1 to be public, got %v", published)
+	}
+
+	drafts := DraftsBy(posts, "jane")
+	if len(drafts) != 1 || drafts[0].ID != 2 {
+		t.Errorf("Expected only post 2 as jane's draft, got %v", drafts)
+	}
+}
+
+func TestUpdatePost(t *testing.T) {
+	store := NewStore()
+	original, _ := store.GetByID(1)
+
+	edit := original
+	edit.Title = "Updated Title"
+	edit.Content = "Fresh content about kubernetes"
+	edit.Tags = []string{"k8s"}
+	edit.Author = "Someone Else" // not editable
+
+	updated, err := store.Update(edit)
+	if err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+
+	if updated.Title != "Updated Title" || updated.Tags[0] != "k8s" {
+		t.Errorf("Expected edited fields to change, got %+v", updated)
+	}
+	if updated.Author != original.Author || !updated.CreatedAt.Equal(original.CreatedAt) {
+		t.Error("Expected author and CreatedAt to be preserved")
+	}
+	if updated.UpdatedAt.IsZero() {
+		t.Error("Expected UpdatedAt to be set")
+	}
+
+	// The search index follows the edit
+	if len(store.Search("kubernetes")) != 1 {
+		t.Error("Expected updated content to be searchable")
+	}
+	if len(store.Search("javascript")) != 1 {
+		t.Error("Expected old content of post 1 to no longer match")
+	}
+}
+
+func TestUpdatePostValidation(t *testing.T) {
+	store := NewStore()
+
+	if _, err := store.Update(Post{ID: 1, Title: "", Content: "x"}); err == nil {
+		t.Error("Expected error for empty title")
+	}
+	if _, err := store.Update(Post{ID: 999, Title: "x", Content: "x"}); err != ErrPostNotFound {
+		t.Errorf("Expected ErrPostNotFound, got %v", err)
+	}
+}
+
+func TestUpdatePublishesDraft(t *testing.T) {
+	store := NewStore()
+	store.Add(Post{Title: "Draft", Content: "Later"})
+	draft := store.GetAll()[0]
+
+	draft.Published = true
+	updated, err := store.Update(draft)
+	if err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if !updated.Published || updated.PublishedAt.IsZero() {
+		t.Error("Expected update to publish the draft")
+	}
+}
+
+func TestDeletePost(t *testing.T) {
+	store := NewStore()
+	initialCount := len(store.GetAll())
+
+	if err := store.Delete(2); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+
+	if len(store.GetAll()) != initialCount-1 {
+		t.Errorf("Expected %d posts, got %d", initialCount-1, len(store.GetAll()))
+	}
+	if _, err := store.GetByID(2); err != ErrPostNotFound {
+		t.Error("Expected deleted post to be gone")
+	}
+	for _, post := range store.Search("htmx") {
+		if post.ID == 2 {
+			t.Error("Expected deleted post to be removed from the search index")
+		}
+	}
+
+	if err := store.Delete(2); err != ErrPostNotFound {
+		t.Errorf("Expected ErrPostNotFound on second delete, got %v", err)
+	}
+}
+
+func TestCoverImageURLValidation(t *testing.T) {
+	store := NewStore()
+
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{url: "", valid: true},
+		{url: "https://images.example.com/cover.jpg", valid: true},
+		{url: "http://example.com/a.png", valid: true},
+		{url: "/uploads/0123456789abcdef0123456789abcdef.png", valid: true},
+		{url: "/uploads/../main.go", valid: false},
+		{url: "javascript:alert(1)", valid: false},
+		{url: "ftp://example.com/a.png", valid: false},
+		{url: "//example.com/a.png", valid: false},
+	}
+
+	for _, tt := range tests {
+		err := store.Add(Post{Title: "Cover", Content: "Body", CoverImageURL: tt.url})
+		if (err == nil) != tt.valid {
+			t.Errorf("CoverImageURL %q: expected valid=%v, got error %v", tt.url, tt.valid, err)
+		}
+	}
+
+	post := store.GetAll()[0]
+	post.CoverImageURL = "https://images.example.com/new.jpg"
+	updated, err := store.Update(post)
+	if err != nil || updated.CoverImageURL != post.CoverImageURL {
+		t.Errorf("Expected cover image to be updated, got %q (%v)", updated.CoverImageURL, err)
+	}
+}
+
+func TestReturnedPostsAreCopies(t *testing.T) {
+	store := NewStore()
+
+	posts := store.GetAll()
+	posts[0].Title = "Changed"
+	posts[0].Tags[0] = "changed"
+	posts = append(posts[:1], posts[2:]...)
+
+	post, _ := store.GetByID(1)
+	post.Tags[1] = "changed"
+
+	for _, stored := range store.GetAll() {
+		if stored.Title == "Changed" || slices.Contains(stored.Tags, "changed") {
+			t.Fatalf("Expected the store to be unaffected, got %+v", stored)
+		}
+	}
+	if len(store.GetAll()) != 4 {
+		t.Error("Expected the store to keep all 4 posts")
+	}
+
+	// Nor can the caller change a post after adding it
+	tags := []string{"original"}
+	store.Add(Post{Title: "Tagged", Content: "Body", Tags: tags})
+	tags[0] = "changed"
+	if got := store.GetAll()[0].Tags[0]; got != "original" {
+		t.Errorf("Expected the stored tag to stay original, got %q", got)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	store := NewStore()
+
+	// Run with -race: readers and writers share the store
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			store.Add(Post{Title: fmt.Sprintf("Post %d", i), Content: "Concurrent go post", Tags: []string{"go"}, Published: true})
+		}()
+		go func() {
+			defer wg.Done()
+			for _, post := range store.Search("go") {
+				_ = post.Title
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for _, post := range store.GetAll() {
+				_ = len(post.Tags)
+			}
+			store.ByTag("go")
+			store.MostViewed(3)
+			store.After("", 5)
+		}()
+	}
+	wg.Wait()
+
+	if got := len(store.GetAll()); got != 12 {
+		t.Errorf("Expected 12 posts, got %d", got)
+	}
+	if got := len(store.Search("concurrent")); got != 8 {
+		t.Errorf("Expected every added post to be searchable, got %d", got)
+	}
+}
@@ -0,0 +1,53 @@
+package models
+
+import "errors"
+
+// Reaction is an emoji readers can leave on a post
+type Reaction string
+
+// ReactionLike is the reaction recorded by a plain "like"
+const ReactionLike Reaction = "👍"
+
+// Reactions lists the supported reactions in display order
+var Reactions = []Reaction{ReactionLike, "❤️", "🎉", "😂", "🤔"}
+
+// ErrInvalidReaction is returned for reactions outside Reactions
+var ErrInvalidReaction = errors.New("unsupported reaction")
+
+// ValidReaction reports whether r is one of the supported reactions
+func ValidReaction(r Reaction) bool {
+	for _, known := range Reactions {
+		if r == known {
+			return true
+		}
+	}
+	return false
+}
+
+// React adds one reaction to a post and returns the updated post
+func (s *MemoryStore) React(id int, reaction Reaction) (Post, error) {
+	if !ValidReaction(reaction) {
+		return Post{}, ErrInvalidReaction
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.posts {
+		if s.posts[i].ID != id {
+			continue
+		}
+
+		// Copy on write so posts handed out earlier keep their counts
+		counts := make(map[Reaction]int, len(s.posts[i].Reactions)+1)
+		for r, n := range s.posts[i].Reactions {
+			counts[r] = n
+		}
+		counts[reaction]++
+		s.posts[i].Reactions = counts
+
+		return s.posts[i].clone(), nil
+	}
+
+	return Post{}, ErrPostNotFound
+}
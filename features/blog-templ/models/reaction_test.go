@@ -0,0 +1,56 @@
+package models
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestReact(t *testing.T) {
+	store := NewStore()
+	before, _ := store.GetByID(1)
+
+	store.React(1, ReactionLike)
+	post, err := store.React(1, ReactionLike)
+	if err != nil {
+		t.Fatal(err)
+	}
+	post, _ = store.React(1, "🎉")
+
+	if post.Reactions[ReactionLike] != 2 || post.Reactions["🎉"] != 1 {
+		t.Errorf("Unexpected reaction counts: %v", post.Reactions)
+	}
+	if len(before.Reactions) != 0 {
+		t.Error("Expected earlier copies of the post not to change")
+	}
+}
+
+func TestReactErrors(t *testing.T) {
+	store := NewStore()
+
+	if _, err := store.React(1, "💩"); err != ErrInvalidReaction {
+		t.Errorf("Expected ErrInvalidReaction, got %v", err)
+	}
+	if _, err := store.React(999, ReactionLike); err != ErrPostNotFound {
+		t.Errorf("Expected ErrPostNotFound, got %v", err)
+	}
+}
+
+func TestJSONStorePersistsReactions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posts.json")
+	store, err := NewJSONStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Add(Post{Title: "Liked", Content: "Body", Author: "Jane", Published: true})
+	id := store.GetAll()[0].ID
+	store.React(id, "❤️")
+
+	reloaded, err := NewJSONStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	post, _ := reloaded.GetByID(id)
+	if post.Reactions["❤️"] != 1 {
+		t.Errorf("Expected reaction to survive a reload, got %v", post.Reactions)
+	}
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// tagSimilarityWeight scales tag overlap against text similarity when
+// ranking related posts; shared tags are a strong, explicit signal
+const tagSimilarityWeight = 2.0
+
+// Related returns up to n published posts most similar to the given post,
+// ranked by tag overlap and TF-IDF cosine similarity of titles and content.
+// Posts with nothing in common are left out.
+func (s *MemoryStore) Related(postID, n int) ([]Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	target := -1
+	for i, post := range s.posts {
+		if post.ID == postID {
+			target = i
+			break
+		}
+	}
+	if target < 0 {
+		return nil, ErrPostNotFound
+	}
+
+	vectors := tfidfVectors(s.posts)
+	source := s.posts[target]
+
+	type scored struct {
+		post  Post
+		score float64
+	}
+	var candidates []scored
+	for i, post := range s.posts {
+		if i == target || !post.Published {
+			continue
+		}
+		score := tagSimilarityWeight*tagOverlap(source.Tags, post.Tags) +
+			cosineSimilarity(vectors[target], vectors[i])
+		if score > 0 {
+			candidates = append(candidates, scored{post: post, score: score})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return newerThan(candidates[i].post, candidates[j].post)
+	})
+
+	related := make([]Post, 0, min(n, len(candidates)))
+	for _, c := range candidates[:min(n, len(candidates))] {
+		related = append(related, c.post.clone())
+	}
+	return related, nil
+}
+
+// tfidfVectors builds a unit-length TF-IDF vector over the title and content
+// of each post, with title words weighted like in search
+func tfidfVectors(posts []Post) []map[string]float64 {
+	counts := make([]map[string]float64, len(posts))
+	docFreq := make(map[string]int)
+
+	for i, post := range posts {
+		tf := make(map[string]float64)
+		for _, tok := range tokenize(post.Title) {
+			tf[tok] += titleWeight
+		}
+		for _, tok := range tokenize(post.Content) {
+			tf[tok] += contentWeight
+		}
+		for tok := range tf {
+			docFreq[tok]++
+		}
+		counts[i] = tf
+	}
+
+	total := float64(len(posts))
+	for _, tf := range counts {
+		var norm float64
+		for tok, w := range tf {
+			// Words found in every post carry no signal
+			tf[tok] = w * math.Log(total/float64(docFreq[tok]))
+			norm += tf[tok] * tf[tok]
+		}
+		if norm == 0 {
+			continue
+		}
+		norm = math.Sqrt(norm)
+		for tok := range tf {
+			tf[tok] /= norm
+		}
+	}
+
+	return counts
+}
+
+// cosineSimilarity returns the dot product of two unit vectors
+func cosineSimilarity(a, b map[string]float64) float64 {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	var dot float64
+	for tok, w := range a {
+		dot += w * b[tok]
+	}
+	return dot
+}
+
+// tagOverlap returns the Jaccard similarity of two tag sets, ignoring case
+func tagOverlap(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	set := make(map[string]bool, len(a))
+	for _, tag := range a {
+		set[strings.ToLower(tag)] = true
+	}
+
+	shared := 0
+	union := len(set)
+	seen := make(map[string]bool, len(b))
+	for _, tag := range b {
+		tag = strings.ToLower(tag)
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		if set[tag] {
+			shared++
+		} else {
+			union++
+		}
+	}
+
+	return float64(shared) / float64(union)
+}
@@ -0,0 +1,95 @@
+package models
+
+import "testing"
+
+func newRelatedStore() *MemoryStore {
+	return newMemoryStore([]Post{
+		{ID: 1, Title: "Gardening basics", Content: "Tomatoes need sun and rain", Tags: []string{"garden"}, Published: true},
+		{ID: 2, Title: "Growing tomatoes", Content: "Tomatoes grow best in full sun", Tags: []string{"Garden", "vegetables"}, Published: true},
+		{ID: 3, Title: "Watering schedule", Content: "Water early in the morning", Tags: []string{"garden", "watering"}, Published: true},
+		{ID: 4, Title: "Go generics", Content: "Type parameters arrived in Go", Tags: []string{"go"}, Published: true},
+		{ID: 5, Title: "Tomato soup draft", Content: "Tomatoes tomatoes tomatoes", Tags: []string{"garden"}},
+	})
+}
+
+func TestRelatedRanksBySimilarity(t *testing.T) {
+	store := newRelatedStore()
+
+	related, err := store.Related(1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Posts 2 and 3 overlap equally on tags but only post 2 shares words;
+	// post 4 has nothing in common and post 5 is a draft
+	if len(related) != 2 {
+		t.Fatalf("Expected 2 related posts, got %d", len(related))
+	}
+	if related[0].ID != 2 || related[1].ID != 3 {
+		t.Errorf("Expected posts [2 3], got [%d %d]", related[0].ID, related[1].ID)
+	}
+}
+
+func TestRelatedLimit(t *testing.T) {
+	store := newRelatedStore()
+
+	related, err := store.Related(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(related) != 1 || related[0].ID != 2 {
+		t.Errorf("Expected only post 2, got %v", related)
+	}
+
+	related, _ = store.Related(1, 0)
+	if len(related) != 0 {
+		t.Errorf("Expected no posts for n=0, got %d", len(related))
+	}
+}
+
+func TestRelatedNotFound(t *testing.T) {
+	store := NewStore()
+
+	if _, err := store.Related(999, 3); err != ErrPostNotFound {
+		t.Errorf("Expected ErrPostNotFound, got %v", err)
+	}
+}
+
+func TestRelatedSamplePosts(t *testing.T) {
+	store := NewStore()
+
+	related, err := store.Related(4, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(related) == 0 {
+		t.Fatal("Expected related posts for the templ post")
+	}
+	// Both templ posts are about type-safe templates
+	if related[0].ID != 1 {
+		t.Errorf("Expected post 1 to be most related, got %d", related[0].ID)
+	}
+	for _, post := range related {
+		if post.ID == 4 {
+			t.Error("Expected a post not to be related to itself")
+		}
+	}
+}
+
+func TestTagOverlap(t *testing.T) {
+	tests := []struct {
+		a, b []string
+		want float64
+	}{
+		{a: []string{"go"}, b: []string{"Go"}, want: 1},
+		{a: []string{"go", "htmx"}, b: []string{"go", "templ"}, want: 1.0 / 3},
+		{a: []string{"go"}, b: nil, want: 0},
+		{a: []string{"go"}, b: []string{"go", "go"}, want: 1},
+	}
+
+	for _, tt := range tests {
+		if got := tagOverlap(tt.a, tt.b); got != tt.want {
+			t.Errorf("tagOverlap(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
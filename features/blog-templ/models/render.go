@@ -0,0 +1,246 @@
+package models
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	headingPattern     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	rulePattern        = regexp.MustCompile(`^ {0,3}(-[ -]*-[ -]*-|\*[ *]*\*[ *]*\*|_[ _]*_[ _]*_)\s*$`)
+	bulletPattern      = regexp.MustCompile(`^\s{0,3}[-*+]\s+(.*)$`)
+	orderedPattern     = regexp.MustCompile(`^\s{0,3}\d{1,9}[.)]\s+(.*)$`)
+	fencePattern       = regexp.MustCompile("^\\s{0,3}(```|~~~)\\s*([A-Za-z0-9_-]*)")
+	codeLanguageFilter = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+)
+
+// RenderMarkdown converts Markdown to HTML for the editor preview.
+//
+// It supports headings, paragraphs, emphasis, inline code, fenced code
+// blocks, block quotes, bullet and numbered lists, horizontal rules, links
+// and images. The output is sanitized by construction: all source text is
+// escaped, raw HTML is shown as text, and only http(s), mailto and relative
+// URLs become links or images.
+func RenderMarkdown(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	var b strings.Builder
+	renderBlocks(&b, strings.Split(src, "\n"))
+	return b.String()
+}
+
+// renderBlocks writes the block-level elements of lines
+func renderBlocks(b *strings.Builder, lines []string) {
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			b.WriteString("<p>")
+			b.WriteString(renderInline(strings.Join(paragraph, "\n")))
+			b.WriteString("</p>\n")
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+
+		case fencePattern.MatchString(line):
+			flush()
+			m := fencePattern.FindStringSubmatch(line)
+			fence := m[1]
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			b.WriteString("<pre><code")
+			if lang := codeLanguageFilter.ReplaceAllString(m[2], ""); lang != "" {
+				b.WriteString(` class="language-` + lang + `"`)
+			}
+			b.WriteString(">")
+			b.WriteString(html.EscapeString(strings.Join(code, "\n")))
+			b.WriteString("</code></pre>\n")
+
+		case headingPattern.MatchString(line):
+			flush()
+			m := headingPattern.FindStringSubmatch(line)
+			level := strconv.Itoa(len(m[1]))
+			b.WriteString("<h" + level + ">" + renderInline(m[2]) + "</h" + level + ">\n")
+
+		case rulePattern.MatchString(line):
+			flush()
+			b.WriteString("<hr>\n")
+
+		case strings.HasPrefix(strings.TrimLeft(line, " "), ">"):
+			flush()
+			var quoted []string
+			for ; i < len(lines); i++ {
+				trimmed := strings.TrimLeft(lines[i], " ")
+				if !strings.HasPrefix(trimmed, ">") {
+					break
+				}
+				trimmed = strings.TrimPrefix(trimmed, ">")
+				quoted = append(quoted, strings.TrimPrefix(trimmed, " "))
+			}
+			i--
+			b.WriteString("<blockquote>\n")
+			renderBlocks(b, quoted)
+			b.WriteString("</blockquote>\n")
+
+		case bulletPattern.MatchString(line), orderedPattern.MatchString(line):
+			flush()
+			pattern, tag := bulletPattern, "ul"
+			if !bulletPattern.MatchString(line) {
+				pattern, tag = orderedPattern, "ol"
+			}
+			var items []string
+			for ; i < len(lines); i++ {
+				if m := pattern.FindStringSubmatch(lines[i]); m != nil {
+					items = append(items, m[1])
+					continue
+				}
+				// Indented lines continue the previous item
+				if strings.HasPrefix(lines[i], "  ") && strings.TrimSpace(lines[i]) != "" {
+					items[len(items)-1] += "\n" + strings.TrimSpace(lines[i])
+					continue
+				}
+				break
+			}
+			i--
+			b.WriteString("<" + tag + ">\n")
+			for _, item := range items {
+				b.WriteString("<li>" + renderInline(item) + "</li>\n")
+			}
+			b.WriteString("</" + tag + ">\n")
+
+		default:
+			paragraph = append(paragraph, strings.TrimSpace(line))
+		}
+	}
+	flush()
+}
+
+// renderInline escapes text and applies code spans, links, images and
+// emphasis
+func renderInline(text string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		rest := text[i:]
+
+		switch {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte("\\`*_[]()#+-.!>", text[i+1]) >= 0:
+			b.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '`':
+			if end := strings.IndexByte(rest[1:], '`'); end >= 0 {
+				b.WriteString("<code>" + html.EscapeString(rest[1:end+1]) + "</code>")
+				i += end + 2
+				continue
+			}
+
+		case c == '!' && strings.HasPrefix(rest, "!["):
+			if label, target, n, ok := parseLink(rest[1:]); ok {
+				if safeURL(target) {
+					b.WriteString(`<img src="` + html.EscapeString(target) + `" alt="` + html.EscapeString(label) + `">`)
+				} else {
+					b.WriteString(html.EscapeString(label))
+				}
+				i += n + 1
+				continue
+			}
+
+		case c == '[':
+			if label, target, n, ok := parseLink(rest); ok {
+				if safeURL(target) {
+					b.WriteString(`<a href="` + html.EscapeString(target) + `" rel="nofollow noopener">` + renderInline(label) + "</a>")
+				} else {
+					b.WriteString(renderInline(label))
+				}
+				i += n
+				continue
+			}
+
+		case c == '*' || c == '_':
+			// Underscores inside words, as in snake_case, stay literal
+			if c == '*' || i == 0 || !isWordRune(rune(text[i-1])) {
+				if out, n, ok := renderEmphasis(rest); ok {
+					b.WriteString(out)
+					i += n
+					continue
+				}
+			}
+		}
+
+		// Copy bytes as-is so multi-byte characters survive
+		b.WriteString(html.EscapeString(text[i : i+1]))
+		i++
+	}
+
+	return b.String()
+}
+
+// renderEmphasis renders "**strong**" or "*em*" (or the underscore forms)
+// at the start of s, returning the number of bytes it spans
+func renderEmphasis(s string) (out string, n int, ok bool) {
+	for _, delim := range []string{s[:1] + s[:1], s[:1]} {
+		if !strings.HasPrefix(s, delim) || len(s) <= len(delim) || s[len(delim)] == ' ' {
+			continue
+		}
+		end := strings.Index(s[len(delim):], delim)
+		if end <= 0 {
+			continue
+		}
+
+		tag := "em"
+		if len(delim) == 2 {
+			tag = "strong"
+		}
+		inner := s[len(delim) : len(delim)+end]
+		return "<" + tag + ">" + renderInline(inner) + "</" + tag + ">", end + 2*len(delim), true
+	}
+	return "", 0, false
+}
+
+// parseLink reads "[label](target)" at the start of s, returning the
+// number of bytes it spans
+func parseLink(s string) (label, target string, n int, ok bool) {
+	closeLabel := strings.Index(s, "](")
+	if !strings.HasPrefix(s, "[") || closeLabel < 0 {
+		return "", "", 0, false
+	}
+	closeTarget := strings.IndexByte(s[closeLabel+2:], ')')
+	if closeTarget < 0 {
+		return "", "", 0, false
+	}
+
+	label = s[1:closeLabel]
+	target = strings.TrimSpace(s[closeLabel+2 : closeLabel+2+closeTarget])
+	return label, target, closeLabel + 3 + closeTarget, true
+}
+
+// safeURL reports whether a link target is http(s), mailto or relative,
+// ruling out javascript: and other script-capable schemes
+func safeURL(target string) bool {
+	if target == "" {
+		return false
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdownBlocks(t *testing.T) {
+	src := "# Title\n\nFirst paragraph\ncontinues.\n\n- one\n- two\n  wrapped\n\n1. first\n2. second\n\n> quoted\n\n```go\nif a < b {}\n```\n\n---"
+
+	want := "<h1>Title</h1>\n" +
+		"<p>First paragraph\ncontinues.</p>\n" +
+		"<ul>\n<li>one</li>\n<li>two\nwrapped</li>\n</ul>\n" +
+		"<ol>\n<li>first</li>\n<li>second</li>\n</ol>\n" +
+		"<blockquote>\n<p>quoted</p>\n</blockquote>\n" +
+		"<pre><code class=\"language-go\">if a &lt; b {}</code></pre>\n" +
+		"<hr>\n"
+
+	if got := RenderMarkdown(src); got != want {
+		t.Errorf("RenderMarkdown() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderMarkdownInline(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"**bold** and *em*", "<p><strong>bold</strong> and <em>em</em></p>\n"},
+		{"__bold__ and _em_", "<p><strong>bold</strong> and <em>em</em></p>\n"},
+		{"snake_case_name", "<p>snake_case_name</p>\n"},
+		{"`a <b> c`", "<p><code>a &lt;b&gt; c</code></p>\n"},
+		{`\*not em\*`, "<p>*not em*</p>\n"},
+		{"[docs](https://templ.guide)", `<p><a href="https://templ.guide" rel="nofollow noopener">docs</a></p>` + "\n"},
+		{"![logo](/uploads/logo.png)", `<p><img src="/uploads/logo.png" alt="logo"></p>` + "\n"},
+		{"한글 **굵게**", "<p>한글 <strong>굵게</strong></p>\n"},
+		{"2 * 3 * 4", "<p>2 * 3 * 4</p>\n"},
+	}
+
+	for _, tt := range tests {
+		if got := RenderMarkdown(tt.src); got != tt.want {
+			t.Errorf("RenderMarkdown(%q) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestRenderMarkdownSanitizes(t *testing.T) {
+	tests := []string{
+		`<script>alert(1)</script>`,
+		`<img src=x onerror=alert(1)>`,
+		`[click](javascript:alert(1))`,
+		`[click](JavaScript:alert(1))`,
+		`![x](data:text/html;base64,PHNjcmlwdD4=)`,
+		`[x](" onmouseover="alert(1))`,
+	}
+
+	for _, src := range tests {
+		got := RenderMarkdown(src)
+		for _, bad := range []string{"<script", "<img src=x", "javascript:", "JavaScript:", "data:", `" onmouseover`} {
+			if strings.Contains(got, bad) {
+				t.Errorf("RenderMarkdown(%q) = %q, contains %q", src, got, bad)
+			}
+		}
+	}
+}
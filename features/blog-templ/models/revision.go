@@ -0,0 +1,112 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// MaxRevisions is how many previous versions are kept per post; older ones
+// are dropped first
+const MaxRevisions = 50
+
+// ErrRevisionNotFound is returned when a post has no revision with the
+// requested number
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// Revision is a previous version of a post's content, saved when the post
+// was edited. Numbers count up from 1, the first version.
+type Revision struct {
+	Number        int       `json:"number"`
+	Title         string    `json:"title"`
+	Content       string    `json:"content"`
+	Tags          []string  `json:"tags"`
+	Images        []string  `json:"images,omitempty"`
+	CoverImageURL string    `json:"cover_image_url,omitempty"`
+	SavedAt       time.Time `json:"saved_at"`
+}
+
+// Revisions returns a post's previous versions, newest first
+func (s *MemoryStore) Revisions(id int) ([]Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, post := range s.posts {
+		if post.ID == id {
+			revisions := post.clone().Revisions
+			slices.Reverse(revisions)
+			return revisions, nil
+		}
+	}
+
+	return nil, ErrPostNotFound
+}
+
+// RestoreRevision puts an old version's content back, saving the current
+// content as a new revision so the restore can itself be undone
+func (s *MemoryStore) RestoreRevision(id, number int) (Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.posts {
+		if s.posts[i].ID != id {
+			continue
+		}
+
+		existing := &s.posts[i]
+		for _, rev := range existing.Revisions {
+			if rev.Number != number {
+				continue
+			}
+
+			existing.recordRevision()
+			existing.Title = rev.Title
+			existing.Content = rev.Content
+			existing.Tags = rev.Tags
+			existing.Images = rev.Images
+			existing.CoverImageURL = rev.CoverImageURL
+			existing.UpdatedAt = time.Now()
+
+			s.index.add(*existing)
+			s.prefixes.add(*existing)
+			return existing.clone(), nil
+		}
+		return Post{}, ErrRevisionNotFound
+	}
+
+	return Post{}, ErrPostNotFound
+}
+
+// recordRevision saves the post's current content as its newest revision
+func (p *Post) recordRevision() {
+	number := 1
+	if n := len(p.Revisions); n > 0 {
+		number = p.Revisions[n-1].Number + 1
+	}
+
+	// Copy on write so posts handed out earlier keep their history
+	revisions := make([]Revision, 0, len(p.Revisions)+1)
+	revisions = append(revisions, p.Revisions...)
+	revisions = append(revisions, Revision{
+		Number:        number,
+		Title:         p.Title,
+		Content:       p.Content,
+		Tags:          p.Tags,
+		Images:        p.Images,
+		CoverImageURL: p.CoverImageURL,
+		SavedAt:       p.LastModified(),
+	})
+	if len(revisions) > MaxRevisions {
+		revisions = revisions[len(revisions)-MaxRevisions:]
+	}
+	p.Revisions = revisions
+}
+
+// contentChanged reports whether an edit changes anything a revision keeps
+func (p Post) contentChanged(edit Post) bool {
+	return p.Title != edit.Title ||
+		p.Content != edit.Content ||
+		p.CoverImageURL != edit.CoverImageURL ||
+		!slices.Equal(p.Tags, edit.Tags) ||
+		!slices.Equal(p.Images, edit.Images)
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUpdateKeepsRevisions(t *testing.T) {
+	store := newMemoryStore(nil)
+	store.Add(Post{Title: "First", Content: "one", Tags: []string{"go"}})
+	post := store.GetAll()[0]
+
+	post.Title = "Second"
+	post.Content = "two"
+	if _, err := store.Update(post); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+
+	// Saving without changes doesn't add a revision
+	if _, err := store.Update(post); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+
+	post.Content = "three"
+	store.Update(post)
+
+	revisions, err := store.Revisions(post.ID)
+	if err != nil {
+		t.Fatalf("Revisions() failed: %v", err)
+	}
+	if len(revisions) != 2 {
+		t.Fatalf("Expected 2 revisions, got %d", len(revisions))
+	}
+	if revisions[0].Number != 2 || revisions[0].Content != "two" {
+		t.Errorf("Expected newest revision first, got %+v", revisions[0])
+	}
+	if revisions[1].Number != 1 || revisions[1].Title != "First" || !reflect.DeepEqual(revisions[1].Tags, []string{"go"}) {
+		t.Errorf("Expected the original version last, got %+v", revisions[1])
+	}
+
+	if _, err := store.Revisions(999); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("Expected ErrPostNotFound, got %v", err)
+	}
+}
+
+func TestRestoreRevision(t *testing.T) {
+	store := newMemoryStore(nil)
+	store.Add(Post{Title: "Original", Content: "the first draft"})
+	post := store.GetAll()[0]
+	post.Title = "Edited"
+	post.Content = "a rewrite"
+	store.Update(post)
+
+	restored, err := store.RestoreRevision(post.ID, 1)
+	if err != nil {
+		t.Fatalf("RestoreRevision() failed: %v", err)
+	}
+	if restored.Title != "Original" || restored.Content != "the first draft" {
+		t.Errorf("Expected the original content back, got %q / %q", restored.Title, restored.Content)
+	}
+
+	// The replaced version is kept so the restore can be undone
+	revisions, _ := store.Revisions(post.ID)
+	if len(revisions) != 2 || revisions[0].Title != "Edited" {
+		t.Errorf("Expected the edited version saved as revision 2, got %+v", revisions)
+	}
+
+	// Search sees the restored content
+	if results := store.Search("rewrite"); len(results) != 0 {
+		t.Error("Expected the search index to drop the replaced content")
+	}
+
+	if _, err := store.RestoreRevision(post.ID, 42); !errors.Is(err, ErrRevisionNotFound) {
+		t.Errorf("Expected ErrRevisionNotFound, got %v", err)
+	}
+	if _, err := store.RestoreRevision(999, 1); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("Expected ErrPostNotFound, got %v", err)
+	}
+}
+
+func TestRevisionsAreCapped(t *testing.T) {
+	store := newMemoryStore(nil)
+	store.Add(Post{Title: "Post", Content: "v0"})
+	post := store.GetAll()[0]
+
+	for i := 1; i <= MaxRevisions+5; i++ {
+		post.Content = fmt.Sprintf("v%d", i)
+		store.Update(post)
+	}
+
+	revisions, _ := store.Revisions(post.ID)
+	if len(revisions) != MaxRevisions {
+		t.Fatalf("Expected %d revisions, got %d", MaxRevisions, len(revisions))
+	}
+	if revisions[len(revisions)-1].Number != 6 {
+		t.Errorf("Expected the oldest revisions dropped, oldest kept is %d", revisions[len(revisions)-1].Number)
+	}
+}
+
+func TestJSONStorePersistsRevisions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posts.json")
+	store, _ := NewJSONStore(path)
+	store.Add(Post{Title: "Post", Content: "before"})
+	post := store.GetAll()[0]
+	post.Content = "after"
+	store.Update(post)
+
+	reloaded, err := NewJSONStore(path)
+	if err != nil {
+		t.Fatalf("NewJSONStore() failed: %v", err)
+	}
+	if _, err := reloaded.RestoreRevision(post.ID, 1); err != nil {
+		t.Fatalf("RestoreRevision() after reload failed: %v", err)
+	}
+
+	reloaded, _ = NewJSONStore(path)
+	got, _ := reloaded.GetByID(post.ID)
+	if got.Content != "before" {
+		t.Errorf("Expected the restore to be saved, got %q", got.Content)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	diff := DiffLines("a\nb\nc\nd", "a\nc\nx\nd")
+
+	want := []DiffLine{
+		{DiffEqual, "a"},
+		{DiffDelete, "b"},
+		{DiffEqual, "c"},
+		{DiffInsert, "x"},
+		{DiffEqual, "d"},
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("DiffLines() = %+v, want %+v", diff, want)
+	}
+
+	if diff := DiffLines("", "new"); !reflect.DeepEqual(diff, []DiffLine{{DiffInsert, "new"}}) {
+		t.Errorf("Expected an insertion from empty text, got %+v", diff)
+	}
+	if diff := DiffLines("same", "same"); len(diff) != 1 || diff[0].Op != DiffEqual {
+		t.Errorf("Expected no changes, got %+v", diff)
+	}
+}
@@ -0,0 +1,317 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Field weights: a match in the title counts more than one in the body
+const (
+	titleWeight   = 3.0
+	tagWeight     = 2.0
+	authorWeight  = 2.0
+	contentWeight = 1.0
+	// prefixPenalty scales matches where the query term is only a prefix
+	prefixPenalty = 0.5
+)
+
+// searchIndex is an inverted index from lowercase tokens to the posts
+// containing them, with field-weighted term frequencies
+type searchIndex struct {
+	postings map[string]map[int]float64
+	docs     map[int][]string // tokens per post, for removal on reindex
+	// tokens caches the sorted keys of postings for prefix lookups. It is
+	// rebuilt lazily by searches, which may run concurrently under the
+	// store's read lock, so tokensMu guards it.
+	tokens   []string
+	dirty    bool
+	tokensMu sync.Mutex
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		postings: make(map[string]map[int]float64),
+		docs:     make(map[int][]string),
+	}
+}
+
+// add indexes a post, replacing any previous entry for its ID
+func (idx *searchIndex) add(post Post) {
+	idx.remove(post.ID)
+
+	weights := make(map[string]float64)
+	for _, tok := range tokenize(post.Title) {
+		weights[tok] += titleWeight
+	}
+	for _, tag := range post.Tags {
+		for _, tok := range tokenize(tag) {
+			weights[tok] += tagWeight
+		}
+	}
+	for _, tok := range tokenize(post.Author) {
+		weights[tok] += authorWeight
+	}
+	for _, tok := range tokenize(post.Content) {
+		weights[tok] += contentWeight
+	}
+
+	tokens := make([]string, 0, len(weights))
+	for tok, w := range weights {
+		if idx.postings[tok] == nil {
+			idx.postings[tok] = make(map[int]float64)
+			idx.dirty = true
+		}
+		idx.postings[tok][post.ID] = w
+		tokens = append(tokens, tok)
+	}
+	idx.docs[post.ID] = tokens
+}
+
+// remove drops a post from the index
+func (idx *searchIndex) remove(id int) {
+	for _, tok := range idx.docs[id] {
+		delete(idx.postings[tok], id)
+		if len(idx.postings[tok]) == 0 {
+			delete(idx.postings, tok)
+			idx.dirty = true
+		}
+	}
+	delete(idx.docs, id)
+}
+
+// expand returns the indexed tokens that equal or start with term
+func (idx *searchIndex) expand(term string) []string {
+	idx.tokensMu.Lock()
+	defer idx.tokensMu.Unlock()
+
+	if idx.dirty {
+		idx.tokens = idx.tokens[:0]
+		for tok := range idx.postings {
+			idx.tokens = append(idx.tokens, tok)
+		}
+		sort.Strings(idx.tokens)
+		idx.dirty = false
+	}
+
+	var matches []string
+	for i := sort.SearchStrings(idx.tokens, term); i < len(idx.tokens); i++ {
+		if !strings.HasPrefix(idx.tokens[i], term) {
+			break
+		}
+		matches = append(matches, idx.tokens[i])
+	}
+	return matches
+}
+
+// search returns the IDs of posts matching every query term, best first.
+// Each term scores by weighted term frequency times inverse document
+// frequency; tokens that merely start with the term score less.
+func (idx *searchIndex) search(query string) []int {
+	terms := uniqueTerms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	total := float64(len(idx.docs))
+	var scores map[int]float64
+
+	for _, term := range terms {
+		termScores := make(map[int]float64)
+		for _, tok := range idx.expand(term) {
+			factor := 1.0
+			if tok != term {
+				factor = prefixPenalty
+			}
+			for id, w := range idx.postings[tok] {
+				termScores[id] += w * factor
+			}
+		}
+
+		idf := math.Log(1 + total/float64(len(termScores)+1))
+
+		// Every term must match (AND semantics)
+		if scores == nil {
+			scores = make(map[int]float64, len(termScores))
+			for id, s := range termScores {
+				scores[id] = s * idf
+			}
+			continue
+		}
+		for id := range scores {
+			s, ok := termScores[id]
+			if !ok {
+				delete(scores, id)
+				continue
+			}
+			scores[id] += s * idf
+		}
+	}
+
+	ids := make([]int, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		// Newer posts (higher IDs) win ties
+		return ids[i] > ids[j]
+	})
+
+	return ids
+}
+
+// tokenize splits text into lowercase words of letters and digits
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// uniqueTerms tokenizes a query, dropping repeated terms
+func uniqueTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range tokenize(query) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// TextSegment is a piece of text that either matched a search term or not
+type TextSegment struct {
+	Text  string
+	Match bool
+}
+
+// SearchResult is a post with its title and a content snippet split
+// into segments so templates can highlight matching words
+type SearchResult struct {
+	Post    Post
+	Title   []TextSegment
+	Snippet []TextSegment
+}
+
+// snippetLength is the approximate number of characters in a result snippet
+const snippetLength = 160
+
+// Highlight builds search results for posts, marking words that start
+// with any of the query terms
+func Highlight(posts []Post, query string) []SearchResult {
+	terms := uniqueTerms(query)
+
+	results := make([]SearchResult, 0, len(posts))
+	for _, post := range posts {
+		results = append(results, SearchResult{
+			Post:    post,
+			Title:   highlight(post.Title, terms),
+			Snippet: highlight(snippet(post.Content, terms, snippetLength), terms),
+		})
+	}
+	return results
+}
+
+// highlight splits text into matching and non-matching segments
+func highlight(text string, terms []string) []TextSegment {
+	var segments []TextSegment
+	plainStart := 0
+
+	for start := 0; start < len(text); {
+		r, size := utf8.DecodeRuneInString(text[start:])
+		if !isWordRune(r) {
+			start += size
+			continue
+		}
+
+		end := start
+		for end < len(text) {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if !isWordRune(r) {
+				break
+			}
+			end += size
+		}
+
+		if matchesAnyTerm(strings.ToLower(text[start:end]), terms) {
+			if plainStart < start {
+				segments = append(segments, TextSegment{Text: text[plainStart:start]})
+			}
+			segments = append(segments, TextSegment{Text: text[start:end], Match: true})
+			plainStart = end
+		}
+		start = end
+	}
+
+	if plainStart < len(text) {
+		segments = append(segments, TextSegment{Text: text[plainStart:]})
+	}
+	return segments
+}
+
+// snippet returns a window of about length characters around the first
+// matching word, with ellipses where text was cut
+func snippet(content string, terms []string, length int) string {
+	runes := []rune(strings.Join(strings.Fields(content), " "))
+	if len(runes) <= length {
+		return string(runes)
+	}
+
+	// Find the first matching word, starting the window a little before it
+	start := 0
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && isWordRune(runes[j]) {
+			j++
+		}
+		if matchesAnyTerm(strings.ToLower(string(runes[i:j])), terms) {
+			start = max(0, i-length/4)
+			break
+		}
+		i = j
+	}
+
+	end := min(len(runes), start+length)
+	start = max(0, end-length)
+
+	// Don't cut words in half
+	for start > 0 && isWordRune(runes[start-1]) && start < end {
+		start++
+	}
+	for end < len(runes) && isWordRune(runes[end]) && end > start {
+		end--
+	}
+
+	text := strings.TrimSpace(string(runes[start:end]))
+	if start > 0 {
+		text = "…" + text
+	}
+	if end < len(runes) {
+		text += "…"
+	}
+	return text
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func matchesAnyTerm(word string, terms []string) bool {
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
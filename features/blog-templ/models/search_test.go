@@ -0,0 +1,143 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSearchRanksTitleMatchesFirst(t *testing.T) {
+	store := newMemoryStore(nil)
+	store.Add(Post{Title: "Cooking pasta", Content: "A note about go routines in the kitchen"})
+	store.Add(Post{Title: "Go concurrency patterns", Content: "Channels and goroutines"})
+
+	results := store.Search("go")
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].Title != "Go concurrency patterns" {
+		t.Errorf("Expected title match first, got %q", results[0].Title)
+	}
+}
+
+func TestSearchMultiTermRequiresAllTerms(t *testing.T) {
+	store := NewStore()
+
+	results := store.Search("htmx search")
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result for 'htmx search', got %d", len(results))
+	}
+	if results[0].ID != 2 {
+		t.Errorf("Expected post 2, got %d", results[0].ID)
+	}
+
+	// Term order and punctuation don't matter
+	if got := store.Search("search, HTMX!"); len(got) != 1 || got[0].ID != 2 {
+		t.Errorf("Expected the same result regardless of order, got %v", got)
+	}
+}
+
+func TestSearchPrefixMatching(t *testing.T) {
+	store := NewStore()
+
+	results := store.Search("templ")
+	ids := make(map[int]bool)
+	for _, post := range results {
+		ids[post.ID] = true
+	}
+
+	// "templ" is a word in posts 1 and 4, and a prefix of "templates" in post 4
+	if !ids[1] || !ids[4] {
+		t.Errorf("Expected posts 1 and 4, got %v", ids)
+	}
+
+	// Exact matches outrank prefix-only matches
+	store = newMemoryStore(nil)
+	store.Add(Post{Title: "Templates everywhere", Content: "x"})
+	store.Add(Post{Title: "Templ basics", Content: "y"})
+	store.Add(Post{Title: "Unrelated", Content: "z"})
+
+	results = store.Search("templ")
+	if len(results) != 2 || results[0].Title != "Templ basics" {
+		t.Errorf("Expected exact match first, got %v", results)
+	}
+}
+
+func TestSearchIndexTracksNewPosts(t *testing.T) {
+	store := NewStore()
+
+	if len(store.Search("kubernetes")) != 0 {
+		t.Fatal("Expected no results before adding the post")
+	}
+
+	store.Add(Post{Title: "Deploying to Kubernetes", Content: "Helm charts"})
+
+	if len(store.Search("kubernetes")) != 1 {
+		t.Error("Expected new post to be searchable")
+	}
+}
+
+func TestSearchNonLatinText(t *testing.T) {
+	store := newMemoryStore(nil)
+	store.Add(Post{Title: "한국어 블로그", Content: "템플릿과 HTMX로 만든 블로그입니다"})
+
+	if len(store.Search("블로그")) != 1 {
+		t.Error("Expected Korean term to match")
+	}
+	if len(store.Search("템플")) != 1 {
+		t.Error("Expected Korean prefix to match")
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	results := Highlight([]Post{{Title: "Go and HTMX", Content: "Use Go with htmx."}}, "htmx go")
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+
+	want := []TextSegment{
+		{Text: "Go", Match: true},
+		{Text: " and "},
+		{Text: "HTMX", Match: true},
+	}
+	if !equalSegments(results[0].Title, want) {
+		t.Errorf("Unexpected title segments: %+v", results[0].Title)
+	}
+
+	var matched []string
+	for _, seg := range results[0].Snippet {
+		if seg.Match {
+			matched = append(matched, seg.Text)
+		}
+	}
+	if strings.Join(matched, ",") != "Go,htmx" {
+		t.Errorf("Expected Go and htmx marked in snippet, got %v", matched)
+	}
+}
+
+func TestSnippetCentersOnMatch(t *testing.T) {
+	content := strings.Repeat("filler words here ", 30) + "the needle is here " + strings.Repeat("more trailing text ", 30)
+
+	got := snippet(content, []string{"needle"}, 80)
+
+	if !strings.Contains(got, "needle") {
+		t.Errorf("Expected snippet around the match, got %q", got)
+	}
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Errorf("Expected ellipses on both sides, got %q", got)
+	}
+	if n := len([]rune(got)); n > 82 {
+		t.Errorf("Expected snippet of about 80 characters, got %d", n)
+	}
+}
+
+func equalSegments(a, b []TextSegment) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
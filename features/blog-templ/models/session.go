@@ -0,0 +1,75 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+// SessionTTL is how long a login session stays valid
+const SessionTTL = 7 * 24 * time.Hour
+
+type session struct {
+	username  string
+	expiresAt time.Time
+}
+
+// SessionStore maps random session tokens to logged-in usernames
+type SessionStore struct {
+	sessions map[string]session
+	mu       sync.Mutex
+	now      func() time.Time
+}
+
+// NewSessionStore creates an empty in-memory session store
+func NewSessionStore() *SessionStore {
+	return &SessionStore{
+		sessions: make(map[string]session),
+		now:      time.Now,
+	}
+}
+
+// Create starts a session for username and returns its token
+func (s *SessionStore) Create(username string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[token] = session{
+		username:  username,
+		expiresAt: s.now().Add(SessionTTL),
+	}
+
+	return token, nil
+}
+
+// Get returns the username for a valid, unexpired token
+func (s *SessionStore) Get(token string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[token]
+	if !ok {
+		return "", false
+	}
+	if s.now().After(sess.expiresAt) {
+		delete(s.sessions, token)
+		return "", false
+	}
+
+	return sess.username, true
+}
+
+// Delete ends a session
+func (s *SessionStore) Delete(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, token)
+}
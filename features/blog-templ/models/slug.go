@@ -0,0 +1,169 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+)
+
+// MaxSlugLength is the longest accepted post slug, in characters
+const MaxSlugLength = 100
+
+var (
+	// ErrInvalidSlug is returned for slugs that aren't lowercase words
+	// joined by hyphens
+	ErrInvalidSlug = errors.New("slug must be lowercase letters and digits joined by single hyphens, not only digits, and at most 100 characters")
+	// ErrSlugTaken is returned when another post already uses a slug
+	ErrSlugTaken = errors.New("another post already uses that slug")
+)
+
+// reservedSlugs are taken by other pages under /posts/
+var reservedSlugs = []string{"more"}
+
+// URL returns the path of the post's page: its slug when it has one, and
+// its ID otherwise
+func (p Post) URL() string {
+	if p.Slug != "" {
+		return "/posts/" + url.PathEscape(p.Slug)
+	}
+	return fmt.Sprintf("/posts/%d", p.ID)
+}
+
+// ValidSlug reports whether slug can name a post: lowercase letters and
+// digits in words joined by single hyphens. Slugs of only digits would be
+// mistaken for post IDs, so they are refused, as are reserved ones.
+func ValidSlug(slug string) bool {
+	if slug == "" || utf8.RuneCountInString(slug) > MaxSlugLength || slugify(slug) != slug {
+		return false
+	}
+	if slices.Contains(reservedSlugs, slug) {
+		return false
+	}
+	_, err := strconv.Atoi(slug)
+	return err != nil
+}
+
+// BySlug returns the live post whose slug is slug, or that used slug
+// before it was renamed. Callers can tell the two apart by comparing the
+// returned post's Slug, and redirect old links to the post's URL.
+func (s *MemoryStore) BySlug(slug string) (Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, post := range s.posts {
+		if post.Slug == slug {
+			return post.clone(), nil
+		}
+	}
+	for _, post := range s.posts {
+		if slices.Contains(post.OldSlugs, slug) {
+			return post.clone(), nil
+		}
+	}
+	return Post{}, ErrPostNotFound
+}
+
+// SetSlug renames a post's slug. The old slug is kept in the post's
+// OldSlugs, the redirect table BySlug consults, so links to it keep
+// working. A slug another post used to have can be taken over, which
+// ends that post's redirect.
+func (s *MemoryStore) SetSlug(id int, slug string) (Post, error) {
+	if !ValidSlug(slug) {
+		return Post{}, ErrInvalidSlug
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.posts, func(p Post) bool { return p.ID == id })
+	if i < 0 {
+		return Post{}, ErrPostNotFound
+	}
+	post := &s.posts[i]
+	if post.Slug == slug {
+		return post.clone(), nil
+	}
+	if s.slugInUse(slug, id) {
+		return Post{}, ErrSlugTaken
+	}
+
+	s.releaseOldSlug(slug)
+	if post.Slug != "" {
+		post.OldSlugs = append(post.OldSlugs, post.Slug)
+	}
+	post.Slug = slug
+	return post.clone(), nil
+}
+
+// slugInUse reports whether a post other than id, trashed ones included,
+// has slug as its current slug. The caller must hold s.mu.
+func (s *MemoryStore) slugInUse(slug string, id int) bool {
+	for _, posts := range [][]Post{s.posts, s.trash} {
+		for _, post := range posts {
+			if post.ID != id && post.Slug == slug {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// releaseOldSlug stops any post redirecting from slug, so it can become a
+// post's current slug. The caller must hold s.mu.
+func (s *MemoryStore) releaseOldSlug(slug string) {
+	for _, posts := range [][]Post{s.posts, s.trash} {
+		for i := range posts {
+			posts[i].OldSlugs = slices.DeleteFunc(posts[i].OldSlugs, func(old string) bool { return old == slug })
+		}
+	}
+}
+
+// uniqueSlug turns base into a free slug for post id, appending -2, -3 and
+// so on until no other post has it, as its slug or one it redirects from.
+// Titles that make no valid slug give "". The caller must hold s.mu.
+func (s *MemoryStore) uniqueSlug(base string, id int) string {
+	base = slugify(base)
+	if base == "" {
+		return ""
+	}
+	if _, err := strconv.Atoi(base); err == nil || slices.Contains(reservedSlugs, base) {
+		base = "post-" + base
+	}
+	base = trimSlug(base, MaxSlugLength)
+
+	slug := base
+	for n := 2; s.slugInUse(slug, id) || s.redirectsFrom(slug, id); n++ {
+		suffix := "-" + strconv.Itoa(n)
+		slug = trimSlug(base, MaxSlugLength-len(suffix)) + suffix
+	}
+	return slug
+}
+
+// redirectsFrom reports whether a post other than id redirects from slug.
+// The caller must hold s.mu.
+func (s *MemoryStore) redirectsFrom(slug string, id int) bool {
+	for _, posts := range [][]Post{s.posts, s.trash} {
+		for _, post := range posts {
+			if post.ID != id && slices.Contains(post.OldSlugs, slug) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// trimSlug shortens slug to at most n characters without leaving a
+// trailing hyphen
+func trimSlug(slug string, n int) string {
+	if utf8.RuneCountInString(slug) <= n {
+		return slug
+	}
+	runes := []rune(slug)[:n]
+	for len(runes) > 0 && runes[len(runes)-1] == '-' {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes)
+}
@@ -0,0 +1,192 @@
+package models
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestValidSlug(t *testing.T) {
+	tests := []struct {
+		slug string
+		want bool
+	}{
+		{"hello-world", true},
+		{"go-1-23", true},
+		{"안녕-세상", true},
+		{"", false},
+		{"Hello-World", false},
+		{"hello--world", false},
+		{"-hello", false},
+		{"hello world", false},
+		{"hello_world", false},
+		{"2024", false},
+		{"more", false},
+		{strings.Repeat("a", MaxSlugLength), true},
+		{strings.Repeat("a", MaxSlugLength+1), false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidSlug(tt.slug); got != tt.want {
+			t.Errorf("ValidSlug(%q) = %v, want %v", tt.slug, got, tt.want)
+		}
+	}
+}
+
+func TestSlugsFromTitles(t *testing.T) {
+	store := NewStore()
+
+	post, _ := store.GetByID(4)
+	if post.Slug != "type-safe-html-templates" || post.URL() != "/posts/type-safe-html-templates" {
+		t.Errorf("Expected sample posts to get slugs from their titles, got %q", post.Slug)
+	}
+
+	// Titles that clash are numbered; ones that can't make a slug get none
+	for _, title := range []string{"Type-Safe HTML Templates!", "2024", "More", "???"} {
+		if err := store.Add(Post{Title: title, Content: "Body"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got []string
+	for _, post := range store.GetAll()[:4] {
+		got = append(got, post.Slug)
+	}
+	if want := []string{"", "post-more", "post-2024", "type-safe-html-templates-2"}; !slices.Equal(got, want) {
+		t.Errorf("Expected slugs %q, got %q", want, got)
+	}
+	if post := store.GetAll()[0]; post.URL() != "/posts/"+strconv.Itoa(post.ID) {
+		t.Errorf("Expected a post without a slug to use its ID, got %s", post.URL())
+	}
+}
+
+func TestAddWithSlug(t *testing.T) {
+	store := NewStore()
+
+	if err := store.Add(Post{Title: "Mine", Content: "Body", Slug: "Not Valid"}); !errors.Is(err, ErrInvalidSlug) {
+		t.Errorf("Expected ErrInvalidSlug, got %v", err)
+	}
+	if err := store.Add(Post{Title: "Mine", Content: "Body", Slug: "type-safe-html-templates"}); !errors.Is(err, ErrSlugTaken) {
+		t.Errorf("Expected ErrSlugTaken, got %v", err)
+	}
+	if err := store.Add(Post{Title: "Mine", Content: "Body", Slug: "my-own"}); err != nil {
+		t.Fatal(err)
+	}
+	if post, err := store.BySlug("my-own"); err != nil || post.Title != "Mine" {
+		t.Errorf("Expected the post by its chosen slug, got %+v, %v", post, err)
+	}
+}
+
+func TestSetSlugRedirectsOldSlugs(t *testing.T) {
+	store := NewStore()
+
+	post, err := store.SetSlug(4, "templ-types")
+	if err != nil {
+		t.Fatalf("SetSlug() failed: %v", err)
+	}
+	if post.Slug != "templ-types" || !slices.Equal(post.OldSlugs, []string{"type-safe-html-templates"}) {
+		t.Fatalf("Expected the old slug kept for redirects, got %q %q", post.Slug, post.OldSlugs)
+	}
+	store.SetSlug(4, "templ")
+
+	// Every slug the post had still finds it
+	for _, slug := range []string{"templ", "templ-types", "type-safe-html-templates"} {
+		if found, err := store.BySlug(slug); err != nil || found.ID != 4 || found.Slug != "templ" {
+			t.Errorf("BySlug(%q) = %d %q, %v; want post 4 at templ", slug, found.ID, found.Slug, err)
+		}
+	}
+	if _, err := store.BySlug("nope"); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("Expected ErrPostNotFound, got %v", err)
+	}
+
+	// Titles don't take a slug that still redirects
+	store.Add(Post{Title: "Templ Types", Content: "Body"})
+	if slug := store.GetAll()[0].Slug; slug != "templ-types-2" {
+		t.Errorf("Expected the new post numbered, got %q", slug)
+	}
+}
+
+func TestSetSlugErrors(t *testing.T) {
+	store := NewStore()
+
+	if _, err := store.SetSlug(4, "Bad Slug"); !errors.Is(err, ErrInvalidSlug) {
+		t.Errorf("Expected ErrInvalidSlug, got %v", err)
+	}
+	if _, err := store.SetSlug(4, "why-go-is-great-for-web-development"); !errors.Is(err, ErrSlugTaken) {
+		t.Errorf("Expected ErrSlugTaken, got %v", err)
+	}
+	if _, err := store.SetSlug(99, "anything"); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("Expected ErrPostNotFound, got %v", err)
+	}
+
+	// Trashed posts keep their slug, so restoring them can't clash
+	store.Delete(3)
+	if _, err := store.SetSlug(4, "why-go-is-great-for-web-development"); !errors.Is(err, ErrSlugTaken) {
+		t.Errorf("Expected a trashed post's slug to stay taken, got %v", err)
+	}
+}
+
+func TestSetSlugTakesOverOldSlug(t *testing.T) {
+	store := NewStore()
+	store.SetSlug(4, "templ-types")
+
+	// Another post may claim a slug that only redirects, ending the redirect
+	post, err := store.SetSlug(1, "type-safe-html-templates")
+	if err != nil {
+		t.Fatalf("SetSlug() failed: %v", err)
+	}
+	if found, _ := store.BySlug("type-safe-html-templates"); found.ID != post.ID {
+		t.Errorf("Expected the slug to name post %d, got %d", post.ID, found.ID)
+	}
+	if old, _ := store.GetByID(4); len(old.OldSlugs) != 0 {
+		t.Errorf("Expected post 4 to stop redirecting, got %q", old.OldSlugs)
+	}
+
+	// Changing back to a previous slug works too
+	if post, err := store.SetSlug(1, "getting-started-with-templ-and-htmx"); err != nil || post.Slug != "getting-started-with-templ-and-htmx" {
+		t.Errorf("Expected the post to get its old slug back, got %q, %v", post.Slug, err)
+	}
+}
+
+func TestJSONStorePersistsSlugs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posts.json")
+	store, err := NewJSONStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Add(Post{Title: "Renamed", Content: "Body", Published: true})
+	id := store.GetAll()[0].ID
+	if _, err := store.SetSlug(id, "new-name"); err != nil {
+		t.Fatalf("SetSlug() failed: %v", err)
+	}
+
+	reloaded, err := NewJSONStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post, err := reloaded.BySlug("renamed"); err != nil || post.ID != id || post.Slug != "new-name" {
+		t.Errorf("Expected the redirect to survive a restart, got %+v, %v", post, err)
+	}
+}
+
+func TestMarkdownKeepsSlug(t *testing.T) {
+	post, err := UnmarshalMarkdown(MarshalMarkdown(Post{Title: "Title", Slug: "custom", Content: "Body"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.Slug != "custom" {
+		t.Errorf("Expected the slug to round-trip, got %q", post.Slug)
+	}
+
+	// Imported slugs are normalized and kept unique
+	store := NewStore()
+	imported, err := store.Import(Post{Title: "Anything", Slug: "Type Safe HTML Templates", Content: "Body"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Slug != "type-safe-html-templates-2" {
+		t.Errorf("Expected a normalized, numbered slug, got %q", imported.Slug)
+	}
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// SortOrder selects how a list of posts is ordered
+type SortOrder string
+
+const (
+	// SortRelevance keeps search results in ranked order (newest first when not searching)
+	SortRelevance SortOrder = ""
+	// SortNewest lists the most recently published posts first
+	SortNewest SortOrder = "newest"
+	// SortOldest lists the earliest published posts first
+	SortOldest SortOrder = "oldest"
+	// SortTitle lists posts alphabetically by title
+	SortTitle SortOrder = "title"
+	// SortAuthor lists posts alphabetically by author, newest first per author
+	SortAuthor SortOrder = "author"
+)
+
+// ParseSortOrder converts a query parameter to a SortOrder,
+// falling back to SortRelevance for unknown values
+func ParseSortOrder(value string) SortOrder {
+	switch order := SortOrder(strings.ToLower(strings.TrimSpace(value))); order {
+	case SortNewest, SortOldest, SortTitle, SortAuthor:
+		return order
+	default:
+		return SortRelevance
+	}
+}
+
+// SortPosts returns a sorted copy of posts. SortRelevance leaves the
+// order unchanged, since ranked search results are already ordered.
+func SortPosts(posts []Post, order SortOrder) []Post {
+	sorted := make([]Post, len(posts))
+	copy(sorted, posts)
+
+	var less func(a, b Post) bool
+	switch order {
+	case SortNewest:
+		less = newerThan
+	case SortOldest:
+		less = func(a, b Post) bool { return newerThan(b, a) }
+	case SortTitle:
+		less = func(a, b Post) bool {
+			return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+		}
+	case SortAuthor:
+		less = func(a, b Post) bool {
+			aa, ba := strings.ToLower(a.Author), strings.ToLower(b.Author)
+			if aa != ba {
+				return aa < ba
+			}
+			return newerThan(a, b)
+		}
+	default:
+		return sorted
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return less(sorted[i], sorted[j])
+	})
+	return sorted
+}
+
+// newerThan compares posts by publication date, using creation
+// date for drafts, and ID to break ties
+func newerThan(a, b Post) bool {
+	at, bt := a.PublishedAt, b.PublishedAt
+	if at.IsZero() {
+		at = a.CreatedAt
+	}
+	if bt.IsZero() {
+		bt = b.CreatedAt
+	}
+	if !at.Equal(bt) {
+		return at.After(bt)
+	}
+	return a.ID > b.ID
+}
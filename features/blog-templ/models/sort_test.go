@@ -0,0 +1,67 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSortOrder(t *testing.T) {
+	tests := map[string]SortOrder{
+		"":        SortRelevance,
+		"newest":  SortNewest,
+		"OLDEST":  SortOldest,
+		" title ": SortTitle,
+		"author":  SortAuthor,
+		"bogus":   SortRelevance,
+	}
+
+	for input, want := range tests {
+		if got := ParseSortOrder(input); got != want {
+			t.Errorf("ParseSortOrder(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestSortPosts(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	posts := []Post{
+		{ID: 1, Title: "banana", Author: "Zoe", PublishedAt: base.Add(2 * time.Hour)},
+		{ID: 2, Title: "Apple", Author: "adam", PublishedAt: base},
+		{ID: 3, Title: "cherry", Author: "Zoe", PublishedAt: base.Add(time.Hour)},
+	}
+
+	tests := []struct {
+		order SortOrder
+		want  []int
+	}{
+		{order: SortNewest, want: []int{1, 3, 2}},
+		{order: SortOldest, want: []int{2, 3, 1}},
+		{order: SortTitle, want: []int{2, 1, 3}},
+		{order: SortAuthor, want: []int{2, 1, 3}},
+		{order: SortRelevance, want: []int{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			sorted := SortPosts(posts, tt.order)
+			for i, id := range tt.want {
+				if sorted[i].ID != id {
+					t.Fatalf("Expected order %v, got %v", tt.want, ids(sorted))
+				}
+			}
+		})
+	}
+
+	// The input slice is left untouched
+	if posts[0].ID != 1 || posts[1].ID != 2 || posts[2].ID != 3 {
+		t.Error("SortPosts modified its input")
+	}
+}
+
+func ids(posts []Post) []int {
+	var result []int
+	for _, post := range posts {
+		result = append(result, post.ID)
+	}
+	return result
+}
@@ -0,0 +1,220 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SpamVerdict is what a spam check decided about a comment
+type SpamVerdict int
+
+const (
+	// SpamClean comments are published right away
+	SpamClean SpamVerdict = iota
+	// SpamSuspect comments are held in the moderation queue
+	SpamSuspect
+	// SpamRejected comments are not stored at all
+	SpamRejected
+)
+
+// String returns the verdict's name for logs
+func (v SpamVerdict) String() string {
+	switch v {
+	case SpamClean:
+		return "clean"
+	case SpamSuspect:
+		return "suspect"
+	case SpamRejected:
+		return "rejected"
+	}
+	return fmt.Sprintf("SpamVerdict(%d)", int(v))
+}
+
+// CommentSubmission is a comment as submitted, with the request details
+// spam checks look at
+type CommentSubmission struct {
+	Comment   Comment
+	IP        string
+	UserAgent string
+	Referrer  string
+	// Permalink is the absolute URL of the post being commented on
+	Permalink string
+	// Honeypot is the value of a form field hidden from people; only bots
+	// fill it in
+	Honeypot string
+}
+
+// SpamChecker decides whether a submitted comment is spam
+type SpamChecker interface {
+	Check(ctx context.Context, sub CommentSubmission) (SpamVerdict, error)
+}
+
+// SpamCheckers runs several checks and keeps the harshest verdict. A
+// check that fails doesn't stop the others; its error is returned along
+// with the verdict of the rest.
+type SpamCheckers []SpamChecker
+
+// Check runs every check, stopping early once one rejects the comment
+func (checks SpamCheckers) Check(ctx context.Context, sub CommentSubmission) (SpamVerdict, error) {
+	verdict := SpamClean
+	var errs []error
+	for _, check := range checks {
+		v, err := check.Check(ctx, sub)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		verdict = max(verdict, v)
+		if verdict == SpamRejected {
+			break
+		}
+	}
+	return verdict, errors.Join(errs...)
+}
+
+// HoneypotChecker rejects comments that filled in the honeypot field
+type HoneypotChecker struct{}
+
+// Check rejects the comment when the honeypot isn't empty
+func (HoneypotChecker) Check(_ context.Context, sub CommentSubmission) (SpamVerdict, error) {
+	if strings.TrimSpace(sub.Honeypot) != "" {
+		return SpamRejected, nil
+	}
+	return SpamClean, nil
+}
+
+// linkPattern matches the links a comment carries
+var linkPattern = regexp.MustCompile(`(?i)https?://|www\.|\[url`)
+
+// KeywordChecker scores comments by simple heuristics: blocked words,
+// suspicious words, and how many links they carry
+type KeywordChecker struct {
+	// Blocked words reject a comment outright
+	Blocked []string
+	// Suspicious words send a comment to moderation
+	Suspicious []string
+	// MaxLinks is how many links a comment may carry before it is held;
+	// twice as many reject it
+	MaxLinks int
+}
+
+// NewKeywordChecker creates a checker with word lists for common spam
+func NewKeywordChecker() *KeywordChecker {
+	return &KeywordChecker{
+		Blocked: []string{
+			"viagra", "cialis", "casino", "payday loan", "replica watches",
+			"[url=", "buy followers",
+		},
+		Suspicious: []string{
+			"crypto", "bitcoin", "forex", "seo services", "backlinks",
+			"make money", "work from home", "click here", "free gift",
+			"limited offer", "discount",
+		},
+		MaxLinks: 2,
+	}
+}
+
+// Check looks for blocked and suspicious words in the comment and its
+// author's name, and counts its links
+func (k *KeywordChecker) Check(_ context.Context, sub CommentSubmission) (SpamVerdict, error) {
+	text := strings.ToLower(sub.Comment.Author + "\n" + sub.Comment.Content)
+
+	for _, word := range k.Blocked {
+		if strings.Contains(text, word) {
+			return SpamRejected, nil
+		}
+	}
+
+	links := len(linkPattern.FindAllStringIndex(text, -1))
+	if links > 2*k.MaxLinks {
+		return SpamRejected, nil
+	}
+	if links > k.MaxLinks {
+		return SpamSuspect, nil
+	}
+
+	for _, word := range k.Suspicious {
+		if strings.Contains(text, word) {
+			return SpamSuspect, nil
+		}
+	}
+	// Shouting the whole comment is a spam tell, but a weak one
+	if len(sub.Comment.Content) >= 20 && strings.ToUpper(sub.Comment.Content) == sub.Comment.Content &&
+		strings.ToLower(sub.Comment.Content) != sub.Comment.Content {
+		return SpamSuspect, nil
+	}
+	return SpamClean, nil
+}
+
+// AkismetChecker asks the Akismet service whether a comment is spam.
+// Comments Akismet calls spam are held for moderation, unless it is sure
+// enough to say they can be discarded.
+type AkismetChecker struct {
+	key      string
+	blog     string
+	endpoint string
+	client   *http.Client
+}
+
+// NewAkismetChecker creates a checker for the site at blogURL, using the
+// given Akismet API key
+func NewAkismetChecker(key, blogURL string) *AkismetChecker {
+	return &AkismetChecker{
+		key:      key,
+		blog:     blogURL,
+		endpoint: "https://rest.akismet.com/1.1/comment-check",
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Check calls Akismet's comment-check API
+func (a *AkismetChecker) Check(ctx context.Context, sub CommentSubmission) (SpamVerdict, error) {
+	form := url.Values{
+		"api_key":         {a.key},
+		"blog":            {a.blog},
+		"user_ip":         {sub.IP},
+		"user_agent":      {sub.UserAgent},
+		"referrer":        {sub.Referrer},
+		"permalink":       {sub.Permalink},
+		"comment_type":    {"comment"},
+		"comment_author":  {sub.Comment.Author},
+		"comment_content": {sub.Comment.Content},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return SpamClean, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return SpamClean, fmt.Errorf("akismet: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return SpamClean, fmt.Errorf("akismet: %w", err)
+	}
+
+	switch strings.TrimSpace(string(body)) {
+	case "true":
+		if resp.Header.Get("X-akismet-pro-tip") == "discard" {
+			return SpamRejected, nil
+		}
+		return SpamSuspect, nil
+	case "false":
+		return SpamClean, nil
+	}
+	// Akismet explains bad requests, such as an invalid key, in a header
+	if reason := resp.Header.Get("X-akismet-debug-help"); reason != "" {
+		return SpamClean, fmt.Errorf("akismet: %s", reason)
+	}
+	return SpamClean, fmt.Errorf("akismet: unexpected response %q (%s)", body, resp.Status)
+}
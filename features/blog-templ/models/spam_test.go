@@ -0,0 +1,110 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestKeywordChecker(t *testing.T) {
+	checker := NewKeywordChecker()
+
+	tests := []struct {
+		name    string
+		author  string
+		content string
+		want    SpamVerdict
+	}{
+		{"ordinary", "Reader", "Great post, thanks! The part about <b>templ</b> helped.", SpamClean},
+		{"one link", "Reader", "I wrote more at https://example.com/notes", SpamClean},
+		{"blocked word", "Reader", "Cheap VIAGRA here", SpamRejected},
+		{"blocked author", "Best Casino Deals", "Nice", SpamRejected},
+		{"suspicious word", "Reader", "Earn bitcoin fast", SpamSuspect},
+		{"several links", "Reader", "http://a.example http://b.example www.c.example", SpamSuspect},
+		{"many links", "Reader", strings.Repeat("http://spam.example ", 5), SpamRejected},
+		{"shouting", "Reader", "THIS IS THE BEST BLOG EVER WRITTEN", SpamSuspect},
+	}
+	for _, tt := range tests {
+		sub := CommentSubmission{Comment: Comment{Author: tt.author, Content: tt.content}}
+		if got, _ := checker.Check(context.Background(), sub); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestHoneypotChecker(t *testing.T) {
+	if got, _ := (HoneypotChecker{}).Check(context.Background(), CommentSubmission{Honeypot: "http://spam.example"}); got != SpamRejected {
+		t.Errorf("Expected a filled honeypot to be rejected, got %v", got)
+	}
+	if got, _ := (HoneypotChecker{}).Check(context.Background(), CommentSubmission{}); got != SpamClean {
+		t.Errorf("Expected an empty honeypot to pass, got %v", got)
+	}
+}
+
+// fixedChecker returns the same verdict for every comment
+type fixedChecker struct {
+	verdict SpamVerdict
+	err     error
+}
+
+func (f fixedChecker) Check(context.Context, CommentSubmission) (SpamVerdict, error) {
+	return f.verdict, f.err
+}
+
+func TestSpamCheckers(t *testing.T) {
+	down := errors.New("service down")
+	checks := SpamCheckers{fixedChecker{SpamClean, nil}, fixedChecker{SpamClean, down}, fixedChecker{SpamSuspect, nil}}
+
+	verdict, err := checks.Check(context.Background(), CommentSubmission{})
+	if verdict != SpamSuspect {
+		t.Errorf("Expected the harshest verdict, got %v", verdict)
+	}
+	if !errors.Is(err, down) {
+		t.Errorf("Expected the failed check's error, got %v", err)
+	}
+}
+
+func TestAkismetChecker(t *testing.T) {
+	var form map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = map[string]string{}
+		for key := range r.PostForm {
+			form[key] = r.PostForm.Get(key)
+		}
+		switch r.PostForm.Get("comment_content") {
+		case "spam":
+			w.Write([]byte("true"))
+		case "obvious spam":
+			w.Header().Set("X-akismet-pro-tip", "discard")
+			w.Write([]byte("true"))
+		case "ham":
+			w.Write([]byte("false"))
+		default:
+			w.Header().Set("X-akismet-debug-help", "Empty comment")
+			w.Write([]byte("invalid"))
+		}
+	}))
+	defer server.Close()
+
+	checker := NewAkismetChecker("secret-key", "https://blog.example.com")
+	checker.endpoint = server.URL
+
+	for content, want := range map[string]SpamVerdict{"ham": SpamClean, "spam": SpamSuspect, "obvious spam": SpamRejected} {
+		sub := CommentSubmission{Comment: Comment{Author: "Reader", Content: content}, IP: "203.0.113.7"}
+		got, err := checker.Check(context.Background(), sub)
+		if err != nil || got != want {
+			t.Errorf("%q: expected %v, got %v %v", content, want, got, err)
+		}
+	}
+	if form["api_key"] != "secret-key" || form["blog"] != "https://blog.example.com" || form["user_ip"] != "203.0.113.7" {
+		t.Errorf("Expected the key, blog and IP to be sent, got %v", form)
+	}
+
+	if _, err := checker.Check(context.Background(), CommentSubmission{}); err == nil || !strings.Contains(err.Error(), "Empty comment") {
+		t.Errorf("Expected Akismet's explanation, got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidEmail is returned when subscribing with a malformed address
+	ErrInvalidEmail = errors.New("enter a valid email address")
+	// ErrAlreadySubscribed is returned when a confirmed subscriber subscribes again
+	ErrAlreadySubscribed = errors.New("that address is already subscribed")
+	// ErrSubscriberNotFound is returned for unknown confirmation or unsubscribe tokens
+	ErrSubscriberNotFound = errors.New("subscription not found")
+)
+
+// Subscriber is an email address that gets new posts. Subscriptions are
+// double opt-in: nothing but the confirmation email is sent until the
+// address owner follows the link in it.
+type Subscriber struct {
+	Email string
+	// Token is the secret in the confirmation and unsubscribe links
+	Token       string
+	Confirmed   bool
+	CreatedAt   time.Time
+	ConfirmedAt time.Time
+	// Locale is the UI language the reader subscribed in, used for emails
+	Locale string
+}
+
+// SubscriberStore manages email subscriptions
+type SubscriberStore interface {
+	Subscribe(email, locale string) (Subscriber, error)
+	Confirm(token string) (Subscriber, error)
+	Get(token string) (Subscriber, error)
+	Unsubscribe(token string) error
+	Confirmed() []Subscriber
+}
+
+// MemorySubscriberStore keeps subscribers in memory
+type MemorySubscriberStore struct {
+	subscribers map[string]Subscriber // by token
+	mu          sync.Mutex
+}
+
+// NewSubscriberStore creates an empty in-memory subscriber store
+func NewSubscriberStore() *MemorySubscriberStore {
+	return &MemorySubscriberStore{subscribers: make(map[string]Subscriber)}
+}
+
+// Subscribe adds a pending subscription. Subscribing again before
+// confirming returns the same pending subscription, so the confirmation
+// email can be resent.
+func (s *MemorySubscriberStore) Subscribe(email, locale string) (Subscriber, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return Subscriber{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sub := range s.subscribers {
+		if sub.Email != email {
+			continue
+		}
+		if sub.Confirmed {
+			return Subscriber{}, ErrAlreadySubscribed
+		}
+		return sub, nil
+	}
+
+	token, err := newSubscriberToken()
+	if err != nil {
+		return Subscriber{}, err
+	}
+
+	sub := Subscriber{Email: email, Token: token, CreatedAt: time.Now(), Locale: locale}
+	s.subscribers[token] = sub
+	return sub, nil
+}
+
+// Confirm activates the subscription with the given token
+func (s *MemorySubscriberStore) Confirm(token string) (Subscriber, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subscribers[token]
+	if !ok {
+		return Subscriber{}, ErrSubscriberNotFound
+	}
+	if !sub.Confirmed {
+		sub.Confirmed = true
+		sub.ConfirmedAt = time.Now()
+		s.subscribers[token] = sub
+	}
+	return sub, nil
+}
+
+// Get returns the subscription with the given token
+func (s *MemorySubscriberStore) Get(token string) (Subscriber, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subscribers[token]
+	if !ok {
+		return Subscriber{}, ErrSubscriberNotFound
+	}
+	return sub, nil
+}
+
+// Unsubscribe removes the subscription with the given token
+func (s *MemorySubscriberStore) Unsubscribe(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscribers[token]; !ok {
+		return ErrSubscriberNotFound
+	}
+	delete(s.subscribers, token)
+	return nil
+}
+
+// Confirmed returns the subscribers who should get new posts
+func (s *MemorySubscriberStore) Confirmed() []Subscriber {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var confirmed []Subscriber
+	for _, sub := range s.subscribers {
+		if sub.Confirmed {
+			confirmed = append(confirmed, sub)
+		}
+	}
+	return confirmed
+}
+
+// normalizeEmail validates a bare address and lowercases it
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	addr, err := mail.ParseAddress(email)
+	// Reject "Name <addr>" forms; the field takes a bare address
+	if err != nil || addr.Address != email {
+		return "", ErrInvalidEmail
+	}
+	return strings.ToLower(addr.Address), nil
+}
+
+// newSubscriberToken returns a random URL-safe token
+func newSubscriberToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSubscriberDoubleOptIn(t *testing.T) {
+	store := NewSubscriberStore()
+
+	sub, err := store.Subscribe("  Reader@Example.com ", "ko")
+	if err != nil {
+		t.Fatalf("Subscribe() failed: %v", err)
+	}
+	if sub.Email != "reader@example.com" || sub.Token == "" || sub.Confirmed || sub.Locale != "ko" {
+		t.Errorf("Unexpected pending subscriber %+v", sub)
+	}
+	if len(store.Confirmed()) != 0 {
+		t.Error("Expected no confirmed subscribers before confirmation")
+	}
+
+	// Subscribing again before confirming returns the same pending entry
+	again, err := store.Subscribe("reader@example.com", "en")
+	if err != nil || again.Token != sub.Token {
+		t.Errorf("Expected the pending subscription back, got %+v, %v", again, err)
+	}
+
+	confirmed, err := store.Confirm(sub.Token)
+	if err != nil || !confirmed.Confirmed || confirmed.ConfirmedAt.IsZero() {
+		t.Fatalf("Expected a confirmed subscriber, got %+v, %v", confirmed, err)
+	}
+	if got := store.Confirmed(); len(got) != 1 || got[0].Email != "reader@example.com" {
+		t.Errorf("Expected one confirmed subscriber, got %+v", got)
+	}
+
+	if _, err := store.Subscribe("READER@example.com", "en"); !errors.Is(err, ErrAlreadySubscribed) {
+		t.Errorf("Expected ErrAlreadySubscribed, got %v", err)
+	}
+}
+
+func TestSubscribeInvalidEmail(t *testing.T) {
+	store := NewSubscriberStore()
+
+	for _, email := range []string{"", "not-an-email", "Reader <reader@example.com>", "a@b.com\r\nBcc: x@y.com"} {
+		if _, err := store.Subscribe(email, "en"); !errors.Is(err, ErrInvalidEmail) {
+			t.Errorf("Subscribe(%q): expected ErrInvalidEmail, got %v", email, err)
+		}
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	store := NewSubscriberStore()
+	sub, _ := store.Subscribe("reader@example.com", "en")
+	store.Confirm(sub.Token)
+
+	if err := store.Unsubscribe(sub.Token); err != nil {
+		t.Fatalf("Unsubscribe() failed: %v", err)
+	}
+	if len(store.Confirmed()) != 0 {
+		t.Error("Expected no subscribers after unsubscribing")
+	}
+	if _, err := store.Get(sub.Token); !errors.Is(err, ErrSubscriberNotFound) {
+		t.Errorf("Expected ErrSubscriberNotFound, got %v", err)
+	}
+	if err := store.Unsubscribe(sub.Token); !errors.Is(err, ErrSubscriberNotFound) {
+		t.Errorf("Expected ErrSubscriberNotFound unsubscribing twice, got %v", err)
+	}
+	if _, err := store.Confirm("nope"); !errors.Is(err, ErrSubscriberNotFound) {
+		t.Errorf("Expected ErrSubscriberNotFound for an unknown token, got %v", err)
+	}
+}
+
+func TestFormatMessage(t *testing.T) {
+	msg := string(formatMessage("blog@example.com", Message{
+		To:      "reader@example.com",
+		Subject: "새 글: Hello\r\nBcc: evil@example.com",
+		Body:    "Line one\nLine two",
+	}))
+
+	if strings.Contains(msg, "\r\nBcc:") {
+		t.Error("Expected line breaks in headers to be stripped")
+	}
+	if !strings.Contains(msg, "Subject: =?utf-8?q?") {
+		t.Errorf("Expected a Q-encoded subject, got %s", msg)
+	}
+	if !strings.Contains(msg, "Content-Type: text/plain; charset=utf-8\r\n") {
+		t.Error("Expected a UTF-8 plain-text content type")
+	}
+	if !strings.HasSuffix(msg, "\r\n\r\nLine one\r\nLine two") {
+		t.Errorf("Expected the body with CRLF line endings, got %q", msg)
+	}
+}
@@ -0,0 +1,192 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// TagSuggestion is a tag completing a search, with how many published
+// posts carry it
+type TagSuggestion struct {
+	Tag   string
+	Posts int
+}
+
+// PostSuggestion is a post completing a search, with its title split
+// into segments so the words being typed can be highlighted
+type PostSuggestion struct {
+	Post  Post
+	Title []TextSegment
+}
+
+// Suggestions are the completions for what a reader has typed so far
+type Suggestions struct {
+	Posts []PostSuggestion
+	Tags  []TagSuggestion
+}
+
+// prefixEntry is one way to reach a post by typing: its title, or one of
+// its tags, starting from any word
+type prefixEntry struct {
+	key    string // lowercase words from the word typed onwards
+	postID int
+	tag    string // empty for title entries
+	// word is the position of the key's first word, so titles that start
+	// with what was typed rank above ones that only contain it
+	word int
+}
+
+// prefixIndex completes partly typed titles and tags. Its entries are
+// sorted by key, so everything starting with a prefix is one contiguous
+// run found by binary search. It is maintained alongside the search
+// index, under the store's lock.
+type prefixIndex struct {
+	entries []prefixEntry
+}
+
+// add indexes a post's title and tags, replacing any previous entries
+func (idx *prefixIndex) add(post Post) {
+	idx.remove(post.ID)
+
+	var added []prefixEntry
+	for i, key := range wordSuffixes(post.Title) {
+		added = append(added, prefixEntry{key: key, postID: post.ID, word: i})
+	}
+	for _, tag := range post.Tags {
+		for i, key := range wordSuffixes(tag) {
+			added = append(added, prefixEntry{key: key, postID: post.ID, tag: tag, word: i})
+		}
+	}
+
+	sort.SliceStable(added, func(i, j int) bool { return added[i].key < added[j].key })
+
+	// Merge the new entries in rather than sorting everything again
+	merged := make([]prefixEntry, 0, len(idx.entries)+len(added))
+	i, j := 0, 0
+	for i < len(idx.entries) && j < len(added) {
+		if added[j].key < idx.entries[i].key {
+			merged = append(merged, added[j])
+			j++
+		} else {
+			merged = append(merged, idx.entries[i])
+			i++
+		}
+	}
+	merged = append(merged, idx.entries[i:]...)
+	idx.entries = append(merged, added[j:]...)
+}
+
+// remove drops a post's entries
+func (idx *prefixIndex) remove(id int) {
+	kept := idx.entries[:0]
+	for _, e := range idx.entries {
+		if e.postID != id {
+			kept = append(kept, e)
+		}
+	}
+	idx.entries = kept
+}
+
+// lookup returns the entries whose key starts with prefix
+func (idx *prefixIndex) lookup(prefix string) []prefixEntry {
+	start := sort.Search(len(idx.entries), func(i int) bool {
+		return idx.entries[i].key >= prefix
+	})
+	end := start
+	for end < len(idx.entries) && strings.HasPrefix(idx.entries[end].key, prefix) {
+		end++
+	}
+	return idx.entries[start:end]
+}
+
+// wordSuffixes returns text's lowercase words joined by spaces, starting
+// from each word in turn: "Go in Practice" gives "go in practice",
+// "in practice" and "practice"
+func wordSuffixes(text string) []string {
+	words := tokenize(text)
+	keys := make([]string, len(words))
+	for i := range words {
+		keys[i] = strings.Join(words[i:], " ")
+	}
+	return keys
+}
+
+// Suggest completes a partly typed search with up to n published post
+// titles and n tags. Posts whose title starts with what was typed come
+// first, then the most viewed; tags on the most posts come first.
+func (s *MemoryStore) Suggest(prefix string, n int) Suggestions {
+	key := strings.Join(tokenize(prefix), " ")
+	if key == "" || n <= 0 {
+		return Suggestions{}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byID := make(map[int]Post, len(s.posts))
+	for _, post := range s.posts {
+		if post.Published {
+			byID[post.ID] = post
+		}
+	}
+
+	// The best word position each post's title matched at, and the
+	// published posts carrying each matched tag
+	titles := make(map[int]int)
+	tagPosts := make(map[string]map[int]bool)
+	tagNames := make(map[string]string)
+	for _, e := range s.prefixes.lookup(key) {
+		if _, ok := byID[e.postID]; !ok {
+			continue
+		}
+		if e.tag == "" {
+			if word, ok := titles[e.postID]; !ok || e.word < word {
+				titles[e.postID] = e.word
+			}
+			continue
+		}
+		// Tags match case-insensitively; the first spelling seen is shown
+		lower := strings.ToLower(e.tag)
+		if tagPosts[lower] == nil {
+			tagPosts[lower] = make(map[int]bool)
+			tagNames[lower] = e.tag
+		}
+		tagPosts[lower][e.postID] = true
+	}
+
+	terms := uniqueTerms(prefix)
+	posts := make([]PostSuggestion, 0, len(titles))
+	for id := range titles {
+		post := byID[id].clone()
+		posts = append(posts, PostSuggestion{Post: post, Title: highlight(post.Title, terms)})
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		a, b := posts[i].Post, posts[j].Post
+		if starts := titles[a.ID] == 0; starts != (titles[b.ID] == 0) {
+			return starts
+		}
+		if a.Views != b.Views {
+			return a.Views > b.Views
+		}
+		return newerThan(a, b)
+	})
+	if len(posts) > n {
+		posts = posts[:n]
+	}
+
+	tags := make([]TagSuggestion, 0, len(tagPosts))
+	for lower, ids := range tagPosts {
+		tags = append(tags, TagSuggestion{Tag: tagNames[lower], Posts: len(ids)})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Posts != tags[j].Posts {
+			return tags[i].Posts > tags[j].Posts
+		}
+		return strings.ToLower(tags[i].Tag) < strings.ToLower(tags[j].Tag)
+	})
+	if len(tags) > n {
+		tags = tags[:n]
+	}
+
+	return Suggestions{Posts: posts, Tags: tags}
+}
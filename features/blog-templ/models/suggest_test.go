@@ -0,0 +1,97 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func suggestedTitles(s Suggestions) []string {
+	var titles []string
+	for _, p := range s.Posts {
+		titles = append(titles, p.Post.Title)
+	}
+	return titles
+}
+
+func TestSuggestTitles(t *testing.T) {
+	store := NewStore()
+
+	// "Why Go is Great for Web Development" starts with "why"; nothing else
+	// has a word starting with it
+	got := suggestedTitles(store.Suggest("Wh", 5))
+	if !slices.Equal(got, []string{"Why Go is Great for Web Development"}) {
+		t.Errorf("Expected one title, got %q", got)
+	}
+
+	// Titles starting with what was typed come before ones containing it
+	store.Add(Post{Title: "HTMX Tips", Content: "Body", Published: true})
+	got = suggestedTitles(store.Suggest("htm", 5))
+	if want := []string{"HTMX Tips", "Type-Safe HTML Templates", "Building Real-time Search with HTMX", "Getting Started with Templ and HTMX"}; !slices.Equal(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	// Several words match as a phrase, punctuation aside
+	if got := suggestedTitles(store.Suggest("real-ti", 5)); !slices.Equal(got, []string{"Building Real-time Search with HTMX"}) {
+		t.Errorf("Expected a phrase match, got %q", got)
+	}
+	if got := store.Suggest("search real", 5); len(got.Posts) != 0 {
+		t.Errorf("Expected words out of order not to match, got %q", suggestedTitles(got))
+	}
+
+	// The typed words are marked for highlighting
+	title := store.Suggest("type-saf", 1).Posts[0].Title
+	if len(title) < 3 || !title[0].Match || title[0].Text != "Type" || !title[2].Match || title[2].Text != "Safe" {
+		t.Errorf("Unexpected highlighting %+v", title)
+	}
+}
+
+func TestSuggestTags(t *testing.T) {
+	store := NewStore()
+
+	got := store.Suggest("t", 5).Tags
+	want := []TagSuggestion{{"templ", 2}, {"tutorial", 1}, {"type safety", 1}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	// Later words of a tag match too
+	if got := store.Suggest("dev", 5).Tags; !slices.Equal(got, []TagSuggestion{{"web development", 2}}) {
+		t.Errorf("Expected web development, got %v", got)
+	}
+}
+
+func TestSuggestSkipsDraftsAndTrash(t *testing.T) {
+	store := NewStore()
+	store.Add(Post{Title: "Zebra Draft", Content: "Body", Tags: []string{"zebras"}})
+	store.Delete(3)
+
+	if got := store.Suggest("zeb", 5); len(got.Posts) != 0 || len(got.Tags) != 0 {
+		t.Errorf("Expected drafts to be left out, got %+v", got)
+	}
+	if got := suggestedTitles(store.Suggest("why", 5)); len(got) != 0 {
+		t.Errorf("Expected trashed posts to be left out, got %q", got)
+	}
+
+	// Edits and restores keep the index current
+	store.Restore(3)
+	post, _ := store.GetByID(3)
+	post.Title = "Zero to Go"
+	store.Update(post)
+	if got := suggestedTitles(store.Suggest("why", 5)); len(got) != 0 {
+		t.Errorf("Expected the old title gone, got %q", got)
+	}
+	if got := suggestedTitles(store.Suggest("ze", 5)); !slices.Equal(got, []string{"Zero to Go"}) {
+		t.Errorf("Expected the new title, got %q", got)
+	}
+}
+
+func TestSuggestLimits(t *testing.T) {
+	store := NewStore()
+
+	if got := store.Suggest("   ", 5); len(got.Posts) != 0 || len(got.Tags) != 0 {
+		t.Errorf("Expected nothing for a blank query, got %+v", got)
+	}
+	if got := store.Suggest("t", 1); len(got.Posts) != 1 || len(got.Tags) != 1 {
+		t.Errorf("Expected at most one of each, got %+v", got)
+	}
+}
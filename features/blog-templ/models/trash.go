@@ -0,0 +1,72 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Trash returns deleted posts that can still be restored, most recently
+// deleted first
+func (s *MemoryStore) Trash() []Post {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	trash := clonePosts(s.trash)
+	slices.SortStableFunc(trash, func(a, b Post) int {
+		return b.DeletedAt.Compare(a.DeletedAt)
+	})
+	return trash
+}
+
+// Restore moves a post out of the trash. It keeps its ID and dates, so
+// sorted listings show it where it was before.
+func (s *MemoryStore) Restore(id int) (Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.trash, func(p Post) bool { return p.ID == id })
+	if i < 0 {
+		return Post{}, ErrPostNotFound
+	}
+
+	post := s.trash[i]
+	post.DeletedAt = time.Time{}
+	s.trash = slices.Delete(s.trash, i, i+1)
+
+	s.posts = append([]Post{post}, s.posts...)
+	s.index.add(post)
+	s.prefixes.add(post)
+
+	return post.clone(), nil
+}
+
+// Purge permanently deletes a post from the trash
+func (s *MemoryStore) Purge(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.trash, func(p Post) bool { return p.ID == id })
+	if i < 0 {
+		return ErrPostNotFound
+	}
+	s.trash = slices.Delete(slices.Clip(s.trash), i, i+1)
+	return nil
+}
+
+// PurgeTrash permanently deletes posts trashed before the cutoff and
+// returns how many were removed
+func (s *MemoryStore) PurgeTrash(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := make([]Post, 0, len(s.trash))
+	for _, post := range s.trash {
+		if !post.DeletedAt.Before(before) {
+			kept = append(kept, post)
+		}
+	}
+
+	purged := len(s.trash) - len(kept)
+	s.trash = kept
+	return purged, nil
+}
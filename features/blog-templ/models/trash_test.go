@@ -0,0 +1,129 @@
+package models
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDeleteMovesPostToTrash(t *testing.T) {
+	store := NewStore()
+	before := len(store.GetAll())
+
+	if err := store.Delete(2); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+
+	if len(store.GetAll()) != before-1 {
+		t.Errorf("Expected %d live posts, got %d", before-1, len(store.GetAll()))
+	}
+	if _, err := store.GetByID(2); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("Expected trashed post to be hidden, got %v", err)
+	}
+
+	trash := store.Trash()
+	if len(trash) != 1 || trash[0].ID != 2 || trash[0].DeletedAt.IsZero() {
+		t.Fatalf("Expected post 2 in the trash with a deletion time, got %+v", trash)
+	}
+
+	if err := store.Delete(2); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("Expected deleting a trashed post to fail, got %v", err)
+	}
+}
+
+func TestRestoreFromTrash(t *testing.T) {
+	store := NewStore()
+	post, _ := store.GetByID(2)
+
+	store.Delete(2)
+	restored, err := store.Restore(2)
+	if err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+
+	if !restored.DeletedAt.IsZero() {
+		t.Error("Expected the restored post to have no deletion time")
+	}
+	if !restored.CreatedAt.Equal(post.CreatedAt) || !restored.PublishedAt.Equal(post.PublishedAt) {
+		t.Error("Expected the restored post to keep its dates")
+	}
+	if got := ids(SortPosts(store.GetAll(), SortNewest)); !slices.Equal(got, []int{4, 3, 2, 1}) {
+		t.Errorf("Expected the post back in date order, got %v", got)
+	}
+	if len(store.Trash()) != 0 {
+		t.Error("Expected the trash to be empty")
+	}
+	if results := store.Search(post.Title); len(results) == 0 {
+		t.Error("Expected the restored post to be searchable again")
+	}
+
+	if _, err := store.Restore(2); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("Expected restoring a live post to fail, got %v", err)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	store := NewStore()
+	store.Delete(1)
+	store.Delete(3)
+
+	if err := store.Purge(1); err != nil {
+		t.Fatalf("Purge() failed: %v", err)
+	}
+	if trash := store.Trash(); len(trash) != 1 || trash[0].ID != 3 {
+		t.Errorf("Expected only post 3 left in the trash, got %+v", trash)
+	}
+	if err := store.Purge(2); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("Expected purging a live post to fail, got %v", err)
+	}
+}
+
+func TestPurgeTrashRemovesExpiredPosts(t *testing.T) {
+	store := NewStore()
+	store.Delete(1)
+	store.Delete(2)
+	store.trash[0].DeletedAt = time.Now().Add(-40 * 24 * time.Hour)
+
+	purged, err := store.PurgeTrash(time.Now().Add(-30 * 24 * time.Hour))
+	if err != nil {
+		t.Fatalf("PurgeTrash() failed: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("Expected 1 purged post, got %d", purged)
+	}
+	if trash := store.Trash(); len(trash) != 1 || trash[0].ID != 2 {
+		t.Errorf("Expected the recent deletion to stay, got %+v", trash)
+	}
+}
+
+func TestJSONStorePersistsTrash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posts.json")
+	store, _ := NewJSONStore(path)
+	store.Add(Post{Title: "Keep", Content: "Live"})
+	store.Add(Post{Title: "Bin", Content: "Trashed"})
+	store.Delete(2)
+
+	reloaded, err := NewJSONStore(path)
+	if err != nil {
+		t.Fatalf("NewJSONStore() failed: %v", err)
+	}
+	if len(reloaded.GetAll()) != 1 || len(reloaded.Trash()) != 1 {
+		t.Fatalf("Expected 1 live and 1 trashed post, got %d and %d", len(reloaded.GetAll()), len(reloaded.Trash()))
+	}
+
+	// Trashed IDs are not reused
+	reloaded.Add(Post{Title: "New", Content: "Post"})
+	if id := reloaded.GetAll()[0].ID; id != 3 {
+		t.Errorf("Expected new post ID 3, got %d", id)
+	}
+
+	if _, err := reloaded.Restore(2); err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+	reloaded, _ = NewJSONStore(path)
+	if _, err := reloaded.GetByID(2); err != nil {
+		t.Errorf("Expected the restore to be saved, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Activity is a kind of reader activity that makes a post trend
+type Activity int
+
+const (
+	// ActivityView is a reader opening a post
+	ActivityView Activity = iota
+	// ActivityReaction is a reader reacting to a post
+	ActivityReaction
+)
+
+// weight is how much one activity counts towards a post's trending score.
+// Reacting takes more interest than opening a page, so it counts more.
+func (a Activity) weight() int {
+	if a == ActivityReaction {
+		return 3
+	}
+	return 1
+}
+
+// trendingBucket is the score each post earned in one bucket of time
+type trendingBucket struct {
+	start  time.Time
+	scores map[int]int
+}
+
+// TrendingPost is a post with its score over the trending window
+type TrendingPost struct {
+	Post  Post
+	Score int
+}
+
+// TrendingTag is a tag with the summed score of its posts
+type TrendingTag struct {
+	Tag   string
+	Score int
+}
+
+// Trending scores posts by recent views and reactions over a sliding
+// window. Activity is kept in buckets of a fraction of the window, so
+// memory stays bounded however busy the blog is, and the window slides a
+// bucket at a time. It is safe for concurrent use.
+type Trending struct {
+	window  time.Duration
+	bucket  time.Duration
+	buckets []trendingBucket // oldest first
+	mu      sync.Mutex
+	now     func() time.Time
+}
+
+// trendingBuckets is how many buckets the window is split into
+const trendingBuckets = 60
+
+// NewTrending creates a tracker that counts activity from the last window
+func NewTrending(window time.Duration) *Trending {
+	return &Trending{
+		window: window,
+		bucket: max(window/trendingBuckets, time.Second),
+		now:    time.Now,
+	}
+}
+
+// Record counts one activity on a post
+func (t *Trending) Record(postID int, activity Activity) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.prune(now)
+
+	start := now.Truncate(t.bucket)
+	if n := len(t.buckets); n == 0 || t.buckets[n-1].start.Before(start) {
+		t.buckets = append(t.buckets, trendingBucket{start: start, scores: make(map[int]int)})
+	}
+	t.buckets[len(t.buckets)-1].scores[postID] += activity.weight()
+}
+
+// Scores returns each post's score over the window. Posts with no recent
+// activity are left out.
+func (t *Trending) Scores() map[int]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(t.now())
+	scores := make(map[int]int)
+	for _, b := range t.buckets {
+		for id, score := range b.scores {
+			scores[id] += score
+		}
+	}
+	return scores
+}
+
+// prune drops buckets that have slid out of the window. The caller must
+// hold t.mu.
+func (t *Trending) prune(now time.Time) {
+	cutoff := now.Add(-t.window)
+	i := 0
+	for i < len(t.buckets) && !t.buckets[i].start.After(cutoff) {
+		i++
+	}
+	t.buckets = t.buckets[i:]
+}
+
+// Top ranks the published posts among posts by their trending score, and
+// their tags by the summed score of the posts carrying them. It returns
+// at most n of each; posts and tags with no recent activity are left out.
+func (t *Trending) Top(posts []Post, n int) ([]TrendingPost, []TrendingTag) {
+	scores := t.Scores()
+
+	var (
+		top       []TrendingPost
+		tagScores = make(map[string]int)
+		tagNames  = make(map[string]string)
+	)
+	for _, post := range posts {
+		score := scores[post.ID]
+		if !post.Published || score == 0 {
+			continue
+		}
+		top = append(top, TrendingPost{Post: post, Score: score})
+		// Tags match case-insensitively; the first spelling seen is shown
+		for _, tag := range post.Tags {
+			key := strings.ToLower(tag)
+			if _, ok := tagNames[key]; !ok {
+				tagNames[key] = tag
+			}
+			tagScores[key] += score
+		}
+	}
+
+	sort.SliceStable(top, func(i, j int) bool {
+		if top[i].Score != top[j].Score {
+			return top[i].Score > top[j].Score
+		}
+		return newerThan(top[i].Post, top[j].Post)
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+
+	tags := make([]TrendingTag, 0, len(tagScores))
+	for key, score := range tagScores {
+		tags = append(tags, TrendingTag{Tag: tagNames[key], Score: score})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Score != tags[j].Score {
+			return tags[i].Score > tags[j].Score
+		}
+		return strings.ToLower(tags[i].Tag) < strings.ToLower(tags[j].Tag)
+	})
+	if len(tags) > n {
+		tags = tags[:n]
+	}
+
+	return top, tags
+}
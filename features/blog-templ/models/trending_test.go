@@ -0,0 +1,82 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock is a settable time source for trackers
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func newTestTrending(window time.Duration) (*Trending, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	trending := NewTrending(window)
+	trending.now = clock.Now
+	return trending, clock
+}
+
+func TestTrendingWeighsReactions(t *testing.T) {
+	trending, _ := newTestTrending(time.Hour)
+
+	trending.Record(1, ActivityView)
+	trending.Record(1, ActivityView)
+	trending.Record(2, ActivityReaction)
+	trending.Record(3, ActivityView)
+
+	scores := trending.Scores()
+	if scores[1] != 2 || scores[2] != 3 || scores[3] != 1 || len(scores) != 3 {
+		t.Errorf("Unexpected scores %v", scores)
+	}
+}
+
+func TestTrendingWindowSlides(t *testing.T) {
+	trending, clock := newTestTrending(time.Hour)
+
+	trending.Record(1, ActivityReaction)
+	clock.now = clock.now.Add(40 * time.Minute)
+	trending.Record(2, ActivityView)
+
+	clock.now = clock.now.Add(30 * time.Minute)
+	if scores := trending.Scores(); scores[1] != 0 || scores[2] != 1 {
+		t.Errorf("Expected only activity from the last hour, got %v", scores)
+	}
+
+	clock.now = clock.now.Add(time.Hour)
+	if scores := trending.Scores(); len(scores) != 0 {
+		t.Errorf("Expected nothing left after the window, got %v", scores)
+	}
+}
+
+func TestTrendingTop(t *testing.T) {
+	store := NewStore()
+	if err := store.Add(Post{Title: "Draft", Content: "Body", Tags: []string{"secret"}}); err != nil {
+		t.Fatal(err)
+	}
+	draft := store.GetAll()[0]
+	trending, _ := newTestTrending(time.Hour)
+
+	// Post 4 outscores posts 1 and 3, which tie; post 3 is newer. Drafts
+	// never trend.
+	for range 3 {
+		trending.Record(4, ActivityView)
+	}
+	trending.Record(1, ActivityView)
+	trending.Record(3, ActivityView)
+	trending.Record(draft.ID, ActivityReaction)
+
+	posts, tags := trending.Top(store.GetAll(), 2)
+	if len(posts) != 2 || posts[0].Post.ID != 4 || posts[0].Score != 3 || posts[1].Post.ID != 3 {
+		t.Errorf("Expected posts 4 and 3, got %+v", posts)
+	}
+	// Tags sum the scores of every trending post, not just the top ones:
+	// go is on posts 4, 1 and 3, templ on posts 4 and 1
+	if len(tags) != 2 || tags[0] != (TrendingTag{"go", 5}) || tags[1] != (TrendingTag{"templ", 4}) {
+		t.Errorf("Expected go and templ, got %+v", tags)
+	}
+
+	if posts, tags := NewTrending(time.Hour).Top(store.GetAll(), 5); len(posts) != 0 || len(tags) != 0 {
+		t.Errorf("Expected nothing trending without activity, got %v %v", posts, tags)
+	}
+}
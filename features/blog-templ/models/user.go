@@ -0,0 +1,118 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidCredentials is returned when a username/password pair doesn't match
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrUserExists is returned when registering a taken username
+	ErrUserExists = errors.New("username is already taken")
+)
+
+// MinPasswordLength is the shortest accepted password
+const MinPasswordLength = 8
+
+// User represents an author account
+type User struct {
+	Username     string
+	DisplayName  string
+	PasswordHash []byte
+}
+
+// UserStore manages author accounts
+type UserStore interface {
+	Register(username, displayName, password string) (User, error)
+	Authenticate(username, password string) (User, error)
+	GetByUsername(username string) (User, bool)
+}
+
+// MemoryUserStore keeps author accounts in memory
+type MemoryUserStore struct {
+	users map[string]User
+	mu    sync.RWMutex
+}
+
+// NewUserStore creates an empty in-memory user store
+func NewUserStore() *MemoryUserStore {
+	return &MemoryUserStore{users: make(map[string]User)}
+}
+
+// Register creates an account with a bcrypt-hashed password
+func (s *MemoryUserStore) Register(username, displayName, password string) (User, error) {
+	username = strings.ToLower(strings.TrimSpace(username))
+	displayName = strings.TrimSpace(displayName)
+
+	if username == "" {
+		return User{}, errors.New("username is required")
+	}
+	if displayName == "" {
+		displayName = username
+	}
+	if len(password) < MinPasswordLength {
+		return User{}, errors.New("password must be at least 8 characters")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return User{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.users[username]; exists {
+		return User{}, ErrUserExists
+	}
+
+	user := User{
+		Username:     username,
+		DisplayName:  displayName,
+		PasswordHash: hash,
+	}
+	s.users[username] = user
+
+	return user, nil
+}
+
+// Authenticate returns the user if the password matches
+func (s *MemoryUserStore) Authenticate(username, password string) (User, error) {
+	user, ok := s.GetByUsername(username)
+	if !ok {
+		return User{}, ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password)); err != nil {
+		return User{}, ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+// GetByUsername looks up an account (case-insensitive)
+func (s *MemoryUserStore) GetByUsername(username string) (User, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[strings.ToLower(strings.TrimSpace(username))]
+	return user, ok
+}
+
+type userContextKey struct{}
+
+// ContextWithUser returns a copy of ctx carrying the logged-in user
+func ContextWithUser(ctx context.Context, user User) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
+// UserFromContext returns the logged-in user, if any
+func UserFromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(userContextKey{}).(User)
+	return user, ok
+}
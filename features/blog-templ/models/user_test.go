@@ -0,0 +1,96 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRegisterAndAuthenticate(t *testing.T) {
+	store := NewUserStore()
+
+	user, err := store.Register(" Jane ", "Jane Doe", "correct horse")
+	if err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+	if user.Username != "jane" {
+		t.Errorf("Expected normalized username 'jane', got %q", user.Username)
+	}
+	if string(user.PasswordHash) == "correct horse" {
+		t.Error("Password must not be stored in plain text")
+	}
+
+	got, err := store.Authenticate("JANE", "correct horse")
+	if err != nil {
+		t.Fatalf("Authenticate() failed: %v", err)
+	}
+	if got.DisplayName != "Jane Doe" {
+		t.Errorf("Expected display name 'Jane Doe', got %q", got.DisplayName)
+	}
+
+	if _, err := store.Authenticate("jane", "wrong password"); err != ErrInvalidCredentials {
+		t.Errorf("Expected ErrInvalidCredentials for wrong password, got %v", err)
+	}
+	if _, err := store.Authenticate("nobody", "correct horse"); err != ErrInvalidCredentials {
+		t.Errorf("Expected ErrInvalidCredentials for unknown user, got %v", err)
+	}
+}
+
+func TestRegisterValidation(t *testing.T) {
+	store := NewUserStore()
+
+	if _, err := store.Register("", "No Name", "long enough"); err == nil {
+		t.Error("Expected error for empty username")
+	}
+	if _, err := store.Register("short", "Short", "abc"); err == nil {
+		t.Error("Expected error for short password")
+	}
+
+	store.Register("jane", "Jane Doe", "long enough")
+	if _, err := store.Register("Jane", "Other Jane", "long enough"); err != ErrUserExists {
+		t.Errorf("Expected ErrUserExists, got %v", err)
+	}
+}
+
+func TestUserContext(t *testing.T) {
+	ctx := context.Background()
+	if _, ok := UserFromContext(ctx); ok {
+		t.Error("Expected no user in empty context")
+	}
+
+	ctx = ContextWithUser(ctx, User{Username: "jane"})
+	user, ok := UserFromContext(ctx)
+	if !ok || user.Username != "jane" {
+		t.Errorf("Expected user jane in context, got %+v", user)
+	}
+}
+
+func TestSessionStore(t *testing.T) {
+	now := time.Now()
+	sessions := NewSessionStore()
+	sessions.now = func() time.Time { return now }
+
+	token, err := sessions.Create("jane")
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	other, _ := sessions.Create("jane")
+	if token == other {
+		t.Error("Expected unique session tokens")
+	}
+
+	if username, ok := sessions.Get(token); !ok || username != "jane" {
+		t.Errorf("Expected session for jane, got %q, %v", username, ok)
+	}
+
+	sessions.Delete(token)
+	if _, ok := sessions.Get(token); ok {
+		t.Error("Expected deleted session to be invalid")
+	}
+
+	now = now.Add(SessionTTL + time.Second)
+	if _, ok := sessions.Get(other); ok {
+		t.Error("Expected expired session to be invalid")
+	}
+}
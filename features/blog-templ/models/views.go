@@ -0,0 +1,43 @@
+package models
+
+import "sort"
+
+// RecordView counts one view of a post and returns its new total
+func (s *MemoryStore) RecordView(id int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.posts {
+		if s.posts[i].ID == id {
+			s.posts[i].Views++
+			return s.posts[i].Views, nil
+		}
+	}
+
+	return 0, ErrPostNotFound
+}
+
+// MostViewed returns up to n published posts with the most views.
+// Posts nobody has viewed yet are left out.
+func (s *MemoryStore) MostViewed(n int) []Post {
+	s.mu.RLock()
+	var posts []Post
+	for _, post := range s.posts {
+		if post.Published && post.Views > 0 {
+			posts = append(posts, post.clone())
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(posts, func(i, j int) bool {
+		if posts[i].Views != posts[j].Views {
+			return posts[i].Views > posts[j].Views
+		}
+		return newerThan(posts[i], posts[j])
+	})
+
+	if len(posts) > n {
+		posts = posts[:n]
+	}
+	return posts
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordView(t *testing.T) {
+	store := NewStore()
+
+	for want := 1; want <= 3; want++ {
+		views, err := store.RecordView(2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if views != want {
+			t.Errorf("Expected %d views, got %d", want, views)
+		}
+	}
+
+	post, _ := store.GetByID(2)
+	if post.Views != 3 {
+		t.Errorf("Expected stored post to have 3 views, got %d", post.Views)
+	}
+	if !post.UpdatedAt.IsZero() {
+		t.Error("Expected a view not to count as an update")
+	}
+
+	if _, err := store.RecordView(999); err != ErrPostNotFound {
+		t.Errorf("Expected ErrPostNotFound, got %v", err)
+	}
+}
+
+func TestMostViewed(t *testing.T) {
+	store := NewStore()
+	store.Add(Post{Title: "Draft", Content: "Hidden", Author: "Jane"})
+	draft := store.GetAll()[0]
+
+	views := map[int]int{1: 2, 3: 5, 4: 2, draft.ID: 10}
+	for id, n := range views {
+		for i := 0; i < n; i++ {
+			store.RecordView(id)
+		}
+	}
+
+	popular := store.MostViewed(5)
+
+	// Post 2 was never viewed and drafts are excluded; post 4 is newer than
+	// post 1 so it wins the tie
+	want := []int{3, 4, 1}
+	if len(popular) != len(want) {
+		t.Fatalf("Expected %d posts, got %d", len(want), len(popular))
+	}
+	for i, id := range want {
+		if popular[i].ID != id {
+			t.Errorf("Position %d: expected post %d, got %d", i, id, popular[i].ID)
+		}
+	}
+
+	if got := store.MostViewed(1); len(got) != 1 || got[0].ID != 3 {
+		t.Errorf("Expected only post 3 with n=1, got %v", got)
+	}
+}
+
+func TestJSONStorePersistsViews(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posts.json")
+	store, err := NewJSONStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Add(Post{Title: "Counted", Content: "Body", Author: "Jane", Published: true})
+	id := store.GetAll()[0].ID
+	written, _ := os.ReadFile(path)
+	store.RecordView(id)
+	store.RecordView(id)
+
+	// Views are counted in memory until flushed
+	if data, _ := os.ReadFile(path); string(data) != string(written) {
+		t.Error("Expected counting a view not to rewrite the file")
+	}
+	if err := store.FlushViews(); err != nil {
+		t.Fatalf("FlushViews() failed: %v", err)
+	}
+
+	reloaded, err := NewJSONStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	post, _ := reloaded.GetByID(id)
+	if post.Views != 2 {
+		t.Errorf("Expected 2 views after reload, got %d", post.Views)
+	}
+
+	// Nothing new to write, so the file stays as it is
+	os.Remove(path)
+	if err := store.FlushViews(); err != nil {
+		t.Fatalf("FlushViews() failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected FlushViews to skip the write with no new views")
+	}
+
+	// Other changes write the views counted before them
+	store.RecordView(id)
+	store.Add(Post{Title: "Second", Content: "Body", Author: "Jane"})
+	reloaded, _ = NewJSONStore(path)
+	if post, _ := reloaded.GetByID(id); post.Views != 3 {
+		t.Errorf("Expected views written with the next change, got %d", post.Views)
+	}
+}
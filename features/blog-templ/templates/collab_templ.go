@@ -0,0 +1,323 @@
+// Code generated by templ - DO NOT EDIT.
+
+// templ: version: v0.3.943
+package templates
+
+//lint:file-ignore SA4006 This context is only used if a nested component is present.
+
+import "github.com/a-h/templ"
+import templruntime "github.com/a-h/templ/runtime"
+
+import (
+	"fmt"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+// DraftEditor is the collaborative editor for a draft. Authors with the
+// page open see each other's changes and cursors over a WebSocket.
+func DraftEditor(post models.Post, owner bool) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var1 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var1 == nil {
+			templ_7745c5c3_Var1 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Var2 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+			templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+			templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+			if !templ_7745c5c3_IsBuffer {
+				defer func() {
+					templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+					if templ_7745c5c3_Err == nil {
+						templ_7745c5c3_Err = templ_7745c5c3_BufErr
+					}
+				}()
+			}
+			ctx = templ.InitializeContext(ctx)
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div id=\"collab-editor\" class=\"collab-editor\" data-socket=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var3 string
+			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("/ws/drafts/%d", post.ID))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/collab.templ`, Line: 16, Col: 54}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "\" data-connecting=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var4 string
+			templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "collab.connecting"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/collab.templ`, Line: 17, Col: 48}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "\" data-saved=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var5 string
+			templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "collab.saved"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/collab.templ`, Line: 18, Col: 38}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "\" data-unsaved=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var6 string
+			templ_7745c5c3_Var6, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "collab.unsaved"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/collab.templ`, Line: 19, Col: 42}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var6))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "\" data-saving=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var7 string
+			templ_7745c5c3_Var7, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "collab.saving"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/collab.templ`, Line: 20, Col: 40}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var7))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "\" data-offline=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var8 string
+			templ_7745c5c3_Var8, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "collab.offline"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/collab.templ`, Line: 21, Col: 42}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var8))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "\" data-you=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var9 string
+			templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "collab.you"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/collab.templ`, Line: 22, Col: 34}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "\" data-label-title=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var10 string
+			templ_7745c5c3_Var10, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.label.title"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/collab.templ`, Line: 23, Col: 48}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var10))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "\" data-label-content=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var11 string
+			templ_7745c5c3_Var11, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.label.content"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/collab.templ`, Line: 24, Col: 52}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var11))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "\"><div class=\"drafts-header\"><h2>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var12 string
+			templ_7745c5c3_Var12, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "collab.heading"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/collab.templ`, Line: 27, Col: 34}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var12))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "</h2><a href=\"/drafts\" class=\"btn-back\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var13 string
+			templ_7745c5c3_Var13, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "collab.back"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/collab.templ`, Line: 28, Col: 62}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var13))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "</a></div><p class=\"collab-share\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var14 string
+			templ_7745c5c3_Var14, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "collab.share"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/collab.templ`, Line: 30, Col: 51}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var14))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "</p><div class=\"collab-bar\"><ul id=\"collab-peers\" class=\"collab-peers\" aria-live=\"polite\"></ul><span id=\"collab-status\" class=\"collab-status\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var15 string
+			templ_7745c5c3_Var15, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "collab.connecting"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/collab.templ`, Line: 33, Col: 80}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var15))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "</span></div><label for=\"collab-title\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var16 string
+			templ_7745c5c3_Var16, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.label.title"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/collab.templ`, Line: 35, Col: 57}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var16))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, "</label> <input type=\"text\" id=\"collab-title\" data-field=\"title\" value=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var17 string
+			templ_7745c5c3_Var17, templ_7745c5c3_Err = templ.JoinStringErrs(post.Title)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/collab.templ`, Line: 36, Col: 77}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var17))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "\" disabled> <label for=\"collab-content\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var18 string
+			templ_7745c5c3_Var18, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.label.content"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/collab.templ`, Line: 37, Col: 61}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var18))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "</label> <textarea id=\"collab-content\" data-field=\"content\" rows=\"18\" disabled>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var19 string
+			templ_7745c5c3_Var19, templ_7745c5c3_Err = templ.JoinStringErrs(post.Content)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/collab.templ`, Line: 38, Col: 87}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var19))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, "</textarea> <small>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var20 string
+			templ_7745c5c3_Var20, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.hint.markdown"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/collab.templ`, Line: 39, Col: 40}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var20))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "</small><div class=\"collab-actions\"><button type=\"button\" id=\"collab-save\" class=\"collab-save\" disabled>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var21 string
+			templ_7745c5c3_Var21, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "collab.save"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/collab.templ`, Line: 41, Col: 95}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var21))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, "</button> ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if owner {
+				templ_7745c5c3_Err = PublishButton(post).Render(ctx, templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 21, "</div></div><style>\n\t\t\t.drafts-header {\n\t\t\t\tdisplay: flex;\n\t\t\t\tjustify-content: space-between;\n\t\t\t\talign-items: center;\n\t\t\t\tmargin-bottom: 1rem;\n\t\t\t}\n\t\t\t.drafts-header h2 {\n\t\t\t\tcolor: #2c3e50;\n\t\t\t}\n\t\t\t.collab-editor {\n\t\t\t\tdisplay: grid;\n\t\t\t\tgap: 0.5rem;\n\t\t\t}\n\t\t\t.collab-editor label {\n\t\t\t\tfont-weight: 600;\n\t\t\t\tcolor: #2c3e50;\n\t\t\t\tmargin-top: 0.5rem;\n\t\t\t}\n\t\t\t.collab-editor input, .collab-editor textarea {\n\t\t\t\tpadding: 0.75rem;\n\t\t\t\tfont-size: 1rem;\n\t\t\t\tfont-family: inherit;\n\t\t\t\tborder: 2px solid #e0e0e0;\n\t\t\t\tborder-radius: 6px;\n\t\t\t\ttransition: box-shadow 0.2s;\n\t\t\t}\n\t\t\t.collab-editor small, .collab-share {\n\t\t\t\tcolor: #7f8c8d;\n\t\t\t}\n\t\t\t.collab-bar {\n\t\t\t\tdisplay: flex;\n\t\t\t\tjustify-content: space-between;\n\t\t\t\talign-items: center;\n\t\t\t\tgap: 1rem;\n\t\t\t\tbackground: white;\n\t\t\t\tpadding: 0.75rem 1rem;\n\t\t\t\tborder-radius: 8px;\n\t\t\t\tbox-shadow: 0 2px 4px rgba(0,0,0,0.1);\n\t\t\t}\n\t\t\t.collab-peers {\n\t\t\t\tlist-style: none;\n\t\t\t\tdisplay: flex;\n\t\t\t\tflex-wrap: wrap;\n\t\t\t\tgap: 0.5rem;\n\t\t\t}\n\t\t\t.collab-peers li {\n\t\t\t\tdisplay: flex;\n\t\t\t\talign-items: center;\n\t\t\t\tgap: 0.35rem;\n\t\t\t\tfont-size: 0.9rem;\n\t\t\t}\n\t\t\t.collab-dot {\n\t\t\t\twidth: 0.75rem;\n\t\t\t\theight: 0.75rem;\n\t\t\t\tborder-radius: 50%;\n\t\t\t}\n\t\t\t.collab-where {\n\t\t\t\tcolor: #7f8c8d;\n\t\t\t}\n\t\t\t.collab-status {\n\t\t\t\tcolor: #7f8c8d;\n\t\t\t\tfont-size: 0.9rem;\n\t\t\t\twhite-space: nowrap;\n\t\t\t}\n\t\t\t.collab-actions {\n\t\t\t\tdisplay: flex;\n\t\t\t\tgap: 1rem;\n\t\t\t\talign-items: center;\n\t\t\t\tmargin-top: 1rem;\n\t\t\t}\n\t\t\t.collab-save {\n\t\t\t\tpadding: 0.5rem 1rem;\n\t\t\t\tbackground: #3498db;\n\t\t\t\tcolor: white;\n\t\t\t\tborder: none;\n\t\t\t\tborder-radius: 6px;\n\t\t\t\tfont-weight: 600;\n\t\t\t\tcursor: pointer;\n\t\t\t}\n\t\t\t.collab-save:disabled {\n\t\t\t\topacity: 0.6;\n\t\t\t\tcursor: default;\n\t\t\t}\n\t\t\t.btn-publish {\n\t\t\t\tpadding: 0.5rem 1rem;\n\t\t\t\tbackground: #27ae60;\n\t\t\t\tcolor: white;\n\t\t\t\tborder: none;\n\t\t\t\tborder-radius: 6px;\n\t\t\t\tfont-weight: 600;\n\t\t\t\tcursor: pointer;\n\t\t\t}\n\t\t</style> <script>\n\t\t\t(function() {\n\t\t\t\tconst editor = document.getElementById('collab-editor');\n\t\t\t\tconst text = editor.dataset;\n\t\t\t\tconst status = document.getElementById('collab-status');\n\t\t\t\tconst peerList = document.getElementById('collab-peers');\n\t\t\t\tconst saveButton = document.getElementById('collab-save');\n\t\t\t\tconst fields = {\n\t\t\t\t\ttitle: document.getElementById('collab-title'),\n\t\t\t\t\tcontent: document.getElementById('collab-content'),\n\t\t\t\t};\n\t\t\t\tconst labels = {title: text.labelTitle, content: text.labelContent};\n\t\t\t\tconst pending = {};\n\t\t\t\tlet self = '';\n\t\t\t\tlet peers = [];\n\n\t\t\t\tconst scheme = location.protocol === 'https:' ? 'wss:' : 'ws:';\n\t\t\t\tconst socket = new WebSocket(scheme + '//' + location.host + text.socket);\n\n\t\t\t\tfunction send(msg) {\n\t\t\t\t\tif (socket.readyState === WebSocket.OPEN) {\n\t\t\t\t\t\tsocket.send(JSON.stringify(msg));\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// replaceValue applies a remote change while keeping the local\n\t\t\t\t// selection on the same text: positions after the changed span\n\t\t\t\t// shift by however much it grew or shrank\n\t\t\t\tfunction replaceValue(el, value) {\n\t\t\t\t\tconst old = el.value;\n\t\t\t\t\tif (old === value) {\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\t\t\t\t\tlet prefix = 0;\n\t\t\t\t\twhile (prefix < old.length && prefix < value.length && old[prefix] === value[prefix]) {\n\t\t\t\t\t\tprefix++;\n\t\t\t\t\t}\n\t\t\t\t\tlet suffix = 0;\n\t\t\t\t\twhile (suffix < old.length - prefix && suffix < value.length - prefix &&\n\t\t\t\t\t\told[old.length - 1 - suffix] === value[value.length - 1 - suffix]) {\n\t\t\t\t\t\tsuffix++;\n\t\t\t\t\t}\n\t\t\t\t\tconst shift = function(pos) {\n\t\t\t\t\t\tif (pos <= prefix) {\n\t\t\t\t\t\t\treturn pos;\n\t\t\t\t\t\t}\n\t\t\t\t\t\tif (pos >= old.length - suffix) {\n\t\t\t\t\t\t\treturn pos + value.length - old.length;\n\t\t\t\t\t\t}\n\t\t\t\t\t\treturn value.length - suffix;\n\t\t\t\t\t};\n\n\t\t\t\t\tconst focused = document.activeElement === el;\n\t\t\t\t\tconst start = el.selectionStart, end = el.selectionEnd;\n\t\t\t\t\tel.value = value;\n\t\t\t\t\tif (focused) {\n\t\t\t\t\t\tel.setSelectionRange(shift(start), shift(end));\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// position describes a cursor offset as line:column\n\t\t\t\tfunction position(value, offset) {\n\t\t\t\t\tconst before = value.slice(0, offset);\n\t\t\t\t\tconst line = before.split('\\n').length;\n\t\t\t\t\treturn line + ':' + (offset - before.lastIndexOf('\\n'));\n\t\t\t\t}\n\n\t\t\t\tfunction renderPeers() {\n\t\t\t\t\tpeerList.replaceChildren();\n\t\t\t\t\tfor (const field of Object.values(fields)) {\n\t\t\t\t\t\tfield.style.boxShadow = '';\n\t\t\t\t\t}\n\t\t\t\t\tfor (const peer of peers) {\n\t\t\t\t\t\tconst item = document.createElement('li');\n\t\t\t\t\t\tconst dot = document.createElement('span');\n\t\t\t\t\t\tdot.className = 'collab-dot';\n\t\t\t\t\t\tdot.style.background = peer.color;\n\t\t\t\t\t\titem.append(dot, peer.id === self ? peer.name + ' (' + text.you + ')' : peer.name);\n\n\t\t\t\t\t\tconst field = fields[peer.field];\n\t\t\t\t\t\tif (field) {\n\t\t\t\t\t\t\tconst where = document.createElement('span');\n\t\t\t\t\t\t\twhere.className = 'collab-where';\n\t\t\t\t\t\t\twhere.textContent = labels[peer.field] + ' ' + position(field.value, peer.start);\n\t\t\t\t\t\t\titem.append(where);\n\t\t\t\t\t\t\tif (peer.id !== self) {\n\t\t\t\t\t\t\t\tfield.style.boxShadow = '0 0 0 3px ' + peer.color;\n\t\t\t\t\t\t\t}\n\t\t\t\t\t\t}\n\t\t\t\t\t\tpeerList.append(item);\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\tfunction sendCursor(el) {\n\t\t\t\t\tsend({type: 'cursor', field: el.dataset.field, start: el.selectionStart, end: el.selectionEnd});\n\t\t\t\t}\n\n\t\t\t\t// flush sends a field's change right away instead of after the\n\t\t\t\t// typing pause\n\t\t\t\tfunction flush(name) {\n\t\t\t\t\tif (pending[name]) {\n\t\t\t\t\t\tclearTimeout(pending[name]);\n\t\t\t\t\t\tdelete pending[name];\n\t\t\t\t\t\tsend({type: 'edit', field: name, value: fields[name].value});\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\tfor (const [name, el] of Object.entries(fields)) {\n\t\t\t\t\tel.addEventListener('input', function() {\n\t\t\t\t\t\tstatus.textContent = text.unsaved;\n\t\t\t\t\t\tclearTimeout(pending[name]);\n\t\t\t\t\t\tpending[name] = setTimeout(function() { flush(name); }, 150);\n\t\t\t\t\t\tsendCursor(el);\n\t\t\t\t\t});\n\t\t\t\t\tfor (const event of ['focus', 'click', 'keyup', 'select']) {\n\t\t\t\t\t\tel.addEventListener(event, function() { sendCursor(el); });\n\t\t\t\t\t}\n\t\t\t\t\tel.addEventListener('blur', function() {\n\t\t\t\t\t\tflush(name);\n\t\t\t\t\t\tsend({type: 'cursor'});\n\t\t\t\t\t});\n\t\t\t\t}\n\n\t\t\t\tsaveButton.addEventListener('click', function() {\n\t\t\t\t\tflush('title');\n\t\t\t\t\tflush('content');\n\t\t\t\t\tstatus.textContent = text.saving;\n\t\t\t\t\tsend({type: 'save'});\n\t\t\t\t});\n\n\t\t\t\tsocket.addEventListener('message', function(event) {\n\t\t\t\t\tconst msg = JSON.parse(event.data);\n\t\t\t\t\tswitch (msg.type) {\n\t\t\t\t\tcase 'init':\n\t\t\t\t\t\tself = msg.peer;\n\t\t\t\t\t\treplaceValue(fields.title, msg.title || '');\n\t\t\t\t\t\treplaceValue(fields.content, msg.content || '');\n\t\t\t\t\t\tfor (const field of Object.values(fields)) {\n\t\t\t\t\t\t\tfield.disabled = false;\n\t\t\t\t\t\t}\n\t\t\t\t\t\tsaveButton.disabled = false;\n\t\t\t\t\t\tstatus.textContent = msg.version ? text.unsaved : text.saved;\n\t\t\t\t\t\tbreak;\n\t\t\t\t\tcase 'edit':\n\t\t\t\t\t\t// A change of ours still waiting to be sent will win anyway\n\t\t\t\t\t\tif (msg.peer !== self && !pending[msg.field]) {\n\t\t\t\t\t\t\treplaceValue(fields[msg.field], msg.value || '');\n\t\t\t\t\t\t\trenderPeers();\n\t\t\t\t\t\t}\n\t\t\t\t\t\tstatus.textContent = text.unsaved;\n\t\t\t\t\t\tbreak;\n\t\t\t\t\tcase 'presence':\n\t\t\t\t\t\tpeers = msg.peers || [];\n\t\t\t\t\t\trenderPeers();\n\t\t\t\t\t\tbreak;\n\t\t\t\t\tcase 'saved':\n\t\t\t\t\t\tstatus.textContent = text.saved;\n\t\t\t\t\t\tbreak;\n\t\t\t\t\tcase 'error':\n\t\t\t\t\t\tstatus.textContent = msg.error;\n\t\t\t\t\t\tbreak;\n\t\t\t\t\t}\n\t\t\t\t});\n\n\t\t\t\tsocket.addEventListener('close', function() {\n\t\t\t\t\tstatus.textContent = text.offline;\n\t\t\t\t\tfor (const field of Object.values(fields)) {\n\t\t\t\t\t\tfield.disabled = true;\n\t\t\t\t\t}\n\t\t\t\t\tsaveButton.disabled = true;\n\t\t\t\t});\n\t\t\t})();\n\t\t</script>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			return nil
+		})
+		templ_7745c5c3_Err = Layout(T(ctx, "title.page", T(ctx, "collab.title", post.Title))).Render(templ.WithChildren(ctx, templ_7745c5c3_Var2), templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+var _ = templruntime.GeneratedTemplate
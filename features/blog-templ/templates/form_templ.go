@@ -8,7 +8,9 @@ package templates
 import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
-func NewPostForm() templ.Component {
+import "github.com/homveloper/doodle/features/blog-templ/models"
+
+func NewPostForm(categories []models.Category) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -41,13 +43,325 @@ func NewPostForm() templ.Component {
 				}()
 			}
 			ctx = templ.InitializeContext(ctx)
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"form-container\"><div class=\"form-header\"><h2>Write New Post</h2><a href=\"/\" class=\"btn-secondary\">← Back to Home</a></div><form hx-post=\"/posts\" hx-target=\"#post-list\" hx-swap=\"afterbegin\" class=\"post-form\"><div class=\"form-group\"><label for=\"title\">Title</label> <input type=\"text\" id=\"title\" name=\"title\" class=\"form-input\" placeholder=\"Enter post title\" required></div><div class=\"form-group\"><label for=\"content\">Content</label> <textarea id=\"content\" name=\"content\" class=\"form-textarea\" rows=\"10\" placeholder=\"Write your post content here...\" required></textarea></div><div class=\"form-group\"><label for=\"tags-input\">Tags</label><div class=\"tags-container\"><div id=\"tags-display\" class=\"tags-display\"></div><input type=\"text\" id=\"tags-input\" class=\"form-input\" placeholder=\"Add tags (press Enter or comma)\"> <input type=\"hidden\" id=\"tags\" name=\"tags\" value=\"\"></div><small class=\"form-hint\">Press Enter or use comma to add tags</small></div><div class=\"form-actions\"><button type=\"submit\" class=\"btn-primary\">Publish Post</button> <button type=\"reset\" class=\"btn-secondary\" onclick=\"clearTags()\">Clear Form</button></div></form></div><style>\n\t\t\t.form-container {\n\t\t\t\tbackground: white;\n\t\t\t\tpadding: 2rem;\n\t\t\t\tborder-radius: 8px;\n\t\t\t\tbox-shadow: 0 2px 4px rgba(0,0,0,0.1);\n\t\t\t}\n\t\t\t.form-header {\n\t\t\t\tdisplay: flex;\n\t\t\t\tjustify-content: space-between;\n\t\t\t\talign-items: center;\n\t\t\t\tmargin-bottom: 2rem;\n\t\t\t\tpadding-bottom: 1rem;\n\t\t\t\tborder-bottom: 2px solid #e0e0e0;\n\t\t\t}\n\t\t\t.form-header h2 {\n\t\t\t\tfont-size: 1.8rem;\n\t\t\t\tcolor: #2c3e50;\n\t\t\t}\n\t\t\t.form-group {\n\t\t\t\tmargin-bottom: 1.5rem;\n\t\t\t}\n\t\t\t.form-group label {\n\t\t\t\tdisplay: block;\n\t\t\t\tmargin-bottom: 0.5rem;\n\t\t\t\tfont-weight: 600;\n\t\t\t\tcolor: #2c3e50;\n\t\t\t}\n\t\t\t.form-input {\n\t\t\t\twidth: 100%;\n\t\t\t\tpadding: 0.75rem 1rem;\n\t\t\t\tfont-size: 1rem;\n\t\t\t\tborder: 2px solid #e0e0e0;\n\t\t\t\tborder-radius: 6px;\n\t\t\t\ttransition: border-color 0.3s;\n\t\t\t}\n\t\t\t.form-input:focus {\n\t\t\t\toutline: none;\n\t\t\t\tborder-color: #3498db;\n\t\t\t}\n\t\t\t.form-textarea {\n\t\t\t\twidth: 100%;\n\t\t\t\tpadding: 0.75rem 1rem;\n\t\t\t\tfont-size: 1rem;\n\t\t\t\tborder: 2px solid #e0e0e0;\n\t\t\t\tborder-radius: 6px;\n\t\t\t\tfont-family: inherit;\n\t\t\t\tresize: vertical;\n\t\t\t\ttransition: border-color 0.3s;\n\t\t\t}\n\t\t\t.form-textarea:focus {\n\t\t\t\toutline: none;\n\t\t\t\tborder-color: #3498db;\n\t\t\t}\n\t\t\t.tags-container {\n\t\t\t\tposition: relative;\n\t\t\t}\n\t\t\t.tags-display {\n\t\t\t\tdisplay: flex;\n\t\t\t\tflex-wrap: wrap;\n\t\t\t\tgap: 0.5rem;\n\t\t\t\tmargin-bottom: 0.5rem;\n\t\t\t\tmin-height: 32px;\n\t\t\t}\n\t\t\t.tag-item {\n\t\t\t\tdisplay: inline-flex;\n\t\t\t\talign-items: center;\n\t\t\t\tgap: 0.5rem;\n\t\t\t\tbackground: #3498db;\n\t\t\t\tcolor: white;\n\t\t\t\tpadding: 0.25rem 0.75rem;\n\t\t\t\tborder-radius: 16px;\n\t\t\t\tfont-size: 0.9rem;\n\t\t\t}\n\t\t\t.tag-remove {\n\t\t\t\tcursor: pointer;\n\t\t\t\tfont-weight: bold;\n\t\t\t\tbackground: none;\n\t\t\t\tborder: none;\n\t\t\t\tcolor: white;\n\t\t\t\tfont-size: 1.2rem;\n\t\t\t\tpadding: 0;\n\t\t\t\tline-height: 1;\n\t\t\t}\n\t\t\t.tag-remove:hover {\n\t\t\t\tcolor: #e74c3c;\n\t\t\t}\n\t\t\t.form-hint {\n\t\t\t\tdisplay: block;\n\t\t\t\tcolor: #7f8c8d;\n\t\t\t\tfont-size: 0.875rem;\n\t\t\t\tmargin-top: 0.25rem;\n\t\t\t}\n\t\t\t.form-actions {\n\t\t\t\tdisplay: flex;\n\t\t\t\tgap: 1rem;\n\t\t\t\tmargin-top: 2rem;\n\t\t\t}\n\t\t\t.btn-primary, .btn-secondary {\n\t\t\t\tpadding: 0.75rem 1.5rem;\n\t\t\t\tfont-size: 1rem;\n\t\t\t\tfont-weight: 600;\n\t\t\t\tborder: none;\n\t\t\t\tborder-radius: 6px;\n\t\t\t\tcursor: pointer;\n\t\t\t\ttransition: all 0.3s;\n\t\t\t\ttext-decoration: none;\n\t\t\t\tdisplay: inline-block;\n\t\t\t}\n\t\t\t.btn-primary {\n\t\t\t\tbackground: #3498db;\n\t\t\t\tcolor: white;\n\t\t\t}\n\t\t\t.btn-primary:hover {\n\t\t\t\tbackground: #2980b9;\n\t\t\t}\n\t\t\t.btn-secondary {\n\t\t\t\tbackground: #ecf0f1;\n\t\t\t\tcolor: #2c3e50;\n\t\t\t}\n\t\t\t.btn-secondary:hover {\n\t\t\t\tbackground: #bdc3c7;\n\t\t\t}\n\t\t</style> <script>\n\t\t\t// Tag management\n\t\t\tlet tags = [];\n\n\t\t\tfunction updateTagsDisplay() {\n\t\t\t\tconst display = document.getElementById('tags-display');\n\t\t\t\tconst hiddenInput = document.getElementById('tags');\n\n\t\t\t\tdisplay.innerHTML = tags.map((tag, index) => `\n\t\t\t\t\t<span class=\"tag-item\">\n\t\t\t\t\t\t${tag}\n\t\t\t\t\t\t<button type=\"button\" class=\"tag-remove\" onclick=\"removeTag(${index})\">×</button>\n\t\t\t\t\t</span>\n\t\t\t\t`).join('');\n\n\t\t\t\thiddenInput.value = tags.join(',');\n\t\t\t}\n\n\t\t\tfunction addTag(tag) {\n\t\t\t\ttag = tag.trim();\n\t\t\t\tif (tag && !tags.includes(tag)) {\n\t\t\t\t\ttags.push(tag);\n\t\t\t\t\tupdateTagsDisplay();\n\t\t\t\t}\n\t\t\t}\n\n\t\t\tfunction removeTag(index) {\n\t\t\t\ttags.splice(index, 1);\n\t\t\t\tupdateTagsDisplay();\n\t\t\t}\n\n\t\t\tfunction clearTags() {\n\t\t\t\ttags = [];\n\t\t\t\tupdateTagsDisplay();\n\t\t\t}\n\n\t\t\t// Handle tag input\n\t\t\tdocument.addEventListener('DOMContentLoaded', function() {\n\t\t\t\tconst tagInput = document.getElementById('tags-input');\n\n\t\t\t\ttagInput.addEventListener('keydown', function(e) {\n\t\t\t\t\tif (e.key === 'Enter') {\n\t\t\t\t\t\te.preventDefault();\n\t\t\t\t\t\taddTag(this.value);\n\t\t\t\t\t\tthis.value = '';\n\t\t\t\t\t} else if (e.key === ',') {\n\t\t\t\t\t\te.preventDefault();\n\t\t\t\t\t\taddTag(this.value);\n\t\t\t\t\t\tthis.value = '';\n\t\t\t\t\t}\n\t\t\t\t});\n\n\t\t\t\ttagInput.addEventListener('blur', function() {\n\t\t\t\t\tif (this.value.trim()) {\n\t\t\t\t\t\taddTag(this.value);\n\t\t\t\t\t\tthis.value = '';\n\t\t\t\t\t}\n\t\t\t\t});\n\n\t\t\t\t// Handle form submission with HTMX\n\t\t\t\tdocument.querySelector('.post-form').addEventListener('htmx:afterRequest', function(event) {\n\t\t\t\t\tif (event.detail.successful) {\n\t\t\t\t\t\t// Redirect to home page after successful submission\n\t\t\t\t\t\twindow.location.href = '/';\n\t\t\t\t\t}\n\t\t\t\t});\n\t\t\t});\n\t\t</script>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"form-container\"><div class=\"form-header\"><h2>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var3 string
+			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.heading"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 9, Col: 32}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "</h2><a href=\"/\" class=\"btn-secondary\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var4 string
+			templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "post.back"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 10, Col: 59}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "</a></div><form hx-post=\"/posts\" hx-target=\"#post-list\" hx-swap=\"afterbegin\" class=\"post-form\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = CSRFField().Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "<div class=\"form-group\"><label for=\"title\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var5 string
+			templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.label.title"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 20, Col: 52}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "</label> <input type=\"text\" id=\"title\" name=\"title\" class=\"form-input\" placeholder=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var6 string
+			templ_7745c5c3_Var6, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.placeholder.title"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 26, Col: 52}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var6))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "\" required></div><div class=\"form-group\"><label for=\"content\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var7 string
+			templ_7745c5c3_Var7, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.label.content"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 31, Col: 56}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var7))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "</label><div class=\"editor-split\"><textarea id=\"content\" name=\"content\" class=\"form-textarea\" rows=\"10\" placeholder=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var8 string
+			templ_7745c5c3_Var8, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.placeholder.body"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 38, Col: 52}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var8))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "\" hx-post=\"/preview\" hx-trigger=\"keyup changed delay:400ms\" hx-target=\"#markdown-preview\" required></textarea><div id=\"markdown-preview\" class=\"markdown-preview\" aria-live=\"polite\"><p class=\"preview-empty\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var9 string
+			templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.preview_empty"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 45, Col: 62}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "</p></div></div><small class=\"form-hint\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var10 string
+			templ_7745c5c3_Var10, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.hint.markdown"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 48, Col: 60}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var10))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "</small></div><div class=\"form-group\"><label for=\"category\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var11 string
+			templ_7745c5c3_Var11, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.label.category"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 51, Col: 58}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var11))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "</label> <select id=\"category\" name=\"category\" class=\"form-input\"><option value=\"\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var12 string
+			templ_7745c5c3_Var12, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.no_category"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 53, Col: 51}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var12))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "</option> ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			for _, category := range categories {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "<option value=\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var13 string
+				templ_7745c5c3_Var13, templ_7745c5c3_Err = templ.JoinStringErrs(category.Slug)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 55, Col: 36}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var13))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "\">")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var14 string
+				templ_7745c5c3_Var14, templ_7745c5c3_Err = templ.JoinStringErrs(category.Name)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 55, Col: 54}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var14))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, "</option>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "</select></div><div class=\"form-group\"><label for=\"tags-input\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var15 string
+			templ_7745c5c3_Var15, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.label.tags"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 60, Col: 56}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var15))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "</label><div class=\"tags-container\"><div id=\"tags-display\" class=\"tags-display\"></div><input type=\"text\" id=\"tags-input\" class=\"form-input\" placeholder=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var16 string
+			templ_7745c5c3_Var16, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.placeholder.tags"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 67, Col: 52}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var16))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, "\"> <input type=\"hidden\" id=\"tags\" name=\"tags\" value=\"\"></div><small class=\"form-hint\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var17 string
+			templ_7745c5c3_Var17, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.hint.tags"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 71, Col: 56}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var17))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "</small></div><div class=\"form-group\"><label for=\"cover_image_url\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var18 string
+			templ_7745c5c3_Var18, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.label.cover"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 74, Col: 62}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var18))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, "</label> <input type=\"text\" id=\"cover_image_url\" name=\"cover_image_url\" class=\"form-input\" placeholder=\"https://example.com/cover.jpg\"> <small class=\"form-hint\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var19 string
+			templ_7745c5c3_Var19, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.hint.cover"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 82, Col: 57}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var19))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 21, "</small></div><div class=\"form-group\"><label for=\"image\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var20 string
+			templ_7745c5c3_Var20, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.label.images"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 85, Col: 53}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var20))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 22, "</label>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = ImagePicker().Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 23, "<small class=\"form-hint\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var21 string
+			templ_7745c5c3_Var21, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.hint.images"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 87, Col: 58}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var21))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 24, "</small></div><div class=\"form-actions\"><button type=\"submit\" name=\"action\" value=\"publish\" class=\"btn-primary\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var22 string
+			templ_7745c5c3_Var22, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.publish"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 90, Col: 101}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var22))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 25, "</button> <button type=\"submit\" name=\"action\" value=\"draft\" class=\"btn-secondary\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var23 string
+			templ_7745c5c3_Var23, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.save_draft"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 91, Col: 104}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var23))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 26, "</button> <button type=\"reset\" class=\"btn-secondary\" onclick=\"clearTags(); clearImages(); clearPreview()\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var24 string
+			templ_7745c5c3_Var24, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.clear"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 92, Col: 123}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var24))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 27, "</button></div></form></div><style>\n\t\t\t.form-container {\n\t\t\t\tbackground: white;\n\t\t\t\tpadding: 2rem;\n\t\t\t\tborder-radius: 8px;\n\t\t\t\tbox-shadow: 0 2px 4px rgba(0,0,0,0.1);\n\t\t\t}\n\t\t\t.form-header {\n\t\t\t\tdisplay: flex;\n\t\t\t\tjustify-content: space-between;\n\t\t\t\talign-items: center;\n\t\t\t\tmargin-bottom: 2rem;\n\t\t\t\tpadding-bottom: 1rem;\n\t\t\t\tborder-bottom: 2px solid #e0e0e0;\n\t\t\t}\n\t\t\t.form-header h2 {\n\t\t\t\tfont-size: 1.8rem;\n\t\t\t\tcolor: #2c3e50;\n\t\t\t}\n\t\t\t.form-group {\n\t\t\t\tmargin-bottom: 1.5rem;\n\t\t\t}\n\t\t\t.form-group label {\n\t\t\t\tdisplay: block;\n\t\t\t\tmargin-bottom: 0.5rem;\n\t\t\t\tfont-weight: 600;\n\t\t\t\tcolor: #2c3e50;\n\t\t\t}\n\t\t\t.form-input {\n\t\t\t\twidth: 100%;\n\t\t\t\tpadding: 0.75rem 1rem;\n\t\t\t\tfont-size: 1rem;\n\t\t\t\tborder: 2px solid #e0e0e0;\n\t\t\t\tborder-radius: 6px;\n\t\t\t\ttransition: border-color 0.3s;\n\t\t\t}\n\t\t\t.form-input:focus {\n\t\t\t\toutline: none;\n\t\t\t\tborder-color: #3498db;\n\t\t\t}\n\t\t\t.form-textarea {\n\t\t\t\twidth: 100%;\n\t\t\t\tpadding: 0.75rem 1rem;\n\t\t\t\tfont-size: 1rem;\n\t\t\t\tborder: 2px solid #e0e0e0;\n\t\t\t\tborder-radius: 6px;\n\t\t\t\tfont-family: inherit;\n\t\t\t\tresize: vertical;\n\t\t\t\ttransition: border-color 0.3s;\n\t\t\t}\n\t\t\t.form-textarea:focus {\n\t\t\t\toutline: none;\n\t\t\t\tborder-color: #3498db;\n\t\t\t}\n\t\t\t.editor-split {\n\t\t\t\tdisplay: grid;\n\t\t\t\tgrid-template-columns: 1fr 1fr;\n\t\t\t\tgap: 1rem;\n\t\t\t}\n\t\t\t@media (max-width: 720px) {\n\t\t\t\t.editor-split {\n\t\t\t\t\tgrid-template-columns: 1fr;\n\t\t\t\t}\n\t\t\t}\n\t\t\t.markdown-preview {\n\t\t\t\tpadding: 0.75rem 1rem;\n\t\t\t\tborder: 2px dashed #e0e0e0;\n\t\t\t\tborder-radius: 6px;\n\t\t\t\tline-height: 1.6;\n\t\t\t\toverflow-wrap: anywhere;\n\t\t\t\toverflow-y: auto;\n\t\t\t\tmax-height: 24rem;\n\t\t\t}\n\t\t\t.markdown-preview > * + * {\n\t\t\t\tmargin-top: 0.75rem;\n\t\t\t}\n\t\t\t.markdown-preview pre {\n\t\t\t\tbackground: #f4f6f7;\n\t\t\t\tpadding: 0.75rem;\n\t\t\t\tborder-radius: 4px;\n\t\t\t\toverflow-x: auto;\n\t\t\t}\n\t\t\t.markdown-preview blockquote {\n\t\t\t\tborder-left: 3px solid #bdc3c7;\n\t\t\t\tpadding-left: 0.75rem;\n\t\t\t\tcolor: #7f8c8d;\n\t\t\t}\n\t\t\t.markdown-preview ul, .markdown-preview ol {\n\t\t\t\tpadding-left: 1.5rem;\n\t\t\t}\n\t\t\t.markdown-preview img {\n\t\t\t\tmax-width: 100%;\n\t\t\t}\n\t\t\t.preview-empty {\n\t\t\t\tcolor: #95a5a6;\n\t\t\t}\n\t\t\t.tags-container {\n\t\t\t\tposition: relative;\n\t\t\t}\n\t\t\t.tags-display {\n\t\t\t\tdisplay: flex;\n\t\t\t\tflex-wrap: wrap;\n\t\t\t\tgap: 0.5rem;\n\t\t\t\tmargin-bottom: 0.5rem;\n\t\t\t\tmin-height: 32px;\n\t\t\t}\n\t\t\t.tag-item {\n\t\t\t\tdisplay: inline-flex;\n\t\t\t\talign-items: center;\n\t\t\t\tgap: 0.5rem;\n\t\t\t\tbackground: #3498db;\n\t\t\t\tcolor: white;\n\t\t\t\tpadding: 0.25rem 0.75rem;\n\t\t\t\tborder-radius: 16px;\n\t\t\t\tfont-size: 0.9rem;\n\t\t\t}\n\t\t\t.tag-remove {\n\t\t\t\tcursor: pointer;\n\t\t\t\tfont-weight: bold;\n\t\t\t\tbackground: none;\n\t\t\t\tborder: none;\n\t\t\t\tcolor: white;\n\t\t\t\tfont-size: 1.2rem;\n\t\t\t\tpadding: 0;\n\t\t\t\tline-height: 1;\n\t\t\t}\n\t\t\t.tag-remove:hover {\n\t\t\t\tcolor: #e74c3c;\n\t\t\t}\n\t\t\t.form-hint {\n\t\t\t\tdisplay: block;\n\t\t\t\tcolor: #7f8c8d;\n\t\t\t\tfont-size: 0.875rem;\n\t\t\t\tmargin-top: 0.25rem;\n\t\t\t}\n\t\t\t.form-actions {\n\t\t\t\tdisplay: flex;\n\t\t\t\tgap: 1rem;\n\t\t\t\tmargin-top: 2rem;\n\t\t\t}\n\t\t\t.btn-primary, .btn-secondary {\n\t\t\t\tpadding: 0.75rem 1.5rem;\n\t\t\t\tfont-size: 1rem;\n\t\t\t\tfont-weight: 600;\n\t\t\t\tborder: none;\n\t\t\t\tborder-radius: 6px;\n\t\t\t\tcursor: pointer;\n\t\t\t\ttransition: all 0.3s;\n\t\t\t\ttext-decoration: none;\n\t\t\t\tdisplay: inline-block;\n\t\t\t}\n\t\t\t.btn-primary {\n\t\t\t\tbackground: #3498db;\n\t\t\t\tcolor: white;\n\t\t\t}\n\t\t\t.btn-primary:hover {\n\t\t\t\tbackground: #2980b9;\n\t\t\t}\n\t\t\t.btn-secondary {\n\t\t\t\tbackground: #ecf0f1;\n\t\t\t\tcolor: #2c3e50;\n\t\t\t}\n\t\t\t.btn-secondary:hover {\n\t\t\t\tbackground: #bdc3c7;\n\t\t\t}\n\t\t</style> <script>\n\t\t\t// Tag management\n\t\t\tlet tags = [];\n\n\t\t\tfunction updateTagsDisplay() {\n\t\t\t\tconst display = document.getElementById('tags-display');\n\t\t\t\tconst hiddenInput = document.getElementById('tags');\n\n\t\t\t\tdisplay.innerHTML = tags.map((tag, index) => `\n\t\t\t\t\t<span class=\"tag-item\">\n\t\t\t\t\t\t${tag}\n\t\t\t\t\t\t<button type=\"button\" class=\"tag-remove\" onclick=\"removeTag(${index})\">×</button>\n\t\t\t\t\t</span>\n\t\t\t\t`).join('');\n\n\t\t\t\thiddenInput.value = tags.join(',');\n\t\t\t}\n\n\t\t\tfunction addTag(tag) {\n\t\t\t\ttag = tag.trim();\n\t\t\t\tif (tag && !tags.includes(tag)) {\n\t\t\t\t\ttags.push(tag);\n\t\t\t\t\tupdateTagsDisplay();\n\t\t\t\t}\n\t\t\t}\n\n\t\t\tfunction removeTag(index) {\n\t\t\t\ttags.splice(index, 1);\n\t\t\t\tupdateTagsDisplay();\n\t\t\t}\n\n\t\t\tfunction clearTags() {\n\t\t\t\ttags = [];\n\t\t\t\tupdateTagsDisplay();\n\t\t\t}\n\n\t\t\tfunction clearImages() {\n\t\t\t\tdocument.getElementById('attached-images').innerHTML = '';\n\t\t\t}\n\n\t\t\tfunction clearPreview() {\n\t\t\t\tdocument.getElementById('markdown-preview').innerHTML = '';\n\t\t\t}\n\n\t\t\t// Handle tag input\n\t\t\tdocument.addEventListener('DOMContentLoaded', function() {\n\t\t\t\tconst tagInput = document.getElementById('tags-input');\n\n\t\t\t\ttagInput.addEventListener('keydown', function(e) {\n\t\t\t\t\tif (e.key === 'Enter') {\n\t\t\t\t\t\te.preventDefault();\n\t\t\t\t\t\taddTag(this.value);\n\t\t\t\t\t\tthis.value = '';\n\t\t\t\t\t} else if (e.key === ',') {\n\t\t\t\t\t\te.preventDefault();\n\t\t\t\t\t\taddTag(this.value);\n\t\t\t\t\t\tthis.value = '';\n\t\t\t\t\t}\n\t\t\t\t});\n\n\t\t\t\ttagInput.addEventListener('blur', function() {\n\t\t\t\t\tif (this.value.trim()) {\n\t\t\t\t\t\taddTag(this.value);\n\t\t\t\t\t\tthis.value = '';\n\t\t\t\t\t}\n\t\t\t\t});\n\n\t\t\t\t// After a successful submission the server responds with\n\t\t\t\t// HX-Redirect: the home page for published posts, /drafts for drafts\n\t\t\t});\n\t\t</script>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 			return nil
 		})
-		templ_7745c5c3_Err = Layout("New Post - Blog Doodle").Render(templ.WithChildren(ctx, templ_7745c5c3_Var2), templ_7745c5c3_Buffer)
+		templ_7745c5c3_Err = Layout(T(ctx, "title.page", T(ctx, "form.title"))).Render(templ.WithChildren(ctx, templ_7745c5c3_Var2), templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -55,4 +369,55 @@ func NewPostForm() templ.Component {
 	})
 }
 
+// MarkdownPreview shows post content rendered by models.RenderMarkdown,
+// which escapes the source and only emits a safe subset of HTML
+func MarkdownPreview(rendered string) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var25 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var25 == nil {
+			templ_7745c5c3_Var25 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		if rendered == "" {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 28, "<p class=\"preview-empty\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var26 string
+			templ_7745c5c3_Var26, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "form.preview_empty"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/form.templ`, Line: 340, Col: 57}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var26))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 29, "</p>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		} else {
+			templ_7745c5c3_Err = templ.Raw(rendered).Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		return nil
+	})
+}
+
 var _ = templruntime.GeneratedTemplate
@@ -0,0 +1,100 @@
+package templates
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+// Locale is a UI language
+type Locale string
+
+const (
+	LocaleEnglish Locale = "en"
+	LocaleKorean  Locale = "ko"
+)
+
+// DefaultLocale is used when a reader's language isn't supported
+const DefaultLocale = LocaleEnglish
+
+// Locales lists the supported languages in switcher order
+var Locales = []Locale{LocaleEnglish, LocaleKorean}
+
+// ParseLocale matches a language tag such as "ko" or "ko-KR" to a
+// supported locale
+func ParseLocale(tag string) (Locale, bool) {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	for _, locale := range Locales {
+		if primary == string(locale) {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+type localeKey struct{}
+
+// ContextWithLocale returns a context carrying the reader's language
+func ContextWithLocale(ctx context.Context, locale Locale) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// LocaleFromContext returns the locale set by ContextWithLocale, or the default
+func LocaleFromContext(ctx context.Context) Locale {
+	if locale, ok := ctx.Value(localeKey{}).(Locale); ok {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// T looks up a UI string in the reader's language, falling back to English
+// and then to the key itself. Args are applied with fmt.Sprintf.
+func T(ctx context.Context, key string, args ...any) string {
+	msg, ok := messages[LocaleFromContext(ctx)][key]
+	if !ok {
+		msg, ok = messages[DefaultLocale][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// plural picks the ".one" or ".other" form of key for n
+func plural(ctx context.Context, key string, n int) string {
+	if n == 1 {
+		return T(ctx, key+".one", n)
+	}
+	return T(ctx, key+".other", n)
+}
+
+// formatDate formats a date in the reader's language
+func formatDate(ctx context.Context, t time.Time) string {
+	return t.Format(T(ctx, "format.date"))
+}
+
+// formatDateTime formats a date and time in the reader's language
+func formatDateTime(ctx context.Context, t time.Time) string {
+	return t.Format(T(ctx, "format.datetime"))
+}
+
+// monthName names a month in the reader's language
+func monthName(ctx context.Context, month time.Month) string {
+	return T(ctx, "format.month", month.String(), int(month))
+}
+
+// monthLabel names an archive month and year in the reader's language
+func monthLabel(ctx context.Context, month models.ArchiveMonth) string {
+	return T(ctx, "format.month_year", month.Month.String(), int(month.Month), month.Year)
+}
+
+// localeName is the name of a language in that language, for the switcher
+func localeName(locale Locale) string {
+	return messages[locale]["locale.name"]
+}
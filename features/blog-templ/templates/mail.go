@@ -0,0 +1,36 @@
+package templates
+
+import (
+	"context"
+	"strings"
+
+	"github.com/homveloper/doodle/features/blog-templ/models"
+)
+
+// ConfirmationEmail asks a new subscriber to confirm their address
+func ConfirmationEmail(ctx context.Context, to, confirmURL string) models.Message {
+	return models.Message{
+		To:      to,
+		Subject: T(ctx, "email.confirm.subject"),
+		Body:    T(ctx, "email.confirm.body", confirmURL),
+	}
+}
+
+// NewPostEmail tells a subscriber about a newly published post
+func NewPostEmail(ctx context.Context, to string, post models.Post, summary, postURL, unsubscribeURL string) models.Message {
+	var body strings.Builder
+	body.WriteString(post.Title + "\n")
+	body.WriteString(T(ctx, "post.by", post.Author) + "\n\n")
+	if summary != "" {
+		body.WriteString(summary + "\n\n")
+	}
+	body.WriteString(T(ctx, "email.post.read", postURL) + "\n\n")
+	body.WriteString("--\n")
+	body.WriteString(T(ctx, "email.post.unsubscribe", unsubscribeURL) + "\n")
+
+	return models.Message{
+		To:      to,
+		Subject: T(ctx, "email.post.subject", post.Title),
+		Body:    body.String(),
+	}
+}
@@ -0,0 +1,445 @@
+package templates
+
+// messages is the UI string catalog. Every English key must also be
+// translated; lookups fall back to English when one is missing.
+var messages = map[Locale]map[string]string{
+	LocaleEnglish: {
+		"locale.name":     "English",
+		"format.date":     "Jan 2, 2006",
+		"format.datetime": "Jan 2, 2006 15:04",
+		// Args: English month name, month number, year
+		"format.month":      "%[1]s",
+		"format.month_year": "%[1]s %[3]d",
+
+		"site.title":    "Blog Doodle",
+		"site.subtitle": "Real-time search with Templ & HTMX",
+		"title.page":    "%s - Blog Doodle",
+		"title.home":    "Blog Doodle - Home",
+		"title.tag":     "#%s - Blog Doodle",
+
+		"nav.signed_in_as": "Signed in as",
+		"nav.profile":      "Profile",
+		"nav.drafts":       "My drafts",
+		"nav.stats":        "Stats",
+		"nav.categories":   "Categories",
+		"nav.trash":        "Trash",
+		"nav.moderation":   "Moderation",
+		"nav.reading_list": "Reading list",
+		"nav.logout":       "Log out",
+		"nav.login":        "Log in",
+		"nav.language":     "Language",
+		"theme.to_light":   "Switch to light mode",
+		"theme.to_dark":    "Switch to dark mode",
+
+		"index.archive":      "📚 Archive",
+		"index.write":        "✏️ Write New Post",
+		"search.placeholder": "Search posts by title, content, author, or tags...",
+		"search.searching":   "Searching...",
+		"suggest.aria":       "Search suggestions",
+		"sort.aria":          "Sort posts",
+		"sort.label":         "Sort:",
+		"sort.relevance":     "Best match",
+		"sort.newest":        "Newest",
+		"sort.oldest":        "Oldest",
+		"sort.title":         "Title",
+		"sort.author":        "Author",
+		"filters.toggle":     "Filters",
+		"filters.author":     "Author",
+		"filters.tag":        "Tag",
+		"filters.category":   "Category",
+		"filters.any":        "Any",
+		"filters.from":       "From",
+		"filters.to":         "To",
+		"posts.none":         "No posts found. Try a different search term.",
+		"posts.loading":      "Loading more posts...",
+		"post.by":            "By %s",
+		"post.back":          "← Back to Home",
+		"post.draft_banner":  "📝 This is a draft — only you can see it.",
+		"related.title":      "Related posts",
+
+		"comments.title":            "Comments",
+		"comments.loading":          "Loading comments...",
+		"comments.name_placeholder": "Your name",
+		"comments.placeholder":      "Write a comment...",
+		"comments.submit":           "Post Comment",
+		"comments.none":             "No comments yet. Be the first to comment!",
+		"comments.honeypot":         "Leave this field empty",
+		"comments.held":             "Thanks! Your comment will appear once an author has reviewed it.",
+
+		"moderation.title":   "Comment Moderation",
+		"moderation.none":    "No comments are waiting for review.",
+		"moderation.on_post": "on “%s”",
+		"moderation.approve": "Approve",
+		"moderation.delete":  "Delete",
+
+		"slug.label": "Post URL",
+		"slug.hint":  "Lowercase words joined by hyphens. Links to the old URL will redirect here.",
+		"slug.save":  "Change URL",
+
+		"form.title":             "New Post",
+		"form.heading":           "Write New Post",
+		"form.label.title":       "Title",
+		"form.placeholder.title": "Enter post title",
+		"form.label.content":     "Content",
+		"form.placeholder.body":  "Write your post content here...",
+		"form.preview_empty":     "A preview of your post appears here as you type.",
+		"form.hint.markdown":     "Markdown is supported: **bold**, *italic*, `code`, [links](https://…), lists, quotes and ``` code blocks",
+		"form.label.category":    "Category",
+		"form.no_category":       "No category",
+		"form.label.tags":        "Tags",
+		"form.placeholder.tags":  "Add tags (press Enter or comma)",
+		"form.hint.tags":         "Press Enter or use comma to add tags",
+		"form.label.cover":       "Cover Image URL",
+		"form.hint.cover":        "Optional. Shown on the post card and in link previews; click an uploaded image below to use it",
+		"form.label.images":      "Images",
+		"form.hint.images":       "JPEG, PNG or GIF, up to 5 MB each",
+		"form.publish":           "Publish Post",
+		"form.save_draft":        "Save as Draft",
+		"form.clear":             "Clear Form",
+
+		"login.title":    "Log In",
+		"login.username": "Username",
+		"login.password": "Password",
+		"login.submit":   "Log In",
+		"login.invalid":  "invalid username or password",
+
+		"drafts.title":     "My Drafts",
+		"drafts.none":      "You have no drafts. Use “Save as Draft” on the new post form to keep a post private until it's ready.",
+		"drafts.saved":     "Saved %s",
+		"drafts.publish":   "Publish",
+		"drafts.published": "✓ Published —",
+		"drafts.view":      "view post",
+		"drafts.edit":      "Edit together",
+
+		"collab.title":      "Editing “%s”",
+		"collab.heading":    "Edit Draft",
+		"collab.back":       "← My drafts",
+		"collab.share":      "Share this page's address with another author to edit together. You'll see each other's changes and cursors as you type.",
+		"collab.connecting": "Connecting…",
+		"collab.saved":      "All changes saved",
+		"collab.unsaved":    "Unsaved changes",
+		"collab.saving":     "Saving…",
+		"collab.offline":    "Disconnected. Reload the page to keep editing.",
+		"collab.you":        "you",
+		"collab.save":       "Save Draft",
+
+		"tags.heading": "Posts tagged “%s”",
+
+		"authors.posts":       "Posts by %s",
+		"authors.none":        "%s hasn't published anything yet.",
+		"authors.avatar":      "%s's avatar",
+		"profile.title":       "Your profile",
+		"profile.bio":         "Bio",
+		"profile.bio_hint":    "Shown on your author page. Up to %d characters.",
+		"profile.avatar":      "Avatar URL",
+		"profile.avatar_hint": "An http(s) image URL or an uploaded image path such as /uploads/…",
+		"profile.save":        "Save Profile",
+		"profile.view":        "View your author page",
+
+		"categories.title":          "Categories",
+		"categories.add":            "Add a category",
+		"categories.name":           "Name",
+		"categories.description":    "Description",
+		"categories.submit":         "Add Category",
+		"categories.delete":         "Delete",
+		"categories.delete_confirm": "Delete the “%s” category?",
+
+		"archive.title":         "Archive",
+		"archive.back":          "← Archive",
+		"archive.empty":         "Nothing published yet.",
+		"archive.month_heading": "Posts from %s",
+		"count.posts.one":       "%d post",
+		"count.posts.other":     "%d posts",
+
+		"popular.heading":   "🔥 Popular posts",
+		"popular.none":      "No views yet.",
+		"trending.heading":  "📈 Trending now",
+		"trending.loading":  "Loading...",
+		"trending.none":     "Nothing is trending yet.",
+		"count.views.one":   "%d view",
+		"count.views.other": "%d views",
+
+		"stats.title":     "Stats",
+		"stats.posts":     "Posts",
+		"stats.published": "Published",
+		"stats.drafts":    "Drafts",
+		"stats.views":     "Views",
+		"stats.post":      "Post",
+		"stats.author":    "Author",
+		"stats.status":    "Status",
+		"stats.draft":     "Draft",
+
+		"images.uploaded":  "Uploaded image",
+		"images.use_cover": "Use as cover image",
+		"reactions.aria":   "React with %s",
+
+		"bookmarks.title":  "Reading List",
+		"bookmarks.add":    "Save to reading list",
+		"bookmarks.remove": "Remove from reading list",
+		"bookmarks.empty":  "Your reading list is empty. Tap ☆ on a post to save it for later.",
+		"bookmarks.note":   "Saved in this browser until you close it.",
+
+		"revisions.link":          "🕘 History",
+		"revisions.title":         "History: %s",
+		"revisions.back":          "← Back to post",
+		"revisions.all":           "← All revisions",
+		"revisions.current":       "Current version",
+		"revisions.none":          "This post hasn't been edited yet.",
+		"revisions.number":        "Revision %d",
+		"revisions.saved":         "Saved %s",
+		"revisions.changes":       "Changes since revision %d",
+		"revisions.title_changed": "Title: %s → %s",
+		"revisions.tags_changed":  "Tags: %s → %s",
+		"revisions.no_changes":    "The content is the same as the current version.",
+		"revisions.restore":       "Restore this revision",
+		"revisions.restore_hint":  "The current version stays in the history.",
+
+		"trash.title":         "Trash",
+		"trash.none":          "The trash is empty. Deleted posts stay here for %d days.",
+		"trash.deleted":       "Deleted %s · gone for good on %s",
+		"trash.restore":       "Restore",
+		"trash.purge":         "Delete forever",
+		"trash.purge_confirm": "Delete this post forever? This can't be undone.",
+		"trash.move":          "🗑 Move to trash",
+		"trash.confirm":       "Move this post to the trash?",
+
+		"subscribe.heading":         "📬 Get new posts by email",
+		"subscribe.intro":           "We'll email you when a new post is published.",
+		"subscribe.placeholder":     "you@example.com",
+		"subscribe.submit":          "Subscribe",
+		"subscribe.check_inbox":     "Almost done! Check your inbox for a confirmation link.",
+		"subscribe.confirmed_title": "Subscribed",
+		"subscribe.confirmed":       "Thanks! New posts will be sent to %s.",
+		"subscribe.not_found_title": "Link expired",
+		"subscribe.not_found":       "This link is no longer valid. You may have already unsubscribed.",
+		"unsubscribe.title":         "Unsubscribe",
+		"unsubscribe.confirm":       "Stop sending new posts to %s?",
+		"unsubscribe.submit":        "Unsubscribe",
+		"unsubscribe.done":          "You're unsubscribed and won't get any more emails from us.",
+
+		"email.confirm.subject":  "Confirm your Blog Doodle subscription",
+		"email.confirm.body":     "Someone, hopefully you, asked to get new Blog Doodle posts at this address.\n\nConfirm your subscription here:\n%s\n\nIf it wasn't you, ignore this email and you won't hear from us again.\n",
+		"email.post.subject":     "New post: %s",
+		"email.post.read":        "Read the full post: %s",
+		"email.post.unsubscribe": "You're getting this because you subscribed to Blog Doodle. Unsubscribe: %s",
+	},
+	LocaleKorean: {
+		"locale.name":       "한국어",
+		"format.date":       "2006년 1월 2일",
+		"format.datetime":   "2006년 1월 2일 15:04",
+		"format.month":      "%[2]d월",
+		"format.month_year": "%[3]d년 %[2]d월",
+
+		"site.title":    "블로그 두들",
+		"site.subtitle": "Templ과 HTMX로 만든 실시간 검색",
+		"title.page":    "%s - 블로그 두들",
+		"title.home":    "블로그 두들 - 홈",
+		"title.tag":     "#%s - 블로그 두들",
+
+		"nav.signed_in_as": "로그인:",
+		"nav.profile":      "프로필",
+		"nav.drafts":       "내 임시글",
+		"nav.stats":        "통계",
+		"nav.categories":   "카테고리",
+		"nav.trash":        "휴지통",
+		"nav.moderation":   "댓글 검토",
+		"nav.reading_list": "읽기 목록",
+		"nav.logout":       "로그아웃",
+		"nav.login":        "로그인",
+		"nav.language":     "언어",
+		"theme.to_light":   "라이트 모드로 전환",
+		"theme.to_dark":    "다크 모드로 전환",
+
+		"index.archive":      "📚 아카이브",
+		"index.write":        "✏️ 새 글 쓰기",
+		"search.placeholder": "제목, 내용, 작성자, 태그로 글 검색...",
+		"search.searching":   "검색 중...",
+		"suggest.aria":       "검색 추천",
+		"sort.aria":          "글 정렬",
+		"sort.label":         "정렬:",
+		"sort.relevance":     "정확도순",
+		"sort.newest":        "최신순",
+		"sort.oldest":        "오래된순",
+		"sort.title":         "제목순",
+		"sort.author":        "작성자순",
+		"filters.toggle":     "필터",
+		"filters.author":     "작성자",
+		"filters.tag":        "태그",
+		"filters.category":   "카테고리",
+		"filters.any":        "전체",
+		"filters.from":       "시작일",
+		"filters.to":         "종료일",
+		"posts.none":         "글이 없습니다. 다른 검색어를 입력해 보세요.",
+		"posts.loading":      "글을 더 불러오는 중...",
+		"post.by":            "작성자 %s",
+		"post.back":          "← 홈으로",
+		"post.draft_banner":  "📝 임시글입니다 — 나만 볼 수 있어요.",
+		"related.title":      "관련 글",
+
+		"comments.title":            "댓글",
+		"comments.loading":          "댓글을 불러오는 중...",
+		"comments.name_placeholder": "이름",
+		"comments.placeholder":      "댓글을 입력하세요...",
+		"comments.submit":           "댓글 작성",
+		"comments.none":             "아직 댓글이 없습니다. 첫 댓글을 남겨 보세요!",
+		"comments.honeypot":         "이 칸은 비워 두세요",
+		"comments.held":             "감사합니다! 작성자가 검토한 뒤 댓글이 표시됩니다.",
+
+		"moderation.title":   "댓글 검토",
+		"moderation.none":    "검토를 기다리는 댓글이 없습니다.",
+		"moderation.on_post": "“%s”에 남김",
+		"moderation.approve": "승인",
+		"moderation.delete":  "삭제",
+
+		"slug.label": "글 주소",
+		"slug.hint":  "소문자 단어를 하이픈으로 이어 주세요. 이전 주소로 들어온 링크는 이곳으로 연결됩니다.",
+		"slug.save":  "주소 바꾸기",
+
+		"form.title":             "새 글",
+		"form.heading":           "새 글 쓰기",
+		"form.label.title":       "제목",
+		"form.placeholder.title": "제목을 입력하세요",
+		"form.label.content":     "내용",
+		"form.placeholder.body":  "내용을 입력하세요...",
+		"form.preview_empty":     "입력하는 동안 여기에 미리보기가 표시됩니다.",
+		"form.hint.markdown":     "마크다운을 지원합니다: **굵게**, *기울임*, `코드`, [링크](https://…), 목록, 인용, ``` 코드 블록",
+		"form.label.category":    "카테고리",
+		"form.no_category":       "카테고리 없음",
+		"form.label.tags":        "태그",
+		"form.placeholder.tags":  "태그 추가 (Enter 또는 쉼표)",
+		"form.hint.tags":         "Enter 키나 쉼표로 태그를 추가하세요",
+		"form.label.cover":       "커버 이미지 URL",
+		"form.hint.cover":        "선택 사항. 글 카드와 링크 미리보기에 표시됩니다. 아래 업로드한 이미지를 클릭하면 커버로 사용합니다",
+		"form.label.images":      "이미지",
+		"form.hint.images":       "JPEG, PNG, GIF, 각 5MB까지",
+		"form.publish":           "글 발행",
+		"form.save_draft":        "임시 저장",
+		"form.clear":             "초기화",
+
+		"login.title":    "로그인",
+		"login.username": "아이디",
+		"login.password": "비밀번호",
+		"login.submit":   "로그인",
+		"login.invalid":  "아이디 또는 비밀번호가 올바르지 않습니다",
+
+		"drafts.title":     "내 임시글",
+		"drafts.none":      "임시글이 없습니다. 새 글 작성 화면에서 “임시 저장”을 누르면 준비될 때까지 나만 볼 수 있게 보관됩니다.",
+		"drafts.saved":     "%s 저장",
+		"drafts.publish":   "발행",
+		"drafts.published": "✓ 발행됨 —",
+		"drafts.view":      "글 보기",
+		"drafts.edit":      "함께 편집",
+
+		"collab.title":      "“%s” 편집 중",
+		"collab.heading":    "임시글 편집",
+		"collab.back":       "← 내 임시글",
+		"collab.share":      "이 페이지 주소를 다른 작성자와 공유하면 함께 편집할 수 있습니다. 입력하는 동안 서로의 변경 내용과 커서가 보입니다.",
+		"collab.connecting": "연결 중…",
+		"collab.saved":      "모든 변경 사항이 저장됨",
+		"collab.unsaved":    "저장하지 않은 변경 사항",
+		"collab.saving":     "저장 중…",
+		"collab.offline":    "연결이 끊겼습니다. 계속 편집하려면 페이지를 새로고침하세요.",
+		"collab.you":        "나",
+		"collab.save":       "임시 저장",
+
+		"tags.heading": "“%s” 태그가 달린 글",
+
+		"authors.posts":       "%s의 글",
+		"authors.none":        "%s 님은 아직 발행한 글이 없습니다.",
+		"authors.avatar":      "%s의 프로필 사진",
+		"profile.title":       "내 프로필",
+		"profile.bio":         "소개",
+		"profile.bio_hint":    "작성자 페이지에 표시됩니다. 최대 %d자.",
+		"profile.avatar":      "프로필 사진 URL",
+		"profile.avatar_hint": "http(s) 이미지 URL 또는 /uploads/… 같은 업로드한 이미지 경로",
+		"profile.save":        "프로필 저장",
+		"profile.view":        "내 작성자 페이지 보기",
+
+		"categories.title":          "카테고리",
+		"categories.add":            "카테고리 추가",
+		"categories.name":           "이름",
+		"categories.description":    "설명",
+		"categories.submit":         "카테고리 추가",
+		"categories.delete":         "삭제",
+		"categories.delete_confirm": "“%s” 카테고리를 삭제할까요?",
+
+		"archive.title":         "아카이브",
+		"archive.back":          "← 아카이브",
+		"archive.empty":         "아직 발행된 글이 없습니다.",
+		"archive.month_heading": "%s의 글",
+		"count.posts.one":       "글 %d개",
+		"count.posts.other":     "글 %d개",
+
+		"popular.heading":   "🔥 인기 글",
+		"popular.none":      "아직 조회수가 없습니다.",
+		"trending.heading":  "📈 지금 뜨는 글",
+		"trending.loading":  "불러오는 중...",
+		"trending.none":     "아직 뜨는 글이 없습니다.",
+		"count.views.one":   "조회 %d회",
+		"count.views.other": "조회 %d회",
+
+		"stats.title":     "통계",
+		"stats.posts":     "글",
+		"stats.published": "발행됨",
+		"stats.drafts":    "임시글",
+		"stats.views":     "조회수",
+		"stats.post":      "글",
+		"stats.author":    "작성자",
+		"stats.status":    "상태",
+		"stats.draft":     "임시글",
+
+		"images.uploaded":  "업로드한 이미지",
+		"images.use_cover": "커버 이미지로 사용",
+		"reactions.aria":   "%s 반응 남기기",
+
+		"bookmarks.title":  "읽기 목록",
+		"bookmarks.add":    "읽기 목록에 저장",
+		"bookmarks.remove": "읽기 목록에서 삭제",
+		"bookmarks.empty":  "읽기 목록이 비어 있습니다. 글의 ☆를 눌러 나중에 읽을 글을 저장하세요.",
+		"bookmarks.note":   "브라우저를 닫을 때까지 이 브라우저에 저장됩니다.",
+
+		"revisions.link":          "🕘 수정 기록",
+		"revisions.title":         "수정 기록: %s",
+		"revisions.back":          "← 글로 돌아가기",
+		"revisions.all":           "← 전체 수정 기록",
+		"revisions.current":       "현재 버전",
+		"revisions.none":          "아직 수정된 적이 없는 글입니다.",
+		"revisions.number":        "%d번째 버전",
+		"revisions.saved":         "%s 저장",
+		"revisions.changes":       "%d번째 버전 이후 변경 사항",
+		"revisions.title_changed": "제목: %s → %s",
+		"revisions.tags_changed":  "태그: %s → %s",
+		"revisions.no_changes":    "내용이 현재 버전과 같습니다.",
+		"revisions.restore":       "이 버전으로 복원",
+		"revisions.restore_hint":  "현재 버전은 수정 기록에 남습니다.",
+
+		"trash.title":         "휴지통",
+		"trash.none":          "휴지통이 비어 있습니다. 삭제한 글은 %d일 동안 보관됩니다.",
+		"trash.deleted":       "%s 삭제 · %s에 영구 삭제",
+		"trash.restore":       "복원",
+		"trash.purge":         "영구 삭제",
+		"trash.purge_confirm": "이 글을 영구 삭제할까요? 되돌릴 수 없습니다.",
+		"trash.move":          "🗑 휴지통으로 이동",
+		"trash.confirm":       "이 글을 휴지통으로 옮길까요?",
+
+		"subscribe.heading":         "📬 새 글을 이메일로 받기",
+		"subscribe.intro":           "새 글이 발행되면 이메일로 알려 드립니다.",
+		"subscribe.placeholder":     "you@example.com",
+		"subscribe.submit":          "구독하기",
+		"subscribe.check_inbox":     "거의 다 됐습니다! 받은편지함에서 확인 링크를 눌러 주세요.",
+		"subscribe.confirmed_title": "구독 완료",
+		"subscribe.confirmed":       "감사합니다! 새 글을 %s(으)로 보내 드립니다.",
+		"subscribe.not_found_title": "만료된 링크",
+		"subscribe.not_found":       "더 이상 유효하지 않은 링크입니다. 이미 구독을 취소하셨을 수 있습니다.",
+		"unsubscribe.title":         "구독 취소",
+		"unsubscribe.confirm":       "%s(으)로 새 글을 그만 보낼까요?",
+		"unsubscribe.submit":        "구독 취소",
+		"unsubscribe.done":          "구독이 취소되었습니다. 더 이상 이메일을 보내지 않습니다.",
+
+		"email.confirm.subject":  "블로그 두들 구독을 확인해 주세요",
+		"email.confirm.body":     "이 주소로 블로그 두들의 새 글을 받겠다는 요청이 있었습니다.\n\n아래 링크에서 구독을 확인해 주세요:\n%s\n\n요청하지 않으셨다면 이 메일을 무시하세요. 더 이상 연락드리지 않습니다.\n",
+		"email.post.subject":     "새 글: %s",
+		"email.post.read":        "전체 글 읽기: %s",
+		"email.post.unsubscribe": "블로그 두들을 구독하셔서 이 메일을 받으셨습니다. 구독 취소: %s",
+	},
+}
@@ -0,0 +1,177 @@
+// Code generated by templ - DO NOT EDIT.
+
+// templ: version: v0.3.943
+package templates
+
+//lint:file-ignore SA4006 This context is only used if a nested component is present.
+
+import "github.com/a-h/templ"
+import templruntime "github.com/a-h/templ/runtime"
+
+import "context"
+
+// Theme is the colour scheme a reader picked
+type Theme string
+
+const (
+	ThemeLight Theme = "light"
+	ThemeDark  Theme = "dark"
+)
+
+// ParseTheme converts a cookie or form value to a Theme, defaulting to light
+func ParseTheme(value string) Theme {
+	if Theme(value) == ThemeDark {
+		return ThemeDark
+	}
+	return ThemeLight
+}
+
+type themeKey struct{}
+
+// ContextWithTheme returns a context carrying the reader's theme for Layout
+func ContextWithTheme(ctx context.Context, theme Theme) context.Context {
+	return context.WithValue(ctx, themeKey{}, theme)
+}
+
+// themeFromContext returns the theme set by ContextWithTheme, or light
+func themeFromContext(ctx context.Context) Theme {
+	if theme, ok := ctx.Value(themeKey{}).(Theme); ok {
+		return theme
+	}
+	return ThemeLight
+}
+
+// ThemeToggle switches between the light and dark theme
+func ThemeToggle() templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var1 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var1 == nil {
+			templ_7745c5c3_Var1 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<form class=\"theme-toggle\" method=\"post\" action=\"/theme\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = CSRFField().Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if themeFromContext(ctx) == ThemeDark {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<input type=\"hidden\" name=\"theme\" value=\"light\"> <button type=\"submit\" title=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var2 string
+			templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "theme.to_light"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/theme.templ`, Line: 42, Col: 57}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "\" aria-label=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var3 string
+			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "theme.to_light"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/theme.templ`, Line: 42, Col: 97}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "\">☀️</button>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		} else {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "<input type=\"hidden\" name=\"theme\" value=\"dark\"> <button type=\"submit\" title=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var4 string
+			templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "theme.to_dark"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/theme.templ`, Line: 45, Col: 56}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "\" aria-label=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var5 string
+			templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(T(ctx, "theme.to_dark"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/theme.templ`, Line: 45, Col: 95}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "\">🌙</button>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "</form>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+// darkThemeStyle overrides component colours when the dark theme is active.
+// Selectors are prefixed with .theme-dark so they win over the light
+// defaults in each template's own style block.
+func darkThemeStyle() templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var6 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var6 == nil {
+			templ_7745c5c3_Var6 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "<style>\n\t\t.theme-dark body {\n\t\t\tcolor: #d5dce3;\n\t\t\tbackground: #12171d;\n\t\t}\n\t\t.theme-dark header,\n\t\t.theme-dark .search-box,\n\t\t.theme-dark .post-card,\n\t\t.theme-dark .post-detail,\n\t\t.theme-dark .comments,\n\t\t.theme-dark .form-container,\n\t\t.theme-dark .login-container,\n\t\t.theme-dark .draft-item,\n\t\t.theme-dark .no-drafts,\n\t\t.theme-dark .popular-posts,\n\t\t.theme-dark .related-posts,\n\t\t.theme-dark .stats-page,\n\t\t.theme-dark .archive,\n\t\t.theme-dark .revisions,\n\t\t.theme-dark .trash-item,\n\t\t.theme-dark .no-trash {\n\t\t\tbackground: #1e2630;\n\t\t\tbox-shadow: 0 2px 4px rgba(0,0,0,0.4);\n\t\t}\n\t\t.theme-dark h1,\n\t\t.theme-dark .post-title,\n\t\t.theme-dark .post-detail-title,\n\t\t.theme-dark .tag-header h2,\n\t\t.theme-dark .drafts-header h2,\n\t\t.theme-dark .form-header h2,\n\t\t.theme-dark .login-container h2,\n\t\t.theme-dark .comments h3,\n\t\t.theme-dark .related-posts h3,\n\t\t.theme-dark .popular-posts h3,\n\t\t.theme-dark .stats-page h2,\n\t\t.theme-dark .archive-year,\n\t\t.theme-dark .form-group label,\n\t\t.theme-dark .login-form label,\n\t\t.theme-dark .comment-author,\n\t\t.theme-dark .popular-posts a,\n\t\t.theme-dark .stats-table a,\n\t\t.theme-dark .draft-item a,\n\t\t.theme-dark .revision-item,\n\t\t.theme-dark .trash-title {\n\t\t\tcolor: #ecf0f1;\n\t\t}\n\t\t.theme-dark .post-content,\n\t\t.theme-dark .post-body {\n\t\t\tcolor: #c3ccd5;\n\t\t}\n\t\t.theme-dark .subtitle,\n\t\t.theme-dark .post-meta,\n\t\t.theme-dark .comment-meta,\n\t\t.theme-dark .form-hint,\n\t\t.theme-dark .sort-label,\n\t\t.theme-dark .related-meta,\n\t\t.theme-dark .popular-views,\n\t\t.theme-dark .archive-count,\n\t\t.theme-dark .revision-meta,\n\t\t.theme-dark .trash-date,\n\t\t.theme-dark .stat-label,\n\t\t.theme-dark .stats-table th {\n\t\t\tcolor: #95a5a6;\n\t\t}\n\t\t.theme-dark .search-input,\n\t\t.theme-dark .form-input,\n\t\t.theme-dark .form-textarea {\n\t\t\tbackground: #141a21;\n\t\t\tcolor: #d5dce3;\n\t\t\tborder-color: #34404c;\n\t\t}\n\t\t.theme-dark .search-input:focus,\n\t\t.theme-dark .form-input:focus,\n\t\t.theme-dark .form-textarea:focus {\n\t\t\tborder-color: #3498db;\n\t\t}\n\t\t.theme-dark .btn-back,\n\t\t.theme-dark .btn-secondary,\n\t\t.theme-dark .btn-archive,\n\t\t.theme-dark .tag,\n\t\t.theme-dark .reaction,\n\t\t.theme-dark .stat-card {\n\t\t\tbackground: #2c3642;\n\t\t\tcolor: #ecf0f1;\n\t\t\tborder-color: #3b4856;\n\t\t}\n\t\t.theme-dark .btn-back:hover,\n\t\t.theme-dark .btn-secondary:hover,\n\t\t.theme-dark .btn-archive:hover,\n\t\t.theme-dark .tag:hover,\n\t\t.theme-dark .reaction:hover {\n\t\t\tbackground: #3b4856;\n\t\t}\n\t\t.theme-dark .reaction-count {\n\t\t\tcolor: #d5dce3;\n\t\t}\n\t\t.theme-dark .sort-option span {\n\t\t\tcolor: #d5dce3;\n\t\t\tborder-color: #3b4856;\n\t\t}\n\t\t.theme-dark .sort-option input:checked + span {\n\t\t\tcolor: white;\n\t\t\tborder-color: #3498db;\n\t\t}\n\t\t.theme-dark .comment,\n\t\t.theme-dark .archive-month,\n\t\t.theme-dark .revision-item,\n\t\t.theme-dark .stats-table th,\n\t\t.theme-dark .stats-table td,\n\t\t.theme-dark .form-header {\n\t\t\tborder-color: #2c3642;\n\t\t}\n\t\t.theme-dark .draft-banner {\n\t\t\tbackground: #3d3514;\n\t\t\tcolor: #f4d03f;\n\t\t}\n\t\t.theme-dark .login-error {\n\t\t\tbackground: #3d1f1c;\n\t\t\tcolor: #f1948a;\n\t\t}\n\t\t.theme-dark mark {\n\t\t\tbackground: #7d6608;\n\t\t\tcolor: #fff;\n\t\t}\n\t\t.theme-dark .diff {\n\t\t\tbackground: #141a21;\n\t\t}\n\t\t.theme-dark .diff-insert {\n\t\t\tbackground: #16321f;\n\t\t\tcolor: #82e0aa;\n\t\t}\n\t\t.theme-dark .diff-delete {\n\t\t\tbackground: #3d1f1c;\n\t\t\tcolor: #f1948a;\n\t\t}\n\t\t.theme-dark .attached-image img {\n\t\t\tborder-color: #34404c;\n\t\t}\n\t</style>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+var _ = templruntime.GeneratedTemplate
@@ -0,0 +1,75 @@
+package components
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+)
+
+func render(t *testing.T, ctx context.Context, c templ.Component) string {
+	t.Helper()
+	var b strings.Builder
+	if err := c.Render(ctx, &b); err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	return b.String()
+}
+
+func TestTheme(t *testing.T) {
+	if got := ThemeFromContext(context.Background()); got != DefaultTheme {
+		t.Errorf("Expected the default theme without one set, got %+v", got)
+	}
+
+	ctx := ContextWithTheme(context.Background(), Theme{Primary: "#2E7D32", Radius: "4px"})
+	theme := ThemeFromContext(ctx)
+	if theme.Primary != "#2E7D32" || theme.Radius != "4px" {
+		t.Errorf("Expected the theme's own colour and radius, got %+v", theme)
+	}
+	if theme.Danger != DefaultTheme.Danger || theme.Font != DefaultTheme.Font {
+		t.Errorf("Expected gaps filled from the default theme, got %+v", theme)
+	}
+
+	css := render(t, ctx, Styles())
+	if !strings.Contains(css, "--ui-primary: #2E7D32;") || !strings.Contains(css, "--ui-radius: 4px;") {
+		t.Errorf("Expected the theme's variables in the styles, got %q", css[:200])
+	}
+
+	ctx = ContextWithTheme(context.Background(), Theme{Primary: "red; } </style><script>alert(1)</script>"})
+	if css := render(t, ctx, Styles()); strings.Contains(css, "<script>") {
+		t.Error("Expected a theme value not to end the style element")
+	}
+}
+
+func TestBadge(t *testing.T) {
+	tests := []struct {
+		count int
+		want  string
+	}{
+		{0, ">0</span>"},
+		{99, ">99</span>"},
+		{100, ">99+</span>"},
+	}
+	for _, tt := range tests {
+		got := render(t, context.Background(), Badge(tt.count, templ.Attributes{"id": "cart-badge"}))
+		if !strings.HasSuffix(got, tt.want) || !strings.Contains(got, `id="cart-badge"`) {
+			t.Errorf("Badge(%d) = %q, want it to end with %q", tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestLoadMore(t *testing.T) {
+	got := render(t, context.Background(), LoadMore("/?q=a&cursor=20.7", "More", "20 / 48"))
+	if strings.Count(got, `hx-get="/?q=a&amp;cursor=20.7"`) != 2 || !strings.Contains(got, `href="/?q=a&amp;cursor=20.7"`) {
+		t.Errorf("Expected the sentinel and its link to fetch the next page, got %q", got)
+	}
+	if !strings.Contains(got, "20 / 48") {
+		t.Errorf("Expected the progress under the link, got %q", got)
+	}
+
+	got = render(t, context.Background(), LoadMore("/", "More", ""))
+	if strings.Contains(got, "ui-load-more-progress") {
+		t.Errorf("Expected no progress when there is none, got %q", got)
+	}
+}
@@ -0,0 +1,94 @@
+// Package components holds the shop's UI pieces: the page shell, empty
+// states, toasts, badges, buttons, form fields, modals, spinners and the
+// load-more control. Their colours and corners come from a Theme, set once
+// for the page. The blog doesn't use them: features don't import each
+// other, so the blog keeps its own markup.
+package components
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// HTMXVersion is the htmx release Shell loads
+const HTMXVersion = "1.9.10"
+
+// Theme is how the components look. Colours are CSS colours and Radius is
+// a CSS length; empty fields fall back to DefaultTheme's.
+type Theme struct {
+	Primary       string
+	PrimaryActive string
+	Danger        string
+	Text          string
+	Muted         string
+	Surface       string
+	Radius        string
+	Font          string
+}
+
+// DefaultTheme is the blue, rounded look the shop uses
+var DefaultTheme = Theme{
+	Primary:       "#007AFF",
+	PrimaryActive: "#0056CC",
+	Danger:        "#FF3B30",
+	Text:          "#333",
+	Muted:         "#999",
+	Surface:       "#E5E5EA",
+	Radius:        "12px",
+	Font:          "-apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif",
+}
+
+type themeKey struct{}
+
+// ContextWithTheme returns a context carrying theme for the components
+// rendered with it
+func ContextWithTheme(ctx context.Context, theme Theme) context.Context {
+	return context.WithValue(ctx, themeKey{}, theme)
+}
+
+// ThemeFromContext returns the theme set by ContextWithTheme with its gaps
+// filled in from DefaultTheme, or DefaultTheme if none was set
+func ThemeFromContext(ctx context.Context) Theme {
+	theme, _ := ctx.Value(themeKey{}).(Theme)
+	return theme.withDefaults()
+}
+
+// withDefaults fills in the fields theme leaves empty from DefaultTheme
+func (theme Theme) withDefaults() Theme {
+	fill := func(field *string, fallback string) {
+		if *field == "" {
+			*field = fallback
+		}
+	}
+	fill(&theme.Primary, DefaultTheme.Primary)
+	fill(&theme.PrimaryActive, DefaultTheme.PrimaryActive)
+	fill(&theme.Danger, DefaultTheme.Danger)
+	fill(&theme.Text, DefaultTheme.Text)
+	fill(&theme.Muted, DefaultTheme.Muted)
+	fill(&theme.Surface, DefaultTheme.Surface)
+	fill(&theme.Radius, DefaultTheme.Radius)
+	fill(&theme.Font, DefaultTheme.Font)
+	return theme
+}
+
+// variables is the theme as the CSS custom properties the components'
+// styles read, for a :root rule
+func (theme Theme) variables() string {
+	var b strings.Builder
+	for _, v := range []struct{ name, value string }{
+		{"primary", theme.Primary},
+		{"primary-active", theme.PrimaryActive},
+		{"danger", theme.Danger},
+		{"text", theme.Text},
+		{"muted", theme.Muted},
+		{"surface", theme.Surface},
+		{"radius", theme.Radius},
+		{"font", theme.Font},
+	} {
+		// A value can't end the rule or the style element early
+		value := strings.NewReplacer(";", "", "{", "", "}", "", "<", "").Replace(v.value)
+		fmt.Fprintf(&b, "--ui-%s: %s; ", v.name, value)
+	}
+	return strings.TrimSpace(b.String())
+}
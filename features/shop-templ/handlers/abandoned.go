@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+	"github.com/homveloper/doodle/features/shop-templ/templates"
+)
+
+// cartSweepEvery is how often the cart janitor looks for carts to expire
+// or remind their owners of
+const cartSweepEvery = 10 * time.Minute
+
+// CartJanitor looks after carts left alone: it forgets those unchanged
+// for their TTL, giving back the stock they held, and emails account
+// holders about carts they left items in
+type CartJanitor struct {
+	carts  *models.CartStore
+	store  *models.ProductStore
+	users  *models.UserStore
+	sender models.EmailSender
+	// shopURL is where the shop is served, for linking to the cart
+	shopURL string
+	// TTL is how long a cart is kept after it last changed; zero keeps
+	// carts for good
+	TTL time.Duration
+	// RemindAfter is how long after it last changed a cart's owner is
+	// emailed about it; zero sends no reminders. Guests have no email
+	// address to be reminded at.
+	RemindAfter time.Duration
+}
+
+func NewCartJanitor(carts *models.CartStore, store *models.ProductStore, users *models.UserStore, sender models.EmailSender, shopURL string) *CartJanitor {
+	return &CartJanitor{
+		carts:   carts,
+		store:   store,
+		users:   users,
+		sender:  sender,
+		shopURL: strings.TrimSuffix(shopURL, "/"),
+	}
+}
+
+// Run sweeps now and then every cartSweepEvery until ctx is done
+func (j *CartJanitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(cartSweepEvery)
+	defer ticker.Stop()
+	for {
+		j.Sweep(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep reminds the owners of abandoned carts, then expires the carts
+// left for their TTL. Failures are logged: a reminder that couldn't be
+// sent is tried again next time.
+func (j *CartJanitor) Sweep(ctx context.Context) {
+	if j.RemindAfter > 0 {
+		for _, cart := range j.carts.Abandoned(j.RemindAfter) {
+			if ctx.Err() != nil {
+				return
+			}
+			j.remind(ctx, cart)
+		}
+	}
+
+	if j.TTL > 0 {
+		expired := j.carts.Expire(j.TTL)
+		for _, cartID := range expired {
+			j.store.ReleaseAll(cartID)
+		}
+		if len(expired) > 0 {
+			log.Printf("expired %d carts left for %s", len(expired), j.TTL)
+		}
+	}
+}
+
+// remind emails the owner of the cart about what they left in it, if it is
+// an account's cart
+func (j *CartJanitor) remind(ctx context.Context, cart *models.Cart) {
+	userID, ok := models.CartUserID(cart.ID)
+	if !ok {
+		return
+	}
+	user, ok := j.users.GetByID(userID)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, emailTimeout)
+	defer cancel()
+
+	var body bytes.Buffer
+	if err := templates.CartReminderEmail(user.Name, cart.GetItems(), cart.TotalAfterDiscount(), j.shopURL+"/cart").Render(ctx, &body); err != nil {
+		log.Printf("render reminder for cart %s: %v", cart.ID, err)
+		return
+	}
+
+	email := models.Email{
+		To:      user.Email,
+		Subject: templates.CartReminderEmailSubject(),
+		HTML:    body.String(),
+	}
+	if err := j.sender.Send(ctx, email); err != nil {
+		log.Printf("email cart reminder to %s: %v", email.To, err)
+		return
+	}
+	if err := j.carts.MarkReminded(cart); err != nil {
+		// They may be reminded again next time
+		slog.Warn("mark cart reminded", "cart", cart.ID, "error", err)
+	}
+}
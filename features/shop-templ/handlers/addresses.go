@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+	"github.com/homveloper/doodle/features/shop-templ/templates"
+)
+
+// AddressHandler lets logged-in users keep the addresses they ship and
+// bill to, for checkout to fill in
+type AddressHandler struct {
+	book *models.AddressBook
+}
+
+func NewAddressHandler(book *models.AddressBook) *AddressHandler {
+	return &AddressHandler{book: book}
+}
+
+// HandleAddresses lists the user's addresses with the form for adding one
+func (h *AddressHandler) HandleAddresses(w http.ResponseWriter, r *http.Request) {
+	h.renderList(w, r, http.StatusOK, models.Address{Kind: models.AddressShipping}, "")
+}
+
+// HandleCreateAddress saves an address from the form, then goes back to
+// the address list
+func (h *AddressHandler) HandleCreateAddress(w http.ResponseWriter, r *http.Request) {
+	user, _ := models.UserFromContext(r.Context())
+	address := addressFromForm(r, user.ID)
+
+	if _, err := h.book.Add(address); err != nil {
+		h.renderList(w, r, http.StatusUnprocessableEntity, address, addressErrorMessage(r, err))
+		return
+	}
+	http.Redirect(w, r, "/addresses", http.StatusSeeOther)
+}
+
+// HandleEditAddress renders the form for changing one of the user's
+// addresses
+func (h *AddressHandler) HandleEditAddress(w http.ResponseWriter, r *http.Request) {
+	address, ok := h.userAddress(w, r)
+	if !ok {
+		return
+	}
+	h.renderForm(w, r, http.StatusOK, address, "")
+}
+
+// HandleUpdateAddress changes one of the user's addresses from the form,
+// then goes back to the address list
+func (h *AddressHandler) HandleUpdateAddress(w http.ResponseWriter, r *http.Request) {
+	stored, ok := h.userAddress(w, r)
+	if !ok {
+		return
+	}
+
+	address := addressFromForm(r, stored.UserID)
+	address.ID = stored.ID
+	address.Kind = stored.Kind
+	if _, err := h.book.Update(address); err != nil {
+		h.renderForm(w, r, http.StatusUnprocessableEntity, address, addressErrorMessage(r, err))
+		return
+	}
+	http.Redirect(w, r, "/addresses", http.StatusSeeOther)
+}
+
+// HandleDeleteAddress removes one of the user's addresses
+func (h *AddressHandler) HandleDeleteAddress(w http.ResponseWriter, r *http.Request) {
+	address, ok := h.userAddress(w, r)
+	if !ok {
+		return
+	}
+
+	h.book.Delete(address.UserID, address.ID)
+	http.Redirect(w, r, "/addresses", http.StatusSeeOther)
+}
+
+// HandleSetDefaultAddress makes one of the user's addresses the one
+// checkout fills in
+func (h *AddressHandler) HandleSetDefaultAddress(w http.ResponseWriter, r *http.Request) {
+	address, ok := h.userAddress(w, r)
+	if !ok {
+		return
+	}
+
+	h.book.SetDefault(address.UserID, address.ID)
+	http.Redirect(w, r, "/addresses", http.StatusSeeOther)
+}
+
+// userAddress returns the logged-in user's address in the path, writing
+// an error if there is no such address or it is someone else's
+func (h *AddressHandler) userAddress(w http.ResponseWriter, r *http.Request) (models.Address, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid address ID", http.StatusBadRequest)
+		return models.Address{}, false
+	}
+
+	user, _ := models.UserFromContext(r.Context())
+	address, err := h.book.Get(user.ID, id)
+	if err != nil {
+		http.Error(w, "Address not found", http.StatusNotFound)
+		return models.Address{}, false
+	}
+	return address, true
+}
+
+// renderList writes the address list with the given status, keeping what
+// was entered in the form for adding one
+func (h *AddressHandler) renderList(w http.ResponseWriter, r *http.Request, status int, form models.Address, message string) {
+	user, _ := models.UserFromContext(r.Context())
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	templates.Page(tr(r, "addresses.title"), requestCart(r), templates.AddressesPage(h.book.ForUser(user.ID, ""), form, message)).Render(r.Context(), w)
+}
+
+// renderForm writes the form for changing an address with the given status
+func (h *AddressHandler) renderForm(w http.ResponseWriter, r *http.Request, status int, address models.Address, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	templates.Page(tr(r, "addresses.edit.title"), requestCart(r), templates.AddressFormPage(address, message)).Render(r.Context(), w)
+}
+
+// addressFromForm reads an address of the user's from the form
+func addressFromForm(r *http.Request, userID int) models.Address {
+	return models.Address{
+		UserID:  userID,
+		Kind:    models.AddressKind(r.FormValue("kind")),
+		Label:   strings.TrimSpace(r.FormValue("label")),
+		Name:    strings.TrimSpace(r.FormValue("name")),
+		Phone:   strings.TrimSpace(r.FormValue("phone")),
+		Address: strings.TrimSpace(r.FormValue("address")),
+		Country: r.FormValue("country"),
+		Default: r.FormValue("default") != "",
+	}
+}
+
+// addressErrorMessage explains to the user why an address can't be saved
+func addressErrorMessage(r *http.Request, err error) string {
+	switch {
+	case errors.Is(err, models.ErrAddressIncomplete):
+		return tr(r, "shipping.error.incomplete")
+	case errors.Is(err, models.ErrUnsupportedCountry):
+		return tr(r, "shipping.error.country")
+	case errors.Is(err, models.ErrInvalidAddressKind):
+		return tr(r, "addresses.error.kind")
+	default:
+		return tr(r, "addresses.error")
+	}
+}
@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+	"github.com/homveloper/doodle/features/shop-templ/templates"
+)
+
+// maxCatalogSize is the largest catalog file that can be imported
+const maxCatalogSize = 10 << 20
+
+type AdminHandler struct {
+	store *models.ProductStore
+}
+
+func NewAdminHandler(store *models.ProductStore) *AdminHandler {
+	return &AdminHandler{store: store}
+}
+
+// HandleProducts renders every product, archived ones too, with their
+// stock and what can be done with them. With q, only products with a SKU
+// or barcode starting with it are listed.
+func (h *AdminHandler) HandleProducts(w http.ResponseWriter, r *http.Request) {
+	h.renderProducts(w, r, http.StatusOK, "")
+}
+
+// HandleNewProduct renders the form for adding a product
+func (h *AdminHandler) HandleNewProduct(w http.ResponseWriter, r *http.Request) {
+	h.renderProductForm(w, r, http.StatusOK, models.Product{}, "")
+}
+
+// HandleCreateProduct adds a product from the form, then goes to the
+// product list
+func (h *AdminHandler) HandleCreateProduct(w http.ResponseWriter, r *http.Request) {
+	product, message := productFromForm(r)
+	stock, err := strconv.Atoi(r.FormValue("stock"))
+	if message == "" && (err != nil || stock < 0) {
+		message = "재고는 0 이상의 숫자로 입력해주세요"
+	}
+	if message != "" {
+		h.renderProductForm(w, r, http.StatusUnprocessableEntity, product, message)
+		return
+	}
+
+	product.Stock = stock
+	if _, err := h.store.Create(product); errors.Is(err, models.ErrNotSaved) {
+		writeSaveError(w, r, err)
+		return
+	} else if err != nil {
+		h.renderProductForm(w, r, http.StatusUnprocessableEntity, product, productErrorMessage(err))
+		return
+	}
+	http.Redirect(w, r, "/admin/products", http.StatusSeeOther)
+}
+
+// HandleEditProduct renders the form for changing a product
+func (h *AdminHandler) HandleEditProduct(w http.ResponseWriter, r *http.Request) {
+	product, ok := h.product(w, r)
+	if !ok {
+		return
+	}
+
+	h.renderProductForm(w, r, http.StatusOK, product, "")
+}
+
+// HandleUpdateProduct saves the form's changes to a product, then goes to
+// the product list
+func (h *AdminHandler) HandleUpdateProduct(w http.ResponseWriter, r *http.Request) {
+	stored, ok := h.product(w, r)
+	if !ok {
+		return
+	}
+
+	product, message := productFromForm(r)
+	product.ID = stored.ID
+	if message != "" {
+		h.renderProductForm(w, r, http.StatusUnprocessableEntity, product, message)
+		return
+	}
+	if _, err := h.store.Update(product); errors.Is(err, models.ErrProductNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if errors.Is(err, models.ErrNotSaved) {
+		writeSaveError(w, r, err)
+		return
+	} else if err != nil {
+		h.renderProductForm(w, r, http.StatusUnprocessableEntity, product, productErrorMessage(err))
+		return
+	}
+
+	http.Redirect(w, r, "/admin/products", http.StatusSeeOther)
+}
+
+// HandleArchiveProduct takes a product off sale, or puts it back with
+// archived=false
+func (h *AdminHandler) HandleArchiveProduct(w http.ResponseWriter, r *http.Request) {
+	product, ok := h.product(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.store.SetArchived(product.ID, r.FormValue("archived") != "false"); errors.Is(err, models.ErrNotSaved) {
+		writeSaveError(w, r, err)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Redirect(w, r, "/admin/products", http.StatusSeeOther)
+}
+
+// HandleAdjustStock adds the form's delta to a product's stock, or takes it
+// away if negative. Products with variants have the stock of the form's
+// variant_id adjusted. It then goes to the form's next page, by default
+// the product list.
+func (h *AdminHandler) HandleAdjustStock(w http.ResponseWriter, r *http.Request) {
+	product, ok := h.product(w, r)
+	if !ok {
+		return
+	}
+
+	key := models.ItemKey{ProductID: product.ID}
+	key.VariantID, _ = strconv.Atoi(r.FormValue("variant_id"))
+	delta, err := strconv.Atoi(strings.TrimSpace(r.FormValue("delta")))
+	if err != nil || delta == 0 {
+		h.renderProducts(w, r, http.StatusUnprocessableEntity, "늘리거나 줄일 재고 수량을 입력해주세요")
+		return
+	}
+	if _, err := h.store.AdjustStock(key, delta); errors.Is(err, models.ErrNegativeStock) {
+		h.renderProducts(w, r, http.StatusUnprocessableEntity, fmt.Sprintf("%s의 재고는 %d개보다 줄일 수 없습니다", product.Name, product.StockOf(key.VariantID)))
+		return
+	} else if errors.Is(err, models.ErrVariantRequired) {
+		h.renderProducts(w, r, http.StatusUnprocessableEntity, fmt.Sprintf("%s의 재고를 조정할 옵션을 선택해주세요", product.Name))
+		return
+	} else if errors.Is(err, models.ErrNotSaved) {
+		writeSaveError(w, r, err)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	http.Redirect(w, r, safeNext(cmp.Or(r.FormValue("next"), "/admin/products")), http.StatusSeeOther)
+}
+
+// HandleQuickStock renders the screen for adjusting stock by scanning or
+// typing a SKU or barcode, looked up through the JSON API. The sku query
+// parameter is looked up when the page opens.
+func (h *AdminHandler) HandleQuickStock(w http.ResponseWriter, r *http.Request) {
+	component := templates.Page("빠른 재고 조정", requestCart(r), templates.AdminQuickStockPage(strings.TrimSpace(r.URL.Query().Get("sku"))))
+	err := component.Render(r.Context(), w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// HandleExport downloads every product, archived ones too, as CSV or, with
+// format=json, JSON
+func (h *AdminHandler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	format := models.CatalogCSV
+	if value := r.URL.Query().Get("format"); value != "" {
+		var ok bool
+		if format, ok = models.CatalogFormatOf("products." + value); !ok || format == models.CatalogYAML {
+			http.Error(w, "Unknown export format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if format == models.CatalogJSON {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="products.%s"`, format))
+	if err := h.store.Export(w, format); err != nil {
+		log.Printf("export catalog: %v", err)
+	}
+}
+
+// HandleImportPage renders the form for importing a catalog file
+func (h *AdminHandler) HandleImportPage(w http.ResponseWriter, r *http.Request) {
+	h.renderImport(w, r, http.StatusOK, nil, "")
+}
+
+// HandleImport imports the uploaded CSV or JSON catalog file, or with
+// dry_run only checks it, and shows what changed and which rows couldn't
+// be imported
+func (h *AdminHandler) HandleImport(w http.ResponseWriter, r *http.Request) {
+	// Allow some room for the multipart framing around the file
+	r.Body = http.MaxBytesReader(w, r.Body, maxCatalogSize+1<<20)
+	file, header, err := r.FormFile("catalog")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.renderImport(w, r, http.StatusRequestEntityTooLarge, nil, "파일은 10MB 이하여야 합니다")
+			return
+		}
+		h.renderImport(w, r, http.StatusBadRequest, nil, "가져올 파일을 선택해주세요")
+		return
+	}
+	defer file.Close()
+
+	format, ok := models.CatalogFormatOf(header.Filename)
+	if !ok {
+		h.renderImport(w, r, http.StatusUnsupportedMediaType, nil, "CSV, JSON, YAML 파일만 가져올 수 있습니다")
+		return
+	}
+
+	result, err := h.store.Import(file, format, r.FormValue("dry_run") != "")
+	if errors.Is(err, models.ErrCatalogFormat) {
+		h.renderImport(w, r, http.StatusUnprocessableEntity, nil, "파일을 읽을 수 없습니다. CSV는 id나 name 열이 있는 머리글 행이, JSON과 YAML은 상품 목록이 필요합니다")
+		return
+	} else if errors.Is(err, models.ErrNotSaved) {
+		writeSaveError(w, r, err)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to read upload", http.StatusBadRequest)
+		return
+	}
+
+	status := http.StatusOK
+	if len(result.Errors) > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	h.renderImport(w, r, status, &result, "")
+}
+
+// HandleCategories renders every category with how many products it and
+// its sub-categories have
+func (h *AdminHandler) HandleCategories(w http.ResponseWriter, r *http.Request) {
+	h.renderCategories(w, r, http.StatusOK, "")
+}
+
+// HandleRenameCategory moves every product in one category and its
+// sub-categories to another, merging them if the other already exists
+func (h *AdminHandler) HandleRenameCategory(w http.ResponseWriter, r *http.Request) {
+	from := r.FormValue("from")
+	to := strings.TrimSpace(r.FormValue("to"))
+	if to == "" {
+		h.renderCategories(w, r, http.StatusUnprocessableEntity, "새 카테고리 이름을 입력해주세요")
+		return
+	}
+
+	if _, err := h.store.RenameCategory(from, to); errors.Is(err, models.ErrNotSaved) {
+		writeSaveError(w, r, err)
+		return
+	} else if err != nil {
+		h.renderCategories(w, r, http.StatusUnprocessableEntity, "카테고리 이름을 바꿀 수 없습니다")
+		return
+	}
+	http.Redirect(w, r, "/admin/categories", http.StatusSeeOther)
+}
+
+// product finds the product in the path, archived or not, writing an
+// error if there is none
+func (h *AdminHandler) product(w http.ResponseWriter, r *http.Request) (models.Product, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return models.Product{}, false
+	}
+
+	product, exists := h.store.GetByID(id)
+	if !exists {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return models.Product{}, false
+	}
+	return product, true
+}
+
+// renderProducts writes the product list with the given status, only
+// with the products matching the q query parameter if there is one
+func (h *AdminHandler) renderProducts(w http.ResponseWriter, r *http.Request, status int, message string) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	products := h.store.GetAllWithArchived()
+	if query != "" {
+		products = h.store.SearchCodes(query)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	templates.Page("상품 관리", requestCart(r), templates.AdminProductsPage(products, h.store.LowOnStock(), query, message)).Render(r.Context(), w)
+}
+
+// renderProductForm writes the form for a new product, or for changing
+// product if it has an ID, keeping what was entered
+func (h *AdminHandler) renderProductForm(w http.ResponseWriter, r *http.Request, status int, product models.Product, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	templates.Page("상품 관리", requestCart(r), templates.ProductFormPage(product, h.categories(), message)).Render(r.Context(), w)
+}
+
+// renderImport writes the import page with the given status and, after an
+// upload, what it did
+func (h *AdminHandler) renderImport(w http.ResponseWriter, r *http.Request, status int, result *models.ImportResult, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	templates.Page("상품 가져오기", requestCart(r), templates.AdminImportPage(result, message)).Render(r.Context(), w)
+}
+
+// renderCategories writes the category list with the given status
+func (h *AdminHandler) renderCategories(w http.ResponseWriter, r *http.Request, status int, message string) {
+	counts := make(map[string]int)
+	for _, p := range h.store.GetAllWithArchived() {
+		for _, category := range models.CategoryAncestors(p.Category) {
+			counts[category]++
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	templates.Page("카테고리 관리", requestCart(r), templates.AdminCategoriesPage(h.categories(), counts, message)).Render(r.Context(), w)
+}
+
+// categories returns every category, archived products' and parent
+// categories too, sorted
+func (h *AdminHandler) categories() []string {
+	return models.CategoriesOf(h.store.GetAllWithArchived())
+}
+
+// productFromForm reads a product's details from the admin form. The
+// message explains what is wrong with them, if anything.
+func productFromForm(r *http.Request) (models.Product, string) {
+	product := models.Product{
+		Name:        strings.TrimSpace(r.FormValue("name")),
+		SKU:         strings.TrimSpace(r.FormValue("sku")),
+		Barcode:     strings.TrimSpace(r.FormValue("barcode")),
+		Description: strings.TrimSpace(r.FormValue("description")),
+		Category:    models.NormalizeCategory(r.FormValue("category")),
+	}
+	for _, tag := range strings.Split(r.FormValue("tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" && !slices.Contains(product.Tags, tag) {
+			product.Tags = append(product.Tags, tag)
+		}
+	}
+
+	price, err := models.ParseMoney(r.FormValue("price"), models.DefaultCurrency)
+	if err != nil {
+		return product, "가격을 원 단위 숫자로 입력해주세요"
+	}
+	product.Price = price
+	if err := product.Validate(); err != nil {
+		return product, "이름, 카테고리, 0 이상의 가격을 입력해주세요"
+	}
+	return product, ""
+}
+
+// productErrorMessage explains why a product from the admin form can't be
+// saved
+func productErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, models.ErrDuplicateSKU):
+		return "다른 상품이나 옵션이 이미 쓰는 SKU입니다"
+	case errors.Is(err, models.ErrDuplicateBarcode):
+		return "다른 상품이 이미 쓰는 바코드입니다"
+	case errors.Is(err, models.ErrInvalidVariant):
+		return "바뀐 가격으로는 옵션 가격이 0보다 작아집니다"
+	default:
+		return "상품을 저장할 수 없습니다"
+	}
+}
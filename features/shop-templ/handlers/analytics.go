@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/homveloper/doodle/features/shop-templ/htmxkit"
+	"github.com/homveloper/doodle/features/shop-templ/models"
+	"github.com/homveloper/doodle/features/shop-templ/templates"
+)
+
+// topSearchCount is how many searches the analytics dashboard lists
+const topSearchCount = 20
+
+// analyticsPeriods are the numbers of days the dashboard can sum up
+var analyticsPeriods = []int{1, 7, 30}
+
+// untrackedPaths are the paths under which GET requests aren't page views:
+// the API, admin pages, monitoring, and what pages load for themselves
+var untrackedPaths = []string{"/api/", "/admin/", "/media/", "/events", "/search/suggest", "/cart/recommendations", "/checkout/summary", "/healthz", "/metrics"}
+
+// Analytics records what shoppers do into an event sink and reports on
+// it. A nil *Analytics records nothing, so handlers work without one.
+type Analytics struct {
+	sink models.EventSink
+}
+
+func NewAnalytics(sink models.EventSink) *Analytics {
+	return &Analytics{sink: sink}
+}
+
+// Track records the event as done now by whoever made the request.
+// Analytics is best-effort: an event that can't be recorded is logged
+// rather than failing the request.
+func (a *Analytics) Track(r *http.Request, event models.Event) {
+	if a == nil {
+		return
+	}
+	event.At = time.Now()
+	event.Visitor = visitorID(r)
+	if err := a.sink.RecordEvent(event); err != nil {
+		log.Printf("record %s event: %v", event.Kind, err)
+	}
+}
+
+// TrackPageViews records every page shown: GET requests answered with a
+// page of their own, not HTMX requests updating part of one
+func (a *Analytics) TrackPageViews(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || htmxkit.IsRequest(r) || !trackedPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		if sw.status == http.StatusOK {
+			a.Track(r, models.Event{Kind: models.EventPageView, Path: r.URL.Path})
+		}
+	})
+}
+
+// HandleDashboard renders the conversion funnel and top searches over the
+// last few days, one by default
+func (a *Analytics) HandleDashboard(w http.ResponseWriter, r *http.Request) {
+	days, err := strconv.Atoi(r.URL.Query().Get("days"))
+	if err != nil || days < 1 || days > analyticsPeriods[len(analyticsPeriods)-1] {
+		days = analyticsPeriods[0]
+	}
+	since := time.Now().AddDate(0, 0, -days)
+
+	events, err := a.sink.LoadEvents(since)
+	if err != nil {
+		log.Printf("load events: %v", err)
+		http.Error(w, "Could not load analytics", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	templates.Page("통계", requestCart(r), templates.AdminAnalyticsPage(models.NewAnalyticsReport(events, since, topSearchCount), days, analyticsPeriods)).Render(r.Context(), w)
+}
+
+// trackedPath reports whether a GET request for path is for a page
+func trackedPath(path string) bool {
+	for _, prefix := range untrackedPaths {
+		if strings.HasPrefix(path, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// visitorID tells apart who made the request in analytics events: the
+// account if they are logged in, or else the visitor's cart. It is hashed
+// so events hold nothing that finds the cart or account again.
+func visitorID(r *http.Request) string {
+	sum := sha256.Sum256([]byte(requestCart(r).ID))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,750 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+)
+
+// maxAPIPageSize is the most products a page of the API's listing can have
+const maxAPIPageSize = 100
+
+// APIHandler serves the JSON API under /api/v1 for clients other than the
+// browser, such as the mobile app. It works on the same carts and
+// sessions as the HTML pages, identified by the same cookies.
+type APIHandler struct {
+	store    *models.ProductStore
+	orders   *models.OrderStore
+	coupons  *models.CouponStore
+	cart     *CartHandler
+	checkout *CheckoutHandler
+	order    *OrderHandler
+}
+
+func NewAPIHandler(store *models.ProductStore, orders *models.OrderStore, coupons *models.CouponStore, cart *CartHandler, checkout *CheckoutHandler, order *OrderHandler) *APIHandler {
+	return &APIHandler{
+		store:    store,
+		orders:   orders,
+		coupons:  coupons,
+		cart:     cart,
+		checkout: checkout,
+		order:    order,
+	}
+}
+
+// variantParam is the query parameter naming which variant of a product in
+// the cart a request is about
+var variantParam = APIParam{Name: "variant", Type: "integer", Description: "옵션이 있는 상품의 옵션 ID"}
+
+// Routes returns every endpoint of the API, for registering on a mux as
+// "METHOD path" and for describing in the OpenAPI spec
+func (h *APIHandler) Routes() []APIRoute {
+	return []APIRoute{
+		{
+			Method: "GET", Path: "/api/v1/products", Summary: "상품 목록과 검색",
+			Query: []APIParam{
+				{Name: "q", Type: "string", Description: "검색어"},
+				{Name: "category", Type: "string", Description: "카테고리", Repeated: true},
+				{Name: "tag", Type: "string", Description: "태그", Repeated: true},
+				{Name: "min_price", Type: "string", Description: "최저 가격 (원)"},
+				{Name: "max_price", Type: "string", Description: "최고 가격 (원)"},
+				{Name: "sort", Type: "string", Description: "정렬: price_asc, price_desc, name, newest, popular"},
+				{Name: "offset", Type: "integer", Description: "건너뛸 상품 수"},
+				{Name: "cursor", Type: "string", Description: "이전 페이지의 nextCursor (있으면 offset 대신 사용)"},
+				{Name: "limit", Type: "integer", Description: "한 페이지의 상품 수 (1-100)"},
+			},
+			Status: http.StatusOK, Response: apiProductPage{},
+			Errors:    []int{http.StatusBadRequest},
+			RateLimit: "search",
+			Handler:   h.HandleProducts,
+		},
+		{
+			Method: "GET", Path: "/api/v1/products/{id}", Summary: "상품 상세",
+			Status: http.StatusOK, Response: models.Product{},
+			Errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+			Handler: h.HandleProduct,
+		},
+		{
+			Method: "GET", Path: "/api/v1/products/{id}/recommendations", Summary: "함께 볼 만한 상품",
+			Status: http.StatusOK, Response: []models.Product{},
+			Errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+			Handler: h.HandleRecommendations,
+		},
+		{
+			Method: "GET", Path: "/api/products/by-sku/{sku}", Summary: "SKU나 바코드로 상품 찾기 (관리자, 보관된 상품 포함)",
+			Status: http.StatusOK, Response: apiCodeMatch{},
+			Errors:  []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+			Handler: h.HandleFindSKU,
+		},
+		{
+			Method: "GET", Path: "/api/v1/skus/{sku}", Summary: "/api/products/by-sku/{sku}와 같음",
+			Status: http.StatusOK, Response: apiCodeMatch{},
+			Errors:  []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+			Handler: h.HandleFindSKU,
+		},
+		{
+			Method: "GET", Path: "/api/v1/categories", Summary: "카테고리 목록",
+			Status: http.StatusOK, Response: []string{},
+			Handler: h.HandleCategories,
+		},
+		{
+			Method: "GET", Path: "/api/v1/cart", Summary: "장바구니",
+			Status: http.StatusOK, Response: apiCart{},
+			Handler: h.HandleCart,
+		},
+		{
+			Method: "DELETE", Path: "/api/v1/cart", Summary: "장바구니 비우기",
+			Status: http.StatusOK, Response: apiCart{},
+			RateLimit: "cart",
+			Handler:   h.HandleClearCart,
+		},
+		{
+			Method: "POST", Path: "/api/v1/cart/items", Summary: "장바구니에 상품 담기",
+			Request: apiAddItem{}, Status: http.StatusOK, Response: apiCart{},
+			Errors:    []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+			RateLimit: "add_to_cart",
+			Handler:   h.HandleAddItem,
+		},
+		{
+			Method: "PUT", Path: "/api/v1/cart/items/{productID}", Summary: "장바구니 상품 수량 변경",
+			Query:   []APIParam{variantParam},
+			Request: apiSetQuantity{}, Status: http.StatusOK, Response: apiCart{},
+			Errors:    []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+			RateLimit: "cart",
+			Handler:   h.HandleSetQuantity,
+		},
+		{
+			Method: "DELETE", Path: "/api/v1/cart/items/{productID}", Summary: "장바구니에서 상품 빼기",
+			Query:  []APIParam{variantParam},
+			Status: http.StatusOK, Response: apiCart{},
+			Errors:    []int{http.StatusBadRequest},
+			RateLimit: "cart",
+			Handler:   h.HandleRemoveItem,
+		},
+		{
+			Method: "PUT", Path: "/api/v1/cart/coupon", Summary: "쿠폰 적용",
+			Request: apiApplyCoupon{}, Status: http.StatusOK, Response: apiCart{},
+			Errors:    []int{http.StatusBadRequest, http.StatusUnprocessableEntity},
+			RateLimit: "cart",
+			Handler:   h.HandleApplyCoupon,
+		},
+		{
+			Method: "DELETE", Path: "/api/v1/cart/coupon", Summary: "쿠폰 해제",
+			Status: http.StatusOK, Response: apiCart{},
+			RateLimit: "cart",
+			Handler:   h.HandleRemoveCoupon,
+		},
+		{
+			Method: "GET", Path: "/api/v1/checkout/quote", Summary: "배송비와 세금을 포함한 결제 금액",
+			Query: []APIParam{
+				{Name: "country", Type: "string", Description: "배송 국가 코드, 기본값 KR"},
+				{Name: "method", Type: "string", Description: "배송 방법, 기본값은 가장 저렴한 방법"},
+			},
+			Status: http.StatusOK, Response: apiQuote{},
+			Errors:  []int{http.StatusBadRequest},
+			Handler: h.HandleQuote,
+		},
+		{
+			Method: "POST", Path: "/api/v1/orders", Summary: "주문하기",
+			Request: apiPlaceOrder{}, Status: http.StatusCreated, Response: models.Order{},
+			Errors: []int{
+				http.StatusBadRequest, http.StatusPaymentRequired, http.StatusConflict,
+				http.StatusUnprocessableEntity, http.StatusBadGateway,
+			},
+			Handler: h.HandlePlaceOrder,
+		},
+		{
+			Method: "GET", Path: "/api/v1/orders", Summary: "로그인한 계정의 주문 목록",
+			Status: http.StatusOK, Response: []models.Order{},
+			Errors:  []int{http.StatusUnauthorized},
+			Handler: h.HandleOrders,
+		},
+		{
+			Method: "GET", Path: "/api/v1/orders/{id}", Summary: "주문 상세",
+			Status: http.StatusOK, Response: models.Order{},
+			Errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+			Handler: h.HandleOrder,
+		},
+		{
+			Method: "POST", Path: "/api/v1/orders/{id}/cancel", Summary: "주문 취소",
+			Status: http.StatusOK, Response: models.Order{},
+			Errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusBadGateway},
+			Handler: h.HandleCancelOrder,
+		},
+		{
+			Method: "GET", Path: "/api/v1/openapi.json", Summary: "이 API의 OpenAPI 명세",
+			Status: http.StatusOK, Response: map[string]any{},
+			Handler: h.HandleOpenAPI,
+		},
+	}
+}
+
+// HandleOpenAPI returns the OpenAPI description of the API
+func (h *APIHandler) HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, OpenAPISpec("Shop API", "1.0.0", h.Routes()))
+}
+
+// apiError is the body of every API error response
+type apiError struct {
+	// Error is a stable code for the problem, such as "insufficient_stock"
+	Error string `json:"error"`
+	// Message describes the problem to the customer, in the language the
+	// request asked for in Accept-Language
+	Message string `json:"message"`
+}
+
+// apiProductPage is a page of the product listing
+type apiProductPage struct {
+	Products []models.Product `json:"products"`
+	Offset   int              `json:"offset"`
+	Limit    int              `json:"limit"`
+	// Total is how many products the whole listing has
+	Total int `json:"total"`
+	// NextCursor asks for the page after this one, if there is one
+	NextCursor string `json:"nextCursor,omitempty"`
+	// Suggestions are searches to try when nothing matched the query
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// apiCodeMatch is the product a SKU or barcode belongs to
+type apiCodeMatch struct {
+	Product models.Product `json:"product"`
+	// VariantID and Variant, the variant's values, are set if the SKU is a
+	// variant's
+	VariantID int    `json:"variantId,omitempty"`
+	Variant   string `json:"variant,omitempty"`
+	// Stock is the variant's stock, or the product's
+	Stock int `json:"stock"`
+}
+
+// apiCart is the visitor's cart and what it comes to
+type apiCart struct {
+	ID        string            `json:"id"`
+	Items     []models.CartItem `json:"items"`
+	ItemCount int               `json:"itemCount"`
+	Subtotal  models.Money      `json:"subtotal"`
+	// Bundles are what the bundles put in the cart save on it
+	Bundles        []models.BundleLine `json:"bundles,omitempty"`
+	BundleDiscount models.Money        `json:"bundleDiscount,omitzero"`
+	// CouponCode is the coupon applied to the cart, if any
+	CouponCode string       `json:"couponCode,omitempty"`
+	Discount   models.Money `json:"discount"`
+	// Taxes are estimated for delivery within the country; the checkout
+	// quote has them for other destinations
+	Taxes models.TaxLines `json:"taxes"`
+	// Total is the subtotal after bundle savings and the discount, before
+	// shipping and taxes
+	Total models.Money `json:"total"`
+}
+
+// apiQuote is what the cart comes to shipped to a country
+type apiQuote struct {
+	Options  []models.ShippingOption `json:"options"`
+	Delivery models.ShippingOption   `json:"delivery"`
+	Taxes    models.TaxLines         `json:"taxes"`
+	// Total is what placing the order would charge
+	Total models.Money `json:"total"`
+}
+
+// apiAddItem asks for a product to be added to the cart
+type apiAddItem struct {
+	ProductID int `json:"productId"`
+	// VariantID is the variant to add, for products that come in variants
+	VariantID int `json:"variantId,omitempty"`
+	// Quantity is how many to add; zero adds one
+	Quantity int `json:"quantity,omitempty"`
+}
+
+// apiSetQuantity sets how many of a product the cart holds; zero removes it
+type apiSetQuantity struct {
+	Quantity int `json:"quantity"`
+}
+
+// apiApplyCoupon asks for a coupon to be applied to the cart
+type apiApplyCoupon struct {
+	Code string `json:"code"`
+}
+
+// apiPlaceOrder asks for the cart to be ordered
+type apiPlaceOrder struct {
+	Shipping models.ShippingInfo `json:"shipping"`
+	// Card is the card number to pay with
+	Card string `json:"card"`
+	// Points are the account's points to pay with, if any
+	Points int `json:"points,omitempty"`
+}
+
+// HandleProducts returns a page of products matching the search query and
+// filters, with the same parameters as /products plus limit
+func (h *APIHandler) HandleProducts(w http.ResponseWriter, r *http.Request) {
+	listing := parseListing(r, h.store.Facets())
+	limit := models.DefaultPageSize
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > maxAPIPageSize {
+			writeAPIError(w, http.StatusBadRequest, "invalid_limit", tr(r, "api.error.limit", maxAPIPageSize))
+			return
+		}
+	}
+
+	products := h.store.Filter(listing.Query, listing.Categories, listing.MinPrice, listing.MaxPrice, listing.Tags)
+	page, _, ok := listingPage(r, products, limit, listing.Sort)
+	if !ok {
+		writeAPIError(w, http.StatusBadRequest, "invalid_cursor", tr(r, "api.error.cursor"))
+		return
+	}
+	response := apiProductPage{
+		Products: page.Products,
+		Offset:   page.Offset,
+		Limit:    page.Limit,
+		Total:    page.Total,
+	}
+	if page.HasMore() {
+		response.NextCursor = page.NextCursor().String()
+	}
+	if response.Products == nil {
+		response.Products = []models.Product{}
+	}
+	if page.Total == 0 && listing.Query != "" {
+		response.Suggestions = h.store.DidYouMean(listing.Query)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleProduct returns a product. Archived ones are only shown to admins.
+func (h *APIHandler) HandleProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid_id", tr(r, "api.error.product_id"))
+		return
+	}
+
+	product, exists := h.store.GetByID(id)
+	user, _ := models.UserFromContext(r.Context())
+	if !exists || (product.Archived && !user.Admin) {
+		writeAPIError(w, http.StatusNotFound, "not_found", tr(r, "api.error.product_not_found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, product)
+}
+
+// HandleFindSKU returns the product with the SKU or barcode in the path,
+// archived or not, and the variant if the SKU is a variant's, for admins
+// counting or receiving stock
+func (h *APIHandler) HandleFindSKU(w http.ResponseWriter, r *http.Request) {
+	user, ok := models.UserFromContext(r.Context())
+	if !ok {
+		writeAPIError(w, http.StatusUnauthorized, "login_required", tr(r, "api.error.login"))
+		return
+	}
+	if !user.Admin {
+		writeAPIError(w, http.StatusForbidden, "admin_only", tr(r, "api.error.admin"))
+		return
+	}
+
+	product, key, ok := h.store.FindCode(r.PathValue("sku"))
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "not_found", tr(r, "api.error.sku_not_found"))
+		return
+	}
+	match := apiCodeMatch{Product: product, VariantID: key.VariantID, Stock: product.StockOf(key.VariantID)}
+	if variant, ok := product.Variant(key.VariantID); ok {
+		match.Variant = variant.Label()
+	}
+	writeJSON(w, http.StatusOK, match)
+}
+
+// HandleRecommendations returns the products a customer looking at a
+// product may also like, best matches first
+func (h *APIHandler) HandleRecommendations(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid_id", tr(r, "api.error.product_id"))
+		return
+	}
+	if _, exists := h.store.GetByID(id); !exists {
+		writeAPIError(w, http.StatusNotFound, "not_found", tr(r, "api.error.product_not_found"))
+		return
+	}
+
+	recommended := h.store.Recommend([]int{id}, h.orders.BoughtTogether(), recommendCount)
+	if recommended == nil {
+		recommended = []models.Product{}
+	}
+	writeJSON(w, http.StatusOK, recommended)
+}
+
+// HandleCategories returns the categories of the products on sale
+func (h *APIHandler) HandleCategories(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.store.GetCategories())
+}
+
+// HandleCart returns the visitor's cart
+func (h *APIHandler) HandleCart(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.cartResponse(requestCart(r)))
+}
+
+// HandleAddItem adds a product to the cart, or more of it if the cart
+// already has some
+func (h *APIHandler) HandleAddItem(w http.ResponseWriter, r *http.Request) {
+	var req apiAddItem
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+	if req.Quantity < 0 {
+		writeAPIError(w, http.StatusBadRequest, "invalid_quantity", tr(r, "api.error.quantity_add"))
+		return
+	}
+
+	cart := requestCart(r)
+	product, exists := h.store.GetByID(req.ProductID)
+	if !exists {
+		writeAPIError(w, http.StatusNotFound, "not_found", tr(r, "api.error.product_not_found"))
+		return
+	}
+	// Check stock for what the cart will hold, not just what is being added
+	key := models.ItemKey{ProductID: product.ID, VariantID: req.VariantID}
+	err := cart.AddItemChecked(product, key.VariantID, req.Quantity, func(total int) error {
+		return h.cart.reserve(cart, key, total)
+	})
+	if errors.Is(err, models.ErrNotSaved) {
+		// The cart is as it was, so hold only what it still has
+		h.cart.reserve(cart, key, cart.Quantity(key))
+	}
+	if err != nil {
+		h.writeStockError(w, r, err)
+		return
+	}
+	h.cart.Analytics.Track(r, models.Event{Kind: models.EventAddToCart, ProductID: product.ID, Quantity: req.Quantity})
+	writeJSON(w, http.StatusOK, h.cartResponse(cart))
+}
+
+// HandleSetQuantity sets how many of a product, or of the variant in the
+// query, the cart holds, removing it for zero
+func (h *APIHandler) HandleSetQuantity(w http.ResponseWriter, r *http.Request) {
+	key, ok := itemKey(w, r)
+	if !ok {
+		return
+	}
+	var req apiSetQuantity
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.Quantity < 0 {
+		writeAPIError(w, http.StatusBadRequest, "invalid_quantity", tr(r, "api.error.quantity"))
+		return
+	}
+
+	cart := requestCart(r)
+	if cart.Quantity(key) == 0 {
+		writeAPIError(w, http.StatusNotFound, "not_in_cart", tr(r, "api.error.not_in_cart"))
+		return
+	}
+	err := cart.UpdateQuantityChecked(key, req.Quantity, func(total int) error {
+		return h.cart.reserve(cart, key, total)
+	})
+	if errors.Is(err, models.ErrNotSaved) {
+		h.cart.reserve(cart, key, cart.Quantity(key))
+	}
+	if err != nil {
+		h.writeStockError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.cartResponse(cart))
+}
+
+// HandleRemoveItem takes a product, or the variant in the query, out of
+// the cart
+func (h *APIHandler) HandleRemoveItem(w http.ResponseWriter, r *http.Request) {
+	key, ok := itemKey(w, r)
+	if !ok {
+		return
+	}
+
+	cart := requestCart(r)
+	if err := cart.RemoveItem(key); err != nil {
+		writeSaveError(w, r, err)
+		return
+	}
+	h.store.Release(cart.ID, key)
+	writeJSON(w, http.StatusOK, h.cartResponse(cart))
+}
+
+// HandleClearCart empties the cart
+func (h *APIHandler) HandleClearCart(w http.ResponseWriter, r *http.Request) {
+	cart := requestCart(r)
+	if err := cart.Clear(); err != nil {
+		writeSaveError(w, r, err)
+		return
+	}
+	h.store.ReleaseAll(cart.ID)
+	writeJSON(w, http.StatusOK, h.cartResponse(cart))
+}
+
+// HandleApplyCoupon applies a coupon to the cart
+func (h *APIHandler) HandleApplyCoupon(w http.ResponseWriter, r *http.Request) {
+	var req apiApplyCoupon
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	cart := requestCart(r)
+	coupon, err := h.coupons.Validate(req.Code, cart.TotalAfterBundles())
+	if err != nil {
+		writeAPIError(w, http.StatusUnprocessableEntity, couponErrorCode(err), couponErrorMessage(r, err))
+		return
+	}
+
+	if err := cart.ApplyCoupon(coupon); err != nil {
+		writeSaveError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.cartResponse(cart))
+}
+
+// HandleRemoveCoupon takes the coupon off the cart
+func (h *APIHandler) HandleRemoveCoupon(w http.ResponseWriter, r *http.Request) {
+	cart := requestCart(r)
+	if err := cart.RemoveCoupon(); err != nil {
+		writeSaveError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.cartResponse(cart))
+}
+
+// HandleQuote returns the shipping options for the cart to the country in
+// the query, and the taxes and total for the chosen method or the default
+func (h *APIHandler) HandleQuote(w http.ResponseWriter, r *http.Request) {
+	cart := requestCart(r)
+	shipping := models.ShippingInfo{Country: r.URL.Query().Get("country")}
+	if !slices.Contains(models.ShippingCountries, shipping.Destination()) {
+		writeAPIError(w, http.StatusBadRequest, "unsupported_country", tr(r, "shipping.error.country"))
+		return
+	}
+
+	options, delivery := h.checkout.shippingOptions(cart, shipping.Destination(), r.URL.Query().Get("method"))
+	taxes := h.checkout.taxes.ForCart(cart, shipping.Destination())
+	h.checkout.Analytics.Track(r, models.Event{Kind: models.EventCheckout})
+	writeJSON(w, http.StatusOK, apiQuote{
+		Options:  options,
+		Delivery: delivery,
+		Taxes:    taxes,
+		Total:    cart.TotalAfterDiscount().Add(delivery.Fee).Add(taxes.Total()),
+	})
+}
+
+// HandlePlaceOrder orders the cart, as the checkout page does, and returns
+// the order
+func (h *APIHandler) HandlePlaceOrder(w http.ResponseWriter, r *http.Request) {
+	var req apiPlaceOrder
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	order, err := h.checkout.placeOrder(r.Context(), requestCart(r), req.Shipping, req.Card, req.Points)
+	var stockErr *models.InsufficientStockError
+	switch {
+	case err == nil:
+		h.checkout.Analytics.Track(r, models.Event{Kind: models.EventPurchase, OrderID: order.ID})
+		w.Header().Set("Location", fmt.Sprintf("/api/v1/orders/%d", order.ID))
+		writeJSON(w, http.StatusCreated, order)
+	case errors.Is(err, models.ErrEmptyCart):
+		writeAPIError(w, http.StatusConflict, "empty_cart", tr(r, "api.error.empty_cart"))
+	case errors.Is(err, models.ErrUnsupportedCountry):
+		writeAPIError(w, http.StatusUnprocessableEntity, "unsupported_country", tr(r, "shipping.error.country"))
+	case errors.Is(err, models.ErrShippingIncomplete):
+		writeAPIError(w, http.StatusUnprocessableEntity, "shipping_incomplete", tr(r, "shipping.error.incomplete"))
+	case errors.Is(err, models.ErrInvalidEmail):
+		writeAPIError(w, http.StatusUnprocessableEntity, "invalid_email", tr(r, "checkout.error.email"))
+	case errors.Is(err, models.ErrGuestEmailRequired):
+		writeAPIError(w, http.StatusUnprocessableEntity, "email_required", tr(r, "api.error.guest_email"))
+	case errors.Is(err, errMissingCard):
+		writeAPIError(w, http.StatusUnprocessableEntity, "missing_card", tr(r, "checkout.error.card"))
+	case errors.Is(err, models.ErrShippingUnavailable):
+		writeAPIError(w, http.StatusUnprocessableEntity, "shipping_unavailable", tr(r, "shipping.error.method"))
+	case errors.Is(err, models.ErrInvalidPoints):
+		writeAPIError(w, http.StatusUnprocessableEntity, "invalid_points", tr(r, "api.error.points"))
+	case errors.Is(err, models.ErrInsufficientPoints):
+		writeAPIError(w, http.StatusUnprocessableEntity, "insufficient_points", tr(r, "checkout.error.points_balance"))
+	case errors.Is(err, errCouponRedeem):
+		writeAPIError(w, http.StatusConflict, couponErrorCode(err), tr(r, "checkout.error.coupon", couponErrorMessage(r, err)))
+	case errors.As(err, &stockErr):
+		writeAPIError(w, http.StatusConflict, "insufficient_stock", tr(r, "api.error.stock", stockErr.Name, stockErr.Available))
+	case errors.Is(err, models.ErrProductNotFound):
+		writeAPIError(w, http.StatusConflict, "product_unavailable", tr(r, "checkout.error.unavailable"))
+	case errors.Is(err, models.ErrPaymentDeclined):
+		writeAPIError(w, http.StatusPaymentRequired, "payment_declined", tr(r, "checkout.error.declined"))
+	case errors.Is(err, models.ErrNotSaved):
+		writeSaveError(w, r, err)
+	default:
+		log.Printf("place order: %v", err)
+		writeAPIError(w, http.StatusBadGateway, "payment_failed", tr(r, "checkout.error.payment"))
+	}
+}
+
+// HandleOrders returns the logged-in account's orders, newest first
+func (h *APIHandler) HandleOrders(w http.ResponseWriter, r *http.Request) {
+	user, ok := models.UserFromContext(r.Context())
+	if !ok {
+		writeAPIError(w, http.StatusUnauthorized, "login_required", tr(r, "api.error.login"))
+		return
+	}
+	writeJSON(w, http.StatusOK, h.orders.ByUser(user.ID))
+}
+
+// HandleOrder returns an order the visitor can see
+func (h *APIHandler) HandleOrder(w http.ResponseWriter, r *http.Request) {
+	order, ok := h.findOrder(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, order)
+}
+
+// HandleCancelOrder calls off an order that hasn't shipped, as the order
+// page does, and returns it
+func (h *APIHandler) HandleCancelOrder(w http.ResponseWriter, r *http.Request) {
+	order, ok := h.findOrder(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := h.order.cancel(r.Context(), order)
+	if errors.Is(err, models.ErrOrderStatus) {
+		writeAPIError(w, http.StatusConflict, "not_cancellable", tr(r, "order.error.shipped"))
+		return
+	} else if errors.Is(err, models.ErrNotSaved) {
+		writeSaveError(w, r, err)
+		return
+	} else if err != nil {
+		log.Printf("cancel order %d: %v", order.ID, err)
+		writeAPIError(w, http.StatusBadGateway, "payment_failed", tr(r, "order.error.cancel_payment"))
+		return
+	}
+	writeJSON(w, http.StatusOK, order)
+}
+
+// findOrder finds the order in the path, writing an error if there is none
+// or the visitor can't see it
+func (h *APIHandler) findOrder(w http.ResponseWriter, r *http.Request) (models.Order, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid_id", tr(r, "api.error.order_id"))
+		return models.Order{}, false
+	}
+
+	order, exists := h.orders.GetByID(id)
+	if !exists || !canSeeOrder(r, order) {
+		writeAPIError(w, http.StatusNotFound, "not_found", tr(r, "api.error.order_not_found"))
+		return models.Order{}, false
+	}
+	return order, true
+}
+
+// writeStockError writes the error for stock that couldn't be held for the
+// cart
+func (h *APIHandler) writeStockError(w http.ResponseWriter, r *http.Request, err error) {
+	var stockErr *models.InsufficientStockError
+	switch {
+	case errors.As(err, &stockErr):
+		writeAPIError(w, http.StatusConflict, "insufficient_stock", tr(r, "api.error.stock", stockErr.Name, stockErr.Available))
+	case errors.Is(err, models.ErrVariantRequired):
+		writeAPIError(w, http.StatusUnprocessableEntity, "variant_required", tr(r, "api.error.variant"))
+	case errors.Is(err, models.ErrProductNotFound):
+		writeAPIError(w, http.StatusNotFound, "not_found", tr(r, "api.error.product_not_found"))
+	case errors.Is(err, models.ErrNotSaved):
+		writeSaveError(w, r, err)
+	default:
+		writeAPIError(w, http.StatusInternalServerError, "internal", err.Error())
+	}
+}
+
+// itemKey reads the product in the path and the variant in the query, if
+// there is one, writing an error if either isn't a number
+func itemKey(w http.ResponseWriter, r *http.Request) (models.ItemKey, bool) {
+	var key models.ItemKey
+	var err error
+	key.ProductID, err = strconv.Atoi(r.PathValue("productID"))
+	if value := r.URL.Query().Get("variant"); err == nil && value != "" {
+		key.VariantID, err = strconv.Atoi(value)
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid_id", tr(r, "api.error.item_id"))
+		return key, false
+	}
+	return key, true
+}
+
+// cartResponse returns the cart as the API shows it
+func (h *APIHandler) cartResponse(cart *models.Cart) apiCart {
+	response := apiCart{
+		ID:        cart.ID,
+		Items:     cart.GetItems(),
+		ItemCount: cart.GetItemCount(),
+		Subtotal:  cart.Total,
+		Bundles:   cart.BundleLines(),
+		Discount:  cart.Discount(),
+		Taxes:     h.cart.estimateTaxes(cart),
+		Total:     cart.TotalAfterDiscount(),
+	}
+	response.BundleDiscount = models.BundleSavings(response.Bundles)
+	if response.Items == nil {
+		response.Items = []models.CartItem{}
+	}
+	if coupon, ok := cart.GetCoupon(); ok {
+		response.CouponCode = coupon.Code
+	}
+	return response
+}
+
+// couponErrorCode is the API error code for why a coupon can't be used
+func couponErrorCode(err error) string {
+	switch {
+	case errors.Is(err, models.ErrCouponNotFound):
+		return "coupon_not_found"
+	case errors.Is(err, models.ErrCouponExpired):
+		return "coupon_expired"
+	case errors.Is(err, models.ErrCouponUsedUp):
+		return "coupon_used_up"
+	case errors.Is(err, models.ErrCouponMinOrder):
+		return "coupon_min_order"
+	default:
+		return "coupon_invalid"
+	}
+}
+
+// readJSON decodes the request body into v, writing an error and
+// returning false if it isn't valid JSON for it
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	decoder := json.NewDecoder(io.LimitReader(r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid_body", tr(r, "api.error.body", err))
+		return false
+	}
+	return true
+}
+
+// writeJSON writes v as the JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("write JSON response: %v", err)
+	}
+}
+
+// writeAPIError writes an API error response
+func writeAPIError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, apiError{Error: code, Message: message})
+}
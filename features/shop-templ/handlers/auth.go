@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/homveloper/doodle/features/shop-templ/htmxkit"
+	"github.com/homveloper/doodle/features/shop-templ/models"
+	"github.com/homveloper/doodle/features/shop-templ/templates"
+)
+
+const (
+	// sessionCookieName is the cookie holding the login session token
+	sessionCookieName = "shop_session"
+	// cartCookieName is the cookie holding a visitor's cart token
+	cartCookieName = "shop_cart"
+	// cartCookieTTL is how long a visitor's cart is remembered between visits
+	cartCookieTTL = 30 * 24 * time.Hour
+)
+
+type AuthHandler struct {
+	store    *models.ProductStore
+	users    *models.UserStore
+	sessions *models.SessionStore
+	carts    *models.CartStore
+	orders   *models.OrderStore
+	// ReserveFor is how long stock merged into an account's cart at login
+	// is held for it. It should match the cart handler's.
+	ReserveFor time.Duration
+}
+
+func NewAuthHandler(store *models.ProductStore, users *models.UserStore, sessions *models.SessionStore, carts *models.CartStore, orders *models.OrderStore) *AuthHandler {
+	return &AuthHandler{
+		store:    store,
+		users:    users,
+		sessions: sessions,
+		carts:    carts,
+		orders:   orders,
+	}
+}
+
+// LoadSession attaches the logged-in user, if any, and their cart to every
+// request context. Visitors who aren't logged in get a cart of their own,
+// found again through a cookie.
+func (h *AuthHandler) LoadSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var cart *models.Cart
+		if user, ok := h.sessionUser(r); ok {
+			ctx = models.ContextWithUser(ctx, user)
+			cart = h.carts.Get(models.UserCartID(user.ID))
+		} else {
+			cart = h.carts.Get(models.GuestCartID(h.guestToken(w, r)))
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, cartContextKey{}, cart)))
+	})
+}
+
+// RequireAuth only lets logged-in users through, sending everyone else to /login
+func (h *AuthHandler) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := models.UserFromContext(r.Context()); ok {
+			next(w, r)
+			return
+		}
+
+		htmxkit.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()))
+	}
+}
+
+// RequireAdmin only lets admins through. Everyone else is sent to log in
+// as RequireAuth does, or refused if they already are.
+func (h *AuthHandler) RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return h.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		if user, _ := models.UserFromContext(r.Context()); !user.Admin {
+			http.Error(w, "Admins only", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	})
+}
+
+// HandleLoginForm renders the login page
+func (h *AuthHandler) HandleLoginForm(w http.ResponseWriter, r *http.Request) {
+	h.renderLogin(w, r, http.StatusOK, "", "")
+}
+
+// HandleLogin checks credentials and starts a session
+func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	email := r.FormValue("email")
+
+	user, err := h.users.Authenticate(email, r.FormValue("password"))
+	if err != nil {
+		h.renderLogin(w, r, http.StatusUnauthorized, email, tr(r, "account.error.credentials"))
+		return
+	}
+
+	h.startSession(w, r, user)
+	http.Redirect(w, r, safeNext(r.FormValue("next")), http.StatusSeeOther)
+}
+
+// HandleRegisterForm renders the registration page
+func (h *AuthHandler) HandleRegisterForm(w http.ResponseWriter, r *http.Request) {
+	h.renderRegister(w, r, http.StatusOK, "", "", "")
+}
+
+// HandleRegister creates an account and logs it in
+func (h *AuthHandler) HandleRegister(w http.ResponseWriter, r *http.Request) {
+	email := r.FormValue("email")
+	name := r.FormValue("name")
+
+	user, err := h.users.Register(email, name, r.FormValue("password"))
+	switch {
+	case errors.Is(err, models.ErrInvalidEmail):
+		h.renderRegister(w, r, http.StatusUnprocessableEntity, email, name, tr(r, "account.error.email"))
+		return
+	case errors.Is(err, models.ErrPasswordTooShort):
+		h.renderRegister(w, r, http.StatusUnprocessableEntity, email, name, tr(r, "account.error.password"))
+		return
+	case errors.Is(err, models.ErrUserExists):
+		h.renderRegister(w, r, http.StatusConflict, email, name, tr(r, "account.error.exists"))
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.startSession(w, r, user)
+	http.Redirect(w, r, safeNext(r.FormValue("next")), http.StatusSeeOther)
+}
+
+// HandleOrderAccount turns a guest's order into an account: it registers
+// the email the order was placed with, moves the order to the new account
+// and logs it in. Only a visitor who can see the order can do this.
+func (h *AuthHandler) HandleOrderAccount(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+	order, exists := h.orders.GetByID(id)
+	if !exists || !canSeeOrder(r, order) {
+		http.Error(w, "Order not found", http.StatusNotFound)
+		return
+	}
+	if !order.IsGuest() {
+		h.renderOrder(w, r, http.StatusConflict, order, tr(r, "order.account.error.linked"))
+		return
+	}
+	if _, loggedIn := models.UserFromContext(r.Context()); loggedIn {
+		h.renderOrder(w, r, http.StatusConflict, order, tr(r, "order.account.error.logged_in"))
+		return
+	}
+
+	user, err := h.users.Register(order.Shipping.Email, r.FormValue("name"), r.FormValue("password"))
+	switch {
+	case errors.Is(err, models.ErrInvalidEmail):
+		h.renderOrder(w, r, http.StatusUnprocessableEntity, order, tr(r, "order.account.error.email"))
+		return
+	case errors.Is(err, models.ErrPasswordTooShort):
+		h.renderOrder(w, r, http.StatusUnprocessableEntity, order, tr(r, "account.error.password"))
+		return
+	case errors.Is(err, models.ErrUserExists):
+		h.renderOrder(w, r, http.StatusConflict, order, tr(r, "order.account.error.exists"))
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := h.orders.AssignUser(order.ID, user.ID); err != nil {
+		// The account is made either way, so the guest can still log in
+		log.Printf("move order %d to user %d: %v", order.ID, user.ID, err)
+	}
+	h.startSession(w, r, user)
+	http.Redirect(w, r, fmt.Sprintf("/orders/%d", order.ID), http.StatusSeeOther)
+}
+
+// HandleLogout ends the current session
+func (h *AuthHandler) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		h.sessions.Delete(cookie.Value)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// HandleAccount renders the logged-in user's account page with their orders
+func (h *AuthHandler) HandleAccount(w http.ResponseWriter, r *http.Request) {
+	user, _ := models.UserFromContext(r.Context())
+
+	component := templates.Page(tr(r, "account.title"), requestCart(r), templates.AccountPage(user, h.orders.ByUser(user.ID)))
+	err := component.Render(r.Context(), w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// startSession logs a user in by setting the session cookie. What the
+// visitor put in their cart before logging in moves to the account's cart.
+func (h *AuthHandler) startSession(w http.ResponseWriter, r *http.Request, user models.User) {
+	if _, loggedIn := models.UserFromContext(r.Context()); !loggedIn {
+		guest := requestCart(r)
+		if err := h.store.MergeCart(guest, h.carts.Get(models.UserCartID(user.ID)), h.ReserveFor); err != nil {
+			// What didn't move stays in the guest's cart
+			slog.Error("merge cart", "cart", guest.ID, "user", user.ID, "error", err)
+		} else if err := h.carts.Delete(guest.ID); err != nil {
+			slog.Warn("delete merged cart", "cart", guest.ID, "error", err)
+		}
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    h.sessions.Create(user.ID),
+		Path:     "/",
+		MaxAge:   int(models.SessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// sessionUser resolves the session cookie to a user
+func (h *AuthHandler) sessionUser(r *http.Request) (models.User, bool) {
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return models.User{}, false
+	}
+
+	userID, ok := h.sessions.Get(cookie.Value)
+	if !ok {
+		return models.User{}, false
+	}
+
+	return h.users.GetByID(userID)
+}
+
+// guestToken returns the visitor's cart token, handing out a new one in a
+// cookie if they don't have one yet
+func (h *AuthHandler) guestToken(w http.ResponseWriter, r *http.Request) string {
+	if cookie, err := r.Cookie(cartCookieName); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+
+	token := models.NewCart().ID
+	http.SetCookie(w, &http.Cookie{
+		Name:     cartCookieName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(cartCookieTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return token
+}
+
+func (h *AuthHandler) renderLogin(w http.ResponseWriter, r *http.Request, status int, email string, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	templates.Page(tr(r, "account.login"), requestCart(r), templates.LoginPage(safeNext(r.FormValue("next")), email, message)).Render(r.Context(), w)
+}
+
+func (h *AuthHandler) renderRegister(w http.ResponseWriter, r *http.Request, status int, email string, name string, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	templates.Page(tr(r, "account.register"), requestCart(r), templates.RegisterPage(safeNext(r.FormValue("next")), email, name, message)).Render(r.Context(), w)
+}
+
+// renderOrder writes the order page with the given status and why the
+// order couldn't be turned into an account
+func (h *AuthHandler) renderOrder(w http.ResponseWriter, r *http.Request, status int, order models.Order, message string) {
+	user, _ := models.UserFromContext(r.Context())
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	templates.Page(tr(r, "order.title"), requestCart(r), templates.OrderConfirmation(order, user.Admin, message)).Render(r.Context(), w)
+}
+
+type cartContextKey struct{}
+
+// requestCart returns the cart LoadSession attached to the request, or an
+// empty one if there is none
+func requestCart(r *http.Request) *models.Cart {
+	if cart, ok := r.Context().Value(cartContextKey{}).(*models.Cart); ok {
+		return cart
+	}
+	return models.NewCart()
+}
+
+// safeNext only allows local redirect targets, defaulting to the home page
+func safeNext(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+	"github.com/homveloper/doodle/features/shop-templ/templates"
+)
+
+type BundleHandler struct {
+	store   *models.ProductStore
+	bundles *models.BundleStore
+}
+
+func NewBundleHandler(store *models.ProductStore, bundles *models.BundleStore) *BundleHandler {
+	return &BundleHandler{store: store, bundles: bundles}
+}
+
+// HandleBundles renders every bundle with the form for adding one
+func (h *BundleHandler) HandleBundles(w http.ResponseWriter, r *http.Request) {
+	h.render(w, r, http.StatusOK, "")
+}
+
+// HandleCreateBundle adds a bundle from the form, then goes back to the
+// bundle list
+func (h *BundleHandler) HandleCreateBundle(w http.ResponseWriter, r *http.Request) {
+	bundle, message := bundleFromForm(r)
+	if message == "" {
+		if _, err := h.bundles.Add(bundle); errors.Is(err, models.ErrInvalidBundle) {
+			message = "이름, 가격과 서로 다른 상품 두 개 이상을 입력해주세요"
+		} else if err != nil {
+			message = "묶음을 등록할 수 없습니다"
+		}
+	}
+	if message != "" {
+		h.render(w, r, http.StatusUnprocessableEntity, message)
+		return
+	}
+	http.Redirect(w, r, "/admin/bundles", http.StatusSeeOther)
+}
+
+// HandleDeleteBundle removes a bundle. Carts it is in keep its products.
+func (h *BundleHandler) HandleDeleteBundle(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid bundle ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.bundles.Delete(id); err != nil {
+		http.Error(w, "Bundle not found", http.StatusNotFound)
+		return
+	}
+	http.Redirect(w, r, "/admin/bundles", http.StatusSeeOther)
+}
+
+// render writes the bundle list with the given status
+func (h *BundleHandler) render(w http.ResponseWriter, r *http.Request, status int, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	templates.Page("묶음 상품 관리", requestCart(r), templates.AdminBundlesPage(h.bundles.GetAll(), h.store.GetAll(), message)).Render(r.Context(), w)
+}
+
+// bundleFromForm reads a bundle from the admin form. Each of its rows
+// pairs an item, "<product id>:<variant id>", with a quantity; rows with
+// no item chosen are skipped. The message explains what could not be
+// read, if anything.
+func bundleFromForm(r *http.Request) (models.Bundle, string) {
+	r.ParseForm()
+	bundle := models.Bundle{Name: strings.TrimSpace(r.FormValue("name"))}
+
+	price, err := models.ParseMoney(r.FormValue("price"), models.DefaultCurrency)
+	if err != nil {
+		return bundle, "묶음 가격을 원 단위 숫자로 입력해주세요"
+	}
+	bundle.Price = price
+
+	quantities := r.Form["quantity"]
+	for i, value := range r.Form["item"] {
+		if value == "" {
+			continue
+		}
+		var item models.BundleItem
+		if _, err := fmt.Sscanf(value, "%d:%d", &item.ProductID, &item.VariantID); err != nil {
+			return bundle, "묶을 상품을 선택해주세요"
+		}
+		if i >= len(quantities) {
+			return bundle, "상품마다 수량을 입력해주세요"
+		}
+		if item.Quantity, err = strconv.Atoi(quantities[i]); err != nil {
+			return bundle, "수량을 숫자로 입력해주세요"
+		}
+		bundle.Items = append(bundle.Items, item)
+	}
+	return bundle, ""
+}
@@ -1,40 +1,77 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/homveloper/doodle/features/shop-templ/components"
+	"github.com/homveloper/doodle/features/shop-templ/htmxkit"
 	"github.com/homveloper/doodle/features/shop-templ/models"
 	"github.com/homveloper/doodle/features/shop-templ/templates"
 )
 
+// cartChangedEvent is fired on the page whenever the cart changes, for
+// what shows the cart outside the drawer, such as the checkout summary,
+// to refresh
+const cartChangedEvent = "cart-changed"
+
 type CartHandler struct {
-	store *models.ProductStore
-	cart  *models.Cart
+	store   *models.ProductStore
+	orders  *models.OrderStore
+	coupons *models.CouponStore
+	bundles *models.BundleStore
+	taxes   models.TaxRules
+	// ReserveFor is how long stock put in the cart is held for it. Zero
+	// only checks stock, leaving it to be taken at checkout.
+	ReserveFor time.Duration
+	// Analytics records what is put in the cart, if set
+	Analytics *Analytics
 }
 
-func NewCartHandler(store *models.ProductStore, cart *models.Cart) *CartHandler {
+func NewCartHandler(store *models.ProductStore, orders *models.OrderStore, coupons *models.CouponStore, bundles *models.BundleStore, taxes models.TaxRules) *CartHandler {
 	return &CartHandler{
-		store: store,
-		cart:  cart,
+		store:   store,
+		orders:  orders,
+		coupons: coupons,
+		bundles: bundles,
+		taxes:   taxes,
 	}
 }
 
 // HandleCart renders the cart drawer
 func (h *CartHandler) HandleCart(w http.ResponseWriter, r *http.Request) {
-	component := templates.CartDrawer(h.cart)
-	err := component.Render(r.Context(), w)
+	cart := requestCart(r)
+	err := htmxkit.NewResponse().Swap(templates.CartDrawer(cart, h.estimateTaxes(cart))).Write(w, r)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
-// HandleAddToCart adds a product to the cart
+// HandleRecommendations renders the products a customer may also like
+// given what is in their cart. The drawer loads it after it opens.
+func (h *CartHandler) HandleRecommendations(w http.ResponseWriter, r *http.Request) {
+	var productIDs []int
+	for _, item := range requestCart(r).GetItems() {
+		productIDs = append(productIDs, item.Product.ID)
+	}
+
+	recommended := h.store.Recommend(productIDs, h.orders.BoughtTogether(), recommendCount)
+	err := htmxkit.NewResponse().Swap(templates.Recommendations(recommended)).Write(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// HandleAddToCart adds a product to the cart. The posted form has the
+// product_id and may have a quantity. For products with variants it also
+// has the chosen value of each option, in order, or a variant_id.
 func (h *CartHandler) HandleAddToCart(w http.ResponseWriter, r *http.Request) {
-	productIDStr := r.URL.Query().Get("product_id")
-	quantityStr := r.URL.Query().Get("quantity")
+	cart := requestCart(r)
+	quantityStr := r.PostFormValue("quantity")
 
-	productID, err := strconv.Atoi(productIDStr)
+	key, err := parseItemKey(r)
 	if err != nil {
 		http.Error(w, "Invalid product ID", http.StatusBadRequest)
 		return
@@ -49,34 +86,123 @@ func (h *CartHandler) HandleAddToCart(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	product, exists := h.store.GetByID(productID)
+	product, exists := h.store.GetByID(key.ProductID)
 	if !exists {
 		http.Error(w, "Product not found", http.StatusNotFound)
 		return
 	}
+	if values := r.PostForm["option"]; key.VariantID == 0 && len(values) > 0 {
+		variant, ok := product.FindVariant(values)
+		if !ok {
+			http.Error(w, "This combination of options isn't sold", http.StatusBadRequest)
+			return
+		}
+		key.VariantID = variant.ID
+	}
 
-	// Check stock
-	if product.Stock < quantity {
-		http.Error(w, "Insufficient stock", http.StatusBadRequest)
+	// Check stock for what the cart will hold, not just what is being added
+	err = cart.AddItemChecked(product, key.VariantID, quantity, func(total int) error {
+		return h.reserve(cart, key, total)
+	})
+	if errors.Is(err, models.ErrNotSaved) {
+		// The cart is as it was, so hold only what it still has
+		h.reserve(cart, key, cart.Quantity(key))
+	}
+	if err != nil {
+		h.writeStockError(w, r, cart, err)
 		return
 	}
+	h.Analytics.Track(r, models.Event{Kind: models.EventAddToCart, ProductID: product.ID, Quantity: quantity})
 
-	h.cart.AddItem(product, quantity)
+	h.writeChange(w, r, cart, false, tr(r, "cart.toast.added", product.Name))
+}
+
+// HandleAddBundle puts a bundle in the cart: each of its products goes in
+// as an item, with stock held for all of them or none. The quantity, how
+// many of the bundle, may come from the posted form.
+func (h *CartHandler) HandleAddBundle(w http.ResponseWriter, r *http.Request) {
+	cart := requestCart(r)
 
-	// Return updated cart badge with OOB swap
-	component := templates.CartBadge(h.cart.GetItemCount())
-	err = component.Render(r.Context(), w)
+	id, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Invalid bundle ID", http.StatusBadRequest)
+		return
+	}
+	quantity := 1
+	if value := r.FormValue("quantity"); value != "" {
+		quantity, err = strconv.Atoi(value)
+		if err != nil || quantity < 1 {
+			http.Error(w, "Invalid quantity", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// A bundle with a product no longer sold can't be put in
+	bundle, exists := h.bundles.GetByID(id)
+	offer, sold := h.store.BundleOffer(bundle)
+	if !exists || !sold {
+		http.Error(w, "Bundle not found", http.StatusNotFound)
+		return
 	}
+
+	held := make(map[models.ItemKey]int)
+	for _, item := range bundle.Items {
+		key := item.Key()
+		before := cart.Quantity(key)
+		if err := h.reserve(cart, key, before+item.Quantity*quantity); err != nil {
+			// Give back what was held for the bundle's other products
+			for key, before := range held {
+				h.reserve(cart, key, before)
+			}
+			h.writeStockError(w, r, cart, err)
+			return
+		}
+		held[key] = before
+	}
+
+	if err := cart.AddBundle(bundle, offer.Products, quantity); err != nil {
+		for key, before := range held {
+			h.reserve(cart, key, before)
+		}
+		writeSaveError(w, r, err)
+		return
+	}
+	h.Analytics.Track(r, models.Event{Kind: models.EventAddToCart, BundleID: bundle.ID, Quantity: quantity})
+
+	h.writeChange(w, r, cart, false, tr(r, "cart.toast.added", bundle.Name))
+}
+
+// HandleRemoveBundle takes a bundle out of the cart along with the
+// products it put in
+func (h *CartHandler) HandleRemoveBundle(w http.ResponseWriter, r *http.Request) {
+	cart := requestCart(r)
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid bundle ID", http.StatusBadRequest)
+		return
+	}
+
+	left, err := cart.RemoveBundle(id)
+	if err != nil {
+		writeSaveError(w, r, err)
+		return
+	}
+	for key, quantity := range left {
+		// Holding less can't fail
+		h.reserve(cart, key, quantity)
+	}
+
+	h.writeChange(w, r, cart, true, tr(r, "cart.toast.removed"))
 }
 
-// HandleUpdateCart updates the quantity of a product in the cart
+// HandleUpdateCart updates the quantity of a product or variant in the
+// cart to the posted quantity
 func (h *CartHandler) HandleUpdateCart(w http.ResponseWriter, r *http.Request) {
-	productIDStr := r.URL.Query().Get("product_id")
-	quantityStr := r.URL.Query().Get("quantity")
+	cart := requestCart(r)
+	quantityStr := r.PostFormValue("quantity")
 
-	productID, err := strconv.Atoi(productIDStr)
+	key, err := parseItemKey(r)
 	if err != nil {
 		http.Error(w, "Invalid product ID", http.StatusBadRequest)
 		return
@@ -88,53 +214,190 @@ func (h *CartHandler) HandleUpdateCart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check stock if increasing quantity
-	if quantity > 0 {
-		product, exists := h.store.GetByID(productID)
-		if exists && product.Stock < quantity {
-			http.Error(w, "Insufficient stock", http.StatusBadRequest)
-			return
-		}
+	err = cart.UpdateQuantityChecked(key, quantity, func(total int) error {
+		return h.reserve(cart, key, total)
+	})
+	if errors.Is(err, models.ErrNotSaved) {
+		h.reserve(cart, key, cart.Quantity(key))
 	}
-
-	h.cart.UpdateQuantity(productID, quantity)
-
-	// Return updated cart drawer
-	component := templates.CartDrawer(h.cart)
-	err = component.Render(r.Context(), w)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.writeStockError(w, r, cart, err)
+		return
 	}
+
+	h.writeChange(w, r, cart, true, "")
 }
 
-// HandleRemoveFromCart removes a product from the cart
+// HandleRemoveFromCart removes a product or variant from the cart
 func (h *CartHandler) HandleRemoveFromCart(w http.ResponseWriter, r *http.Request) {
-	productIDStr := r.URL.Query().Get("product_id")
+	cart := requestCart(r)
 
-	productID, err := strconv.Atoi(productIDStr)
+	key, err := parseItemKey(r)
 	if err != nil {
 		http.Error(w, "Invalid product ID", http.StatusBadRequest)
 		return
 	}
 
-	h.cart.RemoveItem(productID)
-
-	// Return updated cart drawer
-	component := templates.CartDrawer(h.cart)
-	err = component.Render(r.Context(), w)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := cart.RemoveItem(key); err != nil {
+		writeSaveError(w, r, err)
+		return
 	}
+	h.store.Release(cart.ID, key)
+
+	h.writeChange(w, r, cart, true, tr(r, "cart.toast.removed"))
 }
 
 // HandleClearCart clears all items from the cart
 func (h *CartHandler) HandleClearCart(w http.ResponseWriter, r *http.Request) {
-	h.cart.Clear()
+	cart := requestCart(r)
+	if err := cart.Clear(); err != nil {
+		writeSaveError(w, r, err)
+		return
+	}
+	h.store.ReleaseAll(cart.ID)
+
+	h.writeChange(w, r, cart, true, tr(r, "cart.toast.cleared"))
+}
+
+// HandleApplyCoupon applies a coupon code to the cart
+func (h *CartHandler) HandleApplyCoupon(w http.ResponseWriter, r *http.Request) {
+	cart := requestCart(r)
 
-	// Return updated cart drawer
-	component := templates.CartDrawer(h.cart)
-	err := component.Render(r.Context(), w)
+	coupon, err := h.coupons.Validate(r.FormValue("code"), cart.TotalAfterBundles())
 	if err != nil {
+		// The drawer shows why, so the swap still happens
+		htmxkit.NewResponse().Swap(templates.CartDrawerWithCouponError(cart, h.estimateTaxes(cart), couponErrorMessage(r, err))).Write(w, r)
+		return
+	}
+
+	if err := cart.ApplyCoupon(coupon); err != nil {
+		writeSaveError(w, r, err)
+		return
+	}
+
+	h.writeChange(w, r, cart, true, tr(r, "cart.toast.coupon", coupon.Code))
+}
+
+// HandleRemoveCoupon takes the coupon off the cart
+func (h *CartHandler) HandleRemoveCoupon(w http.ResponseWriter, r *http.Request) {
+	cart := requestCart(r)
+	if err := cart.RemoveCoupon(); err != nil {
+		writeSaveError(w, r, err)
+		return
+	}
+
+	h.writeChange(w, r, cart, true, "")
+}
+
+// writeChange answers a change to the cart: the drawer, if the request
+// came from it, then the badge swapped out of band, a toast saying what
+// changed if there is anything to say, and the cart-changed event with the
+// new item count for anything else on the page showing the cart
+func (h *CartHandler) writeChange(w http.ResponseWriter, r *http.Request, cart *models.Cart, drawer bool, toast string) {
+	count := cart.GetItemCount()
+	resp := htmxkit.NewResponse()
+	if drawer {
+		resp.Swap(templates.CartDrawer(cart, h.estimateTaxes(cart)))
+	}
+	// The badge marks itself to be swapped out of band
+	resp.Swap(templates.CartBadge(count))
+	if toast != "" {
+		resp.SwapOOB("innerHTML", "#toast", components.Toast(toast))
+	}
+	resp.Trigger(cartChangedEvent, map[string]int{"count": count})
+
+	if err := resp.Write(w, r); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
+
+// estimateTaxes returns the taxes on the cart if it ships within the
+// country, as the drawer shows them before a destination is chosen
+func (h *CartHandler) estimateTaxes(cart *models.Cart) models.TaxLines {
+	return h.taxes.ForCart(cart, models.DefaultCountry)
+}
+
+// couponErrorMessage explains to the customer why a coupon can't be used
+func couponErrorMessage(r *http.Request, err error) string {
+	switch {
+	case errors.Is(err, models.ErrCouponNotFound):
+		return tr(r, "coupon.error.not_found")
+	case errors.Is(err, models.ErrCouponExpired):
+		return tr(r, "coupon.error.expired")
+	case errors.Is(err, models.ErrCouponUsedUp):
+		return tr(r, "coupon.error.used_up")
+	case errors.Is(err, models.ErrCouponMinOrder):
+		return tr(r, "coupon.error.min_order")
+	default:
+		return tr(r, "coupon.error")
+	}
+}
+
+// writeStockError writes the response for stock that couldn't be held.
+// HTMX requests get the cart drawer with why, in place of what they were
+// to swap, so the customer sees what is already in their cart.
+func (h *CartHandler) writeStockError(w http.ResponseWriter, r *http.Request, cart *models.Cart, err error) {
+	var message string
+	var status int
+	var stockErr *models.InsufficientStockError
+	switch {
+	case errors.As(err, &stockErr):
+		message, status = stockErrorMessage(r, cart, stockErr), http.StatusConflict
+	case errors.Is(err, models.ErrVariantRequired):
+		message, status = tr(r, "cart.error.variant"), http.StatusBadRequest
+	case errors.Is(err, models.ErrProductNotFound):
+		message, status = tr(r, "cart.error.not_found"), http.StatusNotFound
+	case errors.Is(err, models.ErrNotSaved):
+		writeSaveError(w, r, err)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !htmxkit.IsRequest(r) {
+		http.Error(w, message, status)
+		return
+	}
+	htmxkit.NewResponse().
+		Swap(templates.CartDrawerWithStockError(cart, h.estimateTaxes(cart), message)).
+		Retarget("#cart-drawer", "innerHTML").
+		Status(status).
+		Write(w, r)
+}
+
+// stockErrorMessage explains to the customer why they can't have as many
+// of a product as they asked for, telling them when it is because of what
+// their cart already holds
+func stockErrorMessage(r *http.Request, cart *models.Cart, err *models.InsufficientStockError) string {
+	inCart := cart.Quantity(models.ItemKey{ProductID: err.ProductID, VariantID: err.VariantID})
+	if inCart > 0 && inCart <= err.Available {
+		return tr(r, "cart.error.stock.in_cart", err.Name, inCart, err.Available)
+	}
+	return tr(r, "cart.error.stock", err.Name, err.Available)
+}
+
+// reserve checks there is stock for quantity of a product or variant in
+// the cart and, when reservations are on, holds it for the cart
+func (h *CartHandler) reserve(cart *models.Cart, key models.ItemKey, quantity int) error {
+	if h.ReserveFor > 0 {
+		return h.store.Reserve(cart.ID, key, quantity, h.ReserveFor)
+	} else if quantity > 0 {
+		return h.store.CheckStock(cart.ID, key, quantity)
+	}
+	return nil
+}
+
+// parseItemKey reads the posted product_id of a cart request and its
+// variant_id, if it has one
+func parseItemKey(r *http.Request) (models.ItemKey, error) {
+	var key models.ItemKey
+	var err error
+	if key.ProductID, err = strconv.Atoi(r.PostFormValue("product_id")); err != nil {
+		return key, err
+	}
+	if value := r.PostFormValue("variant_id"); value != "" {
+		key.VariantID, err = strconv.Atoi(value)
+	}
+	return key, err
+}
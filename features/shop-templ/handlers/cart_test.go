@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+)
+
+// brokenCarts loads no carts and can't write any
+type brokenCarts struct{}
+
+func (brokenCarts) LoadCarts() ([]models.SavedCart, error) { return nil, nil }
+func (brokenCarts) SaveCart(models.SavedCart) error        { return errors.New("disk full") }
+func (brokenCarts) DeleteCart(string) error                { return errors.New("disk full") }
+
+func TestCartChangeNotSaved(t *testing.T) {
+	h, product := cartRoutes(t)
+	carts, err := models.NewPersistentCartStore(brokenCarts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cart := carts.Get("guest-a")
+	token := models.NewCSRFToken()
+
+	r := cartPost("/cart/add", url.Values{"product_id": {fmt.Sprint(product.ID)}, "quantity": {"2"}}, token)
+	r.Header.Set(csrfHeaderName, token)
+	r = r.WithContext(context.WithValue(r.Context(), cartContextKey{}, cart))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500, got %d", w.Code)
+	}
+	if w.Header().Get("HX-Trigger") != "" {
+		t.Errorf("Expected no cart change announced, got HX-Trigger %q", w.Header().Get("HX-Trigger"))
+	}
+	if cart.GetItemCount() != 0 {
+		t.Errorf("Expected the cart left empty, got %d items", cart.GetItemCount())
+	}
+}
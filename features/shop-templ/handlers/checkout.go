@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+	"github.com/homveloper/doodle/features/shop-templ/templates"
+)
+
+var (
+	// errMissingCard is returned for an order placed without a card
+	errMissingCard = errors.New("card number is required")
+	// errCouponRedeem wraps why the cart's coupon couldn't be redeemed
+	// when the order was placed
+	errCouponRedeem = errors.New("coupon can't be redeemed")
+)
+
+type CheckoutHandler struct {
+	store    *models.ProductStore
+	orders   *models.OrderStore
+	coupons  *models.CouponStore
+	taxes    models.TaxRules
+	fees     models.ShippingCalculator
+	payments models.PaymentProvider
+	points   *models.PointsLedger
+	rewards  models.PointRules
+	// addresses fill in the checkout form for logged-in users
+	addresses *models.AddressBook
+	// Analytics records checkouts started and orders placed, if set
+	Analytics *Analytics
+}
+
+func NewCheckoutHandler(store *models.ProductStore, orders *models.OrderStore, coupons *models.CouponStore, taxes models.TaxRules, fees models.ShippingCalculator, payments models.PaymentProvider, points *models.PointsLedger, rewards models.PointRules, addresses *models.AddressBook) *CheckoutHandler {
+	return &CheckoutHandler{
+		store:     store,
+		orders:    orders,
+		coupons:   coupons,
+		taxes:     taxes,
+		fees:      fees,
+		payments:  payments,
+		points:    points,
+		rewards:   rewards,
+		addresses: addresses,
+	}
+}
+
+// HandleCheckout renders the checkout page with the shipping form. For a
+// logged-in user it is filled in from their default addresses, or from the
+// saved shipping address in the address query parameter.
+func (h *CheckoutHandler) HandleCheckout(w http.ResponseWriter, r *http.Request) {
+	cart := requestCart(r)
+	if len(cart.GetItems()) == 0 {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	// Start with the account's name and email filled in
+	var shipping models.ShippingInfo
+	if user, ok := models.UserFromContext(r.Context()); ok {
+		shipping.Name = user.Name
+		shipping.Email = user.Email
+
+		address, found := h.addresses.Default(user.ID, models.AddressShipping)
+		if id, err := strconv.Atoi(r.URL.Query().Get("address")); err == nil {
+			chosen, err := h.addresses.Get(user.ID, id)
+			if err == nil && chosen.Kind == models.AddressShipping {
+				address, found = chosen, true
+			}
+		}
+		if found {
+			shipping = address.FillShipping(shipping)
+		}
+		if billing, ok := h.addresses.Default(user.ID, models.AddressBilling); ok {
+			shipping.Billing = billing.Line()
+		}
+	}
+
+	h.Analytics.Track(r, models.Event{Kind: models.EventCheckout})
+	h.renderCheckout(w, r, http.StatusOK, shipping, "")
+}
+
+// HandlePlaceOrder places an order for the cart with the shipping details
+// and card in the form, then redirects to the order confirmation. A
+// logged-in user can have the shipping address saved to their address
+// book along the way.
+func (h *CheckoutHandler) HandlePlaceOrder(w http.ResponseWriter, r *http.Request) {
+	shipping := models.ShippingInfo{
+		Name:    strings.TrimSpace(r.FormValue("name")),
+		Phone:   strings.TrimSpace(r.FormValue("phone")),
+		Address: strings.TrimSpace(r.FormValue("address")),
+		Country: r.FormValue("country"),
+		Method:  r.FormValue("method"),
+		Memo:    strings.TrimSpace(r.FormValue("memo")),
+		Email:   strings.TrimSpace(r.FormValue("email")),
+		Billing: strings.TrimSpace(r.FormValue("billing")),
+	}
+
+	order, err := h.placeOrder(r.Context(), requestCart(r), shipping, strings.TrimSpace(r.FormValue("card")), formPoints(r))
+	var stockErr *models.InsufficientStockError
+	switch {
+	case err == nil:
+		h.Analytics.Track(r, models.Event{Kind: models.EventPurchase, OrderID: order.ID})
+		if r.FormValue("save_address") != "" {
+			h.saveAddress(order)
+		}
+		http.Redirect(w, r, fmt.Sprintf("/orders/%d", order.ID), http.StatusSeeOther)
+	case errors.Is(err, models.ErrEmptyCart):
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	case errors.Is(err, models.ErrUnsupportedCountry):
+		shipping.Country = ""
+		h.renderCheckout(w, r, http.StatusUnprocessableEntity, shipping, tr(r, "shipping.error.country"))
+	case errors.Is(err, models.ErrShippingIncomplete):
+		h.renderCheckout(w, r, http.StatusUnprocessableEntity, shipping, tr(r, "shipping.error.incomplete"))
+	case errors.Is(err, models.ErrInvalidEmail):
+		h.renderCheckout(w, r, http.StatusUnprocessableEntity, shipping, tr(r, "checkout.error.email"))
+	case errors.Is(err, models.ErrGuestEmailRequired):
+		h.renderCheckout(w, r, http.StatusUnprocessableEntity, shipping, tr(r, "checkout.error.guest_email"))
+	case errors.Is(err, errMissingCard):
+		h.renderCheckout(w, r, http.StatusUnprocessableEntity, shipping, tr(r, "checkout.error.card"))
+	case errors.Is(err, models.ErrShippingUnavailable):
+		shipping.Method = ""
+		h.renderCheckout(w, r, http.StatusUnprocessableEntity, shipping, tr(r, "shipping.error.method"))
+	case errors.Is(err, models.ErrInvalidPoints):
+		h.renderCheckout(w, r, http.StatusUnprocessableEntity, shipping, tr(r, "checkout.error.points"))
+	case errors.Is(err, models.ErrInsufficientPoints):
+		h.renderCheckout(w, r, http.StatusUnprocessableEntity, shipping, tr(r, "checkout.error.points_balance"))
+	case errors.Is(err, errCouponRedeem):
+		h.renderCheckout(w, r, http.StatusConflict, shipping, tr(r, "checkout.error.coupon", couponErrorMessage(r, err)))
+	case errors.As(err, &stockErr):
+		message := tr(r, "checkout.error.stock", stockErr.Name, stockErr.Available)
+		h.renderCheckout(w, r, http.StatusConflict, shipping, message)
+	case errors.Is(err, models.ErrProductNotFound):
+		h.renderCheckout(w, r, http.StatusConflict, shipping, tr(r, "checkout.error.unavailable"))
+	case errors.Is(err, models.ErrPaymentDeclined):
+		h.renderCheckout(w, r, http.StatusPaymentRequired, shipping, tr(r, "checkout.error.declined"))
+	case errors.Is(err, models.ErrNotSaved):
+		writeSaveError(w, r, err)
+	default:
+		log.Printf("place order: %v", err)
+		h.renderCheckout(w, r, http.StatusBadGateway, shipping, tr(r, "checkout.error.payment"))
+	}
+}
+
+// placeOrder redeems the cart's coupon and up to points of the account's
+// points, takes the cart's items out of stock and authorizes payment for
+// them, then records the order and clears the cart. Guests must give an
+// email to find the order by again. Points pay for the products only, so
+// any more than they cost after bundle savings and the coupon are left.
+// The order is paid once the payment is captured; if that fails here, the
+// provider's webhook can still report it. Nothing is kept if placing the
+// order fails, except that a coupon which can no longer be redeemed is
+// taken off the cart.
+func (h *CheckoutHandler) placeOrder(ctx context.Context, cart *models.Cart, shipping models.ShippingInfo, card string, points int) (models.Order, error) {
+	if len(cart.GetItems()) == 0 {
+		return models.Order{}, models.ErrEmptyCart
+	}
+	if points < 0 {
+		return models.Order{}, models.ErrInvalidPoints
+	}
+	if err := shipping.Validate(); err != nil {
+		return models.Order{}, err
+	}
+	// Guests find their order again by its number and email
+	if _, ok := models.UserFromContext(ctx); !ok && shipping.Email == "" {
+		return models.Order{}, models.ErrGuestEmailRequired
+	}
+	if card == "" {
+		return models.Order{}, errMissingCard
+	}
+	// Checked before stock is taken, as it can't be put back
+	delivery, err := models.ChooseShipping(h.fees.Options(models.CartParcel(cart, shipping.Destination())), shipping.Method)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	// A coupon only counts as used if it takes something off this order
+	coupon, hasCoupon := cart.GetCoupon()
+	if hasCoupon && !cart.Discount().IsZero() {
+		if _, err := h.coupons.Redeem(coupon.Code, cart.TotalAfterBundles()); err != nil {
+			cart.RemoveCoupon()
+			return models.Order{}, fmt.Errorf("%w: %w", errCouponRedeem, err)
+		}
+	} else {
+		hasCoupon = false
+	}
+
+	items, err := h.store.TakeStock(cart.ID, cart.GetItems())
+	if err != nil {
+		if hasCoupon {
+			h.coupons.Unredeem(coupon.Code)
+		}
+		return models.Order{}, err
+	}
+	// undo gives back what was taken for an order that can't be placed
+	undo := func(order models.Order) {
+		if err := h.store.ReturnStock(items); err != nil {
+			slog.Error("return stock", "cart", cart.ID, "error", err)
+		}
+		if hasCoupon {
+			h.coupons.Unredeem(coupon.Code)
+		}
+		if order.PointsUsed > 0 {
+			h.points.Restore(order.UserID, 0, order.PointsUsed)
+		}
+	}
+
+	// Priced as the products are now, which may differ from the cart
+	order := models.Order{CartID: cart.ID, Items: items, Shipping: shipping}
+	order.Bundles = models.BundlesFor(cart.GetBundles(), items)
+	if hasCoupon {
+		order.CouponCode = coupon.Code
+		order.Discount = coupon.Discount(order.Subtotal().Sub(order.BundleDiscount()))
+	}
+	// The fee may change with the prices, such as whether it is waived
+	if option, err := models.ChooseShipping(h.fees.Options(order.Parcel()), delivery.Method); err == nil {
+		delivery = option
+	}
+	order.Delivery = delivery
+	order.Taxes = h.taxes.ForOrder(order)
+	if user, ok := models.UserFromContext(ctx); ok {
+		order.UserID = user.ID
+		if order.Shipping.Email == "" {
+			order.Shipping.Email = user.Email
+		}
+	}
+	order.PointsUsed = min(points, int(order.Subtotal().Sub(order.BundleDiscount()).Sub(order.Discount).Amount))
+	if order.PointsUsed > 0 {
+		if _, err := h.points.Redeem(order.UserID, order.PointsUsed); err != nil {
+			undo(models.Order{})
+			return models.Order{}, err
+		}
+	}
+	if order.UserID != 0 {
+		order.PointsEarned = h.rewards.ForOrder(order)
+	}
+
+	payment, err := h.payments.Authorize(ctx, models.PaymentRequest{
+		Reference: cart.ID,
+		Amount:    order.TotalDue(),
+		Source:    card,
+	})
+	if err != nil {
+		undo(order)
+		return models.Order{}, fmt.Errorf("authorize payment: %w", err)
+	}
+
+	order.PaymentID = payment.ID
+	placed, err := h.orders.Add(order)
+	if err != nil {
+		// The payment was only authorized, and lapses uncaptured
+		undo(order)
+		return models.Order{}, err
+	}
+	order = placed
+	if err := cart.Clear(); err != nil {
+		slog.Warn("clear cart after order", "order", order.ID, "error", err)
+	}
+	if _, err := h.payments.Capture(ctx, payment.ID); err != nil {
+		log.Printf("capture payment for order %d: %v", order.ID, err)
+	} else if paid, err := h.orders.SetStatus(order.ID, models.OrderPaid); err != nil {
+		log.Printf("order %d paid: %v", order.ID, err)
+	} else {
+		order = paid
+	}
+	return order, nil
+}
+
+// HandleCheckoutSummary renders the order summary with the shipping fee and
+// taxes for the chosen country and shipping method, along with the methods
+// on offer there and the place order button's new total
+func (h *CheckoutHandler) HandleCheckoutSummary(w http.ResponseWriter, r *http.Request) {
+	cart := requestCart(r)
+	shipping := models.ShippingInfo{Country: r.FormValue("country")}
+	if !slices.Contains(models.ShippingCountries, shipping.Destination()) {
+		http.Error(w, "Unsupported country", http.StatusBadRequest)
+		return
+	}
+
+	options, delivery := h.shippingOptions(cart, shipping.Destination(), r.FormValue("method"))
+	taxes := h.taxes.ForCart(cart, shipping.Destination())
+	points := h.usablePoints(r, cart)
+	templates.CheckoutSummary(cart, delivery, taxes, points).Render(r.Context(), w)
+	templates.ShippingMethods(options, delivery, true).Render(r.Context(), w)
+	templates.PlaceOrderLabel(cart.TotalAfterDiscount().Add(delivery.Fee).Add(taxes.Total()).Sub(models.Won(int64(points))), true).Render(r.Context(), w)
+}
+
+// renderCheckout writes the checkout page with the given status, keeping
+// what was entered in the form
+func (h *CheckoutHandler) renderCheckout(w http.ResponseWriter, r *http.Request, status int, shipping models.ShippingInfo, message string) {
+	cart := requestCart(r)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	options, delivery := h.shippingOptions(cart, shipping.Destination(), shipping.Method)
+	templates.Page(tr(r, "checkout.title"), cart, templates.CheckoutPage(cart, options, delivery, h.taxes.ForCart(cart, shipping.Destination()), h.usablePoints(r, cart), h.savedAddresses(r), shipping, message)).Render(r.Context(), w)
+}
+
+// saveAddress adds the order's shipping address to its account's address
+// book, unless it is a guest's or already there
+func (h *CheckoutHandler) saveAddress(order models.Order) {
+	if order.UserID == 0 {
+		return
+	}
+	address := models.Address{
+		UserID:  order.UserID,
+		Kind:    models.AddressShipping,
+		Name:    order.Shipping.Name,
+		Phone:   order.Shipping.Phone,
+		Address: order.Shipping.Address,
+		Country: order.Shipping.Country,
+	}
+	for _, saved := range h.addresses.ForUser(order.UserID, models.AddressShipping) {
+		if saved.Name == address.Name && saved.Phone == address.Phone && saved.Address == address.Address && saved.Destination() == address.Destination() {
+			return
+		}
+	}
+	if _, err := h.addresses.Add(address); err != nil {
+		log.Printf("save address of order %d: %v", order.ID, err)
+	}
+}
+
+// savedAddresses returns the logged-in user's saved shipping addresses,
+// for the checkout form to offer
+func (h *CheckoutHandler) savedAddresses(r *http.Request) []models.Address {
+	user, ok := models.UserFromContext(r.Context())
+	if !ok {
+		return nil
+	}
+	return h.addresses.ForUser(user.ID, models.AddressShipping)
+}
+
+// usablePoints returns the points entered in the form, up to what the
+// logged-in user has and what the cart's products cost after the coupon
+func (h *CheckoutHandler) usablePoints(r *http.Request, cart *models.Cart) int {
+	user, ok := models.UserFromContext(r.Context())
+	if !ok {
+		return 0
+	}
+	return max(min(formPoints(r), h.points.Balance(user.ID), int(cart.TotalAfterDiscount().Amount)), 0)
+}
+
+// formPoints reads the points to use from the form: none if left empty,
+// and a negative number, which can't be used, if not a number
+func formPoints(r *http.Request) int {
+	value := strings.TrimSpace(r.FormValue("points"))
+	if value == "" {
+		return 0
+	}
+	points, err := strconv.Atoi(value)
+	if err != nil {
+		return -1
+	}
+	return points
+}
+
+// shippingOptions returns the ways the cart can ship to country and the one
+// to show as chosen: method if it is on offer, otherwise the default
+func (h *CheckoutHandler) shippingOptions(cart *models.Cart, country, method string) ([]models.ShippingOption, models.ShippingOption) {
+	options := h.fees.Options(models.CartParcel(cart, country))
+	delivery, err := models.ChooseShipping(options, method)
+	if err != nil {
+		delivery, _ = models.ChooseShipping(options, "")
+	}
+	return options, delivery
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+)
+
+const (
+	// csrfCookieName is the cookie holding the visitor's CSRF token
+	csrfCookieName = "shop_csrf"
+	// csrfHeaderName is the header HTMX requests send the token in. The
+	// layout sets it on every HTMX request from the page.
+	csrfHeaderName = "X-CSRF-Token"
+	// csrfFieldName is the form field plain form posts send the token in
+	csrfFieldName = "csrf_token"
+)
+
+// LoadCSRF attaches the visitor's CSRF token to every request context,
+// handing out a new one in a cookie if they don't have one yet
+func LoadCSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie(csrfCookieName); err == nil && cookie.Value != "" {
+			next.ServeHTTP(w, r.WithContext(models.ContextWithCSRFToken(r.Context(), cookie.Value)))
+			return
+		}
+
+		token := models.NewCSRFToken()
+		http.SetCookie(w, &http.Cookie{
+			Name:     csrfCookieName,
+			Value:    token,
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+		next.ServeHTTP(w, r.WithContext(models.ContextWithCSRFToken(r.Context(), token)))
+	})
+}
+
+// RequireCSRF only lets requests through that send back the visitor's CSRF
+// token, in the header or the form. Other sites can make a browser post to
+// the shop with its cookies, but can't read the token to send along.
+func RequireCSRF(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sent := r.Header.Get(csrfHeaderName)
+		if sent == "" {
+			sent = r.PostFormValue(csrfFieldName)
+		}
+		if !models.CSRFTokenMatches(models.CSRFTokenFromContext(r.Context()), sent) {
+			http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+)
+
+// cartRoutes serves the cart changes the way main.go registers them,
+// without the rate limits, behind LoadCSRF
+func cartRoutes(t *testing.T) (http.Handler, models.Product) {
+	t.Helper()
+	store := models.NewProductStore()
+	product, _ := store.Add(models.Product{Name: "머그컵", Price: models.Won(12000), Category: "주방", Stock: 5})
+	h := NewCartHandler(store, models.NewOrderStore(), models.NewCouponStore(), models.NewBundleStore(), models.DefaultTaxRules)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /cart/add", RequireCSRF(h.HandleAddToCart))
+	mux.HandleFunc("POST /cart/update", RequireCSRF(h.HandleUpdateCart))
+	mux.HandleFunc("POST /cart/remove", RequireCSRF(h.HandleRemoveFromCart))
+	mux.HandleFunc("POST /cart/clear", RequireCSRF(h.HandleClearCart))
+	mux.HandleFunc("POST /cart/coupon", RequireCSRF(h.HandleApplyCoupon))
+	mux.HandleFunc("POST /cart/coupon/remove", RequireCSRF(h.HandleRemoveCoupon))
+	return LoadCSRF(mux), product
+}
+
+// cartPost is an HTMX post of form to target from a visitor holding token
+// in their cookie
+func cartPost(target string, form url.Values, token string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("HX-Request", "true")
+	r.AddCookie(&http.Cookie{Name: csrfCookieName, Value: token})
+	return r
+}
+
+func TestCartChangesRejectGet(t *testing.T) {
+	h, product := cartRoutes(t)
+
+	for _, target := range []string{"/cart/add", "/cart/update", "/cart/remove", "/cart/clear", "/cart/coupon", "/cart/coupon/remove"} {
+		token := models.NewCSRFToken()
+		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("%s?product_id=%d&%s=%s", target, product.ID, csrfFieldName, token), nil)
+		r.Header.Set("HX-Request", "true")
+		r.Header.Set(csrfHeaderName, token)
+		r.AddCookie(&http.Cookie{Name: csrfCookieName, Value: token})
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "POST" {
+			t.Errorf("GET %s: expected 405 allowing POST, got %d %q", target, w.Code, w.Header().Get("Allow"))
+		}
+	}
+}
+
+func TestCartChangesRequireCSRFToken(t *testing.T) {
+	h, product := cartRoutes(t)
+	token := models.NewCSRFToken()
+
+	tests := []struct {
+		name   string
+		cookie string
+		header string
+		field  string
+	}{
+		{"no token", token, "", ""},
+		{"wrong header", token, models.NewCSRFToken(), ""},
+		{"wrong field", token, "", models.NewCSRFToken()},
+		{"wrong header, right field", token, models.NewCSRFToken(), token},
+		{"no cookie", "", token, ""},
+	}
+	for _, tt := range tests {
+		for _, target := range []string{"/cart/add", "/cart/clear"} {
+			form := url.Values{"product_id": {fmt.Sprint(product.ID)}}
+			if tt.field != "" {
+				form.Set(csrfFieldName, tt.field)
+			}
+			r := cartPost(target, form, tt.cookie)
+			if tt.cookie == "" {
+				r.Header.Del("Cookie")
+			}
+			if tt.header != "" {
+				r.Header.Set(csrfHeaderName, tt.header)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != http.StatusForbidden {
+				t.Errorf("%s, %s: expected 403, got %d", tt.name, target, w.Code)
+			}
+			if w.Header().Get("HX-Trigger") != "" {
+				t.Errorf("%s, %s: expected the cart left alone, got HX-Trigger %q", tt.name, target, w.Header().Get("HX-Trigger"))
+			}
+		}
+	}
+}
+
+func TestCartChangesWithCSRFToken(t *testing.T) {
+	h, product := cartRoutes(t)
+	token := models.NewCSRFToken()
+
+	// HTMX requests send the token in the header, as the layout sets it
+	r := cartPost("/cart/add", url.Values{"product_id": {fmt.Sprint(product.ID)}, "quantity": {"2"}}, token)
+	r.Header.Set(csrfHeaderName, token)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || !strings.Contains(w.Header().Get("HX-Trigger"), `"cart-changed":{"count":2}`) {
+		t.Errorf("Expected the product added with the header's token, got %d %q", w.Code, w.Header().Get("HX-Trigger"))
+	}
+	if !strings.Contains(w.Body.String(), `id="cart-badge"`) {
+		t.Errorf("Expected the badge swapped in, got %q", w.Body.String())
+	}
+
+	// Plain forms send it as a field
+	r = cartPost("/cart/clear", url.Values{csrfFieldName: {token}}, token)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || !strings.Contains(w.Header().Get("HX-Trigger"), cartChangedEvent) {
+		t.Errorf("Expected the cart cleared with the form's token, got %d %q", w.Code, w.Header().Get("HX-Trigger"))
+	}
+}
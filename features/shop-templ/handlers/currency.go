@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+)
+
+const (
+	// currencyCookieName is the cookie holding the currency prices are shown in
+	currencyCookieName = "shop_currency"
+	// currencyCookieTTL is how long the chosen currency is remembered
+	currencyCookieTTL = 365 * 24 * time.Hour
+)
+
+type CurrencyHandler struct {
+	rates models.RateProvider
+}
+
+func NewCurrencyHandler(rates models.RateProvider) *CurrencyHandler {
+	return &CurrencyHandler{rates: rates}
+}
+
+// LoadCurrency attaches how to show prices to every request context: in
+// the currency the visitor picked, converted at the current rate. Prices
+// stay in the catalog's currency if there is no rate for it.
+func (h *CurrencyHandler) LoadCurrency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(currencyCookieName)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		currency, ok := models.ParseCurrency(cookie.Value)
+		if !ok || currency == models.DefaultCurrency {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rate, err := h.rates.Rate(r.Context(), models.DefaultCurrency, currency)
+		if err != nil {
+			log.Printf("exchange rate to %s: %v", currency, err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		display := models.Display{Currency: currency, Rate: rate}
+		next.ServeHTTP(w, r.WithContext(models.ContextWithDisplay(r.Context(), display)))
+	})
+}
+
+// HandleSetCurrency remembers the currency picked in the header and goes
+// back to the page it was picked on
+func (h *CurrencyHandler) HandleSetCurrency(w http.ResponseWriter, r *http.Request) {
+	currency, ok := models.ParseCurrency(r.FormValue("currency"))
+	if !ok {
+		http.Error(w, "Unsupported currency", http.StatusBadRequest)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     currencyCookieName,
+		Value:    string(currency),
+		Path:     "/",
+		MaxAge:   int(currencyCookieTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, safeNext(r.FormValue("next")), http.StatusSeeOther)
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+	"github.com/homveloper/doodle/features/shop-templ/templates"
+)
+
+// emailTimeout is how long sending an email may take before it is given up
+const emailTimeout = 30 * time.Second
+
+// emailedStatuses are the order statuses the customer is emailed about: a
+// confirmation once the order is paid for, then shipping updates
+var emailedStatuses = []models.OrderStatus{models.OrderPaid, models.OrderShipped, models.OrderDelivered}
+
+// OrderMailer emails customers as their orders are confirmed and shipped
+type OrderMailer struct {
+	sender models.EmailSender
+	// shopURL is where the shop is served, for linking to orders
+	shopURL string
+}
+
+func NewOrderMailer(sender models.EmailSender, shopURL string) *OrderMailer {
+	return &OrderMailer{
+		sender:  sender,
+		shopURL: strings.TrimSuffix(shopURL, "/"),
+	}
+}
+
+// OrderChanged emails the customer about the order's new status, if it is
+// one they hear about and the order has an email address. It is meant for
+// OrderStore.OnStatusChange, so failures are logged rather than returned.
+func (m *OrderMailer) OrderChanged(order models.Order) {
+	if order.Shipping.Email == "" || !slices.Contains(emailedStatuses, order.Status) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), emailTimeout)
+	defer cancel()
+
+	var body bytes.Buffer
+	orderURL := fmt.Sprintf("%s/orders/%d", m.shopURL, order.ID)
+	if err := templates.OrderEmail(order, orderURL).Render(ctx, &body); err != nil {
+		log.Printf("render email for order %d: %v", order.ID, err)
+		return
+	}
+
+	email := models.Email{
+		To:      order.Shipping.Email,
+		Subject: templates.OrderEmailSubject(order),
+		HTML:    body.String(),
+	}
+	if err := m.sender.Send(ctx, email); err != nil {
+		log.Printf("email order %d %s to %s: %v", order.ID, order.Status, email.To, err)
+	}
+}
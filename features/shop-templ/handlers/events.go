@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+	"github.com/homveloper/doodle/features/shop-templ/templates"
+)
+
+const (
+	// eventsBuffer is how many product changes wait for a slow stream
+	// before it misses some
+	eventsBuffer = 32
+	// eventsKeepAlive is how often an idle stream gets a comment, so
+	// proxies don't close it
+	eventsKeepAlive = 30 * time.Second
+)
+
+// EventsHandler streams product changes to shoppers' browsers as
+// server-sent events, so product cards show new stock and prices without
+// a refresh
+type EventsHandler struct {
+	mu      sync.Mutex
+	streams map[chan models.Product]struct{}
+	// done is closed to end every stream
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewEventsHandler() *EventsHandler {
+	return &EventsHandler{streams: make(map[chan models.Product]struct{}), done: make(chan struct{})}
+}
+
+// Close ends every open stream, for shutting the server down without
+// waiting for shoppers to leave. Browsers reconnect on their own.
+func (h *EventsHandler) Close() {
+	h.closeOnce.Do(func() { close(h.done) })
+}
+
+// ProductChanged passes a changed product to every open stream. It is
+// meant for ProductStore.OnChange, so it never waits: a stream too far
+// behind misses the change.
+func (h *EventsHandler) ProductChanged(product models.Product) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for stream := range h.streams {
+		select {
+		case stream <- product:
+		default:
+		}
+	}
+}
+
+// HandleEvents streams a "product-{id}" event for every product that
+// changes, with its card rendered for the shopper's currency, until the
+// browser goes away
+func (h *EventsHandler) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	stream := make(chan models.Product, eventsBuffer)
+	h.mu.Lock()
+	h.streams[stream] = struct{}{}
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.streams, stream)
+		h.mu.Unlock()
+	}()
+
+	// Streams last much longer than the server's write timeout
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		case <-keepAlive.C:
+			io.WriteString(w, ": keep-alive\n\n")
+		case product := <-stream:
+			var card bytes.Buffer
+			if err := templates.ProductCardUpdate(product).Render(r.Context(), &card); err != nil {
+				log.Printf("render product %d event: %v", product.ID, err)
+				continue
+			}
+			writeEvent(w, fmt.Sprintf("product-%d", product.ID), card.String())
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes a server-sent event, giving each line of data its own
+// data field
+func writeEvent(w io.Writer, name, data string) {
+	fmt.Fprintf(w, "event: %s\n", name)
+	for line := range strings.Lines(data) {
+		fmt.Fprintf(w, "data: %s\n", strings.TrimRight(line, "\r\n"))
+	}
+	io.WriteString(w, "\n")
+}
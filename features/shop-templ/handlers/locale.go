@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+)
+
+const (
+	// localeCookieName is the cookie holding the language the visitor picked
+	localeCookieName = "shop_locale"
+	// localeCookieTTL is how long the chosen language is remembered
+	localeCookieTTL = 365 * 24 * time.Hour
+)
+
+// LoadLocale attaches the language to show the shop in to every request
+// context: the one the visitor picked, or else the one their browser asks
+// for. Responses say which it is in Content-Language.
+func LoadLocale(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale := requestLocale(r)
+		w.Header().Set("Content-Language", string(locale))
+		w.Header().Add("Vary", "Accept-Language, Cookie")
+		next.ServeHTTP(w, r.WithContext(models.ContextWithLocale(r.Context(), locale)))
+	})
+}
+
+// requestLocale is the language picked in the header, or else the
+// browser's
+func requestLocale(r *http.Request) models.Locale {
+	if cookie, err := r.Cookie(localeCookieName); err == nil {
+		if picked, ok := models.ParseLocale(cookie.Value); ok {
+			return picked
+		}
+	}
+	return models.PreferredLocale(r.Header.Get("Accept-Language"))
+}
+
+// HandleSetLocale remembers the language picked in the header and goes
+// back to the page it was picked on
+func HandleSetLocale(w http.ResponseWriter, r *http.Request) {
+	locale, ok := models.ParseLocale(r.FormValue("locale"))
+	if !ok {
+		http.Error(w, "Unsupported language", http.StatusBadRequest)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     localeCookieName,
+		Value:    string(locale),
+		Path:     "/",
+		MaxAge:   int(localeCookieTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, safeNext(r.FormValue("next")), http.StatusSeeOther)
+}
+
+// tr returns the message with key in the visitor's language
+func tr(r *http.Request, key string, args ...any) string {
+	return models.LocaleFromContext(r.Context()).T(key, args...)
+}
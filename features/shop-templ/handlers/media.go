@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+	"github.com/homveloper/doodle/features/shop-templ/templates"
+)
+
+// mediaCacheControl lets browsers cache media forever; names are random
+// and files are never rewritten
+const mediaCacheControl = "public, max-age=31536000, immutable"
+
+type MediaHandler struct {
+	store  *models.ProductStore
+	images *models.ImageStore
+}
+
+func NewMediaHandler(store *models.ProductStore, images *models.ImageStore) *MediaHandler {
+	return &MediaHandler{
+		store:  store,
+		images: images,
+	}
+}
+
+// HandleProductImages renders a product's image management page
+func (h *MediaHandler) HandleProductImages(w http.ResponseWriter, r *http.Request) {
+	product, ok := h.product(w, r)
+	if !ok {
+		return
+	}
+
+	h.renderProductImages(w, r, http.StatusOK, product, "")
+}
+
+// HandleUploadProductImage stores an image from a multipart form and adds
+// it to the product's gallery
+func (h *MediaHandler) HandleUploadProductImage(w http.ResponseWriter, r *http.Request) {
+	product, ok := h.product(w, r)
+	if !ok {
+		return
+	}
+
+	// Allow some room for the multipart framing around the file
+	r.Body = http.MaxBytesReader(w, r.Body, models.MaxImageSize+1<<20)
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.renderProductImages(w, r, http.StatusRequestEntityTooLarge, product, "이미지는 5MB 이하여야 합니다")
+			return
+		}
+		h.renderProductImages(w, r, http.StatusBadRequest, product, "올릴 이미지를 선택해주세요")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, models.MaxImageSize+1))
+	if err != nil {
+		http.Error(w, "Failed to read upload", http.StatusBadRequest)
+		return
+	}
+
+	img, err := h.images.Save(data)
+	switch {
+	case errors.Is(err, models.ErrImageTooLarge):
+		h.renderProductImages(w, r, http.StatusRequestEntityTooLarge, product, "이미지는 5MB 이하여야 합니다")
+		return
+	case errors.Is(err, models.ErrUnsupportedImage):
+		h.renderProductImages(w, r, http.StatusUnsupportedMediaType, product, "JPEG, PNG, GIF 이미지만 올릴 수 있습니다")
+		return
+	case err != nil:
+		http.Error(w, "Failed to store image", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := h.store.AddImage(product.ID, img.URL()); errors.Is(err, models.ErrNotSaved) {
+		writeSaveError(w, r, err)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/admin/products/%d/images", product.ID), http.StatusSeeOther)
+}
+
+// Media serves stored images under /media/
+func (h *MediaHandler) Media() http.Handler {
+	files := http.StripPrefix("/media/", http.FileServerFS(h.images))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// No directory listings
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", mediaCacheControl)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		files.ServeHTTP(w, r)
+	})
+}
+
+// product looks up the product named in the path, answering the request
+// itself if there is none
+func (h *MediaHandler) product(w http.ResponseWriter, r *http.Request) (models.Product, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return models.Product{}, false
+	}
+
+	product, exists := h.store.GetByID(id)
+	if !exists {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return models.Product{}, false
+	}
+	return product, true
+}
+
+func (h *MediaHandler) renderProductImages(w http.ResponseWriter, r *http.Request, status int, product models.Product, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	templates.Page("이미지 관리", requestCart(r), templates.ProductImagesPage(product, message)).Render(r.Context(), w)
+}
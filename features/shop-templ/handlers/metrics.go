@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+)
+
+// healthTimeout is how long a health check may take before it counts as
+// failed
+const healthTimeout = 2 * time.Second
+
+// cartOperations name the cart changes counted in metrics, by the route
+// pattern making them
+var cartOperations = map[string]string{
+	"POST /cart/add":                        "add",
+	"POST /cart/update":                     "update",
+	"POST /cart/remove":                     "remove",
+	"POST /cart/clear":                      "clear",
+	"POST /cart/coupon":                     "apply_coupon",
+	"POST /cart/coupon/remove":              "remove_coupon",
+	"POST /cart/bundles/{id}":               "add_bundle",
+	"POST /cart/bundles/{id}/remove":        "remove_bundle",
+	"POST /api/v1/cart/items":               "add",
+	"PUT /api/v1/cart/items/{productID}":    "update",
+	"DELETE /api/v1/cart/items/{productID}": "remove",
+	"DELETE /api/v1/cart":                   "clear",
+	"PUT /api/v1/cart/coupon":               "apply_coupon",
+	"DELETE /api/v1/cart/coupon":            "remove_coupon",
+}
+
+// HealthCheck reports whether something the shop depends on can be
+// reached
+type HealthCheck func(ctx context.Context) error
+
+// MetricsHandler serves the health check and Prometheus metrics: request
+// latencies and cart changes counted as they happen, and stock and orders
+// read from the stores when scraped
+type MetricsHandler struct {
+	store    *models.ProductStore
+	orders   *models.OrderStore
+	requests *models.Histogram
+	cartOps  *models.Counter
+	// Checks are what /healthz checks, by name, besides the stores
+	Checks map[string]HealthCheck
+}
+
+func NewMetricsHandler(store *models.ProductStore, orders *models.OrderStore) *MetricsHandler {
+	return &MetricsHandler{
+		store:    store,
+		orders:   orders,
+		requests: models.NewHistogram(models.LatencyBuckets, "method", "route", "status"),
+		cartOps:  models.NewCounter("operation"),
+		Checks:   make(map[string]HealthCheck),
+	}
+}
+
+// Instrument times every request and counts the cart changes that
+// succeed. It needs to wrap the mux directly, to see the route pattern the
+// mux matched; requests no route matched are counted under "other".
+func (h *MetricsHandler) Instrument(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		mux.ServeHTTP(sw, r)
+
+		route := "other"
+		if r.Pattern != "" {
+			// The method is a label of its own
+			_, path, found := strings.Cut(r.Pattern, " ")
+			if !found {
+				path = r.Pattern
+			}
+			route = path
+		}
+		h.requests.Observe(time.Since(start).Seconds(), r.Method, route, strconv.Itoa(sw.status))
+		if operation, ok := cartOperations[r.Pattern]; ok && sw.status < http.StatusBadRequest {
+			h.cartOps.Add(1, operation)
+		}
+	})
+}
+
+// HandleMetrics serves the metrics in the Prometheus text format
+func (h *MetricsHandler) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	orderCounts, orderTotals := models.OrderSamples(h.orders.All())
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m := models.NewMetricsWriter(w)
+	m.Histogram("shop_http_request_duration_seconds", "How long requests took to answer, by method, route and status.", h.requests)
+	m.Counter("shop_cart_operations_total", "Cart changes made, by operation.", h.cartOps.Samples())
+	cache := h.store.ListingCacheStats()
+	m.Counter("shop_listing_cache_hits_total", "Product listings found in the cache.", []models.Sample{{Value: float64(cache.Hits)}})
+	m.Counter("shop_listing_cache_misses_total", "Product listings built for lack of a fresh one in the cache.", []models.Sample{{Value: float64(cache.Misses)}})
+	m.Gauge("shop_product_stock", "Units in stock, by product and variant.", models.StockSamples(h.store.GetAll()))
+	m.Gauge("shop_orders", "Orders, by status.", orderCounts)
+	m.Gauge("shop_order_value", "What orders come to, by status and currency, in the currency's major unit.", orderTotals)
+	if err := m.Err(); err != nil {
+		log.Printf("write metrics: %v", err)
+	}
+}
+
+// healthStatus is the body of a /healthz response
+type healthStatus struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// HandleHealth checks the stores can be reached, answering 200 if they all
+// answer in time and 503 if not, with each check's result
+func (h *MetricsHandler) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]HealthCheck{
+		// The stores answer from memory; a store that can't be reached
+		// is one stuck behind its lock
+		"products": func(context.Context) error { h.store.GetByID(0); return nil },
+		"orders":   func(context.Context) error { h.orders.GetByID(0); return nil },
+	}
+	for name, check := range h.Checks {
+		checks[name] = check
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), healthTimeout)
+	defer cancel()
+	status := healthStatus{Status: "ok", Checks: make(map[string]string, len(checks))}
+	for name, check := range checks {
+		if err := runHealthCheck(ctx, check); err != nil {
+			status.Status = "unavailable"
+			status.Checks[name] = err.Error()
+			continue
+		}
+		status.Checks[name] = "ok"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if status.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(status)
+}
+
+// runHealthCheck runs check, giving up on it once ctx is done even if the
+// check itself doesn't watch ctx
+func runHealthCheck(ctx context.Context, check HealthCheck) error {
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
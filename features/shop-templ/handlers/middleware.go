@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/homveloper/doodle/features/shop-templ/htmxkit"
+	"github.com/homveloper/doodle/features/shop-templ/models"
+	"github.com/homveloper/doodle/features/shop-templ/templates"
+)
+
+// requestIDHeader is the header a request's ID is taken from, if a proxy
+// in front set one, and returned in
+const requestIDHeader = "X-Request-ID"
+
+type requestIDContextKey struct{}
+
+// RequestIDs gives every request an ID, in its context and in the
+// response's X-Request-ID header, for finding its log lines. An ID a proxy
+// in front already gave it is kept.
+func RequestIDs(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			b := make([]byte, 8)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDContextKey{}, id)))
+	})
+}
+
+// requestID returns the ID RequestIDs gave the request, if any
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// validRequestID reports whether an ID from a request header is safe to
+// log and send back: short, and letters, digits and dashes only
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// LogRequests writes an access log line for every request once it is
+// answered, with its ID, status, size and how long it took
+func LogRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+
+		level := slog.LevelInfo
+		if sw.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(r.Context(), level, "request",
+			slog.String("id", requestID(r)),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", sw.status),
+			slog.Int64("bytes", sw.bytes),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("ip", remoteIP(r)),
+			slog.Bool("htmx", htmxkit.IsRequest(r)),
+		)
+	})
+}
+
+// Recover turns a panicking handler into a 500 response: an error page
+// with the request ID to quote, or for HTMX and API requests just the
+// message. The panic and its stack are logged. If the handler had already
+// started its response there is nothing to be done for it but to end it,
+// unless a writer further out, such as Compress's, was still holding it
+// back and can throw it away.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+			slog.Error("panic", "id", requestID(r), "method", r.Method, "path", r.URL.Path, "error", err, "stack", string(debug.Stack()))
+			if held, ok := w.(heldWriter); sw.wrote && (!ok || !held.discard()) {
+				return
+			}
+
+			// The panic may have come before the locale was loaded
+			writeServerError(w, r.WithContext(models.ContextWithLocale(r.Context(), requestLocale(r))))
+		}()
+		next.ServeHTTP(sw, r)
+	})
+}
+
+// writeServerError answers with a 500: an error page with the request ID
+// to quote, or for HTMX and API requests just the message
+func writeServerError(w http.ResponseWriter, r *http.Request) {
+	message := tr(r, "error.server")
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/api/"):
+		writeAPIError(w, http.StatusInternalServerError, "internal_error", message)
+	case htmxkit.IsRequest(r):
+		http.Error(w, message, http.StatusInternalServerError)
+	default:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		templates.Page(message, requestCart(r), templates.ErrorPage(requestID(r))).Render(r.Context(), w)
+	}
+}
+
+// writeSaveError answers a change the stores couldn't save, such as one
+// failing with models.ErrNotSaved, with a 500, logging why
+func writeSaveError(w http.ResponseWriter, r *http.Request, err error) {
+	slog.Error("not saved", "id", requestID(r), "method", r.Method, "path", r.URL.Path, "error", err)
+	writeServerError(w, r)
+}
+
+// heldWriter is a response writer that holds back the start of the
+// response before sending it
+type heldWriter interface {
+	// discard throws away what has been held back, so a different
+	// response can be written, and reports whether it could: once
+	// anything has gone out it can't
+	discard() bool
+}
+
+// compressMinSize is the smallest response worth compressing; smaller ones
+// can come out bigger
+const compressMinSize = 512
+
+// gzipWriters are reused between responses, as each holds large buffers
+var gzipWriters = sync.Pool{
+	New: func() any { return gzip.NewWriter(nil) },
+}
+
+// Compress gzips text responses for clients that accept it. Images are
+// compressed already, and event streams are sent as they happen, so they
+// are left alone.
+func Compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(r) || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, coding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(coding), ";")
+		if strings.EqualFold(name, "gzip") && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+// compressible reports whether responses of the content type are worth
+// compressing
+func compressible(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "text/event-stream":
+		return false
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	default:
+		return mediaType == "application/json" || mediaType == "application/javascript" || mediaType == "image/svg+xml"
+	}
+}
+
+// compressWriter holds back the start of the response until there is
+// enough of it to be worth compressing, or the handler flushes or ends it,
+// then decides whether to compress
+type compressWriter struct {
+	http.ResponseWriter
+	gz *gzip.Writer
+	// buf is the body written before deciding
+	buf     []byte
+	decided bool
+	status  int
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.decided || w.status != 0 {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	// Wait for the body, whose size decides and whose content may be
+	// needed to tell the type, before sending the headers
+	w.status = status
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= compressMinSize {
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// decide compresses the response if it is of a compressible type and the
+// body held back isn't too small to bother, then sends the headers and
+// that body
+func (w *compressWriter) decide() error {
+	w.decided = true
+	header := w.Header()
+	if header.Get("Content-Type") == "" && len(w.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	bodyless := w.status == http.StatusNoContent || w.status == http.StatusNotModified
+	if !bodyless && header.Get("Content-Encoding") == "" && compressible(header.Get("Content-Type")) &&
+		len(w.buf) >= compressMinSize {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", "gzip")
+		w.gz = gzipWriters.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (w *compressWriter) discard() bool {
+	if w.decided {
+		return false
+	}
+	w.buf = nil
+	w.status = 0
+	return true
+}
+
+// Flush sends what has been written so far, compressed or not
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close sends a response too small to have been decided on yet, or
+// finishes the compressed stream
+func (w *compressWriter) Close() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.gz != nil {
+		w.gz.Close()
+		gzipWriters.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// statusWriter remembers the status a handler answered with and how much
+// it wrote
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+	// wrote is whether the response has been started
+	wrote bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wrote {
+		w.status = status
+		w.wrote = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	w.wrote = true
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush sends what has been written so far, for streams
+func (w *statusWriter) Flush() {
+	w.wrote = true
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection, if the server allows
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("hijacking not supported")
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// remoteIP is the address the request came from, without its port
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// captureLogs sends the default logger's output to the returned buffer,
+// as JSON lines, until the test ends
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	before := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(before) })
+	return &buf
+}
+
+func TestRequestIDs(t *testing.T) {
+	logs := captureLogs(t)
+	var seen string
+	h := RequestIDs(LogRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestID(r)
+	})))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if len(seen) != 16 || w.Header().Get("X-Request-ID") != seen {
+		t.Errorf("Expected a generated ID in the context and response, got %q and %q", seen, w.Header().Get("X-Request-ID"))
+	}
+
+	// A proxy's ID is kept and logged, unless it could mess up the logs
+	for id, keep := range map[string]bool{"abc-123": true, "bad id\nforged": false, strings.Repeat("x", 65): false} {
+		logs.Reset()
+		r := httptest.NewRequest(http.MethodGet, "/products", nil)
+		r.Header.Set("X-Request-ID", id)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if (seen == id) != keep || w.Header().Get("X-Request-ID") != seen {
+			t.Errorf("Incoming ID %q: keep=%v, got %q", id, keep, seen)
+		}
+
+		var entry struct {
+			Msg  string
+			ID   string
+			Path string
+		}
+		if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+			t.Fatalf("Expected one JSON log line, got %q: %v", logs.String(), err)
+		}
+		if entry.Msg != "request" || entry.ID != seen || entry.Path != "/products" {
+			t.Errorf("Expected the access log to carry the request ID %q, got %+v", seen, entry)
+		}
+	}
+}
+
+func TestRecover(t *testing.T) {
+	logs := captureLogs(t)
+	h := RequestIDs(Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+
+	tests := []struct {
+		name        string
+		target      string
+		htmx        bool
+		contentType string
+		body        string
+	}{
+		{"page", "/", false, "text/html; charset=utf-8", "req-1"},
+		{"api", "/api/v1/products", false, "application/json", `"internal_error"`},
+		{"htmx", "/cart/add", true, "text/plain; charset=utf-8", ""},
+	}
+	for _, tt := range tests {
+		logs.Reset()
+		r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		r.Header.Set("X-Request-ID", "req-1")
+		if tt.htmx {
+			r.Header.Set("HX-Request", "true")
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Code != http.StatusInternalServerError || !strings.HasPrefix(w.Header().Get("Content-Type"), tt.contentType) {
+			t.Errorf("%s: expected a 500 %s, got %d %q", tt.name, tt.contentType, w.Code, w.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("%s: expected %q in the response, got %q", tt.name, tt.body, w.Body.String())
+		}
+		if !strings.Contains(logs.String(), `"error":"boom"`) || !strings.Contains(logs.String(), `"id":"req-1"`) || !strings.Contains(logs.String(), "goroutine") {
+			t.Errorf("%s: expected the panic, request ID and stack to be logged, got %q", tt.name, logs.String())
+		}
+	}
+}
+
+func TestRecoverAfterResponseStarted(t *testing.T) {
+	captureLogs(t)
+	w := httptest.NewRecorder()
+	Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("half a page"))
+		panic("boom")
+	})).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusOK || w.Body.String() != "half a page" {
+		t.Errorf("Expected the started response left alone, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestRecoverBehindCompress(t *testing.T) {
+	captureLogs(t)
+	panicAfter := func(body string) http.Handler {
+		return Compress(Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			io.WriteString(w, body)
+			panic("boom")
+		})))
+	}
+
+	// Compress still held the start of the page back, so nothing went out
+	w := httptest.NewRecorder()
+	panicAfter("<html><body>partial").ServeHTTP(w, gzipRequest("/"))
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "partial") {
+		t.Errorf("Expected the held back page replaced by a 500, got %d %q", w.Code, w.Body.String())
+	}
+
+	// Past compressMinSize the page has started and can only be ended
+	page := "<html><body>" + strings.Repeat("<p>상품</p>", compressMinSize)
+	w = httptest.NewRecorder()
+	panicAfter(page).ServeHTTP(w, gzipRequest("/"))
+	if w.Code != http.StatusOK || gunzip(t, w) != page {
+		t.Errorf("Expected the started page left alone, got %d", w.Code)
+	}
+}
+
+func TestRecoverLetsAbortThrough(t *testing.T) {
+	h := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if recover() != http.ErrAbortHandler {
+			t.Error("Expected ErrAbortHandler to be re-raised")
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func gzipRequest(target string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	r.Header.Set("Accept-Encoding", "gzip, deflate")
+	return r
+}
+
+// gunzip returns the decompressed body of a gzipped response
+func gunzip(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("Expected a gzip body: %v", err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestCompress(t *testing.T) {
+	page := strings.Repeat("<li>상품</li>", 100)
+	h := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		// Written in pieces smaller than compressMinSize
+		for chunk := range strings.SplitSeq(page, "</li>") {
+			io.WriteString(w, chunk+"</li>")
+		}
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, gzipRequest("/"))
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("Expected a gzipped page, got headers %v", w.Header())
+	}
+	if got := gunzip(t, w); got != page+"</li>" {
+		t.Errorf("Expected the page back whole, got %d bytes", len(got))
+	}
+
+	// A client that doesn't take gzip gets the page as it is
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != page+"</li>" {
+		t.Errorf("Expected an uncompressed page without Accept-Encoding, got headers %v", w.Header())
+	}
+}
+
+func TestCompressSkips(t *testing.T) {
+	small := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, "<span>3</span>")
+	})
+	png := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(bytes.Repeat([]byte{0x89}, 2*compressMinSize))
+	})
+	notModified := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusNotModified)
+	})
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		status  int
+	}{
+		{"responses under compressMinSize", small, http.StatusOK},
+		{"images", png, http.StatusOK},
+		{"304s", notModified, http.StatusNotModified},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		Compress(tt.handler).ServeHTTP(w, gzipRequest("/"))
+		if w.Header().Get("Content-Encoding") != "" || w.Code != tt.status {
+			t.Errorf("%s: expected an uncompressed %d, got %d %v", tt.name, tt.status, w.Code, w.Header())
+		}
+	}
+}
+
+func TestCompressKeepsStatus(t *testing.T) {
+	w := httptest.NewRecorder()
+	Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		w.Write(bytes.Repeat([]byte(" "), compressMinSize))
+	})).ServeHTTP(w, gzipRequest("/api/v1/cart/items"))
+
+	if w.Code != http.StatusConflict || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("Expected a gzipped 409, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestCompressStreams(t *testing.T) {
+	w := httptest.NewRecorder()
+	Compress(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(rw, "event: stock\ndata: 3\n\n")
+		http.NewResponseController(rw).Flush()
+
+		// Each event reaches the client as it is sent
+		if !w.Flushed || w.Body.String() != "event: stock\ndata: 3\n\n" {
+			t.Errorf("Expected the event flushed through uncompressed, got %q", w.Body.String())
+		}
+		io.WriteString(rw, "event: stock\ndata: 2\n\n")
+	})).ServeHTTP(w, gzipRequest("/events"))
+
+	if w.Header().Get("Content-Encoding") != "" || strings.Count(w.Body.String(), "event: stock") != 2 {
+		t.Errorf("Expected the stream passed through, got %v %q", w.Header(), w.Body.String())
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	for header, want := range map[string]bool{
+		"gzip":              true,
+		"deflate, gzip;q=1": true,
+		"GZIP":              true,
+		"gzip;q=0":          false,
+		"br, deflate":       false,
+		"":                  false,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Encoding", header)
+		if got := acceptsGzip(r); got != want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
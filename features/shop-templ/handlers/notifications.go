@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+	"github.com/homveloper/doodle/features/shop-templ/templates"
+)
+
+// NotificationHandler shows users the notifications the site has for them
+type NotificationHandler struct {
+	notifications *models.NotificationStore
+}
+
+func NewNotificationHandler(notifications *models.NotificationStore) *NotificationHandler {
+	return &NotificationHandler{notifications: notifications}
+}
+
+// LoadUnread attaches how many notifications the logged-in user hasn't
+// seen to the request context, for the badge in the navigation. It must
+// come after AuthHandler.LoadSession.
+func (h *NotificationHandler) LoadUnread(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, ok := models.UserFromContext(r.Context()); ok {
+			if unread := h.notifications.Unread(user.ID); unread > 0 {
+				r = r.WithContext(models.ContextWithUnread(r.Context(), unread))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// HandleNotifications lists the logged-in user's notifications, newest
+// first, and marks them seen
+func (h *NotificationHandler) HandleNotifications(w http.ResponseWriter, r *http.Request) {
+	user, _ := models.UserFromContext(r.Context())
+	notifications := h.notifications.ForUser(user.ID)
+	h.notifications.MarkRead(user.ID)
+
+	// The page shows what was unread, but the badge is cleared
+	ctx := models.ContextWithUnread(r.Context(), 0)
+	templates.Page(tr(r, "notifications.title"), requestCart(r), templates.NotificationsPage(notifications)).Render(ctx, w)
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIRoute is an endpoint of the JSON API and what its OpenAPI description
+// says about it
+type APIRoute struct {
+	Method  string
+	Path    string
+	Summary string
+	// Query lists the query parameters the endpoint reads
+	Query []APIParam
+	// Request is a value of the type of the JSON body, if the endpoint
+	// takes one
+	Request any
+	// Status is the success status, and Response a value of the type of
+	// its body
+	Status   int
+	Response any
+	// Errors are the error statuses the endpoint may respond with
+	Errors []int
+	// RateLimit names the group of routes whose rate limit the endpoint
+	// counts against, if any
+	RateLimit string
+	Handler   http.HandlerFunc
+}
+
+// APIParam is a query parameter of an API endpoint
+type APIParam struct {
+	Name string
+	// Type is the parameter's JSON schema type, such as "string"
+	Type        string
+	Description string
+	// Repeated parameters may be given more than once
+	Repeated bool
+}
+
+// pathParamPattern matches the wildcards of a route's path
+var pathParamPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// OpenAPISpec describes the routes as an OpenAPI 3 document. Request and
+// response schemas are built from their types' JSON encoding.
+func OpenAPISpec(title, version string, routes []APIRoute) map[string]any {
+	schemas := make(openAPISchemas)
+	paths := make(map[string]map[string]any)
+
+	for _, route := range routes {
+		var parameters []any
+		for _, match := range pathParamPattern.FindAllStringSubmatch(route.Path, -1) {
+			// IDs are numbers, and anything else, such as a SKU, text
+			kind := "string"
+			if strings.HasSuffix(strings.ToLower(match[1]), "id") {
+				kind = "integer"
+			}
+			parameters = append(parameters, map[string]any{
+				"name":     match[1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]any{"type": kind},
+			})
+		}
+		for _, param := range route.Query {
+			schema := map[string]any{"type": param.Type}
+			if param.Repeated {
+				schema = map[string]any{"type": "array", "items": schema}
+			}
+			parameters = append(parameters, map[string]any{
+				"name":        param.Name,
+				"in":          "query",
+				"description": param.Description,
+				"schema":      schema,
+			})
+		}
+
+		responses := map[string]any{
+			strconv.Itoa(route.Status): map[string]any{
+				"description": http.StatusText(route.Status),
+				"content":     jsonContent(schemas.of(reflect.TypeOf(route.Response))),
+			},
+		}
+		errors := route.Errors
+		if route.RateLimit != "" {
+			errors = append(slices.Clone(errors), http.StatusTooManyRequests)
+		}
+		for _, status := range errors {
+			responses[strconv.Itoa(status)] = map[string]any{
+				"description": http.StatusText(status),
+				"content":     jsonContent(schemas.of(reflect.TypeOf(apiError{}))),
+			}
+		}
+
+		operation := map[string]any{
+			"summary":   route.Summary,
+			"responses": responses,
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+		if route.Request != nil {
+			operation["requestBody"] = map[string]any{
+				"required": true,
+				"content":  jsonContent(schemas.of(reflect.TypeOf(route.Request))),
+			}
+		}
+
+		if paths[route.Path] == nil {
+			paths[route.Path] = make(map[string]any)
+		}
+		paths[route.Path][strings.ToLower(route.Method)] = operation
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   title,
+			"version": version,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"session": map[string]any{"type": "apiKey", "in": "cookie", "name": sessionCookieName},
+			},
+		},
+	}
+}
+
+// jsonContent describes a JSON body with the given schema
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+// openAPISchemas holds the schemas of the named types a document uses, by
+// name, so they are described once and referred to everywhere else
+type openAPISchemas map[string]any
+
+// of returns the schema of t as encoding/json writes it
+func (s openAPISchemas) of(t reflect.Type) map[string]any {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return s.of(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		name := schemaName(t)
+		if _, ok := s[name]; !ok {
+			// Claimed before it is described, so types that refer to
+			// themselves don't recurse forever
+			s[name] = nil
+			s[name] = s.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	default:
+		return map[string]any{}
+	}
+}
+
+// object returns the schema of a struct's exported fields. Fields left out
+// when empty aren't required.
+func (s openAPISchemas) object(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = s.of(field.Type)
+		if !strings.Contains(options, "omitempty") && !strings.Contains(options, "omitzero") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// schemaName is what a type's schema is called: its name, without the
+// api prefix of the API's own request and response types
+func schemaName(t reflect.Type) string {
+	name := t.Name()
+	if rest, ok := strings.CutPrefix(name, "api"); ok {
+		return strings.ToUpper(rest[:1]) + rest[1:]
+	}
+	return name
+}
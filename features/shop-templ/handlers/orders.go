@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+	"github.com/homveloper/doodle/features/shop-templ/templates"
+)
+
+// orderLookupCookieName is the cookie holding the email a guest looked
+// their orders up with
+const orderLookupCookieName = "shop_order_lookup"
+
+type OrderHandler struct {
+	store    *models.ProductStore
+	orders   *models.OrderStore
+	payments models.PaymentProvider
+}
+
+func NewOrderHandler(store *models.ProductStore, orders *models.OrderStore, payments models.PaymentProvider) *OrderHandler {
+	return &OrderHandler{
+		store:    store,
+		orders:   orders,
+		payments: payments,
+	}
+}
+
+// HandleOrder renders a placed order. Only the account that placed it, or
+// for guest orders the same cart or a guest who looked it up, can see it,
+// and admins, who can move it along or refund it from there.
+func (h *OrderHandler) HandleOrder(w http.ResponseWriter, r *http.Request) {
+	order, ok := h.order(w, r)
+	if !ok {
+		return
+	}
+
+	h.renderOrder(w, r, http.StatusOK, order, "")
+}
+
+// HandleLookupForm renders the page where guests find an order by its
+// number and email
+func (h *OrderHandler) HandleLookupForm(w http.ResponseWriter, r *http.Request) {
+	h.renderLookup(w, r, http.StatusOK, "", "", "")
+}
+
+// HandleLookup finds the guest order with the number and email in the form
+// and goes to it. The email is remembered for the browser session, so the
+// guest can keep seeing and cancel the order.
+func (h *OrderHandler) HandleLookup(w http.ResponseWriter, r *http.Request) {
+	number := strings.TrimSpace(r.FormValue("order"))
+	email := strings.TrimSpace(r.FormValue("email"))
+
+	id, err := strconv.Atoi(strings.TrimPrefix(number, "#"))
+	if err != nil {
+		h.renderLookup(w, r, http.StatusUnprocessableEntity, number, email, tr(r, "lookup.error.number"))
+		return
+	}
+	order, ok := h.orders.Lookup(id, email)
+	if !ok {
+		h.renderLookup(w, r, http.StatusNotFound, number, email, tr(r, "lookup.error.not_found"))
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     orderLookupCookieName,
+		Value:    url.QueryEscape(email),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, fmt.Sprintf("/orders/%d", order.ID), http.StatusSeeOther)
+}
+
+// HandleCancel calls off the customer's order if it hasn't shipped, giving
+// back what was paid and putting its items back in stock
+func (h *OrderHandler) HandleCancel(w http.ResponseWriter, r *http.Request) {
+	order, ok := h.order(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.cancel(r.Context(), order); errors.Is(err, models.ErrOrderStatus) {
+		h.renderOrder(w, r, http.StatusConflict, order, tr(r, "order.error.shipped"))
+		return
+	} else if errors.Is(err, models.ErrNotSaved) {
+		writeSaveError(w, r, err)
+		return
+	} else if err != nil {
+		log.Printf("cancel order %d: %v", order.ID, err)
+		h.renderOrder(w, r, http.StatusBadGateway, order, tr(r, "order.error.cancel_payment"))
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/orders/%d", order.ID), http.StatusSeeOther)
+}
+
+// HandleAdminOrders renders every order with its status, for admins to
+// work through
+func (h *OrderHandler) HandleAdminOrders(w http.ResponseWriter, r *http.Request) {
+	component := templates.Page("주문 관리", requestCart(r), templates.AdminOrdersPage(h.orders.All()))
+	err := component.Render(r.Context(), w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// HandleSetStatus moves an order to the status in the form, then goes back
+// to the order. Cancelling works as it does for customers. Refunds go
+// through HandleRefund instead.
+func (h *OrderHandler) HandleSetStatus(w http.ResponseWriter, r *http.Request) {
+	order, ok := h.order(w, r)
+	if !ok {
+		return
+	}
+	status := models.OrderStatus(r.FormValue("status"))
+
+	var err error
+	switch {
+	case status == order.Status:
+	case status == models.OrderRefunded:
+		err = fmt.Errorf("%w: refund the payment instead", models.ErrOrderStatus)
+	case status == models.OrderCancelled:
+		_, err = h.cancel(r.Context(), order)
+	default:
+		_, err = h.orders.SetStatus(order.ID, status)
+	}
+	if errors.Is(err, models.ErrNotSaved) {
+		writeSaveError(w, r, err)
+		return
+	} else if err != nil {
+		log.Printf("order %d to %s: %v", order.ID, status, err)
+		h.renderOrder(w, r, http.StatusConflict, order, "주문 상태를 바꿀 수 없습니다")
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/orders/%d", order.ID), http.StatusSeeOther)
+}
+
+// HandleRefund gives back the amount in the form through the payment
+// provider, puts the items the form names back in stock and records the
+// refund on the order. Restock quantities are in restock-{productID}, or
+// restock-{productID}-{variantID} for variants.
+func (h *OrderHandler) HandleRefund(w http.ResponseWriter, r *http.Request) {
+	order, ok := h.order(w, r)
+	if !ok {
+		return
+	}
+
+	amount, err := models.ParseMoney(r.FormValue("amount"), order.Total.Currency)
+	if err != nil {
+		h.renderOrder(w, r, http.StatusUnprocessableEntity, order, "환불 금액을 확인해주세요")
+		return
+	}
+	refund := models.RefundLine{Amount: amount, Reason: strings.TrimSpace(r.FormValue("reason"))}
+	for _, item := range order.Returnable() {
+		quantity, _ := strconv.Atoi(r.FormValue("restock-" + item.Key().String()))
+		if quantity > 0 {
+			item.Quantity = quantity
+			refund.Restocked = append(refund.Restocked, item)
+		}
+	}
+
+	if err := order.CheckRefund(refund); err != nil {
+		h.renderOrder(w, r, http.StatusUnprocessableEntity, order, refundErrorMessage(err))
+		return
+	}
+	if _, err := h.payments.Refund(r.Context(), order.PaymentID, amount); err != nil {
+		log.Printf("refund order %d: %v", order.ID, err)
+		h.renderOrder(w, r, http.StatusBadGateway, order, "결제 제공자가 환불을 처리하지 못했습니다")
+		return
+	}
+	if _, err := h.orders.AddRefund(order.ID, refund); err != nil {
+		// The money went back, so this needs sorting out by hand
+		log.Printf("order %d refunded %v but not recorded: %v", order.ID, amount, err)
+		status := http.StatusConflict
+		if errors.Is(err, models.ErrNotSaved) {
+			status = http.StatusInternalServerError
+		}
+		h.renderOrder(w, r, status, order, "환불은 처리되었지만 주문에 기록하지 못했습니다")
+		return
+	}
+	if err := h.store.ReturnStock(refund.Restocked); err != nil {
+		writeSaveError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/orders/%d", order.ID), http.StatusSeeOther)
+}
+
+// cancel gives back what is left of an order's payment, if it was taken,
+// then calls the order off and puts its items back in stock
+func (h *OrderHandler) cancel(ctx context.Context, order models.Order) (models.Order, error) {
+	if !order.CanBecome(models.OrderCancelled) {
+		return order, fmt.Errorf("%w: %s to %s", models.ErrOrderStatus, order.Status, models.OrderCancelled)
+	}
+
+	// Pending orders haven't been charged
+	refund := models.NewMoney(0, order.Total.Currency)
+	if order.Status != models.OrderPending {
+		refund = order.Refundable()
+	}
+	if !refund.IsZero() {
+		if _, err := h.payments.Refund(ctx, order.PaymentID, refund); err != nil {
+			return order, err
+		}
+	}
+
+	order, restock, err := h.orders.Cancel(order.ID, refund)
+	if err != nil {
+		return order, err
+	}
+	if err := h.store.ReturnStock(restock); err != nil {
+		return order, err
+	}
+	return order, nil
+}
+
+// order finds the order in the path, writing an error if there is none or
+// the visitor can't see it, as canSeeOrder decides
+func (h *OrderHandler) order(w http.ResponseWriter, r *http.Request) (models.Order, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return models.Order{}, false
+	}
+
+	order, exists := h.orders.GetByID(id)
+	if !exists || !canSeeOrder(r, order) {
+		http.Error(w, "Order not found", http.StatusNotFound)
+		return models.Order{}, false
+	}
+	return order, true
+}
+
+// canSeeOrder reports whether the visitor can see the order: the account
+// that placed it, or for guest orders the same cart or a guest who looked
+// it up, and admins can
+func canSeeOrder(r *http.Request, order models.Order) bool {
+	user, _ := models.UserFromContext(r.Context())
+	return order.CartID == requestCart(r).ID || (order.UserID != 0 && order.UserID == user.ID) || user.Admin ||
+		(order.IsGuest() && order.HasEmail(lookupEmail(r)))
+}
+
+// lookupEmail returns the email the visitor looked guest orders up with,
+// if they did
+func lookupEmail(r *http.Request) string {
+	cookie, err := r.Cookie(orderLookupCookieName)
+	if err != nil {
+		return ""
+	}
+	email, _ := url.QueryUnescape(cookie.Value)
+	return email
+}
+
+// renderOrder writes the order page with the given status and message
+func (h *OrderHandler) renderOrder(w http.ResponseWriter, r *http.Request, status int, order models.Order, message string) {
+	user, _ := models.UserFromContext(r.Context())
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	templates.Page(tr(r, "order.title"), requestCart(r), templates.OrderConfirmation(order, user.Admin, message)).Render(r.Context(), w)
+}
+
+// renderLookup writes the order lookup page with the given status, keeping
+// what was entered in the form
+func (h *OrderHandler) renderLookup(w http.ResponseWriter, r *http.Request, status int, number, email, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	templates.Page(tr(r, "lookup.title"), requestCart(r), templates.OrderLookupPage(number, email, message)).Render(r.Context(), w)
+}
+
+// refundErrorMessage explains why a refund can't be made
+func refundErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, models.ErrOrderStatus):
+		return "지금은 환불할 수 없는 주문입니다"
+	case errors.Is(err, models.ErrRefundAmount):
+		return "환불 금액은 0보다 크고 남은 결제 금액 이하여야 합니다"
+	case errors.Is(err, models.ErrRefundItems):
+		return "재입고 수량이 주문 수량보다 많습니다"
+	default:
+		return "환불할 수 없습니다"
+	}
+}
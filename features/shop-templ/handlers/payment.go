@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+)
+
+type PaymentHandler struct {
+	orders   *models.OrderStore
+	payments models.PaymentProvider
+}
+
+func NewPaymentHandler(orders *models.OrderStore, payments models.PaymentProvider) *PaymentHandler {
+	return &PaymentHandler{
+		orders:   orders,
+		payments: payments,
+	}
+}
+
+// HandleWebhook applies a payment event from the provider to the order it
+// paid for. Events for unknown payments, or that don't change the order,
+// are acknowledged so the provider stops sending them.
+func (h *PaymentHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	event, err := h.payments.ParseWebhook(r)
+	if errors.Is(err, models.ErrWebhookSignature) {
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		http.Error(w, "Invalid webhook", http.StatusBadRequest)
+		return
+	}
+
+	order, ok := h.orders.ByPayment(event.Payment.ID)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var status models.OrderStatus
+	switch {
+	case event.Type == models.PaymentCaptured:
+		status = models.OrderPaid
+	// Cancelling refunds the payment itself
+	case event.Type == models.PaymentRefunded && order.Status != models.OrderCancelled:
+		status = models.OrderRefunded
+	default:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if _, err := h.orders.SetStatus(order.ID, status); errors.Is(err, models.ErrNotSaved) {
+		// The provider sends the event again
+		writeSaveError(w, r, err)
+		return
+	} else if err != nil {
+		log.Printf("payment %s %s: %v", event.Payment.ID, event.Type, err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+	"github.com/homveloper/doodle/features/shop-templ/templates"
+)
+
+// PointsHandler gives users points for their orders and shows them what
+// they have
+type PointsHandler struct {
+	ledger *models.PointsLedger
+	orders *models.OrderStore
+	// settling keeps orders' points settled one change at a time
+	settling sync.Mutex
+}
+
+func NewPointsHandler(ledger *models.PointsLedger, orders *models.OrderStore) *PointsHandler {
+	return &PointsHandler{ledger: ledger, orders: orders}
+}
+
+// LoadBalance attaches the logged-in user's point balance to the request
+// context, for the header. It must come after AuthHandler.LoadSession.
+func (h *PointsHandler) LoadBalance(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, ok := models.UserFromContext(r.Context()); ok {
+			r = r.WithContext(models.ContextWithPoints(r.Context(), h.ledger.Balance(user.ID)))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// HandlePoints lists the logged-in user's balance and every change to it,
+// newest first
+func (h *PointsHandler) HandlePoints(w http.ResponseWriter, r *http.Request) {
+	user, _ := models.UserFromContext(r.Context())
+
+	templates.Page(tr(r, "points.title"), requestCart(r), templates.PointsPage(h.ledger.Balance(user.ID), h.ledger.History(user.ID))).Render(r.Context(), w)
+}
+
+// OrderChanged settles an account's points as its order moves along: what
+// the order earns is given once it is paid for, and once it is called off
+// the points used on it are given back and those it earned taken back.
+// Guests' orders have no points. It is meant for OrderStore.OnStatusChange.
+func (h *PointsHandler) OrderChanged(order models.Order) {
+	if order.UserID == 0 {
+		return
+	}
+
+	// Changes can be reported out of order, so the order is settled as it
+	// is now rather than as reported
+	h.settling.Lock()
+	defer h.settling.Unlock()
+	if current, ok := h.orders.GetByID(order.ID); ok {
+		order = current
+	}
+
+	switch order.Status {
+	case models.OrderPaid, models.OrderPacked, models.OrderShipped, models.OrderDelivered:
+		h.ledger.Earn(order.UserID, order.ID, order.PointsEarned)
+	case models.OrderCancelled, models.OrderRefunded:
+		h.ledger.Restore(order.UserID, order.ID, order.PointsUsed)
+		h.ledger.Revoke(order.UserID, order.ID)
+	}
+}
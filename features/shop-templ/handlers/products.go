@@ -1,104 +1,266 @@
 package handlers
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/a-h/templ"
+
+	"github.com/homveloper/doodle/features/shop-templ/htmxkit"
 	"github.com/homveloper/doodle/features/shop-templ/models"
 	"github.com/homveloper/doodle/features/shop-templ/templates"
 )
 
+// suggestCount is how many product names, and how many categories, the
+// search suggestions list at most
+const suggestCount = 5
+
+// recommendCount is how many products a "you may also like" row shows at
+// most
+const recommendCount = 6
+
 type ProductHandler struct {
-	store *models.ProductStore
-	cart  *models.Cart
+	store   *models.ProductStore
+	orders  *models.OrderStore
+	alerts  *models.RestockAlerts
+	bundles *models.BundleStore
+	// Analytics records searches, if set
+	Analytics *Analytics
 }
 
-func NewProductHandler(store *models.ProductStore, cart *models.Cart) *ProductHandler {
+func NewProductHandler(store *models.ProductStore, orders *models.OrderStore, alerts *models.RestockAlerts, bundles *models.BundleStore) *ProductHandler {
 	return &ProductHandler{
-		store: store,
-		cart:  cart,
+		store:   store,
+		orders:  orders,
+		alerts:  alerts,
+		bundles: bundles,
 	}
 }
 
 // HandleHome renders the home page with all products
 func (h *ProductHandler) HandleHome(w http.ResponseWriter, r *http.Request) {
-	products := h.store.GetAll()
-	categories := h.store.GetCategories()
+	cart := requestCart(r)
+	page := h.store.GetPage(0, models.DefaultPageSize, models.SortDefault)
+	facets := h.store.Facets()
 
-	component := templates.Layout("홈", h.cart)
+	component := templates.Page(tr(r, "nav.home"), cart, templates.ProductList(page, facets, templates.Listing{}, nil))
 	err := component.Render(r.Context(), w)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
 	}
-
-	// Render product list inside layout
-	w.Header().Set("Content-Type", "text/html")
-	templates.ProductList(products, categories).Render(r.Context(), w)
 }
 
-// HandleProducts returns filtered products (HTMX endpoint)
+// HandleProducts returns a page of products matching the search query and
+// filters, in the requested order. HTMX requests get just the listing to
+// swap in, or for later pages just the grid items to append in place of
+// the "load more" button; other requests get the full page.
 func (h *ProductHandler) HandleProducts(w http.ResponseWriter, r *http.Request) {
-	category := r.URL.Query().Get("category")
+	facets := h.store.Facets()
+	listing := parseListing(r, facets)
 
-	var products []models.Product
-	if category != "" {
-		products = h.store.FilterByCategory(category)
-	} else {
-		products = h.store.GetAll()
+	products := h.store.Filter(listing.Query, listing.Categories, listing.MinPrice, listing.MaxPrice, listing.Tags)
+	page, later, ok := listingPage(r, products, models.DefaultPageSize, listing.Sort)
+	if !ok {
+		http.Error(w, "Invalid cursor", http.StatusBadRequest)
+		return
 	}
 
-	categories := h.store.GetCategories()
+	// Later pages of a search are the same search
+	if listing.Query != "" && !later {
+		h.Analytics.Track(r, models.Event{Kind: models.EventSearch, Query: listing.Query, Results: page.Total})
+	}
+
+	var suggestions []string
+	if page.Total == 0 && listing.Query != "" {
+		suggestions = h.store.DidYouMean(listing.Query)
+	}
+
+	if htmxkit.IsRequest(r) {
+		var component templ.Component
+		if later {
+			component = templates.ProductGridItems(page, listing)
+		} else {
+			component = templates.ProductListing(page, facets, listing, suggestions)
+		}
+		renderFragment(w, r, h.store.ListingsModified(), component)
+		return
+	}
 
-	component := templates.ProductList(products, categories)
+	component := templates.Page(tr(r, "listing.title"), requestCart(r), templates.ProductList(page, facets, listing, suggestions))
 	err := component.Render(r.Context(), w)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
-// HandleSearch handles product search (HTMX endpoint)
-func (h *ProductHandler) HandleSearch(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query().Get("q")
+// HandleSuggest returns the search box's suggestions for what has been
+// typed so far (HTMX endpoint)
+func (h *ProductHandler) HandleSuggest(w http.ResponseWriter, r *http.Request) {
+	suggestions := h.store.Suggest(r.URL.Query().Get("q"), suggestCount)
+	renderFragment(w, r, h.store.ListingsModified(), templates.SuggestionList(suggestions))
+}
 
-	products := h.store.Search(query)
+// renderFragment renders an HTMX fragment of the catalog with an ETag of
+// its content and the time the catalog last changed, answering 304 Not
+// Modified instead if the browser already has it. The browser checks
+// back every time, so a fragment is never out of date.
+func renderFragment(w http.ResponseWriter, r *http.Request, modified time.Time, component templ.Component) {
+	var body bytes.Buffer
+	if err := component.Render(r.Context(), &body); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-	// Just render the product grid without categories
-	w.Header().Set("Content-Type", "text/html")
-	w.Write([]byte(`<div class="product-grid">`))
+	// Weak, as the same content may be sent compressed or not
+	sum := sha256.Sum256(body.Bytes())
+	etag := `W/"` + hex.EncodeToString(sum[:12]) + `"`
+	header := w.Header()
+	header.Set("ETag", etag)
+	header.Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	header.Set("Cache-Control", "no-cache")
+	// The same URL without HTMX is the full page
+	header.Add("Vary", "HX-Request")
+	if notModified(r, etag, modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 
-	if len(products) == 0 {
-		templates.EmptyState("🔍", "검색 결과가 없습니다", "다른 검색어를 시도해보세요").Render(r.Context(), w)
-	} else {
-		for _, product := range products {
-			templates.ProductCard(product).Render(r.Context(), w)
+	header.Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(body.Bytes())
+}
+
+// notModified reports whether the browser's copy of a response is still
+// current: its ETag matches, or without one, it is no older than modified
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
 		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !modified.Truncate(time.Second).After(since)
+}
+
+// HandleProductDetail renders the detail page of a single product, with
+// products the customer may also like
+func (h *ProductHandler) HandleProductDetail(w http.ResponseWriter, r *http.Request) {
+	cart := requestCart(r)
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	// Archived products are only shown to admins, who can put them back
+	product, exists := h.store.GetByID(id)
+	user, _ := models.UserFromContext(r.Context())
+	if !exists || (product.Archived && !user.Admin) {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return
 	}
 
-	w.Write([]byte(`</div>`))
+	recommended := h.store.Recommend([]int{product.ID}, h.orders.BoughtTogether(), recommendCount)
+	var watching bool
+	if user, ok := models.UserFromContext(r.Context()); ok {
+		watching = h.alerts.Watching(product.ID, user.ID)
+	}
+	var offers []models.BundleOffer
+	for _, bundle := range h.bundles.Containing(product.ID) {
+		if offer, ok := h.store.BundleOffer(bundle); ok {
+			offers = append(offers, offer)
+		}
+	}
+	component := templates.Page(product.Name, cart, templates.ProductDetail(product, recommended, offers, watching))
+	err = component.Render(r.Context(), w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
 }
 
-// HandleCategories renders the categories page
+// HandleCategories renders the categories page, with every category of
+// the products on sale as a tree
 func (h *ProductHandler) HandleCategories(w http.ResponseWriter, r *http.Request) {
-	categories := h.store.GetCategories()
+	cart := requestCart(r)
 
-	component := templates.Layout("카테고리", h.cart)
+	component := templates.Page(tr(r, "category.title"), cart, templates.CategoriesPage(h.store.CategoryTree()))
 	err := component.Render(r.Context(), w)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// HandleCategory renders a category's landing page: its place in the
+// tree, its sub-categories and its products, theirs included, in the
+// listing's order. Other filters in the query apply as on /products.
+func (h *ProductHandler) HandleCategory(w http.ResponseWriter, r *http.Request) {
+	category, ok := h.store.GetCategory(r.PathValue("path"))
+	if !ok {
+		http.Error(w, "Category not found", http.StatusNotFound)
 		return
 	}
 
-	// Render categories list
-	w.Header().Set("Content-Type", "text/html")
-	w.Write([]byte(`<div style="padding: 20px;">`))
-	w.Write([]byte(`<h2 style="margin-bottom: 16px; font-size: 24px; font-weight: 700;">카테고리</h2>`))
-	w.Write([]byte(`<div style="display: flex; flex-direction: column; gap: 12px;">`))
+	facets := h.store.Facets()
+	listing := parseListing(r, facets).WithCategory(category.Path)
+	products := h.store.Filter(listing.Query, listing.Categories, listing.MinPrice, listing.MaxPrice, listing.Tags)
+	page := models.Paginate(products, 0, models.DefaultPageSize, listing.Sort)
 
-	for _, category := range categories {
-		w.Write([]byte(`<a href="/products?category=` + category + `" style="padding: 16px; background: white; border-radius: 12px; text-decoration: none; color: #333; font-weight: 600; box-shadow: 0 2px 4px rgba(0,0,0,0.1);">`))
-		w.Write([]byte(category))
-		w.Write([]byte(`</a>`))
+	component := templates.Page(category.Name(), requestCart(r), templates.CategoryPage(category, page, facets, listing))
+	if err := component.Render(r.Context(), w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// listingPage returns the page of products a request asks for: the one
+// after its cursor, as infinite scrolling asks for them, or the one at its
+// offset. later reports whether it asked for a page after the first; ok
+// is false for a cursor that can't be read.
+func listingPage(r *http.Request, products []models.Product, limit int, order models.ProductSort) (page models.ProductPage, later bool, ok bool) {
+	query := r.URL.Query()
+	if value := query.Get("cursor"); value != "" {
+		cursor, ok := models.ParseCursor(value)
+		if !ok {
+			return models.ProductPage{}, false, false
+		}
+		return models.PaginateAfter(products, cursor, limit, order), true, true
+	}
+
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	return models.Paginate(products, offset, limit, order), offset > 0, true
+}
+
+// parseListing reads the listing a request asks for. Empty values are
+// dropped, and price bounds that can't exclude anything in the catalog
+// count as unbounded, so sliders left at their ends don't count as filters.
+func parseListing(r *http.Request, facets models.Facets) templates.Listing {
+	query := r.URL.Query()
+	listing := templates.Listing{
+		Query:      query.Get("q"),
+		Categories: slices.DeleteFunc(query["category"], func(c string) bool { return c == "" }),
+		Tags:       slices.DeleteFunc(query["tag"], func(t string) bool { return t == "" }),
+		Sort:       models.ParseProductSort(query.Get("sort")),
+	}
+
+	if minPrice, err := models.ParseMoney(query.Get("min_price"), models.DefaultCurrency); err == nil && minPrice.Amount > 0 {
+		listing.MinPrice = minPrice
+	}
+	if maxPrice, err := models.ParseMoney(query.Get("max_price"), models.DefaultCurrency); err == nil && maxPrice.Amount > 0 && maxPrice.Cmp(facets.MaxPrice) < 0 {
+		listing.MaxPrice = maxPrice
+	}
+	if !listing.MaxPrice.IsZero() && listing.MinPrice.Cmp(listing.MaxPrice) > 0 {
+		// The sliders were dragged past each other
+		listing.MinPrice, listing.MaxPrice = listing.MaxPrice, listing.MinPrice
 	}
 
-	w.Write([]byte(`</div></div>`))
+	return listing
 }
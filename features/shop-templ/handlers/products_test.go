@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+)
+
+// newTestProductHandler returns a product handler over a store with a
+// couple of products
+func newTestProductHandler(t *testing.T, products ...models.Product) *ProductHandler {
+	t.Helper()
+	store := models.NewProductStore()
+	if len(products) == 0 {
+		products = []models.Product{
+			{Name: "무선 이어폰", Price: models.Won(89000), Category: "전자제품/오디오", Stock: 10},
+			{Name: "머그컵", Price: models.Won(12000), Category: "주방", Stock: 5},
+		}
+	}
+	for _, p := range products {
+		store.Add(p)
+	}
+	return NewProductHandler(store, models.NewOrderStore(), models.NewRestockAlerts(), models.NewBundleStore())
+}
+
+// htmxGet is an HTMX request for target, as the listing's controls send
+func htmxGet(target string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	r.Header.Set("HX-Request", "true")
+	return r
+}
+
+func TestListingFragmentETag(t *testing.T) {
+	h := newTestProductHandler(t)
+
+	w := httptest.NewRecorder()
+	h.HandleProducts(w, htmxGet("/products?q=이어폰"))
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("Expected a 200 with a weak ETag, got %d %q", w.Code, etag)
+	}
+	if w.Header().Get("Cache-Control") != "no-cache" || w.Header().Get("Vary") != "HX-Request" || w.Header().Get("Last-Modified") == "" {
+		t.Errorf("Expected the fragment to be revalidated every time, got %v", w.Header())
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        int
+	}{
+		{"matching", etag, http.StatusNotModified},
+		{"matching without W/", strings.TrimPrefix(etag, "W/"), http.StatusNotModified},
+		{"one of several", `"other", ` + etag, http.StatusNotModified},
+		{"any", "*", http.StatusNotModified},
+		{"not matching", `W/"0123456789abcdef01234567"`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		r := htmxGet("/products?q=이어폰")
+		r.Header.Set("If-None-Match", tt.ifNoneMatch)
+		w := httptest.NewRecorder()
+		h.HandleProducts(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, w.Code)
+		}
+		if w.Code == http.StatusNotModified && (w.Body.Len() != 0 || w.Header().Get("ETag") != etag) {
+			t.Errorf("%s: expected an empty 304 with the ETag, got %q", tt.name, w.Body.String())
+		}
+	}
+
+	// A different listing has a different ETag
+	w = httptest.NewRecorder()
+	h.HandleProducts(w, htmxGet("/products?q=머그"))
+	if w.Header().Get("ETag") == etag {
+		t.Error("Expected a different search to have a different ETag")
+	}
+}
+
+func TestListingFragmentIfModifiedSince(t *testing.T) {
+	h := newTestProductHandler(t)
+	modified := h.store.ListingsModified()
+
+	tests := []struct {
+		name  string
+		since time.Time
+		want  int
+	}{
+		{"same second", modified, http.StatusNotModified},
+		{"later", modified.Add(time.Hour), http.StatusNotModified},
+		{"earlier", modified.Add(-time.Hour), http.StatusOK},
+	}
+	for _, tt := range tests {
+		r := htmxGet("/search/suggest?q=무선")
+		r.Header.Set("If-Modified-Since", tt.since.UTC().Format(http.TimeFormat))
+		w := httptest.NewRecorder()
+		h.HandleSuggest(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, w.Code)
+		}
+	}
+
+	// If-None-Match wins over If-Modified-Since
+	r := htmxGet("/search/suggest?q=무선")
+	r.Header.Set("If-Modified-Since", modified.Add(time.Hour).UTC().Format(http.TimeFormat))
+	r.Header.Set("If-None-Match", `W/"stale"`)
+	w := httptest.NewRecorder()
+	h.HandleSuggest(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected a non-matching ETag to send the fragment, got %d", w.Code)
+	}
+}
+
+// hostileCategory is a category name that would run a script if it were
+// written into the page unescaped
+const hostileCategory = `<img src=x onerror="alert(1)">`
+
+func TestPagesAreOneDocument(t *testing.T) {
+	h := newTestProductHandler(t,
+		models.Product{Name: "이어폰", Price: models.Won(89000), Category: "전자제품/" + hostileCategory, Stock: 10},
+	)
+
+	tests := []struct {
+		name   string
+		handle http.HandlerFunc
+		target string
+		want   string
+	}{
+		{"home", h.HandleHome, "/", "이어폰"},
+		{"categories", h.HandleCategories, "/categories", "전자제품"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		tt.handle(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		body := w.Body.String()
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", tt.name, w.Code)
+		}
+		if strings.Count(body, "<html") != 1 || strings.Count(body, "</html>") != 1 || !strings.HasSuffix(strings.TrimSpace(body), "</html>") {
+			t.Errorf("%s: expected exactly one document, got %d <html> and %d </html>", tt.name, strings.Count(body, "<html"), strings.Count(body, "</html>"))
+		}
+		// The content is inside the layout's main area, not after it
+		if main := strings.Index(body, `class="main-content"`); main < 0 || strings.Index(body, tt.want) < main {
+			t.Errorf("%s: expected the content inside .main-content", tt.name)
+		}
+		if strings.Contains(body, hostileCategory) {
+			t.Errorf("%s: expected the category name escaped, found it as markup", tt.name)
+		}
+	}
+}
+
+func TestPricesFollowLocale(t *testing.T) {
+	h := newTestProductHandler(t)
+
+	for locale, want := range map[models.Locale]string{
+		models.Korean:  "89,000원",
+		models.English: "₩89,000",
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r = r.WithContext(models.ContextWithLocale(r.Context(), locale))
+		w := httptest.NewRecorder()
+		h.HandleHome(w, r)
+
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("%s: expected the price written %q", locale, want)
+		}
+	}
+}
+
+func TestCategoriesEscapeNames(t *testing.T) {
+	h := newTestProductHandler(t,
+		models.Product{Name: "이어폰", Price: models.Won(89000), Category: hostileCategory + `/"a" & 'b'`, Stock: 10},
+	)
+
+	w := httptest.NewRecorder()
+	h.HandleCategories(w, httptest.NewRequest(http.MethodGet, "/categories", nil))
+	body := w.Body.String()
+
+	if strings.Contains(body, hostileCategory) || strings.Contains(body, `"a" & 'b'`) {
+		t.Error("Expected the category names escaped in the page")
+	}
+	if !strings.Contains(body, "&lt;img src=x onerror=&#34;alert(1)&#34;&gt;") {
+		t.Errorf("Expected the escaped category name in the page")
+	}
+	// Links to the category escape its path
+	if !strings.Contains(body, `href="/categories/%3Cimg%20src=x%20onerror=%22alert%281%29%22%3E"`) {
+		t.Errorf("Expected the category link path-escaped, got %q", categoryLinks(body))
+	}
+}
+
+// categoryLinks returns the links to category pages in body, for messages
+func categoryLinks(body string) []string {
+	var links []string
+	for _, part := range strings.Split(body, `href="/categories/`)[1:] {
+		link, _, _ := strings.Cut(part, `"`)
+		links = append(links, link)
+	}
+	return links
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+	"github.com/homveloper/doodle/features/shop-templ/templates"
+)
+
+// promotionTimeLayout is how a datetime-local input sends its value
+const promotionTimeLayout = "2006-01-02T15:04"
+
+type PromotionHandler struct {
+	store      *models.ProductStore
+	promotions *models.PromotionStore
+}
+
+func NewPromotionHandler(store *models.ProductStore, promotions *models.PromotionStore) *PromotionHandler {
+	return &PromotionHandler{store: store, promotions: promotions}
+}
+
+// HandlePromotions renders every promotion, past and upcoming too, with
+// the form for adding one
+func (h *PromotionHandler) HandlePromotions(w http.ResponseWriter, r *http.Request) {
+	h.render(w, r, http.StatusOK, "")
+}
+
+// HandleCreatePromotion adds a promotion from the form, then goes back to
+// the promotion list
+func (h *PromotionHandler) HandleCreatePromotion(w http.ResponseWriter, r *http.Request) {
+	promotion, message := promotionFromForm(r)
+	if message == "" {
+		if _, err := h.promotions.Add(promotion); errors.Is(err, models.ErrInvalidPromotion) {
+			message = "이름, 대상, 할인율 또는 금액, 종료가 시작보다 늦은 기간을 입력해주세요"
+		} else if err != nil {
+			message = "프로모션을 등록할 수 없습니다"
+		}
+	}
+	if message != "" {
+		h.render(w, r, http.StatusUnprocessableEntity, message)
+		return
+	}
+	http.Redirect(w, r, "/admin/promotions", http.StatusSeeOther)
+}
+
+// HandleDeletePromotion removes a promotion, ending it early if it is on
+func (h *PromotionHandler) HandleDeletePromotion(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid promotion ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.promotions.Delete(id); err != nil {
+		http.Error(w, "Promotion not found", http.StatusNotFound)
+		return
+	}
+	http.Redirect(w, r, "/admin/promotions", http.StatusSeeOther)
+}
+
+// render writes the promotion list with the given status
+func (h *PromotionHandler) render(w http.ResponseWriter, r *http.Request, status int, message string) {
+	products := h.store.GetAll()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	templates.Page("프로모션 관리", requestCart(r), templates.AdminPromotionsPage(h.promotions.GetAll(), products, models.CategoriesOf(products), time.Now(), message)).Render(r.Context(), w)
+}
+
+// promotionFromForm reads a promotion from the admin form. Its target is
+// "product:<id>" or "category:<name>", and its times are local. The message
+// explains what could not be read, if anything.
+func promotionFromForm(r *http.Request) (models.Promotion, string) {
+	promotion := models.Promotion{
+		Name: strings.TrimSpace(r.FormValue("name")),
+		Type: models.DiscountType(r.FormValue("type")),
+	}
+
+	kind, target, _ := strings.Cut(r.FormValue("target"), ":")
+	switch kind {
+	case "product":
+		id, err := strconv.Atoi(target)
+		if err != nil {
+			return promotion, "할인할 상품을 선택해주세요"
+		}
+		promotion.ProductID = id
+	case "category":
+		promotion.Category = target
+	default:
+		return promotion, "할인할 상품이나 카테고리를 선택해주세요"
+	}
+
+	switch promotion.Type {
+	case models.DiscountPercent:
+		percent, err := strconv.Atoi(r.FormValue("value"))
+		if err != nil {
+			return promotion, "할인율을 숫자로 입력해주세요"
+		}
+		promotion.Percent = percent
+	case models.DiscountFixed:
+		amount, err := models.ParseMoney(r.FormValue("value"), models.DefaultCurrency)
+		if err != nil {
+			return promotion, "할인 금액을 원 단위 숫자로 입력해주세요"
+		}
+		promotion.Amount = amount
+	default:
+		return promotion, "할인 방식을 선택해주세요"
+	}
+
+	var err error
+	if promotion.StartsAt, err = time.ParseInLocation(promotionTimeLayout, r.FormValue("starts"), time.Local); err != nil {
+		return promotion, "시작 시각을 입력해주세요"
+	}
+	if promotion.EndsAt, err = time.ParseInLocation(promotionTimeLayout, r.FormValue("ends"), time.Local); err != nil {
+		return promotion, "종료 시각을 입력해주세요"
+	}
+	return promotion, ""
+}
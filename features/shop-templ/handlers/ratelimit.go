@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+)
+
+// RateLimits hold back clients making too many requests of a group of
+// routes, such as searches or cart changes, so no one client can fill the
+// in-memory stores or crowd out the others
+type RateLimits struct {
+	limits   models.RateLimits
+	limiters map[string]*models.RateLimiter
+}
+
+func NewRateLimits(limits models.RateLimits) *RateLimits {
+	limiters := make(map[string]*models.RateLimiter, len(limits))
+	for group, limit := range limits {
+		limiters[group] = models.NewRateLimiter(limit)
+	}
+	return &RateLimits{limits: limits, limiters: limiters}
+}
+
+// Limit counts requests to next against the group's rate limit, answering
+// 429 Too Many Requests once the client has used it up. Groups without a
+// limit are let through. Clients are told apart by account once they log
+// in, and by IP address until then.
+func (l *RateLimits) Limit(group string, next http.HandlerFunc) http.HandlerFunc {
+	limiter, ok := l.limiters[group]
+	if !ok {
+		return next
+	}
+	rejectBots := l.limits[group].RejectBots
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if rejectBots && models.LooksLikeBot(r.UserAgent()) {
+			writeLimitError(w, r, http.StatusForbidden, "bot_suspected", tr(r, "error.bot"))
+			return
+		}
+		if ok, wait := limiter.Allow(rateLimitKey(r), time.Now()); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeLimitError(w, r, http.StatusTooManyRequests, "rate_limited", tr(r, "error.rate_limited"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// rateLimitKey names the client making the request for rate limiting
+func rateLimitKey(r *http.Request) string {
+	if user, ok := models.UserFromContext(r.Context()); ok {
+		return models.UserCartID(user.ID)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip-" + host
+}
+
+// writeLimitError turns a request away as the API does for API requests,
+// or else in plain text
+func writeLimitError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		writeAPIError(w, status, code, message)
+		return
+	}
+	http.Error(w, message, status)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+	"github.com/homveloper/doodle/features/shop-templ/templates"
+)
+
+// RestockHandler signs shoppers up to hear when sold-out products are back,
+// and tells them when they are: on the site and by email
+type RestockHandler struct {
+	store         *models.ProductStore
+	alerts        *models.RestockAlerts
+	notifications *models.NotificationStore
+	sender        models.EmailSender
+	// shopURL is where the shop is served, for linking to products
+	shopURL string
+}
+
+func NewRestockHandler(store *models.ProductStore, alerts *models.RestockAlerts, notifications *models.NotificationStore, sender models.EmailSender, shopURL string) *RestockHandler {
+	return &RestockHandler{
+		store:         store,
+		alerts:        alerts,
+		notifications: notifications,
+		sender:        sender,
+		shopURL:       strings.TrimSuffix(shopURL, "/"),
+	}
+}
+
+// HandleWatch signs the logged-in user up to hear when the product is back
+// in stock, returning the button that calls it off
+func (h *RestockHandler) HandleWatch(w http.ResponseWriter, r *http.Request) {
+	product, ok := h.product(w, r)
+	if !ok {
+		return
+	}
+	user, _ := models.UserFromContext(r.Context())
+
+	err := h.alerts.Watch(product, user)
+	switch {
+	case errors.Is(err, models.ErrInStock):
+		http.Error(w, tr(r, "restock.error.in_stock"), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return
+	}
+
+	templates.RestockAlertButton(product.ID, true).Render(r.Context(), w)
+}
+
+// HandleUnwatch calls off the logged-in user's alert for the product,
+// returning the button that signs up again
+func (h *RestockHandler) HandleUnwatch(w http.ResponseWriter, r *http.Request) {
+	product, ok := h.product(w, r)
+	if !ok {
+		return
+	}
+	user, _ := models.UserFromContext(r.Context())
+
+	h.alerts.Unwatch(product.ID, user.ID)
+	templates.RestockAlertButton(product.ID, false).Render(r.Context(), w)
+}
+
+// ProductRestocked tells everyone who waited for the product that it is
+// back: with a notification on the site and an email. It is meant for
+// RestockAlerts.OnRestock, so failures are logged rather than returned.
+func (h *RestockHandler) ProductRestocked(product models.Product, watchers []models.RestockWatcher) {
+	productURL := fmt.Sprintf("/products/%d", product.ID)
+	for _, watcher := range watchers {
+		h.notifications.Add(models.Notification{
+			UserID:  watcher.UserID,
+			Message: templates.RestockEmailSubject(product),
+			URL:     productURL,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), emailTimeout)
+	defer cancel()
+
+	var body bytes.Buffer
+	if err := templates.RestockEmail(product, h.shopURL+productURL).Render(ctx, &body); err != nil {
+		log.Printf("render restock email for product %d: %v", product.ID, err)
+		return
+	}
+	for _, watcher := range watchers {
+		email := models.Email{
+			To:      watcher.Email,
+			Subject: templates.RestockEmailSubject(product),
+			HTML:    body.String(),
+		}
+		if err := h.sender.Send(ctx, email); err != nil {
+			log.Printf("email restock of product %d to %s: %v", product.ID, email.To, err)
+		}
+	}
+}
+
+// product finds the product named in the path, writing an error if there
+// is none
+func (h *RestockHandler) product(w http.ResponseWriter, r *http.Request) (models.Product, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return models.Product{}, false
+	}
+
+	product, exists := h.store.GetByID(id)
+	if !exists || product.Archived {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return models.Product{}, false
+	}
+	return product, true
+}
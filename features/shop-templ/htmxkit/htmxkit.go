@@ -0,0 +1,139 @@
+// Package htmxkit answers the shop's HTMX requests: telling them apart
+// from page loads, putting several fragments in one response to be swapped
+// in out of band, and setting the HX-* response headers that fire events,
+// retarget swaps and redirect. The blog doesn't use it: features don't
+// import each other, so it stays part of the shop.
+package htmxkit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/a-h/templ"
+)
+
+// IsRequest reports whether htmx made the request, rather than the
+// browser loading a page
+func IsRequest(r *http.Request) bool {
+	return r.Header.Get("HX-Request") == "true"
+}
+
+// Response is the answer to an HTMX request: fragments written one after
+// another, the first swapped into the request's target and the others
+// swapped in out of band, and events fired once they are in
+type Response struct {
+	status    int
+	fragments []templ.Component
+	triggers  map[string]any
+	retarget  string
+	reswap    string
+}
+
+// NewResponse starts a response with status 200 and nothing in it
+func NewResponse() *Response {
+	return &Response{status: http.StatusOK}
+}
+
+// Swap adds a fragment. The first is swapped into the request's target;
+// later ones must mark themselves with hx-swap-oob to say where they go.
+func (resp *Response) Swap(fragment templ.Component) *Response {
+	resp.fragments = append(resp.fragments, fragment)
+	return resp
+}
+
+// SwapOOB adds a fragment swapped out of band into the content of the
+// elements matching target, the way swap says: "innerHTML", "beforeend"
+// and so on. It goes inside an element carrying the hx-swap-oob
+// attribute, so the fragment needn't know where it is shown. To replace
+// an element whole, Swap a fragment that marks itself instead.
+func (resp *Response) SwapOOB(swap, target string, fragment templ.Component) *Response {
+	return resp.Swap(oob(swap+":"+target, fragment))
+}
+
+// Trigger fires event on the element that made the request, bubbling up
+// through the page, once the response is swapped in. Detail, if not nil,
+// is the event's detail.
+func (resp *Response) Trigger(event string, detail any) *Response {
+	if resp.triggers == nil {
+		resp.triggers = make(map[string]any)
+	}
+	resp.triggers[event] = detail
+	return resp
+}
+
+// Retarget swaps the first fragment into the elements matching target the
+// way swap says, instead of where the request asked for it
+func (resp *Response) Retarget(target, swap string) *Response {
+	resp.retarget, resp.reswap = target, swap
+	return resp
+}
+
+// Status sets the response's status. Htmx doesn't swap error responses
+// unless the page tells it to.
+func (resp *Response) Status(status int) *Response {
+	resp.status = status
+	return resp
+}
+
+// Write sends the response's headers, then renders its fragments in order
+func (resp *Response) Write(w http.ResponseWriter, r *http.Request) error {
+	header := w.Header()
+	if len(resp.triggers) > 0 {
+		events, err := json.Marshal(resp.triggers)
+		if err != nil {
+			return fmt.Errorf("encode HX-Trigger: %w", err)
+		}
+		header.Set("HX-Trigger", string(events))
+	}
+	if resp.retarget != "" {
+		header.Set("HX-Retarget", resp.retarget)
+	}
+	if resp.reswap != "" {
+		header.Set("HX-Reswap", resp.reswap)
+	}
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "text/html; charset=utf-8")
+	}
+	w.WriteHeader(resp.status)
+
+	for _, fragment := range resp.fragments {
+		if err := fragment.Render(r.Context(), w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Redirect sends the browser to url. Htmx requests get an HX-Redirect
+// header, as htmx would otherwise follow a redirect itself and swap the
+// page it lands on into the request's target; others get a 303.
+func Redirect(w http.ResponseWriter, r *http.Request, url string) {
+	if IsRequest(r) {
+		w.Header().Set("HX-Redirect", url)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	http.Redirect(w, r, url, http.StatusSeeOther)
+}
+
+// Refresh has htmx reload the whole page once the response arrives
+func Refresh(w http.ResponseWriter) {
+	w.Header().Set("HX-Refresh", "true")
+}
+
+// oob wraps fragment in a div that htmx swaps out of band as swapOOB says
+func oob(swapOOB string, fragment templ.Component) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		if _, err := fmt.Fprintf(w, `<div hx-swap-oob="%s">`, templ.EscapeString(swapOOB)); err != nil {
+			return err
+		}
+		if err := fragment.Render(ctx, w); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "</div>")
+		return err
+	})
+}
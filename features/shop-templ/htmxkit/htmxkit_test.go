@@ -0,0 +1,69 @@
+package htmxkit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/templ"
+)
+
+func text(s string) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		_, err := io.WriteString(w, s)
+		return err
+	})
+}
+
+func TestResponse(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/cart/add", nil)
+	w := httptest.NewRecorder()
+
+	err := NewResponse().
+		Swap(text(`<span id="badge">2</span>`)).
+		SwapOOB("innerHTML", "#toast", text("Added <b>1</b>")).
+		Trigger("cart-changed", map[string]int{"count": 2}).
+		Trigger("opened", nil).
+		Write(w, r)
+	if err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+
+	want := `<span id="badge">2</span><div hx-swap-oob="innerHTML:#toast">Added <b>1</b></div>`
+	if w.Body.String() != want {
+		t.Errorf("Expected body %q, got %q", want, w.Body.String())
+	}
+	if got := w.Header().Get("HX-Trigger"); got != `{"cart-changed":{"count":2},"opened":null}` {
+		t.Errorf("Expected both events in HX-Trigger, got %q", got)
+	}
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Errorf("Expected a 200 HTML response, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if w.Header().Get("HX-Retarget") != "" {
+		t.Error("Expected no HX-Retarget unless asked for")
+	}
+
+	w = httptest.NewRecorder()
+	NewResponse().Swap(text("no")).Retarget("#drawer", "innerHTML").Status(http.StatusConflict).Write(w, r)
+	if w.Code != http.StatusConflict || w.Header().Get("HX-Retarget") != "#drawer" || w.Header().Get("HX-Reswap") != "innerHTML" {
+		t.Errorf("Expected a retargeted 409, got %d with %v", w.Code, w.Header())
+	}
+}
+
+func TestRedirect(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/account", nil)
+	w := httptest.NewRecorder()
+	Redirect(w, r, "/login")
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/login" || IsRequest(r) {
+		t.Errorf("Expected a 303 for a page load, got %d to %q", w.Code, w.Header().Get("Location"))
+	}
+
+	r.Header.Set("HX-Request", "true")
+	w = httptest.NewRecorder()
+	Redirect(w, r, "/login")
+	if !IsRequest(r) || w.Header().Get("HX-Redirect") != "/login" || w.Header().Get("Location") != "" {
+		t.Errorf("Expected HX-Redirect for an htmx request, got %d with %v", w.Code, w.Header())
+	}
+}
@@ -1,180 +1,550 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	_ "embed"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/homveloper/doodle/features/shop-templ/handlers"
 	"github.com/homveloper/doodle/features/shop-templ/models"
-	"github.com/homveloper/doodle/features/shop-templ/templates"
 )
 
+// sampleCatalog is the catalog the shop starts with unless -catalog or
+// SHOP_CATALOG names another
+//
+//go:embed catalog.json
+var sampleCatalog []byte
+
 func main() {
-	// Initialize store and cart
-	store := models.NewProductStore()
-	cart := models.NewCart()
+	dbPath := flag.String("db", "", "SQLite database products, orders and carts are kept in (empty keeps products and orders in memory only, and carts in -carts)")
+	cartsPath := flag.String("carts", "carts.json", "JSON file carts are saved to without -db (empty keeps them in memory only)")
+	mediaDir := flag.String("media", "media", "directory uploaded product images are stored in")
+	adminEmail := flag.String("admin-email", "admin@shop.local", "email of the admin account created at startup")
+	adminPassword := flag.String("admin-password", "", "password of the admin account created at startup (empty creates none)")
+	ratesURL := flag.String("rates-url", "", "exchange rate service returning {\"base\": \"KRW\", \"rates\": {...}} (empty uses built-in rates)")
+	ratesTTL := flag.Duration("rates-ttl", time.Hour, "how long exchange rates from -rates-url are cached")
+	webhookSecret := flag.String("payment-webhook-secret", "sandbox", "secret the sandbox payment gateway signs webhooks with")
+	taxRulesPath := flag.String("tax-rules", "", "JSON file of tax rules (empty charges 10% VAT within Korea)")
+	pointRulesPath := flag.String("point-rules", "", "JSON file of loyalty point rules (empty earns 1% of what is paid)")
+	rateLimitsPath := flag.String("rate-limits", "", "JSON file of rate limits for searches and cart changes, by route group (empty uses built-in limits)")
+	catalogPath := flag.String("catalog", "", "CSV, JSON or YAML file the catalog is imported from at startup (empty imports the sample catalog)")
+	smtpAddr := flag.String("smtp-addr", "", "host:port of the SMTP server order emails are sent through (empty logs them instead)")
+	smtpUser := flag.String("smtp-user", "", "user to sign in to the SMTP server as (empty doesn't sign in)")
+	smtpPassword := flag.String("smtp-password", "", "password for -smtp-user, best given as $SHOP_SMTP_PASSWORD to keep it out of the process list")
+	mailFrom := flag.String("mail-from", "Shop <shop@shop.local>", "sender of order emails")
+	shopURL := flag.String("shop-url", "http://localhost:8080", "address the shop is served at, for links in emails")
+	reserveFor := flag.Duration("reserve-for", 15*time.Minute, "how long stock put in the cart is held for it (0 only checks stock)")
+	cartTTL := flag.Duration("cart-ttl", 30*24*time.Hour, "how long a cart is kept after it last changed, as long as the cart cookie (0 keeps carts for good)")
+	cartReminderAfter := flag.Duration("cart-reminder-after", 0, "how long after a logged-in shopper last changed their cart they are emailed about what they left in it (0 sends no reminders)")
+	eventsPath := flag.String("events", "", "file analytics events are appended to as JSON lines (empty keeps them in -db, or in memory without it)")
+	logFormat := flag.String("log-format", "text", "format of log lines: text, or json for log collectors")
+	listingCacheTTL := flag.Duration("listing-cache-ttl", 30*time.Second, "how long product listings and search results are cached for (0 doesn't cache them)")
+	addr := flag.String("addr", ":8080", "address the server listens on")
+	readTimeout := flag.Duration("read-timeout", 30*time.Second, "longest a request, including its body, may take to arrive")
+	writeTimeout := flag.Duration("write-timeout", time.Minute, "longest a response may take to send, from the end of the request's headers (event streams excepted)")
+	idleTimeout := flag.Duration("idle-timeout", 2*time.Minute, "how long an idle keep-alive connection is kept open")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 30*time.Second, "how long shutting down waits for requests in flight, such as checkouts, to finish")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s:\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintf(flag.CommandLine.Output(), "\nEvery flag can also be set in the environment, as %s and the flag's name in upper case with dashes as underscores, such as %s for -smtp-password.\n", envPrefix, envName("smtp-password"))
+	}
+	flag.Parse()
+	if err := setFlagsFromEnv(); err != nil {
+		log.Fatal(err)
+	}
+
+	switch *logFormat {
+	case "text":
+		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	case "json":
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, nil)))
+	default:
+		log.Fatalf("unknown -log-format %q, want text or json", *logFormat)
+	}
+
+	// Initialize stores
+	store, orders, carts, events, db, closeDB := openStores(*dbPath, *cartsPath, *eventsPath)
+	defer closeDB()
+	users := models.NewUserStore()
+	sessions := models.NewSessionStore()
+	coupons := models.NewCouponStore()
+	promotions := models.NewPromotionStore()
+	store.UsePromotions(promotions)
+	store.CacheListings(*listingCacheTTL)
+	bundles := models.NewBundleStore()
+	images, err := models.NewImageStore(*mediaDir)
+	if err != nil {
+		log.Fatalf("open media directory: %v", err)
+	}
+	payments := models.NewMockPayments(*webhookSecret)
+	taxes := models.DefaultTaxRules
+	if *taxRulesPath != "" {
+		if taxes, err = models.LoadTaxRules(*taxRulesPath); err != nil {
+			log.Fatalf("load tax rules: %v", err)
+		}
+	}
+	pointRules := models.DefaultPointRules
+	if *pointRulesPath != "" {
+		if pointRules, err = models.LoadPointRules(*pointRulesPath); err != nil {
+			log.Fatalf("load point rules: %v", err)
+		}
+	}
+	rateLimits := models.DefaultRateLimits
+	if *rateLimitsPath != "" {
+		if rateLimits, err = models.LoadRateLimits(*rateLimitsPath); err != nil {
+			log.Fatalf("load rate limits: %v", err)
+		}
+	}
+	var mailer models.EmailSender = models.LogSender{}
+	if *smtpAddr != "" {
+		mailer = models.SMTPSender{Addr: *smtpAddr, From: *mailFrom, Username: *smtpUser, Password: *smtpPassword}
+	}
+	points := models.NewPointsLedger()
+	addresses := models.NewAddressBook()
+	pointsHandler := handlers.NewPointsHandler(points, orders)
+	orderMailer := handlers.NewOrderMailer(mailer, *shopURL)
+	orders.OnStatusChange(func(order models.Order) {
+		pointsHandler.OrderChanged(order)
+		orderMailer.OrderChanged(order)
+	})
+	notifications := models.NewNotificationStore()
+	restockAlerts := models.NewRestockAlerts()
+	restockHandler := handlers.NewRestockHandler(store, restockAlerts, notifications, mailer, *shopURL)
+	restockAlerts.OnRestock(restockHandler.ProductRestocked)
+	eventsHandler := handlers.NewEventsHandler()
+	store.OnChange(func(product models.Product) {
+		eventsHandler.ProductChanged(product)
+		restockAlerts.ProductChanged(product)
+	})
 
 	// Seed sample data
-	seedData(store)
+	seedCatalog(store, *catalogPath)
+	seedCoupons(coupons)
+	seedPromotions(promotions, store)
+	seedBundles(bundles, store)
+	if *adminPassword != "" {
+		seedAdmin(users, *adminEmail, *adminPassword)
+	}
 
 	// Initialize handlers
-	productHandler := handlers.NewProductHandler(store, cart)
-	cartHandler := handlers.NewCartHandler(store, cart)
+	limits := handlers.NewRateLimits(rateLimits)
+	analytics := handlers.NewAnalytics(events)
+	metricsHandler := handlers.NewMetricsHandler(store, orders)
+	if db != nil {
+		metricsHandler.Checks["database"] = db.Ping
+	}
+	productHandler := handlers.NewProductHandler(store, orders, restockAlerts, bundles)
+	productHandler.Analytics = analytics
+	cartHandler := handlers.NewCartHandler(store, orders, coupons, bundles, taxes)
+	cartHandler.ReserveFor = *reserveFor
+	cartHandler.Analytics = analytics
+	checkoutHandler := handlers.NewCheckoutHandler(store, orders, coupons, taxes, models.DefaultShipping, payments, points, pointRules, addresses)
+	checkoutHandler.Analytics = analytics
+	authHandler := handlers.NewAuthHandler(store, users, sessions, carts, orders)
+	authHandler.ReserveFor = *reserveFor
+	mediaHandler := handlers.NewMediaHandler(store, images)
+	paymentHandler := handlers.NewPaymentHandler(orders, payments)
+	orderHandler := handlers.NewOrderHandler(store, orders, payments)
+	adminHandler := handlers.NewAdminHandler(store)
+	promotionHandler := handlers.NewPromotionHandler(store, promotions)
+	bundleHandler := handlers.NewBundleHandler(store, bundles)
+	addressHandler := handlers.NewAddressHandler(addresses)
+	apiHandler := handlers.NewAPIHandler(store, orders, coupons, cartHandler, checkoutHandler, orderHandler)
+
+	var rates models.RateProvider = models.DefaultRates
+	if *ratesURL != "" {
+		rates = models.NewHTTPRates(*ratesURL, *ratesTTL)
+	}
+	currencyHandler := handlers.NewCurrencyHandler(rates)
+	notificationHandler := handlers.NewNotificationHandler(notifications)
 
 	// Setup routes
 	mux := http.NewServeMux()
 
 	// Product routes
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/" {
-			http.NotFound(w, r)
-			return
-		}
-		products := store.GetAll()
-		categories := store.GetCategories()
-
-		component := templates.Layout("홈", cart)
-		component.Render(r.Context(), w)
-
-		// Write product list inside
-		w.Write([]byte(`<div class="product-container">`))
-		templates.ProductList(products, categories).Render(r.Context(), w)
-		w.Write([]byte(`</div>`))
-	})
-	mux.HandleFunc("/products", productHandler.HandleProducts)
-	mux.HandleFunc("/search", productHandler.HandleSearch)
+	mux.HandleFunc("GET /{$}", productHandler.HandleHome)
+	mux.HandleFunc("/products", limits.Limit("search", productHandler.HandleProducts))
+	mux.HandleFunc("GET /products/{id}", productHandler.HandleProductDetail)
+	mux.HandleFunc("/search", limits.Limit("search", productHandler.HandleProducts))
+	mux.HandleFunc("GET /search/suggest", limits.Limit("suggest", productHandler.HandleSuggest))
 	mux.HandleFunc("/categories", productHandler.HandleCategories)
+	mux.HandleFunc("GET /categories/{path...}", productHandler.HandleCategory)
+	mux.HandleFunc("GET /events", eventsHandler.HandleEvents)
 
 	// Cart routes
 	mux.HandleFunc("/cart", cartHandler.HandleCart)
-	mux.HandleFunc("/cart/add", cartHandler.HandleAddToCart)
-	mux.HandleFunc("/cart/update", cartHandler.HandleUpdateCart)
-	mux.HandleFunc("/cart/remove", cartHandler.HandleRemoveFromCart)
-	mux.HandleFunc("/cart/clear", cartHandler.HandleClearCart)
+	// Changes to the cart are posted with the visitor's CSRF token, and
+	// rate limited
+	mux.HandleFunc("POST /cart/add", limits.Limit("add_to_cart", handlers.RequireCSRF(cartHandler.HandleAddToCart)))
+	mux.HandleFunc("POST /cart/update", limits.Limit("cart", handlers.RequireCSRF(cartHandler.HandleUpdateCart)))
+	mux.HandleFunc("POST /cart/remove", limits.Limit("cart", handlers.RequireCSRF(cartHandler.HandleRemoveFromCart)))
+	mux.HandleFunc("POST /cart/clear", limits.Limit("cart", handlers.RequireCSRF(cartHandler.HandleClearCart)))
+	mux.HandleFunc("POST /cart/coupon", limits.Limit("cart", handlers.RequireCSRF(cartHandler.HandleApplyCoupon)))
+	mux.HandleFunc("POST /cart/coupon/remove", limits.Limit("cart", handlers.RequireCSRF(cartHandler.HandleRemoveCoupon)))
+	mux.HandleFunc("GET /cart/recommendations", cartHandler.HandleRecommendations)
+	mux.HandleFunc("POST /cart/bundles/{id}", limits.Limit("add_to_cart", handlers.RequireCSRF(cartHandler.HandleAddBundle)))
+	mux.HandleFunc("POST /cart/bundles/{id}/remove", limits.Limit("cart", handlers.RequireCSRF(cartHandler.HandleRemoveBundle)))
+
+	// Checkout routes
+	mux.HandleFunc("GET /checkout", checkoutHandler.HandleCheckout)
+	mux.HandleFunc("POST /checkout", checkoutHandler.HandlePlaceOrder)
+	mux.HandleFunc("GET /checkout/summary", checkoutHandler.HandleCheckoutSummary)
+	mux.HandleFunc("GET /orders/lookup", orderHandler.HandleLookupForm)
+	mux.HandleFunc("POST /orders/lookup", orderHandler.HandleLookup)
+	mux.HandleFunc("GET /orders/{id}", orderHandler.HandleOrder)
+	mux.HandleFunc("POST /orders/{id}/cancel", orderHandler.HandleCancel)
+	mux.HandleFunc("POST /orders/{id}/account", authHandler.HandleOrderAccount)
+
+	// Account routes
+	mux.HandleFunc("GET /login", authHandler.HandleLoginForm)
+	mux.HandleFunc("POST /login", authHandler.HandleLogin)
+	mux.HandleFunc("GET /register", authHandler.HandleRegisterForm)
+	mux.HandleFunc("POST /register", authHandler.HandleRegister)
+	mux.HandleFunc("POST /logout", authHandler.HandleLogout)
+	mux.HandleFunc("GET /account", authHandler.RequireAuth(authHandler.HandleAccount))
+	mux.HandleFunc("GET /notifications", authHandler.RequireAuth(notificationHandler.HandleNotifications))
+	mux.HandleFunc("GET /points", authHandler.RequireAuth(pointsHandler.HandlePoints))
+	mux.HandleFunc("GET /addresses", authHandler.RequireAuth(addressHandler.HandleAddresses))
+	mux.HandleFunc("POST /addresses", authHandler.RequireAuth(addressHandler.HandleCreateAddress))
+	mux.HandleFunc("GET /addresses/{id}/edit", authHandler.RequireAuth(addressHandler.HandleEditAddress))
+	mux.HandleFunc("POST /addresses/{id}", authHandler.RequireAuth(addressHandler.HandleUpdateAddress))
+	mux.HandleFunc("POST /addresses/{id}/delete", authHandler.RequireAuth(addressHandler.HandleDeleteAddress))
+	mux.HandleFunc("POST /addresses/{id}/default", authHandler.RequireAuth(addressHandler.HandleSetDefaultAddress))
+	mux.HandleFunc("POST /products/{id}/restock-alert", authHandler.RequireAuth(restockHandler.HandleWatch))
+	mux.HandleFunc("POST /products/{id}/restock-alert/cancel", authHandler.RequireAuth(restockHandler.HandleUnwatch))
+
+	// Payment routes
+	mux.HandleFunc("POST /payments/webhook", paymentHandler.HandleWebhook)
+
+	// Currency & language routes
+	mux.HandleFunc("POST /currency", currencyHandler.HandleSetCurrency)
+	mux.HandleFunc("POST /locale", handlers.HandleSetLocale)
+
+	// Admin routes
+	mux.HandleFunc("GET /admin/products", authHandler.RequireAdmin(adminHandler.HandleProducts))
+	mux.HandleFunc("GET /admin/products/export", authHandler.RequireAdmin(adminHandler.HandleExport))
+	mux.HandleFunc("GET /admin/products/import", authHandler.RequireAdmin(adminHandler.HandleImportPage))
+	mux.HandleFunc("POST /admin/products/import", authHandler.RequireAdmin(adminHandler.HandleImport))
+	mux.HandleFunc("GET /admin/products/new", authHandler.RequireAdmin(adminHandler.HandleNewProduct))
+	mux.HandleFunc("POST /admin/products", authHandler.RequireAdmin(adminHandler.HandleCreateProduct))
+	mux.HandleFunc("GET /admin/products/{id}/edit", authHandler.RequireAdmin(adminHandler.HandleEditProduct))
+	mux.HandleFunc("POST /admin/products/{id}", authHandler.RequireAdmin(adminHandler.HandleUpdateProduct))
+	mux.HandleFunc("POST /admin/products/{id}/archive", authHandler.RequireAdmin(adminHandler.HandleArchiveProduct))
+	mux.HandleFunc("POST /admin/products/{id}/stock", authHandler.RequireAdmin(adminHandler.HandleAdjustStock))
+	mux.HandleFunc("GET /admin/stock", authHandler.RequireAdmin(adminHandler.HandleQuickStock))
+	mux.HandleFunc("GET /admin/categories", authHandler.RequireAdmin(adminHandler.HandleCategories))
+	mux.HandleFunc("POST /admin/categories/rename", authHandler.RequireAdmin(adminHandler.HandleRenameCategory))
+	mux.HandleFunc("GET /admin/promotions", authHandler.RequireAdmin(promotionHandler.HandlePromotions))
+	mux.HandleFunc("POST /admin/promotions", authHandler.RequireAdmin(promotionHandler.HandleCreatePromotion))
+	mux.HandleFunc("POST /admin/promotions/{id}/delete", authHandler.RequireAdmin(promotionHandler.HandleDeletePromotion))
+	mux.HandleFunc("GET /admin/bundles", authHandler.RequireAdmin(bundleHandler.HandleBundles))
+	mux.HandleFunc("POST /admin/bundles", authHandler.RequireAdmin(bundleHandler.HandleCreateBundle))
+	mux.HandleFunc("POST /admin/bundles/{id}/delete", authHandler.RequireAdmin(bundleHandler.HandleDeleteBundle))
+	mux.HandleFunc("GET /admin/products/{id}/images", authHandler.RequireAdmin(mediaHandler.HandleProductImages))
+	mux.HandleFunc("POST /admin/products/{id}/images", authHandler.RequireAdmin(mediaHandler.HandleUploadProductImage))
+	mux.HandleFunc("GET /admin/orders", authHandler.RequireAdmin(orderHandler.HandleAdminOrders))
+	mux.HandleFunc("POST /admin/orders/{id}/status", authHandler.RequireAdmin(orderHandler.HandleSetStatus))
+	mux.HandleFunc("POST /admin/orders/{id}/refund", authHandler.RequireAdmin(orderHandler.HandleRefund))
+	mux.HandleFunc("GET /admin/analytics", authHandler.RequireAdmin(analytics.HandleDashboard))
+
+	// Monitoring routes
+	mux.HandleFunc("GET /healthz", metricsHandler.HandleHealth)
+	mux.HandleFunc("GET /metrics", metricsHandler.HandleMetrics)
+
+	// Media routes
+	mux.Handle("GET /media/", mediaHandler.Media())
+
+	// JSON API routes
+	for _, route := range apiHandler.Routes() {
+		mux.HandleFunc(route.Method+" "+route.Path, limits.Limit(route.RateLimit, route.Handler))
+	}
 
 	// Start server
-	port := ":8080"
-	fmt.Printf("🛍️  Shop app running at http://localhost%s\n", port)
+	fmt.Printf("🛍️  Shop app listening on %s\n", *addr)
 	fmt.Println("📱 Open in mobile viewport (430px) for best experience")
-	log.Fatal(http.ListenAndServe(port, mux))
+
+	// Middleware, innermost first: a panic is recovered before the
+	// response is compressed and logged
+	var handler http.Handler = metricsHandler.Instrument(mux)
+	handler = analytics.TrackPageViews(handler)
+	handler = pointsHandler.LoadBalance(handler)
+	handler = notificationHandler.LoadUnread(handler)
+	handler = authHandler.LoadSession(handler)
+	handler = currencyHandler.LoadCurrency(handler)
+	handler = handlers.LoadLocale(handler)
+	handler = handlers.LoadCSRF(handler)
+	handler = handlers.Recover(handler)
+	handler = handlers.Compress(handler)
+	handler = handlers.LogRequests(handler)
+	handler = handlers.RequestIDs(handler)
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           handler,
+		ReadHeaderTimeout: min(*readTimeout, readHeaderTimeout),
+		ReadTimeout:       *readTimeout,
+		WriteTimeout:      *writeTimeout,
+		IdleTimeout:       *idleTimeout,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+	// Event streams never finish on their own, so shutting down ends them
+	server.RegisterOnShutdown(eventsHandler.Close)
+
+	cartJanitor := handlers.NewCartJanitor(carts, store, users, mailer, *shopURL)
+	cartJanitor.TTL = *cartTTL
+	cartJanitor.RemindAfter = *cartReminderAfter
+	janitorCtx, stopJanitor := context.WithCancel(context.Background())
+	server.RegisterOnShutdown(stopJanitor)
+	go cartJanitor.Run(janitorCtx)
+
+	serve(server, *shutdownTimeout)
 }
 
-func seedData(store *models.ProductStore) {
-	products := []models.Product{
-		{
-			Name:        "무선 이어폰",
-			Description: "고품질 사운드와 노이즈 캔슬링 기능",
-			Price:       129000,
-			ImageURL:    "",
-			Category:    "전자제품",
-			Stock:       15,
-			Tags:        []string{"audio", "wireless"},
-		},
-		{
-			Name:        "스마트워치",
-			Description: "건강 추적 및 알림 기능",
-			Price:       299000,
-			ImageURL:    "",
-			Category:    "전자제품",
-			Stock:       8,
-			Tags:        []string{"wearable", "smart"},
-		},
-		{
-			Name:        "백팩",
-			Description: "노트북 수납 가능한 여행용 백팩",
-			Price:       89000,
-			ImageURL:    "",
-			Category:    "패션",
-			Stock:       20,
-			Tags:        []string{"bag", "travel"},
-		},
-		{
-			Name:        "텀블러",
-			Description: "보온/보냉 스테인리스 텀블러",
-			Price:       35000,
-			ImageURL:    "",
-			Category:    "생활용품",
-			Stock:       50,
-			Tags:        []string{"bottle", "insulated"},
-		},
-		{
-			Name:        "USB-C 케이블",
-			Description: "고속 충전 및 데이터 전송",
-			Price:       19000,
-			ImageURL:    "",
-			Category:    "전자제품",
-			Stock:       100,
-			Tags:        []string{"cable", "usb-c"},
-		},
-		{
-			Name:        "무선 마우스",
-			Description: "인체공학적 디자인의 무선 마우스",
-			Price:       45000,
-			ImageURL:    "",
-			Category:    "전자제품",
-			Stock:       30,
-			Tags:        []string{"mouse", "wireless"},
-		},
-		{
-			Name:        "노트북 파우치",
-			Description: "13인치 노트북용 보호 파우치",
-			Price:       25000,
-			ImageURL:    "",
-			Category:    "패션",
-			Stock:       25,
-			Tags:        []string{"laptop", "case"},
-		},
-		{
-			Name:        "블루투스 스피커",
-			Description: "휴대용 방수 스피커",
-			Price:       79000,
-			ImageURL:    "",
-			Category:    "전자제품",
-			Stock:       12,
-			Tags:        []string{"speaker", "bluetooth"},
-		},
+// readHeaderTimeout is the longest a request's headers may take to arrive,
+// so clients trickling them in can't hold connections open
+const readHeaderTimeout = 10 * time.Second
+
+// serve runs the server until it is interrupted or terminated, then stops
+// taking requests and waits up to shutdownTimeout for those in flight to
+// finish. Requests still running after that are cut off.
+func serve(server *http.Server, shutdownTimeout time.Duration) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	failed := make(chan error, 1)
+	go func() { failed <- server.ListenAndServe() }()
+	select {
+	case err := <-failed:
+		log.Fatal(err)
+	case <-ctx.Done():
+	}
+	// A second signal stops the shop right away
+	stop()
+
+	slog.Info("shutting down", "timeout", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("requests still running at shutdown were cut off", "error", err)
+		return
+	}
+	slog.Info("shut down")
+}
+
+// envPrefix starts the names of the environment variables flags can be
+// set with
+const envPrefix = "SHOP_"
+
+// envName is the environment variable the flag named name can be set with
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// setFlagsFromEnv sets the flags not given on the command line from their
+// environment variables, if set, so the shop can be configured the way
+// containers usually are. The command line wins over the environment.
+func setFlagsFromEnv() error {
+	given := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	var err error
+	flag.VisitAll(func(f *flag.Flag) {
+		value, ok := os.LookupEnv(envName(f.Name))
+		if given[f.Name] || !ok || err != nil {
+			return
+		}
+		if setErr := f.Value.Set(value); setErr != nil {
+			err = fmt.Errorf("invalid value %q for %s: %v", value, envName(f.Name), setErr)
+		}
+	})
+	return err
+}
+
+// memoryEventLimit is how many analytics events are kept without a
+// database or event file
+const memoryEventLimit = 100000
+
+// openStores opens the product, order and cart stores and the analytics
+// event sink: in the SQLite database at dbPath if there is one, or else in
+// memory with carts in the JSON file at cartsPath. Events go to the file
+// at eventsPath instead if there is one. The database is nil without
+// dbPath.
+func openStores(dbPath, cartsPath, eventsPath string) (*models.ProductStore, *models.OrderStore, *models.CartStore, models.EventSink, *models.SQLiteStore, func()) {
+	var eventFile *models.FileEvents
+	if eventsPath != "" {
+		var err error
+		if eventFile, err = models.OpenEventFile(eventsPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if dbPath == "" {
+		carts := models.NewCartStore()
+		if cartsPath != "" {
+			var err error
+			if carts, err = models.NewJSONCartStore(cartsPath); err != nil {
+				log.Fatalf("load carts: %v", err)
+			}
+		}
+		if eventFile != nil {
+			return models.NewProductStore(), models.NewOrderStore(), carts, eventFile, nil, func() { eventFile.Close() }
+		}
+		return models.NewProductStore(), models.NewOrderStore(), carts, models.NewMemoryEvents(memoryEventLimit), nil, func() {}
+	}
+
+	db, err := models.OpenSQLite(dbPath)
+	if err != nil {
+		log.Fatalf("open database: %v", err)
+	}
+	store, err := models.NewPersistentProductStore(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	orders, err := models.NewPersistentOrderStore(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	carts, err := models.NewPersistentCartStore(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if eventFile != nil {
+		return store, orders, carts, eventFile, db, func() { eventFile.Close(); db.Close() }
+	}
+	return store, orders, carts, db, db, func() { db.Close() }
+}
+
+// seedCatalog imports the catalog at path, or the sample catalog if there
+// is no path and the store is empty. The sample has no product IDs, so
+// importing it into a store kept from the last run would add it twice.
+func seedCatalog(store *models.ProductStore, path string) {
+	var result models.ImportResult
+	var err error
+	if path == "" {
+		if products := store.GetAllWithArchived(); len(products) > 0 {
+			fmt.Printf("✅ Loaded %d products\n", len(products))
+			return
+		}
+		result, err = store.Import(bytes.NewReader(sampleCatalog), models.CatalogJSON, false)
+		if err == nil {
+			err = result.Err()
+		}
+	} else {
+		result, err = store.LoadCatalog(path)
+	}
+	if err != nil {
+		log.Fatalf("load catalog: %v", err)
+	}
+
+	fmt.Printf("✅ Seeded %d products\n", result.Created)
+}
+
+func seedCoupons(coupons *models.CouponStore) {
+	sample := []models.Coupon{
 		{
-			Name:        "손목 보호대",
-			Description: "키보드 사용 시 손목 보호",
-			Price:       15000,
-			ImageURL:    "",
-			Category:    "생활용품",
-			Stock:       40,
-			Tags:        []string{"ergonomic", "wrist"},
+			Code:     "WELCOME10",
+			Type:     models.DiscountPercent,
+			Percent:  10,
+			MinOrder: models.Won(30000),
 		},
 		{
-			Name:        "스마트폰 거치대",
-			Description: "각도 조절 가능한 거치대",
-			Price:       22000,
-			ImageURL:    "",
-			Category:    "전자제품",
-			Stock:       35,
-			Tags:        []string{"phone", "stand"},
+			Code:     "SAVE5000",
+			Type:     models.DiscountFixed,
+			Amount:   models.Won(5000),
+			MinOrder: models.Won(50000),
+			MaxUses:  100,
 		},
 		{
-			Name:        "캔버스 토트백",
-			Description: "친환경 에코백",
-			Price:       18000,
-			ImageURL:    "",
-			Category:    "패션",
-			Stock:       60,
-			Tags:        []string{"bag", "eco"},
+			Code:      "FLASH20",
+			Type:      models.DiscountPercent,
+			Percent:   20,
+			MinOrder:  models.Won(100000),
+			ExpiresAt: time.Now().Add(7 * 24 * time.Hour),
+			MaxUses:   10,
 		},
+	}
+
+	for _, c := range sample {
+		coupons.Add(c)
+	}
+
+	fmt.Printf("✅ Seeded %d coupons\n", len(sample))
+}
+
+// seedPromotions puts a category on sale for a few days and runs a short
+// flash sale on the first product, if there is one
+func seedPromotions(promotions *models.PromotionStore, store *models.ProductStore) {
+	now := time.Now()
+	sample := []models.Promotion{
 		{
-			Name:        "LED 데스크 램프",
-			Description: "밝기 조절 가능 LED 램프",
-			Price:       65000,
-			ImageURL:    "",
-			Category:    "생활용품",
-			Stock:       18,
-			Tags:        []string{"lamp", "led"},
+			Name:     "전자제품 주간 세일",
+			Category: "전자제품",
+			Type:     models.DiscountPercent,
+			Percent:  10,
+			StartsAt: now,
+			EndsAt:   now.Add(3 * 24 * time.Hour),
 		},
 	}
+	if products := store.GetAll(); len(products) > 0 {
+		sample = append(sample, models.Promotion{
+			Name:      "타임 세일",
+			ProductID: products[0].ID,
+			Type:      models.DiscountFixed,
+			Amount:    models.Won(5000),
+			StartsAt:  now,
+			EndsAt:    now.Add(2 * time.Hour),
+		})
+	}
+
+	for _, p := range sample {
+		promotions.Add(p)
+	}
+
+	fmt.Printf("✅ Seeded %d promotions\n", len(sample))
+}
+
+// seedBundles sells the first two products without variants together for
+// 10% less than they cost apart
+func seedBundles(bundles *models.BundleStore, store *models.ProductStore) {
+	var items []models.BundleItem
+	for _, product := range store.GetAll() {
+		if !product.HasVariants() && len(items) < 2 {
+			items = append(items, models.BundleItem{ProductID: product.ID, Quantity: 1})
+		}
+	}
+	offer, ok := store.BundleOffer(models.Bundle{Items: items})
+	if len(items) < 2 || !ok {
+		return
+	}
+
+	regular := offer.RegularPrice()
+	if _, err := bundles.Add(models.Bundle{Name: "함께 사면 좋은 세트", Items: items, Price: regular.Sub(regular.Percent(10))}); err != nil {
+		log.Printf("seed bundle: %v", err)
+		return
+	}
 
-	for _, p := range products {
-		store.Add(p)
+	fmt.Println("✅ Seeded 1 bundle")
+}
+
+func seedAdmin(users *models.UserStore, email, password string) {
+	user, err := users.Register(email, "관리자", password)
+	if err != nil {
+		log.Fatalf("create admin account: %v", err)
+	}
+	if _, err := users.SetAdmin(user.ID, true); err != nil {
+		log.Fatalf("create admin account: %v", err)
 	}
 
-	fmt.Printf("✅ Seeded %d products\n", len(products))
+	fmt.Printf("✅ Admin account: %s\n", user.Email)
 }
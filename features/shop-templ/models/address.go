@@ -0,0 +1,258 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrAddressNotFound is returned for an address the user doesn't have
+	ErrAddressNotFound = errors.New("address not found")
+	// ErrAddressIncomplete is returned for an address without a name, a
+	// phone number or the address itself
+	ErrAddressIncomplete = errors.New("address needs a name, a phone number and an address")
+	// ErrInvalidAddressKind is returned for an address that is neither for
+	// shipping nor for billing
+	ErrInvalidAddressKind = errors.New("address must be for shipping or billing")
+)
+
+// AddressKind is what an address is used for
+type AddressKind string
+
+const (
+	// AddressShipping is an address orders are shipped to
+	AddressShipping AddressKind = "shipping"
+	// AddressBilling is an address cards are billed to
+	AddressBilling AddressKind = "billing"
+)
+
+// Address is an address a user saved to fill in at checkout
+type Address struct {
+	ID     int         `json:"id"`
+	UserID int         `json:"userId"`
+	Kind   AddressKind `json:"kind"`
+	// Label names the address for the user, such as "집" or "회사"
+	Label   string `json:"label,omitempty"`
+	Name    string `json:"name"`
+	Phone   string `json:"phone"`
+	Address string `json:"address"`
+	// Country is where the address is; empty means DefaultCountry
+	Country string `json:"country,omitempty"`
+	// Default addresses are filled in at checkout. Each user has one of
+	// each kind they have saved.
+	Default bool `json:"default"`
+}
+
+// Validate checks that the address has a kind and every required field,
+// and that the shop ships to shipping addresses' countries
+func (a Address) Validate() error {
+	if a.Kind != AddressShipping && a.Kind != AddressBilling {
+		return ErrInvalidAddressKind
+	}
+	if strings.TrimSpace(a.Name) == "" || strings.TrimSpace(a.Phone) == "" || strings.TrimSpace(a.Address) == "" {
+		return ErrAddressIncomplete
+	}
+	if a.Kind == AddressShipping && !slices.Contains(ShippingCountries, a.Destination()) {
+		return ErrUnsupportedCountry
+	}
+	return nil
+}
+
+// Destination returns the country the address is in
+func (a Address) Destination() string {
+	if a.Country == "" {
+		return DefaultCountry
+	}
+	return a.Country
+}
+
+// Line returns the address on one line with who it is for, as a billing
+// address is kept on an order
+func (a Address) Line() string {
+	return a.Name + ", " + a.Address + " (" + a.Destination() + ")"
+}
+
+// FillShipping fills in the shipping details of an order from the
+// address, keeping the email, memo and method already there
+func (a Address) FillShipping(shipping ShippingInfo) ShippingInfo {
+	shipping.Name = a.Name
+	shipping.Phone = a.Phone
+	shipping.Address = a.Address
+	shipping.Country = a.Country
+	return shipping
+}
+
+// AddressBook keeps users' saved addresses with thread-safe operations
+type AddressBook struct {
+	mu        sync.RWMutex
+	addresses map[int]Address
+	nextID    int
+}
+
+// NewAddressBook creates an empty address book
+func NewAddressBook() *AddressBook {
+	return &AddressBook{
+		addresses: make(map[int]Address),
+		nextID:    1,
+	}
+}
+
+// ForUser returns the user's addresses of kind, or of every kind if kind
+// is empty: defaults first, then oldest first
+func (b *AddressBook) ForUser(userID int, kind AddressKind) []Address {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var addresses []Address
+	for _, a := range b.addresses {
+		if a.UserID == userID && (kind == "" || a.Kind == kind) {
+			addresses = append(addresses, a)
+		}
+	}
+	slices.SortFunc(addresses, func(a, b Address) int {
+		if a.Default != b.Default {
+			if a.Default {
+				return -1
+			}
+			return 1
+		}
+		return a.ID - b.ID
+	})
+	return addresses
+}
+
+// Get returns one of the user's addresses. Other users' addresses are not
+// found.
+func (b *AddressBook) Get(userID, id int) (Address, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	a, exists := b.addresses[id]
+	if !exists || a.UserID != userID {
+		return Address{}, ErrAddressNotFound
+	}
+	return a, nil
+}
+
+// Default returns the user's default address of kind, if they have saved
+// one
+func (b *AddressBook) Default(userID int, kind AddressKind) (Address, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.defaultUnlocked(userID, kind)
+}
+
+// Add validates an address and saves it with an assigned ID. The user's
+// first address of a kind becomes its default, as does one marked Default.
+func (b *AddressBook) Add(address Address) (Address, error) {
+	if err := address.Validate(); err != nil {
+		return Address{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	address.ID = b.nextID
+	b.nextID++
+	if _, hasDefault := b.defaultUnlocked(address.UserID, address.Kind); !hasDefault {
+		address.Default = true
+	}
+	b.addresses[address.ID] = address
+	if address.Default {
+		b.setDefaultUnlocked(address)
+	}
+
+	return address, nil
+}
+
+// Update replaces one of the user's addresses. Its kind can't change, and
+// it stays the default if it was; marking it Default makes it so.
+func (b *AddressBook) Update(address Address) (Address, error) {
+	if err := address.Validate(); err != nil {
+		return Address{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stored, exists := b.addresses[address.ID]
+	if !exists || stored.UserID != address.UserID {
+		return Address{}, ErrAddressNotFound
+	}
+	address.Kind = stored.Kind
+	address.Default = address.Default || stored.Default
+	b.addresses[address.ID] = address
+	if address.Default {
+		b.setDefaultUnlocked(address)
+	}
+
+	return address, nil
+}
+
+// SetDefault makes one of the user's addresses the default of its kind
+func (b *AddressBook) SetDefault(userID, id int) (Address, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	address, exists := b.addresses[id]
+	if !exists || address.UserID != userID {
+		return Address{}, ErrAddressNotFound
+	}
+	address.Default = true
+	b.addresses[id] = address
+	b.setDefaultUnlocked(address)
+
+	return address, nil
+}
+
+// Delete removes one of the user's addresses. If it was the default, the
+// oldest address of its kind left becomes the default.
+func (b *AddressBook) Delete(userID, id int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	address, exists := b.addresses[id]
+	if !exists || address.UserID != userID {
+		return ErrAddressNotFound
+	}
+	delete(b.addresses, id)
+
+	if address.Default {
+		oldest := Address{}
+		for _, a := range b.addresses {
+			if a.UserID == userID && a.Kind == address.Kind && (oldest.ID == 0 || a.ID < oldest.ID) {
+				oldest = a
+			}
+		}
+		if oldest.ID != 0 {
+			oldest.Default = true
+			b.addresses[oldest.ID] = oldest
+		}
+	}
+	return nil
+}
+
+// defaultUnlocked returns the user's default address of kind. The caller
+// must hold b.mu.
+func (b *AddressBook) defaultUnlocked(userID int, kind AddressKind) (Address, bool) {
+	for _, a := range b.addresses {
+		if a.UserID == userID && a.Kind == kind && a.Default {
+			return a, true
+		}
+	}
+	return Address{}, false
+}
+
+// setDefaultUnlocked takes the default off the user's other addresses of
+// the kind of address. The caller must hold b.mu.
+func (b *AddressBook) setDefaultUnlocked(address Address) {
+	for id, a := range b.addresses {
+		if a.UserID == address.UserID && a.Kind == address.Kind && id != address.ID && a.Default {
+			a.Default = false
+			b.addresses[id] = a
+		}
+	}
+}
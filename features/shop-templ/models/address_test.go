@@ -0,0 +1,133 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func testAddress(userID int, kind AddressKind) Address {
+	return Address{UserID: userID, Kind: kind, Name: "홍길동", Phone: "010-1234-5678", Address: "서울시 강남구"}
+}
+
+func TestAddressValidate(t *testing.T) {
+	if err := testAddress(1, AddressShipping).Validate(); err != nil {
+		t.Errorf("Expected a valid address, got %v", err)
+	}
+
+	address := testAddress(1, "")
+	if err := address.Validate(); !errors.Is(err, ErrInvalidAddressKind) {
+		t.Errorf("Expected ErrInvalidAddressKind, got %v", err)
+	}
+	address = testAddress(1, AddressShipping)
+	address.Phone = " "
+	if err := address.Validate(); !errors.Is(err, ErrAddressIncomplete) {
+		t.Errorf("Expected ErrAddressIncomplete, got %v", err)
+	}
+
+	// Cards can be billed anywhere, but orders only ship to some countries
+	address = testAddress(1, AddressShipping)
+	address.Country = "FR"
+	if err := address.Validate(); !errors.Is(err, ErrUnsupportedCountry) {
+		t.Errorf("Expected ErrUnsupportedCountry, got %v", err)
+	}
+	address.Kind = AddressBilling
+	if err := address.Validate(); err != nil {
+		t.Errorf("Expected a billing address abroad to be valid, got %v", err)
+	}
+}
+
+func TestAddressFillShipping(t *testing.T) {
+	address := testAddress(1, AddressShipping)
+	address.Country = "JP"
+	shipping := address.FillShipping(ShippingInfo{Name: "Old", Email: "a@example.com", Method: "express"})
+
+	if shipping.Name != address.Name || shipping.Address != address.Address || shipping.Country != "JP" {
+		t.Errorf("Expected the address filled in, got %+v", shipping)
+	}
+	if shipping.Email != "a@example.com" || shipping.Method != "express" {
+		t.Errorf("Expected the rest kept, got %+v", shipping)
+	}
+	if got := address.Line(); got != "홍길동, 서울시 강남구 (JP)" {
+		t.Errorf("Unexpected line %q", got)
+	}
+}
+
+func TestAddressBookDefaults(t *testing.T) {
+	book := NewAddressBook()
+
+	home, err := book.Add(testAddress(1, AddressShipping))
+	if err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if !home.Default {
+		t.Error("Expected the first address of a kind to be the default")
+	}
+	office, _ := book.Add(testAddress(1, AddressShipping))
+	billing, _ := book.Add(testAddress(1, AddressBilling))
+	if office.Default || !billing.Default {
+		t.Errorf("Expected a default per kind, got %+v and %+v", office, billing)
+	}
+	book.Add(testAddress(2, AddressShipping))
+
+	if _, err := book.SetDefault(1, office.ID); err != nil {
+		t.Fatalf("SetDefault() failed: %v", err)
+	}
+	if got, _ := book.Default(1, AddressShipping); got.ID != office.ID {
+		t.Errorf("Expected the office to be the default, got %+v", got)
+	}
+	if got, _ := book.Default(1, AddressBilling); got.ID != billing.ID {
+		t.Error("Expected the billing default to be left alone")
+	}
+	if got := book.ForUser(1, AddressShipping); len(got) != 2 || got[0].ID != office.ID || got[1].Default {
+		t.Errorf("Expected the default first and only one default, got %+v", got)
+	}
+	if got := book.ForUser(1, ""); len(got) != 3 {
+		t.Errorf("Expected every kind of the user's addresses, got %d", len(got))
+	}
+
+	// Deleting the default hands it to the oldest left
+	if err := book.Delete(1, office.ID); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if got, _ := book.Default(1, AddressShipping); got.ID != home.ID {
+		t.Errorf("Expected home to be the default again, got %+v", got)
+	}
+	book.Delete(1, home.ID)
+	if _, ok := book.Default(1, AddressShipping); ok {
+		t.Error("Expected no default without addresses")
+	}
+}
+
+func TestAddressBookOwnership(t *testing.T) {
+	book := NewAddressBook()
+	address, _ := book.Add(testAddress(1, AddressShipping))
+
+	if _, err := book.Get(2, address.ID); !errors.Is(err, ErrAddressNotFound) {
+		t.Errorf("Expected another user's address not to be found, got %v", err)
+	}
+	if err := book.Delete(2, address.ID); !errors.Is(err, ErrAddressNotFound) {
+		t.Errorf("Expected another user not to delete the address, got %v", err)
+	}
+	if _, err := book.SetDefault(2, address.ID); !errors.Is(err, ErrAddressNotFound) {
+		t.Errorf("Expected another user not to change the default, got %v", err)
+	}
+
+	changed := address
+	changed.UserID = 2
+	changed.Address = "부산시"
+	if _, err := book.Update(changed); !errors.Is(err, ErrAddressNotFound) {
+		t.Errorf("Expected another user not to update the address, got %v", err)
+	}
+
+	// The kind stays and so does being the default
+	changed.UserID = 1
+	changed.Kind = AddressBilling
+	changed.Default = false
+	updated, err := book.Update(changed)
+	if err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if updated.Address != "부산시" || updated.Kind != AddressShipping || !updated.Default {
+		t.Errorf("Unexpected update %+v", updated)
+	}
+}
@@ -0,0 +1,301 @@
+package models
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventKind is what a shopper did
+type EventKind string
+
+const (
+	// EventPageView is a page of the shop being shown
+	EventPageView EventKind = "page_view"
+	// EventSearch is a search for products
+	EventSearch EventKind = "search"
+	// EventAddToCart is a product or bundle being put in the cart
+	EventAddToCart EventKind = "add_to_cart"
+	// EventCheckout is checkout being started
+	EventCheckout EventKind = "checkout"
+	// EventPurchase is an order being placed
+	EventPurchase EventKind = "purchase"
+)
+
+// FunnelSteps are the steps from looking around to buying, in order
+var FunnelSteps = []EventKind{EventPageView, EventAddToCart, EventCheckout, EventPurchase}
+
+// Event is something a shopper did, for analytics. Only the fields that
+// say something about its kind are set.
+type Event struct {
+	Kind EventKind `json:"kind"`
+	At   time.Time `json:"at"`
+	// Visitor tells apart who did what without saying who they are
+	Visitor string `json:"visitor"`
+	// Path is the page viewed
+	Path string `json:"path,omitempty"`
+	// Query is what was searched for, and Results how many products it
+	// found
+	Query   string `json:"query,omitempty"`
+	Results int    `json:"results,omitempty"`
+	// ProductID and Quantity are what was put in the cart. Bundles are
+	// recorded as BundleID instead.
+	ProductID int `json:"productId,omitempty"`
+	BundleID  int `json:"bundleId,omitempty"`
+	Quantity  int `json:"quantity,omitempty"`
+	// OrderID is the order placed
+	OrderID int `json:"orderId,omitempty"`
+}
+
+// EventSink records analytics events and reads them back for reports
+type EventSink interface {
+	RecordEvent(event Event) error
+	// LoadEvents returns the events recorded at since or later, oldest
+	// first
+	LoadEvents(since time.Time) ([]Event, error)
+}
+
+// MemoryEvents keeps analytics events in memory, up to a limit past which
+// the oldest tenth are dropped
+type MemoryEvents struct {
+	mu     sync.RWMutex
+	events []Event
+	limit  int
+}
+
+// NewMemoryEvents creates an event sink keeping the latest limit events
+func NewMemoryEvents(limit int) *MemoryEvents {
+	return &MemoryEvents{limit: limit}
+}
+
+// RecordEvent keeps the event, making room for it first if there are too
+// many
+func (m *MemoryEvents) RecordEvent(event Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.events) >= m.limit {
+		drop := len(m.events) - m.limit + 1 + m.limit/10
+		m.events = append(m.events[:0], m.events[drop:]...)
+	}
+	m.events = append(m.events, event)
+	return nil
+}
+
+// LoadEvents returns the events kept from since on
+func (m *MemoryEvents) LoadEvents(since time.Time) ([]Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i := sort.Search(len(m.events), func(i int) bool { return !m.events[i].At.Before(since) })
+	return append([]Event(nil), m.events[i:]...), nil
+}
+
+// FileEvents appends analytics events to a file, one JSON object per line,
+// for other tools to read as well
+type FileEvents struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// OpenEventFile opens the event file at path for appending, creating it if
+// it doesn't exist
+func OpenEventFile(path string) (*FileEvents, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open event file: %w", err)
+	}
+	return &FileEvents{file: file}, nil
+}
+
+// Close closes the file
+func (f *FileEvents) Close() error {
+	return f.file.Close()
+}
+
+// RecordEvent appends the event to the file
+func (f *FileEvents) RecordEvent(event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, err = f.file.Write(append(data, '\n'))
+	return err
+}
+
+// LoadEvents reads the file for the events from since on. Lines that
+// aren't events, such as one cut short by a crash, are skipped.
+func (f *FileEvents) LoadEvents(since time.Time) ([]Event, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.file.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	var events []Event
+	scanner := bufio.NewScanner(f.file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+		if !event.At.Before(since) {
+			events = append(events, event)
+		}
+	}
+	return events, scanner.Err()
+}
+
+// FunnelStep is how many visitors took a step towards buying
+type FunnelStep struct {
+	Kind     EventKind
+	Visitors int
+	// FromStart and FromPrevious are the share of visitors of the first
+	// step and of the step before who took this one, from 0 to 1
+	FromStart    float64
+	FromPrevious float64
+}
+
+// Funnel counts the visitors who took each of FunnelSteps. Each step
+// counts everyone who took it, whether or not they were seen taking the
+// ones before: a shopper who logs in on the way is a new visitor from then
+// on.
+func Funnel(events []Event) []FunnelStep {
+	visitors := make(map[EventKind]map[string]bool)
+	for _, event := range events {
+		if visitors[event.Kind] == nil {
+			visitors[event.Kind] = make(map[string]bool)
+		}
+		visitors[event.Kind][event.Visitor] = true
+	}
+
+	steps := make([]FunnelStep, len(FunnelSteps))
+	for i, kind := range FunnelSteps {
+		steps[i] = FunnelStep{Kind: kind, Visitors: len(visitors[kind])}
+		if i > 0 {
+			steps[i].FromStart = share(steps[i].Visitors, steps[0].Visitors)
+			steps[i].FromPrevious = share(steps[i].Visitors, steps[i-1].Visitors)
+		} else if steps[i].Visitors > 0 {
+			steps[i].FromStart, steps[i].FromPrevious = 1, 1
+		}
+	}
+	return steps
+}
+
+// share is n out of total, or 0 out of none
+func share(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// searchTypingGap is how soon a visitor's next search must follow for it
+// to be them still typing the last one
+const searchTypingGap = 30 * time.Second
+
+// SearchCount is how often something was searched for
+type SearchCount struct {
+	Query    string
+	Searches int
+	// NoResults is how many of the searches found nothing
+	NoResults int
+}
+
+// TopSearches returns the n queries searched for most, most first, with
+// ties in alphabetical order. Queries are compared ignoring case and
+// spacing. The search box searches as the shopper types, so a search
+// followed shortly by the same visitor's search for more of it, such as
+// "노트" before "노트북", is taken to be the same search unfinished and
+// isn't counted.
+func TopSearches(events []Event, n int) []SearchCount {
+	var searches []Event
+	last := make(map[string]int)
+	for _, event := range events {
+		if event.Kind != EventSearch {
+			continue
+		}
+		event.Query = strings.ToLower(strings.Join(strings.Fields(event.Query), " "))
+		if event.Query == "" {
+			continue
+		}
+		if i, ok := last[event.Visitor]; ok {
+			prev := searches[i]
+			if strings.HasPrefix(event.Query, prev.Query) && event.At.Sub(prev.At) <= searchTypingGap {
+				searches[i] = event
+				continue
+			}
+		}
+		last[event.Visitor] = len(searches)
+		searches = append(searches, event)
+	}
+
+	counts := make(map[string]*SearchCount)
+	for _, search := range searches {
+		count, ok := counts[search.Query]
+		if !ok {
+			count = &SearchCount{Query: search.Query}
+			counts[search.Query] = count
+		}
+		count.Searches++
+		if search.Results == 0 {
+			count.NoResults++
+		}
+	}
+
+	top := make([]SearchCount, 0, len(counts))
+	for _, count := range counts {
+		top = append(top, *count)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Searches != top[j].Searches {
+			return top[i].Searches > top[j].Searches
+		}
+		return top[i].Query < top[j].Query
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// AnalyticsReport sums up what shoppers did over a period
+type AnalyticsReport struct {
+	Since       time.Time
+	PageViews   int
+	Visitors    int
+	Funnel      []FunnelStep
+	TopSearches []SearchCount
+}
+
+// NewAnalyticsReport sums up the events from since on, listing the top
+// searches searches
+func NewAnalyticsReport(events []Event, since time.Time, searches int) AnalyticsReport {
+	var recent []Event
+	visitors := make(map[string]bool)
+	report := AnalyticsReport{Since: since}
+	for _, event := range events {
+		if event.At.Before(since) {
+			continue
+		}
+		recent = append(recent, event)
+		visitors[event.Visitor] = true
+		if event.Kind == EventPageView {
+			report.PageViews++
+		}
+	}
+
+	report.Visitors = len(visitors)
+	report.Funnel = Funnel(recent)
+	report.TopSearches = TopSearches(recent, searches)
+	return report
+}
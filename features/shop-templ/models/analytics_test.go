@@ -0,0 +1,154 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEventSinks(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2025, time.March, 14, 9, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Kind: EventPageView, At: start, Visitor: "a", Path: "/"},
+		{Kind: EventSearch, At: start.Add(time.Minute), Visitor: "a", Query: "노트북", Results: 2},
+		{Kind: EventAddToCart, At: start.Add(2 * time.Minute), Visitor: "a", ProductID: 1, Quantity: 2},
+	}
+
+	sinks := map[string]func() EventSink{
+		"memory": func() EventSink { return NewMemoryEvents(100) },
+		"file": func() EventSink {
+			f, err := OpenEventFile(filepath.Join(dir, "events.jsonl"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { f.Close() })
+			return f
+		},
+		"sqlite": func() EventSink {
+			db, err := OpenSQLite(filepath.Join(dir, "shop.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { db.Close() })
+			return db
+		},
+	}
+	for name, open := range sinks {
+		sink := open()
+		for _, event := range events {
+			if err := sink.RecordEvent(event); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+
+		got, err := sink.LoadEvents(start.Add(time.Minute))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(got) != 2 || got[0].Query != "노트북" || got[0].Results != 2 || got[1].Quantity != 2 || !got[1].At.Equal(events[2].At) {
+			t.Errorf("%s: expected the events from the search on, got %+v", name, got)
+		}
+	}
+
+	// The file is appended to and keeps events across restarts, skipping
+	// a line cut short
+	path := filepath.Join(dir, "events.jsonl")
+	data, _ := os.ReadFile(path)
+	os.WriteFile(path, append(data, `{"kind":"page_vi`...), 0o644)
+	f, err := OpenEventFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if got, err := f.LoadEvents(time.Time{}); err != nil || len(got) != 3 {
+		t.Errorf("Expected the 3 events written before, got %d, %v", len(got), err)
+	}
+}
+
+func TestMemoryEventsLimit(t *testing.T) {
+	sink := NewMemoryEvents(20)
+	start := time.Now()
+	for i := range 25 {
+		sink.RecordEvent(Event{Kind: EventPageView, At: start.Add(time.Duration(i) * time.Second), Visitor: "a"})
+	}
+
+	events, _ := sink.LoadEvents(time.Time{})
+	if len(events) > 20 {
+		t.Fatalf("Expected at most 20 events, got %d", len(events))
+	}
+	if last := events[len(events)-1]; !last.At.Equal(start.Add(24 * time.Second)) {
+		t.Errorf("Expected the latest event to be kept, got %v", last.At)
+	}
+}
+
+func TestFunnel(t *testing.T) {
+	var events []Event
+	for _, visitor := range []string{"a", "b", "c", "d"} {
+		events = append(events, Event{Kind: EventPageView, Visitor: visitor}, Event{Kind: EventPageView, Visitor: visitor})
+	}
+	events = append(events,
+		Event{Kind: EventAddToCart, Visitor: "a"},
+		Event{Kind: EventAddToCart, Visitor: "a"},
+		Event{Kind: EventAddToCart, Visitor: "b"},
+		Event{Kind: EventCheckout, Visitor: "a"},
+		Event{Kind: EventPurchase, Visitor: "a"},
+		Event{Kind: EventSearch, Visitor: "c"},
+	)
+
+	funnel := Funnel(events)
+	want := []FunnelStep{
+		{Kind: EventPageView, Visitors: 4, FromStart: 1, FromPrevious: 1},
+		{Kind: EventAddToCart, Visitors: 2, FromStart: 0.5, FromPrevious: 0.5},
+		{Kind: EventCheckout, Visitors: 1, FromStart: 0.25, FromPrevious: 0.5},
+		{Kind: EventPurchase, Visitors: 1, FromStart: 0.25, FromPrevious: 1},
+	}
+	if len(funnel) != len(want) {
+		t.Fatalf("Expected %d steps, got %d", len(want), len(funnel))
+	}
+	for i := range want {
+		if funnel[i] != want[i] {
+			t.Errorf("Step %d: expected %+v, got %+v", i, want[i], funnel[i])
+		}
+	}
+
+	if empty := Funnel(nil); empty[0].FromStart != 0 || empty[3].FromPrevious != 0 {
+		t.Errorf("Expected no visitors to convert nothing, got %+v", empty)
+	}
+}
+
+func TestTopSearches(t *testing.T) {
+	start := time.Now()
+	search := func(visitor string, after time.Duration, query string, results int) Event {
+		return Event{Kind: EventSearch, Visitor: visitor, At: start.Add(after), Query: query, Results: results}
+	}
+	events := []Event{
+		// Typed as it was searched for, then searched again later
+		search("a", 0, "노", 9),
+		search("a", time.Second, "노트", 3),
+		search("a", 2*time.Second, "노트북", 2),
+		search("a", 5*time.Minute, "노트북", 2),
+		// Someone else, with other spacing and case
+		search("b", 0, " 노트북  ", 2),
+		search("b", time.Second, "Mouse", 1),
+		search("c", 0, "mouse", 1),
+		search("c", time.Second, "키보드", 0),
+		search("c", time.Minute, "키보드", 0),
+		{Kind: EventPageView, Visitor: "a"},
+	}
+
+	top := TopSearches(events, 2)
+	want := []SearchCount{
+		{Query: "노트북", Searches: 3},
+		{Query: "mouse", Searches: 2},
+	}
+	if len(top) != len(want) || top[0] != want[0] || top[1] != want[1] {
+		t.Errorf("Expected %+v, got %+v", want, top)
+	}
+
+	all := TopSearches(events, 10)
+	if len(all) != 3 || all[2] != (SearchCount{Query: "키보드", Searches: 2, NoResults: 2}) {
+		t.Errorf("Expected searches with no results counted, got %+v", all)
+	}
+}
@@ -0,0 +1,253 @@
+package models
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrBundleNotFound is returned for an ID no bundle has
+	ErrBundleNotFound = errors.New("bundle not found")
+	// ErrInvalidBundle is returned for a bundle without a name, a price or
+	// at least two different products
+	ErrInvalidBundle = errors.New("bundle needs a name, a price and at least two different products")
+)
+
+// BundleItem is a product, or a variant of one, in a bundle and how many
+// of it the bundle holds
+type BundleItem struct {
+	ProductID int `json:"productId"`
+	// VariantID is zero for products without variants
+	VariantID int `json:"variantId,omitempty"`
+	Quantity  int `json:"quantity"`
+}
+
+// Key returns the product and variant of the item
+func (i BundleItem) Key() ItemKey {
+	return ItemKey{ProductID: i.ProductID, VariantID: i.VariantID}
+}
+
+// Bundle sells several products together for less than they cost apart.
+// Its products go in the cart as they are, and the cart takes off what the
+// bundle saves for as long as it holds all of them.
+type Bundle struct {
+	ID    int          `json:"id"`
+	Name  string       `json:"name"`
+	Items []BundleItem `json:"items"`
+	Price Money        `json:"price"`
+}
+
+// Validate checks that the bundle has a name, a price and at least two
+// different products, each at least once
+func (b Bundle) Validate() error {
+	if strings.TrimSpace(b.Name) == "" || b.Price.Amount <= 0 || len(b.Items) < 2 {
+		return ErrInvalidBundle
+	}
+	for i, item := range b.Items {
+		if item.Quantity < 1 || slices.ContainsFunc(b.Items[:i], func(other BundleItem) bool { return other.Key() == item.Key() }) {
+			return ErrInvalidBundle
+		}
+	}
+	return nil
+}
+
+// Contains reports whether the bundle holds the product, in any variant
+func (b Bundle) Contains(productID int) bool {
+	return slices.ContainsFunc(b.Items, func(item BundleItem) bool { return item.ProductID == productID })
+}
+
+// BundleStore manages bundles with thread-safe operations
+type BundleStore struct {
+	mu      sync.RWMutex
+	bundles map[int]Bundle
+	nextID  int
+}
+
+// NewBundleStore creates a new bundle store
+func NewBundleStore() *BundleStore {
+	return &BundleStore{
+		bundles: make(map[int]Bundle),
+		nextID:  1,
+	}
+}
+
+// Add validates a bundle and stores it with an assigned ID
+func (s *BundleStore) Add(bundle Bundle) (Bundle, error) {
+	if err := bundle.Validate(); err != nil {
+		return Bundle{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bundle.ID = s.nextID
+	s.nextID++
+	s.bundles[bundle.ID] = bundle
+
+	return bundle, nil
+}
+
+// GetByID returns a bundle by ID
+func (s *BundleStore) GetByID(id int) (Bundle, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bundle, exists := s.bundles[id]
+	return bundle, exists
+}
+
+// Delete removes a bundle. Carts it was put in keep its products but no
+// longer save anything on them.
+func (s *BundleStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.bundles[id]; !exists {
+		return ErrBundleNotFound
+	}
+	delete(s.bundles, id)
+	return nil
+}
+
+// GetAll returns every bundle, oldest first
+func (s *BundleStore) GetAll() []Bundle {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bundles := make([]Bundle, 0, len(s.bundles))
+	for _, b := range s.bundles {
+		bundles = append(bundles, b)
+	}
+	slices.SortFunc(bundles, func(a, b Bundle) int { return a.ID - b.ID })
+	return bundles
+}
+
+// Containing returns the bundles that hold the product, oldest first
+func (s *BundleStore) Containing(productID int) []Bundle {
+	var bundles []Bundle
+	for _, b := range s.GetAll() {
+		if b.Contains(productID) {
+			bundles = append(bundles, b)
+		}
+	}
+	return bundles
+}
+
+// BundleOffer is a bundle with its products as they are sold now
+type BundleOffer struct {
+	Bundle Bundle
+	// Products are the bundle's products by ID
+	Products map[int]Product
+}
+
+// BundleOffer returns the bundle with its products as they are sold now,
+// or false if any of them is no longer sold
+func (s *ProductStore) BundleOffer(bundle Bundle) (BundleOffer, bool) {
+	offer := BundleOffer{Bundle: bundle, Products: make(map[int]Product)}
+	for _, item := range bundle.Items {
+		product, exists := s.GetByID(item.ProductID)
+		if !exists || product.Archived || variantOf(product, item.Key()) != nil {
+			return BundleOffer{}, false
+		}
+		offer.Products[product.ID] = product
+	}
+	return offer, true
+}
+
+// RegularPrice returns what the bundle's items cost bought apart
+func (o BundleOffer) RegularPrice() Money {
+	var total Money
+	for _, item := range o.Bundle.Items {
+		total = total.Add(o.Products[item.ProductID].PriceOf(item.VariantID).Mul(item.Quantity))
+	}
+	return total
+}
+
+// Savings returns what buying the bundle saves, or nothing if its items
+// cost less apart
+func (o BundleOffer) Savings() Money {
+	if regular := o.RegularPrice(); regular.Cmp(o.Bundle.Price) > 0 {
+		return regular.Sub(o.Bundle.Price)
+	}
+	return Money{}
+}
+
+// InStock reports whether there is stock of every item for the bundle
+func (o BundleOffer) InStock() bool {
+	for _, item := range o.Bundle.Items {
+		if o.Products[item.ProductID].StockOf(item.VariantID) < item.Quantity {
+			return false
+		}
+	}
+	return true
+}
+
+// CartBundle is a bundle put in a cart and how many times
+type CartBundle struct {
+	Bundle   Bundle `json:"bundle"`
+	Quantity int    `json:"quantity"`
+}
+
+// BundleLine is what a bundle saved on a cart or an order
+type BundleLine struct {
+	BundleID int    `json:"bundleId"`
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+	Savings  Money  `json:"savings"`
+}
+
+// BundleSavings returns what the lines saved altogether
+func BundleSavings(lines []BundleLine) Money {
+	var total Money
+	for _, line := range lines {
+		total = total.Add(line.Savings)
+	}
+	return total
+}
+
+// BundlesFor returns what the bundles put in a cart save on the order
+// items placed from it, at the prices they were ordered at
+func BundlesFor(bundles []CartBundle, items []OrderItem) []BundleLine {
+	held := make(map[ItemKey]int)
+	prices := make(map[ItemKey]Money)
+	for _, item := range items {
+		held[item.Key()] += item.Quantity
+		prices[item.Key()] = item.Price
+	}
+	return bundleLines(bundles, held, prices)
+}
+
+// bundleLines works out what bundles save on items held in the given
+// quantities at the given prices. Each bundle counts as many times as it
+// was put in, or as many as the items still make up if fewer, taking the
+// items of bundles put in earlier first so no item counts twice. A bundle
+// saves what its items cost less its price, or nothing if they cost less.
+// Bundles that count no times have no line.
+func bundleLines(bundles []CartBundle, held map[ItemKey]int, prices map[ItemKey]Money) []BundleLine {
+	left := maps.Clone(held)
+	var lines []BundleLine
+	for _, cb := range bundles {
+		count := cb.Quantity
+		var cost Money
+		for _, item := range cb.Bundle.Items {
+			count = min(count, left[item.Key()]/item.Quantity)
+			cost = cost.Add(prices[item.Key()].Mul(item.Quantity))
+		}
+		if count <= 0 {
+			continue
+		}
+		for _, item := range cb.Bundle.Items {
+			left[item.Key()] -= count * item.Quantity
+		}
+
+		line := BundleLine{BundleID: cb.Bundle.ID, Name: cb.Bundle.Name, Quantity: count}
+		if cost.Cmp(cb.Bundle.Price) > 0 {
+			line.Savings = cost.Sub(cb.Bundle.Price).Mul(count)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,232 @@
+package models
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func testBundle() Bundle {
+	return Bundle{
+		ID:   1,
+		Name: "Desk set",
+		Items: []BundleItem{
+			{ProductID: 1, Quantity: 1},
+			{ProductID: 2, VariantID: 3, Quantity: 2},
+		},
+		Price: Won(25000),
+	}
+}
+
+func TestBundleValidate(t *testing.T) {
+	if err := testBundle().Validate(); err != nil {
+		t.Errorf("Expected a valid bundle, got %v", err)
+	}
+
+	tests := map[string]func(*Bundle){
+		"no name":         func(b *Bundle) { b.Name = " " },
+		"no price":        func(b *Bundle) { b.Price = Money{} },
+		"one product":     func(b *Bundle) { b.Items = b.Items[:1] },
+		"zero quantity":   func(b *Bundle) { b.Items[1].Quantity = 0 },
+		"same item twice": func(b *Bundle) { b.Items[1] = BundleItem{ProductID: 1, Quantity: 2} },
+	}
+	for name, change := range tests {
+		bundle := testBundle()
+		change(&bundle)
+		if err := bundle.Validate(); !errors.Is(err, ErrInvalidBundle) {
+			t.Errorf("%s: expected ErrInvalidBundle, got %v", name, err)
+		}
+	}
+
+	// Two variants of a product are different items
+	bundle := testBundle()
+	bundle.Items[0] = BundleItem{ProductID: 2, VariantID: 4, Quantity: 1}
+	if err := bundle.Validate(); err != nil {
+		t.Errorf("Expected variants of one product to be allowed, got %v", err)
+	}
+}
+
+func TestBundleStore(t *testing.T) {
+	store := NewBundleStore()
+	if _, err := store.Add(Bundle{Name: "Bad"}); !errors.Is(err, ErrInvalidBundle) {
+		t.Errorf("Expected ErrInvalidBundle, got %v", err)
+	}
+
+	first, _ := store.Add(testBundle())
+	second, _ := store.Add(Bundle{Name: "Pair", Items: []BundleItem{{ProductID: 2, VariantID: 3, Quantity: 1}, {ProductID: 5, Quantity: 1}}, Price: Won(9000)})
+	if first.ID != 1 || second.ID != 2 {
+		t.Errorf("Expected IDs 1 and 2, got %d and %d", first.ID, second.ID)
+	}
+
+	if got := store.Containing(2); len(got) != 2 {
+		t.Errorf("Expected both bundles to hold product 2, got %d", len(got))
+	}
+	if got := store.Containing(1); len(got) != 1 || got[0].ID != first.ID {
+		t.Errorf("Expected only the first bundle to hold product 1, got %+v", got)
+	}
+
+	if err := store.Delete(first.ID); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if _, ok := store.GetByID(first.ID); ok {
+		t.Error("Expected the bundle to be deleted")
+	}
+	if err := store.Delete(first.ID); !errors.Is(err, ErrBundleNotFound) {
+		t.Errorf("Expected ErrBundleNotFound, got %v", err)
+	}
+}
+
+func TestBundleOffer(t *testing.T) {
+	store := NewProductStore()
+	pen, _ := store.Add(Product{Name: "Pen", Price: Won(3000), Stock: 5})
+	pad, _ := store.Add(Product{Name: "Pad", Price: Won(5000), Stock: 1})
+
+	bundle := Bundle{Name: "Set", Items: []BundleItem{{ProductID: pen.ID, Quantity: 2}, {ProductID: pad.ID, Quantity: 1}}, Price: Won(9000)}
+	offer, ok := store.BundleOffer(bundle)
+	if !ok {
+		t.Fatal("Expected the bundle to be sold")
+	}
+	if offer.RegularPrice() != Won(11000) || offer.Savings() != Won(2000) {
+		t.Errorf("Expected 11000 regular and 2000 saved, got %v and %v", offer.RegularPrice(), offer.Savings())
+	}
+	if !offer.InStock() {
+		t.Error("Expected the bundle in stock")
+	}
+
+	// A bundle dearer than its items saves nothing
+	bundle.Price = Won(12000)
+	if offer, _ := store.BundleOffer(bundle); !offer.Savings().IsZero() {
+		t.Errorf("Expected no savings, got %v", offer.Savings())
+	}
+
+	bundle.Items[1].Quantity = 2
+	if offer, _ := store.BundleOffer(bundle); offer.InStock() {
+		t.Error("Expected the bundle out of stock")
+	}
+
+	store.SetArchived(pad.ID, true)
+	if _, ok := store.BundleOffer(bundle); ok {
+		t.Error("Expected a bundle with an archived product not to be sold")
+	}
+}
+
+func TestCartBundles(t *testing.T) {
+	pen := Product{ID: 1, Name: "Pen", Price: Won(3000)}
+	pad := Product{ID: 2, Name: "Pad", Price: Won(5000)}
+	products := map[int]Product{1: pen, 2: pad}
+	bundle := Bundle{ID: 7, Name: "Set", Items: []BundleItem{{ProductID: 1, Quantity: 2}, {ProductID: 2, Quantity: 1}}, Price: Won(9000)}
+
+	cart := NewCart()
+	cart.AddItem(pen, 0, 1)
+	cart.AddBundle(bundle, products, 2)
+
+	if cart.Quantity(ItemKey{ProductID: 1}) != 5 || cart.Quantity(ItemKey{ProductID: 2}) != 2 {
+		t.Errorf("Expected the bundle's products in the cart, got %+v", cart.GetItems())
+	}
+	lines := cart.BundleLines()
+	if len(lines) != 1 || lines[0].Quantity != 2 || lines[0].Savings != Won(4000) {
+		t.Errorf("Expected the bundle to save 2000 twice, got %+v", lines)
+	}
+	if cart.TotalAfterDiscount() != Won(25000-4000) {
+		t.Errorf("Expected the savings off the total, got %v", cart.TotalAfterDiscount())
+	}
+
+	// The coupon is worked out on the total after bundle savings
+	cart.ApplyCoupon(Coupon{Code: "TEN", Type: DiscountPercent, Percent: 10})
+	if cart.Discount() != Won(2100) || cart.TotalAfterDiscount() != Won(18900) {
+		t.Errorf("Expected 2100 off 21000, got %v and %v", cart.Discount(), cart.TotalAfterDiscount())
+	}
+	cart.RemoveCoupon()
+
+	// Taking a bundle's product out leaves as many bundles as are still
+	// whole, and putting it back doesn't bring them back
+	cart.UpdateQuantity(ItemKey{ProductID: 2}, 1)
+	if got := cart.GetBundles(); len(got) != 1 || got[0].Quantity != 1 {
+		t.Errorf("Expected one bundle left, got %+v", got)
+	}
+	cart.UpdateQuantity(ItemKey{ProductID: 2}, 2)
+	if got := cart.BundleDiscount(); got != Won(2000) {
+		t.Errorf("Expected the bundle to save once, got %v", got)
+	}
+
+	left, _ := cart.RemoveBundle(bundle.ID)
+	if left[ItemKey{ProductID: 1}] != 3 || left[ItemKey{ProductID: 2}] != 1 {
+		t.Errorf("Expected what is left of the bundle's items, got %v", left)
+	}
+	if len(cart.GetBundles()) != 0 || !cart.BundleDiscount().IsZero() {
+		t.Error("Expected the bundle taken out")
+	}
+	if left, _ := cart.RemoveBundle(bundle.ID); left != nil {
+		t.Error("Expected nothing to change for a bundle not in the cart")
+	}
+
+	cart.AddBundle(bundle, products, 1)
+	cart.RemoveItem(ItemKey{ProductID: 1})
+	if len(cart.GetBundles()) != 0 {
+		t.Error("Expected the bundle dropped with its product")
+	}
+	cart.AddBundle(bundle, products, 1)
+	cart.Clear()
+	if len(cart.GetBundles()) != 0 {
+		t.Error("Expected clearing the cart to drop its bundles")
+	}
+}
+
+func TestBundlesFor(t *testing.T) {
+	bundle := Bundle{ID: 1, Name: "Set", Items: []BundleItem{{ProductID: 1, Quantity: 1}, {ProductID: 2, Quantity: 1}}, Price: Won(7000)}
+	other := Bundle{ID: 2, Name: "Pair", Items: []BundleItem{{ProductID: 2, Quantity: 1}, {ProductID: 3, Quantity: 1}}, Price: Won(6000)}
+	items := []OrderItem{
+		{ProductID: 1, Price: Won(3000), Quantity: 1},
+		{ProductID: 2, Price: Won(5000), Quantity: 1},
+		{ProductID: 3, Price: Won(2000), Quantity: 1},
+	}
+
+	// Product 2 counts for the bundle put in first only
+	lines := BundlesFor([]CartBundle{{Bundle: bundle, Quantity: 1}, {Bundle: other, Quantity: 1}}, items)
+	if len(lines) != 1 || lines[0].BundleID != bundle.ID || lines[0].Savings != Won(1000) {
+		t.Errorf("Expected only the first bundle to save, got %+v", lines)
+	}
+
+	order := Order{Items: items, Bundles: lines, Discount: Won(500)}
+	if order.TotalDue() != Won(10000-1000-500) {
+		t.Errorf("Expected the savings off the total, got %v", order.TotalDue())
+	}
+}
+
+func TestCartStoreKeepsBundles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "carts.json")
+	carts, err := NewJSONCartStore(path)
+	if err != nil {
+		t.Fatalf("NewJSONCartStore() failed: %v", err)
+	}
+	bundle := Bundle{ID: 1, Name: "Set", Items: []BundleItem{{ProductID: 1, Quantity: 1}, {ProductID: 2, Quantity: 1}}, Price: Won(7000)}
+	products := map[int]Product{1: {ID: 1, Price: Won(3000)}, 2: {ID: 2, Price: Won(5000)}}
+	carts.Get("guest-a").AddBundle(bundle, products, 1)
+
+	reopened, err := NewJSONCartStore(path)
+	if err != nil {
+		t.Fatalf("NewJSONCartStore() failed: %v", err)
+	}
+	if got := reopened.Get("guest-a").BundleDiscount(); got != Won(1000) {
+		t.Errorf("Expected the bundle to survive a restart, got %v", got)
+	}
+}
+
+func TestMergeCartBundles(t *testing.T) {
+	store := NewProductStore()
+	pen, _ := store.Add(Product{Name: "Pen", Price: Won(3000), Stock: 5})
+	pad, _ := store.Add(Product{Name: "Pad", Price: Won(5000), Stock: 5})
+	bundle := Bundle{ID: 1, Name: "Set", Items: []BundleItem{{ProductID: pen.ID, Quantity: 1}, {ProductID: pad.ID, Quantity: 1}}, Price: Won(7000)}
+
+	guest := NewCart()
+	guest.AddBundle(bundle, map[int]Product{pen.ID: pen, pad.ID: pad}, 1)
+	account := NewCart()
+
+	store.MergeCart(guest, account, 0)
+	if got := account.BundleDiscount(); got != Won(1000) {
+		t.Errorf("Expected the bundle moved over, got %v", got)
+	}
+	if len(guest.GetBundles()) != 0 {
+		t.Error("Expected the guest cart emptied")
+	}
+}
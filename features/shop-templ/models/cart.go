@@ -1,65 +1,233 @@
 package models
 
 import (
+	"crypto/rand"
+	"slices"
 	"sync"
+	"time"
 )
 
 // CartItem represents a product in the shopping cart
 type CartItem struct {
-	Product  Product `json:"product"`
-	Quantity int     `json:"quantity"`
+	Product Product `json:"product"`
+	// VariantID is the variant of the product chosen, for products that
+	// come in variants
+	VariantID int `json:"variantId,omitempty"`
+	Quantity  int `json:"quantity"`
+}
+
+// Key returns the product and variant the item holds
+func (i CartItem) Key() ItemKey {
+	return ItemKey{ProductID: i.Product.ID, VariantID: i.VariantID}
+}
+
+// Price returns the price of one of the item: the product's, with the
+// variant's price delta
+func (i CartItem) Price() Money {
+	return i.Product.PriceOf(i.VariantID)
+}
+
+// Subtotal returns the price of the line
+func (i CartItem) Subtotal() Money {
+	return i.Price().Mul(i.Quantity)
+}
+
+// Variant returns the variant of the product chosen, if it has variants
+func (i CartItem) Variant() (Variant, bool) {
+	return i.Product.Variant(i.VariantID)
 }
 
 // Cart represents a shopping cart
 type Cart struct {
-	mu    sync.RWMutex
+	mu sync.RWMutex
+	// ID identifies the cart when it reserves stock
+	ID    string     `json:"id"`
 	Items []CartItem `json:"items"`
-	Total float64    `json:"total"`
+	Total Money      `json:"total"`
+	// Coupon is the coupon applied to the cart, if any
+	Coupon *Coupon `json:"coupon,omitempty"`
+	// Bundles are the bundles put in the cart, in the order they were
+	// first put in. Their products are among the items.
+	Bundles []CartBundle `json:"bundles,omitempty"`
+	// UpdatedAt is when the cart last changed
+	UpdatedAt time.Time `json:"updatedAt"`
+	// RemindedAt is when its owner was last emailed about leaving it, if
+	// ever
+	RemindedAt time.Time `json:"remindedAt,omitzero"`
+	// onChange, if set, is called with every change to the cart, with the
+	// cart still locked. If it fails the change is undone.
+	onChange func(SavedCart) error
 }
 
-// NewCart creates a new empty cart
+// NewCart creates a new empty cart with a random ID
 func NewCart() *Cart {
 	return &Cart{
-		Items: make([]CartItem, 0),
-		Total: 0,
+		ID:        rand.Text(),
+		Items:     make([]CartItem, 0),
+		UpdatedAt: time.Now(),
 	}
 }
 
-// AddItem adds a product to the cart or increases quantity if it already exists
-func (c *Cart) AddItem(product Product, quantity int) {
+// AddItem adds a product, or the variant of it with the given ID, to the
+// cart or increases quantity if it already exists
+func (c *Cart) AddItem(product Product, variantID int, quantity int) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	before := c.stateUnlocked()
+	c.addItemUnlocked(product, variantID, quantity)
+	c.calculateTotal()
+	return c.changedUnlocked(before)
+}
+
+// AddItemChecked adds like AddItem once check allows what the cart will
+// then hold of the product or variant: what it holds already and quantity
+// more. The cart stays locked from the check to the add, so requests
+// adding at once can't both pass on the same count. Nothing is added if
+// check fails.
+func (c *Cart) AddItemChecked(product Product, variantID int, quantity int, check func(total int) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := ItemKey{ProductID: product.ID, VariantID: variantID}
+	if err := check(c.quantityUnlocked(key) + quantity); err != nil {
+		return err
+	}
+	before := c.stateUnlocked()
+	c.addItemUnlocked(product, variantID, quantity)
+	c.calculateTotal()
+	return c.changedUnlocked(before)
+}
+
+// addItemUnlocked adds an item without locking (internal use)
+func (c *Cart) addItemUnlocked(product Product, variantID int, quantity int) {
 	// Check if product already exists in cart
+	key := ItemKey{ProductID: product.ID, VariantID: variantID}
 	for i, item := range c.Items {
-		if item.Product.ID == product.ID {
+		if item.Key() == key {
 			c.Items[i].Quantity += quantity
-			c.calculateTotal()
 			return
 		}
 	}
 
 	// Add new item
 	c.Items = append(c.Items, CartItem{
-		Product:  product,
-		Quantity: quantity,
+		Product:   product,
+		VariantID: variantID,
+		Quantity:  quantity,
 	})
+}
+
+// AddBundle puts a bundle in the cart quantity times: each of its
+// products, from the given ones by ID, goes in as an item, and the cart
+// saves what the bundle does on them
+func (c *Cart) AddBundle(bundle Bundle, products map[int]Product, quantity int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	before := c.stateUnlocked()
+	for _, item := range bundle.Items {
+		c.addItemUnlocked(products[item.ProductID], item.VariantID, item.Quantity*quantity)
+	}
+
+	i := slices.IndexFunc(c.Bundles, func(cb CartBundle) bool { return cb.Bundle.ID == bundle.ID })
+	if i < 0 {
+		c.Bundles = append(c.Bundles, CartBundle{Bundle: bundle, Quantity: quantity})
+	} else {
+		c.Bundles[i].Quantity += quantity
+	}
 	c.calculateTotal()
+	return c.changedUnlocked(before)
 }
 
-// UpdateQuantity updates the quantity of a product in the cart
+// RemoveBundle takes a bundle out of the cart along with as many of its
+// products as it holds. It returns the items that changed and how many of
+// each are left, so their reservations can follow.
+func (c *Cart) RemoveBundle(bundleID int) (map[ItemKey]int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	before := c.stateUnlocked()
+	i := slices.IndexFunc(c.Bundles, func(cb CartBundle) bool { return cb.Bundle.ID == bundleID })
+	if i < 0 {
+		return nil, c.changedUnlocked(before)
+	}
+	cb := c.Bundles[i]
+	c.Bundles = slices.Delete(c.Bundles, i, i+1)
+
+	left := make(map[ItemKey]int)
+	for _, item := range cb.Bundle.Items {
+		key := item.Key()
+		for j := range c.Items {
+			if c.Items[j].Key() == key {
+				c.Items[j].Quantity -= min(item.Quantity*cb.Quantity, c.Items[j].Quantity)
+				left[key] = c.Items[j].Quantity
+			}
+		}
+	}
+	c.Items = slices.DeleteFunc(c.Items, func(item CartItem) bool { return item.Quantity == 0 })
+	c.calculateTotal()
+	if err := c.changedUnlocked(before); err != nil {
+		return nil, err
+	}
+
+	return left, nil
+}
+
+// GetBundles returns a copy of the bundles in the cart
+func (c *Cart) GetBundles() []CartBundle {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return slices.Clone(c.Bundles)
+}
+
+// BundleLines returns what each bundle in the cart saves on its items
+func (c *Cart) BundleLines() []BundleLine {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.bundleLinesUnlocked()
+}
+
+// BundleDiscount returns what the bundles in the cart save altogether
+func (c *Cart) BundleDiscount() Money {
+	return BundleSavings(c.BundleLines())
+}
+
+// bundleLinesUnlocked works out the bundle lines without locking
+// (internal use)
+func (c *Cart) bundleLinesUnlocked() []BundleLine {
+	held := make(map[ItemKey]int)
+	prices := make(map[ItemKey]Money)
+	for _, item := range c.Items {
+		held[item.Key()] = item.Quantity
+		prices[item.Key()] = item.Price()
+	}
+	return bundleLines(c.Bundles, held, prices)
+}
+
+// UpdateQuantity updates the quantity of a product or variant in the cart
 // If quantity is 0, the item is removed
-func (c *Cart) UpdateQuantity(productID int, quantity int) {
+func (c *Cart) UpdateQuantity(key ItemKey, quantity int) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	before := c.stateUnlocked()
+	c.setQuantityUnlocked(key, quantity)
+	return c.changedUnlocked(before)
+}
+
+// setQuantityUnlocked sets the quantity of an item, removing it at 0. The
+// caller must hold c.mu.
+func (c *Cart) setQuantityUnlocked(key ItemKey, quantity int) {
 	if quantity == 0 {
-		c.removeItemUnlocked(productID)
+		c.removeItemUnlocked(key)
 		return
 	}
 
 	for i, item := range c.Items {
-		if item.Product.ID == productID {
+		if item.Key() == key {
 			c.Items[i].Quantity = quantity
 			c.calculateTotal()
 			return
@@ -67,18 +235,38 @@ func (c *Cart) UpdateQuantity(productID int, quantity int) {
 	}
 }
 
-// RemoveItem removes a product from the cart
-func (c *Cart) RemoveItem(productID int) {
+// UpdateQuantityChecked updates like UpdateQuantity once check allows the
+// new quantity, with the cart locked from the check to the update. Nothing
+// changes if check fails or the item isn't in the cart.
+func (c *Cart) UpdateQuantityChecked(key ItemKey, quantity int, check func(total int) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.quantityUnlocked(key) == 0 {
+		return nil
+	}
+	if err := check(quantity); err != nil {
+		return err
+	}
+	before := c.stateUnlocked()
+	c.setQuantityUnlocked(key, quantity)
+	return c.changedUnlocked(before)
+}
+
+// RemoveItem removes a product or variant from the cart
+func (c *Cart) RemoveItem(key ItemKey) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.removeItemUnlocked(productID)
+	before := c.stateUnlocked()
+	c.removeItemUnlocked(key)
+	return c.changedUnlocked(before)
 }
 
 // removeItemUnlocked removes an item without locking (internal use)
-func (c *Cart) removeItemUnlocked(productID int) {
+func (c *Cart) removeItemUnlocked(key ItemKey) {
 	for i, item := range c.Items {
-		if item.Product.ID == productID {
+		if item.Key() == key {
 			c.Items = append(c.Items[:i], c.Items[i+1:]...)
 			c.calculateTotal()
 			return
@@ -87,12 +275,101 @@ func (c *Cart) removeItemUnlocked(productID int) {
 }
 
 // Clear removes all items from the cart
-func (c *Cart) Clear() {
+func (c *Cart) Clear() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	before := c.stateUnlocked()
 	c.Items = make([]CartItem, 0)
-	c.Total = 0
+	c.Total = Money{}
+	c.Coupon = nil
+	c.Bundles = nil
+	return c.changedUnlocked(before)
+}
+
+// ApplyCoupon applies a coupon to the cart, replacing any applied before
+func (c *Cart) ApplyCoupon(coupon Coupon) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	before := c.stateUnlocked()
+	c.Coupon = &coupon
+	return c.changedUnlocked(before)
+}
+
+// RemoveCoupon takes the applied coupon off the cart
+func (c *Cart) RemoveCoupon() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	before := c.stateUnlocked()
+	c.Coupon = nil
+	return c.changedUnlocked(before)
+}
+
+// GetCoupon returns the coupon applied to the cart, if any
+func (c *Cart) GetCoupon() (Coupon, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.Coupon == nil {
+		return Coupon{}, false
+	}
+	return *c.Coupon, true
+}
+
+// Discount returns how much the applied coupon takes off the cart's
+// total after bundle savings. It is zero while that is below the coupon's
+// minimum.
+func (c *Cart) Discount() Money {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.Coupon == nil {
+		return Money{}
+	}
+	return c.Coupon.Discount(c.Total.Sub(BundleSavings(c.bundleLinesUnlocked())))
+}
+
+// TotalAfterBundles returns the cart's total less what its bundles save,
+// which is what coupons are checked against
+func (c *Cart) TotalAfterBundles() Money {
+	return c.Total.Sub(c.BundleDiscount())
+}
+
+// TotalAfterDiscount returns the cart's total less bundle savings and the
+// coupon discount
+func (c *Cart) TotalAfterDiscount() Money {
+	return c.TotalAfterBundles().Sub(c.Discount())
+}
+
+// GetItems returns a copy of the items in the cart
+func (c *Cart) GetItems() []CartItem {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	items := make([]CartItem, len(c.Items))
+	copy(items, c.Items)
+	return items
+}
+
+// Quantity returns how many of a product or variant are in the cart
+func (c *Cart) Quantity(key ItemKey) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.quantityUnlocked(key)
+}
+
+// quantityUnlocked returns how many of a product or variant are in the
+// cart. The caller must hold c.mu.
+func (c *Cart) quantityUnlocked(key ItemKey) int {
+	for _, item := range c.Items {
+		if item.Key() == key {
+			return item.Quantity
+		}
+	}
+	return 0
 }
 
 // GetItemCount returns the total number of items in the cart
@@ -107,12 +384,107 @@ func (c *Cart) GetItemCount() int {
 	return count
 }
 
+// cartState is what a change can do to a cart, kept to undo the change
+type cartState struct {
+	items     []CartItem
+	total     Money
+	coupon    *Coupon
+	bundles   []CartBundle
+	updatedAt time.Time
+}
+
+// stateUnlocked returns the cart as it is, for restoreUnlocked. The caller
+// must hold c.mu.
+func (c *Cart) stateUnlocked() cartState {
+	return cartState{
+		items:     slices.Clone(c.Items),
+		total:     c.Total,
+		coupon:    c.Coupon,
+		bundles:   slices.Clone(c.Bundles),
+		updatedAt: c.UpdatedAt,
+	}
+}
+
+// restoreUnlocked puts the cart back as it was. The caller must hold c.mu.
+func (c *Cart) restoreUnlocked(state cartState) {
+	c.Items = state.items
+	c.Total = state.total
+	c.Coupon = state.coupon
+	c.Bundles = state.bundles
+	c.UpdatedAt = state.updatedAt
+}
+
+// savedUnlocked returns what a repository keeps of the cart. The caller
+// must hold c.mu.
+func (c *Cart) savedUnlocked() SavedCart {
+	sc := SavedCart{
+		ID:         c.ID,
+		Items:      slices.Clone(c.Items),
+		Bundles:    slices.Clone(c.Bundles),
+		UpdatedAt:  c.UpdatedAt,
+		RemindedAt: c.RemindedAt,
+	}
+	if c.Coupon != nil {
+		coupon := *c.Coupon
+		sc.Coupon = &coupon
+	}
+	return sc
+}
+
+// changedUnlocked notes when the cart changed and reports it to onChange,
+// putting the cart back as it was before if that fails. The caller must
+// hold c.mu.
+func (c *Cart) changedUnlocked(before cartState) error {
+	c.UpdatedAt = time.Now()
+	if c.onChange == nil {
+		return nil
+	}
+	if err := c.onChange(c.savedUnlocked()); err != nil {
+		c.restoreUnlocked(before)
+		return err
+	}
+	return nil
+}
+
+// mergeBundles puts another cart's bundles in this one, adding to those
+// already in it. They only save anything once their items are in too.
+func (c *Cart) mergeBundles(bundles []CartBundle) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	before := c.stateUnlocked()
+	for _, cb := range bundles {
+		i := slices.IndexFunc(c.Bundles, func(own CartBundle) bool { return own.Bundle.ID == cb.Bundle.ID })
+		if i < 0 {
+			c.Bundles = append(c.Bundles, cb)
+		} else {
+			c.Bundles[i].Quantity += cb.Quantity
+		}
+	}
+	c.calculateTotal()
+	return c.changedUnlocked(before)
+}
+
 // calculateTotal calculates the total price of all items in the cart
-// This should be called after any modification to cart items
+// This should be called after any modification to cart items. Bundles
+// are cut down to as many times as the items still make up, so one whose
+// items were taken out doesn't save again when they are put back.
 func (c *Cart) calculateTotal() {
-	total := 0.0
+	var total Money
 	for _, item := range c.Items {
-		total += item.Product.Price * float64(item.Quantity)
+		total = total.Add(item.Subtotal())
 	}
 	c.Total = total
+
+	if len(c.Bundles) == 0 {
+		return
+	}
+	counts := make(map[int]int)
+	for _, line := range c.bundleLinesUnlocked() {
+		counts[line.BundleID] = line.Quantity
+	}
+	c.Bundles = slices.DeleteFunc(c.Bundles, func(cb CartBundle) bool { return counts[cb.Bundle.ID] == 0 })
+	for i := range c.Bundles {
+		c.Bundles[i].Quantity = counts[c.Bundles[i].Bundle.ID]
+	}
 }
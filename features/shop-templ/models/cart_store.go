@@ -0,0 +1,331 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UserCartID is the ID of a logged-in user's cart
+func UserCartID(userID int) string {
+	return fmt.Sprintf("user-%d", userID)
+}
+
+// CartUserID returns the user whose cart has the ID, if it is a user's
+func CartUserID(cartID string) (int, bool) {
+	id, err := strconv.Atoi(strings.TrimPrefix(cartID, "user-"))
+	if err != nil || !strings.HasPrefix(cartID, "user-") {
+		return 0, false
+	}
+	return id, true
+}
+
+// GuestCartID is the ID of the cart behind a visitor's cart cookie token.
+// The prefix keeps a forged token from naming a user's cart.
+func GuestCartID(token string) string {
+	return "guest-" + token
+}
+
+// CartStore keeps a cart for every visitor and account, by cart ID. With
+// a repository it persists them, so carts survive restarts.
+type CartStore struct {
+	mu    sync.Mutex
+	carts map[string]*Cart
+	repo  CartRepository
+	// writeMu serializes writes to the repository, so the last one written
+	// is a cart's latest state
+	writeMu sync.Mutex
+	now     func() time.Time
+}
+
+// NewCartStore creates a new cart store kept in memory only
+func NewCartStore() *CartStore {
+	return &CartStore{carts: make(map[string]*Cart), now: time.Now}
+}
+
+// NewPersistentCartStore loads carts from repo and writes every change to
+// a cart back to it
+func NewPersistentCartStore(repo CartRepository) (*CartStore, error) {
+	saved, err := repo.LoadCarts()
+	if err != nil {
+		return nil, fmt.Errorf("load carts: %w", err)
+	}
+
+	s := NewCartStore()
+	s.repo = repo
+	for _, sc := range saved {
+		cart := &Cart{ID: sc.ID, Items: sc.Items, Coupon: sc.Coupon, Bundles: sc.Bundles, UpdatedAt: sc.UpdatedAt, RemindedAt: sc.RemindedAt}
+		// Carts saved before their changes were timed start their TTL now
+		if cart.UpdatedAt.IsZero() {
+			cart.UpdatedAt = s.now()
+		}
+		cart.calculateTotal()
+		cart.onChange = s.save
+		s.carts[cart.ID] = cart
+	}
+
+	return s, nil
+}
+
+// NewJSONCartStore loads carts from the JSON file at path and writes them
+// back after every change. A missing file is not an error: the store
+// starts empty and the file is created on the first change.
+func NewJSONCartStore(path string) (*CartStore, error) {
+	file, err := openJSONCartFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewPersistentCartStore(file)
+}
+
+// Get returns the cart with the given ID, starting an empty one if there
+// is none yet
+func (s *CartStore) Get(id string) *Cart {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cart, exists := s.carts[id]
+	if !exists {
+		cart = NewCart()
+		cart.ID = id
+		if s.repo != nil {
+			cart.onChange = s.save
+		}
+		s.carts[id] = cart
+	}
+	return cart
+}
+
+// Delete forgets a cart. If it can't be deleted from the repository it is
+// kept.
+func (s *CartStore) Delete(id string) error {
+	if s.repo != nil {
+		s.writeMu.Lock()
+		err := s.repo.DeleteCart(id)
+		s.writeMu.Unlock()
+		if err != nil {
+			return fmt.Errorf("%w: delete cart %s: %w", ErrNotSaved, id, err)
+		}
+	}
+
+	s.mu.Lock()
+	delete(s.carts, id)
+	s.mu.Unlock()
+	return nil
+}
+
+// Expire forgets the carts that haven't changed for ttl, empty or not, and
+// returns their IDs, for releasing the stock they held. A cart that can't
+// be deleted is left for the next time.
+func (s *CartStore) Expire(ttl time.Duration) []string {
+	cutoff := s.now().Add(-ttl)
+	var expired []string
+	s.mu.Lock()
+	for id, cart := range s.carts {
+		cart.mu.RLock()
+		stale := cart.UpdatedAt.Before(cutoff)
+		cart.mu.RUnlock()
+		if stale {
+			expired = append(expired, id)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Strings(expired)
+	return slices.DeleteFunc(expired, func(id string) bool {
+		if err := s.Delete(id); err != nil {
+			slog.Warn("expire cart", "cart", id, "error", err)
+			return true
+		}
+		return false
+	})
+}
+
+// Abandoned returns the carts left with items in them for at least after
+// whose owners haven't been reminded of them since, oldest first
+func (s *CartStore) Abandoned(after time.Duration) []*Cart {
+	cutoff := s.now().Add(-after)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var abandoned []*Cart
+	leftAt := make(map[*Cart]time.Time)
+	for _, cart := range s.carts {
+		cart.mu.RLock()
+		left := len(cart.Items) > 0 && !cart.UpdatedAt.After(cutoff) && cart.RemindedAt.Before(cart.UpdatedAt)
+		updated := cart.UpdatedAt
+		cart.mu.RUnlock()
+		if left {
+			abandoned = append(abandoned, cart)
+			leftAt[cart] = updated
+		}
+	}
+	sort.Slice(abandoned, func(i, j int) bool { return leftAt[abandoned[i]].Before(leftAt[abandoned[j]]) })
+	return abandoned
+}
+
+// MarkReminded notes that the cart's owner was reminded of it now, so
+// they aren't again until it changes and is left again
+func (s *CartStore) MarkReminded(cart *Cart) error {
+	cart.mu.Lock()
+	defer cart.mu.Unlock()
+
+	before := cart.RemindedAt
+	cart.RemindedAt = s.now()
+	if s.repo == nil {
+		return nil
+	}
+	if err := s.save(cart.savedUnlocked()); err != nil {
+		cart.RemindedAt = before
+		return err
+	}
+	return nil
+}
+
+// save writes a cart to the repository, as its change hook. The cart
+// stays locked while it is written, so writes of the same cart can't
+// overtake each other.
+func (s *CartStore) save(sc SavedCart) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := s.repo.SaveCart(sc); err != nil {
+		return fmt.Errorf("%w: save cart %s: %w", ErrNotSaved, sc.ID, err)
+	}
+	return nil
+}
+
+// jsonCartFile keeps carts in a JSON file, rewriting all of it on every
+// change
+type jsonCartFile struct {
+	mu    sync.Mutex
+	path  string
+	carts map[string]SavedCart
+}
+
+// openJSONCartFile reads the carts in the JSON file at path, if it exists
+func openJSONCartFile(path string) (*jsonCartFile, error) {
+	f := &jsonCartFile{path: path, carts: make(map[string]SavedCart)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read data file: %w", err)
+	}
+
+	var carts []SavedCart
+	if err := json.Unmarshal(data, &carts); err != nil {
+		return nil, fmt.Errorf("decode data file: %w", err)
+	}
+	for _, cart := range carts {
+		f.carts[cart.ID] = cart
+	}
+
+	return f, nil
+}
+
+// LoadCarts returns the carts in the file
+func (f *jsonCartFile) LoadCarts() ([]SavedCart, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.sortedUnlocked(), nil
+}
+
+// SaveCart adds or replaces the cart, or deletes it if it is empty, and
+// rewrites the file
+func (f *jsonCartFile) SaveCart(cart SavedCart) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(cart.Items) == 0 {
+		return f.putUnlocked(cart.ID, nil)
+	}
+	return f.putUnlocked(cart.ID, &cart)
+}
+
+// DeleteCart deletes the cart and rewrites the file
+func (f *jsonCartFile) DeleteCart(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.putUnlocked(id, nil)
+}
+
+// putUnlocked replaces the cart with the ID, or deletes it if cart is nil,
+// and rewrites the file. If the file can't be written the cart is left as
+// it was, so a later write doesn't save the change after all. The caller
+// must hold f.mu.
+func (f *jsonCartFile) putUnlocked(id string, cart *SavedCart) error {
+	before, existed := f.carts[id]
+	if cart == nil {
+		delete(f.carts, id)
+	} else {
+		f.carts[id] = *cart
+	}
+
+	err := f.writeUnlocked()
+	if err != nil {
+		if existed {
+			f.carts[id] = before
+		} else {
+			delete(f.carts, id)
+		}
+	}
+	return err
+}
+
+// sortedUnlocked returns the carts by ID
+func (f *jsonCartFile) sortedUnlocked() []SavedCart {
+	carts := make([]SavedCart, 0, len(f.carts))
+	for _, cart := range f.carts {
+		carts = append(carts, cart)
+	}
+	sort.Slice(carts, func(i, j int) bool { return carts[i].ID < carts[j].ID })
+	return carts
+}
+
+// writeUnlocked writes the carts to the file atomically: the data goes to
+// a temp file in the same directory which is then renamed over the target
+func (f *jsonCartFile) writeUnlocked() error {
+	data, err := json.MarshalIndent(f.sortedUnlocked(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode carts: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	// Clean up the temp file if anything below fails
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("replace data file: %w", err)
+	}
+
+	return nil
+}
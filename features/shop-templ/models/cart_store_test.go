@@ -0,0 +1,149 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCartStore(t *testing.T) {
+	store := NewCartStore()
+
+	cart := store.Get(UserCartID(1))
+	if cart.ID != "user-1" {
+		t.Errorf("Expected the cart to take its ID, got %q", cart.ID)
+	}
+	cart.AddItem(Product{ID: 1, Name: "P1", Price: usd(1000)}, 0, 2)
+
+	if store.Get(UserCartID(1)).GetItemCount() != 2 {
+		t.Error("Expected the same cart back for the same ID")
+	}
+	if store.Get(GuestCartID("user-1")).GetItemCount() != 0 {
+		t.Error("A guest token should never name a user's cart")
+	}
+
+	store.Delete(UserCartID(1))
+	if store.Get(UserCartID(1)).GetItemCount() != 0 {
+		t.Error("Expected a deleted cart to start over empty")
+	}
+}
+
+func TestJSONCartStorePersistsCarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "carts.json")
+	store, err := NewJSONCartStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.Get(GuestCartID("abc")).AddItem(Product{ID: 1, Name: "P1", Price: usd(1000)}, 0, 2)
+	store.Get(UserCartID(1)).AddItem(Product{ID: 2, Name: "P2", Price: usd(500)}, 0, 1)
+	store.Get(GuestCartID("abc")).ApplyCoupon(Coupon{Code: "SAVE", Type: DiscountFixed, Amount: usd(500)})
+	store.Get(GuestCartID("empty"))
+
+	reloaded, err := NewJSONCartStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cart := reloaded.Get(GuestCartID("abc"))
+	if cart.GetItemCount() != 2 || cart.Total != usd(2000) {
+		t.Errorf("Expected the guest cart to survive a restart, got %d items, total %v", cart.GetItemCount(), cart.Total)
+	}
+	if coupon, ok := cart.GetCoupon(); !ok || coupon.Code != "SAVE" {
+		t.Error("Expected the applied coupon to survive a restart")
+	}
+
+	// Changes after loading are saved too
+	reloaded.Get(UserCartID(1)).Clear()
+	again, err := NewJSONCartStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Get(UserCartID(1)).GetItemCount() != 0 {
+		t.Error("Expected the cleared cart to stay cleared")
+	}
+	if len(again.carts) != 2 {
+		t.Errorf("Expected empty carts not to be saved, got %d carts", len(again.carts))
+	}
+}
+
+func TestJSONCartStoreErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := NewJSONCartStore(filepath.Join(dir, "missing.json")); err != nil {
+		t.Errorf("A missing file should start an empty store, got %v", err)
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	os.WriteFile(bad, []byte("not json"), 0o644)
+	if _, err := NewJSONCartStore(bad); err == nil {
+		t.Error("Expected an error for a corrupt data file")
+	}
+}
+
+func TestCartStoreExpiry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "carts.json")
+	store, err := NewJSONCartStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	now := start.Add(2 * time.Hour)
+	store.now = func() time.Time { return now }
+
+	left := store.Get(UserCartID(1))
+	left.AddItem(Product{ID: 1, Name: "P1", Price: usd(1000)}, 0, 1)
+	left.UpdatedAt = start
+	store.Get(GuestCartID("empty")).UpdatedAt = start
+	busy := store.Get(GuestCartID("busy"))
+	busy.AddItem(Product{ID: 2, Name: "P2", Price: usd(500)}, 0, 1)
+	busy.UpdatedAt = now
+
+	// Only carts with items left alone long enough are abandoned, once
+	abandoned := store.Abandoned(time.Hour)
+	if len(abandoned) != 1 || abandoned[0] != left {
+		t.Fatalf("Expected the cart left with items to be abandoned, got %v", abandoned)
+	}
+	store.MarkReminded(left)
+	if got := store.Abandoned(time.Hour); len(got) != 0 {
+		t.Errorf("Expected a reminded cart not to be abandoned again, got %v", got)
+	}
+
+	// Reminders are saved, and carts keep when they last changed
+	reloaded, err := NewJSONCartStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cart := reloaded.Get(UserCartID(1)); !cart.RemindedAt.Equal(now) || !cart.UpdatedAt.Equal(start) {
+		t.Errorf("Expected the cart's times to survive a restart, got %v and %v", cart.UpdatedAt, cart.RemindedAt)
+	}
+
+	// Changing the cart after the reminder makes it count as left again
+	left.UpdatedAt = start.Add(3 * time.Hour)
+	now = start.Add(5 * time.Hour)
+	if got := store.Abandoned(time.Hour); len(got) != 2 || got[0] != busy || got[1] != left {
+		t.Errorf("Expected both carts with items abandoned, longest left first, got %v", got)
+	}
+
+	expired := store.Expire(150 * time.Minute)
+	if len(expired) != 2 || expired[0] != GuestCartID("busy") || expired[1] != GuestCartID("empty") {
+		t.Fatalf("Expected the carts unchanged for the TTL to expire, got %v", expired)
+	}
+	if store.Get(GuestCartID("busy")).GetItemCount() != 0 || store.Get(UserCartID(1)).GetItemCount() != 1 {
+		t.Error("Expected an expired cart to start over empty, and the others to stay")
+	}
+	if again, _ := NewJSONCartStore(path); again.Get(GuestCartID("busy")).GetItemCount() != 0 {
+		t.Error("Expected an expired cart to be deleted from the file too")
+	}
+}
+
+func TestCartUserID(t *testing.T) {
+	if id, ok := CartUserID(UserCartID(42)); !ok || id != 42 {
+		t.Errorf("Expected user 42, got %d, %v", id, ok)
+	}
+	for _, cartID := range []string{GuestCartID("42"), "user-", "user-x"} {
+		if _, ok := CartUserID(cartID); ok {
+			t.Errorf("Expected %q not to be a user's cart", cartID)
+		}
+	}
+}
@@ -12,16 +12,16 @@ func TestNewCart(t *testing.T) {
 	if len(cart.Items) != 0 {
 		t.Errorf("New cart should be empty, got %d items", len(cart.Items))
 	}
-	if cart.Total != 0 {
-		t.Errorf("New cart total should be 0, got %.2f", cart.Total)
+	if !cart.Total.IsZero() {
+		t.Errorf("New cart total should be 0, got %v", cart.Total)
 	}
 }
 
 func TestAddToCart(t *testing.T) {
 	cart := NewCart()
-	product := Product{ID: 1, Name: "Test Product", Price: 19.99, Stock: 10}
+	product := Product{ID: 1, Name: "Test Product", Price: usd(1999), Stock: 10}
 
-	cart.AddItem(product, 2)
+	cart.AddItem(product, 0, 2)
 
 	if len(cart.Items) != 1 {
 		t.Errorf("Expected 1 item in cart, got %d", len(cart.Items))
@@ -34,17 +34,17 @@ func TestAddToCart(t *testing.T) {
 	if item.Quantity != 2 {
 		t.Errorf("Expected quantity 2, got %d", item.Quantity)
 	}
-	if cart.Total != 39.98 {
-		t.Errorf("Expected total 39.98, got %.2f", cart.Total)
+	if cart.Total != usd(3998) {
+		t.Errorf("Expected total 39.98, got %v", cart.Total)
 	}
 }
 
 func TestAddExistingProductToCart(t *testing.T) {
 	cart := NewCart()
-	product := Product{ID: 1, Name: "Test Product", Price: 10.00, Stock: 10}
+	product := Product{ID: 1, Name: "Test Product", Price: usd(1000), Stock: 10}
 
-	cart.AddItem(product, 2)
-	cart.AddItem(product, 3)
+	cart.AddItem(product, 0, 2)
+	cart.AddItem(product, 0, 3)
 
 	if len(cart.Items) != 1 {
 		t.Errorf("Expected 1 item in cart (same product), got %d", len(cart.Items))
@@ -54,54 +54,54 @@ func TestAddExistingProductToCart(t *testing.T) {
 		t.Errorf("Expected quantity 5, got %d", cart.Items[0].Quantity)
 	}
 
-	if cart.Total != 50.00 {
-		t.Errorf("Expected total 50.00, got %.2f", cart.Total)
+	if cart.Total != usd(5000) {
+		t.Errorf("Expected total 50.00, got %v", cart.Total)
 	}
 }
 
 func TestUpdateQuantity(t *testing.T) {
 	cart := NewCart()
-	product := Product{ID: 1, Name: "Test Product", Price: 15.00, Stock: 10}
+	product := Product{ID: 1, Name: "Test Product", Price: usd(1500), Stock: 10}
 
-	cart.AddItem(product, 2)
-	cart.UpdateQuantity(1, 5)
+	cart.AddItem(product, 0, 2)
+	cart.UpdateQuantity(ItemKey{ProductID: 1}, 5)
 
 	if cart.Items[0].Quantity != 5 {
 		t.Errorf("Expected quantity 5, got %d", cart.Items[0].Quantity)
 	}
-	if cart.Total != 75.00 {
-		t.Errorf("Expected total 75.00, got %.2f", cart.Total)
+	if cart.Total != usd(7500) {
+		t.Errorf("Expected total 75.00, got %v", cart.Total)
 	}
 }
 
 func TestUpdateQuantityToZero(t *testing.T) {
 	cart := NewCart()
-	product := Product{ID: 1, Name: "Test Product", Price: 10.00, Stock: 10}
+	product := Product{ID: 1, Name: "Test Product", Price: usd(1000), Stock: 10}
 
-	cart.AddItem(product, 2)
-	cart.UpdateQuantity(1, 0)
+	cart.AddItem(product, 0, 2)
+	cart.UpdateQuantity(ItemKey{ProductID: 1}, 0)
 
 	if len(cart.Items) != 0 {
 		t.Errorf("Expected 0 items after setting quantity to 0, got %d", len(cart.Items))
 	}
-	if cart.Total != 0 {
-		t.Errorf("Expected total 0, got %.2f", cart.Total)
+	if !cart.Total.IsZero() {
+		t.Errorf("Expected total 0, got %v", cart.Total)
 	}
 }
 
 func TestRemoveFromCart(t *testing.T) {
 	cart := NewCart()
-	product1 := Product{ID: 1, Name: "Product 1", Price: 10.00, Stock: 10}
-	product2 := Product{ID: 2, Name: "Product 2", Price: 20.00, Stock: 5}
+	product1 := Product{ID: 1, Name: "Product 1", Price: usd(1000), Stock: 10}
+	product2 := Product{ID: 2, Name: "Product 2", Price: usd(2000), Stock: 5}
 
-	cart.AddItem(product1, 1)
-	cart.AddItem(product2, 2)
+	cart.AddItem(product1, 0, 1)
+	cart.AddItem(product2, 0, 2)
 
 	if len(cart.Items) != 2 {
 		t.Fatalf("Expected 2 items, got %d", len(cart.Items))
 	}
 
-	cart.RemoveItem(1)
+	cart.RemoveItem(ItemKey{ProductID: 1})
 
 	if len(cart.Items) != 1 {
 		t.Errorf("Expected 1 item after removal, got %d", len(cart.Items))
@@ -109,17 +109,17 @@ func TestRemoveFromCart(t *testing.T) {
 	if cart.Items[0].Product.ID != 2 {
 		t.Errorf("Expected remaining product ID 2, got %d", cart.Items[0].Product.ID)
 	}
-	if cart.Total != 40.00 {
-		t.Errorf("Expected total 40.00, got %.2f", cart.Total)
+	if cart.Total != usd(4000) {
+		t.Errorf("Expected total 40.00, got %v", cart.Total)
 	}
 }
 
 func TestRemoveNonExistentProduct(t *testing.T) {
 	cart := NewCart()
-	product := Product{ID: 1, Name: "Test Product", Price: 10.00, Stock: 10}
+	product := Product{ID: 1, Name: "Test Product", Price: usd(1000), Stock: 10}
 
-	cart.AddItem(product, 1)
-	cart.RemoveItem(999) // Non-existent ID
+	cart.AddItem(product, 0, 1)
+	cart.RemoveItem(ItemKey{ProductID: 999}) // Non-existent ID
 
 	if len(cart.Items) != 1 {
 		t.Errorf("Removing non-existent product should not affect cart, got %d items", len(cart.Items))
@@ -128,29 +128,29 @@ func TestRemoveNonExistentProduct(t *testing.T) {
 
 func TestClearCart(t *testing.T) {
 	cart := NewCart()
-	product1 := Product{ID: 1, Name: "Product 1", Price: 10.00, Stock: 10}
-	product2 := Product{ID: 2, Name: "Product 2", Price: 20.00, Stock: 5}
+	product1 := Product{ID: 1, Name: "Product 1", Price: usd(1000), Stock: 10}
+	product2 := Product{ID: 2, Name: "Product 2", Price: usd(2000), Stock: 5}
 
-	cart.AddItem(product1, 2)
-	cart.AddItem(product2, 3)
+	cart.AddItem(product1, 0, 2)
+	cart.AddItem(product2, 0, 3)
 
 	cart.Clear()
 
 	if len(cart.Items) != 0 {
 		t.Errorf("Expected 0 items after clear, got %d", len(cart.Items))
 	}
-	if cart.Total != 0 {
-		t.Errorf("Expected total 0 after clear, got %.2f", cart.Total)
+	if !cart.Total.IsZero() {
+		t.Errorf("Expected total 0 after clear, got %v", cart.Total)
 	}
 }
 
 func TestGetItemCount(t *testing.T) {
 	cart := NewCart()
-	product1 := Product{ID: 1, Name: "Product 1", Price: 10.00, Stock: 10}
-	product2 := Product{ID: 2, Name: "Product 2", Price: 20.00, Stock: 5}
+	product1 := Product{ID: 1, Name: "Product 1", Price: usd(1000), Stock: 10}
+	product2 := Product{ID: 2, Name: "Product 2", Price: usd(2000), Stock: 5}
 
-	cart.AddItem(product1, 3)
-	cart.AddItem(product2, 2)
+	cart.AddItem(product1, 0, 3)
+	cart.AddItem(product2, 0, 2)
 
 	count := cart.GetItemCount()
 	if count != 5 {
@@ -162,12 +162,63 @@ func TestCalculateTotal(t *testing.T) {
 	cart := NewCart()
 
 	// Test with multiple products
-	cart.AddItem(Product{ID: 1, Price: 10.50, Stock: 10}, 2) // 21.00
-	cart.AddItem(Product{ID: 2, Price: 15.99, Stock: 5}, 1)  // 15.99
-	cart.AddItem(Product{ID: 3, Price: 7.25, Stock: 20}, 3)  // 21.75
+	cart.AddItem(Product{ID: 1, Price: usd(1050), Stock: 10}, 0, 2) // 21.00
+	cart.AddItem(Product{ID: 2, Price: usd(1599), Stock: 5}, 0, 1)  // 15.99
+	cart.AddItem(Product{ID: 3, Price: usd(725), Stock: 20}, 0, 3)  // 21.75
 
-	expected := 58.74
+	expected := usd(5874)
 	if cart.Total != expected {
-		t.Errorf("Expected total %.2f, got %.2f", expected, cart.Total)
+		t.Errorf("Expected total %v, got %v", expected, cart.Total)
+	}
+}
+
+func TestGetItems(t *testing.T) {
+	cart := NewCart()
+	cart.AddItem(Product{ID: 1, Name: "P1", Price: usd(1000)}, 0, 2)
+
+	items := cart.GetItems()
+	items[0].Quantity = 99
+	if cart.GetItemCount() != 2 {
+		t.Error("GetItems should return a copy of the cart's items")
+	}
+}
+
+func TestCartQuantity(t *testing.T) {
+	cart := NewCart()
+	if cart.ID == "" || cart.ID == NewCart().ID {
+		t.Error("Carts should get distinct IDs")
+	}
+
+	cart.AddItem(Product{ID: 1, Name: "P1", Price: usd(1000)}, 0, 2)
+	cart.AddItem(Product{ID: 1, Name: "P1", Price: usd(1000)}, 0, 1)
+	if cart.Quantity(ItemKey{ProductID: 1}) != 3 {
+		t.Errorf("Expected quantity 3, got %d", cart.Quantity(ItemKey{ProductID: 1}))
+	}
+	if cart.Quantity(ItemKey{ProductID: 2}) != 0 {
+		t.Errorf("Expected quantity 0 for a product not in the cart, got %d", cart.Quantity(ItemKey{ProductID: 2}))
+	}
+}
+
+func TestCartCoupon(t *testing.T) {
+	cart := NewCart()
+	cart.AddItem(Product{ID: 1, Name: "P1", Price: Won(20000)}, 0, 2)
+
+	cart.ApplyCoupon(Coupon{Code: "WELCOME10", Type: DiscountPercent, Percent: 10, MinOrder: Won(30000)})
+	if cart.Discount() != Won(4000) || cart.TotalAfterDiscount() != Won(36000) {
+		t.Errorf("Expected discount 4000 and total 36000, got %v and %v", cart.Discount(), cart.TotalAfterDiscount())
+	}
+
+	// The coupon stays applied but stops counting below its minimum
+	cart.UpdateQuantity(ItemKey{ProductID: 1}, 1)
+	if !cart.Discount().IsZero() || cart.TotalAfterDiscount() != Won(20000) {
+		t.Errorf("Expected no discount below the minimum, got %v", cart.Discount())
+	}
+	if _, ok := cart.GetCoupon(); !ok {
+		t.Error("Expected the coupon to stay applied")
+	}
+
+	cart.RemoveCoupon()
+	if _, ok := cart.GetCoupon(); ok {
+		t.Error("Expected the coupon to be removed")
 	}
 }
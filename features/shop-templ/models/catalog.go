@@ -0,0 +1,493 @@
+package models
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrCatalogFormat is returned for a catalog file that can't be read at
+	// all, such as CSV without a name column or JSON that isn't a list
+	ErrCatalogFormat = errors.New("invalid catalog file")
+	// ErrImportValue is returned for a catalog cell that can't be read as
+	// what its column holds
+	ErrImportValue = errors.New("invalid value")
+	// ErrDuplicateProduct is returned for a product ID that appears more than
+	// once in a catalog file
+	ErrDuplicateProduct = errors.New("product appears more than once")
+)
+
+// CatalogFormat is a file format the catalog can be imported from. All but
+// YAML can be exported to as well.
+type CatalogFormat string
+
+const (
+	CatalogCSV  CatalogFormat = "csv"
+	CatalogJSON CatalogFormat = "json"
+	// CatalogYAML is a list of products like CatalogJSON, for hand-written
+	// fixtures
+	CatalogYAML CatalogFormat = "yaml"
+)
+
+// CatalogFormatOf returns the format of a catalog file by its extension
+func CatalogFormatOf(filename string) (CatalogFormat, bool) {
+	switch format := CatalogFormat(strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))); format {
+	case CatalogCSV, CatalogJSON, CatalogYAML:
+		return format, true
+	case "yml":
+		return CatalogYAML, true
+	default:
+		return "", false
+	}
+}
+
+// catalogColumns are the columns of an exported CSV catalog. Imports may
+// have any of them in any order, but need an id or name column; other
+// columns are ignored.
+var catalogColumns = []string{"id", "name", "sku", "barcode", "description", "price", "category", "stock", "tags", "image_url", "images", "archived"}
+
+// ImportRowError reports a row of a catalog file that can't be imported
+type ImportRowError struct {
+	// Row is the line of a CSV file, counting the header, or the position
+	// in a JSON list, counting from 1
+	Row int
+	// Field is the column the problem is in, if it is in one
+	Field string
+	Err   error
+}
+
+func (e *ImportRowError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+	}
+	return fmt.Sprintf("row %d, %s: %v", e.Row, e.Field, e.Err)
+}
+
+func (e *ImportRowError) Unwrap() error {
+	return e.Err
+}
+
+// ImportResult is what an import did, or for a dry run what it would do
+type ImportResult struct {
+	Created int
+	Updated int
+	// Errors lists every row that can't be imported. Nothing is imported
+	// unless it is empty.
+	Errors []*ImportRowError
+	DryRun bool
+}
+
+// Applied reports whether the import changed the catalog
+func (r ImportResult) Applied() bool {
+	return !r.DryRun && len(r.Errors) == 0
+}
+
+// Err returns the row errors joined into one, or nil if there are none
+func (r ImportResult) Err() error {
+	errs := make([]error, len(r.Errors))
+	for i, rowErr := range r.Errors {
+		errs[i] = rowErr
+	}
+	return errors.Join(errs...)
+}
+
+// LoadCatalog imports the catalog file at path, in the format its extension
+// says. It fails if the file has any row that can't be imported, with an
+// error listing them.
+func (s *ProductStore) LoadCatalog(path string) (ImportResult, error) {
+	format, ok := CatalogFormatOf(path)
+	if !ok {
+		return ImportResult{}, fmt.Errorf("%w: %s isn't a .csv, .json or .yaml file", ErrCatalogFormat, path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("read catalog: %w", err)
+	}
+	defer file.Close()
+
+	result, err := s.Import(file, format, false)
+	if err != nil {
+		return result, err
+	}
+	return result, result.Err()
+}
+
+// jsonColumns are the catalog columns of Product's JSON fields, where
+// they are named differently
+var jsonColumns = map[string]string{"imageUrl": "image_url"}
+
+// catalogRow is a product read from a catalog file and where it was
+type catalogRow struct {
+	row     int
+	product Product
+	// columns are the columns the row has. Products already in the store
+	// keep their values for the others.
+	columns map[string]bool
+}
+
+// merged returns the row's product with the values of stored for the
+// columns the row doesn't have. Sales so far are kept too, as they still
+// count for popularity.
+func (r catalogRow) merged(stored Product) Product {
+	p := r.product
+	p.Sold = stored.Sold
+	if !r.columns["name"] {
+		p.Name = stored.Name
+	}
+	if !r.columns["sku"] {
+		p.SKU = stored.SKU
+	}
+	if !r.columns["barcode"] {
+		p.Barcode = stored.Barcode
+	}
+	if !r.columns["description"] {
+		p.Description = stored.Description
+	}
+	if !r.columns["price"] {
+		p.Price = stored.Price
+	}
+	if !r.columns["category"] {
+		p.Category = stored.Category
+	}
+	if !r.columns["stock"] {
+		p.Stock = stored.Stock
+	}
+	if !r.columns["tags"] {
+		p.Tags = stored.Tags
+	}
+	if !r.columns["image_url"] {
+		p.ImageURL = stored.ImageURL
+	}
+	if !r.columns["images"] {
+		p.Images = stored.Images
+	}
+	if !r.columns["archived"] {
+		p.Archived = stored.Archived
+	}
+	// Options and variants only make sense together
+	if !r.columns["options"] && !r.columns["variants"] {
+		p.Options = stored.Options
+		p.Variants = stored.Variants
+	}
+	return p
+}
+
+// Export writes every product, archived ones too, in format. Prices are
+// written in the major unit, such as "129000" won.
+func (s *ProductStore) Export(w io.Writer, format CatalogFormat) error {
+	products := s.GetAllWithArchived()
+
+	switch format {
+	case CatalogJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(products)
+	case CatalogCSV:
+		writer := csv.NewWriter(w)
+		writer.Write(catalogColumns)
+		for _, p := range products {
+			writer.Write([]string{
+				strconv.Itoa(p.ID),
+				p.Name,
+				p.SKU,
+				p.Barcode,
+				p.Description,
+				p.Price.Decimal(),
+				p.Category,
+				strconv.Itoa(p.Stock),
+				strings.Join(p.Tags, ", "),
+				p.ImageURL,
+				strings.Join(p.Images, ", "),
+				strconv.FormatBool(p.Archived),
+			})
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		return fmt.Errorf("%w: unknown format %q", ErrCatalogFormat, format)
+	}
+}
+
+// Import reads products in format and adds them to the catalog. Products
+// with the ID of one in the store replace its details and stock, keeping
+// what the file has no column for; others are added, keeping their ID if
+// they have one. Products with variants take their stock from them, so a
+// stock column is ignored for them. Either every row is imported or, if
+// any has a problem, none is and the result lists them. A dry run checks
+// the file and counts what would change without changing anything.
+func (s *ProductStore) Import(r io.Reader, format CatalogFormat, dryRun bool) (ImportResult, error) {
+	var rows []catalogRow
+	var rowErrors []*ImportRowError
+	var err error
+	switch format {
+	case CatalogJSON:
+		rows, rowErrors, err = readCatalogJSON(r)
+	case CatalogCSV:
+		rows, rowErrors, err = readCatalogCSV(r)
+	case CatalogYAML:
+		rows, rowErrors, err = readCatalogYAML(r)
+	default:
+		err = fmt.Errorf("%w: unknown format %q", ErrCatalogFormat, format)
+	}
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := ImportResult{Errors: rowErrors, DryRun: dryRun}
+	products := make([]Product, 0, len(rows))
+	seen := make(map[int]bool)
+	// Codes must be unused by the products in the store and the rows
+	// before, which new products are told apart in by their row
+	storeCodes, fileCodes := s.codesUnlocked(), newProductCodes()
+	for _, row := range rows {
+		product := row.product
+		stored, exists := s.products[product.ID]
+		if exists {
+			product = row.merged(stored)
+		}
+		product = product.withVariantStock()
+		product.Category = NormalizeCategory(product.Category)
+		if rowError := checkImported(product); rowError != nil {
+			rowError.Row = row.row
+			result.Errors = append(result.Errors, rowError)
+			continue
+		}
+		if id := product.ID; id != 0 {
+			if seen[id] {
+				result.Errors = append(result.Errors, &ImportRowError{Row: row.row, Field: "id", Err: fmt.Errorf("%w: %d", ErrDuplicateProduct, id)})
+				continue
+			}
+			seen[id] = true
+		}
+		owner := cmp.Or(product.ID, -row.row)
+		if err := cmp.Or(storeCodes.check(product, owner), fileCodes.check(product, owner)); err != nil {
+			result.Errors = append(result.Errors, &ImportRowError{Row: row.row, Field: codeField(err), Err: err})
+			continue
+		}
+		fileCodes.add(product, owner)
+
+		if exists {
+			result.Updated++
+		} else {
+			result.Created++
+		}
+		products = append(products, product)
+	}
+	slices.SortFunc(result.Errors, func(a, b *ImportRowError) int { return cmp.Compare(a.Row, b.Row) })
+	if !result.Applied() {
+		return result, nil
+	}
+
+	nextID := s.nextID
+	for i := range products {
+		if products[i].ID == 0 {
+			products[i].ID = nextID
+		}
+		nextID = max(nextID, products[i].ID+1)
+	}
+	if err := s.putUnlocked(products...); err != nil {
+		return ImportResult{}, err
+	}
+	s.nextID = nextID
+	return result, nil
+}
+
+// codeField returns the catalog column a duplicate code error is about
+func codeField(err error) string {
+	if errors.Is(err, ErrDuplicateBarcode) {
+		return "barcode"
+	}
+	return "sku"
+}
+
+// checkImported checks a product read from a catalog file
+func checkImported(p Product) *ImportRowError {
+	err := p.Validate()
+	switch {
+	case errors.Is(err, ErrInvalidVariant):
+		return &ImportRowError{Field: "variants", Err: err}
+	case errors.Is(err, ErrDuplicateSKU):
+		return &ImportRowError{Field: "sku", Err: err}
+	case err != nil, p.Price.Currency == "":
+		return &ImportRowError{Err: ErrInvalidProduct}
+	case p.Price.Currency != DefaultCurrency:
+		return &ImportRowError{Field: "price", Err: fmt.Errorf("%w: price must be in %s", ErrImportValue, DefaultCurrency)}
+	case p.ID < 0:
+		return &ImportRowError{Field: "id", Err: fmt.Errorf("%w: %d", ErrImportValue, p.ID)}
+	case p.Stock < 0:
+		return &ImportRowError{Field: "stock", Err: ErrNegativeStock}
+	}
+	return nil
+}
+
+// readCatalogJSON reads a JSON list of products, as Export writes it.
+// Items that aren't products are reported as row errors.
+func readCatalogJSON(r io.Reader) ([]catalogRow, []*ImportRowError, error) {
+	var items []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrCatalogFormat, err)
+	}
+
+	var rows []catalogRow
+	var rowErrors []*ImportRowError
+	for i, item := range items {
+		var fields map[string]json.RawMessage
+		var product Product
+		err := json.Unmarshal(item, &fields)
+		if err == nil {
+			err = json.Unmarshal(item, &product)
+		}
+		if err != nil {
+			rowError := &ImportRowError{Row: i + 1, Err: fmt.Errorf("%w: %v", ErrImportValue, err)}
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				rowError.Field = typeErr.Field
+			}
+			rowErrors = append(rowErrors, rowError)
+			continue
+		}
+
+		columns := make(map[string]bool)
+		for field := range fields {
+			if column, ok := jsonColumns[field]; ok {
+				field = column
+			}
+			columns[field] = true
+		}
+		rows = append(rows, catalogRow{row: i + 1, product: product, columns: columns})
+	}
+	return rows, rowErrors, nil
+}
+
+// readCatalogYAML reads a YAML list of products with the same fields as
+// JSON ones. Prices may be plain numbers of won.
+func readCatalogYAML(r io.Reader) ([]catalogRow, []*ImportRowError, error) {
+	var items []any
+	if err := yaml.NewDecoder(r).Decode(&items); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrCatalogFormat, err)
+	}
+
+	// Products only know how to read themselves from JSON
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrCatalogFormat, err)
+	}
+	return readCatalogJSON(bytes.NewReader(data))
+}
+
+// readCatalogCSV reads a CSV file with a header row naming its columns.
+// Cells that can't be read are reported as row errors.
+func readCatalogCSV(r io.Reader) ([]catalogRow, []*ImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrCatalogFormat, err)
+	}
+	// Spreadsheets may start the file with a byte order mark
+	positions := make(map[string]int)
+	columns := make(map[string]bool)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		positions[name] = i
+		columns[name] = true
+	}
+	if !columns["id"] && !columns["name"] {
+		return nil, nil, fmt.Errorf("%w: no id or name column", ErrCatalogFormat)
+	}
+
+	var rows []catalogRow
+	var rowErrors []*ImportRowError
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrCatalogFormat, err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		cell := func(column string) string {
+			if i, ok := positions[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		product, rowError := parseCatalogRecord(cell, columns)
+		if rowError != nil {
+			rowError.Row = line
+			rowErrors = append(rowErrors, rowError)
+			continue
+		}
+		rows = append(rows, catalogRow{row: line, product: product, columns: columns})
+	}
+	return rows, rowErrors, nil
+}
+
+// parseCatalogRecord reads a product from the cells of a CSV row with the
+// given columns. Empty id, stock and archived cells read as zero, none and
+// false.
+func parseCatalogRecord(cell func(column string) string, columns map[string]bool) (Product, *ImportRowError) {
+	product := Product{
+		Name:        cell("name"),
+		SKU:         cell("sku"),
+		Barcode:     cell("barcode"),
+		Description: cell("description"),
+		Category:    cell("category"),
+		ImageURL:    cell("image_url"),
+		Tags:        splitList(cell("tags")),
+		Images:      splitList(cell("images")),
+	}
+
+	var err error
+	if columns["price"] {
+		if product.Price, err = ParseMoney(cell("price"), DefaultCurrency); err != nil {
+			return product, &ImportRowError{Field: "price", Err: err}
+		}
+	}
+	for _, number := range []struct {
+		column string
+		target *int
+	}{{"id", &product.ID}, {"stock", &product.Stock}} {
+		if value := cell(number.column); value != "" {
+			if *number.target, err = strconv.Atoi(value); err != nil {
+				return product, &ImportRowError{Field: number.column, Err: fmt.Errorf("%w: %q", ErrImportValue, value)}
+			}
+		}
+	}
+	if value := cell("archived"); value != "" {
+		if product.Archived, err = strconv.ParseBool(value); err != nil {
+			return product, &ImportRowError{Field: "archived", Err: fmt.Errorf("%w: %q", ErrImportValue, value)}
+		}
+	}
+	return product, nil
+}
+
+// splitList splits a comma separated list, dropping empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
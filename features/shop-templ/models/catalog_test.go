@@ -0,0 +1,223 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCatalogFormatOf(t *testing.T) {
+	tests := []struct {
+		filename string
+		format   CatalogFormat
+		ok       bool
+	}{
+		{"products.csv", CatalogCSV, true},
+		{"Products.JSON", CatalogJSON, true},
+		{"fixtures/demo.yml", CatalogYAML, true},
+		{"products.xlsx", "", false},
+		{"products", "", false},
+	}
+
+	for _, tt := range tests {
+		if format, ok := CatalogFormatOf(tt.filename); format != tt.format || ok != tt.ok {
+			t.Errorf("CatalogFormatOf(%q) = %q, %v, expected %q, %v", tt.filename, format, ok, tt.format, tt.ok)
+		}
+	}
+}
+
+func TestCatalogRoundTrip(t *testing.T) {
+	for _, format := range []CatalogFormat{CatalogCSV, CatalogJSON} {
+		store := NewProductStore()
+		store.Add(Product{Name: "Mug, large", Description: "Holds \"a lot\"", Price: Won(12000), Category: "Kitchen", Stock: 5, Tags: []string{"cup", "tea"}, ImageURL: "/media/a.jpg"})
+		lamp, _ := store.Add(Product{Name: "Lamp", Price: Won(30000), Category: "Lighting", Stock: 2})
+		store.SetArchived(lamp.ID, true)
+
+		var buf bytes.Buffer
+		if err := store.Export(&buf, format); err != nil {
+			t.Fatalf("Export(%s) failed: %v", format, err)
+		}
+
+		restored := NewProductStore()
+		result, err := restored.Import(&buf, format, false)
+		if err != nil || !result.Applied() || result.Created != 2 {
+			t.Fatalf("Import(%s) = %+v, %v, expected 2 created", format, result, err)
+		}
+
+		mug, _ := restored.GetByID(1)
+		if mug.Name != "Mug, large" || mug.Description != "Holds \"a lot\"" || mug.Price != Won(12000) || mug.Stock != 5 ||
+			len(mug.Tags) != 2 || mug.ImageURL != "/media/a.jpg" {
+			t.Errorf("Expected the mug restored from %s, got %+v", format, mug)
+		}
+		if product, _ := restored.GetByID(lamp.ID); !product.Archived {
+			t.Errorf("Expected the lamp still archived from %s, got %+v", format, product)
+		}
+		if added, _ := restored.Add(Product{Name: "Plate"}); added.ID != 3 {
+			t.Errorf("Expected IDs to carry on after imported ones, got %d", added.ID)
+		}
+	}
+}
+
+func TestImportUpdatesExisting(t *testing.T) {
+	store := NewProductStore()
+	mug, _ := store.Add(Product{Name: "Mug", Description: "Ceramic", Price: Won(12000), Category: "Kitchen", Stock: 5, Sold: 7})
+
+	csv := "id,stock,price\n1,20,15000\n,3,9000\n"
+	result, err := store.Import(strings.NewReader(csv), CatalogCSV, false)
+	if err != nil {
+		t.Fatalf("Import() failed: %v", err)
+	}
+	// The second row has no name or category to be added with
+	if result.Applied() || len(result.Errors) != 1 || result.Errors[0].Row != 3 || !errors.Is(result.Errors[0], ErrInvalidProduct) {
+		t.Fatalf("Expected row 3 rejected, got %+v", result)
+	}
+
+	result, _ = store.Import(strings.NewReader("id,stock,price\n1,20,15000\n"), CatalogCSV, false)
+	if !result.Applied() || result.Updated != 1 || result.Created != 0 {
+		t.Fatalf("Expected 1 updated, got %+v", result)
+	}
+	product, _ := store.GetByID(mug.ID)
+	if product.Stock != 20 || product.Price != Won(15000) {
+		t.Errorf("Expected stock and price updated, got %+v", product)
+	}
+	if product.Name != "Mug" || product.Description != "Ceramic" || product.Sold != 7 {
+		t.Errorf("Expected columns not in the file kept, got %+v", product)
+	}
+}
+
+func TestImportRowErrors(t *testing.T) {
+	store := NewProductStore()
+	store.Add(Product{Name: "Mug", Price: Won(12000), Category: "Kitchen", Stock: 5})
+
+	csv := strings.Join([]string{
+		"id,name,price,category,stock,archived",
+		",Plate,8000,Kitchen,3,",
+		",,1000,Kitchen,1,",
+		",Bowl,12.5,Kitchen,1,",
+		",Cup,1000,Kitchen,-1,",
+		",Jug,1000,Kitchen,many,",
+		",Pot,1000,Kitchen,1,maybe",
+		"1,Mug,12000,Kitchen,5,",
+		"1,Mug,12000,Kitchen,5,",
+	}, "\n")
+	result, err := store.Import(strings.NewReader(csv), CatalogCSV, false)
+	if err != nil {
+		t.Fatalf("Import() failed: %v", err)
+	}
+
+	expected := []struct {
+		row   int
+		field string
+		err   error
+	}{
+		{3, "", ErrInvalidProduct},
+		{4, "price", ErrInvalidMoney},
+		{5, "stock", ErrNegativeStock},
+		{6, "stock", ErrImportValue},
+		{7, "archived", ErrImportValue},
+		{9, "id", ErrDuplicateProduct},
+	}
+	if len(result.Errors) != len(expected) {
+		t.Fatalf("Expected %d row errors, got %v", len(expected), result.Errors)
+	}
+	for i, e := range expected {
+		got := result.Errors[i]
+		if got.Row != e.row || got.Field != e.field || !errors.Is(got, e.err) {
+			t.Errorf("Expected row %d %q %v, got %v", e.row, e.field, e.err, got)
+		}
+	}
+
+	if result.Applied() || len(store.GetAll()) != 1 {
+		t.Errorf("Expected nothing imported while rows have errors, got %d products", len(store.GetAll()))
+	}
+}
+
+func TestImportDryRun(t *testing.T) {
+	store := NewProductStore()
+	store.Add(Product{Name: "Mug", Price: Won(12000), Category: "Kitchen", Stock: 5})
+
+	json := `[{"id": 1, "stock": 9}, {"name": "Plate", "price": 8000, "category": "Kitchen"}]`
+	result, err := store.Import(strings.NewReader(json), CatalogJSON, true)
+	if err != nil {
+		t.Fatalf("Import() failed: %v", err)
+	}
+	if result.Applied() || result.Created != 1 || result.Updated != 1 || len(result.Errors) != 0 {
+		t.Errorf("Expected 1 to create and 1 to update, got %+v", result)
+	}
+	if products := store.GetAll(); len(products) != 1 || products[0].Stock != 5 {
+		t.Errorf("Expected a dry run to change nothing, got %+v", products)
+	}
+}
+
+func TestImportUnreadableFile(t *testing.T) {
+	store := NewProductStore()
+
+	tests := []struct {
+		name   string
+		format CatalogFormat
+		data   string
+	}{
+		{"no id or name column", CatalogCSV, "price,category\n1000,Kitchen\n"},
+		{"empty CSV", CatalogCSV, ""},
+		{"JSON object", CatalogJSON, `{"name": "Mug"}`},
+		{"unknown format", CatalogFormat("xml"), "<products/>"},
+	}
+
+	for _, tt := range tests {
+		if _, err := store.Import(strings.NewReader(tt.data), tt.format, false); !errors.Is(err, ErrCatalogFormat) {
+			t.Errorf("%s: expected ErrCatalogFormat, got %v", tt.name, err)
+		}
+	}
+
+	result, err := store.Import(strings.NewReader(`[{"name": "Mug", "stock": "lots"}]`), CatalogJSON, false)
+	if err != nil || len(result.Errors) != 1 || result.Errors[0].Field != "stock" || !errors.Is(result.Errors[0], ErrImportValue) {
+		t.Errorf("Expected a row error for stock, got %+v, %v", result, err)
+	}
+}
+
+func TestLoadCatalog(t *testing.T) {
+	store := NewProductStore()
+	result, err := store.LoadCatalog("testdata/catalog.yaml")
+	if err != nil || result.Created != 3 {
+		t.Fatalf("LoadCatalog() = %+v, %v, expected 3 created", result, err)
+	}
+
+	tumbler, _ := store.GetByID(1)
+	if tumbler.Name != "텀블러" || tumbler.Price != Won(35000) || tumbler.Stock != 50 || len(tumbler.Tags) != 2 {
+		t.Errorf("Expected the tumbler loaded, got %+v", tumbler)
+	}
+	if mouse, _ := store.GetByID(2); mouse.Price != Won(45000) {
+		t.Errorf("Expected the mouse priced ₩45,000, got %v", mouse.Price)
+	}
+	if bag, ok := store.GetByID(10); !ok || !bag.Archived {
+		t.Errorf("Expected the bag archived with its ID, got %+v", bag)
+	}
+
+	if _, err := store.LoadCatalog("testdata/missing.json"); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+	if _, err := store.LoadCatalog("testdata/catalog.txt"); !errors.Is(err, ErrCatalogFormat) {
+		t.Errorf("Expected ErrCatalogFormat for an unknown extension, got %v", err)
+	}
+}
+
+func TestLoadCatalogRowErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.yml")
+	os.WriteFile(path, []byte("- name: Mug\n  price: 1000\n- name: Plate\n  price: lots\n  category: Kitchen\n"), 0o644)
+
+	store := NewProductStore()
+	_, err := store.LoadCatalog(path)
+	var rowErr *ImportRowError
+	if !errors.As(err, &rowErr) || rowErr.Row != 1 {
+		t.Fatalf("Expected an error for row 1, got %v", err)
+	}
+	if !errors.Is(err, ErrInvalidProduct) || !errors.Is(err, ErrImportValue) {
+		t.Errorf("Expected both rows' errors, got %v", err)
+	}
+	if len(store.GetAll()) != 0 {
+		t.Errorf("Expected nothing loaded, got %d products", len(store.GetAll()))
+	}
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// CategorySeparator separates the levels of a category path. A product in
+// "전자제품/오디오" is in 오디오, a sub-category of 전자제품, and in
+// 전자제품 too.
+const CategorySeparator = "/"
+
+// NormalizeCategory returns a category path with each level trimmed and
+// empty levels dropped, so " 전자제품 / 오디오/" is "전자제품/오디오"
+func NormalizeCategory(category string) string {
+	var levels []string
+	for _, level := range strings.Split(category, CategorySeparator) {
+		if level = strings.TrimSpace(level); level != "" {
+			levels = append(levels, level)
+		}
+	}
+	return strings.Join(levels, CategorySeparator)
+}
+
+// CategoryName returns the last level of a category path, which is what
+// the category is called on its own
+func CategoryName(category string) string {
+	return category[strings.LastIndex(category, CategorySeparator)+1:]
+}
+
+// CategoryParent returns the category a category path is a sub-category
+// of, or "" for a top-level one
+func CategoryParent(category string) string {
+	parent, _, found := cutLast(category, CategorySeparator)
+	if !found {
+		return ""
+	}
+	return parent
+}
+
+// CategoryAncestors returns the categories on a category path, top level
+// first and ending with the category itself: "a/b/c" is in "a", "a/b"
+// and "a/b/c"
+func CategoryAncestors(category string) []string {
+	if category == "" {
+		return nil
+	}
+	var ancestors []string
+	for i, c := range category {
+		if string(c) == CategorySeparator {
+			ancestors = append(ancestors, category[:i])
+		}
+	}
+	return append(ancestors, category)
+}
+
+// InCategory reports whether a product of category is in ancestor, being
+// in the category itself or one of its sub-categories
+func InCategory(category, ancestor string) bool {
+	return category == ancestor || strings.HasPrefix(category, ancestor+CategorySeparator)
+}
+
+// CategoriesOf returns the categories of products and every category they
+// are sub-categories of, sorted, so each category is followed by its own
+func CategoriesOf(products []Product) []string {
+	var categories []string
+	for _, p := range products {
+		categories = append(categories, CategoryAncestors(p.Category)...)
+	}
+	slices.Sort(categories)
+	return slices.Compact(categories)
+}
+
+// Category is a node of the category tree: a category with how many
+// products on sale are in it, its sub-categories' included
+type Category struct {
+	Path     string
+	Products int
+	Children []Category
+}
+
+// Name is what the category is called on its own
+func (c Category) Name() string {
+	return CategoryName(c.Path)
+}
+
+// CategoryTree returns the top-level categories of the products on sale,
+// each with its sub-categories, sorted by name at every level
+func (s *ProductStore) CategoryTree() []Category {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, p := range s.getAllUnlocked() {
+		for _, category := range CategoryAncestors(p.Category) {
+			counts[category]++
+		}
+	}
+	return categoryChildren("", counts)
+}
+
+// GetCategory returns the category at a path with its sub-categories, if
+// any product on sale is in it
+func (s *ProductStore) GetCategory(path string) (Category, bool) {
+	path = NormalizeCategory(path)
+	tree := s.CategoryTree()
+	for _, level := range CategoryAncestors(path) {
+		i := slices.IndexFunc(tree, func(c Category) bool { return c.Path == level })
+		if i < 0 {
+			return Category{}, false
+		}
+		if level == path {
+			return tree[i], true
+		}
+		tree = tree[i].Children
+	}
+	return Category{}, false
+}
+
+// categoryChildren returns the categories under parent, "" for the top
+// level, with their product counts
+func categoryChildren(parent string, counts map[string]int) []Category {
+	var children []Category
+	for path, products := range counts {
+		if CategoryParent(path) == parent {
+			children = append(children, Category{Path: path, Products: products, Children: categoryChildren(path, counts)})
+		}
+	}
+	slices.SortFunc(children, func(a, b Category) int { return cmp.Compare(a.Path, b.Path) })
+	return children
+}
+
+// cutLast slices s around the last instance of sep, as strings.Cut does
+// around the first
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func categoryTestStore() *ProductStore {
+	store := NewProductStore()
+	store.Add(Product{Name: "무선 이어폰", Category: "전자제품 / 오디오 /", Price: Won(129000), Stock: 5})
+	store.Add(Product{Name: "블루투스 스피커", Category: "전자제품/오디오", Price: Won(89000), Stock: 5})
+	store.Add(Product{Name: "스마트워치", Category: "전자제품/웨어러블", Price: Won(299000), Stock: 5})
+	store.Add(Product{Name: "충전기", Category: "전자제품", Price: Won(19000), Stock: 5})
+	store.Add(Product{Name: "백팩", Category: "패션/가방", Price: Won(59000), Stock: 5})
+	archived, _ := store.Add(Product{Name: "유선 이어폰", Category: "전자제품/오디오/유선", Price: Won(9000), Stock: 5})
+	store.SetArchived(archived.ID, true)
+	return store
+}
+
+func TestCategoryPaths(t *testing.T) {
+	if got := NormalizeCategory(" 전자제품 //오디오 / "); got != "전자제품/오디오" {
+		t.Errorf("NormalizeCategory(): got %q", got)
+	}
+	if got := CategoryAncestors("a/b/c"); !slices.Equal(got, []string{"a", "a/b", "a/b/c"}) {
+		t.Errorf("CategoryAncestors(): got %v", got)
+	}
+	if CategoryAncestors("") != nil {
+		t.Error("Expected no ancestors without a category")
+	}
+	if CategoryName("a/b/c") != "c" || CategoryName("a") != "a" {
+		t.Error("Expected the name to be the last level")
+	}
+	if CategoryParent("a/b/c") != "a/b" || CategoryParent("a") != "" {
+		t.Error("Expected the parent to be the path without its last level")
+	}
+
+	tests := []struct {
+		category, ancestor string
+		want               bool
+	}{
+		{"a/b", "a", true},
+		{"a/b", "a/b", true},
+		{"a", "a/b", false},
+		{"ab/c", "a", false},
+	}
+	for _, tt := range tests {
+		if got := InCategory(tt.category, tt.ancestor); got != tt.want {
+			t.Errorf("InCategory(%q, %q): expected %v", tt.category, tt.ancestor, tt.want)
+		}
+	}
+
+	if err := (Product{Name: "Mug", Category: " / ", Price: Won(1000)}).Validate(); !errors.Is(err, ErrInvalidProduct) {
+		t.Errorf("Expected ErrInvalidProduct for an empty path, got %v", err)
+	}
+}
+
+func TestCategoryTree(t *testing.T) {
+	store := categoryTestStore()
+
+	tree := store.CategoryTree()
+	if len(tree) != 2 || tree[0].Path != "전자제품" || tree[1].Path != "패션" {
+		t.Fatalf("Expected two top-level categories, got %+v", tree)
+	}
+	electronics := tree[0]
+	if electronics.Products != 4 || len(electronics.Children) != 2 {
+		t.Errorf("Expected sub-categories' products counted, got %+v", electronics)
+	}
+	// The archived product's category isn't on sale
+	audio := electronics.Children[0]
+	if audio.Path != "전자제품/오디오" || audio.Name() != "오디오" || audio.Products != 2 || len(audio.Children) != 0 {
+		t.Errorf("Unexpected sub-category %+v", audio)
+	}
+
+	if got, ok := store.GetCategory("전자제품/웨어러블"); !ok || got.Products != 1 {
+		t.Errorf("GetCategory(): got %+v, %v", got, ok)
+	}
+	for _, path := range []string{"", "가전", "전자제품/오디오/유선", "패션/오디오"} {
+		if _, ok := store.GetCategory(path); ok {
+			t.Errorf("GetCategory(%q): expected no category", path)
+		}
+	}
+
+	want := []string{"전자제품", "전자제품/오디오", "전자제품/웨어러블", "패션", "패션/가방"}
+	if got := store.GetCategories(); !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if got := store.Facets().Categories; !slices.Equal(got, want) {
+		t.Errorf("Expected facets %v, got %v", want, got)
+	}
+}
+
+func TestFilterIncludesSubCategories(t *testing.T) {
+	store := categoryTestStore()
+
+	if got := store.FilterByCategory("전자제품"); len(got) != 4 {
+		t.Errorf("Expected the sub-categories' products, got %d", len(got))
+	}
+	if got := store.Filter("", []string{"전자제품/오디오", "패션"}, Money{}, Money{}, nil); len(got) != 3 {
+		t.Errorf("Expected 3 products, got %d", len(got))
+	}
+	if got := store.Filter("오디", nil, Money{}, Money{}, nil); len(got) != 2 {
+		t.Errorf("Expected a search to match a sub-category, got %d", len(got))
+	}
+
+	suggestions := store.Suggest("오디", 5)
+	if len(suggestions.Categories) != 1 || suggestions.Categories[0] != (CategorySuggestion{"전자제품/오디오", 2}) {
+		t.Errorf("Unexpected category suggestions %+v", suggestions.Categories)
+	}
+}
+
+func TestRenameCategoryMovesSubCategories(t *testing.T) {
+	store := categoryTestStore()
+
+	moved, err := store.RenameCategory("전자제품", " 가전 ")
+	if err != nil || moved != 5 {
+		t.Fatalf("Expected 5 products moved, archived too, got %d, %v", moved, err)
+	}
+	if _, ok := store.GetCategory("가전/오디오"); !ok {
+		t.Error("Expected the sub-category to move along")
+	}
+	if got, _ := store.GetByID(6); got.Category != "가전/오디오/유선" {
+		t.Errorf("Expected the archived product moved, got %q", got.Category)
+	}
+
+	// Moving a sub-category to the top level
+	store.RenameCategory("가전/오디오", "오디오")
+	if got := store.GetCategories(); !slices.Equal(got, []string{"가전", "가전/웨어러블", "오디오", "패션", "패션/가방"}) {
+		t.Errorf("Unexpected categories %v", got)
+	}
+}
+
+func TestCategoryRulesApplyToSubCategories(t *testing.T) {
+	taxes := TaxRules{
+		{Name: "부가세", Rate: 1000, Country: "KR"},
+		{Name: "전자제품", Rate: 1200, Category: "전자제품", Country: "KR"},
+		{Name: "오디오", Rate: 1500, Category: "전자제품/오디오"},
+	}
+	tests := []struct{ category, want string }{
+		{"전자제품/웨어러블", "전자제품"},
+		{"전자제품/오디오/유선", "오디오"},
+		{"전자제품오디오", "부가세"},
+	}
+	for _, tt := range tests {
+		if rule, _ := taxes.rule(tt.category, "KR"); rule.Name != tt.want {
+			t.Errorf("Tax on %s: expected %q, got %q", tt.category, tt.want, rule.Name)
+		}
+	}
+
+	points := PointRules{
+		{Name: "기본", Rate: 100},
+		{Name: "오디오", Rate: 500, Category: "전자제품/오디오"},
+		{Name: "전자제품", Rate: 300, Category: "전자제품"},
+	}
+	if got := points.rate("전자제품/오디오/유선"); got != 500 {
+		t.Errorf("Expected the deepest category's rate, got %d", got)
+	}
+	if got := points.rate("전자제품/웨어러블"); got != 300 {
+		t.Errorf("Expected the parent category's rate, got %d", got)
+	}
+	if got := points.rate("패션"); got != 100 {
+		t.Errorf("Expected the rate for any category, got %d", got)
+	}
+
+	sale := Promotion{Category: "전자제품"}
+	if !sale.AppliesTo(Product{Category: "전자제품/오디오"}) || sale.AppliesTo(Product{Category: "전자제품오디오"}) {
+		t.Error("Expected a category sale to cover its sub-categories only")
+	}
+}
@@ -0,0 +1,166 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrCouponNotFound is returned for a code no coupon has
+	ErrCouponNotFound = errors.New("coupon not found")
+	// ErrCouponExpired is returned for a coupon past its expiry
+	ErrCouponExpired = errors.New("coupon has expired")
+	// ErrCouponMinOrder is returned when the order is below the coupon's minimum
+	ErrCouponMinOrder = errors.New("order is below the coupon's minimum")
+	// ErrCouponUsedUp is returned for a coupon redeemed as often as it allows
+	ErrCouponUsedUp = errors.New("coupon has been used up")
+)
+
+// DiscountType is how a coupon takes money off an order
+type DiscountType string
+
+const (
+	// DiscountPercent takes Percent percent off the order
+	DiscountPercent DiscountType = "percent"
+	// DiscountFixed takes Amount off the order
+	DiscountFixed DiscountType = "fixed"
+)
+
+// Coupon is a discount code customers can apply to their cart
+type Coupon struct {
+	Code    string       `json:"code"`
+	Type    DiscountType `json:"type"`
+	Percent int          `json:"percent,omitempty"`
+	Amount  Money        `json:"amount,omitzero"`
+	// MinOrder is the smallest order subtotal the coupon applies to
+	MinOrder Money `json:"minOrder,omitzero"`
+	// ExpiresAt is when the coupon stops working; zero means never
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
+	// MaxUses is how many orders may use the coupon; zero means no limit
+	MaxUses int `json:"maxUses,omitempty"`
+	Uses    int `json:"uses"`
+}
+
+// Discount returns how much the coupon takes off an order subtotal, or
+// zero if the subtotal is below the coupon's minimum. Percentage
+// discounts are rounded down to the minor unit, and no discount exceeds
+// the subtotal.
+func (c Coupon) Discount(subtotal Money) Money {
+	if subtotal.Cmp(c.MinOrder) < 0 {
+		return Money{Currency: subtotal.Currency}
+	}
+
+	var discount Money
+	switch c.Type {
+	case DiscountPercent:
+		discount = subtotal.Percent(c.Percent)
+	case DiscountFixed:
+		discount = c.Amount
+	}
+	if discount.Cmp(subtotal) > 0 {
+		return subtotal
+	}
+	return discount
+}
+
+// check reports why the coupon can't be used on an order subtotal at now
+func (c Coupon) check(subtotal Money, now time.Time) error {
+	if !c.ExpiresAt.IsZero() && !now.Before(c.ExpiresAt) {
+		return ErrCouponExpired
+	}
+	if c.MaxUses > 0 && c.Uses >= c.MaxUses {
+		return ErrCouponUsedUp
+	}
+	if subtotal.Cmp(c.MinOrder) < 0 {
+		return ErrCouponMinOrder
+	}
+	return nil
+}
+
+// CouponStore manages coupons with thread-safe operations
+type CouponStore struct {
+	mu      sync.RWMutex
+	coupons map[string]Coupon
+	now     func() time.Time
+}
+
+// NewCouponStore creates a new coupon store
+func NewCouponStore() *CouponStore {
+	return &CouponStore{
+		coupons: make(map[string]Coupon),
+		now:     time.Now,
+	}
+}
+
+// Add adds or replaces a coupon. Codes are matched case-insensitively.
+func (s *CouponStore) Add(coupon Coupon) Coupon {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	coupon.Code = normalizeCode(coupon.Code)
+	s.coupons[coupon.Code] = coupon
+
+	return coupon
+}
+
+// GetByCode retrieves a coupon by its code
+func (s *CouponStore) GetByCode(code string) (Coupon, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	coupon, exists := s.coupons[normalizeCode(code)]
+	return coupon, exists
+}
+
+// Validate returns the coupon if it can be used on an order subtotal
+func (s *CouponStore) Validate(code string, subtotal Money) (Coupon, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	coupon, exists := s.coupons[normalizeCode(code)]
+	if !exists {
+		return Coupon{}, ErrCouponNotFound
+	}
+	if err := coupon.check(subtotal, s.now()); err != nil {
+		return Coupon{}, err
+	}
+	return coupon, nil
+}
+
+// Redeem validates the coupon for an order subtotal and counts a use, in
+// one step so a coupon can't be used more often than it allows
+func (s *CouponStore) Redeem(code string, subtotal Money) (Coupon, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	coupon, exists := s.coupons[normalizeCode(code)]
+	if !exists {
+		return Coupon{}, ErrCouponNotFound
+	}
+	if err := coupon.check(subtotal, s.now()); err != nil {
+		return Coupon{}, err
+	}
+
+	coupon.Uses++
+	s.coupons[coupon.Code] = coupon
+	return coupon, nil
+}
+
+// Unredeem gives back a use counted by Redeem, for an order that then
+// couldn't be placed
+func (s *CouponStore) Unredeem(code string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if coupon, exists := s.coupons[normalizeCode(code)]; exists && coupon.Uses > 0 {
+		coupon.Uses--
+		s.coupons[coupon.Code] = coupon
+	}
+}
+
+// normalizeCode makes codes match regardless of case and surrounding space
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCouponDiscount(t *testing.T) {
+	tests := []struct {
+		name     string
+		coupon   Coupon
+		subtotal Money
+		want     Money
+	}{
+		{"percent", Coupon{Type: DiscountPercent, Percent: 10}, Won(35000), Won(3500)},
+		{"percent rounds down", Coupon{Type: DiscountPercent, Percent: 15}, Won(999), Won(149)},
+		{"fixed", Coupon{Type: DiscountFixed, Amount: Won(5000)}, Won(50000), Won(5000)},
+		{"below minimum", Coupon{Type: DiscountFixed, Amount: Won(5000), MinOrder: Won(50000)}, Won(49000), Won(0)},
+		{"capped at subtotal", Coupon{Type: DiscountFixed, Amount: Won(5000)}, Won(3000), Won(3000)},
+	}
+
+	for _, tt := range tests {
+		if got := tt.coupon.Discount(tt.subtotal); got != tt.want {
+			t.Errorf("%s: expected discount %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestCouponValidate(t *testing.T) {
+	store := NewCouponStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	store.Add(Coupon{Code: "welcome10", Type: DiscountPercent, Percent: 10, MinOrder: Won(30000)})
+	store.Add(Coupon{Code: "OLD", Type: DiscountFixed, Amount: Won(1000), ExpiresAt: now.Add(time.Hour)})
+
+	if coupon, err := store.Validate(" Welcome10 ", Won(30000)); err != nil || coupon.Code != "WELCOME10" {
+		t.Errorf("Expected codes to match regardless of case, got %+v, %v", coupon, err)
+	}
+	if _, err := store.Validate("WELCOME10", Won(29000)); !errors.Is(err, ErrCouponMinOrder) {
+		t.Errorf("Expected ErrCouponMinOrder, got %v", err)
+	}
+	if _, err := store.Validate("NOPE", Won(30000)); !errors.Is(err, ErrCouponNotFound) {
+		t.Errorf("Expected ErrCouponNotFound, got %v", err)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := store.Validate("OLD", Won(30000)); !errors.Is(err, ErrCouponExpired) {
+		t.Errorf("Expected ErrCouponExpired, got %v", err)
+	}
+}
+
+func TestCouponRedeem(t *testing.T) {
+	store := NewCouponStore()
+	store.Add(Coupon{Code: "ONCE", Type: DiscountFixed, Amount: Won(1000), MaxUses: 1})
+
+	coupon, err := store.Redeem("once", Won(10000))
+	if err != nil {
+		t.Fatalf("Redeem() failed: %v", err)
+	}
+	if coupon.Uses != 1 {
+		t.Errorf("Expected 1 use, got %d", coupon.Uses)
+	}
+	if _, err := store.Redeem("ONCE", Won(10000)); !errors.Is(err, ErrCouponUsedUp) {
+		t.Errorf("Expected ErrCouponUsedUp, got %v", err)
+	}
+
+	// A use given back can be redeemed again
+	store.Unredeem("ONCE")
+	if _, err := store.Redeem("ONCE", Won(10000)); err != nil {
+		t.Errorf("Expected an unredeemed coupon to work again, got %v", err)
+	}
+}
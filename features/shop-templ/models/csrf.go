@@ -0,0 +1,34 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+)
+
+// NewCSRFToken returns a new random token for a visitor to send back with
+// the requests that change their cart, proving the site's pages sent them
+func NewCSRFToken() string {
+	return rand.Text()
+}
+
+// CSRFTokenMatches reports whether a token sent with a request is the
+// visitor's, taking as long whatever the tokens are
+func CSRFTokenMatches(token, sent string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(sent)) == 1
+}
+
+type csrfContextKey struct{}
+
+// ContextWithCSRFToken returns a copy of ctx carrying the visitor's CSRF
+// token, for pages to send back with their requests
+func ContextWithCSRFToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, csrfContextKey{}, token)
+}
+
+// CSRFTokenFromContext returns the visitor's CSRF token, or "" if there is
+// none
+func CSRFTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(csrfContextKey{}).(string)
+	return token
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCSRFToken(t *testing.T) {
+	token := NewCSRFToken()
+	if token == "" || token == NewCSRFToken() {
+		t.Fatalf("Expected a new random token each time, got %q", token)
+	}
+
+	if !CSRFTokenMatches(token, token) {
+		t.Error("Expected the token to match itself")
+	}
+	for _, sent := range []string{"", token[1:], token + "x"} {
+		if CSRFTokenMatches(token, sent) {
+			t.Errorf("Expected %q not to match", sent)
+		}
+	}
+	if CSRFTokenMatches("", "") {
+		t.Error("Expected no token never to match")
+	}
+
+	ctx := ContextWithCSRFToken(context.Background(), token)
+	if got := CSRFTokenFromContext(ctx); got != token {
+		t.Errorf("Expected the token from the context, got %q", got)
+	}
+	if got := CSRFTokenFromContext(context.Background()); got != "" {
+		t.Errorf("Expected no token, got %q", got)
+	}
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// Email is an HTML email to one recipient
+type Email struct {
+	To      string
+	Subject string
+	HTML    string
+}
+
+// EmailSender delivers emails
+type EmailSender interface {
+	Send(ctx context.Context, email Email) error
+}
+
+// SMTPSender sends emails through an SMTP server. It switches to TLS if
+// the server offers STARTTLS and signs in if Username is set.
+type SMTPSender struct {
+	// Addr is the server's host:port
+	Addr string
+	// From is the sender, such as "Shop <shop@example.com>"
+	From     string
+	Username string
+	Password string
+}
+
+// Send delivers the email, giving up when ctx is done
+func (s SMTPSender) Send(ctx context.Context, email Email) error {
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return fmt.Errorf("smtp address %q: %w", s.Addr, err)
+	}
+	from, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return fmt.Errorf("sender %q: %w", s.From, err)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(email.To); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(email.message(from.String(), time.Now())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// LogSender writes emails to the log instead of sending them, for running
+// the shop without a mail server
+type LogSender struct{}
+
+// Send logs the email
+func (LogSender) Send(_ context.Context, email Email) error {
+	log.Printf("email to %s: %s\n%s", email.To, email.Subject, email.HTML)
+	return nil
+}
+
+// message returns the email as an RFC 5322 message from from, sent at
+// date. The subject is encoded for the non-ASCII characters it may have,
+// and the body is quoted-printable.
+func (e Email) message(from string, date time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", e.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", e.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/html; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	body := quotedprintable.NewWriter(&b)
+	body.Write([]byte(e.HTML))
+	body.Close()
+	return b.Bytes()
+}
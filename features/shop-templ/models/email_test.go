@@ -0,0 +1,146 @@
+package models
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTP accepts one message on a local port, recording what the client
+// sent, and returns its address
+func fakeSMTP(t *testing.T) (string, <-chan []string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var lines []string
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 fake ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				break
+			}
+			lines = append(lines, line)
+			switch command := strings.ToUpper(strings.Fields(line + " ")[0]); command {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 fake")
+			case "DATA":
+				tp.PrintfLine("354 go ahead")
+				data, _ := tp.ReadDotLines()
+				lines = append(lines, data...)
+				tp.PrintfLine("250 queued")
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				received <- lines
+				return
+			default:
+				tp.PrintfLine("250 ok")
+			}
+		}
+		received <- lines
+	}()
+	return listener.Addr().String(), received
+}
+
+func TestSMTPSender(t *testing.T) {
+	addr, received := fakeSMTP(t)
+	sender := SMTPSender{Addr: addr, From: "쇼핑몰 <shop@shop.local>"}
+	email := Email{To: "kim@example.com", Subject: "주문이 확인되었습니다", HTML: "<p>주문 #1</p>"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sender.Send(ctx, email); err != nil {
+		t.Fatalf("Send() failed: %v", err)
+	}
+
+	lines := <-received
+	session := strings.Join(lines, "\n")
+	for _, want := range []string{"MAIL FROM:<shop@shop.local>", "RCPT TO:<kim@example.com>"} {
+		if !strings.Contains(session, want) {
+			t.Errorf("Expected %q in the session:\n%s", want, session)
+		}
+	}
+
+	// The message follows the DATA command
+	var message string
+	for i, line := range lines {
+		if line == "DATA" {
+			message = strings.Join(lines[i+1:len(lines)-1], "\r\n")
+		}
+	}
+	msg, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(message)))
+	if err != nil {
+		t.Fatalf("Couldn't read the message: %v\n%s", err, message)
+	}
+	var decoder mime.WordDecoder
+	if subject, _ := decoder.DecodeHeader(msg.Header.Get("Subject")); subject != email.Subject {
+		t.Errorf("Expected subject %q, got %q", email.Subject, subject)
+	}
+	if from, err := msg.Header.AddressList("From"); err != nil || from[0].Name != "쇼핑몰" {
+		t.Errorf("Expected the sender's name, got %v, %v", from, err)
+	}
+	if body, _ := io.ReadAll(msg.Body); !strings.Contains(string(body), "#1") {
+		t.Errorf("Expected the body, got %q", body)
+	}
+}
+
+func TestSMTPSenderErrors(t *testing.T) {
+	email := Email{To: "kim@example.com", Subject: "주문", HTML: "<p>주문</p>"}
+	if err := (SMTPSender{Addr: "localhost", From: "shop@shop.local"}).Send(context.Background(), email); err == nil {
+		t.Error("Expected an error for an address without a port")
+	}
+	if err := (SMTPSender{Addr: "localhost:25", From: "shop"}).Send(context.Background(), email); err == nil {
+		t.Error("Expected an error for a sender that isn't an address")
+	}
+
+	// Nothing listens on a port just closed
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := listener.Addr().String()
+	listener.Close()
+	if err := (SMTPSender{Addr: addr, From: "shop@shop.local"}).Send(context.Background(), email); err == nil {
+		t.Error("Expected an error for a server that isn't there")
+	}
+}
+
+func TestEmailMessage(t *testing.T) {
+	email := Email{To: "kim@example.com", Subject: "배송이 시작되었습니다", HTML: strings.Repeat("가", 100)}
+	message := string(email.message("shop@shop.local", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)))
+
+	for _, want := range []string{
+		"Date: Fri, 02 Jan 2026 03:04:05 +0000\r\n",
+		"Content-Type: text/html; charset=utf-8\r\n",
+		"Content-Transfer-Encoding: quoted-printable\r\n",
+	} {
+		if !strings.Contains(message, want) {
+			t.Errorf("Expected %q in the message:\n%s", want, message)
+		}
+	}
+	_, body, _ := strings.Cut(message, "\r\n\r\n")
+	for i, line := range strings.Split(body, "\r\n") {
+		if len(line) > 76 {
+			t.Errorf("Body line %d is %d characters long, more than 76", i+1, len(line))
+		}
+	}
+	if strings.Contains(message, "배송") {
+		t.Errorf("Expected the subject encoded, got:\n%s", message)
+	}
+}
@@ -0,0 +1,260 @@
+package models
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"image"
+	_ "image/gif" // register the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Upload limits
+const (
+	MaxImageSize   = 5 << 20 // 5 MB
+	MaxImagePixels = 40_000_000
+)
+
+var (
+	// ErrImageTooLarge is returned for an upload over MaxImageSize
+	ErrImageTooLarge = errors.New("image must be 5 MB or smaller")
+	// ErrUnsupportedImage is returned for an upload that isn't an image we can read
+	ErrUnsupportedImage = errors.New("only JPEG, PNG and GIF images are supported")
+)
+
+// ImageSize is one of the sizes a product image is scaled to
+type ImageSize string
+
+const (
+	// ImageGrid is the square thumbnail on product cards
+	ImageGrid ImageSize = "grid"
+	// ImageDetail is the gallery image on the product detail page
+	ImageDetail ImageSize = "detail"
+)
+
+// imageSizes are the pixel widths of each size. Grid thumbnails are
+// cropped square to fit the cards.
+var imageSizes = map[ImageSize]int{
+	ImageGrid:   400,
+	ImageDetail: 800,
+}
+
+// mediaPrefix is the path stored images are served under
+const mediaPrefix = "/media/"
+
+// imageExtensions maps accepted content types to file extensions
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// imageNamePattern matches the names generated by Save
+var imageNamePattern = regexp.MustCompile(`^[0-9a-f]{32}\.(jpg|png|gif)$`)
+
+// Image describes a stored upload
+type Image struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
+
+// URL returns the path the original image is served from
+func (img Image) URL() string {
+	return mediaPrefix + img.Name
+}
+
+// ImageURL returns the URL of an image at the given size. Stored images
+// have a scaled copy for each size; any other URL is returned as it is.
+func ImageURL(url string, size ImageSize) string {
+	name, ok := strings.CutPrefix(url, mediaPrefix)
+	if !ok || !ValidImageName(name) {
+		return url
+	}
+	return mediaPrefix + scaledName(name, size)
+}
+
+// scaledName returns the file name of an image's copy at the given size
+func scaledName(name string, size ImageSize) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if ext == ".jpg" {
+		return base + "_" + string(size) + ".jpg"
+	}
+	// GIFs are scaled to PNG since only the first frame is kept
+	return base + "_" + string(size) + ".png"
+}
+
+// ValidImageName reports whether name looks like an image stored by Save
+func ValidImageName(name string) bool {
+	return imageNamePattern.MatchString(name)
+}
+
+// ImageStore keeps uploaded product images on disk, each with a scaled copy
+// for every ImageSize. As an fs.FS it serves the stored files.
+type ImageStore struct {
+	dir string
+	fs.FS
+}
+
+// NewImageStore creates an image store in dir, creating it if needed
+func NewImageStore(dir string) (*ImageStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &ImageStore{dir: dir, FS: os.DirFS(dir)}, nil
+}
+
+// Save validates an uploaded image, then writes it with a random name
+// alongside its scaled copies
+func (s *ImageStore) Save(data []byte) (Image, error) {
+	img, scaled, err := prepareImage(data)
+	if err != nil {
+		return Image{}, err
+	}
+
+	written := []string{img.Name}
+	if err := os.WriteFile(filepath.Join(s.dir, img.Name), data, 0o644); err != nil {
+		return Image{}, err
+	}
+	for name, data := range scaled {
+		if err := os.WriteFile(filepath.Join(s.dir, name), data, 0o644); err != nil {
+			for _, name := range written {
+				os.Remove(filepath.Join(s.dir, name))
+			}
+			return Image{}, err
+		}
+		written = append(written, name)
+	}
+
+	return img, nil
+}
+
+// prepareImage validates an upload, names it and encodes its scaled
+// copies, by file name
+func prepareImage(data []byte) (Image, map[string][]byte, error) {
+	if len(data) > MaxImageSize {
+		return Image{}, nil, ErrImageTooLarge
+	}
+
+	// Trust the bytes, not the client-supplied filename or header
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return Image{}, nil, ErrUnsupportedImage
+	}
+
+	// Check dimensions before decoding to avoid decompression bombs
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width*cfg.Height > MaxImagePixels {
+		return Image{}, nil, ErrUnsupportedImage
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, nil, ErrUnsupportedImage
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Image{}, nil, err
+	}
+	name := hex.EncodeToString(id) + ext
+
+	scaled := make(map[string][]byte)
+	for size, width := range imageSizes {
+		img := src
+		if size == ImageGrid {
+			img = cropSquare(src)
+		}
+
+		var buf bytes.Buffer
+		if ext == ".jpg" {
+			err = jpeg.Encode(&buf, Thumbnail(img, width), &jpeg.Options{Quality: 85})
+		} else {
+			err = png.Encode(&buf, Thumbnail(img, width))
+		}
+		if err != nil {
+			return Image{}, nil, err
+		}
+		scaled[scaledName(name, size)] = buf.Bytes()
+	}
+
+	return Image{
+		Name:        name,
+		ContentType: contentType,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+	}, scaled, nil
+}
+
+// cropSquare returns the largest square in the middle of src
+func cropSquare(src image.Image) image.Image {
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+	return subImage(src, image.Rect(x0, y0, x0+side, y0+side))
+}
+
+// subImage returns the part of src within r
+func subImage(src image.Image, r image.Rectangle) image.Image {
+	if sub, ok := src.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(r)
+	}
+	dst := image.NewRGBA(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			dst.Set(x, y, src.At(x, y))
+		}
+	}
+	return dst
+}
+
+// Thumbnail scales src down to at most width pixels wide, keeping its
+// aspect ratio. Each output pixel averages the source pixels it covers.
+func Thumbnail(src image.Image, width int) image.Image {
+	b := src.Bounds()
+	if b.Dx() <= width {
+		width = b.Dx()
+	}
+	height := max(1, b.Dy()*width/b.Dx())
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := b.Min.Y + y*b.Dy()/height
+		y1 := max(y0+1, b.Min.Y+(y+1)*b.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := b.Min.X + x*b.Dx()/width
+			x1 := max(x0+1, b.Min.X+(x+1)*b.Dx()/width)
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(pr), g+uint64(pg), bl+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(bl / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+
+	return dst
+}
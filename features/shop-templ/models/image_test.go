@@ -0,0 +1,171 @@
+package models
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestImageStoreSave(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewImageStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := store.Save(encodePNG(t, 1200, 600))
+	if err != nil {
+		t.Fatalf("Expected upload to be saved, got %v", err)
+	}
+
+	if !ValidImageName(img.Name) || filepath.Ext(img.Name) != ".png" {
+		t.Errorf("Unexpected image name %q", img.Name)
+	}
+	if img.Width != 1200 || img.Height != 600 || img.ContentType != "image/png" {
+		t.Errorf("Unexpected image metadata: %+v", img)
+	}
+
+	tests := []struct {
+		size          ImageSize
+		width, height int
+	}{
+		{ImageGrid, 400, 400},
+		{ImageDetail, 800, 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.size), func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join(dir, scaledName(img.Name, tt.size)))
+			if err != nil {
+				t.Fatalf("Expected scaled copy on disk: %v", err)
+			}
+			cfg, err := png.DecodeConfig(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Width != tt.width || cfg.Height != tt.height {
+				t.Errorf("Expected %dx%d, got %dx%d", tt.width, tt.height, cfg.Width, cfg.Height)
+			}
+		})
+	}
+}
+
+func TestImageStoreSaveJPEG(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewImageStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := store.Save(encodeJPEG(t, 300, 500))
+	if err != nil {
+		t.Fatalf("Expected upload to be saved, got %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, scaledName(img.Name, ImageGrid)))
+	if err != nil {
+		t.Fatalf("Expected grid copy on disk: %v", err)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Expected JPEG grid copy: %v", err)
+	}
+	// Small images aren't scaled up, only cropped square
+	if cfg.Width != 300 || cfg.Height != 300 {
+		t.Errorf("Expected 300x300 grid copy, got %dx%d", cfg.Width, cfg.Height)
+	}
+}
+
+func TestImageStoreRejectsInvalidUploads(t *testing.T) {
+	store, err := NewImageStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{name: "text", data: []byte("hello, not an image"), want: ErrUnsupportedImage},
+		{name: "svg", data: []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), want: ErrUnsupportedImage},
+		{name: "truncated png", data: encodePNG(t, 10, 10)[:40], want: ErrUnsupportedImage},
+		{name: "too large", data: make([]byte, MaxImageSize+1), want: ErrImageTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := store.Save(tt.data); err != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestImageURL(t *testing.T) {
+	name := "0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		url  string
+		size ImageSize
+		want string
+	}{
+		{"/media/" + name + ".jpg", ImageGrid, "/media/" + name + "_grid.jpg"},
+		{"/media/" + name + ".png", ImageDetail, "/media/" + name + "_detail.png"},
+		{"/media/" + name + ".gif", ImageGrid, "/media/" + name + "_grid.png"},
+		{"https://example.com/photo.jpg", ImageGrid, "https://example.com/photo.jpg"},
+		{"/media/../secret.png", ImageGrid, "/media/../secret.png"},
+		{"", ImageGrid, ""},
+	}
+
+	for _, tt := range tests {
+		if got := ImageURL(tt.url, tt.size); got != tt.want {
+			t.Errorf("ImageURL(%q, %q) = %q, want %q", tt.url, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestValidImageName(t *testing.T) {
+	name := "0123456789abcdef0123456789abcdef.gif"
+
+	if !ValidImageName(name) {
+		t.Errorf("Expected %q to be valid", name)
+	}
+	for _, bad := range []string{"../secret.png", "photo.png", name + "/x", ""} {
+		if ValidImageName(bad) {
+			t.Errorf("Expected %q to be invalid", bad)
+		}
+	}
+}
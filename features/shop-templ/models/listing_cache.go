@@ -0,0 +1,170 @@
+package models
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// listingCacheSize is how many listings the cache keeps at most. Searches
+// can ask for any number of different listings, so when it is full the
+// expired ones are dropped, and if that isn't enough, all of them.
+const listingCacheSize = 1000
+
+// listingCache keeps product listings for a while, so listing pages don't
+// go through and rank the whole catalog on every request. The store
+// clears it whenever a product changes.
+type listingCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cachedListing
+	hits    uint64
+	misses  uint64
+}
+
+type cachedListing struct {
+	products []Product
+	built    time.Time
+}
+
+func newListingCache(ttl time.Duration) *listingCache {
+	return &listingCache{ttl: ttl, entries: make(map[string]cachedListing)}
+}
+
+// get returns a deep copy of the listing cached under key, if it was built less
+// than the TTL before now and after the last change at changed
+func (c *listingCache) get(key string, now, changed time.Time) ([]Product, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !c.fresh(entry, now) || changed.After(entry.built) {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	return cloneListing(entry.products), true
+}
+
+// put caches a listing built at built under key
+func (c *listingCache) put(key string, products []Product, built time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= listingCacheSize {
+		for k, entry := range c.entries {
+			if !c.fresh(entry, built) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= listingCacheSize {
+			clear(c.entries)
+		}
+	}
+	c.entries[key] = cachedListing{products: cloneListing(products), built: built}
+}
+
+func (c *listingCache) fresh(entry cachedListing, now time.Time) bool {
+	return now.Sub(entry.built) < c.ttl
+}
+
+// cloneListing copies products down to their tags, images, options and
+// variants, so neither the cache nor whoever got a listing from it can
+// change the other's
+func cloneListing(products []Product) []Product {
+	listing := make([]Product, len(products))
+	for i, p := range products {
+		listing[i] = p.clone()
+	}
+	return listing
+}
+
+// clear drops every cached listing
+func (c *listingCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.entries)
+}
+
+// ListingCacheStats counts how often product listings were found in the
+// cache, and how often they had to be built
+type ListingCacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// CacheListings keeps the product listings GetAll, Search,
+// FilterByCategory and Filter return, and those the store's other
+// listings are built from, for up to ttl. A product changing clears them,
+// and a promotion being added, removed, starting or ending makes those
+// built before it stale, so the cache only saves work and never shows old
+// products or prices.
+func (s *ProductStore) CacheListings(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.listings = nil
+	if ttl > 0 {
+		s.listings = newListingCache(ttl)
+	}
+}
+
+// ListingCacheStats returns how well the listing cache has done since it
+// was turned on; nothing if it isn't
+func (s *ProductStore) ListingCacheStats() ListingCacheStats {
+	s.mu.RLock()
+	c := s.listings
+	s.mu.RUnlock()
+	if c == nil {
+		return ListingCacheStats{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return ListingCacheStats{Hits: c.hits, Misses: c.misses}
+}
+
+// ListingsModified returns when the store's listings last changed: a
+// product changing, or a promotion being added, removed, starting or
+// ending
+func (s *ProductStore) ListingsModified() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.listingsModifiedUnlocked(s.now())
+}
+
+// listingsModifiedUnlocked returns when the listings last changed, as of
+// now. The caller must hold s.mu.
+func (s *ProductStore) listingsModifiedUnlocked(now time.Time) time.Time {
+	if s.promotions == nil {
+		return s.changed
+	}
+	if changed := s.promotions.LastChange(now); changed.After(s.changed) {
+		return changed
+	}
+	return s.changed
+}
+
+// cachedUnlocked returns the listing cached under key, building it with
+// list if it isn't cached or is stale. The caller gets its own copy to
+// sort or slice. The caller must hold s.mu.
+func (s *ProductStore) cachedUnlocked(key string, list func() []Product) []Product {
+	if s.listings == nil {
+		return list()
+	}
+
+	now := s.now()
+	if products, ok := s.listings.get(key, now, s.listingsModifiedUnlocked(now)); ok {
+		return products
+	}
+	products := list()
+	s.listings.put(key, products, now)
+	return products
+}
+
+// listingKey joins what a listing was asked for into a cache key
+func listingKey(kind string, parts ...string) string {
+	return kind + "\x00" + strings.Join(parts, "\x00")
+}
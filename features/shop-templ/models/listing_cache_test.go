@@ -0,0 +1,101 @@
+package models
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestListingCache(t *testing.T) {
+	store := NewProductStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	store.CacheListings(time.Minute)
+	mouse, _ := store.Add(Product{Name: "무선 마우스", Price: Won(20000), Category: "전자제품", Stock: 5, Tags: []string{"무선"}})
+	store.Add(Product{Name: "마우스 패드", Price: Won(5000), Category: "문구", Stock: 5})
+
+	if got := store.Search("마우스"); len(got) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(got))
+	}
+	before := store.ListingCacheStats()
+	got := store.Search("마우스")
+	if stats := store.ListingCacheStats(); stats.Hits != before.Hits+1 || stats.Misses != before.Misses {
+		t.Errorf("Expected the search to come from the cache, went from %+v to %+v", before, stats)
+	}
+
+	// Callers get their own copy to sort
+	slices.Reverse(got)
+	if again := store.Search("마우스"); again[0].ID != got[1].ID {
+		t.Error("Expected sorting the results not to change the cached listing")
+	}
+	// and to change down to their tags
+	for _, p := range got {
+		for i := range p.Tags {
+			p.Tags[i] = "바뀜"
+		}
+	}
+	for _, p := range store.Search("마우스") {
+		if slices.Contains(p.Tags, "바뀜") {
+			t.Errorf("Expected changing a listing's tags not to change the cached one, got %v", p.Tags)
+		}
+	}
+
+	// A product changing clears the cache
+	mouse.Name = "무선 트랙볼"
+	if _, err := store.Update(mouse); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.Search("마우스"); len(got) != 1 {
+		t.Errorf("Expected the renamed product to drop out of the search, got %d results", len(got))
+	}
+	if got := store.FilterByCategory("전자제품"); len(got) != 1 || got[0].Name != "무선 트랙볼" {
+		t.Errorf("Expected the renamed product in its category, got %+v", got)
+	}
+
+	// Listings go stale after the TTL
+	store.GetAll()
+	before = store.ListingCacheStats()
+	now = now.Add(time.Minute)
+	store.GetAll()
+	if stats := store.ListingCacheStats(); stats.Misses != before.Misses+1 {
+		t.Errorf("Expected the listing to be built again after the TTL, went from %+v to %+v", before, stats)
+	}
+	if !store.ListingsModified().Before(now) {
+		t.Error("Expected the listings to have last changed with the rename")
+	}
+}
+
+func TestListingCachePromotions(t *testing.T) {
+	store := NewProductStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	promotions := NewPromotionStore()
+	store.UsePromotions(promotions)
+	store.CacheListings(time.Hour)
+	product, _ := store.Add(Product{Name: "머그컵", Price: Won(10000), Category: "주방", Stock: 5})
+
+	onSale := func() bool {
+		products := store.Filter("", nil, Money{}, Money{}, nil)
+		return len(products) == 1 && products[0].Sale != nil
+	}
+	if onSale() {
+		t.Fatal("Expected no sale before any promotion")
+	}
+
+	// Adding a promotion shows at once
+	if _, err := promotions.Add(Promotion{Name: "지금 할인", ProductID: product.ID, Type: DiscountPercent, Percent: 10, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if !onSale() {
+		t.Error("Expected the promotion added to show in the cached listing")
+	}
+
+	// So does it ending, within the TTL
+	now = now.Add(2 * time.Minute)
+	if onSale() {
+		t.Error("Expected the promotion that ended to drop out of the cached listing")
+	}
+	if modified := store.ListingsModified(); !modified.Equal(now.Add(-time.Minute)) {
+		t.Errorf("Expected the listings to have changed when the promotion ended, got %v", modified)
+	}
+}
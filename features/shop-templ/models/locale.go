@@ -0,0 +1,165 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale is a language the shop is shown in, as a BCP 47 language code
+type Locale string
+
+const (
+	Korean  Locale = "ko"
+	English Locale = "en"
+)
+
+// Locales are the languages shoppers can pick
+var Locales = []Locale{Korean, English}
+
+// DefaultLocale is the language the shop is shown in unless the visitor
+// asks for another
+const DefaultLocale = Korean
+
+// ParseLocale returns the locale for a language tag such as "en" or
+// "en-US", if it is one of Locales
+func ParseLocale(tag string) (Locale, bool) {
+	language, _, _ := strings.Cut(strings.TrimSpace(tag), "-")
+	language, _, _ = strings.Cut(language, "_")
+	locale := Locale(strings.ToLower(language))
+	return locale, slices.Contains(Locales, locale)
+}
+
+// PreferredLocale returns the locale a browser asks for most in an
+// Accept-Language header, such as "en-US,en;q=0.9,ko;q=0.8", or
+// DefaultLocale if it asks for none of Locales
+func PreferredLocale(acceptLanguage string) Locale {
+	best, bestQ := DefaultLocale, 0.0
+	for _, tag := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(tag, ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if locale, ok := ParseLocale(tag); ok && q > bestQ {
+			best, bestQ = locale, q
+		}
+	}
+	return best
+}
+
+// Name is what the language is called in itself, for the language picker
+func (l Locale) Name() string {
+	return l.T("locale.name")
+}
+
+// T returns the message with key in the locale, filled in with args as by
+// fmt.Sprintf. Messages missing from the locale's catalog are shown in
+// DefaultLocale, and a key in neither is returned as is, so a missing
+// message shows up on the page rather than as an empty string.
+func (l Locale) T(key string, args ...any) string {
+	message, ok := l.Lookup(key)
+	if !ok {
+		return key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// Lookup returns the message with key in the locale or, failing that, in
+// DefaultLocale
+func (l Locale) Lookup(key string) (string, bool) {
+	if message, ok := messages[l][key]; ok {
+		return message, true
+	}
+	message, ok := messages[DefaultLocale][key]
+	return message, ok
+}
+
+// Count returns the message with key for n of something, such as "상품
+// 3개" or "3 products", with n grouped as by Number. A locale that writes
+// one differently from many has the message for one under key + ".one".
+func (l Locale) Count(key string, n int) string {
+	if n == 1 {
+		if message, ok := messages[l][key+".one"]; ok {
+			return fmt.Sprintf(message, l.Number(n))
+		}
+	}
+	return l.T(key, l.Number(n))
+}
+
+// Number returns n with its digits grouped in thousands, such as "12,000".
+// Both languages the shop speaks group them the same way.
+func (l Locale) Number(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Price returns the amount as it is written in the locale, such as
+// "129,000원" or "₩129,000". Currencies the locale has no way of its own
+// to write are written as Money.String does.
+func (l Locale) Price(m Money) string {
+	currency := m.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	message, ok := messages[l]["format.price."+string(currency)]
+	if !ok {
+		return m.String()
+	}
+	sign := ""
+	if m.Amount < 0 {
+		sign = "-"
+	}
+	return sign + fmt.Sprintf(message, m.grouped())
+}
+
+// Date returns the day of t as it is written in the locale, such as
+// "2025.03.14" or "Mar 14, 2025"
+func (l Locale) Date(t time.Time) string {
+	return t.Format(l.T("format.date"))
+}
+
+// DateTime returns t to the minute as it is written in the locale, such as
+// "2025.03.14 15:04" or "Mar 14, 2025 3:04 PM"
+func (l Locale) DateTime(t time.Time) string {
+	return t.Format(l.T("format.datetime"))
+}
+
+type localeContextKey struct{}
+
+// ContextWithLocale returns a copy of ctx carrying the language to show
+// the shop in
+func ContextWithLocale(ctx context.Context, locale Locale) context.Context {
+	return context.WithValue(ctx, localeContextKey{}, locale)
+}
+
+// LocaleFromContext returns the language to show the shop in,
+// DefaultLocale if ctx doesn't say
+func LocaleFromContext(ctx context.Context) Locale {
+	if locale, ok := ctx.Value(localeContextKey{}).(Locale); ok {
+		return locale
+	}
+	return DefaultLocale
+}
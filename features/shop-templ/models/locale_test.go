@@ -0,0 +1,139 @@
+package models
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPreferredLocale(t *testing.T) {
+	for tag, want := range map[string]Locale{
+		"en":     English,
+		"en-US":  English,
+		"EN_gb":  English,
+		" ko-KR": Korean,
+	} {
+		if got, ok := ParseLocale(tag); !ok || got != want {
+			t.Errorf("ParseLocale(%q) = %q, %v, want %q", tag, got, ok, want)
+		}
+	}
+	if _, ok := ParseLocale("fr"); ok {
+		t.Error("Expected fr not to be a locale the shop speaks")
+	}
+
+	for header, want := range map[string]Locale{
+		"":                        DefaultLocale,
+		"fr-FR,fr;q=0.9":          DefaultLocale,
+		"en-US,en;q=0.9,ko;q=0.8": English,
+		"ko;q=0.5,en;q=0.8":       English,
+		"fr,ko;q=0.9,en;q=0.7":    Korean,
+		"en;q=bogus,ko;q=0.1":     Korean,
+	} {
+		if got := PreferredLocale(header); got != want {
+			t.Errorf("PreferredLocale(%q) = %q, want %q", header, got, want)
+		}
+	}
+
+	ctx := ContextWithLocale(context.Background(), English)
+	if got := LocaleFromContext(ctx); got != English {
+		t.Errorf("Expected the locale from the context, got %q", got)
+	}
+	if got := LocaleFromContext(context.Background()); got != DefaultLocale {
+		t.Errorf("Expected the default locale, got %q", got)
+	}
+}
+
+func TestLocaleMessages(t *testing.T) {
+	if got := English.T("order.number", 42); got != "Order #42" {
+		t.Errorf("Expected a filled in message, got %q", got)
+	}
+	if got := English.T("no.such.key"); got != "no.such.key" {
+		t.Errorf("Expected a missing message to show its key, got %q", got)
+	}
+
+	messages[Korean]["test.korean_only"] = "한국어만"
+	defer delete(messages[Korean], "test.korean_only")
+	if got := English.T("test.korean_only"); got != "한국어만" {
+		t.Errorf("Expected a message missing in English to be shown in Korean, got %q", got)
+	}
+
+	for _, tt := range []struct {
+		locale Locale
+		n      int
+		want   string
+	}{
+		{English, 1, "1 product"},
+		{English, 2, "2 products"},
+		{English, 12000, "12,000 products"},
+		{Korean, 1, "상품 1개"},
+		{Korean, 1234567, "상품 1,234,567개"},
+	} {
+		if got := tt.locale.Count("listing.count", tt.n); got != tt.want {
+			t.Errorf("%s.Count(%d) = %q, want %q", tt.locale, tt.n, got, tt.want)
+		}
+	}
+	if got := Korean.Number(-1500); got != "-1,500" {
+		t.Errorf("Expected a grouped negative number, got %q", got)
+	}
+
+	day := time.Date(2025, time.March, 14, 15, 4, 0, 0, time.UTC)
+	if got := Korean.Date(day); got != "2025.03.14" {
+		t.Errorf("Expected a Korean date, got %q", got)
+	}
+	if got := English.DateTime(day); got != "Mar 14, 2025 3:04 PM" {
+		t.Errorf("Expected an English date and time, got %q", got)
+	}
+}
+
+func TestLocalePrice(t *testing.T) {
+	for _, tt := range []struct {
+		locale Locale
+		money  Money
+		want   string
+	}{
+		{Korean, Won(129000), "129,000원"},
+		{Korean, Won(-4000), "-4,000원"},
+		{Korean, Money{}, "0원"},
+		{Korean, usd(5874), "$58.74"},
+		{English, Won(129000), "₩129,000"},
+		{English, Won(-4000), "-₩4,000"},
+		{English, usd(123456789), "$1,234,567.89"},
+	} {
+		if got := tt.locale.Price(tt.money); got != tt.want {
+			t.Errorf("%s.Price(%v) = %q, want %q", tt.locale, tt.money, got, tt.want)
+		}
+	}
+}
+
+func TestMessageCatalogs(t *testing.T) {
+	verbs := regexp.MustCompile(`%[a-z]`)
+	for _, locale := range Locales {
+		if _, ok := messages[locale]; !ok {
+			t.Fatalf("Expected a message catalog for %q", locale)
+		}
+	}
+
+	for key, message := range messages[DefaultLocale] {
+		for _, locale := range Locales {
+			translated, ok := messages[locale][key]
+			if !ok {
+				t.Errorf("Expected %q to have %q", locale, key)
+				continue
+			}
+			if want, got := verbs.FindAllString(message, -1), verbs.FindAllString(translated, -1); !slices.Equal(want, got) {
+				t.Errorf("Expected %q in %q to take %v like %q, got %v", key, locale, want, DefaultLocale, got)
+			}
+		}
+	}
+	for _, locale := range Locales {
+		for key := range messages[locale] {
+			base, _ := strings.CutSuffix(key, ".one")
+			if _, ok := messages[DefaultLocale][base]; !ok {
+				t.Errorf("Expected %q in %q to be in %q too", key, locale, DefaultLocale)
+			}
+		}
+	}
+}
@@ -0,0 +1,615 @@
+package models
+
+// messages are the shop's UI strings in each language, by key. Keys are
+// grouped by the page or feature they belong to, such as "cart.title".
+// Messages taking values are filled in by fmt.Sprintf; counts are passed
+// already grouped, as %s.
+var messages = map[Locale]map[string]string{
+	Korean:  koreanMessages,
+	English: englishMessages,
+}
+
+var koreanMessages = map[string]string{
+	"locale.name":      "한국어",
+	"format.date":      "2006.01.02",
+	"format.datetime":  "2006.01.02 15:04",
+	"format.price.KRW": "%s원",
+
+	// Layout
+	"layout.language": "언어",
+	"layout.currency": "표시 통화",
+	"layout.points":   "보유 포인트",
+	"layout.search":   "상품 검색...",
+	"nav.home":        "홈",
+	"nav.categories":  "카테고리",
+	"nav.cart":        "장바구니",
+	"nav.account":     "내 정보",
+	"nav.login":       "로그인",
+	"nav.unread":      "읽지 않은 알림 %d개",
+
+	// Product listing
+	"listing.title":         "상품",
+	"listing.all":           "전체",
+	"listing.count":         "상품 %s개",
+	"listing.sort":          "정렬",
+	"listing.filters":       "필터",
+	"listing.filters.count": "필터 (%d)",
+	"listing.filters.reset": "필터 초기화",
+	"listing.category":      "카테고리",
+	"listing.price":         "가격",
+	"listing.price.min":     "최소 가격",
+	"listing.price.max":     "최대 가격",
+	"listing.tags":          "태그",
+	"listing.did_you_mean":  "혹시 이것을 찾으셨나요?",
+	"listing.empty":         "상품이 없습니다",
+	"listing.empty.hint":    "검색어를 변경하거나 필터를 해제해보세요",
+	"listing.more":          "더 보기",
+	"sort.default":          "기본순",
+	"sort.popular":          "인기순",
+	"sort.newest":           "최신순",
+	"sort.price_asc":        "낮은 가격순",
+	"sort.price_desc":       "높은 가격순",
+	"sort.name":             "이름순",
+	"search.suggestions":    "검색어 추천",
+
+	// Categories
+	"category.title":       "카테고리",
+	"category.breadcrumbs": "카테고리 경로",
+	"category.empty":       "카테고리가 없습니다",
+	"category.empty.hint":  "판매 중인 상품이 생기면 여기에 표시됩니다",
+
+	// Products
+	"product.back":            "상품 목록",
+	"product.add":             "담기",
+	"product.add_to_cart":     "장바구니 담기",
+	"product.choose_options":  "옵션 선택",
+	"product.recommendations": "이런 상품은 어떠세요?",
+	"product.admin.edit":      "상품 수정",
+	"product.admin.images":    "이미지 관리",
+	"stock.available":         "재고: %s개",
+	"stock.variant":           "%s개",
+	"stock.low":               "%s개 남음",
+	"stock.out":               "품절",
+	"quantity":                "수량",
+	"quantity.decrease":       "수량 줄이기",
+	"quantity.increase":       "수량 늘리기",
+	"restock.watch":           "재입고 알림 받기",
+	"restock.watching":        "재입고 알림 신청됨 · 취소",
+	"restock.error.in_stock":  "재고가 있는 상품입니다. 새로고침 후 장바구니에 담아주세요",
+	"sale.off":                "%s 할인",
+	"sale.left":               "%s 남음",
+	"sale.days":               "%d일 %s",
+	"sale.ended":              "세일 종료",
+	"bundle.offers":           "이 상품이 포함된 묶음",
+	"bundle.savings":          "%s 절약",
+	"bundle.add":              "묶음 담기",
+	"bundle.missing_product":  "상품 #%d",
+
+	// Cart
+	"cart.title":             "장바구니",
+	"cart.empty":             "장바구니가 비어있습니다",
+	"cart.empty.hint":        "상품을 추가해보세요",
+	"cart.item_count":        "상품 개수",
+	"cart.items":             "%s개",
+	"cart.bundle.remove":     "빼기",
+	"cart.coupon.code":       "쿠폰 코드",
+	"cart.coupon.apply":      "적용",
+	"cart.coupon.applied":    "%s 적용됨",
+	"cart.coupon.remove":     "취소",
+	"cart.coupon.min_order":  "%s 이상 주문 시 적용됩니다",
+	"cart.checkout":          "주문하기 (%s)",
+	"cart.clear":             "장바구니 비우기",
+	"coupon.error":           "쿠폰을 적용할 수 없습니다",
+	"coupon.error.not_found": "존재하지 않는 쿠폰 코드입니다",
+	"coupon.error.expired":   "만료된 쿠폰입니다",
+	"coupon.error.used_up":   "사용 한도가 끝난 쿠폰입니다",
+	"coupon.error.min_order": "최소 주문 금액을 채우지 않았습니다",
+
+	// Cart errors
+	"cart.error.stock":         "%s의 재고가 부족합니다 (남은 수량 %d개)",
+	"cart.error.stock.in_cart": "장바구니에 이미 %s %d개가 담겨 있어 더 담을 수 없습니다 (재고 %d개)",
+	"cart.error.variant":       "상품 옵션을 선택해주세요",
+	"cart.error.not_found":     "더 이상 판매하지 않는 상품입니다",
+
+	// Cart toasts
+	"cart.toast.added":   "장바구니에 담았습니다: %s",
+	"cart.toast.removed": "장바구니에서 뺐습니다",
+	"cart.toast.cleared": "장바구니를 비웠습니다",
+	"cart.toast.coupon":  "%s 쿠폰을 적용했습니다",
+
+	// Checkout
+	"checkout.title":                "주문하기",
+	"checkout.guest":                "비회원으로 주문합니다. 주문번호와 이메일로 주문을 조회할 수 있습니다.",
+	"checkout.guest.login":          "로그인",
+	"checkout.guest.points":         "하면 포인트가 적립됩니다.",
+	"checkout.saved_addresses":      "저장된 배송지",
+	"checkout.manage_addresses":     "관리",
+	"checkout.save_address":         "이 배송지를 저장",
+	"checkout.memo":                 "배송 메모 (선택)",
+	"checkout.email.optional":       "주문 안내 이메일 (선택)",
+	"checkout.email.optional.hint":  "주문 확인과 배송 소식을 보내드립니다",
+	"checkout.email.hint":           "주문 조회와 배송 소식에 쓰입니다",
+	"checkout.payment":              "결제",
+	"checkout.points":               "포인트 사용 (보유 %s)",
+	"checkout.card":                 "카드 번호",
+	"checkout.billing":              "청구지 주소 (선택)",
+	"checkout.billing.hint":         "배송지와 다르면 입력해주세요",
+	"checkout.test_payment":         "테스트 결제입니다. 실제로 청구되지 않으며, %s는 거절됩니다.",
+	"checkout.converted":            "약 %s · 결제는 원화(%s)로 진행됩니다",
+	"checkout.pay":                  "%s 결제하기",
+	"checkout.error.email":          "이메일 주소를 확인해주세요",
+	"checkout.error.guest_email":    "비회원 주문은 주문 조회에 쓸 이메일을 입력해주세요",
+	"checkout.error.card":           "카드 번호를 입력해주세요",
+	"checkout.error.points":         "사용할 포인트를 숫자로 입력해주세요",
+	"checkout.error.points_balance": "보유한 포인트보다 많이 사용할 수 없습니다",
+	"checkout.error.coupon":         "%s. 쿠폰 없이 다시 주문해주세요",
+	"checkout.error.stock":          "%s의 재고가 부족합니다 (남은 수량 %d개). 장바구니를 확인해주세요",
+	"checkout.error.unavailable":    "주문할 수 없는 상품이 있습니다. 장바구니를 확인해주세요",
+	"checkout.error.declined":       "결제가 거절되었습니다. 다른 카드로 다시 시도해주세요",
+	"checkout.error.payment":        "결제를 처리할 수 없습니다. 잠시 후 다시 시도해주세요",
+	"shipping.title":                "배송 정보",
+	"shipping.name":                 "받는 분",
+	"shipping.phone":                "연락처",
+	"shipping.address":              "주소",
+	"shipping.country":              "배송 국가",
+	"shipping.memo":                 "배송 메모",
+	"shipping.billing":              "청구지",
+	"shipping.method":               "배송 방법",
+	"shipping.free":                 "무료",
+	"shipping.method.standard":      "일반 배송",
+	"shipping.method.express":       "빠른 배송",
+	"shipping.method.international": "해외 배송",
+	"shipping.days.standard":        "2-3일",
+	"shipping.days.express":         "다음 날",
+	"shipping.days.international":   "7-14일",
+	"shipping.error.country":        "배송할 수 없는 국가입니다",
+	"shipping.error.incomplete":     "이름, 연락처, 주소를 모두 입력해주세요",
+	"shipping.error.method":         "선택한 배송 방법을 사용할 수 없습니다",
+	"country.KR":                    "대한민국",
+	"country.US":                    "미국",
+	"country.JP":                    "일본",
+	"country.CN":                    "중국",
+
+	// Orders
+	"order.title":                   "주문 상세",
+	"order.placed":                  "주문이 완료되었습니다",
+	"order.cancelled":               "주문이 취소되었습니다",
+	"order.number":                  "주문번호 #%d",
+	"order.items":                   "주문 상품",
+	"order.subtotal":                "상품 금액",
+	"order.bundle_discount":         "묶음 할인 (%s × %d)",
+	"order.coupon_discount":         "쿠폰 할인 (%s)",
+	"order.shipping":                "배송비 (%s)",
+	"order.points_used":             "포인트 사용",
+	"order.points_earned":           "적립 포인트",
+	"order.total":                   "총 금액",
+	"order.status":                  "주문 상태",
+	"order.status.pending":          "결제 대기",
+	"order.status.paid":             "결제 완료",
+	"order.status.packed":           "상품 준비 완료",
+	"order.status.shipped":          "배송 중",
+	"order.status.delivered":        "배송 완료",
+	"order.status.cancelled":        "주문 취소",
+	"order.status.refunded":         "환불됨",
+	"order.refunds":                 "환불 내역",
+	"order.cancel":                  "주문 취소",
+	"order.continue_shopping":       "쇼핑 계속하기",
+	"order.error.shipped":           "이미 발송된 주문은 취소할 수 없습니다",
+	"order.error.cancel_payment":    "결제를 취소할 수 없습니다. 잠시 후 다시 시도해주세요",
+	"order.account":                 "회원으로 가입하기",
+	"order.account.hint":            "%s(으)로 가입하면 이 주문이 계정에 저장되고, 다음 주문부터 포인트가 적립됩니다.",
+	"order.account.error.linked":    "이미 계정에 연결된 주문입니다",
+	"order.account.error.logged_in": "로그아웃한 뒤 가입해주세요",
+	"order.account.error.email":     "주문한 이메일로는 가입할 수 없습니다",
+	"order.account.error.exists":    "이미 가입된 이메일입니다. 로그인해주세요",
+	"lookup.title":                  "비회원 주문 조회",
+	"lookup.number":                 "주문번호",
+	"lookup.email":                  "주문한 이메일",
+	"lookup.submit":                 "조회하기",
+	"lookup.member":                 "회원으로 주문하셨다면",
+	"lookup.member.login":           "로그인",
+	"lookup.member.after":           "해서 주문 내역을 확인해주세요.",
+	"lookup.error.number":           "주문번호는 숫자로 입력해주세요",
+	"lookup.error.not_found":        "주문번호와 이메일이 일치하는 비회원 주문이 없습니다",
+
+	// Accounts
+	"account.title":             "내 정보",
+	"account.login":             "로그인",
+	"account.logout":            "로그아웃",
+	"account.register":          "회원가입",
+	"account.register.submit":   "가입하기",
+	"account.email":             "이메일",
+	"account.password":          "비밀번호",
+	"account.new_password":      "비밀번호 (8자 이상)",
+	"account.name":              "이름 (선택)",
+	"account.no_account":        "계정이 없으신가요?",
+	"account.has_account":       "이미 계정이 있으신가요?",
+	"account.guest_order":       "비회원으로 주문하셨나요?",
+	"account.lookup":            "주문 조회",
+	"account.greeting":          "%s님",
+	"account.orders":            "주문 내역",
+	"account.orders.empty":      "아직 주문이 없습니다",
+	"account.admin.orders":      "주문 관리",
+	"account.admin.products":    "상품 관리",
+	"account.admin.categories":  "카테고리 관리",
+	"account.admin.promotions":  "프로모션 관리",
+	"account.admin.bundles":     "묶음 상품 관리",
+	"account.admin.analytics":   "통계",
+	"account.error.credentials": "이메일 또는 비밀번호가 올바르지 않습니다",
+	"account.error.email":       "올바른 이메일 주소를 입력해주세요",
+	"account.error.password":    "비밀번호는 8자 이상이어야 합니다",
+	"account.error.exists":      "이미 가입된 이메일입니다",
+	"notifications.title":       "알림",
+	"notifications.empty":       "알림이 없습니다",
+	"points.title":              "포인트",
+	"points.amount":             "%sP",
+	"points.about":              "결제가 끝난 주문마다 포인트가 적립되고, 주문할 때 1포인트를 1원처럼 쓸 수 있습니다. 주문을 취소하거나 환불하면 쓴 포인트는 돌려드리고 적립된 포인트는 회수합니다.",
+	"points.history":            "포인트 내역",
+	"points.history.empty":      "포인트 내역이 없습니다",
+	"points.order":              "주문 #%d",
+	"points.earned":             "적립",
+	"points.redeemed":           "주문 사용",
+	"points.restored":           "사용 취소",
+	"points.revoked":            "적립 취소",
+	"points.note.pending":       "(결제 완료 후 적립)",
+	"points.note.revoked":       "(적립 취소)",
+
+	// Address book
+	"addresses.title":             "배송지 관리",
+	"addresses.shipping":          "배송지",
+	"addresses.billing":           "청구지",
+	"addresses.empty.shipping":    "저장된 배송지가 없습니다",
+	"addresses.empty.billing":     "저장된 청구지가 없습니다",
+	"addresses.add":               "주소 추가",
+	"addresses.about":             "기본 배송지와 청구지는 주문할 때 자동으로 채워집니다. 처음 저장한 주소는 기본 주소가 됩니다.",
+	"addresses.kind":              "종류",
+	"addresses.label":             "별칭 (선택)",
+	"addresses.label.placeholder": "집, 회사",
+	"addresses.country":           "국가",
+	"addresses.default":           "기본",
+	"addresses.set_default":       "기본 주소로 설정",
+	"addresses.make_default":      "기본으로",
+	"addresses.save":              "저장",
+	"addresses.edit":              "수정",
+	"addresses.edit.title":        "주소 수정",
+	"addresses.edit.shipping":     "배송지 수정",
+	"addresses.edit.billing":      "청구지 수정",
+	"addresses.delete":            "삭제",
+	"addresses.error":             "주소를 저장할 수 없습니다",
+	"addresses.error.kind":        "배송지인지 청구지인지 선택해주세요",
+
+	// Rate limits
+	"error.rate_limited": "요청이 너무 많습니다. 잠시 후 다시 시도해주세요",
+	"error.bot":          "자동화된 요청으로 보여 처리할 수 없습니다",
+
+	// Server errors
+	"error.server":      "문제가 발생했습니다",
+	"error.server.hint": "잠시 후 다시 시도해주세요. 문제가 계속되면 아래 오류 번호와 함께 문의해주세요",
+	"error.request_id":  "오류 번호: %s",
+	"error.home":        "홈으로 가기",
+
+	// JSON API errors
+	"api.error.limit":             "limit은 1에서 %d 사이여야 합니다",
+	"api.error.cursor":            "cursor를 읽을 수 없습니다. 이전 응답의 nextCursor를 그대로 보내주세요",
+	"api.error.body":              "요청 본문을 읽을 수 없습니다: %v",
+	"api.error.login":             "로그인이 필요합니다",
+	"api.error.admin":             "관리자만 사용할 수 있습니다",
+	"api.error.product_id":        "상품 ID는 숫자여야 합니다",
+	"api.error.product_not_found": "상품을 찾을 수 없습니다",
+	"api.error.sku_not_found":     "SKU나 바코드가 일치하는 상품이 없습니다",
+	"api.error.item_id":           "상품과 옵션 ID는 숫자여야 합니다",
+	"api.error.variant":           "상품 옵션을 선택해주세요",
+	"api.error.quantity_add":      "수량은 1 이상이어야 합니다",
+	"api.error.quantity":          "수량은 0 이상이어야 합니다",
+	"api.error.not_in_cart":       "장바구니에 없는 상품입니다",
+	"api.error.empty_cart":        "장바구니가 비어 있습니다",
+	"api.error.guest_email":       "비회원 주문은 이메일이 필요합니다",
+	"api.error.points":            "사용할 포인트를 확인해주세요",
+	"api.error.stock":             "%s의 재고가 부족합니다 (남은 수량 %d개)",
+	"api.error.order_id":          "주문 ID는 숫자여야 합니다",
+	"api.error.order_not_found":   "주문을 찾을 수 없습니다",
+}
+
+var englishMessages = map[string]string{
+	"locale.name":      "English",
+	"format.date":      "Jan 2, 2006",
+	"format.datetime":  "Jan 2, 2006 3:04 PM",
+	"format.price.KRW": "₩%s",
+
+	// Layout
+	"layout.language": "Language",
+	"layout.currency": "Currency",
+	"layout.points":   "Your points",
+	"layout.search":   "Search products...",
+	"nav.home":        "Home",
+	"nav.categories":  "Categories",
+	"nav.cart":        "Cart",
+	"nav.account":     "Account",
+	"nav.login":       "Log in",
+	"nav.unread":      "%d unread notifications",
+
+	// Product listing
+	"listing.title":         "Products",
+	"listing.all":           "All",
+	"listing.count":         "%s products",
+	"listing.count.one":     "%s product",
+	"listing.sort":          "Sort",
+	"listing.filters":       "Filters",
+	"listing.filters.count": "Filters (%d)",
+	"listing.filters.reset": "Clear filters",
+	"listing.category":      "Category",
+	"listing.price":         "Price",
+	"listing.price.min":     "Minimum price",
+	"listing.price.max":     "Maximum price",
+	"listing.tags":          "Tags",
+	"listing.did_you_mean":  "Did you mean",
+	"listing.empty":         "No products found",
+	"listing.empty.hint":    "Try another search or clear the filters",
+	"listing.more":          "Show more",
+	"sort.default":          "Featured",
+	"sort.popular":          "Most popular",
+	"sort.newest":           "Newest",
+	"sort.price_asc":        "Price: low to high",
+	"sort.price_desc":       "Price: high to low",
+	"sort.name":             "Name",
+	"search.suggestions":    "Search suggestions",
+
+	// Categories
+	"category.title":       "Categories",
+	"category.breadcrumbs": "Category path",
+	"category.empty":       "No categories yet",
+	"category.empty.hint":  "Categories show up here once products are on sale",
+
+	// Products
+	"product.back":            "All products",
+	"product.add":             "Add",
+	"product.add_to_cart":     "Add to cart",
+	"product.choose_options":  "Choose options",
+	"product.recommendations": "You might also like",
+	"product.admin.edit":      "Edit product",
+	"product.admin.images":    "Manage images",
+	"stock.available":         "%s in stock",
+	"stock.variant":           "%s left",
+	"stock.low":               "Only %s left",
+	"stock.out":               "Sold out",
+	"quantity":                "Quantity",
+	"quantity.decrease":       "Decrease quantity",
+	"quantity.increase":       "Increase quantity",
+	"restock.watch":           "Notify me when it's back",
+	"restock.watching":        "You'll be notified · Cancel",
+	"restock.error.in_stock":  "This product is in stock. Refresh the page to add it to your cart",
+	"sale.off":                "%s off",
+	"sale.left":               "%s left",
+	"sale.days":               "%dd %s",
+	"sale.ended":              "Sale ended",
+	"bundle.offers":           "Bundles with this product",
+	"bundle.savings":          "Save %s",
+	"bundle.add":              "Add bundle",
+	"bundle.missing_product":  "Product #%d",
+
+	// Cart
+	"cart.title":             "Cart",
+	"cart.empty":             "Your cart is empty",
+	"cart.empty.hint":        "Add some products",
+	"cart.item_count":        "Items",
+	"cart.items":             "%s items",
+	"cart.items.one":         "%s item",
+	"cart.bundle.remove":     "Remove",
+	"cart.coupon.code":       "Coupon code",
+	"cart.coupon.apply":      "Apply",
+	"cart.coupon.applied":    "%s applied",
+	"cart.coupon.remove":     "Remove",
+	"cart.coupon.min_order":  "Applies to orders of %s or more",
+	"cart.checkout":          "Check out (%s)",
+	"cart.clear":             "Empty cart",
+	"coupon.error":           "This coupon can't be applied",
+	"coupon.error.not_found": "There is no coupon with this code",
+	"coupon.error.expired":   "This coupon has expired",
+	"coupon.error.used_up":   "This coupon has been used up",
+	"coupon.error.min_order": "Your order doesn't reach the coupon's minimum",
+
+	// Cart errors
+	"cart.error.stock":         "Not enough %s in stock (%d left)",
+	"cart.error.stock.in_cart": "Your cart already has %s × %d, so no more can be added (%d in stock)",
+	"cart.error.variant":       "Please choose the product's options",
+	"cart.error.not_found":     "This product is no longer sold",
+
+	// Cart toasts
+	"cart.toast.added":   "Added to your cart: %s",
+	"cart.toast.removed": "Removed from your cart",
+	"cart.toast.cleared": "Your cart is empty now",
+	"cart.toast.coupon":  "Coupon %s applied",
+
+	// Checkout
+	"checkout.title":                "Checkout",
+	"checkout.guest":                "You're checking out as a guest. You can look up the order with its number and your email.",
+	"checkout.guest.login":          "Log in",
+	"checkout.guest.points":         " to earn points.",
+	"checkout.saved_addresses":      "Saved addresses",
+	"checkout.manage_addresses":     "Manage",
+	"checkout.save_address":         "Save this address",
+	"checkout.memo":                 "Delivery note (optional)",
+	"checkout.email.optional":       "Email for order updates (optional)",
+	"checkout.email.optional.hint":  "We'll send your confirmation and delivery updates",
+	"checkout.email.hint":           "Used to look up your order and send delivery updates",
+	"checkout.payment":              "Payment",
+	"checkout.points":               "Use points (you have %s)",
+	"checkout.card":                 "Card number",
+	"checkout.billing":              "Billing address (optional)",
+	"checkout.billing.hint":         "If different from the shipping address",
+	"checkout.test_payment":         "This is a test payment. Nothing is charged, and %s is declined.",
+	"checkout.converted":            "About %s · you'll be charged in won (%s)",
+	"checkout.pay":                  "Pay %s",
+	"checkout.error.email":          "Please check the email address",
+	"checkout.error.guest_email":    "Guest orders need an email to look the order up with",
+	"checkout.error.card":           "Please enter a card number",
+	"checkout.error.points":         "Please enter the points to use as a number",
+	"checkout.error.points_balance": "You can't use more points than you have",
+	"checkout.error.coupon":         "%s. Please order again without the coupon",
+	"checkout.error.stock":          "Not enough %s in stock (%d left). Please check your cart",
+	"checkout.error.unavailable":    "Some products can't be ordered. Please check your cart",
+	"checkout.error.declined":       "The payment was declined. Please try another card",
+	"checkout.error.payment":        "The payment couldn't be processed. Please try again shortly",
+	"shipping.title":                "Shipping",
+	"shipping.name":                 "Recipient",
+	"shipping.phone":                "Phone",
+	"shipping.address":              "Address",
+	"shipping.country":              "Country",
+	"shipping.memo":                 "Delivery note",
+	"shipping.billing":              "Billing address",
+	"shipping.method":               "Shipping method",
+	"shipping.free":                 "Free",
+	"shipping.method.standard":      "Standard shipping",
+	"shipping.method.express":       "Express shipping",
+	"shipping.method.international": "International shipping",
+	"shipping.days.standard":        "2-3 days",
+	"shipping.days.express":         "Next day",
+	"shipping.days.international":   "7-14 days",
+	"shipping.error.country":        "We don't ship to this country",
+	"shipping.error.incomplete":     "Please enter a name, phone number and address",
+	"shipping.error.method":         "The shipping method you picked isn't available",
+	"country.KR":                    "South Korea",
+	"country.US":                    "United States",
+	"country.JP":                    "Japan",
+	"country.CN":                    "China",
+
+	// Orders
+	"order.title":                   "Order details",
+	"order.placed":                  "Your order is placed",
+	"order.cancelled":               "Your order was cancelled",
+	"order.number":                  "Order #%d",
+	"order.items":                   "Items",
+	"order.subtotal":                "Subtotal",
+	"order.bundle_discount":         "Bundle discount (%s × %d)",
+	"order.coupon_discount":         "Coupon discount (%s)",
+	"order.shipping":                "Shipping (%s)",
+	"order.points_used":             "Points used",
+	"order.points_earned":           "Points earned",
+	"order.total":                   "Total",
+	"order.status":                  "Order status",
+	"order.status.pending":          "Awaiting payment",
+	"order.status.paid":             "Paid",
+	"order.status.packed":           "Packed",
+	"order.status.shipped":          "Shipped",
+	"order.status.delivered":        "Delivered",
+	"order.status.cancelled":        "Cancelled",
+	"order.status.refunded":         "Refunded",
+	"order.refunds":                 "Refunds",
+	"order.cancel":                  "Cancel order",
+	"order.continue_shopping":       "Continue shopping",
+	"order.error.shipped":           "Orders that have shipped can't be cancelled",
+	"order.error.cancel_payment":    "The payment couldn't be cancelled. Please try again shortly",
+	"order.account":                 "Create an account",
+	"order.account.hint":            "Sign up with %s to keep this order in your account and earn points from your next order.",
+	"order.account.error.linked":    "This order already belongs to an account",
+	"order.account.error.logged_in": "Please log out before signing up",
+	"order.account.error.email":     "You can't sign up with the email this order was placed with",
+	"order.account.error.exists":    "This email is already registered. Please log in",
+	"lookup.title":                  "Find a guest order",
+	"lookup.number":                 "Order number",
+	"lookup.email":                  "Email you ordered with",
+	"lookup.submit":                 "Find order",
+	"lookup.member":                 "Ordered with an account?",
+	"lookup.member.login":           "Log in",
+	"lookup.member.after":           " to see your orders.",
+	"lookup.error.number":           "Please enter the order number as a number",
+	"lookup.error.not_found":        "There is no guest order with this number and email",
+
+	// Accounts
+	"account.title":             "Account",
+	"account.login":             "Log in",
+	"account.logout":            "Log out",
+	"account.register":          "Sign up",
+	"account.register.submit":   "Sign up",
+	"account.email":             "Email",
+	"account.password":          "Password",
+	"account.new_password":      "Password (at least 8 characters)",
+	"account.name":              "Name (optional)",
+	"account.no_account":        "Don't have an account?",
+	"account.has_account":       "Already have an account?",
+	"account.guest_order":       "Ordered as a guest?",
+	"account.lookup":            "Find your order",
+	"account.greeting":          "Hi, %s",
+	"account.orders":            "Orders",
+	"account.orders.empty":      "No orders yet",
+	"account.admin.orders":      "Manage orders",
+	"account.admin.products":    "Manage products",
+	"account.admin.categories":  "Manage categories",
+	"account.admin.promotions":  "Manage promotions",
+	"account.admin.bundles":     "Manage bundles",
+	"account.admin.analytics":   "Analytics",
+	"account.error.credentials": "The email or password is incorrect",
+	"account.error.email":       "Please enter a valid email address",
+	"account.error.password":    "Passwords must be at least 8 characters",
+	"account.error.exists":      "This email is already registered",
+	"notifications.title":       "Notifications",
+	"notifications.empty":       "No notifications",
+	"points.title":              "Points",
+	"points.amount":             "%s pts",
+	"points.amount.one":         "%s pt",
+	"points.about":              "Every paid order earns points, and each point is worth ₩1 when you order. Cancelling or refunding an order gives back the points you used and takes back the points it earned.",
+	"points.history":            "Points history",
+	"points.history.empty":      "No points history yet",
+	"points.order":              "Order #%d",
+	"points.earned":             "Earned",
+	"points.redeemed":           "Used on order",
+	"points.restored":           "Use cancelled",
+	"points.revoked":            "Earning cancelled",
+	"points.note.pending":       "(earned once paid)",
+	"points.note.revoked":       "(cancelled)",
+
+	// Address book
+	"addresses.title":             "Addresses",
+	"addresses.shipping":          "Shipping addresses",
+	"addresses.billing":           "Billing addresses",
+	"addresses.empty.shipping":    "No shipping addresses saved",
+	"addresses.empty.billing":     "No billing addresses saved",
+	"addresses.add":               "Add an address",
+	"addresses.about":             "Your default shipping and billing addresses are filled in when you check out. The first address you save becomes the default.",
+	"addresses.kind":              "Type",
+	"addresses.label":             "Label (optional)",
+	"addresses.label.placeholder": "Home, Work",
+	"addresses.country":           "Country",
+	"addresses.default":           "Default",
+	"addresses.set_default":       "Make this the default address",
+	"addresses.make_default":      "Make default",
+	"addresses.save":              "Save",
+	"addresses.edit":              "Edit",
+	"addresses.edit.title":        "Edit address",
+	"addresses.edit.shipping":     "Edit shipping address",
+	"addresses.edit.billing":      "Edit billing address",
+	"addresses.delete":            "Delete",
+	"addresses.error":             "The address couldn't be saved",
+	"addresses.error.kind":        "Please choose shipping or billing",
+
+	// Rate limits
+	"error.rate_limited": "Too many requests. Please try again shortly",
+	"error.bot":          "This looks like an automated request and can't be processed",
+
+	// Server errors
+	"error.server":      "Something went wrong",
+	"error.server.hint": "Please try again shortly. If the problem persists, contact us with the error number below",
+	"error.request_id":  "Error number: %s",
+	"error.home":        "Go to the home page",
+
+	// JSON API errors
+	"api.error.limit":             "limit must be between 1 and %d",
+	"api.error.cursor":            "cursor can't be read; send the nextCursor of the previous response as it is",
+	"api.error.body":              "The request body couldn't be read: %v",
+	"api.error.login":             "You need to log in",
+	"api.error.admin":             "Only admins can use this",
+	"api.error.product_id":        "The product ID must be a number",
+	"api.error.product_not_found": "Product not found",
+	"api.error.sku_not_found":     "No product has this SKU or barcode",
+	"api.error.item_id":           "Product and variant IDs must be numbers",
+	"api.error.variant":           "Please choose the product's options",
+	"api.error.quantity_add":      "The quantity must be at least 1",
+	"api.error.quantity":          "The quantity can't be negative",
+	"api.error.not_in_cart":       "This product isn't in the cart",
+	"api.error.empty_cart":        "The cart is empty",
+	"api.error.guest_email":       "Guest orders need an email",
+	"api.error.points":            "Please check the points to use",
+	"api.error.stock":             "Not enough %s in stock (%d left)",
+	"api.error.order_id":          "The order ID must be a number",
+	"api.error.order_not_found":   "Order not found",
+}
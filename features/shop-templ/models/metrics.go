@@ -0,0 +1,282 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LatencyBuckets are the upper bounds, in seconds, request latencies are
+// counted into
+var LatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Labels name one series of a metric, such as {"status": "paid"}
+type Labels map[string]string
+
+// Sample is the value of one series of a metric when it is read
+type Sample struct {
+	Labels Labels
+	Value  float64
+}
+
+// Counter counts things that only ever go up, in a series for each set of
+// values of its labels
+type Counter struct {
+	labels []string
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	values []string
+	count  float64
+}
+
+// NewCounter creates a counter with the labels, in the order Add is given
+// their values
+func NewCounter(labels ...string) *Counter {
+	return &Counter{labels: labels, series: make(map[string]*counterSeries)}
+}
+
+// Add adds n to the series with the label values
+func (c *Counter) Add(n float64, values ...string) {
+	key := seriesKey(values)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.series[key]
+	if !ok {
+		s = &counterSeries{values: slices.Clone(values)}
+		c.series[key] = s
+	}
+	s.count += n
+}
+
+// Samples returns the count of every series, in the order of their label
+// values
+func (c *Counter) Samples() []Sample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	samples := make([]Sample, 0, len(c.series))
+	for _, key := range sortedKeys(c.series) {
+		s := c.series[key]
+		samples = append(samples, Sample{Labels: labelsOf(c.labels, s.values), Value: s.count})
+	}
+	return samples
+}
+
+// Histogram counts observations, such as request latencies, into buckets
+// by upper bound, in a series for each set of values of its labels
+type Histogram struct {
+	buckets []float64
+	labels  []string
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	values []string
+	// counts has the observations up to each bucket's bound, but not
+	// those in the buckets before it, and those over the last at the end
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogram creates a histogram with the bucket bounds, in ascending
+// order, and the labels, in the order Observe is given their values
+func NewHistogram(buckets []float64, labels ...string) *Histogram {
+	return &Histogram{buckets: buckets, labels: labels, series: make(map[string]*histogramSeries)}
+}
+
+// Observe counts value into the series with the label values
+func (h *Histogram) Observe(value float64, values ...string) {
+	key := seriesKey(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{values: slices.Clone(values), counts: make([]uint64, len(h.buckets)+1)}
+		h.series[key] = s
+	}
+	i, _ := slices.BinarySearch(h.buckets, value)
+	s.counts[i]++
+	s.sum += value
+	s.count++
+}
+
+// MetricsWriter writes metrics in the Prometheus text format. The first
+// error writing stops it and is kept for Err.
+type MetricsWriter struct {
+	w   io.Writer
+	err error
+}
+
+func NewMetricsWriter(w io.Writer) *MetricsWriter {
+	return &MetricsWriter{w: w}
+}
+
+// Err returns the first error writing, if any
+func (m *MetricsWriter) Err() error {
+	return m.err
+}
+
+// Gauge writes a metric that can go up and down
+func (m *MetricsWriter) Gauge(name, help string, samples []Sample) {
+	m.family(name, help, "gauge", samples)
+}
+
+// Counter writes a metric that only goes up
+func (m *MetricsWriter) Counter(name, help string, samples []Sample) {
+	m.family(name, help, "counter", samples)
+}
+
+// Histogram writes a histogram, with a cumulative count for each bucket
+// and the sum and count of all its observations
+func (m *MetricsWriter) Histogram(name, help string, h *Histogram) {
+	m.header(name, help, "histogram")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		labels := labelsOf(h.labels, s.values)
+
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			labels["le"] = formatMetric(bound)
+			m.sample(name+"_bucket", labels, float64(cumulative))
+		}
+		labels["le"] = "+Inf"
+		m.sample(name+"_bucket", labels, float64(s.count))
+		delete(labels, "le")
+		m.sample(name+"_sum", labels, s.sum)
+		m.sample(name+"_count", labels, float64(s.count))
+	}
+}
+
+func (m *MetricsWriter) family(name, help, kind string, samples []Sample) {
+	m.header(name, help, kind)
+	for _, s := range samples {
+		m.sample(name, s.Labels, s.Value)
+	}
+}
+
+func (m *MetricsWriter) header(name, help, kind string) {
+	m.printf("# HELP %s %s\n# TYPE %s %s\n", name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help), name, kind)
+}
+
+func (m *MetricsWriter) sample(name string, labels Labels, value float64) {
+	if len(labels) == 0 {
+		m.printf("%s %s\n", name, formatMetric(value))
+		return
+	}
+
+	pairs := make([]string, 0, len(labels))
+	for _, label := range sortedKeys(labels) {
+		pairs = append(pairs, label+`="`+labelEscaper.Replace(labels[label])+`"`)
+	}
+	m.printf("%s{%s} %s\n", name, strings.Join(pairs, ","), formatMetric(value))
+}
+
+func (m *MetricsWriter) printf(format string, args ...any) {
+	if m.err == nil {
+		_, m.err = fmt.Fprintf(m.w, format, args...)
+	}
+}
+
+// labelEscaper escapes what can't appear as is in a label value
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatMetric writes a value as Prometheus reads it
+func formatMetric(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// StockSamples are the stock of every product, or of each variant of
+// products sold as variants
+func StockSamples(products []Product) []Sample {
+	var samples []Sample
+	for _, p := range products {
+		id := strconv.Itoa(p.ID)
+		if len(p.Variants) == 0 {
+			samples = append(samples, Sample{Labels: Labels{"product_id": id, "sku": p.SKU}, Value: float64(p.Stock)})
+			continue
+		}
+		for _, v := range p.Variants {
+			samples = append(samples, Sample{Labels: Labels{"product_id": id, "variant_id": strconv.Itoa(v.ID), "sku": v.SKU}, Value: float64(v.Stock)})
+		}
+	}
+	return samples
+}
+
+// OrderSamples are how many orders there are in each status, and what
+// they come to in each status and currency, in the currency's major unit
+func OrderSamples(orders []Order) (counts, totals []Sample) {
+	type statusCurrency struct {
+		status   OrderStatus
+		currency Currency
+	}
+	byStatus := make(map[OrderStatus]int)
+	byCurrency := make(map[statusCurrency]int64)
+	for _, order := range orders {
+		byStatus[order.Status]++
+		byCurrency[statusCurrency{order.Status, order.Total.Currency}] += order.Total.Amount
+	}
+
+	for _, status := range sortedKeys(byStatus) {
+		counts = append(counts, Sample{Labels: Labels{"status": string(status)}, Value: float64(byStatus[status])})
+	}
+	keys := make([]statusCurrency, 0, len(byCurrency))
+	for key := range byCurrency {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b statusCurrency) int {
+		return strings.Compare(string(a.status)+" "+string(a.currency), string(b.status)+" "+string(b.currency))
+	})
+	for _, key := range keys {
+		amount := float64(byCurrency[key]) / math.Pow10(key.currency.format().digits)
+		totals = append(totals, Sample{Labels: Labels{"status": string(key.status), "currency": string(key.currency)}, Value: amount})
+	}
+	return counts, totals
+}
+
+// seriesKey joins label values into a map key
+func seriesKey(values []string) string {
+	return strings.Join(values, "\x00")
+}
+
+// labelsOf pairs label names with their values
+func labelsOf(names, values []string) Labels {
+	labels := make(Labels, len(names))
+	for i, name := range names {
+		if i < len(values) {
+			labels[name] = values[i]
+		}
+	}
+	return labels
+}
+
+// sortedKeys returns a map's keys in order, so metrics come out the same
+// way every time
+func sortedKeys[K ~string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
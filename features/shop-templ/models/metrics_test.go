@@ -0,0 +1,78 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMetricsWriter(t *testing.T) {
+	counter := NewCounter("operation")
+	counter.Add(1, "add")
+	counter.Add(2, "add")
+	counter.Add(1, "clear")
+
+	latency := NewHistogram([]float64{0.1, 1}, "route")
+	latency.Observe(0.05, "/")
+	latency.Observe(0.1, "/")
+	latency.Observe(3, "/")
+
+	var out strings.Builder
+	m := NewMetricsWriter(&out)
+	m.Counter("shop_cart_operations_total", "Cart changes", counter.Samples())
+	m.Histogram("shop_request_seconds", "Request latency", latency)
+	m.Gauge("shop_up", "Whether the shop is up", []Sample{{Value: 1}})
+	m.Gauge("shop_label", "Escaping", []Sample{{Labels: Labels{"name": "a \"b\"\n\\"}, Value: 0.5}})
+
+	want := `# HELP shop_cart_operations_total Cart changes
+# TYPE shop_cart_operations_total counter
+shop_cart_operations_total{operation="add"} 3
+shop_cart_operations_total{operation="clear"} 1
+# HELP shop_request_seconds Request latency
+# TYPE shop_request_seconds histogram
+shop_request_seconds_bucket{le="0.1",route="/"} 2
+shop_request_seconds_bucket{le="1",route="/"} 2
+shop_request_seconds_bucket{le="+Inf",route="/"} 3
+shop_request_seconds_sum{route="/"} 3.15
+shop_request_seconds_count{route="/"} 3
+# HELP shop_up Whether the shop is up
+# TYPE shop_up gauge
+shop_up 1
+# HELP shop_label Escaping
+# TYPE shop_label gauge
+shop_label{name="a \"b\"\n\\"} 0.5
+`
+	if m.Err() != nil || out.String() != want {
+		t.Errorf("Unexpected metrics (err %v):\n%s", m.Err(), out.String())
+	}
+}
+
+func TestStockAndOrderSamples(t *testing.T) {
+	products := []Product{
+		{ID: 1, SKU: "NB-1", Stock: 4},
+		{ID: 2, SKU: "TS", Variants: []Variant{{ID: 1, SKU: "TS-S", Stock: 2}, {ID: 2, SKU: "TS-M", Stock: 0}}},
+	}
+	stock := StockSamples(products)
+	if len(stock) != 3 || stock[0].Value != 4 || stock[2].Labels["variant_id"] != "2" || stock[2].Labels["sku"] != "TS-M" {
+		t.Errorf("Unexpected stock samples: %+v", stock)
+	}
+
+	orders := []Order{
+		{Status: OrderPaid, Total: Won(30000)},
+		{Status: OrderPaid, Total: Won(12000)},
+		{Status: OrderPaid, Total: NewMoney(1999, USD)},
+		{Status: OrderCancelled, Total: Won(5000)},
+	}
+	counts, totals := OrderSamples(orders)
+	if len(counts) != 2 || counts[0].Labels["status"] != "cancelled" || counts[1].Value != 3 {
+		t.Errorf("Unexpected order counts: %+v", counts)
+	}
+	want := map[string]float64{"cancelled KRW": 5000, "paid KRW": 42000, "paid USD": 19.99}
+	if len(totals) != len(want) {
+		t.Fatalf("Expected %d totals, got %+v", len(want), totals)
+	}
+	for _, total := range totals {
+		if got := want[total.Labels["status"]+" "+total.Labels["currency"]]; got != total.Value {
+			t.Errorf("Expected %v for %v, got %v", got, total.Labels, total.Value)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidMoney is returned for an amount ParseMoney can't read
+var ErrInvalidMoney = errors.New("invalid amount")
+
+// Currency is an ISO 4217 currency code
+type Currency string
+
+const (
+	KRW Currency = "KRW"
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	JPY Currency = "JPY"
+)
+
+// Currencies are the currencies shoppers can see prices in
+var Currencies = []Currency{KRW, USD, EUR, JPY}
+
+// DefaultCurrency is the currency the catalog is priced in
+const DefaultCurrency = KRW
+
+// currencyFormat is how amounts in a currency are written
+type currencyFormat struct {
+	symbol string
+	// digits is how many minor units make up the major unit, as a power of ten
+	digits int
+}
+
+var currencyFormats = map[Currency]currencyFormat{
+	KRW: {symbol: "₩", digits: 0},
+	USD: {symbol: "$", digits: 2},
+	EUR: {symbol: "€", digits: 2},
+	JPY: {symbol: "¥", digits: 0},
+}
+
+// ParseCurrency returns the currency with the given code, if it is one of
+// Currencies
+func ParseCurrency(code string) (Currency, bool) {
+	currency := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	_, ok := currencyFormats[currency]
+	return currency, ok
+}
+
+// Symbol returns the sign amounts in the currency are written with
+func (c Currency) Symbol() string {
+	return strings.TrimSpace(c.format().symbol)
+}
+
+// format returns how amounts in the currency are written. The empty
+// currency of a zero Money is written as DefaultCurrency.
+func (c Currency) format() currencyFormat {
+	if c == "" {
+		c = DefaultCurrency
+	}
+	if f, ok := currencyFormats[c]; ok {
+		return f
+	}
+	return currencyFormat{symbol: string(c) + " ", digits: 2}
+}
+
+// Money is an exact amount of money in the currency's minor unit: won for
+// KRW, cents for USD. Amounts in different currencies can't be mixed;
+// arithmetic on them panics, like an out-of-range slice index, since it
+// means prices were put together wrongly.
+//
+// The zero Money is zero in no particular currency, so it can start a sum
+// of amounts in any currency.
+type Money struct {
+	Amount   int64    `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// NewMoney returns amount minor units of currency
+func NewMoney(amount int64, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Won returns an amount in Korean won
+func Won(amount int64) Money {
+	return Money{Amount: amount, Currency: KRW}
+}
+
+// ParseMoney reads a decimal amount in the major unit, such as "58.74"
+// dollars or "20000" won. It rejects more decimal places than the
+// currency has rather than rounding them away.
+func ParseMoney(s string, currency Currency) (Money, error) {
+	s = strings.TrimSpace(s)
+	digits := currency.format().digits
+
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	negative := strings.HasPrefix(whole, "-")
+	whole = strings.TrimPrefix(whole, "-")
+	if whole == "" || !isDigits(whole) || len(frac) > digits || (hasFrac && (frac == "" || !isDigits(frac))) {
+		return Money{}, ErrInvalidMoney
+	}
+
+	amount, err := strconv.ParseInt(whole+frac+strings.Repeat("0", digits-len(frac)), 10, 64)
+	if err != nil {
+		return Money{}, ErrInvalidMoney
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// isDigits reports whether s is only ASCII digits
+func isDigits(s string) bool {
+	return strings.Trim(s, "0123456789") == ""
+}
+
+// IsZero reports whether the amount is zero, in any currency
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Add returns m + o
+func (m Money) Add(o Money) Money {
+	return Money{Amount: m.Amount + o.Amount, Currency: m.common(o)}
+}
+
+// Sub returns m - o
+func (m Money) Sub(o Money) Money {
+	return Money{Amount: m.Amount - o.Amount, Currency: m.common(o)}
+}
+
+// Mul returns m times n, such as a price times a quantity
+func (m Money) Mul(n int) Money {
+	return Money{Amount: m.Amount * int64(n), Currency: m.Currency}
+}
+
+// Percent returns percent of m, rounded down to the minor unit, so a
+// percentage discount never takes off more than it says
+func (m Money) Percent(percent int) Money {
+	return Money{Amount: m.Amount * int64(percent) / 100, Currency: m.Currency}
+}
+
+// BasisPoints returns bp hundredths of a percent of m, such as 1000 for
+// 10%, rounded to the nearest minor unit with halves rounded up
+func (m Money) BasisPoints(bp int) Money {
+	n := m.Amount * int64(bp)
+	amount := n / 10000
+	if rem := n % 10000; rem*2 >= 10000 {
+		amount++
+	} else if rem*2 <= -10000 {
+		amount--
+	}
+	return Money{Amount: amount, Currency: m.Currency}
+}
+
+// Convert returns m in another currency at rate, the units of to that one
+// unit of m's currency buys, rounded to the nearest minor unit of to
+func (m Money) Convert(to Currency, rate float64) Money {
+	major := float64(m.Amount) / math.Pow10(m.Currency.format().digits)
+	amount := math.Round(major * rate * math.Pow10(to.format().digits))
+	return Money{Amount: int64(amount), Currency: to}
+}
+
+// Cmp compares m and o, returning -1, 0 or +1
+func (m Money) Cmp(o Money) int {
+	m.common(o)
+	switch {
+	case m.Amount < o.Amount:
+		return -1
+	case m.Amount > o.Amount:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// common returns the currency of an operation on m and o. A zero Money
+// with no currency takes on the other's.
+func (m Money) common(o Money) Currency {
+	switch {
+	case m.Currency == o.Currency || o.Currency == "":
+		return m.Currency
+	case m.Currency == "":
+		return o.Currency
+	default:
+		panic(fmt.Sprintf("models: mixing %s and %s amounts", m.Currency, o.Currency))
+	}
+}
+
+// Decimal returns the amount in the major unit without a symbol or
+// separators, such as "58.74", as ParseMoney reads it
+func (m Money) Decimal() string {
+	digits := m.Currency.format().digits
+	s := strconv.FormatInt(abs(m.Amount), 10)
+	if digits > 0 {
+		s = fmt.Sprintf("%0*s", digits+1, s)
+		s = s[:len(s)-digits] + "." + s[len(s)-digits:]
+	}
+	if m.Amount < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// String returns the amount as shown to shoppers, such as "₩129,000" or
+// "$58.74"
+func (m Money) String() string {
+	sign := ""
+	if m.Amount < 0 {
+		sign = "-"
+	}
+	return sign + m.Currency.format().symbol + m.grouped()
+}
+
+// grouped returns the size of the amount in the major unit with its
+// digits grouped in thousands, such as "129,000" or "58.74"
+func (m Money) grouped() string {
+	whole, frac, _ := strings.Cut(strings.TrimPrefix(m.Decimal(), "-"), ".")
+
+	var b strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	if frac != "" {
+		b.WriteString("." + frac)
+	}
+	return b.String()
+}
+
+// UnmarshalJSON reads an amount written as an object. A bare number is
+// read as won, the way prices were saved before they were Money.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var legacy float64
+	if err := json.Unmarshal(data, &legacy); err == nil {
+		*m = Won(int64(math.Round(legacy)))
+		return nil
+	}
+
+	type money Money
+	return json.Unmarshal(data, (*money)(m))
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
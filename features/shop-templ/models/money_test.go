@@ -0,0 +1,186 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// usd returns an amount in US cents, for tests with prices that have cents
+func usd(cents int64) Money {
+	return NewMoney(cents, USD)
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	// 0.1 + 0.2 is the classic float64 rounding error
+	if got := usd(10).Add(usd(20)); got != usd(30) {
+		t.Errorf("Expected $0.30, got %v", got)
+	}
+	if got := Won(50000).Sub(Won(4000)); got != Won(46000) {
+		t.Errorf("Expected ₩46,000, got %v", got)
+	}
+	if got := usd(1999).Mul(3); got != usd(5997) {
+		t.Errorf("Expected $59.97, got %v", got)
+	}
+	if got := (Money{}).Add(Won(1000)); got != Won(1000) {
+		t.Errorf("Expected the zero Money to take on the currency, got %+v", got)
+	}
+}
+
+func TestMoneyPercent(t *testing.T) {
+	tests := []struct {
+		money   Money
+		percent int
+		want    Money
+	}{
+		{Won(35000), 10, Won(3500)},
+		{Won(999), 15, Won(149)},
+		{usd(1999), 10, usd(199)},
+		{usd(5), 50, usd(2)},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.Percent(tt.percent); got != tt.want {
+			t.Errorf("%d%% of %v: expected %v, got %v", tt.percent, tt.money, tt.want, got)
+		}
+	}
+}
+
+func TestMoneyMixedCurrencies(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected adding won to dollars to panic")
+		}
+	}()
+
+	Won(1000).Add(usd(100))
+}
+
+func TestMoneyCmp(t *testing.T) {
+	if Won(1000).Cmp(Won(2000)) != -1 || Won(2000).Cmp(Won(1000)) != 1 || Won(1000).Cmp(Won(1000)) != 0 {
+		t.Error("Unexpected comparison of won amounts")
+	}
+	if (Money{}).Cmp(Won(1)) != -1 {
+		t.Error("Expected the zero Money to compare with any currency")
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{Won(129000), "₩129,000"},
+		{Won(1000000), "₩1,000,000"},
+		{Won(999), "₩999"},
+		{Won(-4000), "-₩4,000"},
+		{usd(5874), "$58.74"},
+		{usd(5), "$0.05"},
+		{usd(123456789), "$1,234,567.89"},
+		{Money{}, "₩0"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("Expected %q, got %q", tt.want, got)
+		}
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input    string
+		currency Currency
+		want     Money
+		err      error
+	}{
+		{"20000", KRW, Won(20000), nil},
+		{"58.74", USD, usd(5874), nil},
+		{"58.7", USD, usd(5870), nil},
+		{"58", USD, usd(5800), nil},
+		{"-1.50", USD, usd(-150), nil},
+		{"58.745", USD, Money{}, ErrInvalidMoney},
+		{"1000.5", KRW, Money{}, ErrInvalidMoney},
+		{"58.", USD, Money{}, ErrInvalidMoney},
+		{"1e3", KRW, Money{}, ErrInvalidMoney},
+		{"", KRW, Money{}, ErrInvalidMoney},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.input, tt.currency)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("ParseMoney(%q, %s) = %v, %v; want %v, %v", tt.input, tt.currency, got, err, tt.want, tt.err)
+		}
+	}
+
+	for _, m := range []Money{usd(5874), usd(5), Won(20000)} {
+		if got, _ := ParseMoney(m.Decimal(), m.Currency); got != m {
+			t.Errorf("Expected %v to survive Decimal and ParseMoney, got %v", m, got)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(usd(5874))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m Money
+	if err := json.Unmarshal(data, &m); err != nil || m != usd(5874) {
+		t.Errorf("Expected $58.74 to survive JSON, got %v, %v", m, err)
+	}
+
+	// Carts saved before prices were Money have bare numbers in won
+	if err := json.Unmarshal([]byte("129000"), &m); err != nil || m != Won(129000) {
+		t.Errorf("Expected a bare number to be read as won, got %v, %v", m, err)
+	}
+}
+
+func TestMoneyConvert(t *testing.T) {
+	tests := []struct {
+		money Money
+		to    Currency
+		rate  float64
+		want  Money
+	}{
+		{Won(129000), USD, 0.00072, usd(9288)},
+		{Won(1000), USD, 0.000725, usd(73)}, // 72.5 cents rounds up
+		{usd(5874), KRW, 1380, Won(81061)},
+		{Won(35000), JPY, 0.11, NewMoney(3850, JPY)},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.Convert(tt.to, tt.rate); got != tt.want {
+			t.Errorf("%v to %s at %v: expected %v, got %v", tt.money, tt.to, tt.rate, tt.want, got)
+		}
+	}
+}
+
+func TestParseCurrency(t *testing.T) {
+	if currency, ok := ParseCurrency(" usd "); !ok || currency != USD {
+		t.Errorf("Expected USD, got %q, %v", currency, ok)
+	}
+	if _, ok := ParseCurrency("XYZ"); ok {
+		t.Error("Expected an unknown currency to be rejected")
+	}
+}
+
+func TestMoneyBasisPoints(t *testing.T) {
+	tests := []struct {
+		money Money
+		bp    int
+		want  Money
+	}{
+		{Won(36000), 1000, Won(3600)},
+		{Won(12345), 1000, Won(1235)}, // 1234.5 rounds up
+		{Won(12344), 1000, Won(1234)},
+		{usd(1999), 875, usd(175)},
+		{Won(-12345), 1000, Won(-1235)},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.BasisPoints(tt.bp); got != tt.want {
+			t.Errorf("%d bp of %v: expected %v, got %v", tt.bp, tt.money, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Notification is a message shown to a user on the site, such as that a
+// product they wanted is back in stock
+type Notification struct {
+	ID      int
+	UserID  int
+	Message string
+	// URL is where the notification leads, if anywhere
+	URL       string
+	CreatedAt time.Time
+	Read      bool
+}
+
+// NotificationStore keeps users' notifications with thread-safe operations
+type NotificationStore struct {
+	mu            sync.RWMutex
+	notifications map[int][]Notification
+	nextID        int
+}
+
+// NewNotificationStore creates a new notification store
+func NewNotificationStore() *NotificationStore {
+	return &NotificationStore{
+		notifications: make(map[int][]Notification),
+		nextID:        1,
+	}
+}
+
+// Add stores an unread notification, assigning its ID and creation time
+func (s *NotificationStore) Add(n Notification) Notification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n.ID = s.nextID
+	s.nextID++
+	n.CreatedAt = time.Now()
+	n.Read = false
+	s.notifications[n.UserID] = append(s.notifications[n.UserID], n)
+
+	return n
+}
+
+// ForUser returns a user's notifications, newest first
+func (s *NotificationStore) ForUser(userID int) []Notification {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	notifications := slices.Clone(s.notifications[userID])
+	slices.Reverse(notifications)
+	return notifications
+}
+
+// Unread returns how many of a user's notifications they haven't seen
+func (s *NotificationStore) Unread(userID int) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	unread := 0
+	for _, n := range s.notifications[userID] {
+		if !n.Read {
+			unread++
+		}
+	}
+	return unread
+}
+
+// MarkRead marks all of a user's notifications as seen
+func (s *NotificationStore) MarkRead(userID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.notifications[userID] {
+		s.notifications[userID][i].Read = true
+	}
+}
+
+type unreadContextKey struct{}
+
+// ContextWithUnread returns a copy of ctx carrying how many notifications
+// the logged-in user hasn't seen
+func ContextWithUnread(ctx context.Context, unread int) context.Context {
+	return context.WithValue(ctx, unreadContextKey{}, unread)
+}
+
+// UnreadFromContext returns how many notifications the logged-in user
+// hasn't seen, or zero if no one is logged in
+func UnreadFromContext(ctx context.Context) int {
+	unread, _ := ctx.Value(unreadContextKey{}).(int)
+	return unread
+}
@@ -0,0 +1,447 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrEmptyCart is returned when placing an order with nothing in the cart
+	ErrEmptyCart = errors.New("cart is empty")
+	// ErrShippingIncomplete is returned when shipping info is missing a required field
+	ErrShippingIncomplete = errors.New("shipping name, phone and address are required")
+	// ErrGuestEmailRequired is returned when a guest orders without an email,
+	// which they need to look the order up again
+	ErrGuestEmailRequired = errors.New("guest orders need an email")
+	// ErrUnsupportedCountry is returned for a country the shop doesn't ship to
+	ErrUnsupportedCountry = errors.New("the shop doesn't ship to this country")
+	// ErrOrderNotFound is returned for an order ID that doesn't exist
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrOrderStatus is returned for a status change the order's current
+	// status doesn't allow
+	ErrOrderStatus = errors.New("order can't change to this status")
+	// ErrOrderClaimed is returned when moving an order that already belongs
+	// to an account to another
+	ErrOrderClaimed = errors.New("order already belongs to an account")
+)
+
+// OrderStatus is where an order is in its life
+type OrderStatus string
+
+const (
+	// OrderPending orders are placed but not yet paid for
+	OrderPending OrderStatus = "pending"
+	// OrderPaid orders have had their payment captured
+	OrderPaid OrderStatus = "paid"
+	// OrderPacked orders are packed and waiting for the carrier
+	OrderPacked OrderStatus = "packed"
+	// OrderShipped orders are on their way
+	OrderShipped OrderStatus = "shipped"
+	// OrderDelivered orders have arrived
+	OrderDelivered OrderStatus = "delivered"
+	// OrderCancelled orders were called off before they shipped, and their
+	// payment given back if there was one
+	OrderCancelled OrderStatus = "cancelled"
+	// OrderRefunded orders have had all of their payment given back
+	OrderRefunded OrderStatus = "refunded"
+)
+
+// orderTransitions are the statuses an order can move to from each status.
+// Orders can be cancelled until they ship, and refunded once paid for
+// except while on their way.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderPending:   {OrderPaid, OrderCancelled},
+	OrderPaid:      {OrderPacked, OrderCancelled, OrderRefunded},
+	OrderPacked:    {OrderShipped, OrderCancelled, OrderRefunded},
+	OrderShipped:   {OrderDelivered},
+	OrderDelivered: {OrderRefunded},
+}
+
+// OrderStatusChange records when an order moved to a status
+type OrderStatusChange struct {
+	Status OrderStatus `json:"status"`
+	At     time.Time   `json:"at"`
+}
+
+// DefaultCountry is where orders ship to unless another country is chosen
+const DefaultCountry = "KR"
+
+// ShippingCountries are the ISO 3166 codes of the countries the shop ships to
+var ShippingCountries = []string{DefaultCountry, "US", "JP", "CN"}
+
+// ShippingInfo is where and to whom an order is delivered
+type ShippingInfo struct {
+	Name    string `json:"name"`
+	Phone   string `json:"phone"`
+	Address string `json:"address"`
+	// Country is where the order ships to; empty means DefaultCountry
+	Country string `json:"country,omitempty"`
+	// Method is the shipping method chosen; empty means the default
+	Method string `json:"method,omitempty"`
+	Memo   string `json:"memo,omitempty"`
+	// Email is where emails about the order go; without one none are sent
+	Email string `json:"email,omitempty"`
+	// Billing is the card's billing address, if the customer gave one
+	Billing string `json:"billing,omitempty"`
+}
+
+// Destination returns the country the order ships to
+func (s ShippingInfo) Destination() string {
+	if s.Country == "" {
+		return DefaultCountry
+	}
+	return s.Country
+}
+
+// Validate checks that every required field is filled in, that the email
+// is an address if there is one and that the shop ships to the country
+func (s ShippingInfo) Validate() error {
+	if strings.TrimSpace(s.Name) == "" || strings.TrimSpace(s.Phone) == "" || strings.TrimSpace(s.Address) == "" {
+		return ErrShippingIncomplete
+	}
+	if addr, err := mail.ParseAddress(s.Email); s.Email != "" && (err != nil || addr.Address != s.Email) {
+		return ErrInvalidEmail
+	}
+	if !slices.Contains(ShippingCountries, s.Destination()) {
+		return ErrUnsupportedCountry
+	}
+	return nil
+}
+
+// OrderItem is a product as it was when the order was placed. The name and
+// price are copied so later changes to the product don't alter the order.
+type OrderItem struct {
+	ProductID int `json:"productId"`
+	// VariantID, SKU and Variant, the variant's values, are set for
+	// products that come in variants
+	VariantID int    `json:"variantId,omitempty"`
+	SKU       string `json:"sku,omitempty"`
+	Variant   string `json:"variant,omitempty"`
+	Name      string `json:"name"`
+	Category  string `json:"category,omitempty"`
+	Price     Money  `json:"price"`
+	Quantity  int    `json:"quantity"`
+}
+
+// Key returns the product and variant of the line
+func (i OrderItem) Key() ItemKey {
+	return ItemKey{ProductID: i.ProductID, VariantID: i.VariantID}
+}
+
+// Subtotal returns the price of the line
+func (i OrderItem) Subtotal() Money {
+	return i.Price.Mul(i.Quantity)
+}
+
+// Order represents a placed order
+type Order struct {
+	ID int `json:"id"`
+	// UserID is the account that placed the order, or 0 for a guest
+	UserID int `json:"userId,omitempty"`
+	// CartID is the cart the order was placed from
+	CartID   string       `json:"cartId"`
+	Items    []OrderItem  `json:"items"`
+	Shipping ShippingInfo `json:"shipping"`
+	// Bundles are what the bundles put in the cart saved on the order
+	Bundles []BundleLine `json:"bundles,omitempty"`
+	// CouponCode is the coupon used on the order, if any
+	CouponCode string `json:"couponCode,omitempty"`
+	// Discount is how much the coupon took off the subtotal, after bundle
+	// savings
+	Discount Money `json:"discount,omitzero"`
+	// PointsUsed are the points paid with, each taking a won off the total
+	PointsUsed int `json:"pointsUsed,omitempty"`
+	// PointsEarned are the points the order earns once it is paid for
+	PointsEarned int `json:"pointsEarned,omitempty"`
+	// Delivery is how the order ships and the fee charged for it
+	Delivery ShippingOption `json:"delivery,omitzero"`
+	// Taxes are the taxes charged on the order, after the discount
+	Taxes  TaxLines    `json:"taxes,omitempty"`
+	Total  Money       `json:"total"`
+	Status OrderStatus `json:"status"`
+	// History is every status the order has had, oldest first
+	History []OrderStatusChange `json:"history"`
+	// Refunds are the payment given back, oldest first
+	Refunds []RefundLine `json:"refunds,omitempty"`
+	// PaymentID is the payment provider's ID for the order's payment
+	PaymentID string    `json:"paymentId,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Subtotal returns the order's total before the discount
+func (o Order) Subtotal() Money {
+	var subtotal Money
+	for _, item := range o.Items {
+		subtotal = subtotal.Add(item.Subtotal())
+	}
+	return subtotal
+}
+
+// BundleDiscount returns what the order's bundles saved altogether
+func (o Order) BundleDiscount() Money {
+	return BundleSavings(o.Bundles)
+}
+
+// PointsDiscount returns what the points used took off the total. Points
+// are worth a won each, so they are only used on orders in won.
+func (o Order) PointsDiscount() Money {
+	if o.PointsUsed == 0 {
+		return Money{}
+	}
+	return Won(int64(o.PointsUsed))
+}
+
+// TotalDue returns what the customer pays: the subtotal less bundle
+// savings and the discount, plus the shipping fee and taxes, less the
+// points used
+func (o Order) TotalDue() Money {
+	return o.Subtotal().Sub(o.BundleDiscount()).Sub(o.Discount).Add(o.Delivery.Fee).Add(o.Taxes.Total()).Sub(o.PointsDiscount())
+}
+
+// IsGuest reports whether the order was placed without an account
+func (o Order) IsGuest() bool {
+	return o.UserID == 0
+}
+
+// HasEmail reports whether email is the one the order was placed with,
+// ignoring case and surrounding space. Orders without an email have none.
+func (o Order) HasEmail(email string) bool {
+	email = normalizeEmail(email)
+	return email != "" && normalizeEmail(o.Shipping.Email) == email
+}
+
+// NextStatuses returns the statuses the order can move to
+func (o Order) NextStatuses() []OrderStatus {
+	return orderTransitions[o.Status]
+}
+
+// CanBecome reports whether the order can move to status
+func (o Order) CanBecome(status OrderStatus) bool {
+	return slices.Contains(o.NextStatuses(), status)
+}
+
+// ItemCount returns the total number of items in the order
+func (o Order) ItemCount() int {
+	count := 0
+	for _, item := range o.Items {
+		count += item.Quantity
+	}
+	return count
+}
+
+// OrderStore keeps placed orders with thread-safe operations
+type OrderStore struct {
+	mu     sync.RWMutex
+	orders map[int]Order
+	nextID int
+	// onStatus, if set, is called with orders as they are placed and change
+	// status
+	onStatus func(Order)
+	// repo, if set, is where orders are kept between restarts
+	repo OrderRepository
+}
+
+// NewOrderStore creates a new order store
+func NewOrderStore() *OrderStore {
+	return &OrderStore{
+		orders: make(map[int]Order),
+		nextID: 1,
+	}
+}
+
+// NewPersistentOrderStore loads orders from repo and writes every change
+// to them back to it
+func NewPersistentOrderStore(repo OrderRepository) (*OrderStore, error) {
+	orders, err := repo.LoadOrders()
+	if err != nil {
+		return nil, fmt.Errorf("load orders: %w", err)
+	}
+
+	s := NewOrderStore()
+	s.repo = repo
+	for _, order := range orders {
+		s.orders[order.ID] = order
+		s.nextID = max(s.nextID, order.ID+1)
+	}
+
+	return s, nil
+}
+
+// putUnlocked stores an order, writing it through to the repository first
+// if the store has one. If the write fails nothing changes, as for
+// products. The caller must hold s.mu.
+func (s *OrderStore) putUnlocked(order Order) error {
+	if s.repo != nil {
+		if err := s.repo.SaveOrder(order); err != nil {
+			return fmt.Errorf("%w: save order %d: %w", ErrNotSaved, order.ID, err)
+		}
+	}
+	s.orders[order.ID] = order
+	return nil
+}
+
+// Add stores an order, assigning its ID, creation time and total. Orders
+// without a status are pending.
+func (s *OrderStore) Add(order Order) (Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order.ID = s.nextID
+	order.CreatedAt = time.Now()
+	order.Total = order.TotalDue()
+	if order.Status == "" {
+		order.Status = OrderPending
+	}
+	order.History = []OrderStatusChange{{Status: order.Status, At: order.CreatedAt}}
+	if err := s.putUnlocked(order); err != nil {
+		return Order{}, err
+	}
+	s.nextID++
+	s.statusChanged(order)
+
+	return order, nil
+}
+
+// GetByID retrieves an order by its ID
+func (s *OrderStore) GetByID(id int) (Order, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	order, exists := s.orders[id]
+	return order, exists
+}
+
+// SetStatus moves an order to status, recording when. Setting the status
+// it already has does nothing, so payment events can be applied more than
+// once.
+func (s *OrderStore) SetStatus(id int, status OrderStatus) (Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, exists := s.orders[id]
+	if !exists {
+		return Order{}, ErrOrderNotFound
+	}
+	if order.Status == status {
+		return order, nil
+	}
+	if !order.CanBecome(status) {
+		return order, fmt.Errorf("%w: %s to %s", ErrOrderStatus, order.Status, status)
+	}
+
+	order.moveTo(status)
+	if err := s.putUnlocked(order); err != nil {
+		return Order{}, err
+	}
+	s.statusChanged(order)
+	return order, nil
+}
+
+// OnStatusChange sets fn to be called with every order placed from now on
+// and every time an order's status changes, such as to email the customer.
+// It is called in a goroutine of its own, so it may be slow; calls for the
+// same order may arrive out of order if its status changes quickly.
+func (s *OrderStore) OnStatusChange(fn func(Order)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onStatus = fn
+}
+
+// statusChanged reports an order's new status to onStatus. The caller must
+// hold s.mu.
+func (s *OrderStore) statusChanged(order Order) {
+	if s.onStatus != nil {
+		go s.onStatus(order)
+	}
+}
+
+// moveTo changes the order's status and records when
+func (o *Order) moveTo(status OrderStatus) {
+	o.Status = status
+	o.History = append(slices.Clip(o.History), OrderStatusChange{Status: status, At: time.Now()})
+}
+
+// ByPayment finds the order paid for by a payment
+func (s *OrderStore) ByPayment(paymentID string) (Order, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, order := range s.orders {
+		if paymentID != "" && order.PaymentID == paymentID {
+			return order, true
+		}
+	}
+	return Order{}, false
+}
+
+// All returns every order, newest first
+func (s *OrderStore) All() []Order {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	orders := make([]Order, 0, len(s.orders))
+	for _, order := range s.orders {
+		orders = append(orders, order)
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID > orders[j].ID })
+
+	return orders
+}
+
+// ByUser returns the orders an account placed, newest first
+func (s *OrderStore) ByUser(userID int) []Order {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	orders := make([]Order, 0)
+	for _, order := range s.orders {
+		if userID != 0 && order.UserID == userID {
+			orders = append(orders, order)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID > orders[j].ID })
+
+	return orders
+}
+
+// Lookup finds a guest order by its ID and the email it was placed with,
+// for guests to see their order again. Orders placed with an account are
+// seen by logging in instead.
+func (s *OrderStore) Lookup(id int, email string) (Order, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	order, exists := s.orders[id]
+	if !exists || !order.IsGuest() || !order.HasEmail(email) {
+		return Order{}, false
+	}
+	return order, true
+}
+
+// AssignUser moves a guest order to an account, as when the guest signs up
+// afterwards
+func (s *OrderStore) AssignUser(id, userID int) (Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, exists := s.orders[id]
+	if !exists {
+		return Order{}, ErrOrderNotFound
+	}
+	if !order.IsGuest() {
+		return order, ErrOrderClaimed
+	}
+
+	order.UserID = userID
+	if err := s.putUnlocked(order); err != nil {
+		return Order{}, err
+	}
+	return order, nil
+}
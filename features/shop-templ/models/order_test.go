@@ -0,0 +1,276 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestShippingInfoValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		shipping ShippingInfo
+		valid    bool
+	}{
+		{"complete", ShippingInfo{Name: "홍길동", Phone: "010-1234-5678", Address: "서울시"}, true},
+		{"memo is optional", ShippingInfo{Name: "홍길동", Phone: "010", Address: "서울시", Memo: ""}, true},
+		{"missing name", ShippingInfo{Phone: "010", Address: "서울시"}, false},
+		{"blank phone", ShippingInfo{Name: "홍길동", Phone: "  ", Address: "서울시"}, false},
+		{"missing address", ShippingInfo{Name: "홍길동", Phone: "010"}, false},
+		{"abroad", ShippingInfo{Name: "Kim", Phone: "010", Address: "Tokyo", Country: "JP"}, true},
+		{"with email", ShippingInfo{Name: "홍길동", Phone: "010", Address: "서울시", Email: "hong@example.com"}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.shipping.Validate()
+		if tt.valid && err != nil {
+			t.Errorf("%s: expected valid, got %v", tt.name, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrShippingIncomplete) {
+			t.Errorf("%s: expected ErrShippingIncomplete, got %v", tt.name, err)
+		}
+	}
+
+	for _, email := range []string{"hong", "홍길동 <hong@example.com>"} {
+		shipping := ShippingInfo{Name: "홍길동", Phone: "010", Address: "서울시", Email: email}
+		if err := shipping.Validate(); !errors.Is(err, ErrInvalidEmail) {
+			t.Errorf("Expected ErrInvalidEmail for %q, got %v", email, err)
+		}
+	}
+
+	abroad := ShippingInfo{Name: "Kim", Phone: "010", Address: "Paris", Country: "FR"}
+	if err := abroad.Validate(); !errors.Is(err, ErrUnsupportedCountry) {
+		t.Errorf("Expected ErrUnsupportedCountry, got %v", err)
+	}
+}
+
+func TestOrderStoreAdd(t *testing.T) {
+	store := NewOrderStore()
+
+	order, _ := store.Add(Order{Items: []OrderItem{
+		{ProductID: 1, Name: "P1", Price: usd(1000), Quantity: 2},
+		{ProductID: 2, Name: "P2", Price: usd(550), Quantity: 1},
+	}})
+	if order.ID == 0 {
+		t.Error("Added order should have a non-zero ID")
+	}
+	if order.CreatedAt.IsZero() {
+		t.Error("Added order should have a creation time")
+	}
+	if order.Total != usd(2550) {
+		t.Errorf("Expected total 25.50, got %v", order.Total)
+	}
+	if order.ItemCount() != 3 {
+		t.Errorf("Expected 3 items, got %d", order.ItemCount())
+	}
+
+	found, exists := store.GetByID(order.ID)
+	if !exists || found.Total != order.Total {
+		t.Errorf("Expected to find order %d", order.ID)
+	}
+	if _, exists := store.GetByID(999); exists {
+		t.Error("Should not find non-existent order")
+	}
+}
+
+func TestOrderStoreAddWithDiscount(t *testing.T) {
+	store := NewOrderStore()
+
+	order, _ := store.Add(Order{
+		Items:      []OrderItem{{ProductID: 1, Name: "P1", Price: Won(20000), Quantity: 2}},
+		CouponCode: "WELCOME10",
+		Discount:   Won(4000),
+	})
+	if order.Subtotal() != Won(40000) {
+		t.Errorf("Expected subtotal 40000, got %v", order.Subtotal())
+	}
+	if order.Total != Won(36000) {
+		t.Errorf("Expected the discount taken off the total, got %v", order.Total)
+	}
+}
+
+func TestOrdersByUser(t *testing.T) {
+	store := NewOrderStore()
+	first, _ := store.Add(Order{UserID: 1})
+	store.Add(Order{UserID: 2})
+	store.Add(Order{})
+	second, _ := store.Add(Order{UserID: 1})
+
+	orders := store.ByUser(1)
+	if len(orders) != 2 || orders[0].ID != second.ID || orders[1].ID != first.ID {
+		t.Errorf("Expected user 1's orders newest first, got %+v", orders)
+	}
+	if len(store.ByUser(0)) != 0 {
+		t.Error("Guest orders should not belong to any user")
+	}
+}
+
+func TestOrderStoreAddWithTaxes(t *testing.T) {
+	store := NewOrderStore()
+
+	order, _ := store.Add(Order{
+		Items:    []OrderItem{{ProductID: 1, Name: "P1", Price: Won(20000), Quantity: 2}},
+		Discount: Won(4000),
+		Taxes:    TaxLines{{Name: "부가세", Rate: 1000, Taxable: Won(36000), Amount: Won(3600)}},
+	})
+	if order.Total != Won(39600) {
+		t.Errorf("Expected taxes added to the discounted total, got %v", order.Total)
+	}
+}
+
+func TestOrderStoreAddWithShipping(t *testing.T) {
+	store := NewOrderStore()
+
+	order, _ := store.Add(Order{
+		Items:    []OrderItem{{ProductID: 1, Name: "P1", Price: Won(20000), Quantity: 1}},
+		Delivery: ShippingOption{Method: "express", Name: "빠른 배송", Fee: Won(5000)},
+		Taxes:    TaxLines{{Name: "부가세", Rate: 1000, Taxable: Won(20000), Amount: Won(2000)}},
+	})
+	if order.Total != Won(27000) {
+		t.Errorf("Expected the shipping fee added to the total, got %v", order.Total)
+	}
+}
+
+func TestOrderStoreSetStatus(t *testing.T) {
+	store := NewOrderStore()
+	order, _ := store.Add(Order{Items: []OrderItem{{ProductID: 1, Price: Won(1000), Quantity: 1}}, PaymentID: "pay_1"})
+	if order.Status != OrderPending {
+		t.Fatalf("Expected a new order to be pending, got %q", order.Status)
+	}
+
+	if _, err := store.SetStatus(order.ID, OrderRefunded); !errors.Is(err, ErrOrderStatus) {
+		t.Errorf("Expected an unpaid order not to be refunded, got %v", err)
+	}
+	if order, err := store.SetStatus(order.ID, OrderPaid); err != nil || order.Status != OrderPaid {
+		t.Errorf("Expected the order paid, got %q, %v", order.Status, err)
+	}
+	// Webhooks may report a payment again
+	if _, err := store.SetStatus(order.ID, OrderPaid); err != nil {
+		t.Errorf("Expected setting the same status to succeed, got %v", err)
+	}
+	if _, err := store.SetStatus(99, OrderPaid); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("Expected ErrOrderNotFound, got %v", err)
+	}
+
+	if found, ok := store.ByPayment("pay_1"); !ok || found.ID != order.ID {
+		t.Errorf("Expected to find the order by its payment, got %+v", found)
+	}
+}
+
+func TestOrderStatusWorkflow(t *testing.T) {
+	store := NewOrderStore()
+	order, _ := store.Add(Order{Items: []OrderItem{{ProductID: 1, Price: Won(1000), Quantity: 1}}})
+
+	for _, status := range []OrderStatus{OrderPaid, OrderPacked, OrderShipped, OrderDelivered, OrderRefunded} {
+		var err error
+		if order, err = store.SetStatus(order.ID, status); err != nil {
+			t.Fatalf("Expected the order to move to %s, got %v", status, err)
+		}
+	}
+
+	if len(order.History) != 6 || order.History[0].Status != OrderPending || order.History[5].Status != OrderRefunded {
+		t.Fatalf("Expected every status recorded, got %+v", order.History)
+	}
+	for i := 1; i < len(order.History); i++ {
+		if order.History[i].At.Before(order.History[i-1].At) {
+			t.Errorf("Expected status changes in time order, got %+v", order.History)
+		}
+	}
+	if len(order.NextStatuses()) != 0 {
+		t.Errorf("Expected nothing after a refund, got %v", order.NextStatuses())
+	}
+}
+
+func TestOrderStoreOnStatusChange(t *testing.T) {
+	store := NewOrderStore()
+	changes := make(chan OrderStatus, 10)
+	store.OnStatusChange(func(order Order) { changes <- order.Status })
+
+	order, _ := store.Add(Order{Items: []OrderItem{{ProductID: 1, Price: Won(1000), Quantity: 1}}})
+	store.SetStatus(order.ID, OrderPaid)
+	store.SetStatus(order.ID, OrderPaid)
+	store.SetStatus(order.ID, OrderDelivered)
+	store.Cancel(order.ID, Money{})
+
+	// Each change arrives, in whatever order; repeats and refused changes
+	// aren't changes
+	got := make(map[OrderStatus]int)
+	for range 3 {
+		select {
+		case status := <-changes:
+			got[status]++
+		case <-time.After(time.Second):
+			t.Fatalf("Expected 3 changes, got %v", got)
+		}
+	}
+	if got[OrderPending] != 1 || got[OrderPaid] != 1 || got[OrderCancelled] != 1 {
+		t.Errorf("Expected pending, paid and cancelled, got %v", got)
+	}
+	select {
+	case status := <-changes:
+		t.Errorf("Expected no more changes, got %s", status)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestOrderStatusInvalidTransitions(t *testing.T) {
+	tests := []struct {
+		from, to OrderStatus
+	}{
+		{OrderPending, OrderShipped},
+		{OrderShipped, OrderCancelled},
+		{OrderShipped, OrderRefunded},
+		{OrderCancelled, OrderPaid},
+	}
+
+	for _, tt := range tests {
+		if (Order{Status: tt.from}).CanBecome(tt.to) {
+			t.Errorf("Expected %s not to become %s", tt.from, tt.to)
+		}
+	}
+	if !(Order{Status: OrderPending}).CanBecome(OrderCancelled) {
+		t.Error("Expected a pending order to be cancellable")
+	}
+}
+
+func TestOrderStoreLookup(t *testing.T) {
+	store := NewOrderStore()
+	guest, _ := store.Add(Order{Shipping: ShippingInfo{Email: "Guest@Example.com"}})
+	member, _ := store.Add(Order{UserID: 1, Shipping: ShippingInfo{Email: "member@example.com"}})
+	noEmail, _ := store.Add(Order{})
+
+	if got, ok := store.Lookup(guest.ID, " guest@example.COM "); !ok || got.ID != guest.ID {
+		t.Error("Expected the guest order found by its email, ignoring case")
+	}
+	if _, ok := store.Lookup(guest.ID, "other@example.com"); ok {
+		t.Error("Expected another email not to find the order")
+	}
+	if _, ok := store.Lookup(member.ID, "member@example.com"); ok {
+		t.Error("Expected orders placed with an account not to be looked up")
+	}
+	if _, ok := store.Lookup(noEmail.ID, ""); ok {
+		t.Error("Expected an order without an email not to be found")
+	}
+}
+
+func TestOrderStoreAssignUser(t *testing.T) {
+	store := NewOrderStore()
+	guest, _ := store.Add(Order{Shipping: ShippingInfo{Email: "guest@example.com"}})
+
+	order, err := store.AssignUser(guest.ID, 7)
+	if err != nil {
+		t.Fatalf("AssignUser() failed: %v", err)
+	}
+	if order.IsGuest() || len(store.ByUser(7)) != 1 {
+		t.Errorf("Expected the order in the account, got %+v", order)
+	}
+	if _, ok := store.Lookup(guest.ID, "guest@example.com"); ok {
+		t.Error("Expected the order no longer looked up as a guest's")
+	}
+	if _, err := store.AssignUser(guest.ID, 8); !errors.Is(err, ErrOrderClaimed) {
+		t.Errorf("Expected ErrOrderClaimed, got %v", err)
+	}
+	if _, err := store.AssignUser(99, 7); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("Expected ErrOrderNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrPaymentDeclined is returned when the provider refuses a payment
+	ErrPaymentDeclined = errors.New("payment declined")
+	// ErrPaymentNotFound is returned for a payment the provider doesn't know
+	ErrPaymentNotFound = errors.New("payment not found")
+	// ErrPaymentState is returned for an operation the payment's status
+	// doesn't allow, such as refunding more than was captured
+	ErrPaymentState = errors.New("operation not allowed in the payment's status")
+	// ErrWebhookSignature is returned for a webhook the provider didn't sign
+	ErrWebhookSignature = errors.New("invalid webhook signature")
+)
+
+// PaymentStatus is where a payment is in its life
+type PaymentStatus string
+
+const (
+	// PaymentAuthorized payments have their amount held but not yet taken
+	PaymentAuthorized PaymentStatus = "authorized"
+	// PaymentCaptured payments have been taken
+	PaymentCaptured PaymentStatus = "captured"
+	// PaymentRefunded payments have been given back in full
+	PaymentRefunded PaymentStatus = "refunded"
+)
+
+// PaymentRequest asks a provider to authorize a payment
+type PaymentRequest struct {
+	// Reference ties the payment to what is being paid for
+	Reference string
+	Amount    Money
+	// Source is what pays, such as a card number or a token standing for one
+	Source string
+}
+
+// Payment is a payment as the provider reports it
+type Payment struct {
+	ID        string        `json:"id"`
+	Reference string        `json:"reference"`
+	Amount    Money         `json:"amount"`
+	Refunded  Money         `json:"refunded,omitzero"`
+	Status    PaymentStatus `json:"status"`
+}
+
+// PaymentEvent is a change to a payment the provider tells the shop about
+type PaymentEvent struct {
+	// Type is the payment's new status
+	Type    PaymentStatus `json:"type"`
+	Payment Payment       `json:"payment"`
+}
+
+// PaymentProvider takes payments through a payment gateway. A payment is
+// authorized first, which holds the amount, then captured to take it.
+type PaymentProvider interface {
+	Authorize(ctx context.Context, req PaymentRequest) (Payment, error)
+	Capture(ctx context.Context, paymentID string) (Payment, error)
+	// Refund gives back amount of a captured payment, all at once or in parts
+	Refund(ctx context.Context, paymentID string, amount Money) (Payment, error)
+	// ParseWebhook checks that a webhook request came from the provider and
+	// returns the event it reports
+	ParseWebhook(r *http.Request) (PaymentEvent, error)
+}
+
+// mockSignatureHeader holds the signature of a MockPayments webhook body
+const mockSignatureHeader = "X-Mock-Signature"
+
+// MockDeclinedCard is a card number MockPayments always declines
+const MockDeclinedCard = "4000000000000002"
+
+// MockPayments is a sandbox payment gateway that keeps payments in memory.
+// It authorizes any source except MockDeclinedCard and sends no money
+// anywhere. Its webhooks are signed with an HMAC-SHA256 of the body, hex
+// encoded in the X-Mock-Signature header.
+type MockPayments struct {
+	secret []byte
+
+	mu       sync.Mutex
+	payments map[string]Payment
+}
+
+// NewMockPayments creates a sandbox gateway signing webhooks with secret
+func NewMockPayments(secret string) *MockPayments {
+	return &MockPayments{
+		secret:   []byte(secret),
+		payments: make(map[string]Payment),
+	}
+}
+
+// Authorize holds the amount, unless the source is MockDeclinedCard
+func (m *MockPayments) Authorize(_ context.Context, req PaymentRequest) (Payment, error) {
+	if strings.Join(strings.Fields(req.Source), "") == MockDeclinedCard {
+		return Payment{}, ErrPaymentDeclined
+	}
+	if req.Amount.Amount <= 0 {
+		return Payment{}, fmt.Errorf("%w: amount must be positive", ErrPaymentDeclined)
+	}
+
+	id := make([]byte, 8)
+	rand.Read(id)
+	payment := Payment{
+		ID:        "mock_" + hex.EncodeToString(id),
+		Reference: req.Reference,
+		Amount:    req.Amount,
+		Status:    PaymentAuthorized,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.payments[payment.ID] = payment
+	return payment, nil
+}
+
+// Capture takes an authorized payment
+func (m *MockPayments) Capture(_ context.Context, paymentID string) (Payment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	payment, ok := m.payments[paymentID]
+	if !ok {
+		return Payment{}, ErrPaymentNotFound
+	}
+	if payment.Status != PaymentAuthorized {
+		return Payment{}, fmt.Errorf("%w: payment is %s", ErrPaymentState, payment.Status)
+	}
+	payment.Status = PaymentCaptured
+	m.payments[paymentID] = payment
+	return payment, nil
+}
+
+// Refund gives back amount of a captured payment. The payment is refunded
+// once all of it has been given back.
+func (m *MockPayments) Refund(_ context.Context, paymentID string, amount Money) (Payment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	payment, ok := m.payments[paymentID]
+	if !ok {
+		return Payment{}, ErrPaymentNotFound
+	}
+	if payment.Status != PaymentCaptured {
+		return Payment{}, fmt.Errorf("%w: payment is %s", ErrPaymentState, payment.Status)
+	}
+	refunded := payment.Refunded.Add(amount)
+	if amount.Amount <= 0 || refunded.Cmp(payment.Amount) > 0 {
+		return Payment{}, fmt.Errorf("%w: can't refund %v of %v", ErrPaymentState, amount, payment.Amount.Sub(payment.Refunded))
+	}
+	payment.Refunded = refunded
+	if refunded == payment.Amount {
+		payment.Status = PaymentRefunded
+	}
+	m.payments[paymentID] = payment
+	return payment, nil
+}
+
+// ParseWebhook checks the request's signature and reads its event
+func (m *MockPayments) ParseWebhook(r *http.Request) (PaymentEvent, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return PaymentEvent{}, err
+	}
+	signature, err := hex.DecodeString(r.Header.Get(mockSignatureHeader))
+	if err != nil || !hmac.Equal(signature, m.sign(body)) {
+		return PaymentEvent{}, ErrWebhookSignature
+	}
+
+	var event PaymentEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return PaymentEvent{}, fmt.Errorf("decode webhook: %w", err)
+	}
+	return event, nil
+}
+
+// Webhook returns a signed request reporting event to url, as the sandbox
+// would send it
+func (m *MockPayments) Webhook(url string, event PaymentEvent) (*http.Request, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(mockSignatureHeader, hex.EncodeToString(m.sign(body)))
+	return req, nil
+}
+
+// sign returns the HMAC-SHA256 of body under the webhook secret
+func (m *MockPayments) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
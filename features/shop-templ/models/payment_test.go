@@ -0,0 +1,78 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMockPaymentsAuthorizeAndCapture(t *testing.T) {
+	payments := NewMockPayments("secret")
+	ctx := context.Background()
+
+	payment, err := payments.Authorize(ctx, PaymentRequest{Reference: "cart", Amount: Won(10000), Source: "4242 4242 4242 4242"})
+	if err != nil || payment.Status != PaymentAuthorized || payment.ID == "" {
+		t.Fatalf("Expected an authorized payment, got %+v, %v", payment, err)
+	}
+
+	if payment, err = payments.Capture(ctx, payment.ID); err != nil || payment.Status != PaymentCaptured {
+		t.Errorf("Expected the payment captured, got %+v, %v", payment, err)
+	}
+	if _, err := payments.Capture(ctx, payment.ID); !errors.Is(err, ErrPaymentState) {
+		t.Errorf("Expected ErrPaymentState capturing twice, got %v", err)
+	}
+	if _, err := payments.Capture(ctx, "missing"); !errors.Is(err, ErrPaymentNotFound) {
+		t.Errorf("Expected ErrPaymentNotFound, got %v", err)
+	}
+}
+
+func TestMockPaymentsDecline(t *testing.T) {
+	payments := NewMockPayments("secret")
+
+	_, err := payments.Authorize(context.Background(), PaymentRequest{Amount: Won(10000), Source: "4000 0000 0000 0002"})
+	if !errors.Is(err, ErrPaymentDeclined) {
+		t.Errorf("Expected the test card declined, got %v", err)
+	}
+}
+
+func TestMockPaymentsRefund(t *testing.T) {
+	payments := NewMockPayments("secret")
+	ctx := context.Background()
+	payment, _ := payments.Authorize(ctx, PaymentRequest{Amount: Won(10000), Source: "4242424242424242"})
+
+	if _, err := payments.Refund(ctx, payment.ID, Won(10000)); !errors.Is(err, ErrPaymentState) {
+		t.Errorf("Expected an uncaptured payment not to be refunded, got %v", err)
+	}
+	payments.Capture(ctx, payment.ID)
+
+	payment, err := payments.Refund(ctx, payment.ID, Won(4000))
+	if err != nil || payment.Status != PaymentCaptured || payment.Refunded != Won(4000) {
+		t.Errorf("Expected a partial refund, got %+v, %v", payment, err)
+	}
+	if _, err := payments.Refund(ctx, payment.ID, Won(7000)); !errors.Is(err, ErrPaymentState) {
+		t.Errorf("Expected refunding more than is left to fail, got %v", err)
+	}
+	if payment, err = payments.Refund(ctx, payment.ID, Won(6000)); err != nil || payment.Status != PaymentRefunded {
+		t.Errorf("Expected the payment refunded in full, got %+v, %v", payment, err)
+	}
+}
+
+func TestMockPaymentsWebhook(t *testing.T) {
+	payments := NewMockPayments("secret")
+	event := PaymentEvent{Type: PaymentCaptured, Payment: Payment{ID: "mock_1", Amount: Won(10000), Status: PaymentCaptured}}
+
+	req, err := payments.Webhook("/payments/webhook", event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := payments.ParseWebhook(req)
+	if err != nil || got != event {
+		t.Errorf("Expected the signed event back, got %+v, %v", got, err)
+	}
+
+	// Signed with another secret
+	req, _ = NewMockPayments("other").Webhook("/payments/webhook", event)
+	if _, err := payments.ParseWebhook(req); !errors.Is(err, ErrWebhookSignature) {
+		t.Errorf("Expected ErrWebhookSignature, got %v", err)
+	}
+}
@@ -0,0 +1,279 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidPoints is returned for redeeming no points or fewer
+	ErrInvalidPoints = errors.New("points must be more than zero")
+	// ErrInsufficientPoints is returned for redeeming more points than
+	// the user has
+	ErrInsufficientPoints = errors.New("not enough points")
+)
+
+// A point is worth one won when redeemed.
+
+// PointRule earns points on products of a category
+type PointRule struct {
+	Name string `json:"name"`
+	// Rate is in basis points of what is paid: 100 is 1%
+	Rate int `json:"rate"`
+	// Category limits the rule to products in it or its sub-categories;
+	// empty means any category
+	Category string `json:"category,omitempty"`
+}
+
+// PointRules decide the points earned on each product of an order. A rule
+// for the product's category beats one for a category above it, which
+// beats one for any category, and the first listed of equally specific
+// ones wins. A product no rule applies to earns
+// nothing.
+type PointRules []PointRule
+
+// DefaultPointRules earn 1% of what is paid for every product
+var DefaultPointRules = PointRules{
+	{Name: "기본 적립", Rate: 100},
+}
+
+// LoadPointRules reads point rules from a JSON file holding a list of them
+func LoadPointRules(path string) (PointRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read point rules: %w", err)
+	}
+
+	var rules PointRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("decode point rules: %w", err)
+	}
+	for _, rule := range rules {
+		if rule.Name == "" || rule.Rate < 0 {
+			return nil, fmt.Errorf("point rule %+v needs a name and a rate of at least 0", rule)
+		}
+	}
+
+	return rules, nil
+}
+
+// rate returns the rate products of category earn at
+func (rules PointRules) rate(category string) int {
+	ancestors := CategoryAncestors(category)
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		for _, rule := range rules {
+			if rule.Category == ancestors[i] {
+				return rule.Rate
+			}
+		}
+	}
+	for _, rule := range rules {
+		if rule.Category == "" {
+			return rule.Rate
+		}
+	}
+	return 0
+}
+
+// ForOrder returns the points an order earns. Only what is paid earns
+// points: bundle savings, the coupon discount and the points used are
+// shared among the products in proportion to their prices, as the discount
+// is for taxes. Shipping and taxes earn nothing.
+func (rules PointRules) ForOrder(order Order) int {
+	subtotal := order.Subtotal()
+	off := order.BundleDiscount().Add(order.Discount).Add(order.PointsDiscount())
+
+	var earned int64
+	for _, item := range order.Items {
+		paid := item.Subtotal()
+		if !off.IsZero() && !subtotal.IsZero() {
+			paid = paid.Sub(NewMoney(off.Amount*paid.Amount/subtotal.Amount, paid.Currency))
+		}
+		earned += paid.BasisPoints(rules.rate(item.Category)).Amount
+	}
+	return int(max(earned, 0))
+}
+
+// PointReason is why a user's points changed
+type PointReason string
+
+const (
+	// PointsEarned are earned by an order once it is paid for
+	PointsEarned PointReason = "earned"
+	// PointsRedeemed are taken off an order's total
+	PointsRedeemed PointReason = "redeemed"
+	// PointsRestored are given back from an order that was called off or
+	// couldn't be placed
+	PointsRestored PointReason = "restored"
+	// PointsRevoked are taken back from an order that was called off
+	// after they were earned
+	PointsRevoked PointReason = "revoked"
+)
+
+// PointEntry is one change to a user's points. Entries are never changed
+// or removed, so a user's entries account for their balance.
+type PointEntry struct {
+	ID     int `json:"id"`
+	UserID int `json:"userId"`
+	// Points are added if positive, taken if negative
+	Points int         `json:"points"`
+	Reason PointReason `json:"reason"`
+	// OrderID is the order the points were for, or zero for one not yet
+	// placed
+	OrderID   int       `json:"orderId,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// PointsLedger records every change to users' points with thread-safe
+// operations
+type PointsLedger struct {
+	mu      sync.RWMutex
+	entries []PointEntry
+	now     func() time.Time
+}
+
+// NewPointsLedger creates an empty ledger
+func NewPointsLedger() *PointsLedger {
+	return &PointsLedger{now: time.Now}
+}
+
+// Balance returns the points the user has
+func (l *PointsLedger) Balance(userID int) int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.balanceUnlocked(userID)
+}
+
+// balanceUnlocked adds up the user's entries. The caller must hold l.mu.
+func (l *PointsLedger) balanceUnlocked(userID int) int {
+	balance := 0
+	for _, entry := range l.entries {
+		if entry.UserID == userID {
+			balance += entry.Points
+		}
+	}
+	return balance
+}
+
+// History returns the user's entries, newest first
+func (l *PointsLedger) History(userID int) []PointEntry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var history []PointEntry
+	for _, entry := range slices.Backward(l.entries) {
+		if entry.UserID == userID {
+			history = append(history, entry)
+		}
+	}
+	return history
+}
+
+// Redeem takes points from the user to pay for an order being placed
+func (l *PointsLedger) Redeem(userID, points int) (PointEntry, error) {
+	if points <= 0 {
+		return PointEntry{}, ErrInvalidPoints
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if balance := l.balanceUnlocked(userID); points > balance {
+		return PointEntry{}, fmt.Errorf("%w: %d of %d", ErrInsufficientPoints, points, balance)
+	}
+	return l.addUnlocked(userID, -points, PointsRedeemed, 0), nil
+}
+
+// Earn gives the user the points an order earned. An order earns once;
+// it reports whether the points were given.
+func (l *PointsLedger) Earn(userID, orderID, points int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if points <= 0 || l.hasUnlocked(orderID, PointsEarned) {
+		return false
+	}
+	l.addUnlocked(userID, points, PointsEarned, orderID)
+	return true
+}
+
+// Restore gives back points redeemed for an order that was called off, or
+// for one that couldn't be placed if orderID is zero. Points are given
+// back once per order; it reports whether they were.
+func (l *PointsLedger) Restore(userID, orderID, points int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if points <= 0 || (orderID != 0 && l.hasUnlocked(orderID, PointsRestored)) {
+		return false
+	}
+	l.addUnlocked(userID, points, PointsRestored, orderID)
+	return true
+}
+
+// Revoke takes back the points an order earned, once it is called off.
+// Points already spent can't be taken back, so no more than the user has
+// are. It returns how many were taken.
+func (l *PointsLedger) Revoke(userID, orderID int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.hasUnlocked(orderID, PointsRevoked) {
+		return 0
+	}
+	earned := 0
+	for _, entry := range l.entries {
+		if entry.OrderID == orderID && entry.Reason == PointsEarned {
+			earned += entry.Points
+		}
+	}
+	if taken := min(earned, l.balanceUnlocked(userID)); taken > 0 {
+		l.addUnlocked(userID, -taken, PointsRevoked, orderID)
+		return taken
+	}
+	return 0
+}
+
+// hasUnlocked reports whether the order has an entry for reason. The
+// caller must hold l.mu.
+func (l *PointsLedger) hasUnlocked(orderID int, reason PointReason) bool {
+	return slices.ContainsFunc(l.entries, func(entry PointEntry) bool {
+		return entry.OrderID == orderID && entry.Reason == reason
+	})
+}
+
+// addUnlocked records an entry. The caller must hold l.mu.
+func (l *PointsLedger) addUnlocked(userID, points int, reason PointReason, orderID int) PointEntry {
+	entry := PointEntry{
+		ID:        len(l.entries) + 1,
+		UserID:    userID,
+		Points:    points,
+		Reason:    reason,
+		OrderID:   orderID,
+		CreatedAt: l.now(),
+	}
+	l.entries = append(l.entries, entry)
+	return entry
+}
+
+type pointsContextKey struct{}
+
+// ContextWithPoints returns a copy of ctx carrying the logged-in user's
+// point balance
+func ContextWithPoints(ctx context.Context, points int) context.Context {
+	return context.WithValue(ctx, pointsContextKey{}, points)
+}
+
+// PointsFromContext returns the logged-in user's point balance, or zero if
+// no one is logged in
+func PointsFromContext(ctx context.Context) int {
+	points, _ := ctx.Value(pointsContextKey{}).(int)
+	return points
+}
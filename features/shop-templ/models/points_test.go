@@ -0,0 +1,149 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPointRulesForOrder(t *testing.T) {
+	rules := PointRules{
+		{Name: "기본", Rate: 100},
+		{Name: "도서", Rate: 500, Category: "도서"},
+		{Name: "기본 중복", Rate: 9000},
+	}
+	order := Order{Items: []OrderItem{
+		{Name: "Book", Category: "도서", Price: Won(20000), Quantity: 1},
+		{Name: "Pen", Category: "문구", Price: Won(10000), Quantity: 3},
+	}}
+
+	// 5% of 20000 and 1% of 30000
+	if got := rules.ForOrder(order); got != 1300 {
+		t.Errorf("Expected 1300 points, got %d", got)
+	}
+
+	// Half off shared by price: 5% of 10000 and 1% of 15000
+	order.Discount = Won(20000)
+	order.PointsUsed = 5000
+	if got := rules.ForOrder(order); got != 650 {
+		t.Errorf("Expected points only on what is paid, got %d", got)
+	}
+
+	order.Delivery = ShippingOption{Fee: Won(3000)}
+	order.Taxes = TaxLines{{Name: "VAT", Amount: Won(3500)}}
+	if got := rules.ForOrder(order); got != 650 {
+		t.Errorf("Expected shipping and taxes to earn nothing, got %d", got)
+	}
+
+	if got := (PointRules{{Name: "도서", Rate: 500, Category: "도서"}}).ForOrder(order); got != 500 {
+		t.Errorf("Expected products no rule applies to to earn nothing, got %d", got)
+	}
+}
+
+func TestOrderPointsDiscount(t *testing.T) {
+	order := Order{Items: []OrderItem{{Price: Won(10000), Quantity: 2}}, Discount: Won(2000), PointsUsed: 1500}
+	if got := order.TotalDue(); got != Won(16500) {
+		t.Errorf("Expected the points taken off the total, got %v", got)
+	}
+
+	// Orders without points may be in any currency
+	order = Order{Items: []OrderItem{{Price: usd(1000), Quantity: 1}}}
+	if got := order.TotalDue(); got != usd(1000) {
+		t.Errorf("Expected %v, got %v", usd(1000), got)
+	}
+}
+
+func TestLoadPointRules(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "points.json")
+	os.WriteFile(path, []byte(`[{"name": "기본", "rate": 100}, {"name": "도서", "rate": 300, "category": "도서"}]`), 0o644)
+
+	rules, err := LoadPointRules(path)
+	if err != nil {
+		t.Fatalf("LoadPointRules() failed: %v", err)
+	}
+	if len(rules) != 2 || rules[1].Category != "도서" {
+		t.Errorf("Unexpected rules: %+v", rules)
+	}
+
+	os.WriteFile(path, []byte(`[{"name": "Bad", "rate": -1}]`), 0o644)
+	if _, err := LoadPointRules(path); err == nil {
+		t.Error("Expected a negative rate to be rejected")
+	}
+	if _, err := LoadPointRules(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Expected a missing file to be an error")
+	}
+}
+
+func TestPointsLedger(t *testing.T) {
+	ledger := NewPointsLedger()
+
+	if !ledger.Earn(1, 10, 1000) {
+		t.Fatal("Expected an order's points to be given")
+	}
+	if ledger.Earn(1, 10, 1000) {
+		t.Error("Expected an order to earn only once")
+	}
+	ledger.Earn(2, 11, 500)
+	if got := ledger.Balance(1); got != 1000 {
+		t.Errorf("Expected a balance of 1000, got %d", got)
+	}
+
+	if _, err := ledger.Redeem(1, 1001); !errors.Is(err, ErrInsufficientPoints) {
+		t.Errorf("Expected ErrInsufficientPoints, got %v", err)
+	}
+	if _, err := ledger.Redeem(1, 0); !errors.Is(err, ErrInvalidPoints) {
+		t.Errorf("Expected ErrInvalidPoints, got %v", err)
+	}
+	entry, err := ledger.Redeem(1, 800)
+	if err != nil || entry.Points != -800 || entry.Reason != PointsRedeemed {
+		t.Fatalf("Redeem() = %+v, %v", entry, err)
+	}
+
+	// An order that couldn't be placed gives its points back
+	ledger.Restore(1, 0, 300)
+	if got := ledger.Balance(1); got != 500 {
+		t.Errorf("Expected a balance of 500, got %d", got)
+	}
+	if !ledger.Restore(1, 12, 500) || ledger.Restore(1, 12, 500) {
+		t.Error("Expected an order's points to be given back once")
+	}
+
+	// Order 10's 1000 points can only be taken back as far as the balance
+	ledger.Redeem(1, 600)
+	if got := ledger.Revoke(1, 10); got != 400 {
+		t.Errorf("Expected 400 points taken back, got %d", got)
+	}
+	if got := ledger.Revoke(1, 10); got != 0 {
+		t.Errorf("Expected an order's points to be taken back once, got %d", got)
+	}
+	if got := ledger.Balance(1); got != 0 {
+		t.Errorf("Expected a balance of 0, got %d", got)
+	}
+
+	history := ledger.History(1)
+	if len(history) != 6 || history[0].Reason != PointsRevoked || history[5].Reason != PointsEarned {
+		t.Errorf("Expected every entry, newest first, got %+v", history)
+	}
+	total := 0
+	for _, entry := range history {
+		total += entry.Points
+	}
+	if total != ledger.Balance(1) {
+		t.Errorf("Expected the entries to add up to the balance, got %d", total)
+	}
+	if got := ledger.History(2); len(got) != 1 || got[0].Points != 500 {
+		t.Errorf("Expected only the user's entries, got %+v", got)
+	}
+}
+
+func TestPointsContext(t *testing.T) {
+	if got := PointsFromContext(context.Background()); got != 0 {
+		t.Errorf("Expected no points without a user, got %d", got)
+	}
+	if got := PointsFromContext(ContextWithPoints(context.Background(), 1200)); got != 1200 {
+		t.Errorf("Expected 1200 points, got %d", got)
+	}
+}
@@ -1,20 +1,101 @@
 package models
 
 import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
+// ErrInvalidProduct is returned for a product missing a name or category,
+// or with a negative price
+var ErrInvalidProduct = errors.New("product needs a name, a category and a price of at least zero")
+
 // Product represents an item in the e-commerce store
 type Product struct {
-	ID          int      `json:"id"`
-	Name        string   `json:"name"`
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// SKU and Barcode identify the product in the stockroom. No two
+	// products share one, nor a product and a variant their SKU.
+	SKU         string   `json:"sku,omitempty"`
+	Barcode     string   `json:"barcode,omitempty"`
 	Description string   `json:"description"`
-	Price       float64  `json:"price"`
+	Price       Money    `json:"price"`
 	ImageURL    string   `json:"imageUrl"`
-	Category    string   `json:"category"`
-	Stock       int      `json:"stock"`
-	Tags        []string `json:"tags"`
+	Images      []string `json:"images,omitempty"`
+	// Category is the path of the product's category, its levels separated
+	// by CategorySeparator, such as "전자제품/오디오"
+	Category string   `json:"category"`
+	Stock    int      `json:"stock"`
+	Tags     []string `json:"tags"`
+	// Sold is how many units have been ordered, for sorting by popularity
+	Sold int `json:"sold"`
+	// Archived products are no longer sold. They stay in the store for the
+	// orders and carts that have them, but customers can't find or buy them.
+	Archived bool `json:"archived,omitempty"`
+	// Options are the ways the product comes in more than one kind. It is
+	// then sold as its variants, each with its own stock; Stock is theirs
+	// added up.
+	Options  []ProductOption `json:"options,omitempty"`
+	Variants []Variant       `json:"variants,omitempty"`
+	// Sale is the promotion the product is on sale with, if any. The store
+	// fills it in as it returns products and never keeps it.
+	Sale *Promotion `json:"sale,omitempty"`
+}
+
+// clone returns a copy of the product that shares nothing with it: the
+// slices, the option and variant values and the sale are copied too
+func (p Product) clone() Product {
+	p.Images = slices.Clone(p.Images)
+	p.Tags = slices.Clone(p.Tags)
+	p.Options = slices.Clone(p.Options)
+	for i := range p.Options {
+		p.Options[i].Values = slices.Clone(p.Options[i].Values)
+	}
+	p.Variants = slices.Clone(p.Variants)
+	for i := range p.Variants {
+		p.Variants[i].Values = slices.Clone(p.Variants[i].Values)
+	}
+	if p.Sale != nil {
+		sale := *p.Sale
+		p.Sale = &sale
+	}
+	return p
+}
+
+// CurrentPrice returns what the product sells for now: its price, less
+// any sale
+func (p Product) CurrentPrice() Money {
+	return p.PriceOf(0)
+}
+
+// Validate checks that the product has a name and category, isn't priced
+// below zero and that its variants fit its options. Whether other products
+// use its SKUs or barcode is up to the store.
+func (p Product) Validate() error {
+	if strings.TrimSpace(p.Name) == "" || NormalizeCategory(p.Category) == "" || p.Price.Amount < 0 {
+		return ErrInvalidProduct
+	}
+	if err := p.validateVariants(); err != nil {
+		return err
+	}
+	return p.validateCodes()
+}
+
+// Gallery returns the product's images for the detail page, the main
+// image first. Empty URLs are skipped.
+func (p Product) Gallery() []string {
+	var images []string
+	for _, url := range append([]string{p.ImageURL}, p.Images...) {
+		if url != "" {
+			images = append(images, url)
+		}
+	}
+	return images
 }
 
 // ProductStore manages products with thread-safe operations
@@ -22,26 +103,252 @@ type ProductStore struct {
 	mu       sync.RWMutex
 	products map[int]Product
 	nextID   int
+	// reservations holds stock for carts, by cart ID and then product or
+	// variant
+	reservations map[string]map[ItemKey]reservation
+	now          func() time.Time
+	// repo, if set, is where products are kept between restarts
+	repo ProductRepository
+	// onChange, if set, is called with products as they change
+	onChange func(Product)
+	// promotions, if set, put products on sale as they are returned
+	promotions *PromotionStore
+	// changed is when a product last changed
+	changed time.Time
+	// listings, if set, caches product listings, see CacheListings
+	listings *listingCache
 }
 
 // NewProductStore creates a new product store
 func NewProductStore() *ProductStore {
 	return &ProductStore{
-		products: make(map[int]Product),
-		nextID:   1,
+		products:     make(map[int]Product),
+		nextID:       1,
+		reservations: make(map[string]map[ItemKey]reservation),
+		now:          time.Now,
+		changed:      time.Now(),
+	}
+}
+
+// NewPersistentProductStore loads products from repo and writes every
+// change to them back to it. Reservations are kept in memory only.
+func NewPersistentProductStore(repo ProductRepository) (*ProductStore, error) {
+	products, err := repo.LoadProducts()
+	if err != nil {
+		return nil, fmt.Errorf("load products: %w", err)
+	}
+
+	s := NewProductStore()
+	s.repo = repo
+	for _, product := range products {
+		s.products[product.ID] = product
+		s.nextID = max(s.nextID, product.ID+1)
 	}
+
+	return s, nil
 }
 
-// Add adds a new product to the store and returns it with an assigned ID
-func (s *ProductStore) Add(product Product) Product {
+// putUnlocked stores products, writing them through to the repository
+// first if the store has one. If the write fails nothing changes, so
+// memory never holds what the repository doesn't. The caller must hold
+// s.mu.
+func (s *ProductStore) putUnlocked(products ...Product) error {
+	for i := range products {
+		products[i].Sale = nil
+	}
+	if s.repo != nil && len(products) > 0 {
+		if err := s.repo.SaveProducts(products...); err != nil {
+			return fmt.Errorf("%w: save products: %w", ErrNotSaved, err)
+		}
+	}
+
+	for _, product := range products {
+		s.products[product.ID] = product
+		if s.onChange != nil {
+			s.onChange(s.onSaleUnlocked(product))
+		}
+	}
+	if len(products) > 0 {
+		s.changed = s.now()
+		if s.listings != nil {
+			s.listings.clear()
+		}
+	}
+	return nil
+}
+
+// OnChange sets fn to be called with every product added or changed from
+// now on, such as to show its new stock to shoppers. It is called with the
+// store locked, so it must return quickly and not use the store.
+func (s *ProductStore) OnChange(fn func(Product)) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.onChange = fn
+}
+
+// UsePromotions puts products on sale with the promotions on at the time
+// they are returned
+func (s *ProductStore) UsePromotions(promotions *PromotionStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.promotions = promotions
+}
+
+// onSaleUnlocked returns the product with the promotion that makes it
+// cheapest now, if any. The caller must hold s.mu.
+func (s *ProductStore) onSaleUnlocked(p Product) Product {
+	p.Sale = nil
+	if s.promotions != nil {
+		if promotion, ok := s.promotions.Best(p, s.now()); ok {
+			p.Sale = &promotion
+		}
+	}
+	return p
+}
+
+// Add adds a new product to the store and returns it with an assigned ID.
+// Its variants are numbered too.
+func (s *ProductStore) Add(product Product) (Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addUnlocked(product)
+}
+
+// Create validates a product, including that no other product has its
+// SKUs or barcode, and adds it as Add does
+func (s *ProductStore) Create(product Product) (Product, error) {
+	if err := product.Validate(); err != nil {
+		return Product{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.codesUnlocked().check(product, 0); err != nil {
+		return Product{}, err
+	}
+	return s.addUnlocked(product)
+}
+
+// addUnlocked adds a product with the next ID. The caller must hold s.mu.
+func (s *ProductStore) addUnlocked(product Product) (Product, error) {
+	product = product.withVariantStock()
+	product.Category = NormalizeCategory(product.Category)
 	product.ID = s.nextID
+	if err := s.putUnlocked(product); err != nil {
+		return Product{}, err
+	}
 	s.nextID++
-	s.products[product.ID] = product
 
-	return product
+	return product, nil
+}
+
+// AddImage adds an image to a product's gallery. The first image becomes
+// the product's main image.
+func (s *ProductStore) AddImage(id int, url string) (Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, exists := s.products[id]
+	if !exists {
+		return Product{}, ErrProductNotFound
+	}
+	if product.ImageURL == "" {
+		product.ImageURL = url
+	} else {
+		product.Images = append(slices.Clip(product.Images), url)
+	}
+	if err := s.putUnlocked(product); err != nil {
+		return Product{}, err
+	}
+
+	return product, nil
+}
+
+// Update changes a product's name, SKU, barcode, description, price,
+// category and tags. Its stock, images and whether it is archived have
+// their own methods, and its variants come from catalog imports. The new
+// price must leave every variant's at zero or more, and no other product
+// may have the new SKU or barcode.
+func (s *ProductStore) Update(product Product) (Product, error) {
+	if err := product.Validate(); err != nil {
+		return Product{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, exists := s.products[product.ID]
+	if !exists {
+		return Product{}, ErrProductNotFound
+	}
+	stored.Name = product.Name
+	stored.SKU = product.SKU
+	stored.Barcode = product.Barcode
+	stored.Description = product.Description
+	stored.Price = product.Price
+	stored.Category = NormalizeCategory(product.Category)
+	stored.Tags = product.Tags
+	if err := stored.validateVariants(); err != nil {
+		return Product{}, err
+	}
+	if err := stored.validateCodes(); err != nil {
+		return Product{}, err
+	}
+	if err := s.codesUnlocked().check(stored, stored.ID); err != nil {
+		return Product{}, err
+	}
+	if err := s.putUnlocked(stored); err != nil {
+		return Product{}, err
+	}
+
+	return stored, nil
+}
+
+// SetArchived takes a product off sale, or puts it back
+func (s *ProductStore) SetArchived(id int, archived bool) (Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, exists := s.products[id]
+	if !exists {
+		return Product{}, ErrProductNotFound
+	}
+	product.Archived = archived
+	if err := s.putUnlocked(product); err != nil {
+		return Product{}, err
+	}
+
+	return product, nil
+}
+
+// RenameCategory moves every product in category from to category to,
+// merging the two if to already has products. Sub-categories move along,
+// so renaming "전자제품" to "가전" moves "전자제품/오디오" to "가전/오디오".
+// It returns how many products moved.
+func (s *ProductStore) RenameCategory(from, to string) (int, error) {
+	from, to = NormalizeCategory(from), NormalizeCategory(to)
+	if from == "" || to == "" {
+		return 0, ErrInvalidProduct
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var moved []Product
+	for _, p := range s.products {
+		if InCategory(p.Category, from) {
+			p.Category = to + strings.TrimPrefix(p.Category, from)
+			moved = append(moved, p)
+		}
+	}
+	if err := s.putUnlocked(moved...); err != nil {
+		return 0, err
+	}
+	return len(moved), nil
 }
 
 // GetByID retrieves a product by its ID
@@ -50,47 +357,56 @@ func (s *ProductStore) GetByID(id int) (Product, bool) {
 	defer s.mu.RUnlock()
 
 	product, exists := s.products[id]
-	return product, exists
+	if !exists {
+		return Product{}, false
+	}
+	return s.onSaleUnlocked(product), true
 }
 
-// GetAll returns all products
+// GetAll returns all products on sale in the order they were added
 func (s *ProductStore) GetAll() []Product {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	return s.getAllUnlocked()
+}
+
+// GetAllWithArchived returns every product, archived ones too, in the
+// order they were added
+func (s *ProductStore) GetAllWithArchived() []Product {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	products := make([]Product, 0, len(s.products))
 	for _, p := range s.products {
-		products = append(products, p)
+		products = append(products, s.onSaleUnlocked(p))
 	}
-
+	slices.SortFunc(products, func(a, b Product) int { return cmp.Compare(a.ID, b.ID) })
 	return products
 }
 
-// Search searches for products by name or description
-func (s *ProductStore) Search(query string) []Product {
+// GetPage returns one page of all products in the given order
+func (s *ProductStore) GetPage(offset, limit int, order ProductSort) ProductPage {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if query == "" {
-		return s.getAllUnlocked()
-	}
-
-	query = strings.ToLower(query)
-	results := make([]Product, 0)
-
-	for _, p := range s.products {
-		nameMatch := strings.Contains(strings.ToLower(p.Name), query)
-		descMatch := strings.Contains(strings.ToLower(p.Description), query)
+	return Paginate(s.getAllUnlocked(), offset, limit, order)
+}
 
-		if nameMatch || descMatch {
-			results = append(results, p)
-		}
-	}
+// Search returns the products matching a query in their name, tags,
+// category or description, most relevant first. Terms match the start of
+// words and tolerate a typo; every term must match.
+func (s *ProductStore) Search(query string) []Product {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	return results
+	return s.cachedUnlocked(listingKey("search", query), func() []Product {
+		return rankByRelevance(s.getAllUnlocked(), query)
+	})
 }
 
-// FilterByCategory returns products in a specific category
+// FilterByCategory returns products in a specific category or its
+// sub-categories
 func (s *ProductStore) FilterByCategory(category string) []Product {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -99,39 +415,259 @@ func (s *ProductStore) FilterByCategory(category string) []Product {
 		return s.getAllUnlocked()
 	}
 
+	return s.cachedUnlocked(listingKey("category", category), func() []Product {
+		results := make([]Product, 0)
+		for _, p := range s.products {
+			if InCategory(p.Category, category) && !p.Archived {
+				results = append(results, s.onSaleUnlocked(p))
+			}
+		}
+		return results
+	})
+}
+
+// Filter returns the products matching every facet given: the search
+// query, any of the categories or their sub-categories, the price range
+// and any of the tags. An empty query, category or tag list and a zero
+// price bound don't filter.
+// With a query, the most relevant products come first, as with Search.
+func (s *ProductStore) Filter(query string, categories []string, minPrice, maxPrice Money, tags []string) []Product {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key := listingKey("filter", query, strings.Join(categories, "\x01"), minPrice.String(), maxPrice.String(), strings.Join(tags, "\x01"))
+	return s.cachedUnlocked(key, func() []Product {
+		return s.filterUnlocked(query, categories, minPrice, maxPrice, tags)
+	})
+}
+
+// filterUnlocked does the work of Filter. The caller must hold s.mu.
+func (s *ProductStore) filterUnlocked(query string, categories []string, minPrice, maxPrice Money, tags []string) []Product {
 	results := make([]Product, 0)
-	for _, p := range s.products {
-		if p.Category == category {
-			results = append(results, p)
+	for _, p := range s.getAllUnlocked() {
+		if len(categories) > 0 && !slices.ContainsFunc(categories, func(c string) bool { return InCategory(p.Category, c) }) {
+			continue
 		}
+		if p.CurrentPrice().Cmp(minPrice) < 0 || (!maxPrice.IsZero() && p.CurrentPrice().Cmp(maxPrice) > 0) {
+			continue
+		}
+		if len(tags) > 0 && !slices.ContainsFunc(p.Tags, func(tag string) bool { return slices.Contains(tags, tag) }) {
+			continue
+		}
+		results = append(results, p)
 	}
 
-	return results
+	return rankByRelevance(results, query)
 }
 
-// GetCategories returns a list of unique categories
-func (s *ProductStore) GetCategories() []string {
+// Facets describes what the catalog can be filtered by
+type Facets struct {
+	// Categories are every category with products, parents included, in
+	// the order CategoriesOf gives
+	Categories []string
+	Tags       []string
+	// MaxPrice is the price of the most expensive product
+	MaxPrice Money
+}
+
+// Facets returns the catalog's categories and tags, sorted, and its top price
+func (s *ProductStore) Facets() Facets {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	categoryMap := make(map[string]bool)
-	for _, p := range s.products {
-		categoryMap[p.Category] = true
+	products := s.getAllUnlocked()
+	facets := Facets{Categories: CategoriesOf(products)}
+	for _, p := range products {
+		facets.Tags = append(facets.Tags, p.Tags...)
+		if p.Price.Cmp(facets.MaxPrice) > 0 {
+			facets.MaxPrice = p.Price
+		}
 	}
+	slices.Sort(facets.Tags)
+	facets.Tags = slices.Compact(facets.Tags)
 
-	categories := make([]string, 0, len(categoryMap))
-	for cat := range categoryMap {
-		categories = append(categories, cat)
-	}
+	return facets
+}
 
-	return categories
+// GetCategories returns a sorted list of unique category paths of the
+// products on sale, parent categories included
+func (s *ProductStore) GetCategories() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return CategoriesOf(s.getAllUnlocked())
 }
 
-// getAllUnlocked returns all products without locking (internal use only)
+// getAllUnlocked returns all products on sale in the order they were
+// added, without locking (internal use only)
 func (s *ProductStore) getAllUnlocked() []Product {
+	return s.cachedUnlocked(listingKey("all"), s.listAllUnlocked)
+}
+
+// listAllUnlocked does the work of getAllUnlocked, without the cache
+func (s *ProductStore) listAllUnlocked() []Product {
 	products := make([]Product, 0, len(s.products))
 	for _, p := range s.products {
-		products = append(products, p)
+		if !p.Archived {
+			products = append(products, s.onSaleUnlocked(p))
+		}
 	}
+	slices.SortFunc(products, func(a, b Product) int { return cmp.Compare(a.ID, b.ID) })
 	return products
 }
+
+// DefaultPageSize is how many products a listing page shows
+const DefaultPageSize = 20
+
+// ProductSort is the order a product listing is shown in
+type ProductSort string
+
+const (
+	// SortDefault keeps products in the order the store returned them: by
+	// relevance for a search, otherwise in the order they were added
+	SortDefault ProductSort = ""
+	// SortPriceAsc lists the cheapest products first
+	SortPriceAsc ProductSort = "price_asc"
+	// SortPriceDesc lists the most expensive products first
+	SortPriceDesc ProductSort = "price_desc"
+	// SortName lists products alphabetically by name
+	SortName ProductSort = "name"
+	// SortNewest lists the most recently added products first
+	SortNewest ProductSort = "newest"
+	// SortPopular lists the best-selling products first
+	SortPopular ProductSort = "popular"
+)
+
+// ProductSorts are the orders a listing can be sorted in, as offered to customers
+var ProductSorts = []ProductSort{SortDefault, SortPopular, SortNewest, SortPriceAsc, SortPriceDesc, SortName}
+
+// ParseProductSort returns the sort order named by s, or SortDefault if
+// there is none by that name
+func ParseProductSort(s string) ProductSort {
+	if slices.Contains(ProductSorts, ProductSort(s)) {
+		return ProductSort(s)
+	}
+	return SortDefault
+}
+
+// sortProducts sorts products in place. Products that tie are kept in the
+// order they were added, so the same listing always comes out the same
+// and pages don't overlap.
+func sortProducts(products []Product, order ProductSort) {
+	if order == SortDefault {
+		return
+	}
+
+	slices.SortFunc(products, func(a, b Product) int {
+		var c int
+		switch order {
+		case SortPriceAsc:
+			c = a.CurrentPrice().Cmp(b.CurrentPrice())
+		case SortPriceDesc:
+			c = b.CurrentPrice().Cmp(a.CurrentPrice())
+		case SortName:
+			c = cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		case SortNewest:
+			return cmp.Compare(b.ID, a.ID)
+		case SortPopular:
+			c = cmp.Compare(b.Sold, a.Sold)
+		}
+		return cmp.Or(c, cmp.Compare(a.ID, b.ID))
+	})
+}
+
+// ProductPage is one page of a product listing
+type ProductPage struct {
+	Products []Product
+	// Offset is the position of the page's first product in the listing
+	Offset int
+	Limit  int
+	// Total is how many products the whole listing has
+	Total int
+}
+
+// HasMore reports whether the listing continues after this page
+func (p ProductPage) HasMore() bool {
+	return p.NextOffset() < p.Total
+}
+
+// NextOffset is the offset of the page after this one
+func (p ProductPage) NextOffset() int {
+	return p.Offset + len(p.Products)
+}
+
+// NextCursor is the cursor for the page after this one
+func (p ProductPage) NextCursor() Cursor {
+	cursor := Cursor{Offset: p.NextOffset()}
+	if len(p.Products) > 0 {
+		cursor.After = p.Products[len(p.Products)-1].ID
+	}
+	return cursor
+}
+
+// Cursor marks where a page of a listing ended: how far into the listing
+// it got and the last product on it. The next page carries on after that
+// product wherever it now is, so products added or dropped earlier in the
+// listing while a customer scrolls aren't shown twice or skipped.
+type Cursor struct {
+	Offset int
+	// After is the ID of the last product on the page
+	After int
+}
+
+// String encodes the cursor for a URL, as "<offset>.<product id>"
+func (c Cursor) String() string {
+	return strconv.Itoa(c.Offset) + "." + strconv.Itoa(c.After)
+}
+
+// ParseCursor reads a cursor encoded by Cursor.String
+func ParseCursor(s string) (Cursor, bool) {
+	offset, after, found := strings.Cut(s, ".")
+	if !found {
+		return Cursor{}, false
+	}
+	var c Cursor
+	var err1, err2 error
+	c.Offset, err1 = strconv.Atoi(offset)
+	c.After, err2 = strconv.Atoi(after)
+	if err1 != nil || err2 != nil || c.Offset < 0 {
+		return Cursor{}, false
+	}
+	return c, true
+}
+
+// Paginate sorts products and returns the page of them starting at offset.
+// A negative offset counts as zero and a limit below one as
+// DefaultPageSize. The slice is sorted in place.
+func Paginate(products []Product, offset, limit int, order ProductSort) ProductPage {
+	sortProducts(products, order)
+	return pageOf(products, offset, limit)
+}
+
+// PaginateAfter sorts products and returns the page of them after the
+// cursor: right after its product, or from its offset if that product has
+// left the listing. A limit below one counts as DefaultPageSize. The slice
+// is sorted in place.
+func PaginateAfter(products []Product, cursor Cursor, limit int, order ProductSort) ProductPage {
+	sortProducts(products, order)
+
+	offset := cursor.Offset
+	if i := slices.IndexFunc(products, func(p Product) bool { return p.ID == cursor.After }); i >= 0 {
+		offset = i + 1
+	}
+	return pageOf(products, offset, limit)
+}
+
+// pageOf returns the page of sorted products starting at offset
+func pageOf(products []Product, offset, limit int) ProductPage {
+	if limit < 1 {
+		limit = DefaultPageSize
+	}
+	offset = max(offset, 0)
+
+	page := ProductPage{Offset: offset, Limit: limit, Total: len(products)}
+	if offset < len(products) {
+		page.Products = products[offset:min(offset+limit, len(products))]
+	}
+	return page
+}
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"slices"
 	"testing"
 )
 
@@ -20,14 +23,14 @@ func TestAddProduct(t *testing.T) {
 	product := Product{
 		Name:        "Test Product",
 		Description: "A test product",
-		Price:       19.99,
+		Price:       usd(1999),
 		ImageURL:    "/images/test.jpg",
 		Category:    "Electronics",
 		Stock:       10,
 		Tags:        []string{"test", "sample"},
 	}
 
-	added := store.Add(product)
+	added, _ := store.Add(product)
 	if added.ID == 0 {
 		t.Error("Added product should have a non-zero ID")
 	}
@@ -38,8 +41,8 @@ func TestAddProduct(t *testing.T) {
 
 func TestGetByID(t *testing.T) {
 	store := NewProductStore()
-	product := Product{Name: "Test", Price: 9.99, Category: "Test", Stock: 5}
-	added := store.Add(product)
+	product := Product{Name: "Test", Price: usd(999), Category: "Test", Stock: 5}
+	added, _ := store.Add(product)
 
 	found, exists := store.GetByID(added.ID)
 	if !exists {
@@ -58,9 +61,9 @@ func TestGetByID(t *testing.T) {
 func TestGetAll(t *testing.T) {
 	store := NewProductStore()
 
-	store.Add(Product{Name: "Product 1", Price: 10.00, Category: "Cat1", Stock: 5})
-	store.Add(Product{Name: "Product 2", Price: 20.00, Category: "Cat2", Stock: 3})
-	store.Add(Product{Name: "Product 3", Price: 30.00, Category: "Cat1", Stock: 8})
+	store.Add(Product{Name: "Product 1", Price: usd(1000), Category: "Cat1", Stock: 5})
+	store.Add(Product{Name: "Product 2", Price: usd(2000), Category: "Cat2", Stock: 3})
+	store.Add(Product{Name: "Product 3", Price: usd(3000), Category: "Cat1", Stock: 8})
 
 	all := store.GetAll()
 	if len(all) != 3 {
@@ -68,12 +71,138 @@ func TestGetAll(t *testing.T) {
 	}
 }
 
+func TestGetPage(t *testing.T) {
+	store := NewProductStore()
+	for i := 1; i <= 45; i++ {
+		store.Add(Product{Name: fmt.Sprintf("Product %d", i), Price: usd(1000), Stock: 1})
+	}
+
+	tests := []struct {
+		offset, limit int
+		firstID       int
+		count         int
+		hasMore       bool
+	}{
+		{0, 20, 1, 20, true},
+		{20, 20, 21, 20, true},
+		{40, 20, 41, 5, false},
+		{45, 20, 0, 0, false},
+		{-5, 0, 1, DefaultPageSize, true}, // Out of range values fall back
+	}
+
+	for _, tt := range tests {
+		page := store.GetPage(tt.offset, tt.limit, SortDefault)
+		if len(page.Products) != tt.count || page.Total != 45 || page.HasMore() != tt.hasMore {
+			t.Errorf("GetPage(%d, %d): expected %d of 45 (more: %v), got %d of %d (more: %v)",
+				tt.offset, tt.limit, tt.count, tt.hasMore, len(page.Products), page.Total, page.HasMore())
+			continue
+		}
+		if tt.count > 0 && page.Products[0].ID != tt.firstID {
+			t.Errorf("GetPage(%d, %d): expected to start at product %d, got %d", tt.offset, tt.limit, tt.firstID, page.Products[0].ID)
+		}
+	}
+
+	// Pages follow on from each other without gaps or repeats
+	seen := make(map[int]bool)
+	for page := store.GetPage(0, 7, SortDefault); ; page = store.GetPage(page.NextOffset(), 7, SortDefault) {
+		for _, p := range page.Products {
+			if seen[p.ID] {
+				t.Fatalf("Product %d shown twice", p.ID)
+			}
+			seen[p.ID] = true
+		}
+		if !page.HasMore() {
+			break
+		}
+	}
+	if len(seen) != 45 {
+		t.Errorf("Expected to page through 45 products, got %d", len(seen))
+	}
+}
+
+func TestPaginateAfter(t *testing.T) {
+	var products []Product
+	for i := 1; i <= 10; i++ {
+		products = append(products, Product{ID: i, Name: fmt.Sprintf("Product %d", i), Price: usd(int64(i) * 100)})
+	}
+
+	first := Paginate(products, 0, 4, SortPriceDesc)
+	cursor, ok := ParseCursor(first.NextCursor().String())
+	if !ok || cursor != (Cursor{Offset: 4, After: 7}) {
+		t.Fatalf("Expected the cursor to round-trip as 4.7, got %+v (ok: %v)", cursor, ok)
+	}
+
+	// A product added ahead of the cursor doesn't push the last one shown
+	// onto the next page
+	products = append(products, Product{ID: 11, Name: "Product 11", Price: usd(5000)})
+	next := PaginateAfter(products, cursor, 4, SortPriceDesc)
+	if len(next.Products) != 4 || next.Products[0].ID != 6 || next.Offset != 5 {
+		t.Errorf("Expected the next page to start at product 6 at offset 5, got %+v", next)
+	}
+
+	// Without its product the cursor falls back on its offset
+	next = PaginateAfter(slices.DeleteFunc(products, func(p Product) bool { return p.ID == 7 }), cursor, 4, SortPriceDesc)
+	if next.Offset != 4 || next.Products[0].ID != 6 {
+		t.Errorf("Expected the next page from offset 4, got %+v", next)
+	}
+
+	for _, s := range []string{"", "4", "a.7", "4.b", "-1.7"} {
+		if _, ok := ParseCursor(s); ok {
+			t.Errorf("ParseCursor(%q): expected it to be invalid", s)
+		}
+	}
+}
+
+func TestSortProducts(t *testing.T) {
+	store := NewProductStore()
+	store.Add(Product{Name: "banana", Price: usd(2000), Sold: 5})
+	store.Add(Product{Name: "Apple", Price: usd(1000), Sold: 1})
+	store.Add(Product{Name: "cherry", Price: usd(2000), Sold: 5})
+	store.Add(Product{Name: "date", Price: usd(500)})
+
+	tests := []struct {
+		order ProductSort
+		ids   []int
+	}{
+		{SortDefault, []int{1, 2, 3, 4}},
+		{SortPriceAsc, []int{4, 2, 1, 3}}, // Ties keep the order they were added
+		{SortPriceDesc, []int{1, 3, 2, 4}},
+		{SortName, []int{2, 1, 3, 4}},
+		{SortNewest, []int{4, 3, 2, 1}},
+		{SortPopular, []int{1, 3, 2, 4}},
+	}
+
+	for _, tt := range tests {
+		// Repeat to catch ties resolved by map order
+		for range 5 {
+			page := store.GetPage(0, 10, tt.order)
+			ids := make([]int, len(page.Products))
+			for i, p := range page.Products {
+				ids[i] = p.ID
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.ids) {
+				t.Errorf("Sort %q: expected %v, got %v", tt.order, tt.ids, ids)
+				break
+			}
+		}
+	}
+}
+
+func TestParseProductSort(t *testing.T) {
+	if ParseProductSort("price_desc") != SortPriceDesc {
+		t.Error("Expected price_desc to parse")
+	}
+	if ParseProductSort("bogus") != SortDefault {
+		t.Error("Expected an unknown sort to fall back to the default")
+	}
+}
+
 func TestSearchProducts(t *testing.T) {
 	store := NewProductStore()
 
-	store.Add(Product{Name: "Laptop Computer", Description: "High performance laptop", Price: 999.00, Category: "Electronics", Stock: 5})
-	store.Add(Product{Name: "Wireless Mouse", Description: "Ergonomic mouse", Price: 29.99, Category: "Electronics", Stock: 20})
-	store.Add(Product{Name: "Coffee Mug", Description: "Ceramic mug", Price: 12.99, Category: "Home", Stock: 50})
+	store.Add(Product{Name: "Laptop Computer", Description: "High performance laptop", Price: usd(99900), Category: "Electronics", Stock: 5})
+	store.Add(Product{Name: "Wireless Mouse", Description: "Ergonomic mouse", Price: usd(2999), Category: "Electronics", Stock: 20})
+	store.Add(Product{Name: "Coffee Mug", Description: "Ceramic mug", Price: usd(1299), Category: "Home", Stock: 50})
 
 	tests := []struct {
 		query    string
@@ -82,7 +211,10 @@ func TestSearchProducts(t *testing.T) {
 		{"laptop", 1},
 		{"mouse", 1},
 		{"wireless", 1},
-		{"electronics", 0}, // Search doesn't include category
+		{"electronics", 2},  // Category matches too
+		{"lap", 1},          // Prefix of a word
+		{"laptpo", 1},       // A typo
+		{"wireless mug", 0}, // Every term must match
 		{"mug", 1},
 		{"", 3}, // Empty query returns all
 		{"xyz", 0},
@@ -99,10 +231,10 @@ func TestSearchProducts(t *testing.T) {
 func TestFilterByCategory(t *testing.T) {
 	store := NewProductStore()
 
-	store.Add(Product{Name: "Laptop", Price: 999.00, Category: "Electronics", Stock: 5})
-	store.Add(Product{Name: "Mouse", Price: 29.99, Category: "Electronics", Stock: 20})
-	store.Add(Product{Name: "Mug", Price: 12.99, Category: "Home", Stock: 50})
-	store.Add(Product{Name: "Shirt", Price: 24.99, Category: "Clothing", Stock: 30})
+	store.Add(Product{Name: "Laptop", Price: usd(99900), Category: "Electronics", Stock: 5})
+	store.Add(Product{Name: "Mouse", Price: usd(2999), Category: "Electronics", Stock: 20})
+	store.Add(Product{Name: "Mug", Price: usd(1299), Category: "Home", Stock: 50})
+	store.Add(Product{Name: "Shirt", Price: usd(2499), Category: "Clothing", Stock: 30})
 
 	tests := []struct {
 		category string
@@ -123,13 +255,66 @@ func TestFilterByCategory(t *testing.T) {
 	}
 }
 
+func TestFilter(t *testing.T) {
+	store := NewProductStore()
+	store.Add(Product{Name: "Wireless Earbuds", Price: Won(129000), Category: "Electronics", Tags: []string{"audio", "wireless"}})
+	store.Add(Product{Name: "Wireless Mouse", Price: Won(45000), Category: "Electronics", Tags: []string{"mouse", "wireless"}})
+	store.Add(Product{Name: "Backpack", Description: "Fits a laptop", Price: Won(89000), Category: "Fashion", Tags: []string{"bag"}})
+	store.Add(Product{Name: "Tumbler", Price: Won(35000), Category: "Home", Tags: []string{"bottle"}})
+
+	tests := []struct {
+		name       string
+		query      string
+		categories []string
+		minPrice   Money
+		maxPrice   Money
+		tags       []string
+		expected   int
+	}{
+		{"no filters", "", nil, Money{}, Money{}, nil, 4},
+		{"query", "wireless", nil, Money{}, Money{}, nil, 2},
+		{"query in description", "laptop", nil, Money{}, Money{}, nil, 1},
+		{"any of the categories", "", []string{"Fashion", "Home"}, Money{}, Money{}, nil, 2},
+		{"price range", "", nil, Won(40000), Won(100000), nil, 2},
+		{"min price only", "", nil, Won(100000), Money{}, nil, 1},
+		{"any of the tags", "", nil, Money{}, Money{}, []string{"bag", "bottle"}, 2},
+		{"all facets together", "wireless", []string{"Electronics"}, Money{}, Won(50000), []string{"wireless"}, 1},
+		{"nothing matches", "", []string{"Home"}, Money{}, Money{}, []string{"wireless"}, 0},
+	}
+
+	for _, tt := range tests {
+		results := store.Filter(tt.query, tt.categories, tt.minPrice, tt.maxPrice, tt.tags)
+		if len(results) != tt.expected {
+			t.Errorf("%s: expected %d results, got %d", tt.name, tt.expected, len(results))
+		}
+	}
+}
+
+func TestFacets(t *testing.T) {
+	store := NewProductStore()
+	store.Add(Product{Name: "P1", Price: usd(1000), Category: "B", Tags: []string{"y", "x"}})
+	store.Add(Product{Name: "P2", Price: usd(3000), Category: "A", Tags: []string{"x"}})
+	store.Add(Product{Name: "P3", Price: usd(2000), Category: "B"})
+
+	facets := store.Facets()
+	if fmt.Sprint(facets.Categories) != "[A B]" {
+		t.Errorf("Expected sorted unique categories, got %v", facets.Categories)
+	}
+	if fmt.Sprint(facets.Tags) != "[x y]" {
+		t.Errorf("Expected sorted unique tags, got %v", facets.Tags)
+	}
+	if facets.MaxPrice != usd(3000) {
+		t.Errorf("Expected top price 30.00, got %v", facets.MaxPrice)
+	}
+}
+
 func TestGetCategories(t *testing.T) {
 	store := NewProductStore()
 
-	store.Add(Product{Name: "P1", Price: 10.00, Category: "Electronics", Stock: 5})
-	store.Add(Product{Name: "P2", Price: 20.00, Category: "Electronics", Stock: 3})
-	store.Add(Product{Name: "P3", Price: 30.00, Category: "Home", Stock: 8})
-	store.Add(Product{Name: "P4", Price: 40.00, Category: "Clothing", Stock: 2})
+	store.Add(Product{Name: "P1", Price: usd(1000), Category: "Electronics", Stock: 5})
+	store.Add(Product{Name: "P2", Price: usd(2000), Category: "Electronics", Stock: 3})
+	store.Add(Product{Name: "P3", Price: usd(3000), Category: "Home", Stock: 8})
+	store.Add(Product{Name: "P4", Price: usd(4000), Category: "Clothing", Stock: 2})
 
 	categories := store.GetCategories()
 	if len(categories) != 3 {
@@ -145,3 +330,158 @@ func TestGetCategories(t *testing.T) {
 		categoryMap[cat] = true
 	}
 }
+
+func TestGallery(t *testing.T) {
+	tests := []struct {
+		name     string
+		product  Product
+		expected []string
+	}{
+		{"no images", Product{}, nil},
+		{"main image only", Product{ImageURL: "/a.jpg"}, []string{"/a.jpg"}},
+		{"main image first", Product{ImageURL: "/a.jpg", Images: []string{"/b.jpg", "/c.jpg"}}, []string{"/a.jpg", "/b.jpg", "/c.jpg"}},
+		{"no main image", Product{Images: []string{"/b.jpg", ""}}, []string{"/b.jpg"}},
+	}
+
+	for _, tt := range tests {
+		got := tt.product.Gallery()
+		if len(got) != len(tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+				break
+			}
+		}
+	}
+}
+
+func TestAddImage(t *testing.T) {
+	store := NewProductStore()
+	product, _ := store.Add(Product{Name: "Mug", Price: Won(10000), Stock: 5})
+
+	first, err := store.AddImage(product.ID, "/media/a.jpg")
+	if err != nil || first.ImageURL != "/media/a.jpg" || len(first.Images) != 0 {
+		t.Fatalf("Expected first image to become the main image, got %+v, %v", first, err)
+	}
+
+	second, _ := store.AddImage(product.ID, "/media/b.jpg")
+	if second.ImageURL != "/media/a.jpg" || len(second.Images) != 1 || second.Images[0] != "/media/b.jpg" {
+		t.Errorf("Expected second image in the gallery, got %+v", second)
+	}
+
+	if _, err := store.AddImage(999, "/media/c.jpg"); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound, got %v", err)
+	}
+}
+
+func TestValidateProduct(t *testing.T) {
+	tests := []struct {
+		product Product
+		valid   bool
+	}{
+		{Product{Name: "Mug", Category: "Kitchen", Price: Won(10000)}, true},
+		{Product{Name: "Sample", Category: "Kitchen", Price: Won(0)}, true},
+		{Product{Name: " ", Category: "Kitchen", Price: Won(10000)}, false},
+		{Product{Name: "Mug", Price: Won(10000)}, false},
+		{Product{Name: "Mug", Category: "Kitchen", Price: Won(-1)}, false},
+	}
+
+	for _, tt := range tests {
+		if err := tt.product.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Validate(%+v) = %v, expected valid %v", tt.product, err, tt.valid)
+		}
+	}
+}
+
+func TestUpdateProduct(t *testing.T) {
+	store := NewProductStore()
+	product, _ := store.Add(Product{Name: "Mug", Category: "Kitchen", Price: Won(10000), Stock: 5, ImageURL: "/media/a.jpg"})
+
+	updated, err := store.Update(Product{ID: product.ID, Name: "Big Mug", Category: "Kitchen", Price: Won(12000), Tags: []string{"cup"}, Stock: 99})
+	if err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if updated.Name != "Big Mug" || updated.Price != Won(12000) || len(updated.Tags) != 1 {
+		t.Errorf("Expected details changed, got %+v", updated)
+	}
+	if updated.Stock != 5 || updated.ImageURL != "/media/a.jpg" {
+		t.Errorf("Expected stock and images kept, got %+v", updated)
+	}
+
+	if _, err := store.Update(Product{ID: product.ID, Category: "Kitchen"}); !errors.Is(err, ErrInvalidProduct) {
+		t.Errorf("Expected ErrInvalidProduct, got %v", err)
+	}
+	if _, err := store.Update(Product{ID: 999, Name: "Mug", Category: "Kitchen"}); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound, got %v", err)
+	}
+}
+
+func TestArchivedProductsHidden(t *testing.T) {
+	store := NewProductStore()
+	store.Add(Product{Name: "Mug", Category: "Kitchen", Price: Won(10000), Tags: []string{"cup"}})
+	lamp, _ := store.Add(Product{Name: "Lamp", Category: "Lighting", Price: Won(30000), Tags: []string{"light"}})
+
+	if _, err := store.SetArchived(lamp.ID, true); err != nil {
+		t.Fatalf("SetArchived() failed: %v", err)
+	}
+
+	if products := store.GetAll(); len(products) != 1 || products[0].Name != "Mug" {
+		t.Errorf("Expected only the mug on sale, got %+v", products)
+	}
+	if products := store.Search("lamp"); len(products) != 0 {
+		t.Errorf("Expected archived product not found, got %+v", products)
+	}
+	if products := store.FilterByCategory("Lighting"); len(products) != 0 {
+		t.Errorf("Expected archived product not in its category, got %+v", products)
+	}
+	if categories := store.GetCategories(); len(categories) != 1 || categories[0] != "Kitchen" {
+		t.Errorf("Expected only Kitchen, got %v", categories)
+	}
+	if facets := store.Facets(); len(facets.Tags) != 1 || facets.MaxPrice != Won(10000) {
+		t.Errorf("Expected facets of products on sale only, got %+v", facets)
+	}
+
+	// Admins still see it, and can put it back
+	if products := store.GetAllWithArchived(); len(products) != 2 {
+		t.Errorf("Expected both products, got %d", len(products))
+	}
+	if product, ok := store.GetByID(lamp.ID); !ok || !product.Archived {
+		t.Errorf("Expected archived product by ID, got %+v", product)
+	}
+	store.SetArchived(lamp.ID, false)
+	if products := store.GetAll(); len(products) != 2 {
+		t.Errorf("Expected lamp back on sale, got %d products", len(products))
+	}
+
+	if _, err := store.SetArchived(999, true); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound, got %v", err)
+	}
+}
+
+func TestRenameCategory(t *testing.T) {
+	store := NewProductStore()
+	store.Add(Product{Name: "Mug", Category: "Kitchen", Price: Won(10000)})
+	store.Add(Product{Name: "Plate", Category: "Kitchen", Price: Won(8000)})
+	store.Add(Product{Name: "Lamp", Category: "Lighting", Price: Won(30000)})
+
+	moved, err := store.RenameCategory("Kitchen", "Home")
+	if err != nil || moved != 2 {
+		t.Fatalf("Expected 2 products moved, got %d, %v", moved, err)
+	}
+	if products := store.FilterByCategory("Home"); len(products) != 2 {
+		t.Errorf("Expected 2 products in Home, got %d", len(products))
+	}
+
+	// Renaming to an existing category merges them
+	store.RenameCategory("Lighting", "Home")
+	if categories := store.GetCategories(); len(categories) != 1 || categories[0] != "Home" {
+		t.Errorf("Expected categories merged into Home, got %v", categories)
+	}
+
+	if _, err := store.RenameCategory("Home", " "); !errors.Is(err, ErrInvalidProduct) {
+		t.Errorf("Expected ErrInvalidProduct, got %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrPromotionNotFound is returned for an ID no promotion has
+	ErrPromotionNotFound = errors.New("promotion not found")
+	// ErrInvalidPromotion is returned for a promotion without a name, a
+	// product or category, a discount or an end after its start
+	ErrInvalidPromotion = errors.New("promotion needs a name, a product or category, a discount and an end after its start")
+)
+
+// Promotion puts a product, or every product in a category, on sale for a
+// while. It takes money off prices as coupons do off orders.
+type Promotion struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// ProductID is the product on sale, or zero for a whole category, its
+	// sub-categories included
+	ProductID int          `json:"productId,omitempty"`
+	Category  string       `json:"category,omitempty"`
+	Type      DiscountType `json:"type"`
+	Percent   int          `json:"percent,omitempty"`
+	Amount    Money        `json:"amount,omitzero"`
+	StartsAt  time.Time    `json:"startsAt"`
+	EndsAt    time.Time    `json:"endsAt"`
+}
+
+// Validate checks that the promotion names what is on sale and takes
+// something off for a time
+func (p Promotion) Validate() error {
+	switch {
+	case strings.TrimSpace(p.Name) == "":
+		return ErrInvalidPromotion
+	case (p.ProductID == 0) == (strings.TrimSpace(p.Category) == ""):
+		return ErrInvalidPromotion
+	case p.Type == DiscountPercent && (p.Percent <= 0 || p.Percent > 100):
+		return ErrInvalidPromotion
+	case p.Type == DiscountFixed && p.Amount.Amount <= 0:
+		return ErrInvalidPromotion
+	case p.Type != DiscountPercent && p.Type != DiscountFixed:
+		return ErrInvalidPromotion
+	case !p.EndsAt.After(p.StartsAt):
+		return ErrInvalidPromotion
+	}
+	return nil
+}
+
+// Active reports whether the promotion is on at now
+func (p Promotion) Active(now time.Time) bool {
+	return !now.Before(p.StartsAt) && now.Before(p.EndsAt)
+}
+
+// AppliesTo reports whether the promotion puts the product on sale
+func (p Promotion) AppliesTo(product Product) bool {
+	if p.ProductID != 0 {
+		return p.ProductID == product.ID
+	}
+	return InCategory(product.Category, p.Category)
+}
+
+// Apply returns price with the promotion's discount taken off. Percentage
+// discounts are rounded down to the minor unit, and no price goes below zero.
+func (p Promotion) Apply(price Money) Money {
+	var discount Money
+	switch p.Type {
+	case DiscountPercent:
+		discount = price.Percent(p.Percent)
+	case DiscountFixed:
+		discount = p.Amount
+	}
+	if discount.Cmp(price) > 0 {
+		return Money{Currency: price.Currency}
+	}
+	return price.Sub(discount)
+}
+
+// PromotionStore manages promotions with thread-safe operations
+type PromotionStore struct {
+	mu         sync.RWMutex
+	promotions map[int]Promotion
+	nextID     int
+	// changed is when a promotion was last added or removed
+	changed time.Time
+}
+
+// NewPromotionStore creates a new promotion store
+func NewPromotionStore() *PromotionStore {
+	return &PromotionStore{
+		promotions: make(map[int]Promotion),
+		nextID:     1,
+	}
+}
+
+// Add validates a promotion and stores it with an assigned ID
+func (s *PromotionStore) Add(promotion Promotion) (Promotion, error) {
+	if err := promotion.Validate(); err != nil {
+		return Promotion{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	promotion.ID = s.nextID
+	s.nextID++
+	s.promotions[promotion.ID] = promotion
+	s.changed = time.Now()
+
+	return promotion, nil
+}
+
+// Delete removes a promotion, ending it if it is on
+func (s *PromotionStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.promotions[id]; !exists {
+		return ErrPromotionNotFound
+	}
+	delete(s.promotions, id)
+	s.changed = time.Now()
+	return nil
+}
+
+// LastChange returns when prices last changed with the promotions, as of
+// now: the latest a promotion was added or removed, started or ended
+func (s *PromotionStore) LastChange(now time.Time) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	last := s.changed
+	for _, p := range s.promotions {
+		for _, at := range []time.Time{p.StartsAt, p.EndsAt} {
+			if at.After(last) && !at.After(now) {
+				last = at
+			}
+		}
+	}
+	return last
+}
+
+// GetAll returns every promotion, those ending soonest first
+func (s *PromotionStore) GetAll() []Promotion {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	promotions := make([]Promotion, 0, len(s.promotions))
+	for _, p := range s.promotions {
+		promotions = append(promotions, p)
+	}
+	slices.SortFunc(promotions, func(a, b Promotion) int {
+		return cmp.Or(a.EndsAt.Compare(b.EndsAt), cmp.Compare(a.ID, b.ID))
+	})
+	return promotions
+}
+
+// Best returns the promotion on at now that makes the product cheapest, if
+// any puts it on sale
+func (s *PromotionStore) Best(product Product, now time.Time) (Promotion, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var best Promotion
+	found := false
+	for _, p := range s.promotions {
+		if !p.Active(now) || !p.AppliesTo(product) {
+			continue
+		}
+		if c := p.Apply(product.Price).Cmp(best.Apply(product.Price)); !found || c < 0 || (c == 0 && p.ID < best.ID) {
+			best, found = p, true
+		}
+	}
+	return best, found
+}
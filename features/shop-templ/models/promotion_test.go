@@ -0,0 +1,146 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPromotionValidate(t *testing.T) {
+	now := time.Now()
+	valid := Promotion{Name: "Sale", Category: "Books", Type: DiscountPercent, Percent: 10, StartsAt: now, EndsAt: now.Add(time.Hour)}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected a valid promotion, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(p *Promotion)
+	}{
+		{"no name", func(p *Promotion) { p.Name = " " }},
+		{"no target", func(p *Promotion) { p.Category = "" }},
+		{"product and category", func(p *Promotion) { p.ProductID = 1 }},
+		{"no percent", func(p *Promotion) { p.Percent = 0 }},
+		{"over 100 percent", func(p *Promotion) { p.Percent = 101 }},
+		{"no amount", func(p *Promotion) { p.Type = DiscountFixed }},
+		{"unknown type", func(p *Promotion) { p.Type = "bogo" }},
+		{"ends before start", func(p *Promotion) { p.EndsAt = p.StartsAt }},
+	}
+
+	for _, tt := range tests {
+		p := valid
+		tt.change(&p)
+		if err := p.Validate(); !errors.Is(err, ErrInvalidPromotion) {
+			t.Errorf("%s: expected ErrInvalidPromotion, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestPromotionApply(t *testing.T) {
+	tests := []struct {
+		name      string
+		promotion Promotion
+		price     Money
+		want      Money
+	}{
+		{"percent", Promotion{Type: DiscountPercent, Percent: 20}, Won(35000), Won(28000)},
+		{"percent rounds down", Promotion{Type: DiscountPercent, Percent: 15}, Won(999), Won(850)},
+		{"fixed", Promotion{Type: DiscountFixed, Amount: Won(5000)}, Won(35000), Won(30000)},
+		{"not below zero", Promotion{Type: DiscountFixed, Amount: Won(5000)}, Won(3000), Won(0)},
+	}
+
+	for _, tt := range tests {
+		if got := tt.promotion.Apply(tt.price); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestPromotionActive(t *testing.T) {
+	now := time.Now()
+	p := Promotion{StartsAt: now, EndsAt: now.Add(time.Hour)}
+
+	if p.Active(now.Add(-time.Second)) {
+		t.Error("Expected a promotion to be off before it starts")
+	}
+	if !p.Active(now) || !p.Active(now.Add(59*time.Minute)) {
+		t.Error("Expected a promotion to be on from its start")
+	}
+	if p.Active(now.Add(time.Hour)) {
+		t.Error("Expected a promotion to be off once it ends")
+	}
+}
+
+func TestPromotionStoreBest(t *testing.T) {
+	store := NewPromotionStore()
+	now := time.Now()
+	product := Product{ID: 1, Category: "Books", Price: Won(20000)}
+
+	store.Add(Promotion{Name: "Books", Category: "Books", Type: DiscountPercent, Percent: 10, StartsAt: now, EndsAt: now.Add(time.Hour)})
+	flash, _ := store.Add(Promotion{Name: "Flash", ProductID: 1, Type: DiscountFixed, Amount: Won(5000), StartsAt: now, EndsAt: now.Add(time.Minute)})
+	store.Add(Promotion{Name: "Later", ProductID: 1, Type: DiscountPercent, Percent: 50, StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)})
+	store.Add(Promotion{Name: "Music", Category: "Music", Type: DiscountPercent, Percent: 90, StartsAt: now, EndsAt: now.Add(time.Hour)})
+
+	if best, ok := store.Best(product, now); !ok || best.ID != flash.ID {
+		t.Errorf("Expected the promotion making the product cheapest, got %+v, %v", best, ok)
+	}
+	if best, ok := store.Best(product, now.Add(time.Minute)); !ok || best.Name != "Books" {
+		t.Errorf("Expected the category sale once the flash sale ends, got %+v, %v", best, ok)
+	}
+	if _, ok := store.Best(Product{ID: 2, Category: "Games", Price: Won(20000)}, now); ok {
+		t.Error("Expected no promotion for a product none applies to")
+	}
+
+	if err := store.Delete(flash.ID); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if err := store.Delete(flash.ID); !errors.Is(err, ErrPromotionNotFound) {
+		t.Errorf("Expected ErrPromotionNotFound, got %v", err)
+	}
+	if best, _ := store.Best(product, now); best.Name != "Books" {
+		t.Errorf("Expected a deleted promotion to end, got %+v", best)
+	}
+
+	if got := store.GetAll(); len(got) != 3 || got[0].Name != "Books" || got[2].Name != "Later" {
+		t.Errorf("Expected promotions ending soonest first, got %+v", got)
+	}
+	if _, err := store.Add(Promotion{Name: "Bad"}); !errors.Is(err, ErrInvalidPromotion) {
+		t.Errorf("Expected ErrInvalidPromotion, got %v", err)
+	}
+}
+
+func TestProductStoreSales(t *testing.T) {
+	now := time.Now()
+	promotions := NewPromotionStore()
+	store := NewProductStore()
+	store.now = func() time.Time { return now }
+	store.UsePromotions(promotions)
+
+	book, _ := store.Add(Product{Name: "Book", Category: "Books", Price: Won(20000), Stock: 5})
+	store.Add(Product{Name: "Album", Category: "Music", Price: Won(15000), Stock: 5})
+	promotions.Add(Promotion{Name: "Books", Category: "Books", Type: DiscountPercent, Percent: 50, StartsAt: now, EndsAt: now.Add(time.Hour)})
+
+	product, _ := store.GetByID(book.ID)
+	if product.Sale == nil || product.CurrentPrice() != Won(10000) || product.Price != Won(20000) {
+		t.Errorf("Expected the book on sale for 10000, got %+v", product)
+	}
+	if got := store.Filter("", nil, Money{}, Won(12000), nil); len(got) != 1 || got[0].ID != book.ID {
+		t.Errorf("Expected filters to use the sale price, got %+v", got)
+	}
+
+	items, err := store.TakeStock("cart", []CartItem{{Product: book, Quantity: 1}})
+	if err != nil {
+		t.Fatalf("TakeStock() failed: %v", err)
+	}
+	if items[0].Price != Won(10000) {
+		t.Errorf("Expected the order charged the sale price, got %v", items[0].Price)
+	}
+	if stored := store.products[book.ID]; stored.Sale != nil {
+		t.Errorf("Expected the store not to keep the sale, got %+v", stored.Sale)
+	}
+
+	now = now.Add(time.Hour)
+	if product, _ := store.GetByID(book.ID); product.Sale != nil || product.CurrentPrice() != Won(20000) {
+		t.Errorf("Expected the price back once the sale ends, got %+v", product)
+	}
+}
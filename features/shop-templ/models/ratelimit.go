@@ -0,0 +1,130 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit caps how often one client may make the requests of a group of
+// routes: Requests of them at once, and then one more every Seconds /
+// Requests seconds as the allowance refills
+type RateLimit struct {
+	Requests int `json:"requests"`
+	Seconds  int `json:"seconds"`
+	// RejectBots turns away clients that look like bots, see LooksLikeBot
+	RejectBots bool `json:"rejectBots,omitempty"`
+}
+
+// RateLimits are the rate limits of groups of routes, by group name.
+// Routes of a group without one aren't limited.
+type RateLimits map[string]RateLimit
+
+// DefaultRateLimits leave room for a shopper typing into the search box
+// and tapping through the cart, but not for scripts hammering them
+var DefaultRateLimits = RateLimits{
+	"search":      {Requests: 60, Seconds: 60},
+	"suggest":     {Requests: 120, Seconds: 60},
+	"add_to_cart": {Requests: 30, Seconds: 60, RejectBots: true},
+	"cart":        {Requests: 60, Seconds: 60},
+}
+
+// LoadRateLimits reads rate limits from a JSON file holding an object of
+// them by group name, such as {"search": {"requests": 60, "seconds": 60}}
+func LoadRateLimits(path string) (RateLimits, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rate limits: %w", err)
+	}
+
+	var limits RateLimits
+	if err := json.Unmarshal(data, &limits); err != nil {
+		return nil, fmt.Errorf("decode rate limits: %w", err)
+	}
+	for group, limit := range limits {
+		if limit.Requests < 1 || limit.Seconds < 1 {
+			return nil, fmt.Errorf("rate limit %q needs at least 1 request per at least 1 second", group)
+		}
+	}
+
+	return limits, nil
+}
+
+// interval is how long the allowance takes to refill by one request
+func (l RateLimit) interval() time.Duration {
+	return time.Duration(l.Seconds) * time.Second / time.Duration(l.Requests)
+}
+
+// rateLimitSweepEvery is how often a rate limiter forgets the clients whose
+// allowance has refilled
+const rateLimitSweepEvery = time.Minute
+
+// RateLimiter keeps track of how much of a rate limit each client has
+// used, by a key naming the client
+type RateLimiter struct {
+	limit RateLimit
+	mu    sync.Mutex
+	// full is when each client's allowance is full again. Clients not in
+	// it have their whole allowance.
+	full  map[string]time.Time
+	swept time.Time
+}
+
+// NewRateLimiter creates a rate limiter for limit
+func NewRateLimiter(limit RateLimit) *RateLimiter {
+	return &RateLimiter{limit: limit, full: make(map[string]time.Time)}
+}
+
+// Allow uses up one request of the client's allowance at now, if there is
+// any left. If not, it says how long until there is.
+func (l *RateLimiter) Allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.swept) >= rateLimitSweepEvery {
+		for k, full := range l.full {
+			if !full.After(now) {
+				delete(l.full, k)
+			}
+		}
+		l.swept = now
+	}
+
+	// The allowance is a window of Seconds ending when it is full again;
+	// every request pushes that point back by one interval
+	full := l.full[key]
+	if full.Before(now) {
+		full = now
+	}
+	window := time.Duration(l.limit.Seconds) * time.Second
+	next := full.Add(l.limit.interval())
+	if over := next.Sub(now) - window; over > 0 {
+		return false, over
+	}
+	l.full[key] = next
+	return true, 0
+}
+
+// botUserAgents are parts of the user agents of crawlers and scripts
+// rather than browsers
+var botUserAgents = []string{"bot", "crawl", "spider", "slurp", "scrapy", "headless", "python-requests", "python-urllib", "go-http-client", "wget"}
+
+// LooksLikeBot reports whether a client with the user agent is likely a
+// bot rather than a shopper: it sends none, or names a crawler or a
+// scripting library. Apps and curl are let through; the rate limits
+// still hold them back.
+func LooksLikeBot(userAgent string) bool {
+	userAgent = strings.ToLower(strings.TrimSpace(userAgent))
+	if userAgent == "" {
+		return true
+	}
+	for _, bot := range botUserAgents {
+		if strings.Contains(userAgent, bot) {
+			return true
+		}
+	}
+	return false
+}
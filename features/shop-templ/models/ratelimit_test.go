@@ -0,0 +1,84 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(RateLimit{Requests: 3, Seconds: 6})
+	now := time.Date(2025, time.March, 14, 9, 0, 0, 0, time.UTC)
+
+	for i := range 3 {
+		if ok, _ := limiter.Allow("a", now); !ok {
+			t.Fatalf("Expected request %d of the burst to be allowed", i+1)
+		}
+	}
+	ok, wait := limiter.Allow("a", now)
+	if ok || wait != 2*time.Second {
+		t.Fatalf("Expected the 4th request to wait 2s, got %v, %v", ok, wait)
+	}
+	if ok, _ := limiter.Allow("b", now); !ok {
+		t.Error("Expected another client to have its own allowance")
+	}
+
+	// One request comes back every 2 seconds
+	if ok, _ := limiter.Allow("a", now.Add(2*time.Second)); !ok {
+		t.Error("Expected a request to be allowed once one came back")
+	}
+	if ok, _ := limiter.Allow("a", now.Add(2*time.Second)); ok {
+		t.Error("Expected only one request to have come back")
+	}
+	for i := range 3 {
+		if ok, _ := limiter.Allow("a", now.Add(time.Minute)); !ok {
+			t.Fatalf("Expected the whole burst back after a while, request %d refused", i+1)
+		}
+	}
+
+	// Clients whose allowance is full again are forgotten
+	limiter.Allow("c", now.Add(time.Minute))
+	limiter.Allow("d", now.Add(3*time.Minute))
+	if len(limiter.full) != 1 {
+		t.Errorf("Expected only the latest client to be remembered, got %v", limiter.full)
+	}
+}
+
+func TestLoadRateLimits(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "limits.json")
+	os.WriteFile(path, []byte(`{"search": {"requests": 10, "seconds": 60}, "add_to_cart": {"requests": 5, "seconds": 60, "rejectBots": true}}`), 0o644)
+
+	limits, err := LoadRateLimits(path)
+	if err != nil {
+		t.Fatalf("LoadRateLimits() failed: %v", err)
+	}
+	if len(limits) != 2 || limits["search"].Requests != 10 || !limits["add_to_cart"].RejectBots {
+		t.Errorf("Unexpected limits: %+v", limits)
+	}
+
+	os.WriteFile(path, []byte(`{"cart": {"requests": 0, "seconds": 60}}`), 0o644)
+	if _, err := LoadRateLimits(path); err == nil {
+		t.Error("Expected a limit of no requests to be rejected")
+	}
+	if _, err := LoadRateLimits(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Expected a missing file to be an error")
+	}
+}
+
+func TestLooksLikeBot(t *testing.T) {
+	for userAgent, want := range map[string]bool{
+		"": true,
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Safari/604.1": false,
+		"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)":                 true,
+		"Mozilla/5.0 HeadlessChrome/120.0":                                                         true,
+		"python-requests/2.31":                                                                     true,
+		"curl/8.4.0":                                                                               false,
+		"ShopApp/1.2 (iOS)":                                                                        false,
+	} {
+		if got := LooksLikeBot(userAgent); got != want {
+			t.Errorf("LooksLikeBot(%q) = %v, want %v", userAgent, got, want)
+		}
+	}
+}
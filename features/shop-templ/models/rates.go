@@ -0,0 +1,193 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrNoRate is returned when there is no exchange rate between two currencies
+var ErrNoRate = errors.New("no exchange rate")
+
+// RateProvider supplies exchange rates between currencies
+type RateProvider interface {
+	// Rate returns how many units of to one unit of from buys
+	Rate(ctx context.Context, from, to Currency) (float64, error)
+}
+
+// StaticRates are fixed exchange rates, given as how many units of each
+// currency one unit of Base buys. Rates between two other currencies are
+// worked out through Base.
+type StaticRates struct {
+	Base  Currency             `json:"base"`
+	Rates map[Currency]float64 `json:"rates"`
+}
+
+// DefaultRates are approximate rates for the won, for running without an
+// exchange rate service
+var DefaultRates = StaticRates{
+	Base: KRW,
+	Rates: map[Currency]float64{
+		USD: 0.00072,
+		EUR: 0.00066,
+		JPY: 0.11,
+	},
+}
+
+// Rate returns how many units of to one unit of from buys
+func (s StaticRates) Rate(_ context.Context, from, to Currency) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	fromRate, ok := s.rate(from)
+	if !ok {
+		return 0, fmt.Errorf("%w from %s", ErrNoRate, from)
+	}
+	toRate, ok := s.rate(to)
+	if !ok {
+		return 0, fmt.Errorf("%w to %s", ErrNoRate, to)
+	}
+	return toRate / fromRate, nil
+}
+
+// rate returns how many units of currency one unit of Base buys
+func (s StaticRates) rate(currency Currency) (float64, bool) {
+	if currency == s.Base {
+		return 1, true
+	}
+	rate, ok := s.Rates[currency]
+	return rate, ok && rate > 0
+}
+
+// HTTPRates fetches exchange rates from a web service answering with JSON
+// in the shape of StaticRates, such as
+//
+//	{"base": "KRW", "rates": {"USD": 0.00072, "EUR": 0.00066}}
+//
+// Rates are cached for a while. If a later fetch fails, the last rates
+// fetched are used until one succeeds.
+type HTTPRates struct {
+	endpoint string
+	ttl      time.Duration
+	client   *http.Client
+	now      func() time.Time
+
+	mu      sync.Mutex
+	rates   StaticRates
+	fetched time.Time
+}
+
+// NewHTTPRates creates a provider fetching rates from endpoint at most
+// once every ttl
+func NewHTTPRates(endpoint string, ttl time.Duration) *HTTPRates {
+	return &HTTPRates{
+		endpoint: endpoint,
+		ttl:      ttl,
+		client:   &http.Client{Timeout: 5 * time.Second},
+		now:      time.Now,
+	}
+}
+
+// Rate returns how many units of to one unit of from buys
+func (h *HTTPRates) Rate(ctx context.Context, from, to Currency) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	rates, err := h.current(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return rates.Rate(ctx, from, to)
+}
+
+// current returns the cached rates, fetching them again once they are
+// older than the ttl
+func (h *HTTPRates) current(ctx context.Context) (StaticRates, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.fetched.IsZero() && h.now().Sub(h.fetched) < h.ttl {
+		return h.rates, nil
+	}
+
+	rates, err := h.fetch(ctx)
+	if err != nil {
+		if h.fetched.IsZero() {
+			return StaticRates{}, err
+		}
+		log.Printf("%v; using rates from %s", err, h.fetched.Format(time.RFC3339))
+		// Don't ask again on every request while the service is down
+		h.fetched = h.now()
+		return h.rates, nil
+	}
+
+	h.rates, h.fetched = rates, h.now()
+	return rates, nil
+}
+
+// fetch asks the service for the latest rates
+func (h *HTTPRates) fetch(ctx context.Context) (StaticRates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.endpoint, nil)
+	if err != nil {
+		return StaticRates{}, err
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return StaticRates{}, fmt.Errorf("exchange rates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return StaticRates{}, fmt.Errorf("exchange rates: unexpected response (%s)", resp.Status)
+	}
+
+	var rates StaticRates
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&rates); err != nil {
+		return StaticRates{}, fmt.Errorf("exchange rates: %w", err)
+	}
+	if rates.Base == "" || len(rates.Rates) == 0 {
+		return StaticRates{}, errors.New("exchange rates: response has no rates")
+	}
+	return rates, nil
+}
+
+// Display is how prices are shown to a shopper: amounts in DefaultCurrency
+// converted to Currency at Rate. The zero Display shows them unconverted.
+type Display struct {
+	Currency Currency
+	Rate     float64
+}
+
+// Price returns a catalog amount as the shopper sees it
+func (d Display) Price(m Money) Money {
+	if d.Converts() {
+		return m.Convert(d.Currency, d.Rate)
+	}
+	return m
+}
+
+// Converts reports whether prices are shown in another currency than
+// the one they are charged in
+func (d Display) Converts() bool {
+	return d.Currency != "" && d.Currency != DefaultCurrency
+}
+
+type displayContextKey struct{}
+
+// ContextWithDisplay returns a copy of ctx carrying how to show prices
+func ContextWithDisplay(ctx context.Context, display Display) context.Context {
+	return context.WithValue(ctx, displayContextKey{}, display)
+}
+
+// DisplayFromContext returns how to show prices, unconverted if ctx
+// doesn't say
+func DisplayFromContext(ctx context.Context) Display {
+	display, _ := ctx.Value(displayContextKey{}).(Display)
+	return display
+}
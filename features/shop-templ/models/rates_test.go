@@ -0,0 +1,104 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStaticRates(t *testing.T) {
+	rates := StaticRates{Base: KRW, Rates: map[Currency]float64{USD: 0.0008, EUR: 0.0004}}
+	ctx := context.Background()
+
+	tests := []struct {
+		from, to Currency
+		want     float64
+	}{
+		{KRW, USD, 0.0008},
+		{USD, KRW, 1250},
+		{USD, EUR, 0.5},
+		{EUR, EUR, 1},
+	}
+
+	for _, tt := range tests {
+		got, err := rates.Rate(ctx, tt.from, tt.to)
+		if err != nil || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s to %s: expected %v, got %v, %v", tt.from, tt.to, tt.want, got, err)
+		}
+	}
+
+	if _, err := rates.Rate(ctx, KRW, JPY); !errors.Is(err, ErrNoRate) {
+		t.Errorf("Expected ErrNoRate, got %v", err)
+	}
+}
+
+func TestHTTPRates(t *testing.T) {
+	requests := 0
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if failing {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, `{"base": "KRW", "rates": {"USD": 0.000%d}}`, 700+requests)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	rates := NewHTTPRates(server.URL, time.Hour)
+	rates.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	rate, err := rates.Rate(ctx, KRW, USD)
+	if err != nil || rate != 0.000701 {
+		t.Fatalf("Expected the fetched rate, got %v, %v", rate, err)
+	}
+
+	if rate, _ := rates.Rate(ctx, KRW, USD); rate != 0.000701 || requests != 1 {
+		t.Errorf("Expected the cached rate without asking again, got %v after %d requests", rate, requests)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if rate, _ := rates.Rate(ctx, KRW, USD); rate != 0.000702 {
+		t.Errorf("Expected fresh rates after the ttl, got %v", rate)
+	}
+
+	failing = true
+	now = now.Add(2 * time.Hour)
+	if rate, err := rates.Rate(ctx, KRW, USD); err != nil || rate != 0.000702 {
+		t.Errorf("Expected the last rates while the service is down, got %v, %v", rate, err)
+	}
+}
+
+func TestHTTPRatesUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	if _, err := NewHTTPRates(server.URL, time.Hour).Rate(context.Background(), KRW, USD); err == nil {
+		t.Error("Expected an error with no rates fetched yet")
+	}
+}
+
+func TestDisplay(t *testing.T) {
+	if got := (Display{}).Price(Won(129000)); got != Won(129000) {
+		t.Errorf("Expected the zero Display to leave prices alone, got %v", got)
+	}
+
+	display := Display{Currency: USD, Rate: 0.00072}
+	if got := display.Price(Won(129000)); got != usd(9288) {
+		t.Errorf("Expected $92.88, got %v", got)
+	}
+
+	ctx := ContextWithDisplay(context.Background(), display)
+	if DisplayFromContext(ctx) != display || DisplayFromContext(context.Background()).Converts() {
+		t.Error("Expected the display to come back from the context")
+	}
+}
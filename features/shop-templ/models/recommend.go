@@ -0,0 +1,121 @@
+package models
+
+import (
+	"cmp"
+	"slices"
+)
+
+// How much each thing two products have in common counts towards
+// recommending one for the other. Being bought together says the most
+// about what a customer will want next.
+const (
+	boughtTogetherWeight = 3
+	sameCategoryWeight   = 2
+	sharedTagWeight      = 1
+)
+
+// CoPurchases counts, for each pair of products, how many orders had both.
+// Counts are kept both ways round, so together[a][b] == together[b][a].
+type CoPurchases map[int]map[int]int
+
+// Count returns how many orders had both products
+func (c CoPurchases) Count(a, b int) int {
+	return c[a][b]
+}
+
+// BoughtTogether counts the products bought together in the orders that
+// went through, leaving out those not paid for, cancelled or refunded.
+// Variants of a product count as the product.
+func (s *OrderStore) BoughtTogether() CoPurchases {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	together := make(CoPurchases)
+	for _, order := range s.orders {
+		switch order.Status {
+		case OrderPending, OrderCancelled, OrderRefunded:
+			continue
+		}
+
+		var ids []int
+		for _, item := range order.Items {
+			if !slices.Contains(ids, item.ProductID) {
+				ids = append(ids, item.ProductID)
+			}
+		}
+		for _, a := range ids {
+			for _, b := range ids {
+				if a == b {
+					continue
+				}
+				if together[a] == nil {
+					together[a] = make(map[int]int)
+				}
+				together[a][b]++
+			}
+		}
+	}
+	return together
+}
+
+// Recommend returns up to n products a customer looking at the given ones
+// may also like: those bought together with them, in the same category or
+// sharing tags. The best matches come first, then the best sellers.
+// Products already given, archived or sold out aren't recommended.
+func (s *ProductStore) Recommend(productIDs []int, together CoPurchases, n int) []Product {
+	if len(productIDs) == 0 || n <= 0 {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var given []Product
+	for _, id := range productIDs {
+		if p, exists := s.products[id]; exists {
+			given = append(given, p)
+		}
+	}
+
+	type scored struct {
+		product Product
+		score   int
+	}
+	var candidates []scored
+	for _, p := range s.getAllUnlocked() {
+		if slices.Contains(productIDs, p.ID) || p.Stock <= 0 {
+			continue
+		}
+		score := 0
+		for _, g := range given {
+			score += relatedness(g, p, together)
+		}
+		if score > 0 {
+			candidates = append(candidates, scored{p, score})
+		}
+	}
+
+	slices.SortStableFunc(candidates, func(a, b scored) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), cmp.Compare(b.product.Sold, a.product.Sold))
+	})
+	recommended := make([]Product, 0, min(len(candidates), n))
+	for _, c := range candidates[:min(len(candidates), n)] {
+		recommended = append(recommended, c.product)
+	}
+	return recommended
+}
+
+// relatedness scores how much a customer looking at one product may like
+// another
+func relatedness(from, to Product, together CoPurchases) int {
+	score := together.Count(from.ID, to.ID) * boughtTogetherWeight
+	if from.Category != "" && from.Category == to.Category {
+		score += sameCategoryWeight
+	}
+	for _, tag := range to.Tags {
+		if slices.Contains(from.Tags, tag) {
+			score += sharedTagWeight
+		}
+	}
+	return score
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+)
+
+func productNames(products []Product) []string {
+	names := make([]string, len(products))
+	for i, p := range products {
+		names[i] = p.Name
+	}
+	return names
+}
+
+func TestBoughtTogether(t *testing.T) {
+	orders := NewOrderStore()
+	orders.Add(Order{Status: OrderPaid, Items: []OrderItem{
+		{ProductID: 1, Quantity: 1}, {ProductID: 2, Quantity: 1},
+		{ProductID: 2, VariantID: 1, Quantity: 1}, {ProductID: 3, Quantity: 1},
+	}})
+	orders.Add(Order{Status: OrderDelivered, Items: []OrderItem{{ProductID: 1, Quantity: 2}, {ProductID: 2, Quantity: 1}}})
+	orders.Add(Order{Status: OrderPending, Items: []OrderItem{{ProductID: 1, Quantity: 1}, {ProductID: 3, Quantity: 1}}})
+	orders.Add(Order{Status: OrderCancelled, Items: []OrderItem{{ProductID: 1, Quantity: 1}, {ProductID: 3, Quantity: 1}}})
+
+	together := orders.BoughtTogether()
+	if together.Count(1, 2) != 2 || together.Count(2, 1) != 2 {
+		t.Errorf("Expected 1 and 2 bought together twice, got %v", together)
+	}
+	if together.Count(1, 3) != 1 {
+		t.Errorf("Expected only the paid order to count for 1 and 3, got %d", together.Count(1, 3))
+	}
+	if together.Count(2, 2) != 0 || together.Count(4, 1) != 0 {
+		t.Errorf("Expected no counts for a product with itself or one never bought, got %v", together)
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	store := NewProductStore()
+	earbuds, _ := store.Add(Product{Name: "무선 이어폰", Category: "전자제품", Tags: []string{"audio", "wireless"}, Stock: 5})
+	speaker, _ := store.Add(Product{Name: "블루투스 스피커", Category: "전자제품", Tags: []string{"audio"}, Stock: 5})
+	mouse, _ := store.Add(Product{Name: "무선 마우스", Category: "전자제품", Tags: []string{"wireless"}, Stock: 5, Sold: 9})
+	pouch, _ := store.Add(Product{Name: "이어폰 케이스", Category: "패션", Stock: 5})
+	store.Add(Product{Name: "텀블러", Category: "생활용품", Stock: 5})
+	store.Add(Product{Name: "헤드폰", Category: "전자제품", Tags: []string{"audio"}, Stock: 0})
+	archived, _ := store.Add(Product{Name: "유선 이어폰", Category: "전자제품", Tags: []string{"audio"}, Stock: 5})
+	store.SetArchived(archived.ID, true)
+
+	// Category and tags only: speaker and mouse tie, the best seller first
+	got := productNames(store.Recommend([]int{earbuds.ID}, nil, 5))
+	if fmt.Sprint(got) != "[무선 마우스 블루투스 스피커]" {
+		t.Errorf("Unexpected recommendations: %v", got)
+	}
+
+	// Being bought together counts for the most
+	together := CoPurchases{earbuds.ID: {pouch.ID: 2}}
+	got = productNames(store.Recommend([]int{earbuds.ID}, together, 5))
+	if fmt.Sprint(got) != "[이어폰 케이스 무선 마우스 블루투스 스피커]" {
+		t.Errorf("Unexpected recommendations with co-purchases: %v", got)
+	}
+
+	// Several products add up, and none of them is recommended
+	got = productNames(store.Recommend([]int{speaker.ID, mouse.ID}, nil, 1))
+	if fmt.Sprint(got) != "[무선 이어폰]" {
+		t.Errorf("Unexpected recommendations for two products: %v", got)
+	}
+
+	if got := store.Recommend(nil, together, 5); len(got) != 0 {
+		t.Errorf("Expected nothing recommended for no products, got %v", productNames(got))
+	}
+}
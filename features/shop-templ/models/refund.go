@@ -0,0 +1,145 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+var (
+	// ErrRefundAmount is returned for a refund of nothing, or of more than
+	// is left to give back
+	ErrRefundAmount = errors.New("refund amount must be more than zero and at most what is left to refund")
+	// ErrRefundItems is returned for putting back more of an item than the
+	// order has left to put back
+	ErrRefundItems = errors.New("can't restock more items than were ordered")
+)
+
+// RefundLine is payment given back on an order
+type RefundLine struct {
+	Amount Money  `json:"amount"`
+	Reason string `json:"reason,omitempty"`
+	// Restocked are the items put back in stock with the refund
+	Restocked []OrderItem `json:"restocked,omitempty"`
+	At        time.Time   `json:"at"`
+}
+
+// Refunded returns how much of the order's payment has been given back
+func (o Order) Refunded() Money {
+	total := NewMoney(0, o.Total.Currency)
+	for _, refund := range o.Refunds {
+		total = total.Add(refund.Amount)
+	}
+	return total
+}
+
+// Refundable returns how much of the order's payment is left to give back
+func (o Order) Refundable() Money {
+	return o.Total.Sub(o.Refunded())
+}
+
+// Returnable returns the order's items less those already put back in
+// stock, leaving out items with none left
+func (o Order) Returnable() []OrderItem {
+	restocked := make(map[ItemKey]int)
+	for _, refund := range o.Refunds {
+		for _, item := range refund.Restocked {
+			restocked[item.Key()] += item.Quantity
+		}
+	}
+
+	var items []OrderItem
+	for _, item := range o.Items {
+		item.Quantity -= restocked[item.Key()]
+		if item.Quantity > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// CheckRefund reports whether the refund could be made on the order: the
+// order is in a status that allows refunds, the amount is no more than is
+// left and the restocked items are no more than were ordered
+func (o Order) CheckRefund(refund RefundLine) error {
+	if !o.CanBecome(OrderRefunded) {
+		return fmt.Errorf("%w: %s orders can't be refunded", ErrOrderStatus, o.Status)
+	}
+	if refund.Amount.Amount <= 0 || refund.Amount.Cmp(o.Refundable()) > 0 {
+		return fmt.Errorf("%w: %v of %v", ErrRefundAmount, refund.Amount, o.Refundable())
+	}
+	returnable := o.Returnable()
+	for _, item := range refund.Restocked {
+		i := slices.IndexFunc(returnable, func(r OrderItem) bool { return r.Key() == item.Key() })
+		if item.Quantity <= 0 || i < 0 || item.Quantity > returnable[i].Quantity {
+			return fmt.Errorf("%w: %d of %s", ErrRefundItems, item.Quantity, item.Key())
+		}
+		returnable[i].Quantity -= item.Quantity
+	}
+	return nil
+}
+
+// AddRefund records payment given back on an order. Once all of it has
+// been given back the order is refunded.
+func (s *OrderStore) AddRefund(id int, refund RefundLine) (Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, exists := s.orders[id]
+	if !exists {
+		return Order{}, ErrOrderNotFound
+	}
+	if err := order.CheckRefund(refund); err != nil {
+		return order, err
+	}
+
+	refund.At = time.Now()
+	order.Refunds = append(slices.Clip(order.Refunds), refund)
+	refunded := order.Refundable().IsZero()
+	if refunded {
+		order.moveTo(OrderRefunded)
+	}
+	if err := s.putUnlocked(order); err != nil {
+		return Order{}, err
+	}
+	if refunded {
+		s.statusChanged(order)
+	}
+	return order, nil
+}
+
+// Cancel calls off an order that hasn't shipped, recording refund as given
+// back if it isn't zero. It returns the order and the items to put back in
+// stock: everything not put back already.
+func (s *OrderStore) Cancel(id int, refund Money) (Order, []OrderItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, exists := s.orders[id]
+	if !exists {
+		return Order{}, nil, ErrOrderNotFound
+	}
+	if !order.CanBecome(OrderCancelled) {
+		return order, nil, fmt.Errorf("%w: %s to %s", ErrOrderStatus, order.Status, OrderCancelled)
+	}
+	if refund.Amount < 0 || refund.Cmp(order.Refundable()) > 0 {
+		return order, nil, fmt.Errorf("%w: %v of %v", ErrRefundAmount, refund, order.Refundable())
+	}
+
+	restock := order.Returnable()
+	if !refund.IsZero() {
+		order.Refunds = append(slices.Clip(order.Refunds), RefundLine{
+			Amount:    refund,
+			Reason:    "주문 취소",
+			Restocked: restock,
+			At:        time.Now(),
+		})
+	}
+	order.moveTo(OrderCancelled)
+	if err := s.putUnlocked(order); err != nil {
+		return Order{}, nil, err
+	}
+	s.statusChanged(order)
+	return order, restock, nil
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+// paidOrder stores an order for two of product 1 and one of product 2,
+// totalling ₩50,000, and marks it paid
+func paidOrder(t *testing.T, store *OrderStore) Order {
+	t.Helper()
+	order, _ := store.Add(Order{Items: []OrderItem{
+		{ProductID: 1, Name: "P1", Price: Won(20000), Quantity: 2},
+		{ProductID: 2, Name: "P2", Price: Won(10000), Quantity: 1},
+	}})
+	order, err := store.SetStatus(order.ID, OrderPaid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return order
+}
+
+func TestOrderStoreAddRefund(t *testing.T) {
+	store := NewOrderStore()
+	order := paidOrder(t, store)
+
+	order, err := store.AddRefund(order.ID, RefundLine{
+		Amount:    Won(20000),
+		Reason:    "불량",
+		Restocked: []OrderItem{{ProductID: 1, Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatalf("AddRefund() failed: %v", err)
+	}
+	if order.Status != OrderPaid || order.Refunded() != Won(20000) || order.Refundable() != Won(30000) {
+		t.Errorf("Expected a partial refund leaving ₩30,000, got %s with %v refunded", order.Status, order.Refunded())
+	}
+	if returnable := order.Returnable(); len(returnable) != 2 || returnable[0].Quantity != 1 {
+		t.Errorf("Expected one of product 1 left to restock, got %+v", returnable)
+	}
+
+	order, err = store.AddRefund(order.ID, RefundLine{Amount: Won(30000)})
+	if err != nil || order.Status != OrderRefunded {
+		t.Errorf("Expected the order refunded once all of it was given back, got %s, %v", order.Status, err)
+	}
+}
+
+func TestOrderStoreAddRefundRejects(t *testing.T) {
+	store := NewOrderStore()
+	order := paidOrder(t, store)
+
+	tests := []struct {
+		name   string
+		refund RefundLine
+		err    error
+	}{
+		{"nothing", RefundLine{Amount: Won(0)}, ErrRefundAmount},
+		{"too much", RefundLine{Amount: Won(50001)}, ErrRefundAmount},
+		{"too many items", RefundLine{Amount: Won(1000), Restocked: []OrderItem{{ProductID: 2, Quantity: 2}}}, ErrRefundItems},
+		{"not ordered", RefundLine{Amount: Won(1000), Restocked: []OrderItem{{ProductID: 3, Quantity: 1}}}, ErrRefundItems},
+	}
+
+	for _, tt := range tests {
+		if _, err := store.AddRefund(order.ID, tt.refund); !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.err, err)
+		}
+	}
+
+	pending, _ := store.Add(Order{Items: []OrderItem{{ProductID: 1, Price: Won(1000), Quantity: 1}}})
+	if _, err := store.AddRefund(pending.ID, RefundLine{Amount: Won(1000)}); !errors.Is(err, ErrOrderStatus) {
+		t.Errorf("Expected an unpaid order not to be refunded, got %v", err)
+	}
+}
+
+func TestOrderStoreCancel(t *testing.T) {
+	store := NewOrderStore()
+	order := paidOrder(t, store)
+	store.AddRefund(order.ID, RefundLine{Amount: Won(10000), Restocked: []OrderItem{{ProductID: 2, Quantity: 1}}})
+
+	order, restock, err := store.Cancel(order.ID, Won(40000))
+	if err != nil {
+		t.Fatalf("Cancel() failed: %v", err)
+	}
+	if order.Status != OrderCancelled || !order.Refundable().IsZero() {
+		t.Errorf("Expected the order cancelled and fully refunded, got %s with %v left", order.Status, order.Refundable())
+	}
+	if len(restock) != 1 || restock[0].ProductID != 1 || restock[0].Quantity != 2 {
+		t.Errorf("Expected only what wasn't restocked already to go back, got %+v", restock)
+	}
+
+	if _, _, err := store.Cancel(order.ID, Won(0)); !errors.Is(err, ErrOrderStatus) {
+		t.Errorf("Expected a cancelled order not to be cancelled again, got %v", err)
+	}
+}
+
+func TestOrderStoreCancelShipped(t *testing.T) {
+	store := NewOrderStore()
+	order := paidOrder(t, store)
+	store.SetStatus(order.ID, OrderPacked)
+	store.SetStatus(order.ID, OrderShipped)
+
+	if _, _, err := store.Cancel(order.ID, order.Total); !errors.Is(err, ErrOrderStatus) {
+		t.Errorf("Expected a shipped order not to be cancelled, got %v", err)
+	}
+}
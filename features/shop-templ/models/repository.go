@@ -0,0 +1,50 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// The stores keep everything in memory and answer from there. Given a
+// repository they load what it holds when they are created and write every
+// change through to it, so the shop survives restarts. Without one they
+// are in memory only, as in tests.
+
+// ErrNotSaved is returned, wrapping the repository's error, for a change a
+// store couldn't write through. The change isn't made in memory either.
+var ErrNotSaved = errors.New("change not saved")
+
+// ProductRepository keeps products beyond the life of the process
+type ProductRepository interface {
+	LoadProducts() ([]Product, error)
+	// SaveProducts adds the products or replaces them by ID, all or none
+	SaveProducts(products ...Product) error
+}
+
+// OrderRepository keeps orders beyond the life of the process
+type OrderRepository interface {
+	LoadOrders() ([]Order, error)
+	// SaveOrder adds the order or replaces it by ID
+	SaveOrder(order Order) error
+}
+
+// CartRepository keeps carts beyond the life of the process. Only carts
+// with items in them are kept.
+type CartRepository interface {
+	LoadCarts() ([]SavedCart, error)
+	// SaveCart adds the cart or replaces it by ID, or deletes it if it has
+	// no items
+	SaveCart(cart SavedCart) error
+	DeleteCart(id string) error
+}
+
+// SavedCart is what a repository keeps of a cart
+type SavedCart struct {
+	ID      string       `json:"id"`
+	Items   []CartItem   `json:"items"`
+	Coupon  *Coupon      `json:"coupon,omitempty"`
+	Bundles []CartBundle `json:"bundles,omitempty"`
+	// UpdatedAt is zero for carts saved before it was kept
+	UpdatedAt  time.Time `json:"updatedAt,omitzero"`
+	RemindedAt time.Time `json:"remindedAt,omitzero"`
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+// flakyRepo keeps nothing and fails every write while fail is set
+type flakyRepo struct {
+	fail bool
+}
+
+var errDiskFull = errors.New("disk full")
+
+func (r *flakyRepo) write() error {
+	if r.fail {
+		return errDiskFull
+	}
+	return nil
+}
+
+func (r *flakyRepo) LoadProducts() ([]Product, error) { return nil, nil }
+func (r *flakyRepo) SaveProducts(...Product) error    { return r.write() }
+func (r *flakyRepo) LoadOrders() ([]Order, error)     { return nil, nil }
+func (r *flakyRepo) SaveOrder(Order) error            { return r.write() }
+func (r *flakyRepo) LoadCarts() ([]SavedCart, error)  { return nil, nil }
+func (r *flakyRepo) SaveCart(SavedCart) error         { return r.write() }
+func (r *flakyRepo) DeleteCart(string) error          { return r.write() }
+
+func TestProductStoreKeepsUnsavedChangesOut(t *testing.T) {
+	repo := &flakyRepo{}
+	store, err := NewPersistentProductStore(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mug, err := store.Add(Product{Name: "Mug", Category: "Kitchen", Price: usd(1000), Stock: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo.fail = true
+	if _, err := store.Add(Product{Name: "Cup", Category: "Kitchen", Price: usd(500)}); !errors.Is(err, ErrNotSaved) || !errors.Is(err, errDiskFull) {
+		t.Errorf("Expected ErrNotSaved wrapping the repository's error, got %v", err)
+	}
+	if len(store.GetAll()) != 1 {
+		t.Errorf("Expected the unsaved product left out, got %d products", len(store.GetAll()))
+	}
+	mug.Price = usd(2000)
+	if _, err := store.Update(mug); !errors.Is(err, ErrNotSaved) {
+		t.Errorf("Expected ErrNotSaved for the update, got %v", err)
+	}
+	if got, _ := store.GetByID(mug.ID); got.Price != usd(1000) {
+		t.Errorf("Expected the price unchanged, got %v", got.Price)
+	}
+	if _, err := store.TakeStock("guest-a", []CartItem{{Product: mug, Quantity: 2}}); !errors.Is(err, ErrNotSaved) {
+		t.Errorf("Expected ErrNotSaved for taking stock, got %v", err)
+	}
+	if got, _ := store.GetByID(mug.ID); got.Stock != 5 {
+		t.Errorf("Expected the stock unchanged, got %d", got.Stock)
+	}
+
+	repo.fail = false
+	cup, err := store.Add(Product{Name: "Cup", Category: "Kitchen", Price: usd(500)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cup.ID != mug.ID+1 {
+		t.Errorf("Expected the failed add not to use up an ID, got %d after %d", cup.ID, mug.ID)
+	}
+}
+
+func TestOrderStoreKeepsUnsavedChangesOut(t *testing.T) {
+	repo := &flakyRepo{}
+	orders, err := NewPersistentOrderStore(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := orders.Add(Order{CartID: "guest-a", Total: usd(1000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo.fail = true
+	if _, err := orders.Add(Order{CartID: "guest-b", Total: usd(500)}); !errors.Is(err, ErrNotSaved) {
+		t.Errorf("Expected ErrNotSaved, got %v", err)
+	}
+	if len(orders.All()) != 1 {
+		t.Errorf("Expected the unsaved order left out, got %d orders", len(orders.All()))
+	}
+	if _, err := orders.SetStatus(order.ID, OrderPaid); !errors.Is(err, ErrNotSaved) {
+		t.Errorf("Expected ErrNotSaved for the status change, got %v", err)
+	}
+	if got, _ := orders.GetByID(order.ID); got.Status != order.Status {
+		t.Errorf("Expected the status unchanged, got %s", got.Status)
+	}
+
+	repo.fail = false
+	next, err := orders.Add(Order{CartID: "guest-b", Total: usd(500)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.ID != order.ID+1 {
+		t.Errorf("Expected the failed add not to use up an ID, got %d after %d", next.ID, order.ID)
+	}
+}
+
+func TestCartStoreUndoesUnsavedChanges(t *testing.T) {
+	repo := &flakyRepo{}
+	carts, err := NewPersistentCartStore(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mug := Product{ID: 1, Name: "Mug", Price: usd(1000), Stock: 5}
+	cup := Product{ID: 2, Name: "Cup", Price: usd(500), Stock: 5}
+	cart := carts.Get("guest-a")
+	if err := cart.AddItem(mug, 0, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	repo.fail = true
+	if err := cart.AddItem(cup, 0, 1); !errors.Is(err, ErrNotSaved) || !errors.Is(err, errDiskFull) {
+		t.Errorf("Expected ErrNotSaved wrapping the repository's error, got %v", err)
+	}
+	if err := cart.UpdateQuantity(ItemKey{ProductID: mug.ID}, 4); !errors.Is(err, ErrNotSaved) {
+		t.Errorf("Expected ErrNotSaved for the quantity, got %v", err)
+	}
+	if err := cart.Clear(); !errors.Is(err, ErrNotSaved) {
+		t.Errorf("Expected ErrNotSaved for clearing, got %v", err)
+	}
+	if cart.GetItemCount() != 2 || cart.Total != usd(2000) {
+		t.Errorf("Expected the cart left at 2 mugs, got %d items for %v", cart.GetItemCount(), cart.Total)
+	}
+	if err := carts.Delete(cart.ID); !errors.Is(err, ErrNotSaved) {
+		t.Errorf("Expected ErrNotSaved for the delete, got %v", err)
+	}
+	if carts.Get(cart.ID) != cart {
+		t.Error("Expected the cart kept when its delete isn't saved")
+	}
+}
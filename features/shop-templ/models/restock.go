@@ -0,0 +1,108 @@
+package models
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrInStock is returned for asking to hear about a product that can be
+// bought now
+var ErrInStock = errors.New("product is in stock")
+
+// RestockWatcher is a user waiting for a product to come back in stock
+type RestockWatcher struct {
+	UserID int
+	Email  string
+}
+
+// RestockAlerts keeps who wants to hear when sold-out products come back
+// in stock. Each is told once, the first time the product has stock again.
+type RestockAlerts struct {
+	mu sync.Mutex
+	// watchers are who waits for each product, by product ID and then user ID
+	watchers map[int]map[int]RestockWatcher
+	// onRestock, if set, is called with products back in stock and who
+	// waited for them
+	onRestock func(Product, []RestockWatcher)
+}
+
+// NewRestockAlerts creates an empty set of restock alerts
+func NewRestockAlerts() *RestockAlerts {
+	return &RestockAlerts{watchers: make(map[int]map[int]RestockWatcher)}
+}
+
+// Watch signs a user up to hear when a sold-out product is back. Products
+// with stock, or with any variant in stock, can be bought instead.
+func (a *RestockAlerts) Watch(product Product, user User) error {
+	if product.Archived {
+		return ErrProductNotFound
+	}
+	if product.Stock > 0 {
+		return ErrInStock
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.watchers[product.ID] == nil {
+		a.watchers[product.ID] = make(map[int]RestockWatcher)
+	}
+	a.watchers[product.ID][user.ID] = RestockWatcher{UserID: user.ID, Email: user.Email}
+	return nil
+}
+
+// Unwatch calls off a user's alert for a product
+func (a *RestockAlerts) Unwatch(productID, userID int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.watchers[productID], userID)
+	if len(a.watchers[productID]) == 0 {
+		delete(a.watchers, productID)
+	}
+}
+
+// Watching reports whether a user waits to hear about a product
+func (a *RestockAlerts) Watching(productID, userID int) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	_, ok := a.watchers[productID][userID]
+	return ok
+}
+
+// OnRestock sets fn to be called with every product that comes back in
+// stock and who waited for it. It is called in a goroutine of its own, so
+// it may be slow.
+func (a *RestockAlerts) OnRestock(fn func(Product, []RestockWatcher)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.onRestock = fn
+}
+
+// ProductChanged tells the product's watchers, through OnRestock, if it is
+// on sale with stock again, and forgets them. It is meant for
+// ProductStore.OnChange.
+func (a *RestockAlerts) ProductChanged(product Product) {
+	if product.Archived || product.Stock <= 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	watchers, ok := a.watchers[product.ID]
+	if !ok {
+		return
+	}
+	delete(a.watchers, product.ID)
+
+	if a.onRestock != nil {
+		list := make([]RestockWatcher, 0, len(watchers))
+		for _, w := range watchers {
+			list = append(list, w)
+		}
+		go a.onRestock(product, list)
+	}
+}
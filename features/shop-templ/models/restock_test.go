@@ -0,0 +1,88 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRestockAlerts(t *testing.T) {
+	store := NewProductStore()
+	alerts := NewRestockAlerts()
+	store.OnChange(alerts.ProductChanged)
+	restocked := make(chan []RestockWatcher, 10)
+	alerts.OnRestock(func(p Product, watchers []RestockWatcher) { restocked <- watchers })
+
+	soldOut, _ := store.Add(Product{Name: "P1", Price: usd(1000)})
+	inStock, _ := store.Add(Product{Name: "P2", Price: usd(1000), Stock: 1})
+	kim := User{ID: 1, Email: "kim@example.com"}
+	lee := User{ID: 2, Email: "lee@example.com"}
+
+	if err := alerts.Watch(inStock, kim); !errors.Is(err, ErrInStock) {
+		t.Errorf("Expected ErrInStock, got %v", err)
+	}
+	archived, _ := store.SetArchived(soldOut.ID, true)
+	if err := alerts.Watch(archived, kim); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound for an archived product, got %v", err)
+	}
+	store.SetArchived(soldOut.ID, false)
+
+	alerts.Watch(soldOut, kim)
+	alerts.Watch(soldOut, kim)
+	alerts.Watch(soldOut, lee)
+	alerts.Unwatch(soldOut.ID, lee.ID)
+	if !alerts.Watching(soldOut.ID, kim.ID) || alerts.Watching(soldOut.ID, lee.ID) {
+		t.Error("Expected only kim to be watching")
+	}
+
+	store.AdjustStock(ItemKey{ProductID: soldOut.ID}, 5)
+	select {
+	case watchers := <-restocked:
+		if len(watchers) != 1 || watchers[0] != (RestockWatcher{UserID: 1, Email: "kim@example.com"}) {
+			t.Errorf("Expected kim to hear once, got %+v", watchers)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the restock to be reported")
+	}
+	if alerts.Watching(soldOut.ID, kim.ID) {
+		t.Error("Expected the alert to be used up")
+	}
+
+	// Later changes have no one left to tell
+	store.AdjustStock(ItemKey{ProductID: soldOut.ID}, 5)
+	select {
+	case watchers := <-restocked:
+		t.Errorf("Expected no more restocks, got %+v", watchers)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestNotificationStore(t *testing.T) {
+	store := NewNotificationStore()
+	store.Add(Notification{UserID: 1, Message: "first"})
+	store.Add(Notification{UserID: 1, Message: "second", Read: true})
+	store.Add(Notification{UserID: 2, Message: "other"})
+
+	notifications := store.ForUser(1)
+	if len(notifications) != 2 || notifications[0].Message != "second" {
+		t.Errorf("Expected user 1's notifications newest first, got %+v", notifications)
+	}
+	if store.Unread(1) != 2 {
+		t.Errorf("Expected new notifications unread, got %d unread", store.Unread(1))
+	}
+
+	store.MarkRead(1)
+	if store.Unread(1) != 0 || store.Unread(2) != 1 {
+		t.Errorf("Expected only user 1's notifications read, got %d and %d unread", store.Unread(1), store.Unread(2))
+	}
+}
+
+func TestUnreadContext(t *testing.T) {
+	if UnreadFromContext(context.Background()) != 0 {
+		t.Error("Expected no unread notifications without a user")
+	}
+	if UnreadFromContext(ContextWithUnread(context.Background(), 3)) != 3 {
+		t.Error("Expected the unread count back")
+	}
+}
@@ -0,0 +1,211 @@
+package models
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// How much each kind of match adds to a product's relevance for one search
+// term. A term counts only its best match.
+const (
+	scoreNameWord     = 100 // a word of the name, exactly
+	scoreNamePrefix   = 60  // the start of a word of the name
+	scoreTag          = 50  // a tag, exactly
+	scoreTagPrefix    = 40  // the start of a tag
+	scoreCategory     = 40  // a level of the category, or the start of one
+	scoreNameContains = 30  // anywhere in the name
+	scoreFuzzy        = 20  // a word of the name or a tag, give or take a typo
+	scoreDescription  = 10  // anywhere in the description
+	// scoreWholeName is added when the name contains the whole query
+	scoreWholeName = 50
+)
+
+// maxSuggestions is how many "did you mean" suggestions DidYouMean returns
+const maxSuggestions = 3
+
+// searchTerms splits a query into lowercase terms
+func searchTerms(query string) []string {
+	return strings.Fields(strings.ToLower(query))
+}
+
+// relevance scores how well a product matches a query: the best match of
+// each term, plus a bonus if the name contains the whole query. It is zero
+// if any term doesn't match at all.
+func relevance(p Product, query string) int {
+	total := 0
+	for _, term := range searchTerms(query) {
+		score := termScore(p, term)
+		if score == 0 {
+			return 0
+		}
+		total += score
+	}
+
+	if strings.Contains(strings.ToLower(p.Name), strings.ToLower(strings.TrimSpace(query))) {
+		total += scoreWholeName
+	}
+	return total
+}
+
+// termScore is the score of a search term's best match in a product
+func termScore(p Product, term string) int {
+	name := strings.ToLower(p.Name)
+	nameWords := strings.Fields(name)
+	tags := make([]string, len(p.Tags))
+	for i, tag := range p.Tags {
+		tags[i] = strings.ToLower(tag)
+	}
+
+	switch {
+	case slices.Contains(nameWords, term):
+		return scoreNameWord
+	case slices.ContainsFunc(nameWords, hasPrefix(term)):
+		return scoreNamePrefix
+	case slices.Contains(tags, term):
+		return scoreTag
+	case slices.ContainsFunc(tags, hasPrefix(term)):
+		return scoreTagPrefix
+	case slices.ContainsFunc(strings.Split(strings.ToLower(p.Category), CategorySeparator), hasPrefix(term)):
+		return scoreCategory
+	case strings.Contains(name, term):
+		return scoreNameContains
+	case slices.ContainsFunc(append(nameWords, tags...), func(word string) bool { return isTypo(term, word) }):
+		return scoreFuzzy
+	case strings.Contains(strings.ToLower(p.Description), term):
+		return scoreDescription
+	}
+	return 0
+}
+
+// hasPrefix returns a func reporting whether a word starts with prefix
+func hasPrefix(prefix string) func(string) bool {
+	return func(word string) bool {
+		return strings.HasPrefix(word, prefix)
+	}
+}
+
+// rankByRelevance returns the products matching a query, most relevant
+// first, keeping the order they came in between equally relevant ones. An
+// empty query matches everything in the order it came in.
+func rankByRelevance(products []Product, query string) []Product {
+	if len(searchTerms(query)) == 0 {
+		return products
+	}
+
+	type scored struct {
+		product Product
+		score   int
+	}
+	matches := make([]scored, 0)
+	for _, p := range products {
+		if score := relevance(p, query); score > 0 {
+			matches = append(matches, scored{p, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b scored) int {
+		return cmp.Compare(b.score, a.score)
+	})
+
+	results := make([]Product, len(matches))
+	for i, m := range matches {
+		results[i] = m.product
+	}
+	return results
+}
+
+// DidYouMean suggests product names, words, tags and categories spelled
+// like a query that may have a typo in it, closest first. It allows one
+// letter more than search itself does, since anything search would
+// tolerate already found results.
+func (s *ProductStore) DidYouMean(query string) []string {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if typoLimit(query) == 0 {
+		return nil
+	}
+	limit := typoLimit(query) + 1
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Candidates by their lowercase form, which is what's compared
+	candidates := make(map[string]string)
+	for _, p := range s.getAllUnlocked() {
+		words := append(append([]string{p.Name}, strings.Split(p.Category, CategorySeparator)...), strings.Fields(p.Name)...)
+		for _, c := range append(words, p.Tags...) {
+			candidates[strings.ToLower(c)] = c
+		}
+	}
+
+	type suggestion struct {
+		text     string
+		distance int
+	}
+	var suggestions []suggestion
+	for lower, original := range candidates {
+		if lower == query {
+			continue
+		}
+		if d := editDistance(query, lower); d <= limit {
+			suggestions = append(suggestions, suggestion{original, d})
+		}
+	}
+	slices.SortFunc(suggestions, func(a, b suggestion) int {
+		return cmp.Or(cmp.Compare(a.distance, b.distance), cmp.Compare(a.text, b.text))
+	})
+
+	var texts []string
+	for _, s := range suggestions[:min(len(suggestions), maxSuggestions)] {
+		texts = append(texts, s.text)
+	}
+	return texts
+}
+
+// isTypo reports whether term is word misspelled by a few letters
+func isTypo(term, word string) bool {
+	limit := typoLimit(term)
+	return limit > 0 && editDistance(term, word) <= limit
+}
+
+// typoLimit is how many letters a term may be off by and still count as a
+// typo: none for short terms, where that would match nearly anything
+func typoLimit(term string) int {
+	switch n := len([]rune(term)); {
+	case n < 3:
+		return 0
+	case n < 7:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance is the number of single-rune insertions, deletions,
+// substitutions and swaps of neighbours it takes to turn a into b (the
+// optimal string alignment distance)
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	// Rows i-2, i-1 and i of the distance table
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
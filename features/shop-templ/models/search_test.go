@@ -0,0 +1,107 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSearchRanking(t *testing.T) {
+	store := NewProductStore()
+	store.Add(Product{Name: "Mouse Pad", Description: "For any wireless mouse", Category: "Office"})
+	store.Add(Product{Name: "Desk Lamp", Description: "Pairs well with a wireless mouse", Category: "Office"})
+	store.Add(Product{Name: "Wireless Mouse", Category: "Electronics", Tags: []string{"mouse", "wireless"}})
+	store.Add(Product{Name: "Trackball", Category: "Electronics", Tags: []string{"mouse"}})
+
+	results := store.Search("wireless mouse")
+	names := make([]string, len(results))
+	for i, p := range results {
+		names[i] = p.Name
+	}
+
+	// The whole name beats a word of it, which beats the description
+	expected := "[Wireless Mouse Mouse Pad Desk Lamp]"
+	if fmt.Sprint(names) != expected {
+		t.Errorf("Expected %s, got %v", expected, names)
+	}
+
+	// Tags match, ranked below names
+	results = store.Search("mouse")
+	if len(results) != 4 || results[len(results)-2].Name != "Trackball" {
+		t.Errorf("Expected the tag match after the name matches, got %v", results)
+	}
+}
+
+func TestSearchKorean(t *testing.T) {
+	store := NewProductStore()
+	store.Add(Product{Name: "무선 이어폰", Category: "전자제품", Tags: []string{"audio"}})
+	store.Add(Product{Name: "무선 마우스", Category: "전자제품"})
+	store.Add(Product{Name: "텀블러", Category: "생활용품"})
+
+	tests := []struct {
+		query    string
+		expected int
+	}{
+		{"무선", 2},
+		{"이어", 1},     // Prefix
+		{"마우사", 1},    // Typo
+		{"전자", 2},     // Category prefix
+		{"무선 이어폰", 1}, // Both terms
+		{"텀", 1},
+		{"자전거", 0},
+	}
+
+	for _, tt := range tests {
+		if results := store.Search(tt.query); len(results) != tt.expected {
+			t.Errorf("Search(%q): expected %d results, got %d", tt.query, tt.expected, len(results))
+		}
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	store := NewProductStore()
+	store.Add(Product{Name: "Wireless Mouse", Category: "Electronics", Tags: []string{"mouse"}})
+	store.Add(Product{Name: "Coffee Mug", Category: "Home"})
+	store.Add(Product{Name: "블루투스 스피커", Category: "전자제품"})
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"wireles mouze", "[Wireless Mouse]"},
+		{"cofee", "[Coffee]"},
+		{"electornics", "[Electronics]"},
+		{"스피카", "[스피커]"},
+		{"커피 머그", "[]"},
+		{"wirelss mose", "[Wireless Mouse]"}, // Whole names too
+		{"zz", "[]"},                         // Too short to guess at
+		{"mouse", "[]"},                      // Already right
+		{"keyboard", "[]"},                   // Nothing close
+	}
+
+	for _, tt := range tests {
+		if got := fmt.Sprint(store.DidYouMean(tt.query)); got != tt.expected {
+			t.Errorf("DidYouMean(%q): expected %s, got %s", tt.query, tt.expected, got)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"mouse", "mouse", 0},
+		{"mouse", "mouze", 1},
+		{"mouse", "mose", 1},
+		{"kitten", "sitting", 3},
+		{"laptop", "laptpo", 1}, // A swap counts once
+		{"마우스", "마우사", 1},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.expected {
+			t.Errorf("editDistance(%q, %q): expected %d, got %d", tt.a, tt.b, tt.expected, got)
+		}
+	}
+}
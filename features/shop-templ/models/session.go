@@ -0,0 +1,70 @@
+package models
+
+import (
+	"crypto/rand"
+	"sync"
+	"time"
+)
+
+// SessionTTL is how long a login session stays valid
+const SessionTTL = 7 * 24 * time.Hour
+
+type session struct {
+	userID    int
+	expiresAt time.Time
+}
+
+// SessionStore maps random session tokens to logged-in users
+type SessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]session
+	now      func() time.Time
+}
+
+// NewSessionStore creates an empty session store
+func NewSessionStore() *SessionStore {
+	return &SessionStore{
+		sessions: make(map[string]session),
+		now:      time.Now,
+	}
+}
+
+// Create starts a session for a user and returns its token
+func (s *SessionStore) Create(userID int) string {
+	token := rand.Text()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[token] = session{
+		userID:    userID,
+		expiresAt: s.now().Add(SessionTTL),
+	}
+
+	return token
+}
+
+// Get returns the user ID for a valid, unexpired token
+func (s *SessionStore) Get(token string) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, exists := s.sessions[token]
+	if !exists {
+		return 0, false
+	}
+	if !s.now().Before(sess.expiresAt) {
+		delete(s.sessions, token)
+		return 0, false
+	}
+
+	return sess.userID, true
+}
+
+// Delete ends a session
+func (s *SessionStore) Delete(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, token)
+}
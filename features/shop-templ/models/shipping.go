@@ -0,0 +1,118 @@
+package models
+
+import (
+	"errors"
+	"slices"
+)
+
+// ErrShippingUnavailable is returned for a shipping method that can't
+// deliver an order
+var ErrShippingUnavailable = errors.New("shipping method isn't available for this order")
+
+// ParcelItem is a product in a parcel, as much of it as a shipping fee
+// depends on
+type ParcelItem struct {
+	ProductID int
+	Category  string
+	Quantity  int
+}
+
+// Parcel is an order as a carrier sees it: what is in it, what it is
+// worth and where it goes
+type Parcel struct {
+	Items []ParcelItem
+	// Value is what the goods cost after the discount
+	Value   Money
+	Country string
+}
+
+// CartParcel returns the parcel the cart would make shipped to country
+func CartParcel(cart *Cart, country string) Parcel {
+	parcel := Parcel{Value: cart.TotalAfterDiscount(), Country: country}
+	for _, item := range cart.GetItems() {
+		parcel.Items = append(parcel.Items, ParcelItem{item.Product.ID, item.Product.Category, item.Quantity})
+	}
+	return parcel
+}
+
+// Parcel returns the parcel the order ships in
+func (o Order) Parcel() Parcel {
+	parcel := Parcel{Value: o.Subtotal().Sub(o.BundleDiscount()).Sub(o.Discount), Country: o.Shipping.Destination()}
+	for _, item := range o.Items {
+		parcel.Items = append(parcel.Items, ParcelItem{item.ProductID, item.Category, item.Quantity})
+	}
+	return parcel
+}
+
+// ShippingOption is a way a parcel can be shipped and what it costs
+type ShippingOption struct {
+	Method string `json:"method"`
+	Name   string `json:"name"`
+	// Days is roughly how long delivery takes, as shown to customers
+	Days string `json:"days,omitempty"`
+	Fee  Money  `json:"fee"`
+}
+
+// ShippingCalculator prices shipping. Implementations may go by what is in
+// a parcel, its value, where it goes or anything else about it.
+type ShippingCalculator interface {
+	// Options returns the ways the parcel can be shipped, the default first
+	Options(parcel Parcel) []ShippingOption
+}
+
+// ChooseShipping returns the option for method, or the default option if
+// method is empty
+func ChooseShipping(options []ShippingOption, method string) (ShippingOption, error) {
+	for _, option := range options {
+		if method == "" || option.Method == method {
+			return option, nil
+		}
+	}
+	return ShippingOption{}, ErrShippingUnavailable
+}
+
+// ShippingMethod is a way of shipping at a flat fee
+type ShippingMethod struct {
+	ID   string
+	Name string
+	Days string
+	Fee  Money
+	// FreeOver waives the fee for parcels worth at least this much; zero
+	// never waives it
+	FreeOver Money
+	// Countries limits the method to parcels going there; empty means anywhere
+	Countries []string
+}
+
+// available reports whether the method can ship to country
+func (m ShippingMethod) available(country string) bool {
+	return len(m.Countries) == 0 || slices.Contains(m.Countries, country)
+}
+
+// FlatRateShipping charges each method's fee whatever is in the parcel
+type FlatRateShipping []ShippingMethod
+
+// DefaultShipping offers standard delivery, free on orders of ₩50,000 or
+// more, and next day delivery within Korea, and a flat fee abroad
+var DefaultShipping = FlatRateShipping{
+	{ID: "standard", Name: "일반 배송", Days: "2-3일", Fee: Won(3000), FreeOver: Won(50000), Countries: []string{DefaultCountry}},
+	{ID: "express", Name: "빠른 배송", Days: "다음 날", Fee: Won(5000), Countries: []string{DefaultCountry}},
+	{ID: "international", Name: "해외 배송", Days: "7-14일", Fee: Won(15000), FreeOver: Won(300000), Countries: []string{"US", "JP", "CN"}},
+}
+
+// Options returns the methods that ship to the parcel's country, in the
+// order they are listed
+func (f FlatRateShipping) Options(parcel Parcel) []ShippingOption {
+	var options []ShippingOption
+	for _, m := range f {
+		if !m.available(parcel.Country) {
+			continue
+		}
+		fee := m.Fee
+		if !m.FreeOver.IsZero() && parcel.Value.Cmp(m.FreeOver) >= 0 {
+			fee = NewMoney(0, m.Fee.Currency)
+		}
+		options = append(options, ShippingOption{Method: m.ID, Name: m.Name, Days: m.Days, Fee: fee})
+	}
+	return options
+}
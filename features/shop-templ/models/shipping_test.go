@@ -0,0 +1,68 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFlatRateShippingOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   Money
+		country string
+		want    []ShippingOption
+	}{
+		{"domestic", Won(30000), "KR", []ShippingOption{
+			{Method: "standard", Name: "일반 배송", Days: "2-3일", Fee: Won(3000)},
+			{Method: "express", Name: "빠른 배송", Days: "다음 날", Fee: Won(5000)},
+		}},
+		{"free standard", Won(50000), "KR", []ShippingOption{
+			{Method: "standard", Name: "일반 배송", Days: "2-3일", Fee: Won(0)},
+			{Method: "express", Name: "빠른 배송", Days: "다음 날", Fee: Won(5000)},
+		}},
+		{"abroad", Won(50000), "US", []ShippingOption{
+			{Method: "international", Name: "해외 배송", Days: "7-14일", Fee: Won(15000)},
+		}},
+	}
+
+	for _, tt := range tests {
+		got := DefaultShipping.Options(Parcel{Value: tt.value, Country: tt.country})
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: expected %d options, got %+v", tt.name, len(tt.want), got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: expected %+v, got %+v", tt.name, tt.want[i], got[i])
+			}
+		}
+	}
+}
+
+func TestChooseShipping(t *testing.T) {
+	options := DefaultShipping.Options(Parcel{Value: Won(10000), Country: "KR"})
+
+	if option, err := ChooseShipping(options, ""); err != nil || option.Method != "standard" {
+		t.Errorf("Expected the first option by default, got %+v, %v", option, err)
+	}
+	if option, err := ChooseShipping(options, "express"); err != nil || option.Fee != Won(5000) {
+		t.Errorf("Expected express for ₩5,000, got %+v, %v", option, err)
+	}
+	if _, err := ChooseShipping(options, "international"); !errors.Is(err, ErrShippingUnavailable) {
+		t.Errorf("Expected ErrShippingUnavailable for a method that doesn't ship here, got %v", err)
+	}
+}
+
+func TestCartParcel(t *testing.T) {
+	cart := NewCart()
+	cart.AddItem(Product{ID: 1, Category: "패션", Price: Won(30000)}, 0, 2)
+	cart.ApplyCoupon(Coupon{Code: "SAVE5000", Type: DiscountFixed, Amount: Won(5000)})
+
+	parcel := CartParcel(cart, "JP")
+	if parcel.Value != Won(55000) {
+		t.Errorf("Expected the parcel worth the discounted total, got %v", parcel.Value)
+	}
+	if parcel.Country != "JP" || len(parcel.Items) != 1 || parcel.Items[0] != (ParcelItem{1, "패션", 2}) {
+		t.Errorf("Unexpected parcel %+v", parcel)
+	}
+}
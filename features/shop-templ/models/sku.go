@@ -0,0 +1,131 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrDuplicateSKU is returned for a product or variant SKU another
+	// product or variant already has
+	ErrDuplicateSKU = errors.New("SKU is used by another product")
+	// ErrDuplicateBarcode is returned for a barcode another product already
+	// has
+	ErrDuplicateBarcode = errors.New("barcode is used by another product")
+)
+
+// codeKey returns how a SKU or barcode is compared: without surrounding
+// space and ignoring case, as scanners and people type them differently
+func codeKey(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// productCodes are the SKUs and barcodes in use, each with the product
+// using it
+type productCodes struct {
+	skus     map[string]int
+	barcodes map[string]int
+}
+
+func newProductCodes() productCodes {
+	return productCodes{skus: make(map[string]int), barcodes: make(map[string]int)}
+}
+
+// add marks the codes of p as used by owner
+func (c productCodes) add(p Product, owner int) {
+	for _, sku := range p.skus() {
+		c.skus[codeKey(sku)] = owner
+	}
+	if p.Barcode != "" {
+		c.barcodes[codeKey(p.Barcode)] = owner
+	}
+}
+
+// check returns an error if another owner already uses one of p's codes
+func (c productCodes) check(p Product, owner int) error {
+	for _, sku := range p.skus() {
+		if id, used := c.skus[codeKey(sku)]; used && id != owner {
+			return fmt.Errorf("%w: %s", ErrDuplicateSKU, sku)
+		}
+	}
+	if id, used := c.barcodes[codeKey(p.Barcode)]; p.Barcode != "" && used && id != owner {
+		return fmt.Errorf("%w: %s", ErrDuplicateBarcode, p.Barcode)
+	}
+	return nil
+}
+
+// skus returns the product's own SKU, if it has one, and its variants'
+func (p Product) skus() []string {
+	var skus []string
+	if p.SKU != "" {
+		skus = append(skus, p.SKU)
+	}
+	for _, v := range p.Variants {
+		skus = append(skus, v.SKU)
+	}
+	return skus
+}
+
+// validateCodes checks that the product's SKU isn't one of its variants'
+func (p Product) validateCodes() error {
+	for _, v := range p.Variants {
+		if p.SKU != "" && codeKey(v.SKU) == codeKey(p.SKU) {
+			return fmt.Errorf("%w: %s is also a variant's", ErrDuplicateSKU, p.SKU)
+		}
+	}
+	return nil
+}
+
+// codesUnlocked returns the codes of every product in the store, archived
+// ones too. The caller must hold s.mu.
+func (s *ProductStore) codesUnlocked() productCodes {
+	codes := newProductCodes()
+	for _, p := range s.products {
+		codes.add(p, p.ID)
+	}
+	return codes
+}
+
+// FindCode finds the product, archived or not, with a SKU or barcode, and
+// the variant if the SKU is a variant's. SKUs are looked for first.
+func (s *ProductStore) FindCode(code string) (Product, ItemKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key := codeKey(code)
+	if key == "" {
+		return Product{}, ItemKey{}, false
+	}
+	for _, p := range s.products {
+		if codeKey(p.SKU) == key {
+			return s.onSaleUnlocked(p), ItemKey{ProductID: p.ID}, true
+		}
+		for _, v := range p.Variants {
+			if codeKey(v.SKU) == key {
+				return s.onSaleUnlocked(p), ItemKey{ProductID: p.ID, VariantID: v.ID}, true
+			}
+		}
+	}
+	for _, p := range s.products {
+		if codeKey(p.Barcode) == key {
+			return s.onSaleUnlocked(p), ItemKey{ProductID: p.ID}, true
+		}
+	}
+	return Product{}, ItemKey{}, false
+}
+
+// SearchCodes returns the products, archived ones too, with a SKU,
+// variant SKU or barcode starting with query, in the order they were added
+func (s *ProductStore) SearchCodes(query string) []Product {
+	prefix := codeKey(query)
+	var results []Product
+	for _, p := range s.GetAllWithArchived() {
+		matches := func(code string) bool { return code != "" && strings.HasPrefix(codeKey(code), prefix) }
+		if slices.ContainsFunc(p.skus(), matches) || matches(p.Barcode) {
+			results = append(results, p)
+		}
+	}
+	return results
+}
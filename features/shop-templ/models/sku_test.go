@@ -0,0 +1,165 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func skuTestStore() (*ProductStore, Product, Product) {
+	store := NewProductStore()
+	mug, _ := store.Add(Product{Name: "Mug", SKU: "MUG-01", Barcode: "8801234567890", Price: Won(12000), Category: "Kitchen", Stock: 5})
+	shirt, _ := store.Add(Product{
+		Name: "Shirt", SKU: "SHIRT", Price: Won(20000), Category: "Clothes",
+		Options:  []ProductOption{{Name: "Size", Values: []string{"S", "M"}}},
+		Variants: []Variant{{SKU: "SHIRT-S", Values: []string{"S"}, Stock: 2}, {SKU: "SHIRT-M", Values: []string{"M"}, Stock: 3}},
+	})
+	return store, mug, shirt
+}
+
+func TestProductCreateChecksCodes(t *testing.T) {
+	store, _, _ := skuTestStore()
+
+	tests := []struct {
+		name    string
+		product Product
+		err     error
+	}{
+		{"product SKU", Product{SKU: "mug-01 "}, ErrDuplicateSKU},
+		{"variant SKU", Product{SKU: "SHIRT-M"}, ErrDuplicateSKU},
+		{"barcode", Product{Barcode: "8801234567890"}, ErrDuplicateBarcode},
+		{"own variant", Product{SKU: "CAP", Options: []ProductOption{{Name: "Size", Values: []string{"S"}}}, Variants: []Variant{{ID: 1, SKU: "cap", Values: []string{"S"}}}}, ErrDuplicateSKU},
+	}
+	for _, tt := range tests {
+		product := tt.product
+		product.Name, product.Category, product.Price = "New", "Misc", Won(1000)
+		if _, err := store.Create(product); !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.err, err)
+		}
+	}
+
+	created, err := store.Create(Product{Name: "Plate", SKU: "PLATE", Barcode: "8800000000001", Price: Won(8000), Category: "Kitchen"})
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	if created.ID == 0 {
+		t.Error("Expected the product to get an ID")
+	}
+	if _, err := store.Create(Product{Name: "Bad"}); !errors.Is(err, ErrInvalidProduct) {
+		t.Errorf("Expected ErrInvalidProduct, got %v", err)
+	}
+}
+
+func TestProductUpdateChecksCodes(t *testing.T) {
+	store, mug, _ := skuTestStore()
+
+	// Keeping its own codes is fine
+	mug.Name = "Big mug"
+	if _, err := store.Update(mug); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+
+	mug.SKU = "SHIRT-S"
+	if _, err := store.Update(mug); !errors.Is(err, ErrDuplicateSKU) {
+		t.Errorf("Expected ErrDuplicateSKU, got %v", err)
+	}
+	mug.SKU = "MUG-02"
+	updated, err := store.Update(mug)
+	if err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if updated.SKU != "MUG-02" || updated.Barcode != "8801234567890" {
+		t.Errorf("Expected the new SKU saved, got %+v", updated)
+	}
+}
+
+func TestFindCode(t *testing.T) {
+	store, mug, shirt := skuTestStore()
+	store.SetArchived(mug.ID, true)
+
+	tests := []struct {
+		code string
+		key  ItemKey
+	}{
+		{"MUG-01", ItemKey{ProductID: mug.ID}},
+		{" mug-01", ItemKey{ProductID: mug.ID}},
+		{"8801234567890", ItemKey{ProductID: mug.ID}},
+		{"SHIRT", ItemKey{ProductID: shirt.ID}},
+		{"shirt-m", ItemKey{ProductID: shirt.ID, VariantID: shirt.Variants[1].ID}},
+	}
+	for _, tt := range tests {
+		product, key, ok := store.FindCode(tt.code)
+		if !ok || key != tt.key || product.ID != tt.key.ProductID {
+			t.Errorf("FindCode(%q): expected %v, got %v (%v)", tt.code, tt.key, key, ok)
+		}
+	}
+	for _, code := range []string{"", "MUG", "UNKNOWN"} {
+		if _, _, ok := store.FindCode(code); ok {
+			t.Errorf("FindCode(%q): expected nothing found", code)
+		}
+	}
+}
+
+func TestSearchCodes(t *testing.T) {
+	store, mug, shirt := skuTestStore()
+
+	if got := store.SearchCodes("shirt-"); len(got) != 1 || got[0].ID != shirt.ID {
+		t.Errorf("Expected the shirt by its variants' SKUs, got %+v", got)
+	}
+	if got := store.SearchCodes("880"); len(got) != 1 || got[0].ID != mug.ID {
+		t.Errorf("Expected the mug by its barcode, got %+v", got)
+	}
+	if got := store.SearchCodes("mug"); len(got) != 1 || got[0].ID != mug.ID {
+		t.Errorf("Expected the mug ignoring case, got %+v", got)
+	}
+	if got := store.SearchCodes("Kitchen"); len(got) != 0 {
+		t.Errorf("Expected only codes searched, got %+v", got)
+	}
+}
+
+func TestImportChecksCodes(t *testing.T) {
+	store, mug, _ := skuTestStore()
+
+	csv := strings.Join([]string{
+		"id,name,sku,barcode,price,category,stock",
+		",Plate,PLATE,,8000,Kitchen,3",
+		",Bowl,plate,,8000,Kitchen,3",
+		",Cup,SHIRT-S,,1000,Kitchen,1",
+		",Jug,,8801234567890,1000,Kitchen,1",
+		"1,Mug,MUG-01,8801234567890,12000,Kitchen,5",
+	}, "\n")
+	result, err := store.Import(strings.NewReader(csv), CatalogCSV, false)
+	if err != nil {
+		t.Fatalf("Import() failed: %v", err)
+	}
+
+	expected := []struct {
+		row   int
+		field string
+		err   error
+	}{
+		{3, "sku", ErrDuplicateSKU},
+		{4, "sku", ErrDuplicateSKU},
+		{5, "barcode", ErrDuplicateBarcode},
+	}
+	if len(result.Errors) != len(expected) {
+		t.Fatalf("Expected %d row errors, got %v", len(expected), result.Errors)
+	}
+	for i, e := range expected {
+		got := result.Errors[i]
+		if got.Row != e.row || got.Field != e.field || !errors.Is(got, e.err) {
+			t.Errorf("Expected row %d %q %v, got %v", e.row, e.field, e.err, got)
+		}
+	}
+
+	// The codes survive an export and import
+	var out strings.Builder
+	store.Export(&out, CatalogCSV)
+	reimported := NewProductStore()
+	if result, err := reimported.Import(strings.NewReader(out.String()), CatalogCSV, false); err != nil || len(result.Errors) != 0 {
+		t.Fatalf("Import() of the export failed: %v %v", err, result.Errors)
+	}
+	if got, _ := reimported.GetByID(mug.ID); got.SKU != "MUG-01" || got.Barcode != "8801234567890" {
+		t.Errorf("Expected the codes exported, got %+v", got)
+	}
+}
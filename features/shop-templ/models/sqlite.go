@@ -0,0 +1,161 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	// Registers the "sqlite" driver, pure Go so the shop builds without cgo
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema has a table for each kind of record, keeping each as a
+// JSON document by ID. Nothing is queried by anything but ID: the stores
+// load everything when they start and answer from memory. Analytics
+// events are only ever added, and read back by when they happened.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS products (id INTEGER PRIMARY KEY, data TEXT NOT NULL);
+CREATE TABLE IF NOT EXISTS orders (id INTEGER PRIMARY KEY, data TEXT NOT NULL);
+CREATE TABLE IF NOT EXISTS carts (id TEXT PRIMARY KEY, data TEXT NOT NULL);
+CREATE TABLE IF NOT EXISTS events (id INTEGER PRIMARY KEY, at INTEGER NOT NULL, data TEXT NOT NULL);
+CREATE INDEX IF NOT EXISTS events_at ON events (at);
+`
+
+// SQLiteStore keeps products, orders and carts in a SQLite database file.
+// It is a ProductRepository, an OrderRepository and a CartRepository, and
+// an EventSink.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens the database at path, creating it and its tables if
+// they don't exist
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	// One connection keeps writes from waiting on each other's locks
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create tables: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// Ping checks that the database can still be reached
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// LoadProducts returns every product by ID
+func (s *SQLiteStore) LoadProducts() ([]Product, error) {
+	return loadRows[Product](s.db, "SELECT data FROM products ORDER BY id")
+}
+
+// SaveProducts adds or replaces the products in one transaction
+func (s *SQLiteStore) SaveProducts(products ...Product) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, product := range products {
+		if err := upsertRow(tx, "products", product.ID, product); err != nil {
+			return fmt.Errorf("product %d: %w", product.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadOrders returns every order by ID
+func (s *SQLiteStore) LoadOrders() ([]Order, error) {
+	return loadRows[Order](s.db, "SELECT data FROM orders ORDER BY id")
+}
+
+// SaveOrder adds or replaces the order
+func (s *SQLiteStore) SaveOrder(order Order) error {
+	return upsertRow(s.db, "orders", order.ID, order)
+}
+
+// LoadCarts returns every cart by ID
+func (s *SQLiteStore) LoadCarts() ([]SavedCart, error) {
+	return loadRows[SavedCart](s.db, "SELECT data FROM carts ORDER BY id")
+}
+
+// SaveCart adds or replaces the cart, or deletes it if it is empty
+func (s *SQLiteStore) SaveCart(cart SavedCart) error {
+	if len(cart.Items) == 0 {
+		return s.DeleteCart(cart.ID)
+	}
+	return upsertRow(s.db, "carts", cart.ID, cart)
+}
+
+// DeleteCart deletes the cart
+func (s *SQLiteStore) DeleteCart(id string) error {
+	_, err := s.db.Exec("DELETE FROM carts WHERE id = ?", id)
+	return err
+}
+
+// RecordEvent adds the event
+func (s *SQLiteStore) RecordEvent(event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+	_, err = s.db.Exec("INSERT INTO events (at, data) VALUES (?, ?)", event.At.UnixNano(), string(data))
+	return err
+}
+
+// LoadEvents returns the events from since on, in the order they happened
+func (s *SQLiteStore) LoadEvents(since time.Time) ([]Event, error) {
+	return loadRows[Event](s.db, "SELECT data FROM events WHERE at >= ? ORDER BY at, id", since.UnixNano())
+}
+
+// execer is what upsertRow needs of a database or transaction
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// upsertRow writes a record as JSON into table by id
+func upsertRow(db execer, table string, id any, record any) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+	_, err = db.Exec("INSERT INTO "+table+" (id, data) VALUES (?, ?) ON CONFLICT (id) DO UPDATE SET data = excluded.data", id, string(data))
+	return err
+}
+
+// loadRows decodes the JSON documents a query returns
+func loadRows[T any](db *sql.DB, query string, args ...any) ([]T, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []T
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var record T
+		if err := json.Unmarshal([]byte(data), &record); err != nil {
+			return nil, fmt.Errorf("decode: %w", err)
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
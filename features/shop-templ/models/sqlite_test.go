@@ -0,0 +1,110 @@
+package models
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// reopen closes the database and opens stores on it again, as a restart
+// would
+func reopen(t *testing.T, db *SQLiteStore, path string) (*ProductStore, *OrderStore, *CartStore, *SQLiteStore) {
+	t.Helper()
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	db, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	products, err := NewPersistentProductStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders, err := NewPersistentOrderStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	carts, err := NewPersistentCartStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return products, orders, carts, db
+}
+
+func TestSQLiteStoreSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shop.db")
+	db, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	products, orders, carts, db := reopen(t, db, path)
+
+	mug, err := products.Add(Product{Name: "Mug", Category: "Kitchen", Price: usd(1000), Stock: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shirt, err := products.Add(Product{
+		Name: "Shirt", Category: "Clothes", Price: usd(2000),
+		Options:  []ProductOption{{Name: "Size", Values: []string{"S", "M"}}},
+		Variants: []Variant{{SKU: "SHIRT-S", Values: []string{"S"}, Stock: 2}, {SKU: "SHIRT-M", Values: []string{"M"}, Stock: 3}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := products.TakeStock("guest-a", []CartItem{{Product: mug, Quantity: 2}, {Product: shirt, VariantID: 2, Quantity: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := orders.Add(Order{CartID: "guest-a", Items: items})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := orders.SetStatus(order.ID, OrderPaid); err != nil {
+		t.Fatal(err)
+	}
+	carts.Get(GuestCartID("b")).AddItem(mug, 0, 1)
+	carts.Get(GuestCartID("b")).ApplyCoupon(Coupon{Code: "SAVE", Type: DiscountFixed, Amount: usd(100)})
+	carts.Get(UserCartID(1)).AddItem(mug, 0, 1)
+	carts.Get(UserCartID(1)).Clear()
+	carts.Get(GuestCartID("gone")).AddItem(mug, 0, 1)
+	carts.Delete(GuestCartID("gone"))
+
+	products, orders, carts, _ = reopen(t, db, path)
+
+	if got, _ := products.GetByID(mug.ID); got.Stock != 3 || got.Sold != 2 {
+		t.Errorf("Expected the mug's stock and sales to survive, got %d in stock, %d sold", got.Stock, got.Sold)
+	}
+	if got, _ := products.GetByID(shirt.ID); got.StockOf(2) != 2 || got.Stock != 4 {
+		t.Errorf("Expected the variant's stock to survive, got %d of 4", got.StockOf(2))
+	}
+	if next, err := products.Add(Product{Name: "Cap", Category: "Clothes", Price: usd(500)}); err != nil || next.ID != 3 {
+		t.Errorf("Expected product IDs to carry on from 3, got %d, %v", next.ID, err)
+	}
+
+	got, ok := orders.GetByID(order.ID)
+	if !ok || got.Status != OrderPaid || len(got.History) != 2 || got.Items[1].SKU != items[1].SKU {
+		t.Errorf("Expected the paid order to survive, got %+v", got)
+	}
+	if next, err := orders.Add(Order{}); err != nil || next.ID != 2 {
+		t.Errorf("Expected order IDs to carry on from 2, got %d, %v", next.ID, err)
+	}
+
+	cart := carts.Get(GuestCartID("b"))
+	if cart.GetItemCount() != 1 || cart.Total != usd(1000) {
+		t.Errorf("Expected the cart to survive, got %d items, total %v", cart.GetItemCount(), cart.Total)
+	}
+	if coupon, ok := cart.GetCoupon(); !ok || coupon.Code != "SAVE" {
+		t.Error("Expected the applied coupon to survive")
+	}
+	if len(carts.carts) != 1 {
+		t.Errorf("Expected cleared and deleted carts to be gone, got %d carts", len(carts.carts))
+	}
+}
+
+func TestOpenSQLiteErrors(t *testing.T) {
+	if _, err := OpenSQLite(filepath.Join(t.TempDir(), "missing", "shop.db")); err == nil {
+		t.Error("Expected an error for a directory that doesn't exist")
+	}
+}
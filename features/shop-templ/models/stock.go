@@ -0,0 +1,388 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+var (
+	// ErrProductNotFound is returned when a product doesn't exist
+	ErrProductNotFound = errors.New("product not found")
+	// ErrInsufficientStock matches every InsufficientStockError with errors.Is
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrNegativeStock is returned for a stock adjustment that would leave
+	// less than none
+	ErrNegativeStock = errors.New("stock can't go below zero")
+)
+
+// LowStockThreshold is the stock at or below which shoppers are told how
+// few are left
+const LowStockThreshold = 3
+
+// LowStock reports whether the product is nearly sold out
+func (p Product) LowStock() bool {
+	return p.Stock > 0 && p.Stock <= LowStockThreshold
+}
+
+// LowOnStock returns the products on sale that are sold out or nearly so,
+// for restocking, the fewest in stock first
+func (s *ProductStore) LowOnStock() []Product {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var low []Product
+	for _, p := range s.getAllUnlocked() {
+		if p.Stock <= LowStockThreshold {
+			low = append(low, p)
+		}
+	}
+	slices.SortStableFunc(low, func(a, b Product) int { return a.Stock - b.Stock })
+	return low
+}
+
+// InsufficientStockError reports a product, or a variant of one, without
+// enough stock for a request
+type InsufficientStockError struct {
+	ProductID int
+	VariantID int
+	// Name is the product's name, followed by the variant's values for a
+	// variant
+	Name      string
+	Requested int
+	Available int
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock for %s: %d requested, %d available", e.Name, e.Requested, e.Available)
+}
+
+// Unwrap lets errors.Is match ErrInsufficientStock
+func (e *InsufficientStockError) Unwrap() error {
+	return ErrInsufficientStock
+}
+
+// reservation is stock held for a cart until it expires
+type reservation struct {
+	quantity  int
+	expiresAt time.Time
+}
+
+// Available returns how much of a product, or of a variant of it, can
+// still be put in a cart: its stock less what carts have reserved
+func (s *ProductStore) Available(key ItemKey) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	product, exists := s.products[key.ProductID]
+	if !exists {
+		return 0
+	}
+	return product.StockOf(key.VariantID) - s.reservedUnlocked(key, "")
+}
+
+// CheckStock reports whether quantity of a product, or of a variant of it,
+// is available to a cart, counting what the cart itself has reserved as
+// its own
+func (s *ProductStore) CheckStock(cartID string, key ItemKey, quantity int) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.checkUnlocked(cartID, key, quantity)
+}
+
+// Reserve holds quantity of a product, or of a variant of it, for a cart
+// until ttl has passed, replacing what the cart held of it before.
+// Reserving zero releases it.
+func (s *ProductStore) Reserve(cartID string, key ItemKey, quantity int, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneUnlocked()
+	if quantity <= 0 {
+		s.releaseUnlocked(cartID, key)
+		return nil
+	}
+	if err := s.checkUnlocked(cartID, key, quantity); err != nil {
+		return err
+	}
+
+	s.reserveUnlocked(cartID, key, quantity, ttl)
+	return nil
+}
+
+// Release gives back what a cart reserved of a product or variant
+func (s *ProductStore) Release(cartID string, key ItemKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.releaseUnlocked(cartID, key)
+}
+
+// ReleaseAll gives back everything a cart reserved
+func (s *ProductStore) ReleaseAll(cartID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.reservations, cartID)
+}
+
+// TakeStock removes the cart's items from stock, all or nothing, and
+// returns them as order items priced as the products and variants are
+// now, on sale or not. Stock other carts have reserved can't be taken; the cart's own
+// reservations are used up. Nothing is taken if any product is missing or
+// short, or the change can't be saved.
+func (s *ProductStore) TakeStock(cartID string, items []CartItem) ([]OrderItem, error) {
+	if len(items) == 0 {
+		return nil, ErrEmptyCart
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Check everything first so a shortage leaves stock untouched
+	wanted := make(map[ItemKey]int)
+	for _, item := range items {
+		wanted[item.Key()] += item.Quantity
+	}
+	for key, quantity := range wanted {
+		if err := s.checkUnlocked(cartID, key, quantity); err != nil {
+			return nil, err
+		}
+	}
+
+	orderItems := make([]OrderItem, 0, len(items))
+	taken := make(map[int]Product)
+	for _, item := range items {
+		// A product in the cart more than once, as different variants,
+		// has the stock taken for the earlier ones already
+		product, ok := taken[item.Product.ID]
+		if !ok {
+			product = s.products[item.Product.ID]
+		}
+		product = product.withStockChange(item.VariantID, -item.Quantity)
+		product.Sold += item.Quantity
+		taken[product.ID] = product
+
+		orderItem := OrderItem{
+			ProductID: product.ID,
+			Name:      product.Name,
+			Category:  product.Category,
+			Price:     s.onSaleUnlocked(product).PriceOf(item.VariantID),
+			Quantity:  item.Quantity,
+		}
+		if variant, ok := product.Variant(item.VariantID); ok {
+			orderItem.VariantID = variant.ID
+			orderItem.SKU = variant.SKU
+			orderItem.Variant = variant.Label()
+		}
+		orderItems = append(orderItems, orderItem)
+	}
+	if err := s.putUnlocked(slices.Collect(maps.Values(taken))...); err != nil {
+		return nil, err
+	}
+	delete(s.reservations, cartID)
+
+	return orderItems, nil
+}
+
+// AdjustStock adds delta to the stock of a product, or of a variant of it,
+// or takes it away if delta is negative, as when a delivery arrives or
+// stock is found damaged. Products with variants only have the variants'
+// stock to adjust.
+func (s *ProductStore) AdjustStock(key ItemKey, delta int) (Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, exists := s.products[key.ProductID]
+	if !exists {
+		return Product{}, ErrProductNotFound
+	}
+	if err := variantOf(product, key); err != nil {
+		return product, err
+	}
+	if stock := product.StockOf(key.VariantID); stock+delta < 0 {
+		return product, fmt.Errorf("%w: %d in stock, %d taken away", ErrNegativeStock, stock, -delta)
+	}
+	product = product.withStockChange(key.VariantID, delta)
+	if err := s.putUnlocked(product); err != nil {
+		return Product{}, err
+	}
+
+	return product, nil
+}
+
+// ReturnStock puts taken items back in stock, as when the order they were
+// taken for isn't paid for. Products and variants removed since are
+// skipped. If the change can't be saved none of the stock is returned.
+func (s *ProductStore) ReturnStock(items []OrderItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	returned := make(map[int]Product)
+	for _, item := range items {
+		product, exists := returned[item.ProductID]
+		if !exists {
+			product, exists = s.products[item.ProductID]
+		}
+		if !exists || variantOf(product, item.Key()) != nil {
+			continue
+		}
+		product = product.withStockChange(item.VariantID, item.Quantity)
+		product.Sold -= item.Quantity
+		returned[product.ID] = product
+	}
+	return s.putUnlocked(slices.Collect(maps.Values(returned))...)
+}
+
+// MergeCart moves a guest's cart into their account's cart when they log
+// in. Quantities of items in both carts are added together, then capped
+// at the stock available to the account's cart, so anything sold out in
+// the meantime is dropped. Bundles come along for as long as their items
+// do. The guest's reservations are released; with a
+// ttl the account's cart reserves what it now holds instead. If a change
+// to either cart can't be saved the merge stops there and the guest's cart
+// is kept.
+func (s *ProductStore) MergeCart(from, into *Cart, ttl time.Duration) error {
+	items := from.GetItems()
+	// Read the account's cart before locking the store: carts check stock
+	// while locked, so the store must never wait on a cart
+	held := make(map[ItemKey]int, len(items))
+	for _, item := range items {
+		held[item.Key()] = into.Quantity(item.Key())
+	}
+
+	type merged struct {
+		product  Product
+		key      ItemKey
+		quantity int
+	}
+	var result []merged
+
+	s.mu.Lock()
+	delete(s.reservations, from.ID)
+	for _, item := range items {
+		key := item.Key()
+		product, exists := s.products[key.ProductID]
+		if !exists || variantOf(product, key) != nil {
+			continue
+		}
+
+		available := product.StockOf(key.VariantID) - s.reservedUnlocked(key, into.ID)
+		quantity := max(min(held[key]+item.Quantity, available), 0)
+		switch {
+		case quantity == 0:
+			s.releaseUnlocked(into.ID, key)
+		case ttl > 0:
+			s.reserveUnlocked(into.ID, key, quantity, ttl)
+		}
+		result = append(result, merged{product: product, key: key, quantity: quantity})
+	}
+	s.mu.Unlock()
+
+	for _, m := range result {
+		var err error
+		switch held := into.Quantity(m.key); {
+		case held == 0 && m.quantity > 0:
+			err = into.AddItem(m.product, m.key.VariantID, m.quantity)
+		case held > 0:
+			err = into.UpdateQuantity(m.key, m.quantity)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := into.mergeBundles(from.GetBundles()); err != nil {
+		return err
+	}
+	return from.Clear()
+}
+
+// checkUnlocked reports whether quantity of a product, or of a variant of
+// it, is available to a cart. The caller must hold s.mu.
+func (s *ProductStore) checkUnlocked(cartID string, key ItemKey, quantity int) error {
+	product, exists := s.products[key.ProductID]
+	if !exists {
+		return fmt.Errorf("%w: %d", ErrProductNotFound, key.ProductID)
+	}
+	if product.Archived {
+		return fmt.Errorf("%w: %d is archived", ErrProductNotFound, key.ProductID)
+	}
+	if err := variantOf(product, key); err != nil {
+		return err
+	}
+
+	available := product.StockOf(key.VariantID) - s.reservedUnlocked(key, cartID)
+	if available < quantity {
+		name := product.Name
+		if variant, ok := product.Variant(key.VariantID); ok {
+			name += " (" + variant.Label() + ")"
+		}
+		return &InsufficientStockError{
+			ProductID: key.ProductID,
+			VariantID: key.VariantID,
+			Name:      name,
+			Requested: quantity,
+			Available: max(available, 0),
+		}
+	}
+	return nil
+}
+
+// variantOf checks that the key names one of the product's variants if it
+// has any, or none if it hasn't
+func variantOf(product Product, key ItemKey) error {
+	switch _, ok := product.Variant(key.VariantID); {
+	case product.HasVariants() && key.VariantID == 0:
+		return fmt.Errorf("%w: %s", ErrVariantRequired, product.Name)
+	case key.VariantID != 0 && !ok:
+		return fmt.Errorf("%w: variant %d of %d", ErrProductNotFound, key.VariantID, key.ProductID)
+	}
+	return nil
+}
+
+// reservedUnlocked returns how much of a product or variant carts other
+// than except hold in unexpired reservations. The caller must hold s.mu.
+func (s *ProductStore) reservedUnlocked(key ItemKey, except string) int {
+	now := s.now()
+	reserved := 0
+	for cartID, held := range s.reservations {
+		if r, ok := held[key]; ok && cartID != except && now.Before(r.expiresAt) {
+			reserved += r.quantity
+		}
+	}
+	return reserved
+}
+
+// pruneUnlocked drops expired reservations, which abandoned carts leave
+// behind. The caller must hold s.mu.
+func (s *ProductStore) pruneUnlocked() {
+	now := s.now()
+	for cartID, held := range s.reservations {
+		for key, r := range held {
+			if !now.Before(r.expiresAt) {
+				s.releaseUnlocked(cartID, key)
+			}
+		}
+	}
+}
+
+// reserveUnlocked sets a cart's reservation of a product or variant. The
+// caller must hold s.mu.
+func (s *ProductStore) reserveUnlocked(cartID string, key ItemKey, quantity int, ttl time.Duration) {
+	if s.reservations[cartID] == nil {
+		s.reservations[cartID] = make(map[ItemKey]reservation)
+	}
+	s.reservations[cartID][key] = reservation{quantity: quantity, expiresAt: s.now().Add(ttl)}
+}
+
+// releaseUnlocked drops a cart's reservation of a product or variant. The
+// caller must hold s.mu.
+func (s *ProductStore) releaseUnlocked(cartID string, key ItemKey) {
+	delete(s.reservations[cartID], key)
+	if len(s.reservations[cartID]) == 0 {
+		delete(s.reservations, cartID)
+	}
+}
@@ -0,0 +1,333 @@
+package models
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTakeStock(t *testing.T) {
+	store := NewProductStore()
+	p1, _ := store.Add(Product{Name: "P1", Price: usd(1000), Stock: 5})
+	p2, _ := store.Add(Product{Name: "P2", Price: usd(2000), Stock: 2})
+
+	// The cart holds the price from when the product was added
+	stale := p1
+	stale.Price = usd(800)
+
+	items, err := store.TakeStock("cart", []CartItem{{Product: stale, Quantity: 3}, {Product: p2, Quantity: 2}})
+	if err != nil {
+		t.Fatalf("TakeStock() failed: %v", err)
+	}
+	if len(items) != 2 || items[0].Price != usd(1000) || items[0].Name != "P1" || items[0].Quantity != 3 {
+		t.Errorf("Expected items priced as the product is now, got %+v", items)
+	}
+
+	if product, _ := store.GetByID(p1.ID); product.Stock != 2 || product.Sold != 3 {
+		t.Errorf("Expected stock 2 and 3 sold, got %d and %d", product.Stock, product.Sold)
+	}
+	if product, _ := store.GetByID(p2.ID); product.Stock != 0 {
+		t.Errorf("Expected stock 0, got %d", product.Stock)
+	}
+}
+
+func TestTakeStockAllOrNothing(t *testing.T) {
+	store := NewProductStore()
+	p1, _ := store.Add(Product{Name: "P1", Price: usd(1000), Stock: 5})
+	p2, _ := store.Add(Product{Name: "P2", Price: usd(2000), Stock: 1})
+
+	if _, err := store.TakeStock("cart", []CartItem{{Product: p1, Quantity: 1}, {Product: p2, Quantity: 2}}); !errors.Is(err, ErrInsufficientStock) {
+		t.Errorf("Expected ErrInsufficientStock, got %v", err)
+	}
+	if _, err := store.TakeStock("cart", []CartItem{{Product: p1, Quantity: 1}, {Product: Product{ID: 99}, Quantity: 1}}); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound, got %v", err)
+	}
+	if _, err := store.TakeStock("cart", nil); !errors.Is(err, ErrEmptyCart) {
+		t.Errorf("Expected ErrEmptyCart, got %v", err)
+	}
+
+	if product, _ := store.GetByID(p1.ID); product.Stock != 5 {
+		t.Errorf("Expected stock untouched after a failed order, got %d", product.Stock)
+	}
+}
+
+func TestReturnStock(t *testing.T) {
+	store := NewProductStore()
+	p1, _ := store.Add(Product{Name: "P1", Price: usd(1000), Stock: 5})
+
+	items, _ := store.TakeStock("cart", []CartItem{{Product: p1, Quantity: 2}})
+	store.ReturnStock(items)
+
+	if product, _ := store.GetByID(p1.ID); product.Stock != 5 || product.Sold != 0 {
+		t.Errorf("Expected stock 5 and none sold, got %d and %d", product.Stock, product.Sold)
+	}
+}
+
+func TestInsufficientStockError(t *testing.T) {
+	store := NewProductStore()
+	p, _ := store.Add(Product{Name: "P1", Price: usd(1000), Stock: 2})
+
+	err := store.CheckStock("cart", ItemKey{ProductID: p.ID}, 3)
+	var stockErr *InsufficientStockError
+	if !errors.As(err, &stockErr) {
+		t.Fatalf("Expected an InsufficientStockError, got %v", err)
+	}
+	if stockErr.Name != "P1" || stockErr.Requested != 3 || stockErr.Available != 2 {
+		t.Errorf("Unexpected error details: %+v", stockErr)
+	}
+	if !errors.Is(err, ErrInsufficientStock) {
+		t.Error("InsufficientStockError should match ErrInsufficientStock")
+	}
+}
+
+func TestReserve(t *testing.T) {
+	store := NewProductStore()
+	p, _ := store.Add(Product{Name: "P1", Price: usd(1000), Stock: 5})
+
+	if err := store.Reserve("a", ItemKey{ProductID: p.ID}, 3, time.Minute); err != nil {
+		t.Fatalf("Reserve() failed: %v", err)
+	}
+	if store.Available(ItemKey{ProductID: p.ID}) != 2 {
+		t.Errorf("Expected 2 available, got %d", store.Available(ItemKey{ProductID: p.ID}))
+	}
+
+	// Another cart can't have what is held for the first
+	if err := store.Reserve("b", ItemKey{ProductID: p.ID}, 3, time.Minute); !errors.Is(err, ErrInsufficientStock) {
+		t.Errorf("Expected ErrInsufficientStock, got %v", err)
+	}
+	// The first cart's reservation is replaced, not added to
+	if err := store.Reserve("a", ItemKey{ProductID: p.ID}, 5, time.Minute); err != nil {
+		t.Errorf("Expected the cart to grow its own reservation, got %v", err)
+	}
+	if err := store.Reserve("a", ItemKey{ProductID: p.ID}, 6, time.Minute); !errors.Is(err, ErrInsufficientStock) {
+		t.Errorf("Expected ErrInsufficientStock beyond stock, got %v", err)
+	}
+
+	store.Release("a", ItemKey{ProductID: p.ID})
+	if store.Available(ItemKey{ProductID: p.ID}) != 5 {
+		t.Errorf("Expected 5 available after release, got %d", store.Available(ItemKey{ProductID: p.ID}))
+	}
+	if err := store.Reserve("a", ItemKey{ProductID: 99}, 1, time.Minute); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound, got %v", err)
+	}
+}
+
+func TestReservationExpires(t *testing.T) {
+	store := NewProductStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	p, _ := store.Add(Product{Name: "P1", Price: usd(1000), Stock: 5})
+
+	store.Reserve("a", ItemKey{ProductID: p.ID}, 5, 15*time.Minute)
+	if store.Available(ItemKey{ProductID: p.ID}) != 0 {
+		t.Errorf("Expected nothing available, got %d", store.Available(ItemKey{ProductID: p.ID}))
+	}
+
+	now = now.Add(15 * time.Minute)
+	if store.Available(ItemKey{ProductID: p.ID}) != 5 {
+		t.Errorf("Expected an expired reservation to free its stock, got %d", store.Available(ItemKey{ProductID: p.ID}))
+	}
+	if err := store.Reserve("b", ItemKey{ProductID: p.ID}, 5, 15*time.Minute); err != nil {
+		t.Errorf("Expected another cart to reserve freed stock, got %v", err)
+	}
+}
+
+func TestTakeStockUsesReservations(t *testing.T) {
+	store := NewProductStore()
+	p, _ := store.Add(Product{Name: "P1", Price: usd(1000), Stock: 5})
+	store.Reserve("a", ItemKey{ProductID: p.ID}, 3, time.Minute)
+	store.Reserve("b", ItemKey{ProductID: p.ID}, 2, time.Minute)
+
+	// A cart without a reservation can't take held stock
+	if _, err := store.TakeStock("c", []CartItem{{Product: p, Quantity: 1}}); !errors.Is(err, ErrInsufficientStock) {
+		t.Errorf("Expected ErrInsufficientStock, got %v", err)
+	}
+
+	if _, err := store.TakeStock("a", []CartItem{{Product: p, Quantity: 3}}); err != nil {
+		t.Fatalf("TakeStock() failed: %v", err)
+	}
+	if product, _ := store.GetByID(p.ID); product.Stock != 2 {
+		t.Errorf("Expected stock 2, got %d", product.Stock)
+	}
+	// The reservation is used up; the other cart's still holds the rest
+	if store.Available(ItemKey{ProductID: p.ID}) != 0 {
+		t.Errorf("Expected nothing available, got %d", store.Available(ItemKey{ProductID: p.ID}))
+	}
+	store.ReleaseAll("b")
+	if store.Available(ItemKey{ProductID: p.ID}) != 2 {
+		t.Errorf("Expected 2 available, got %d", store.Available(ItemKey{ProductID: p.ID}))
+	}
+}
+
+func TestMergeCart(t *testing.T) {
+	store := NewProductStore()
+	p1, _ := store.Add(Product{Name: "P1", Price: usd(1000), Stock: 5})
+	p2, _ := store.Add(Product{Name: "P2", Price: usd(2000), Stock: 4})
+	p3, _ := store.Add(Product{Name: "P3", Price: usd(3000), Stock: 1})
+
+	guest := NewCart()
+	guest.AddItem(p1, 0, 2)
+	guest.AddItem(p2, 0, 3)
+	guest.AddItem(p3, 0, 1)
+	store.Reserve(guest.ID, ItemKey{ProductID: p1.ID}, 2, time.Minute)
+
+	account := NewCart()
+	account.AddItem(p2, 0, 2)
+
+	// Someone else holds the last P3
+	store.Reserve("other", ItemKey{ProductID: p3.ID}, 1, time.Minute)
+
+	store.MergeCart(guest, account, time.Minute)
+
+	if account.Quantity(ItemKey{ProductID: p1.ID}) != 2 {
+		t.Errorf("Expected P1 moved over, got %d", account.Quantity(ItemKey{ProductID: p1.ID}))
+	}
+	if account.Quantity(ItemKey{ProductID: p2.ID}) != 4 {
+		t.Errorf("Expected P2 added up and capped at stock, got %d", account.Quantity(ItemKey{ProductID: p2.ID}))
+	}
+	if account.Quantity(ItemKey{ProductID: p3.ID}) != 0 {
+		t.Errorf("Expected P3 dropped, got %d", account.Quantity(ItemKey{ProductID: p3.ID}))
+	}
+	if guest.GetItemCount() != 0 {
+		t.Error("Expected the guest cart emptied")
+	}
+
+	// The account's cart now holds the stock instead of the guest's
+	if err := store.CheckStock(guest.ID, ItemKey{ProductID: p1.ID}, 4); !errors.Is(err, ErrInsufficientStock) {
+		t.Errorf("Expected P1 held for the account, got %v", err)
+	}
+	if store.Available(ItemKey{ProductID: p2.ID}) != 0 {
+		t.Errorf("Expected all of P2 reserved, got %d available", store.Available(ItemKey{ProductID: p2.ID}))
+	}
+}
+
+func TestAdjustStock(t *testing.T) {
+	store := NewProductStore()
+	p1, _ := store.Add(Product{Name: "P1", Price: usd(1000), Stock: 5})
+
+	if product, err := store.AdjustStock(ItemKey{ProductID: p1.ID}, 10); err != nil || product.Stock != 15 {
+		t.Errorf("Expected stock 15, got %d, %v", product.Stock, err)
+	}
+	if product, err := store.AdjustStock(ItemKey{ProductID: p1.ID}, -15); err != nil || product.Stock != 0 {
+		t.Errorf("Expected stock 0, got %d, %v", product.Stock, err)
+	}
+	if _, err := store.AdjustStock(ItemKey{ProductID: p1.ID}, -1); !errors.Is(err, ErrNegativeStock) {
+		t.Errorf("Expected ErrNegativeStock, got %v", err)
+	}
+	if _, err := store.AdjustStock(ItemKey{ProductID: 99}, 1); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound, got %v", err)
+	}
+}
+
+func TestArchivedProductsCantBeBought(t *testing.T) {
+	store := NewProductStore()
+	p1, _ := store.Add(Product{Name: "P1", Price: usd(1000), Stock: 5})
+	store.SetArchived(p1.ID, true)
+
+	if err := store.CheckStock("cart", ItemKey{ProductID: p1.ID}, 1); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound, got %v", err)
+	}
+	if _, err := store.TakeStock("cart", []CartItem{{Product: p1, Quantity: 1}}); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound, got %v", err)
+	}
+}
+
+func TestProductStoreOnChange(t *testing.T) {
+	store := NewProductStore()
+	p1, _ := store.Add(Product{Name: "P1", Price: usd(1000), Stock: 5})
+	p2, _ := store.Add(Product{Name: "P2", Price: usd(2000), Stock: 5})
+
+	var changes []Product
+	store.OnChange(func(p Product) { changes = append(changes, p) })
+
+	store.TakeStock("cart", []CartItem{{Product: p1, Quantity: 2}, {Product: p1, Quantity: 1}, {Product: p2, Quantity: 1}})
+	if len(changes) != 2 {
+		t.Fatalf("Expected each product taken from once, got %d changes", len(changes))
+	}
+	for _, p := range changes {
+		if p.ID == p1.ID && p.Stock != 2 {
+			t.Errorf("Expected P1's new stock, got %d", p.Stock)
+		}
+	}
+
+	// Reserving doesn't change the product, and failures change nothing
+	changes = nil
+	store.Reserve("cart", ItemKey{ProductID: p2.ID}, 1, time.Minute)
+	store.AdjustStock(ItemKey{ProductID: p1.ID}, -10)
+	if len(changes) != 0 {
+		t.Errorf("Expected no changes, got %+v", changes)
+	}
+
+	store.AdjustStock(ItemKey{ProductID: p1.ID}, -1)
+	if len(changes) != 1 || !changes[0].LowStock() {
+		t.Errorf("Expected P1 low on stock, got %+v", changes)
+	}
+}
+
+func TestLowStock(t *testing.T) {
+	for stock, want := range map[int]bool{0: false, 1: true, LowStockThreshold: true, LowStockThreshold + 1: false} {
+		if got := (Product{Stock: stock}).LowStock(); got != want {
+			t.Errorf("LowStock() with %d in stock = %v, want %v", stock, got, want)
+		}
+	}
+}
+
+func TestLowOnStock(t *testing.T) {
+	store := NewProductStore()
+	store.Add(Product{Name: "Plenty", Price: usd(1000), Stock: 10})
+	store.Add(Product{Name: "Few", Price: usd(1000), Stock: 2})
+	store.Add(Product{Name: "None", Price: usd(1000)})
+	archived, _ := store.Add(Product{Name: "Archived", Price: usd(1000)})
+	store.SetArchived(archived.ID, true)
+
+	low := store.LowOnStock()
+	if len(low) != 2 || low[0].Name != "None" || low[1].Name != "Few" {
+		t.Errorf("Expected the sold-out product then the nearly sold out one, got %v", productNames(low))
+	}
+}
+
+func TestCartStockCheck(t *testing.T) {
+	store := NewProductStore()
+	p, _ := store.Add(Product{Name: "P1", Price: usd(1000), Stock: 5})
+	key := ItemKey{ProductID: p.ID}
+	cart := NewCart()
+	check := func(total int) error { return store.CheckStock(cart.ID, key, total) }
+
+	// Adding again counts what the cart holds already
+	if err := cart.AddItemChecked(p, 0, 3, check); err != nil {
+		t.Fatalf("AddItemChecked() failed: %v", err)
+	}
+	err := cart.AddItemChecked(p, 0, 3, check)
+	var stockErr *InsufficientStockError
+	if !errors.As(err, &stockErr) || stockErr.Requested != 6 || stockErr.Available != 5 {
+		t.Fatalf("Expected 6 requested of 5 available, got %v", err)
+	}
+	if cart.Quantity(key) != 3 {
+		t.Errorf("Expected the cart to keep 3 after the failed add, got %d", cart.Quantity(key))
+	}
+
+	if err := cart.UpdateQuantityChecked(key, 6, check); !errors.Is(err, ErrInsufficientStock) {
+		t.Errorf("Expected ErrInsufficientStock updating beyond stock, got %v", err)
+	}
+	if err := cart.UpdateQuantityChecked(key, 5, check); err != nil || cart.Quantity(key) != 5 {
+		t.Errorf("Expected to update to all 5 in stock, got %d, %v", cart.Quantity(key), err)
+	}
+
+	// Adds made at once can't both pass on the same count
+	store.Release(cart.ID, key)
+	cart = NewCart()
+	reserve := func(total int) error { return store.Reserve(cart.ID, key, total, time.Minute) }
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cart.AddItemChecked(p, 0, 1, reserve)
+		}()
+	}
+	wg.Wait()
+	if cart.Quantity(key) != 5 || store.Available(key) != 0 {
+		t.Errorf("Expected 5 in the cart and held for it, got %d in the cart and %d available", cart.Quantity(key), store.Available(key))
+	}
+}
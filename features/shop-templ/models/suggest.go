@@ -0,0 +1,92 @@
+package models
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// CategorySuggestion is a category completing a search, with how many
+// products are in it or its sub-categories
+type CategorySuggestion struct {
+	// Category is the category's path
+	Category string
+	Products int
+}
+
+// Suggestions are the completions for what a customer has typed so far
+type Suggestions struct {
+	Products   []Product
+	Categories []CategorySuggestion
+}
+
+// Suggest completes a partly typed search with up to n product names and
+// n categories. A name completes it if one of its words starts with what
+// was typed, and a category if its own name does. Names that start with
+// it come first, then the best sellers; categories with the most products
+// come first.
+func (s *ProductStore) Suggest(prefix string, n int) Suggestions {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if prefix == "" || n <= 0 {
+		return Suggestions{}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var suggestions Suggestions
+	counts := make(map[string]int)
+	for _, p := range s.getAllUnlocked() {
+		if completesName(p.Name, prefix) {
+			suggestions.Products = append(suggestions.Products, p)
+		}
+		for _, category := range CategoryAncestors(p.Category) {
+			if strings.HasPrefix(strings.ToLower(CategoryName(category)), prefix) {
+				counts[category]++
+			}
+		}
+	}
+
+	startsWith := func(p Product) bool {
+		return strings.HasPrefix(strings.ToLower(p.Name), prefix)
+	}
+	slices.SortStableFunc(suggestions.Products, func(a, b Product) int {
+		return cmp.Or(compareBool(startsWith(b), startsWith(a)), cmp.Compare(b.Sold, a.Sold))
+	})
+	suggestions.Products = suggestions.Products[:min(len(suggestions.Products), n)]
+
+	for category, products := range counts {
+		suggestions.Categories = append(suggestions.Categories, CategorySuggestion{category, products})
+	}
+	slices.SortFunc(suggestions.Categories, func(a, b CategorySuggestion) int {
+		return cmp.Or(cmp.Compare(b.Products, a.Products), cmp.Compare(a.Category, b.Category))
+	})
+	suggestions.Categories = suggestions.Categories[:min(len(suggestions.Categories), n)]
+
+	return suggestions
+}
+
+// completesName reports whether a lowercase prefix is the start of the
+// name or of the name from one of its words on, so "무선 이" completes
+// "무선 이어폰" and "이어" does too
+func completesName(name, prefix string) bool {
+	words := strings.Fields(strings.ToLower(name))
+	for i := range words {
+		if strings.HasPrefix(strings.Join(words[i:], " "), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareBool orders false before true
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+)
+
+func suggestedNames(s Suggestions) []string {
+	names := make([]string, len(s.Products))
+	for i, p := range s.Products {
+		names[i] = p.Name
+	}
+	return names
+}
+
+func TestSuggest(t *testing.T) {
+	store := NewProductStore()
+	store.Add(Product{Name: "블루투스 무선 스피커", Category: "전자제품"})
+	store.Add(Product{Name: "무선 마우스", Category: "전자제품", Sold: 1})
+	store.Add(Product{Name: "무선 이어폰", Category: "전자제품", Sold: 5})
+	store.Add(Product{Name: "무릎 담요", Category: "생활용품"})
+	store.Add(Product{Name: "텀블러", Category: "생활용품"})
+
+	// Names starting with the prefix first, best sellers first among them
+	got := suggestedNames(store.Suggest("무선", 5))
+	if fmt.Sprint(got) != "[무선 이어폰 무선 마우스 블루투스 무선 스피커]" {
+		t.Errorf("Unexpected suggestions for 무선: %v", got)
+	}
+
+	// Any word of the name, and a prefix spanning words
+	if got := suggestedNames(store.Suggest("무선 이", 5)); fmt.Sprint(got) != "[무선 이어폰]" {
+		t.Errorf("Unexpected suggestions for 무선 이: %v", got)
+	}
+	if got := suggestedNames(store.Suggest("스피", 5)); fmt.Sprint(got) != "[블루투스 무선 스피커]" {
+		t.Errorf("Unexpected suggestions for 스피: %v", got)
+	}
+
+	// At most n of each
+	if got := store.Suggest("무", 2); len(got.Products) != 2 {
+		t.Errorf("Expected 2 suggestions, got %d", len(got.Products))
+	}
+
+	// Categories with how many products they have, biggest first
+	categories := store.Suggest("전자", 5).Categories
+	if len(categories) != 1 || categories[0] != (CategorySuggestion{"전자제품", 3}) {
+		t.Errorf("Unexpected category suggestions: %+v", categories)
+	}
+
+	if got := store.Suggest("  ", 5); len(got.Products) != 0 || len(got.Categories) != 0 {
+		t.Errorf("Expected no suggestions for a blank prefix, got %+v", got)
+	}
+}
@@ -0,0 +1,167 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// TaxRule is a tax charged on products of a category shipped to a country
+type TaxRule struct {
+	Name string `json:"name"`
+	// Rate is in basis points: 1000 is 10%
+	Rate int `json:"rate"`
+	// Category limits the rule to products in it or its sub-categories;
+	// empty means any category
+	Category string `json:"category,omitempty"`
+	// Country limits the rule to orders shipped there; empty means anywhere
+	Country string `json:"country,omitempty"`
+}
+
+// matches reports whether the rule applies to a product of category
+// shipped to country
+func (r TaxRule) matches(category, country string) bool {
+	return (r.Category == "" || InCategory(category, r.Category)) && (r.Country == "" || r.Country == country)
+}
+
+// specificity ranks rules applying to the same product: a category beats
+// a country, and both beat neither. A sub-category beats the categories
+// above it.
+func (r TaxRule) specificity() int {
+	n := 0
+	if r.Category != "" {
+		n += 2 * len(CategoryAncestors(r.Category))
+	}
+	if r.Country != "" {
+		n++
+	}
+	return n
+}
+
+// TaxRules decide the tax on each product of an order. Of the rules that
+// apply to a product, the most specific wins, and the first listed of
+// equally specific ones. A product no rule applies to isn't taxed, and a
+// rule with a zero rate exempts the products it is the winner for.
+type TaxRules []TaxRule
+
+// DefaultTaxRules charge Korean VAT on everything shipped within Korea.
+// Exports are zero-rated, so nothing else is taxed.
+var DefaultTaxRules = TaxRules{
+	{Name: "부가세", Rate: 1000, Country: DefaultCountry},
+}
+
+// LoadTaxRules reads tax rules from a JSON file holding a list of them
+func LoadTaxRules(path string) (TaxRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read tax rules: %w", err)
+	}
+
+	var rules TaxRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("decode tax rules: %w", err)
+	}
+	for _, rule := range rules {
+		if rule.Name == "" || rule.Rate < 0 {
+			return nil, fmt.Errorf("tax rule %+v needs a name and a rate of at least 0", rule)
+		}
+	}
+
+	return rules, nil
+}
+
+// rule returns the rule that applies to a product of category shipped to
+// country, if any
+func (rules TaxRules) rule(category, country string) (TaxRule, bool) {
+	var best TaxRule
+	found := false
+	for _, rule := range rules {
+		if rule.matches(category, country) && (!found || rule.specificity() > best.specificity()) {
+			best, found = rule, true
+		}
+	}
+	return best, found
+}
+
+// TaxLine is the tax charged under one rule
+type TaxLine struct {
+	Name string `json:"name"`
+	Rate int    `json:"rate"`
+	// Taxable is what the rule was charged on, after its share of the discount
+	Taxable Money `json:"taxable"`
+	Amount  Money `json:"amount"`
+}
+
+// TaxLines are the taxes on an order, one per rule charged
+type TaxLines []TaxLine
+
+// Total returns the tax on the order
+func (lines TaxLines) Total() Money {
+	var total Money
+	for _, line := range lines {
+		total = total.Add(line.Amount)
+	}
+	return total
+}
+
+// taxable is an amount of an order taxed by the rules for its category
+type taxable struct {
+	category string
+	amount   Money
+}
+
+// ForCart returns the taxes on the cart, after bundle savings and its
+// coupon discount, if it were shipped to country
+func (rules TaxRules) ForCart(cart *Cart, country string) TaxLines {
+	var amounts []taxable
+	for _, item := range cart.GetItems() {
+		amounts = append(amounts, taxable{item.Product.Category, item.Subtotal()})
+	}
+	return rules.calculate(amounts, cart.BundleDiscount().Add(cart.Discount()), country)
+}
+
+// ForOrder returns the taxes on an order's items, after bundle savings and
+// its discount
+func (rules TaxRules) ForOrder(order Order) TaxLines {
+	var amounts []taxable
+	for _, item := range order.Items {
+		amounts = append(amounts, taxable{item.Category, item.Subtotal()})
+	}
+	return rules.calculate(amounts, order.BundleDiscount().Add(order.Discount), order.Shipping.Destination())
+}
+
+// calculate charges each rule on the amounts it wins for. The discount is
+// shared among the rules in proportion to their amounts, so a coupon lowers
+// the tax it was taken off, and each rule's tax is rounded once.
+func (rules TaxRules) calculate(amounts []taxable, discount Money, country string) TaxLines {
+	var subtotal Money
+	var lines TaxLines
+	for _, a := range amounts {
+		subtotal = subtotal.Add(a.amount)
+
+		rule, ok := rules.rule(a.category, country)
+		if !ok || rule.Rate == 0 {
+			continue
+		}
+		i := 0
+		for i < len(lines) && (lines[i].Name != rule.Name || lines[i].Rate != rule.Rate) {
+			i++
+		}
+		if i == len(lines) {
+			lines = append(lines, TaxLine{Name: rule.Name, Rate: rule.Rate})
+		}
+		lines[i].Taxable = lines[i].Taxable.Add(a.amount)
+	}
+
+	if !discount.IsZero() && !subtotal.IsZero() {
+		for i := range lines {
+			share := discount.Amount * lines[i].Taxable.Amount / subtotal.Amount
+			lines[i].Taxable = lines[i].Taxable.Sub(NewMoney(share, discount.Currency))
+		}
+	}
+	for i := range lines {
+		lines[i].Amount = lines[i].Taxable.BasisPoints(lines[i].Rate)
+	}
+
+	return lines
+}
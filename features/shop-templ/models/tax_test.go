@@ -0,0 +1,97 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testTaxRules = TaxRules{
+	{Name: "부가세", Rate: 1000, Country: "KR"},
+	{Name: "면세", Rate: 0, Category: "식품", Country: "KR"},
+	{Name: "Sales tax", Rate: 800, Country: "US"},
+	{Name: "Luxury tax", Rate: 2000, Category: "명품"},
+}
+
+func TestTaxRulesPickMostSpecific(t *testing.T) {
+	tests := []struct {
+		category, country string
+		want              string
+	}{
+		{"전자제품", "KR", "부가세"},
+		{"식품", "KR", "면세"},
+		{"전자제품", "US", "Sales tax"},
+		{"명품", "KR", "Luxury tax"},
+		{"전자제품", "JP", ""},
+	}
+
+	for _, tt := range tests {
+		rule, _ := testTaxRules.rule(tt.category, tt.country)
+		if rule.Name != tt.want {
+			t.Errorf("%s to %s: expected %q, got %q", tt.category, tt.country, tt.want, rule.Name)
+		}
+	}
+}
+
+func TestTaxRulesForCart(t *testing.T) {
+	cart := NewCart()
+	cart.AddItem(Product{ID: 1, Category: "전자제품", Price: Won(30000)}, 0, 2)
+	cart.AddItem(Product{ID: 2, Category: "식품", Price: Won(15000)}, 0, 1)
+	cart.AddItem(Product{ID: 3, Category: "명품", Price: Won(15000)}, 0, 1)
+
+	lines := testTaxRules.ForCart(cart, "KR")
+	if len(lines) != 2 {
+		t.Fatalf("Expected VAT and luxury tax lines, got %+v", lines)
+	}
+	if lines[0].Name != "부가세" || lines[0].Taxable != Won(60000) || lines[0].Amount != Won(6000) {
+		t.Errorf("Unexpected VAT line: %+v", lines[0])
+	}
+	if lines[1].Amount != Won(3000) || lines[1].Rate != 2000 {
+		t.Errorf("Unexpected luxury tax line: %+v", lines[1])
+	}
+	if total := TaxLines(lines).Total(); total != Won(9000) {
+		t.Errorf("Expected ₩9,000 of tax, got %v", total)
+	}
+
+	if lines := testTaxRules.ForCart(cart, "JP"); len(lines) != 1 || lines[0].Name != "Luxury tax" {
+		t.Errorf("Expected only the luxury tax abroad, got %+v", lines)
+	}
+}
+
+func TestTaxRulesShareDiscount(t *testing.T) {
+	// A ₩9,000 discount on ₩90,000: each line is taxed on 90% of its amount
+	order := Order{
+		Items: []OrderItem{
+			{Category: "전자제품", Price: Won(60000), Quantity: 1},
+			{Category: "식품", Price: Won(30000), Quantity: 1},
+		},
+		Discount: Won(9000),
+	}
+
+	lines := testTaxRules.ForOrder(order)
+	if len(lines) != 1 || lines[0].Taxable != Won(54000) || lines[0].Amount != Won(5400) {
+		t.Errorf("Expected VAT on ₩54,000, got %+v", lines)
+	}
+}
+
+func TestLoadTaxRules(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tax.json")
+	os.WriteFile(path, []byte(`[{"name": "VAT", "rate": 1000, "country": "KR"}, {"name": "Books", "rate": 0, "category": "도서"}]`), 0o644)
+
+	rules, err := LoadTaxRules(path)
+	if err != nil {
+		t.Fatalf("LoadTaxRules() failed: %v", err)
+	}
+	if len(rules) != 2 || rules[1].Category != "도서" {
+		t.Errorf("Unexpected rules: %+v", rules)
+	}
+
+	os.WriteFile(path, []byte(`[{"rate": 1000}]`), 0o644)
+	if _, err := LoadTaxRules(path); err == nil {
+		t.Error("Expected a rule without a name to be rejected")
+	}
+	if _, err := LoadTaxRules(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Expected a missing file to be an error")
+	}
+}
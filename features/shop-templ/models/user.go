@@ -0,0 +1,156 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidCredentials is returned when an email/password pair doesn't match
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrUserExists is returned when registering an email that already has an account
+	ErrUserExists = errors.New("email is already registered")
+	// ErrInvalidEmail is returned for a malformed email address
+	ErrInvalidEmail = errors.New("invalid email address")
+	// ErrPasswordTooShort is returned when registering with a short password
+	ErrPasswordTooShort = errors.New("password must be at least 8 characters")
+	// ErrUserNotFound is returned for an account ID that doesn't exist
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// MinPasswordLength is the shortest accepted password
+const MinPasswordLength = 8
+
+// User represents a customer account
+type User struct {
+	ID           int       `json:"id"`
+	Email        string    `json:"email"`
+	Name         string    `json:"name"`
+	PasswordHash []byte    `json:"-"`
+	CreatedAt    time.Time `json:"createdAt"`
+	// Admin accounts can manage the catalog
+	Admin bool `json:"admin"`
+}
+
+// UserStore manages customer accounts with thread-safe operations
+type UserStore struct {
+	mu      sync.RWMutex
+	users   map[int]User
+	byEmail map[string]int
+	nextID  int
+}
+
+// NewUserStore creates a new user store
+func NewUserStore() *UserStore {
+	return &UserStore{
+		users:   make(map[int]User),
+		byEmail: make(map[string]int),
+		nextID:  1,
+	}
+}
+
+// Register creates an account with a bcrypt-hashed password
+func (s *UserStore) Register(email, name, password string) (User, error) {
+	email = normalizeEmail(email)
+	name = strings.TrimSpace(name)
+
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return User{}, ErrInvalidEmail
+	}
+	if name == "" {
+		name = email[:strings.Index(email, "@")]
+	}
+	if len(password) < MinPasswordLength {
+		return User{}, ErrPasswordTooShort
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return User{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.byEmail[email]; exists {
+		return User{}, ErrUserExists
+	}
+
+	user := User{
+		ID:           s.nextID,
+		Email:        email,
+		Name:         name,
+		PasswordHash: hash,
+		CreatedAt:    time.Now(),
+	}
+	s.nextID++
+	s.users[user.ID] = user
+	s.byEmail[email] = user.ID
+
+	return user, nil
+}
+
+// Authenticate returns the user if the password matches
+func (s *UserStore) Authenticate(email, password string) (User, error) {
+	s.mu.RLock()
+	id, exists := s.byEmail[normalizeEmail(email)]
+	user := s.users[id]
+	s.mu.RUnlock()
+
+	if !exists {
+		return User{}, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password)); err != nil {
+		return User{}, ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+// GetByID retrieves a user by their ID
+func (s *UserStore) GetByID(id int) (User, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, exists := s.users[id]
+	return user, exists
+}
+
+// SetAdmin grants or revokes an account's admin rights
+func (s *UserStore) SetAdmin(id int, admin bool) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[id]
+	if !exists {
+		return User{}, ErrUserNotFound
+	}
+	user.Admin = admin
+	s.users[id] = user
+
+	return user, nil
+}
+
+// normalizeEmail makes emails match regardless of case and surrounding space
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+type userContextKey struct{}
+
+// ContextWithUser returns a copy of ctx carrying the logged-in user
+func ContextWithUser(ctx context.Context, user User) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
+// UserFromContext returns the logged-in user, if any
+func UserFromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(userContextKey{}).(User)
+	return user, ok
+}
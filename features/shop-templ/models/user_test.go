@@ -0,0 +1,100 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegister(t *testing.T) {
+	store := NewUserStore()
+
+	user, err := store.Register("  Kim@Example.com ", "", "password123")
+	if err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+	if user.ID == 0 || user.Email != "kim@example.com" || user.Name != "kim" {
+		t.Errorf("Unexpected user: %+v", user)
+	}
+	if string(user.PasswordHash) == "password123" {
+		t.Error("Password should be stored hashed")
+	}
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		err      error
+	}{
+		{"taken email", "KIM@example.com", "password123", ErrUserExists},
+		{"invalid email", "not-an-email", "password123", ErrInvalidEmail},
+		{"named address", "Kim <kim2@example.com>", "password123", ErrInvalidEmail},
+		{"short password", "lee@example.com", "short", ErrPasswordTooShort},
+	}
+	for _, tt := range tests {
+		if _, err := store.Register(tt.email, "", tt.password); !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.err, err)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	store := NewUserStore()
+	registered, _ := store.Register("kim@example.com", "김철수", "password123")
+
+	user, err := store.Authenticate("KIM@example.com", "password123")
+	if err != nil || user.ID != registered.ID {
+		t.Errorf("Expected to log in as %d, got %+v, %v", registered.ID, user, err)
+	}
+	if _, err := store.Authenticate("kim@example.com", "wrong-password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials, got %v", err)
+	}
+	if _, err := store.Authenticate("nobody@example.com", "password123"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials, got %v", err)
+	}
+
+	if found, exists := store.GetByID(registered.ID); !exists || found.Name != "김철수" {
+		t.Errorf("Expected to find user %d", registered.ID)
+	}
+}
+
+func TestSetAdmin(t *testing.T) {
+	store := NewUserStore()
+	registered, _ := store.Register("kim@example.com", "", "password123")
+
+	user, err := store.SetAdmin(registered.ID, true)
+	if err != nil || !user.Admin {
+		t.Fatalf("Expected admin user, got %+v, %v", user, err)
+	}
+	if found, _ := store.GetByID(registered.ID); !found.Admin {
+		t.Error("Expected admin flag to be stored")
+	}
+	if _, err := store.SetAdmin(999, true); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestSessionStore(t *testing.T) {
+	store := NewSessionStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	token := store.Create(7)
+	if userID, ok := store.Get(token); !ok || userID != 7 {
+		t.Errorf("Expected the session for user 7, got %d, %v", userID, ok)
+	}
+	if _, ok := store.Get("unknown"); ok {
+		t.Error("Unknown tokens should not resolve")
+	}
+
+	store.Delete(token)
+	if _, ok := store.Get(token); ok {
+		t.Error("Deleted sessions should not resolve")
+	}
+
+	token = store.Create(7)
+	now = now.Add(SessionTTL)
+	if _, ok := store.Get(token); ok {
+		t.Error("Expired sessions should not resolve")
+	}
+}
@@ -0,0 +1,192 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidVariant is returned for variants that don't fit their
+	// product's options, share a SKU or combination of values, or have
+	// negative stock or a price below zero
+	ErrInvalidVariant = errors.New("invalid product variant")
+	// ErrVariantRequired is returned for buying a product that comes in
+	// variants without choosing one
+	ErrVariantRequired = errors.New("choose a variant of the product")
+)
+
+// ProductOption is a way a product comes in more than one kind, such as
+// its size or color, and the kinds there are
+type ProductOption struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// Variant is one kind of a product that comes in several, with its own
+// stock. Its price is the product's plus its price delta.
+type Variant struct {
+	// ID identifies the variant within its product
+	ID  int    `json:"id"`
+	SKU string `json:"sku"`
+	// Values are the variant's value for each of the product's options, in
+	// the same order
+	Values     []string `json:"values"`
+	PriceDelta Money    `json:"priceDelta,omitzero"`
+	Stock      int      `json:"stock"`
+}
+
+// Label names the variant by its values, such as "M / 블랙"
+func (v Variant) Label() string {
+	return strings.Join(v.Values, " / ")
+}
+
+// ItemKey identifies what a cart or order line holds: a product and, for
+// products that come in variants, which one
+type ItemKey struct {
+	ProductID int `json:"productId"`
+	// VariantID is zero for products without variants
+	VariantID int `json:"variantId,omitempty"`
+}
+
+// String returns the key as it is written in forms: the product ID, then
+// the variant ID after a dash if there is one
+func (k ItemKey) String() string {
+	if k.VariantID == 0 {
+		return strconv.Itoa(k.ProductID)
+	}
+	return fmt.Sprintf("%d-%d", k.ProductID, k.VariantID)
+}
+
+// HasVariants reports whether the product is sold as variants rather than
+// as itself
+func (p Product) HasVariants() bool {
+	return len(p.Variants) > 0
+}
+
+// Variant returns the product's variant with the given ID
+func (p Product) Variant(id int) (Variant, bool) {
+	i := slices.IndexFunc(p.Variants, func(v Variant) bool { return v.ID == id })
+	if i < 0 {
+		return Variant{}, false
+	}
+	return p.Variants[i], true
+}
+
+// FindVariant returns the variant with the given value for each of the
+// product's options
+func (p Product) FindVariant(values []string) (Variant, bool) {
+	i := slices.IndexFunc(p.Variants, func(v Variant) bool { return slices.Equal(v.Values, values) })
+	if i < 0 {
+		return Variant{}, false
+	}
+	return p.Variants[i], true
+}
+
+// PriceOf returns the price of the product's variant, or of the product
+// itself for variant zero, less any sale
+func (p Product) PriceOf(variantID int) Money {
+	price := p.Price
+	if v, ok := p.Variant(variantID); ok {
+		price = p.Price.Add(v.PriceDelta)
+	}
+	if p.Sale != nil {
+		price = p.Sale.Apply(price)
+	}
+	return price
+}
+
+// StockOf returns the stock of the product's variant, or of the product
+// itself for variant zero. Variants the product doesn't have have none.
+func (p Product) StockOf(variantID int) int {
+	if variantID == 0 {
+		return p.Stock
+	}
+	v, _ := p.Variant(variantID)
+	return v.Stock
+}
+
+// withStockChange returns the product with delta added to the stock of
+// its variant, and so to its own, or for variant zero to its own only. The
+// variants are copied rather than changed in place, as other copies of the
+// product share them.
+func (p Product) withStockChange(variantID, delta int) Product {
+	if i := slices.IndexFunc(p.Variants, func(v Variant) bool { return v.ID == variantID }); i >= 0 {
+		p.Variants = slices.Clone(p.Variants)
+		p.Variants[i].Stock += delta
+	}
+	p.Stock += delta
+	return p
+}
+
+// validateVariants checks that every variant has an ID and SKU of its own
+// and one of each option's values, in a combination no other variant has,
+// and isn't priced below zero. Variants without an ID yet are numbered
+// when the product is stored.
+func (p Product) validateVariants() error {
+	if len(p.Options) > 0 && len(p.Variants) == 0 {
+		return fmt.Errorf("%w: options without variants", ErrInvalidVariant)
+	}
+
+	ids := make(map[int]bool)
+	skus := make(map[string]bool)
+	combinations := make(map[string]bool)
+	for _, v := range p.Variants {
+		if len(v.Values) != len(p.Options) {
+			return fmt.Errorf("%w: %s has %d values for %d options", ErrInvalidVariant, v.SKU, len(v.Values), len(p.Options))
+		}
+		for i, value := range v.Values {
+			if !slices.Contains(p.Options[i].Values, value) {
+				return fmt.Errorf("%w: %s isn't a %s", ErrInvalidVariant, value, p.Options[i].Name)
+			}
+		}
+
+		combination := strings.Join(v.Values, "\x00")
+		switch {
+		case strings.TrimSpace(v.SKU) == "":
+			return fmt.Errorf("%w: %s has no SKU", ErrInvalidVariant, v.Label())
+		case v.ID < 0 || (v.ID != 0 && ids[v.ID]):
+			return fmt.Errorf("%w: %s has ID %d", ErrInvalidVariant, v.SKU, v.ID)
+		case skus[v.SKU]:
+			return fmt.Errorf("%w: SKU %s is used twice", ErrInvalidVariant, v.SKU)
+		case combinations[combination]:
+			return fmt.Errorf("%w: %s is listed twice", ErrInvalidVariant, v.Label())
+		case v.Stock < 0:
+			return fmt.Errorf("%w: %s: %w", ErrInvalidVariant, v.SKU, ErrNegativeStock)
+		case !v.PriceDelta.IsZero() && v.PriceDelta.Currency != p.Price.Currency:
+			return fmt.Errorf("%w: %s is priced in %s, not %s", ErrInvalidVariant, v.SKU, v.PriceDelta.Currency, p.Price.Currency)
+		case p.Price.Amount+v.PriceDelta.Amount < 0:
+			return fmt.Errorf("%w: %s is priced below zero", ErrInvalidVariant, v.SKU)
+		}
+		ids[v.ID] = true
+		skus[v.SKU] = true
+		combinations[combination] = true
+	}
+	return nil
+}
+
+// withVariantStock numbers the product's variants that have no ID yet and
+// sets its stock to theirs, as products with variants have none of their
+// own. The variants are copied, so the product's caller keeps its own.
+func (p Product) withVariantStock() Product {
+	if !p.HasVariants() {
+		return p
+	}
+
+	p.Variants = slices.Clone(p.Variants)
+	next := 1
+	for _, v := range p.Variants {
+		next = max(next, v.ID+1)
+	}
+	p.Stock = 0
+	for i := range p.Variants {
+		if p.Variants[i].ID == 0 {
+			p.Variants[i].ID = next
+			next++
+		}
+		p.Stock += p.Variants[i].Stock
+	}
+	return p
+}
@@ -0,0 +1,205 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tshirt is a product in two sizes and two colors, one combination missing
+func tshirt() Product {
+	return Product{
+		Name:     "티셔츠",
+		Category: "패션",
+		Price:    Won(20000),
+		Options: []ProductOption{
+			{Name: "사이즈", Values: []string{"M", "L"}},
+			{Name: "색상", Values: []string{"블랙", "화이트"}},
+		},
+		Variants: []Variant{
+			{SKU: "TS-M-BK", Values: []string{"M", "블랙"}, Stock: 3},
+			{SKU: "TS-L-BK", Values: []string{"L", "블랙"}, PriceDelta: Won(2000), Stock: 1},
+			{SKU: "TS-L-WH", Values: []string{"L", "화이트"}, PriceDelta: Won(2000), Stock: 0},
+		},
+	}
+}
+
+func TestAddNumbersVariants(t *testing.T) {
+	store := NewProductStore()
+	p, _ := store.Add(tshirt())
+
+	if p.Variants[0].ID != 1 || p.Variants[2].ID != 3 {
+		t.Errorf("Expected variants numbered from 1, got %+v", p.Variants)
+	}
+	if p.Stock != 4 {
+		t.Errorf("Expected the variants' stock added up, got %d", p.Stock)
+	}
+	if v, ok := p.FindVariant([]string{"L", "블랙"}); !ok || v.SKU != "TS-L-BK" {
+		t.Errorf("Expected to find TS-L-BK, got %+v", v)
+	}
+	if _, ok := p.FindVariant([]string{"M", "화이트"}); ok {
+		t.Error("Expected no M / 화이트 variant")
+	}
+	if p.PriceOf(2) != Won(22000) || p.PriceOf(0) != Won(20000) {
+		t.Errorf("Expected prices with the variant's delta, got %v and %v", p.PriceOf(2), p.PriceOf(0))
+	}
+}
+
+func TestValidateVariants(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(p *Product)
+	}{
+		{"value not in option", func(p *Product) { p.Variants[0].Values[0] = "XL" }},
+		{"missing value", func(p *Product) { p.Variants[0].Values = []string{"M"} }},
+		{"no SKU", func(p *Product) { p.Variants[0].SKU = "" }},
+		{"shared SKU", func(p *Product) { p.Variants[1].SKU = "TS-M-BK" }},
+		{"same values", func(p *Product) { p.Variants[2].Values = []string{"L", "블랙"} }},
+		{"negative stock", func(p *Product) { p.Variants[0].Stock = -1 }},
+		{"below zero", func(p *Product) { p.Variants[0].PriceDelta = Won(-30000) }},
+		{"other currency", func(p *Product) { p.Variants[0].PriceDelta = usd(100) }},
+		{"options only", func(p *Product) { p.Variants = nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tshirt()
+			tt.change(&p)
+			if err := p.Validate(); !errors.Is(err, ErrInvalidVariant) {
+				t.Errorf("Expected ErrInvalidVariant, got %v", err)
+			}
+		})
+	}
+
+	if err := tshirt().Validate(); err != nil {
+		t.Errorf("Expected the variants to be valid, got %v", err)
+	}
+}
+
+func TestCartKeepsVariantsApart(t *testing.T) {
+	store := NewProductStore()
+	p, _ := store.Add(tshirt())
+	cart := NewCart()
+
+	cart.AddItem(p, 1, 2)
+	cart.AddItem(p, 2, 1)
+	cart.AddItem(p, 1, 1)
+
+	if len(cart.GetItems()) != 2 {
+		t.Fatalf("Expected a line per variant, got %+v", cart.GetItems())
+	}
+	if cart.Quantity(ItemKey{ProductID: p.ID, VariantID: 1}) != 3 {
+		t.Errorf("Expected 3 of variant 1, got %d", cart.Quantity(ItemKey{ProductID: p.ID, VariantID: 1}))
+	}
+	if cart.Total != Won(3*20000+22000) {
+		t.Errorf("Expected the total priced by variant, got %v", cart.Total)
+	}
+
+	cart.RemoveItem(ItemKey{ProductID: p.ID, VariantID: 1})
+	if items := cart.GetItems(); len(items) != 1 || items[0].VariantID != 2 {
+		t.Errorf("Expected only variant 2 left, got %+v", items)
+	}
+}
+
+func TestVariantStock(t *testing.T) {
+	store := NewProductStore()
+	p, _ := store.Add(tshirt())
+	medium := ItemKey{ProductID: p.ID, VariantID: 1}
+	large := ItemKey{ProductID: p.ID, VariantID: 2}
+
+	if err := store.CheckStock("cart", ItemKey{ProductID: p.ID}, 1); !errors.Is(err, ErrVariantRequired) {
+		t.Errorf("Expected ErrVariantRequired without a variant, got %v", err)
+	}
+	if err := store.CheckStock("cart", ItemKey{ProductID: p.ID, VariantID: 9}, 1); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound for a missing variant, got %v", err)
+	}
+
+	var stockErr *InsufficientStockError
+	if err := store.Reserve("other", large, 2, time.Minute); !errors.As(err, &stockErr) || stockErr.Name != "티셔츠 (L / 블랙)" || stockErr.VariantID != 2 {
+		t.Errorf("Expected the variant short of stock, got %v", err)
+	}
+	store.Reserve("other", large, 1, time.Minute)
+	if store.Available(large) != 0 || store.Available(medium) != 3 {
+		t.Errorf("Expected a reservation to hold only its variant, got %d and %d", store.Available(large), store.Available(medium))
+	}
+
+	items, err := store.TakeStock("cart", []CartItem{{Product: p, VariantID: 1, Quantity: 2}})
+	if err != nil {
+		t.Fatalf("TakeStock() failed: %v", err)
+	}
+	if items[0].VariantID != 1 || items[0].SKU != "TS-M-BK" || items[0].Variant != "M / 블랙" || items[0].Price != Won(20000) {
+		t.Errorf("Expected the order item to record the variant, got %+v", items[0])
+	}
+	stored, _ := store.GetByID(p.ID)
+	if stored.StockOf(1) != 1 || stored.Stock != 2 || stored.Sold != 2 {
+		t.Errorf("Expected 1 of the variant and 2 in all left, got %d and %d", stored.StockOf(1), stored.Stock)
+	}
+	if p.StockOf(1) != 3 {
+		t.Error("Taking stock changed the variants of a copy of the product")
+	}
+
+	store.ReturnStock(items)
+	if _, err := store.AdjustStock(ItemKey{ProductID: p.ID, VariantID: 3}, 5); err != nil {
+		t.Fatalf("AdjustStock() failed: %v", err)
+	}
+	if _, err := store.AdjustStock(ItemKey{ProductID: p.ID}, 5); !errors.Is(err, ErrVariantRequired) {
+		t.Errorf("Expected ErrVariantRequired adjusting the product's own stock, got %v", err)
+	}
+	stored, _ = store.GetByID(p.ID)
+	if stored.StockOf(1) != 3 || stored.StockOf(3) != 5 || stored.Stock != 9 || stored.Sold != 0 {
+		t.Errorf("Expected variant stock 3 and 5, 9 in all, got %+v", stored)
+	}
+}
+
+func TestReturnableByVariant(t *testing.T) {
+	order := Order{
+		Status: OrderPaid,
+		Total:  Won(62000),
+		Items: []OrderItem{
+			{ProductID: 1, VariantID: 1, Price: Won(20000), Quantity: 2},
+			{ProductID: 1, VariantID: 2, Price: Won(22000), Quantity: 1},
+		},
+	}
+
+	refund := RefundLine{Amount: Won(22000), Restocked: []OrderItem{{ProductID: 1, VariantID: 2, Quantity: 1}}}
+	if err := order.CheckRefund(refund); err != nil {
+		t.Fatalf("CheckRefund() failed: %v", err)
+	}
+	order.Refunds = append(order.Refunds, refund)
+
+	if items := order.Returnable(); len(items) != 1 || items[0].VariantID != 1 {
+		t.Errorf("Expected only variant 1 left to return, got %+v", items)
+	}
+	if err := order.CheckRefund(refund); !errors.Is(err, ErrRefundItems) {
+		t.Errorf("Expected ErrRefundItems restocking variant 2 again, got %v", err)
+	}
+}
+
+func TestImportVariants(t *testing.T) {
+	store := NewProductStore()
+	catalog := `[{"id": 1, "name": "티셔츠", "category": "패션", "price": 20000,
+		"options": [{"name": "사이즈", "values": ["M", "L"]}],
+		"variants": [{"sku": "TS-M", "values": ["M"], "stock": 2}, {"sku": "TS-L", "values": ["L"], "priceDelta": 2000, "stock": 3}]}]`
+	if result, err := store.Import(strings.NewReader(catalog), CatalogJSON, false); err != nil || !result.Applied() {
+		t.Fatalf("Import() = %+v, %v", result, err)
+	}
+	p, _ := store.GetByID(1)
+	if p.Stock != 5 || p.Variants[1].ID != 2 || p.PriceOf(2) != Won(22000) {
+		t.Errorf("Expected numbered variants with their stock added up, got %+v", p)
+	}
+
+	// A CSV file has no variants, so they are kept, and stock comes from them
+	csv := "id,name,stock\n1,반팔 티셔츠,99\n"
+	if result, err := store.Import(strings.NewReader(csv), CatalogCSV, false); err != nil || !result.Applied() {
+		t.Fatalf("Import() = %+v, %v", result, err)
+	}
+	if p, _ := store.GetByID(1); p.Name != "반팔 티셔츠" || len(p.Variants) != 2 || p.Stock != 5 {
+		t.Errorf("Expected the variants and their stock kept, got %+v", p)
+	}
+
+	bad := `[{"id": 1, "variants": [{"sku": "TS-XL", "values": ["XL"]}]}]`
+	result, err := store.Import(strings.NewReader(bad), CatalogJSON, false)
+	if err != nil || len(result.Errors) != 1 || result.Errors[0].Field != "variants" || !errors.Is(result.Errors[0], ErrInvalidVariant) {
+		t.Errorf("Expected a variants error, got %+v, %v", result, err)
+	}
+}
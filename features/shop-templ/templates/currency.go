@@ -0,0 +1,27 @@
+package templates
+
+import (
+	"context"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+)
+
+// price returns a catalog amount as the shopper sees it, converted to the
+// currency they picked and written as in their language
+func price(ctx context.Context, m models.Money) string {
+	return money(ctx, models.DisplayFromContext(ctx).Price(m))
+}
+
+// money returns an amount as written in the shopper's language, in the
+// currency it is in, as for what an order was charged
+func money(ctx context.Context, m models.Money) string {
+	return models.LocaleFromContext(ctx).Price(m)
+}
+
+// selectedCurrency is the currency prices are shown in
+func selectedCurrency(ctx context.Context) models.Currency {
+	if display := models.DisplayFromContext(ctx); display.Converts() {
+		return display.Currency
+	}
+	return models.DefaultCurrency
+}
@@ -0,0 +1,177 @@
+package templates
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+)
+
+// priceStep is the step of the price range sliders, in won
+const priceStep = 1000
+
+// Listing is what a product listing is showing: the search query, the
+// filters and the sort order. Every control on the listing links to it
+// with one thing changed, so they all compose.
+type Listing struct {
+	Query      string
+	Categories []string
+	// MinPrice and MaxPrice bound the price range; zero means unbounded
+	MinPrice models.Money
+	MaxPrice models.Money
+	Tags     []string
+	Sort     models.ProductSort
+}
+
+// WithCategory returns the listing filtered to just one category instead,
+// or to every category for an empty one
+func (l Listing) WithCategory(category string) Listing {
+	l.Categories = nil
+	if category != "" {
+		l.Categories = []string{category}
+	}
+	return l
+}
+
+// WithQuery returns the listing searching for query instead
+func (l Listing) WithQuery(query string) Listing {
+	l.Query = query
+	return l
+}
+
+// WithoutFilters returns the listing with its filters cleared, keeping the
+// search query and sort order
+func (l Listing) WithoutFilters() Listing {
+	return Listing{Query: l.Query, Sort: l.Sort}
+}
+
+// OnlyCategory reports whether the listing is filtered to exactly category,
+// or to none for an empty one
+func (l Listing) OnlyCategory(category string) bool {
+	if category == "" {
+		return len(l.Categories) == 0
+	}
+	return len(l.Categories) == 1 && l.Categories[0] == category
+}
+
+// HasCategory reports whether category is one the listing is filtered to
+func (l Listing) HasCategory(category string) bool {
+	return slices.Contains(l.Categories, category)
+}
+
+// categoryChips returns the categories the listing offers shortcuts to:
+// the top-level ones and, with the listing in just one category, the
+// sub-categories of every category on its path. They stay in the order of
+// categories, so sub-categories follow their parent.
+func (l Listing) categoryChips(categories []string) []string {
+	var open []string
+	if len(l.Categories) == 1 {
+		open = models.CategoryAncestors(l.Categories[0])
+	}
+
+	var chips []string
+	for _, category := range categories {
+		if parent := models.CategoryParent(category); parent == "" || slices.Contains(open, parent) {
+			chips = append(chips, category)
+		}
+	}
+	return chips
+}
+
+// HasTag reports whether tag is one the listing is filtered to
+func (l Listing) HasTag(tag string) bool {
+	return slices.Contains(l.Tags, tag)
+}
+
+// FilterCount is how many filters are applied, with the price range as one
+func (l Listing) FilterCount() int {
+	count := len(l.Categories) + len(l.Tags)
+	if !l.MinPrice.IsZero() || !l.MaxPrice.IsZero() {
+		count++
+	}
+	return count
+}
+
+// URL is the listing's URL starting at offset
+func (l Listing) URL(offset int) string {
+	query := l.values()
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+	return listingURL(query)
+}
+
+// CursorURL is the listing's URL carrying on after cursor
+func (l Listing) CursorURL(cursor models.Cursor) string {
+	query := l.values()
+	query.Set("cursor", cursor.String())
+	return listingURL(query)
+}
+
+// values are the listing's query parameters
+func (l Listing) values() url.Values {
+	query := url.Values{}
+	if l.Query != "" {
+		query.Set("q", l.Query)
+	}
+	for _, category := range l.Categories {
+		query.Add("category", category)
+	}
+	if !l.MinPrice.IsZero() {
+		query.Set("min_price", l.MinPrice.Decimal())
+	}
+	if !l.MaxPrice.IsZero() {
+		query.Set("max_price", l.MaxPrice.Decimal())
+	}
+	for _, tag := range l.Tags {
+		query.Add("tag", tag)
+	}
+	if l.Sort != models.SortDefault {
+		query.Set("sort", string(l.Sort))
+	}
+	return query
+}
+
+// listingURL is the URL of the product listing with query
+func listingURL(query url.Values) string {
+	if len(query) == 0 {
+		return "/products"
+	}
+	return "/products?" + query.Encode()
+}
+
+// priceCeiling is the top of the price sliders: the catalog's top price
+// rounded up to a whole step
+func priceCeiling(facets models.Facets) models.Money {
+	steps := (facets.MaxPrice.Amount + priceStep - 1) / priceStep
+	return models.NewMoney(steps*priceStep, facets.MaxPrice.Currency)
+}
+
+// maxPriceValue is where the upper price slider sits, at the ceiling when
+// the listing has no upper bound
+func maxPriceValue(l Listing, facets models.Facets) models.Money {
+	if !l.MaxPrice.IsZero() {
+		return l.MaxPrice
+	}
+	return priceCeiling(facets)
+}
+
+// sortLabel is how a sort order is named in the sort dropdown
+func sortLabel(ctx context.Context, order models.ProductSort) string {
+	switch order {
+	case models.SortPopular:
+		return tr(ctx, "sort.popular")
+	case models.SortNewest:
+		return tr(ctx, "sort.newest")
+	case models.SortPriceAsc:
+		return tr(ctx, "sort.price_asc")
+	case models.SortPriceDesc:
+		return tr(ctx, "sort.price_desc")
+	case models.SortName:
+		return tr(ctx, "sort.name")
+	default:
+		return tr(ctx, "sort.default")
+	}
+}
@@ -0,0 +1,29 @@
+package templates
+
+import (
+	"context"
+	"time"
+
+	"github.com/homveloper/doodle/features/shop-templ/models"
+)
+
+// tr returns the message with key in the shopper's language
+func tr(ctx context.Context, key string, args ...any) string {
+	return models.LocaleFromContext(ctx).T(key, args...)
+}
+
+// trCount returns the message with key for n of something in the shopper's
+// language, such as "상품 3개"
+func trCount(ctx context.Context, key string, n int) string {
+	return models.LocaleFromContext(ctx).Count(key, n)
+}
+
+// formatDate is the day of t as the shopper writes it
+func formatDate(ctx context.Context, t time.Time) string {
+	return models.LocaleFromContext(ctx).Date(t)
+}
+
+// formatDateTime is t to the minute as the shopper writes it
+func formatDateTime(ctx context.Context, t time.Time) string {
+	return models.LocaleFromContext(ctx).DateTime(t)
+}